@@ -1,6 +1,9 @@
 package main
 
 import (
+	// User timezones must resolve on hosts without a zoneinfo database.
+	_ "time/tzdata"
+
 	"github.com/begenov/region-llc-task/internal/app"
 	"github.com/begenov/region-llc-task/internal/config"
 	"github.com/begenov/region-llc-task/pkg/logger"
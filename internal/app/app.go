@@ -7,49 +7,93 @@ import (
 
 	"github.com/begenov/region-llc-task/internal/config"
 	"github.com/begenov/region-llc-task/internal/delivery/http"
+	"github.com/begenov/region-llc-task/internal/repository"
+	memoryrepo "github.com/begenov/region-llc-task/internal/repository/memory"
 	mongorepo "github.com/begenov/region-llc-task/internal/repository/mongo"
+	postgresrepo "github.com/begenov/region-llc-task/internal/repository/postgres"
 	redisrepo "github.com/begenov/region-llc-task/internal/repository/redis"
+	sqliterepo "github.com/begenov/region-llc-task/internal/repository/sqlite"
 	"github.com/begenov/region-llc-task/internal/service"
 	"github.com/begenov/region-llc-task/pkg/auth"
 	"github.com/begenov/region-llc-task/pkg/database"
 	"github.com/begenov/region-llc-task/pkg/hash"
+	"github.com/begenov/region-llc-task/pkg/logger"
+	"github.com/begenov/region-llc-task/pkg/mail"
 	"github.com/begenov/region-llc-task/pkg/redis"
 )
 
 const timeout = 10 * time.Second
 
+type repositories struct {
+	users          repository.Users
+	sessions       repository.Sessions
+	personalTokens repository.PersonalTokens
+	mfa            repository.MFA
+	todo           repository.Todo
+	items          repository.TodoItems
+	calendars      repository.Calendars
+	redis          repository.Redis
+	close          func()
+}
+
 func Run(cfg *config.Config) error {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	redisClient, err := redis.CreateClient(cfg.Redis)
+	repos, err := newRepositories(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer repos.close()
+
+	hash := hash.NewHash()
+	manager, err := newTokenManager(cfg.Session)
 	if err != nil {
-		return fmt.Errorf("redis.CreateClient(): %v", err)
+		return fmt.Errorf("newTokenManager(): %v", err)
 	}
-	defer redisClient.Close()
 
-	mongoClient, err := database.NewClient(ctx, cfg.Mongo)
+	mailer, err := newMailer(cfg.Mail)
 	if err != nil {
-		return fmt.Errorf("database.NewClient(): %v", err)
+		return fmt.Errorf("newMailer(): %v", err)
 	}
-	db := mongoClient.Database(cfg.Mongo.Name)
-	defer db.Client().Disconnect(ctx)
 
-	hash := hash.NewHash()
-	manager, err := auth.NewManager(cfg.Session.SignKey)
+	userService := service.NewUserService(repos.users, repos.sessions, repos.personalTokens, repos.mfa, hash, manager,
+		cfg.Session.AccessTokenTTL, cfg.Session.RefreshTokenTTL, repos.redis, service.MFAPolicy{
+			Issuer:          cfg.MFA.Issuer,
+			RequiredDomains: cfg.MFA.RequiredDomains,
+			ChallengeTTL:    cfg.MFA.ChallengeTTL,
+		}, mailer, service.PasswordResetPolicy{
+			URL:      cfg.PasswordReset.URL,
+			TokenTTL: cfg.PasswordReset.TokenTTL,
+		}, service.EmailVerificationPolicy{
+			URL:      cfg.Verification.URL,
+			TokenTTL: cfg.Verification.TokenTTL,
+		}, service.AccountPolicy{
+			DeletionGrace: cfg.Account.DeletionGrace,
+		}, service.SignInPolicy{
+			FreeAttempts:      cfg.SignIn.FreeAttempts,
+			Backoff:           cfg.SignIn.Backoff,
+			LockoutAttempts:   cfg.SignIn.LockoutAttempts,
+			IPLockoutAttempts: cfg.SignIn.IPLockoutAttempts,
+			LockoutDuration:   cfg.SignIn.LockoutDuration,
+			Window:            cfg.SignIn.Window,
+		})
+	go purgeDeletedUsers(userService, cfg.Account.PurgeInterval)
+
+	calendars, err := service.LoadCalendars(cfg.Calendar.Path)
 	if err != nil {
-		return fmt.Errorf("auth.NewManager(): %v", err)
+		return fmt.Errorf("service.LoadCalendars(): %v", err)
 	}
 
-	redisRepo := redisrepo.NewRedis(redisClient)
-	userRepo := mongorepo.NewUserRepo(db)
-	todoRepo := mongorepo.NewTodoRepo(db)
+	calendarService, err := service.NewCalendarService(repos.calendars, repos.users, calendars, cfg.Calendar.Default)
+	if err != nil {
+		return fmt.Errorf("service.NewCalendarService(): %v", err)
+	}
 
-	userService := service.NewUserService(userRepo, hash, manager, cfg.Session.AccessTokenTTL,
-		cfg.Session.RefreshTokenTTL, redisRepo)
-	todoService := service.NewTodoService(todoRepo, userRepo)
+	todoService := service.NewTodoService(repos.todo, repos.items, repos.users, calendarService, cfg.Todo.AutoComplete,
+		cfg.Todo.UnverifiedLimit)
 
-	server := http.NewServer(userService, todoService, manager)
+	server := http.NewServer(userService, todoService, calendarService, manager, cfg.TrustedProxies)
 
 	if err := server.Init(cfg.APIEndpoint); err != nil {
 		return fmt.Errorf("server.Init(): %v", err)
@@ -57,3 +101,232 @@ func Run(cfg *config.Config) error {
 
 	return nil
 }
+
+// purgeDeletedUsers removes the accounts whose grace period has ended every
+// interval.
+func purgeDeletedUsers(userService *service.UserService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		deleted, err := userService.PurgeDeletedUsers(ctx)
+		cancel()
+		if err != nil {
+			logger.Errorf("userService.PurgeDeletedUsers(): %v", err)
+			continue
+		}
+
+		if deleted > 0 {
+			logger.Infof("deleted %d accounts", deleted)
+		}
+	}
+}
+
+// newTokenManager signs access tokens with the keys of KeysPath when it is
+// set, with the shared SignKey otherwise, for the configured issuer and
+// audience.
+func newTokenManager(cfg config.ConfigSession) (*auth.Manager, error) {
+	var (
+		manager *auth.Manager
+		err     error
+	)
+
+	if cfg.KeysPath == "" {
+		manager, err = auth.NewManager(cfg.SignKey)
+	} else {
+		var keys []auth.Key
+		keys, err = auth.LoadKeys(cfg.KeysPath)
+		if err != nil {
+			return nil, fmt.Errorf("auth.LoadKeys(): %v", err)
+		}
+
+		logger.Infof("signing access tokens with %d keys from %s", len(keys), cfg.KeysPath)
+
+		manager, err = auth.NewKeyManager(keys, cfg.KeyOverlap)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	manager.SetIssuer(cfg.Issuer, cfg.Audience)
+
+	return manager, nil
+}
+
+// newMailer sends emails through the SMTP server, or writes them to a file or
+// the log.
+func newMailer(cfg config.ConfigMail) (mail.Mailer, error) {
+	if cfg.Sender == config.MailSenderSMTP {
+		logger.Infof("sending emails through %s:%d", cfg.SMTP.Host, cfg.SMTP.Port)
+
+		mailer, err := mail.NewSMTPMailer(cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.Username, cfg.SMTP.Password, cfg.From)
+		if err != nil {
+			return nil, fmt.Errorf("mail.NewSMTPMailer(): %v", err)
+		}
+
+		return mailer, nil
+	}
+
+	if cfg.Path == "" {
+		logger.Info("emails are written to the log")
+	} else {
+		logger.Infof("emails are written to %s", cfg.Path)
+	}
+
+	return mail.NewFileMailer(cfg.Path, cfg.From), nil
+}
+
+func newRepositories(ctx context.Context, cfg *config.Config) (*repositories, error) {
+	if cfg.Storage == config.StorageMemory {
+		logger.Info("using in-memory storage, data will be lost on restart")
+
+		sessionRepo := memoryrepo.NewSessionRepo()
+		personalTokenRepo := memoryrepo.NewPersonalTokenRepo()
+		mfaRepo := memoryrepo.NewMFARepo()
+		todoRepo := memoryrepo.NewTodoRepo()
+		calendarRepo := memoryrepo.NewCalendarRepo()
+
+		return &repositories{
+			users:          memoryrepo.NewUserRepo(todoRepo, calendarRepo, sessionRepo, personalTokenRepo, mfaRepo),
+			sessions:       sessionRepo,
+			personalTokens: personalTokenRepo,
+			mfa:            mfaRepo,
+			todo:           todoRepo,
+			items:          memoryrepo.NewTodoItemRepo(),
+			calendars:      calendarRepo,
+			redis:          memoryrepo.NewRedis(),
+			close:          func() {},
+		}, nil
+	}
+
+	if cfg.Storage == config.StorageSQLite {
+		db, err := database.NewSQLite(ctx, cfg.SQLite)
+		if err != nil {
+			return nil, fmt.Errorf("database.NewSQLite(): %v", err)
+		}
+
+		if err := sqliterepo.Migrate(ctx, db); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("sqliterepo.Migrate(): %v", err)
+		}
+
+		logger.Infof("using sqlite storage at %s", cfg.SQLite.Path)
+
+		return &repositories{
+			users:          sqliterepo.NewUserRepo(db),
+			sessions:       sqliterepo.NewSessionRepo(db),
+			personalTokens: sqliterepo.NewPersonalTokenRepo(db),
+			mfa:            sqliterepo.NewMFARepo(db),
+			todo:           sqliterepo.NewTodoRepo(db),
+			items:          sqliterepo.NewTodoItemRepo(db),
+			calendars:      sqliterepo.NewCalendarRepo(db),
+			redis:          sqliterepo.NewRedis(db),
+			close:          func() { db.Close() },
+		}, nil
+	}
+
+	redisClient, err := redis.CreateClient(cfg.Redis)
+	if err != nil {
+		return nil, fmt.Errorf("redis.CreateClient(): %v", err)
+	}
+
+	repos := &repositories{
+		redis: redisrepo.NewRedis(redisClient),
+	}
+
+	switch cfg.Storage {
+	case config.StoragePostgres:
+		db, err := database.NewPostgres(ctx, cfg.Postgres)
+		if err != nil {
+			redisClient.Close()
+			return nil, fmt.Errorf("database.NewPostgres(): %v", err)
+		}
+
+		if err := postgresrepo.Migrate(ctx, db); err != nil {
+			db.Close()
+			redisClient.Close()
+			return nil, fmt.Errorf("postgresrepo.Migrate(): %v", err)
+		}
+
+		repos.users = postgresrepo.NewUserRepo(db)
+		repos.sessions = postgresrepo.NewSessionRepo(db)
+		repos.personalTokens = postgresrepo.NewPersonalTokenRepo(db)
+		repos.mfa = postgresrepo.NewMFARepo(db)
+		repos.todo = postgresrepo.NewTodoRepo(db)
+		repos.items = postgresrepo.NewTodoItemRepo(db)
+		repos.calendars = postgresrepo.NewCalendarRepo(db)
+		repos.close = func() {
+			db.Close()
+			redisClient.Close()
+		}
+	default:
+		mongoClient, err := database.NewClient(ctx, cfg.Mongo)
+		if err != nil {
+			redisClient.Close()
+			return nil, fmt.Errorf("database.NewClient(): %v", err)
+		}
+		db := mongoClient.Database(cfg.Mongo.Name)
+
+		todoRepo := mongorepo.NewTodoRepo(db)
+		if err := todoRepo.Migrate(ctx); err != nil {
+			mongoClient.Disconnect(context.Background())
+			redisClient.Close()
+			return nil, fmt.Errorf("todoRepo.Migrate(): %v", err)
+		}
+
+		if err := todoRepo.EnsureIndexes(ctx); err != nil {
+			mongoClient.Disconnect(context.Background())
+			redisClient.Close()
+			return nil, fmt.Errorf("todoRepo.EnsureIndexes(): %v", err)
+		}
+
+		itemRepo := mongorepo.NewTodoItemRepo(db)
+		if err := itemRepo.EnsureIndexes(ctx); err != nil {
+			mongoClient.Disconnect(context.Background())
+			redisClient.Close()
+			return nil, fmt.Errorf("itemRepo.EnsureIndexes(): %v", err)
+		}
+
+		calendarRepo := mongorepo.NewCalendarRepo(db)
+		if err := calendarRepo.EnsureIndexes(ctx); err != nil {
+			mongoClient.Disconnect(context.Background())
+			redisClient.Close()
+			return nil, fmt.Errorf("calendarRepo.EnsureIndexes(): %v", err)
+		}
+
+		sessionRepo := mongorepo.NewSessionRepo(db)
+		if err := sessionRepo.Migrate(ctx); err != nil {
+			mongoClient.Disconnect(context.Background())
+			redisClient.Close()
+			return nil, fmt.Errorf("sessionRepo.Migrate(): %v", err)
+		}
+
+		if err := sessionRepo.EnsureIndexes(ctx); err != nil {
+			mongoClient.Disconnect(context.Background())
+			redisClient.Close()
+			return nil, fmt.Errorf("sessionRepo.EnsureIndexes(): %v", err)
+		}
+
+		personalTokenRepo := mongorepo.NewPersonalTokenRepo(db)
+		if err := personalTokenRepo.EnsureIndexes(ctx); err != nil {
+			mongoClient.Disconnect(context.Background())
+			redisClient.Close()
+			return nil, fmt.Errorf("personalTokenRepo.EnsureIndexes(): %v", err)
+		}
+
+		repos.users = mongorepo.NewUserRepo(db)
+		repos.sessions = sessionRepo
+		repos.personalTokens = personalTokenRepo
+		repos.mfa = mongorepo.NewMFARepo(db)
+		repos.todo = todoRepo
+		repos.items = itemRepo
+		repos.calendars = calendarRepo
+		repos.close = func() {
+			db.Client().Disconnect(context.Background())
+			redisClient.Close()
+		}
+	}
+
+	return repos, nil
+}
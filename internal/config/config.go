@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -8,31 +9,155 @@ import (
 	"github.com/kelseyhightower/envconfig"
 )
 
+const (
+	MailSenderSMTP = "smtp"
+	MailSenderFile = "file"
+)
+
+const (
+	StorageMongo    = "mongo"
+	StoragePostgres = "postgres"
+	StorageSQLite   = "sqlite"
+	StorageMemory   = "memory"
+)
+
+// Config.TrustedProxies are the addresses or CIDR ranges of the reverse
+// proxies whose X-Forwarded-For header gives the client IP, a comma-separated
+// list. None are trusted by default.
+//
+// The settings of a group are named with split_words, not envconfig tags:
+// envconfig falls back to the bare tag when the prefixed variable is unset,
+// so CALENDAR_PATH would read the system PATH.
 type Config struct {
-	APIEndpoint string        `envconfig:"API_ENDPOINT" required:"true"`
-	Mongo       ConfigMongo   `envconfig:"MONGO" required:"true"`
-	Redis       ConfigRedis   `envconfig:"REDIS" required:"true"`
-	Session     ConfigSession `envconfig:"SESSION" required:"true"`
+	APIEndpoint    string              `envconfig:"API_ENDPOINT" required:"true"`
+	TrustedProxies []string            `envconfig:"TRUSTED_PROXIES"`
+	Storage        string              `envconfig:"STORAGE" default:"mongo"`
+	Mongo          ConfigMongo         `envconfig:"MONGO"`
+	Postgres       ConfigPostgres      `envconfig:"POSTGRES"`
+	SQLite         ConfigSQLite        `envconfig:"SQLITE"`
+	Redis          ConfigRedis         `envconfig:"REDIS"`
+	Session        ConfigSession       `envconfig:"SESSION" required:"true"`
+	Todo           ConfigTodo          `envconfig:"TODO"`
+	Calendar       ConfigCalendar      `envconfig:"CALENDAR"`
+	MFA            ConfigMFA           `envconfig:"MFA"`
+	Mail           ConfigMail          `envconfig:"MAIL"`
+	PasswordReset  ConfigPasswordReset `envconfig:"PASSWORD_RESET"`
+	Verification   ConfigVerification  `envconfig:"EMAIL_VERIFICATION"`
+	Account        ConfigAccount       `envconfig:"ACCOUNT"`
+	SignIn         ConfigSignIn        `envconfig:"SIGN_IN"`
 }
 
 type ConfigMongo struct {
-	Uri      string `envconfig:"URI" required:"true"`
-	User     string `envconfig:"USER" required:"true"`
-	Password string `envconfig:"PASSWORD" required:"true"`
-	Name     string `envconfig:"NAME" required:"true"`
+	Uri      string `split_words:"true"`
+	User     string `split_words:"true"`
+	Password string `split_words:"true"`
+	Name     string `split_words:"true"`
 }
 
+type ConfigPostgres struct {
+	Uri string `split_words:"true"`
+}
+
+type ConfigSQLite struct {
+	Path string `split_words:"true"`
+}
+
+// ConfigSession.KeysPath is a directory of asymmetric signing keys listed in
+// its keys.json, access tokens are signed with them instead of SignKey when
+// set. KeyOverlap is how long a replaced key is still accepted and published.
+// Issuer and Audience are the iss and aud of access tokens.
 type ConfigSession struct {
-	SignKey         string        `envconfig:"SIGN_KEY" required:"true"`
-	AccessTokenTTL  time.Duration `envconfig:"ACCESS_TOKEN_TTL" required:"true"`
-	RefreshTokenTTL time.Duration `envconfig:"REFRESH_TOKEN_TTL" required:"true"`
+	SignKey         string        `split_words:"true"`
+	KeysPath        string        `split_words:"true"`
+	KeyOverlap      time.Duration `split_words:"true" default:"24h"`
+	Issuer          string        `split_words:"true" default:"region-llc-task"`
+	Audience        string        `split_words:"true" default:"region-llc-task-api"`
+	AccessTokenTTL  time.Duration `split_words:"true" required:"true"`
+	RefreshTokenTTL time.Duration `split_words:"true" required:"true"`
+}
+
+// ConfigTodo.AutoComplete marks a todo done once every item of its checklist
+// is done. UnverifiedLimit is how many todos a user may have before verifying
+// the email, 0 to allow none.
+type ConfigTodo struct {
+	AutoComplete    bool `split_words:"true" default:"true"`
+	UnverifiedLimit int  `split_words:"true" default:"0"`
+}
+
+// ConfigCalendar.Default is the id of the working calendar of the users who
+// have not chosen one. Path is a directory of .ics and .json calendars served
+// along with the bundled ones, the id of a calendar is its file name.
+type ConfigCalendar struct {
+	Default string `split_words:"true" default:"standard"`
+	Path    string `split_words:"true"`
+}
+
+// ConfigMFA.Issuer is the name authenticator apps show next to the account.
+// RequiredDomains are the email domains of the organizations that enforce
+// two-factor authentication, a comma-separated list. ChallengeTTL is how long
+// a sign-in waits for the code.
+type ConfigMFA struct {
+	Issuer          string        `split_words:"true" default:"Region Todo"`
+	RequiredDomains []string      `split_words:"true"`
+	ChallengeTTL    time.Duration `split_words:"true" default:"5m"`
+}
+
+// ConfigMail.Sender is smtp to send emails through the SMTP server, or file
+// to append them to the file at Path, or to the log without one.
+type ConfigMail struct {
+	Sender string     `split_words:"true" default:"file"`
+	From   string     `split_words:"true" default:"Region Todo <noreply@localhost>"`
+	Path   string     `split_words:"true"`
+	SMTP   ConfigSMTP `split_words:"true"`
+}
+
+type ConfigSMTP struct {
+	Host     string `split_words:"true"`
+	Port     int    `split_words:"true" default:"25"`
+	Username string `split_words:"true"`
+	Password string `split_words:"true"`
+}
+
+// ConfigPasswordReset.URL is the page of the client where a new password is
+// chosen, reset links add the token to it. TokenTTL is how long a link works.
+type ConfigPasswordReset struct {
+	URL      string        `split_words:"true" default:"http://localhost:8080/reset-password"`
+	TokenTTL time.Duration `split_words:"true" default:"1h"`
+}
+
+// ConfigVerification.URL is the page of the client that verifies an email,
+// verification links add the token to it. TokenTTL is how long a link works.
+type ConfigVerification struct {
+	URL      string        `split_words:"true" default:"http://localhost:8080/verify-email"`
+	TokenTTL time.Duration `split_words:"true" default:"24h"`
+}
+
+// ConfigAccount.DeletionGrace is how long a deleted account is kept before it
+// is removed for good. PurgeInterval is how often the removal runs.
+type ConfigAccount struct {
+	DeletionGrace time.Duration `split_words:"true" default:"720h"`
+	PurgeInterval time.Duration `split_words:"true" default:"1h"`
+}
+
+// ConfigSignIn limits failed sign-ins, counted for Window after the first
+// one. After FreeAttempts failures of an account every further one makes it
+// wait Backoff, doubled with each failure. LockoutAttempts failures of an
+// account, or IPLockoutAttempts from an IP address, lock it for
+// LockoutDuration.
+type ConfigSignIn struct {
+	FreeAttempts      int           `split_words:"true" default:"3"`
+	Backoff           time.Duration `split_words:"true" default:"1s"`
+	LockoutAttempts   int           `split_words:"true" default:"10"`
+	IPLockoutAttempts int           `split_words:"true" default:"100"`
+	LockoutDuration   time.Duration `split_words:"true" default:"15m"`
+	Window            time.Duration `split_words:"true" default:"1h"`
 }
 
 type ConfigRedis struct {
-	Host     string `envconfig:"HOST" required:"true"`
-	Port     int    `envconfig:"PORT" required:"true"`
-	Password string `envconfig:"PASSWORD" required:"false"`
-	DB       int    `envconfig:"DB" required:"true"`
+	Host     string `split_words:"true"`
+	Port     int    `split_words:"true"`
+	Password string `split_words:"true" required:"false"`
+	DB       int    `split_words:"true"`
 }
 
 func NewConfig(fpath string) (*Config, error) {
@@ -48,5 +173,85 @@ func NewConfig(fpath string) (*Config, error) {
 		return nil, fmt.Errorf("envconfig.Process(): %s", err.Error())
 	}
 
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("config.validate(): %s", err.Error())
+	}
+
 	return &config, nil
 }
+
+// validate checks that the settings of the selected storage are present.
+// Backends that are not in use may be left unconfigured.
+func (c *Config) validate() error {
+	if err := c.Session.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Mail.validate(); err != nil {
+		return err
+	}
+
+	if c.Account.PurgeInterval <= 0 {
+		return errors.New("ACCOUNT_PURGE_INTERVAL must be positive")
+	}
+
+	switch c.Storage {
+	case StorageMongo:
+		if c.Mongo.Uri == "" || c.Mongo.Name == "" {
+			return errors.New("MONGO_URI and MONGO_NAME are required for mongo storage")
+		}
+	case StoragePostgres:
+		if c.Postgres.Uri == "" {
+			return errors.New("POSTGRES_URI is required for postgres storage")
+		}
+	case StorageSQLite:
+		if c.SQLite.Path == "" {
+			return errors.New("SQLITE_PATH is required for sqlite storage")
+		}
+
+		return nil
+	case StorageMemory:
+		return nil
+	default:
+		return fmt.Errorf("unknown storage %q", c.Storage)
+	}
+
+	if c.Redis.Host == "" || c.Redis.Port == 0 {
+		return errors.New("REDIS_HOST and REDIS_PORT are required")
+	}
+
+	return nil
+}
+
+// validate checks that the selected sender can send emails.
+func (c *ConfigMail) validate() error {
+	switch c.Sender {
+	case MailSenderSMTP:
+		if c.SMTP.Host == "" {
+			return errors.New("MAIL_SMTP_HOST is required for smtp mail sender")
+		}
+	case MailSenderFile:
+	default:
+		return fmt.Errorf("unknown mail sender %q", c.Sender)
+	}
+
+	return nil
+}
+
+// validate checks that access tokens can be signed, and that a replaced key
+// outlives the tokens it has signed.
+func (c *ConfigSession) validate() error {
+	if c.KeysPath == "" {
+		if c.SignKey == "" {
+			return errors.New("SESSION_SIGN_KEY or SESSION_KEYS_PATH is required")
+		}
+
+		return nil
+	}
+
+	if c.KeyOverlap < c.AccessTokenTTL {
+		return errors.New("SESSION_KEY_OVERLAP must be at least SESSION_ACCESS_TOKEN_TTL")
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestConfig loads the config from the environment with the required
+// settings and env on top of them, over an empty .env file.
+func newTestConfig(t *testing.T, env map[string]string) (*Config, error) {
+	fpath := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(fpath, nil, 0o600))
+
+	settings := map[string]string{
+		"API_ENDPOINT":              ":8080",
+		"STORAGE":                   StorageMemory,
+		"SESSION_SIGN_KEY":          "qwerty",
+		"SESSION_ACCESS_TOKEN_TTL":  "15m",
+		"SESSION_REFRESH_TOKEN_TTL": "720h",
+	}
+	for key, value := range env {
+		settings[key] = value
+	}
+
+	for key, value := range settings {
+		t.Setenv(key, value)
+	}
+
+	return NewConfig(fpath)
+}
+
+// The settings of a group do not fall back to the variables of the system
+// with the same bare name.
+func TestNewConfig_systemVariables(t *testing.T) {
+	cfg, err := newTestConfig(t, map[string]string{
+		"PATH":      "/usr/local/bin:/usr/bin:/bin",
+		"DEFAULT":   "system",
+		"ISSUER":    "system",
+		"URL":       "http://system",
+		"TOKEN_TTL": "1s",
+		"WINDOW":    "1s",
+		"USER":      "root",
+		"HOST":      "system",
+	})
+	require.NoError(t, err)
+
+	require.Empty(t, cfg.Calendar.Path)
+	require.Empty(t, cfg.Mail.Path)
+	require.Equal(t, "standard", cfg.Calendar.Default)
+	require.Equal(t, "region-llc-task", cfg.Session.Issuer)
+	require.Equal(t, "Region Todo", cfg.MFA.Issuer)
+	require.Equal(t, "http://localhost:8080/reset-password", cfg.PasswordReset.URL)
+	require.Equal(t, time.Hour, cfg.PasswordReset.TokenTTL)
+	require.Equal(t, 24*time.Hour, cfg.Verification.TokenTTL)
+	require.Equal(t, time.Hour, cfg.SignIn.Window)
+	require.Empty(t, cfg.Mongo.User)
+	require.Empty(t, cfg.Redis.Host)
+}
+
+func TestNewConfig_groupVariables(t *testing.T) {
+	cfg, err := newTestConfig(t, map[string]string{
+		"PATH":                        "/usr/local/bin:/usr/bin:/bin",
+		"CALENDAR_PATH":               "/etc/calendars",
+		"MAIL_PATH":                   "/var/mail/outbox",
+		"MFA_REQUIRED_DOMAINS":        "region.kz,example.org",
+		"SIGN_IN_IP_LOCKOUT_ATTEMPTS": "50",
+		"MAIL_SMTP_PORT":              "587",
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, "/etc/calendars", cfg.Calendar.Path)
+	require.Equal(t, "/var/mail/outbox", cfg.Mail.Path)
+	require.Equal(t, []string{"region.kz", "example.org"}, cfg.MFA.RequiredDomains)
+	require.Equal(t, 50, cfg.SignIn.IPLockoutAttempts)
+	require.Equal(t, 15*time.Minute, cfg.Session.AccessTokenTTL)
+	require.Equal(t, 587, cfg.Mail.SMTP.Port)
+}
+
+func TestNewConfig_sqlite(t *testing.T) {
+	_, err := newTestConfig(t, map[string]string{
+		"PATH":    "/usr/local/bin:/usr/bin:/bin",
+		"STORAGE": StorageSQLite,
+	})
+	require.ErrorContains(t, err, "SQLITE_PATH is required")
+
+	cfg, err := newTestConfig(t, map[string]string{
+		"PATH":        "/usr/local/bin:/usr/bin:/bin",
+		"STORAGE":     StorageSQLite,
+		"SQLITE_PATH": "/var/lib/region/todo.db",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "/var/lib/region/todo.db", cfg.SQLite.Path)
+}
+
+func TestNewConfig_purgeInterval(t *testing.T) {
+	for _, interval := range []string{"0s", "-1h"} {
+		_, err := newTestConfig(t, map[string]string{"ACCOUNT_PURGE_INTERVAL": interval})
+		require.ErrorContains(t, err, "ACCOUNT_PURGE_INTERVAL must be positive")
+	}
+}
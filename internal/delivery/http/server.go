@@ -1,6 +1,8 @@
 package http
 
 import (
+	"net/http"
+
 	_ "github.com/begenov/region-llc-task/docs"
 	v1 "github.com/begenov/region-llc-task/internal/delivery/http/v1"
 	"github.com/begenov/region-llc-task/internal/service"
@@ -12,31 +14,51 @@ import (
 )
 
 type Server struct {
-	engine       *gin.Engine
-	userService  service.Users
-	todoService  service.Todo
-	tokenManager auth.TokenManager
+	engine          *gin.Engine
+	userService     service.Users
+	todoService     service.Todo
+	calendarService service.Calendars
+	tokenManager    auth.TokenManager
+	trustedProxies  []string
 }
 
-func NewServer(userService service.Users, todoService service.Todo, tokenManager auth.TokenManager) *Server {
+// NewServer creates the HTTP server. The client IP is taken from
+// X-Forwarded-For only behind the trustedProxies, addresses or CIDR ranges,
+// otherwise it is the address of the connection.
+func NewServer(userService service.Users, todoService service.Todo, calendarService service.Calendars,
+	tokenManager auth.TokenManager, trustedProxies []string) *Server {
 	return &Server{
-		engine:       gin.New(),
-		userService:  userService,
-		todoService:  todoService,
-		tokenManager: tokenManager,
+		engine:          gin.New(),
+		userService:     userService,
+		todoService:     todoService,
+		calendarService: calendarService,
+		tokenManager:    tokenManager,
+		trustedProxies:  trustedProxies,
 	}
 }
 
 func (s *Server) Init(port string) error {
+	if err := s.engine.SetTrustedProxies(s.trustedProxies); err != nil {
+		return err
+	}
+
 	s.engine.Use(logger.SetLogger())
 	s.engine.Use(gin.Recovery())
 	s.engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
+	s.engine.GET("/.well-known/jwks.json", s.jwks)
 
 	api := s.engine.Group("/api")
 
-	v1 := v1.NewServer(s.userService, s.todoService, s.tokenManager)
+	v1 := v1.NewServer(s.userService, s.todoService, s.calendarService, s.tokenManager)
 
 	v1.Init(api)
 
 	return s.engine.Run(port)
 }
+
+// jwks publishes the public keys of access tokens, so that other services
+// can verify them without a shared secret.
+func (s *Server) jwks(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, s.tokenManager.JWKS())
+}
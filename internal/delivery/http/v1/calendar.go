@@ -0,0 +1,201 @@
+package v1
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxCalendarSize limits the uploaded calendar file, maxCalendarForm the
+	// whole form with it.
+	maxCalendarSize = 1 << 20
+	maxCalendarForm = maxCalendarSize + 1<<10
+)
+
+// @Summary		User Get Calendars
+// @Security UserAuth
+// @Tags			Calendars
+// @Description	Working calendars bundled with the server and uploaded by the user, without their days
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	[]domain.Calendar
+// @Failure		403	{object}	Response
+// @Failure		500	{object}	Response
+// @Router			/users/calendars [get]
+func (s *Server) getCalendars(ctx *gin.Context) {
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	calendars, err := s.calendarService.GetCalendars(ctx, id)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.calendarService.GetCalendars(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, calendars)
+}
+
+// @Summary		User Get Calendar
+// @Security UserAuth
+// @Tags			Calendars
+// @Description	Working calendar with its holidays and transferred working days
+// @Accept			json
+// @Produce		json
+// @Param			id	path		string	true	"Calendar id"
+// @Success		200	{object}	domain.Calendar
+// @Failure		400	{object}	Response
+// @Failure		403	{object}	Response
+// @Failure		404	{object}	Response
+// @Failure		500	{object}	Response
+// @Router			/users/calendars/{id} [get]
+func (s *Server) getCalendar(ctx *gin.Context) {
+	var uri domain.CalendarURI
+	if err := ctx.BindUri(&uri); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindUri(): %v", err))
+		return
+	}
+
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	calendar, err := s.calendarService.GetCalendar(ctx, uri.ID, id)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.calendarService.GetCalendar(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, calendar)
+}
+
+// @Summary		User Upload Calendar
+// @Security UserAuth
+// @Tags			Calendars
+// @Description	Uploads an iCalendar (.ics) or JSON working calendar of up to 1 MiB. All-day events are days off, events with the WORKDAY category are transferred working days.
+// @Accept			multipart/form-data
+// @Produce		json
+// @Param			file	formData	file	true	"Calendar file"
+// @Param			name	formData	string	false	"Calendar name, overrides the one of the file"
+// @Success		200		{object}	domain.Calendar
+// @Failure		400		{object}	Response
+// @Failure		403		{object}	Response
+// @Failure		500		{object}	Response
+// @Router			/users/calendars [post]
+func (s *Server) uploadCalendar(ctx *gin.Context) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxCalendarForm)
+
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.FormFile(): %v", err))
+		return
+	}
+
+	if file.Size > maxCalendarSize {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidCalendar, fmt.Sprintf("file.Size: %d", file.Size))
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("file.Open(): %v", err))
+		return
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("io.ReadAll(): %v", err))
+		return
+	}
+
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	calendar, err := s.calendarService.UploadCalendar(ctx, id, ctx.PostForm("name"), data)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.calendarService.UploadCalendar(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, calendar)
+}
+
+// @Summary		User Delete Calendar
+// @Security UserAuth
+// @Tags			Calendars
+// @Description	Deletes an uploaded calendar, the user falls back to the default calendar when it was selected
+// @Accept			json
+// @Produce		json
+// @Param			id	path		string	true	"Calendar id"
+// @Success		200	{object}	Response
+// @Failure		400	{object}	Response
+// @Failure		403	{object}	Response
+// @Failure		404	{object}	Response
+// @Failure		500	{object}	Response
+// @Router			/users/calendars/{id} [delete]
+func (s *Server) deleteCalendar(ctx *gin.Context) {
+	var uri domain.CalendarURI
+	if err := ctx.BindUri(&uri); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindUri(): %v", err))
+		return
+	}
+
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	if err := s.calendarService.DeleteCalendar(ctx, uri.ID, id); err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.calendarService.DeleteCalendar(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newMessage(ctx, "calendar_deleted"))
+}
+
+// @Summary		User Select Calendar
+// @Security UserAuth
+// @Tags			Calendars
+// @Description	Selects the working calendar the days off of the todos are taken from, an empty id selects the default calendar of the server
+// @Accept			json
+// @Produce		json
+// @Param			calendar	body		domain.CalendarSelection	true	"Calendar"
+// @Success		200			{object}	domain.Calendar
+// @Failure		400			{object}	Response
+// @Failure		403			{object}	Response
+// @Failure		500			{object}	Response
+// @Router			/users/calendar [put]
+func (s *Server) selectCalendar(ctx *gin.Context) {
+	var inp domain.CalendarSelection
+	if err := ctx.BindJSON(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindJSON(): %v", err))
+		return
+	}
+
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	calendar, err := s.calendarService.SelectCalendar(ctx, id, inp.Calendar)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.calendarService.SelectCalendar(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, calendar)
+}
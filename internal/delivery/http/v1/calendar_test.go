@@ -0,0 +1,173 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/internal/repository"
+	"github.com/begenov/region-llc-task/internal/repository/memory"
+	"github.com/begenov/region-llc-task/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newCalendarService(t *testing.T, userRepo repository.Users) *service.CalendarService {
+	calendars, err := service.LoadCalendars("")
+	require.NoError(t, err)
+
+	calendarService, err := service.NewCalendarService(memory.NewCalendarRepo(), userRepo, calendars, "")
+	require.NoError(t, err)
+
+	return calendarService
+}
+
+func uploadCalendar(t *testing.T, router *gin.Engine, name string, data string, accessToken string) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	require.NoError(t, form.WriteField("name", name))
+	file, err := form.CreateFormFile("file", "calendar.ics")
+	require.NoError(t, err)
+	_, err = file.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	request, err := http.NewRequest(http.MethodPost, "/api/v1/users/calendars", &buf)
+	require.NoError(t, err)
+	request.Header.Set("Content-Type", form.FormDataContentType())
+	request.Header.Set(authorizationHeaderKey, fmt.Sprintf("Bearer %s", accessToken))
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+// nextWeekday returns the first date with the weekday at least two days from
+// now, so that a todo of that date is still ahead in any timezone.
+func nextWeekday(weekday time.Weekday) time.Time {
+	date := time.Now().UTC().AddDate(0, 0, 2)
+	for date.Weekday() != weekday {
+		date = date.AddDate(0, 0, 1)
+	}
+
+	return date
+}
+
+func TestServer_calendar(t *testing.T) {
+	router := newMemoryRouter(t)
+	tokens := signUpAndSignIn(t, router)
+
+	holiday := nextWeekday(time.Wednesday)
+	workday := nextWeekday(time.Saturday)
+
+	recorder := uploadCalendar(t, router, "Офис", "BEGIN:VCALENDAR\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"DTSTART;VALUE=DATE:"+holiday.Format("20060102")+"\r\n"+
+		"SUMMARY:День компании\r\n"+
+		"END:VEVENT\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"DTSTART;VALUE=DATE:"+workday.Format("20060102")+"\r\n"+
+		"SUMMARY:Рабочая суббота\r\n"+
+		"CATEGORIES:WORKDAY\r\n"+
+		"END:VEVENT\r\n"+
+		"END:VCALENDAR\r\n", tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var calendar domain.Calendar
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &calendar))
+	require.Equal(t, "Офис", calendar.Name)
+	require.Len(t, calendar.Days, 2)
+
+	recorder = uploadCalendar(t, router, "", "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\n", tokens.AccessToken)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodGet, "/api/v1/users/calendars", nil, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var calendars []domain.Calendar
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &calendars))
+	require.Len(t, calendars, 3)
+	require.Equal(t, "kz", calendars[0].ID)
+	require.Equal(t, domain.StandardCalendar, calendars[1].ID)
+	require.Equal(t, calendar.ID, calendars[2].ID)
+
+	other := signUpAndSignIn(t, router)
+	recorder = doJSON(t, router, http.MethodGet, "/api/v1/users/calendars/"+calendar.ID, nil, other.AccessToken)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodPut, "/api/v1/users/calendar", domain.CalendarSelection{Calendar: calendar.ID}, other.AccessToken)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodPut, "/api/v1/users/calendar", domain.CalendarSelection{Calendar: "missing"}, tokens.AccessToken)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	createTodo := func(title string, date time.Time) domain.Todo {
+		recorder := doJSON(t, router, http.MethodPost, "/api/v1/users/todo-list/todo", domain.TodoRequest{
+			Title:    title,
+			ActiveAt: date.Format(domain.Format),
+		}, tokens.AccessToken)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var todo domain.Todo
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &todo))
+		return todo
+	}
+
+	getTodo := func(id string) domain.Todo {
+		recorder := doJSON(t, router, http.MethodGet, "/api/v1/users/todo-list/todo/"+id, nil, tokens.AccessToken)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var todo domain.Todo
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &todo))
+		return todo
+	}
+
+	// The standard calendar only knows about weekends.
+	onHoliday := createTodo("Праздник", holiday)
+	require.False(t, onHoliday.IsDayOff)
+	require.Equal(t, "Праздник", onHoliday.Title)
+
+	onWorkday := createTodo("Суббота", workday)
+	require.True(t, onWorkday.IsDayOff)
+	require.Equal(t, domain.DayOffWeekend, onWorkday.DayOffReason)
+	require.Equal(t, "Суббота", onWorkday.Title)
+
+	recorder = doJSON(t, router, http.MethodPut, "/api/v1/users/calendar", domain.CalendarSelection{Calendar: calendar.ID}, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	onHoliday = getTodo(onHoliday.ID)
+	require.True(t, onHoliday.IsDayOff)
+	require.Equal(t, "День компании", onHoliday.DayOffReason)
+
+	onWorkday = getTodo(onWorkday.ID)
+	require.False(t, onWorkday.IsDayOff)
+	require.Empty(t, onWorkday.DayOffReason)
+
+	recorder = doJSON(t, router, http.MethodGet, "/api/v1/users/todo-list/todo?status=active,done&active_from="+holiday.Format(domain.Format)+"&active_to="+holiday.Format(domain.Format), nil, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var page domain.TodoPage
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &page))
+	require.Len(t, page.Items, 1)
+	require.True(t, page.Items[0].IsDayOff)
+
+	recorder = doJSON(t, router, http.MethodDelete, "/api/v1/users/calendars/"+calendar.ID, nil, other.AccessToken)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodDelete, "/api/v1/users/calendars/"+calendar.ID, nil, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// The user is back on the default calendar.
+	onHoliday = getTodo(onHoliday.ID)
+	require.False(t, onHoliday.IsDayOff)
+
+	recorder = doJSON(t, router, http.MethodGet, "/api/v1/users/calendars/"+calendar.ID, nil, tokens.AccessToken)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
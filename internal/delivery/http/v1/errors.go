@@ -1,16 +1,34 @@
 package v1
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/begenov/region-llc-task/internal/domain"
 )
 
 func checkErrors(err error) int {
+	var retryErr *domain.RetryError
+	if errors.As(err, &retryErr) {
+		return http.StatusTooManyRequests
+	}
+
 	switch err {
 	case domain.ErrInvalidRequest, domain.ErrEmailAlreadyExists, domain.ErrIncorrectDateFormat, domain.ErrHeaderLength,
-		domain.ErrTitleAlreadyExists, domain.ErrInvalidTitle, domain.ErrTodoInvalidId, domain.ErrTodoActiveAtData:
+		domain.ErrTitleAlreadyExists, domain.ErrInvalidTitle, domain.ErrTodoInvalidId, domain.ErrTodoActiveAtData,
+		domain.ErrInvalidCursor, domain.ErrInvalidFilter, domain.ErrInvalidSearch, domain.ErrDescriptionLength,
+		domain.ErrInvalidPriority, domain.ErrInvalidTag, domain.ErrTooManyTags, domain.ErrTodoDueBeforeActive,
+		domain.ErrTooManyItems, domain.ErrInvalidItemsOrder, domain.ErrInvalidRecurrence, domain.ErrInvalidTimezone,
+		domain.ErrInvalidCalendar, domain.ErrInvalidLanguage, domain.ErrInvalidScope, domain.ErrInvalidTokenName,
+		domain.ErrInvalidExpiry, domain.ErrInvalidMFACode, domain.ErrMFANotEnrolled, domain.ErrMFAAlreadyEnabled,
+		domain.ErrInvalidResetToken, domain.ErrIncorrectEmailAddress, domain.ErrIncorrectPassword,
+		domain.ErrInvalidVerifyToken, domain.ErrEmailAlreadyVerified, domain.ErrIncorrectUserName:
 		return http.StatusBadRequest
+	case domain.ErrEmptyAuthHeader, domain.ErrInvalidAuthHeader, domain.ErrEmptyToken, domain.ErrTokenRevoked,
+		domain.ErrInvalidMFAToken:
+		return http.StatusUnauthorized
+	case domain.ErrForbidden, domain.ErrInsufficientScope, domain.ErrMFARequired, domain.ErrEmailNotVerified:
+		return http.StatusForbidden
 	case domain.ErrNotFound:
 		return http.StatusNotFound
 	default:
@@ -3,68 +3,184 @@ package v1
 import (
 	"errors"
 	"fmt"
-	"net/http"
 	"strings"
 
 	"github.com/begenov/region-llc-task/internal/domain"
-	"github.com/begenov/region-llc-task/pkg/logger"
+	"github.com/begenov/region-llc-task/internal/i18n"
+	"github.com/begenov/region-llc-task/pkg/auth"
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 const (
-	authorizationHeaderKey = "Authorization"
-	userCtx                = "userId"
+	authorizationHeaderKey  = "Authorization"
+	acceptLanguageHeaderKey = "Accept-Language"
+	retryAfterHeaderKey     = "Retry-After"
+	userCtx                 = "userId"
+	claimsCtx               = "claims"
+	principalCtx            = "principal"
+	languageCtx             = "language"
 )
 
 func (s *Server) userIdentity(c *gin.Context) {
-	id, err := s.parseAuthHeader(c)
+	principal, err := s.parseAuthHeader(c)
 	if err != nil {
-		newResponse(c, http.StatusUnauthorized, err.Error(), fmt.Sprintf("s.parseAuthHeader(): %v", err))
+		newResponse(c, checkErrors(err), err, fmt.Sprintf("s.parseAuthHeader(): %v", err))
 		return
 	}
 
-	c.Set(userCtx, id)
+	c.Set(userCtx, principal.UserID)
+	c.Set(principalCtx, principal)
 }
 
-func (s *Server) parseAuthHeader(c *gin.Context) (string, error) {
+// requireScope rejects the request unless the access token allows the scope.
+// It runs after userIdentity.
+func requireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := getPrincipal(c)
+		if err != nil {
+			newResponse(c, checkErrors(err), err, fmt.Sprintf("getPrincipal(): %v", err))
+			return
+		}
+
+		if !principal.HasScope(scope) {
+			err := domain.ErrInsufficientScope
+			newResponse(c, checkErrors(err), err, fmt.Sprintf("requireScope(%s): %v", scope, err))
+			return
+		}
+	}
+}
+
+// parseAuthHeader authenticates the bearer token of the request: a personal
+// token, told by its prefix, or an access token. The claims of an access
+// token are kept for the handlers of its session.
+func (s *Server) parseAuthHeader(c *gin.Context) (domain.Principal, error) {
 	header := c.GetHeader(authorizationHeaderKey)
 	if header == "" {
-		return "", errors.New("empty auth header")
+		return domain.Principal{}, domain.ErrEmptyAuthHeader
 	}
 
 	headerParts := strings.Split(header, " ")
 	if len(headerParts) != 2 || headerParts[0] != "Bearer" {
-		return "", domain.ErrInvalidAuthHeader
+		return domain.Principal{}, domain.ErrInvalidAuthHeader
 	}
 
-	if len(headerParts[1]) == 0 {
-		return "", errors.New("token is empty")
+	token := headerParts[1]
+	if len(token) == 0 {
+		return domain.Principal{}, domain.ErrEmptyToken
 	}
 
-	res, err := s.tokenManager.Parse(headerParts[1])
+	if strings.HasPrefix(token, auth.PersonalTokenPrefix) {
+		return s.userService.CheckPersonalToken(c, token)
+	}
+
+	claims, err := s.tokenManager.Parse(token)
 	if err != nil {
-		return "", domain.ErrInvalidAuthHeader
+		return domain.Principal{}, domain.ErrInvalidAuthHeader
 	}
 
-	return res, nil
+	if err := s.userService.CheckAccessToken(c, claims); err != nil {
+		return domain.Principal{}, err
+	}
+
+	c.Set(claimsCtx, claims)
+
+	return domain.Principal{
+		UserID:    claims.Subject,
+		SessionID: claims.SessionID,
+		Scopes:    claims.Scopes,
+	}, nil
 }
 
-func getUserID(c *gin.Context, context string) (primitive.ObjectID, error) {
+func getUserID(c *gin.Context, context string) (string, error) {
 	idFromCtx, ok := c.Get(context)
 	if !ok {
-		return primitive.ObjectID{}, errors.New("userCtx not found")
+		return "", errors.New("userCtx not found")
 	}
 
-	idStr, ok := idFromCtx.(string)
+	id, ok := idFromCtx.(string)
 	if !ok {
-		return primitive.ObjectID{}, errors.New("userCtx is of invalid type")
+		return "", errors.New("userCtx is of invalid type")
 	}
 
-	id, err := primitive.ObjectIDFromHex(idStr)
-	if err != nil {
-		return primitive.ObjectID{}, domain.ErrInternalServer
+	if id == "" {
+		return "", domain.ErrInternalServer
 	}
-	logger.Info(id)
+
 	return id, nil
 }
+
+func getClaims(c *gin.Context) (auth.Claims, error) {
+	claims, ok := c.Get(claimsCtx)
+	if !ok {
+		return auth.Claims{}, errors.New("claimsCtx not found")
+	}
+
+	res, ok := claims.(auth.Claims)
+	if !ok {
+		return auth.Claims{}, errors.New("claimsCtx is of invalid type")
+	}
+
+	return res, nil
+}
+
+func getPrincipal(c *gin.Context) (domain.Principal, error) {
+	principal, ok := c.Get(principalCtx)
+	if !ok {
+		return domain.Principal{}, errors.New("principalCtx not found")
+	}
+
+	res, ok := principal.(domain.Principal)
+	if !ok {
+		return domain.Principal{}, errors.New("principalCtx is of invalid type")
+	}
+
+	return res, nil
+}
+
+// getClient describes the device of the request for its session.
+func getClient(c *gin.Context, device string) domain.Client {
+	return domain.Client{
+		Device:    device,
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	}
+}
+
+// negotiateLanguage picks the language of the responses from the
+// Accept-Language header.
+func negotiateLanguage(c *gin.Context) {
+	setLanguage(c, i18n.Negotiate(c.GetHeader(acceptLanguageHeaderKey)))
+}
+
+// userLanguage overrides the negotiated language with the one chosen by the
+// user, if any. It runs after userIdentity.
+func (s *Server) userLanguage(c *gin.Context) {
+	id, err := getUserID(c, userCtx)
+	if err != nil {
+		newResponse(c, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	language, err := s.userService.GetLanguage(c, id)
+	if err != nil {
+		newResponse(c, checkErrors(err), err, fmt.Sprintf("s.userService.GetLanguage(): %v", err))
+		return
+	}
+
+	if language != "" {
+		setLanguage(c, language)
+	}
+}
+
+func setLanguage(c *gin.Context, language string) {
+	c.Set(languageCtx, language)
+	c.Header("Content-Language", language)
+}
+
+func getLanguage(c *gin.Context) string {
+	if language := c.GetString(languageCtx); language != "" {
+		return language
+	}
+
+	return i18n.DefaultLanguage
+}
@@ -1,15 +1,58 @@
 package v1
 
 import (
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/internal/i18n"
 	"github.com/begenov/region-llc-task/pkg/logger"
 	"github.com/gin-gonic/gin"
 )
 
+// Response.Code is the stable id of the message, Message its text in the
+// language of the client.
 type Response struct {
+	Code    string `json:"code,omitempty" example:"not_found"`
 	Message string `json:"message"`
 }
 
-func newResponse(c *gin.Context, statusCode int, message string, log string) {
+// newResponse aborts the request with the error translated to the language
+// of the client. Errors that are not meant for the client are reported as
+// ErrInternalServer, log keeps the details. A RetryError sets Retry-After.
+func newResponse(c *gin.Context, statusCode int, err error, log string) {
 	logger.Error(log)
-	c.AbortWithStatusJSON(statusCode, Response{message})
+
+	var retryErr *domain.RetryError
+	if errors.As(err, &retryErr) {
+		c.Header(retryAfterHeaderKey, strconv.Itoa(int(math.Ceil(retryErr.RetryAfter.Seconds()))))
+	}
+
+	var apiErr *domain.Error
+	if !errors.As(err, &apiErr) {
+		apiErr = domain.ErrInternalServer
+	}
+
+	c.AbortWithStatusJSON(statusCode, newMessage(c, apiErr.ID))
+}
+
+func newMessage(c *gin.Context, id string) Response {
+	return Response{
+		Code:    id,
+		Message: i18n.Translate(getLanguage(c), id),
+	}
+}
+
+// localizeTodo sets the day-off label of the todo in the language of the
+// client. Names of holidays come from the calendar and are kept as they are.
+func localizeTodo(c *gin.Context, todo domain.Todo) domain.Todo {
+	switch todo.DayOffReason {
+	case domain.DayOffWeekend, domain.DayOffHoliday:
+		todo.DayOffLabel = i18n.Translate(getLanguage(c), "day_off."+todo.DayOffReason)
+	default:
+		todo.DayOffLabel = todo.DayOffReason
+	}
+
+	return todo
 }
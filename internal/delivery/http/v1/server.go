@@ -7,21 +7,24 @@ import (
 )
 
 type Server struct {
-	userService  service.Users
-	todoService  service.Todo
-	tokenManager auth.TokenManager
+	userService     service.Users
+	todoService     service.Todo
+	calendarService service.Calendars
+	tokenManager    auth.TokenManager
 }
 
-func NewServer(userService service.Users, todoService service.Todo, tokenManager auth.TokenManager) *Server {
+func NewServer(userService service.Users, todoService service.Todo, calendarService service.Calendars,
+	tokenManager auth.TokenManager) *Server {
 	return &Server{
-		userService:  userService,
-		todoService:  todoService,
-		tokenManager: tokenManager,
+		userService:     userService,
+		todoService:     todoService,
+		calendarService: calendarService,
+		tokenManager:    tokenManager,
 	}
 }
 
 func (s *Server) Init(api *gin.RouterGroup) {
-	v1 := api.Group("/v1")
+	v1 := api.Group("/v1", negotiateLanguage)
 	{
 		s.initLoadRoutes(v1)
 	}
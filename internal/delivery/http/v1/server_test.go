@@ -0,0 +1,1780 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/internal/repository/memory"
+	"github.com/begenov/region-llc-task/internal/service"
+	"github.com/begenov/region-llc-task/pkg/auth"
+	"github.com/begenov/region-llc-task/pkg/hash"
+	"github.com/begenov/region-llc-task/pkg/mail"
+	"github.com/begenov/region-llc-task/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// unverifiedTodos lets the users of the tests that do not verify their
+// emails create todos.
+const unverifiedTodos = 1000
+
+func newMemoryRouter(t *testing.T) *gin.Engine {
+	return newMFARouter(t, service.MFAPolicy{})
+}
+
+// newMFARouter is newMemoryRouter with a two-factor authentication policy.
+func newMFARouter(t *testing.T, mfa service.MFAPolicy) *gin.Engine {
+	return newTestRouter(t, mfa, &outbox{}, unverifiedTodos, service.SignInPolicy{})
+}
+
+func newTestRouter(t *testing.T, mfa service.MFAPolicy, mailer mail.Mailer, unverifiedLimit int, signIn service.SignInPolicy) *gin.Engine {
+	sessionRepo := memory.NewSessionRepo()
+	personalTokenRepo := memory.NewPersonalTokenRepo()
+	mfaRepo := memory.NewMFARepo()
+	todoRepo := memory.NewTodoRepo()
+	userRepo := memory.NewUserRepo(todoRepo, sessionRepo, personalTokenRepo, mfaRepo)
+	redisRepo := memory.NewRedis()
+
+	token, err := auth.NewManager("qwerty")
+	require.NoError(t, err)
+
+	calendarService := newCalendarService(t, userRepo)
+
+	handler := NewServer(
+		service.NewUserService(userRepo, sessionRepo, personalTokenRepo, mfaRepo, hash.NewHash(), token, time.Minute, time.Hour, redisRepo, mfa, mailer,
+			service.PasswordResetPolicy{URL: "https://todo.example.com/reset-password"},
+			service.EmailVerificationPolicy{URL: "https://todo.example.com/verify-email"}, service.AccountPolicy{DeletionGrace: time.Hour}, signIn),
+		service.NewTodoService(todoRepo, memory.NewTodoItemRepo(), userRepo, calendarService, true, unverifiedLimit),
+		calendarService,
+		token,
+	)
+
+	router := gin.New()
+	// As the server with no TRUSTED_PROXIES, X-Forwarded-For is ignored.
+	require.NoError(t, router.SetTrustedProxies(nil))
+	handler.Init(router.Group("/api"))
+
+	return router
+}
+
+// outbox keeps the messages sent instead of sending them.
+type outbox struct {
+	mu       sync.Mutex
+	messages []mail.Message
+}
+
+func (o *outbox) Send(ctx context.Context, msg mail.Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+func (o *outbox) last(t *testing.T) mail.Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	require.NotEmpty(t, o.messages)
+	return o.messages[len(o.messages)-1]
+}
+
+func doJSON(t *testing.T, router *gin.Engine, method, url string, body interface{}, accessToken string) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&buf).Encode(body))
+	}
+
+	request, err := http.NewRequest(method, url, &buf)
+	require.NoError(t, err)
+	if accessToken != "" {
+		request.Header.Set(authorizationHeaderKey, fmt.Sprintf("Bearer %s", accessToken))
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func signUpAndSignIn(t *testing.T, router *gin.Engine) domain.Token {
+	user := domain.UserRequest{
+		UserName: utils.RandomString(10),
+		Email:    utils.RandomEmail(),
+		Password: utils.RandomString(10),
+	}
+
+	recorder := doJSON(t, router, http.MethodPost, "/api/v1/users/sign-up", user, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in", domain.UserSignInRequest{
+		Email:    user.Email,
+		Password: user.Password,
+	}, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var tokens domain.Token
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &tokens))
+	require.NotEmpty(t, tokens.AccessToken)
+
+	return tokens
+}
+
+func TestServer_memoryStorage(t *testing.T) {
+	router := newMemoryRouter(t)
+
+	user := domain.UserRequest{
+		UserName: utils.RandomString(10),
+		Email:    utils.RandomEmail(),
+		Password: utils.RandomString(10),
+	}
+
+	recorder := doJSON(t, router, http.MethodPost, "/api/v1/users/sign-up", user, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in", domain.UserSignInRequest{
+		Email:    user.Email,
+		Password: user.Password,
+	}, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var tokens domain.Token
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &tokens))
+	require.NotEmpty(t, tokens.AccessToken)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/auth/refresh", domain.RefreshToken{
+		RefreshToken: tokens.RefreshToken,
+	}, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/todo-list/todo", domain.TodoRequest{
+		Title:    utils.RandomString(10),
+		ActiveAt: time.Now().Add(time.Hour * 48).Format(domain.Format),
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var todo domain.Todo
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &todo))
+	require.Equal(t, domain.Active, todo.Status)
+
+	url := fmt.Sprintf("/api/v1/users/todo-list/todo/%s/done", todo.ID)
+	recorder = doJSON(t, router, http.MethodPut, url, nil, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodGet, "/api/v1/users/todo-list/todo?status=done", nil, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var page domain.TodoPage
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &page))
+	require.Len(t, page.Items, 1)
+	require.Equal(t, int64(1), page.Total)
+	require.Equal(t, todo.ID, page.Items[0].ID)
+
+	url = fmt.Sprintf("/api/v1/users/todo-list/todo/%s", todo.ID)
+	recorder = doJSON(t, router, http.MethodDelete, url, nil, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodDelete, url, nil, tokens.AccessToken)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestServer_ownerAuthorization(t *testing.T) {
+	router := newMemoryRouter(t)
+
+	owner := signUpAndSignIn(t, router)
+	other := signUpAndSignIn(t, router)
+
+	recorder := doJSON(t, router, http.MethodPost, "/api/v1/users/todo-list/todo", domain.TodoRequest{
+		Title:    utils.RandomString(10),
+		ActiveAt: time.Now().Add(time.Hour * 48).Format(domain.Format),
+	}, owner.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var todo domain.Todo
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &todo))
+
+	url := fmt.Sprintf("/api/v1/users/todo-list/todo/%s", todo.ID)
+	update := domain.TodoRequest{
+		Title:    utils.RandomString(10),
+		ActiveAt: time.Now().Add(time.Hour * 72).Format(domain.Format),
+	}
+
+	recorder = doJSON(t, router, http.MethodGet, url, nil, other.AccessToken)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodPut, url, update, other.AccessToken)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodPut, url+"/done", nil, other.AccessToken)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodDelete, url, nil, other.AccessToken)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodGet, url, nil, owner.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var stored domain.Todo
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &stored))
+	require.Equal(t, todo, stored)
+
+	recorder = doJSON(t, router, http.MethodPut, url, update, owner.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodDelete, url, nil, owner.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodGet, url, nil, other.AccessToken)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestServer_todoPagination(t *testing.T) {
+	router := newMemoryRouter(t)
+	tokens := signUpAndSignIn(t, router)
+
+	for i := 0; i < 5; i++ {
+		recorder := doJSON(t, router, http.MethodPost, "/api/v1/users/todo-list/todo", domain.TodoRequest{
+			Title:    fmt.Sprintf("todo %d", i),
+			ActiveAt: time.Now().Add(time.Hour * 24 * time.Duration(i+2)).Format(domain.Format),
+		}, tokens.AccessToken)
+		require.Equal(t, http.StatusOK, recorder.Code)
+	}
+
+	var titles []string
+	url := "/api/v1/users/todo-list/todo?status=active,done&sort=title&order=desc&limit=2"
+	cursor := ""
+	for {
+		recorder := doJSON(t, router, http.MethodGet, url+"&cursor="+cursor, nil, tokens.AccessToken)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var page domain.TodoPage
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &page))
+		require.Equal(t, int64(5), page.Total)
+
+		for _, todo := range page.Items {
+			titles = append(titles, todo.Title)
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	require.Equal(t, []string{"todo 4", "todo 3", "todo 2", "todo 1", "todo 0"}, titles)
+}
+
+func TestServer_memorySearch(t *testing.T) {
+	router := newMemoryRouter(t)
+	tokens := signUpAndSignIn(t, router)
+
+	for _, inp := range []domain.TodoRequest{
+		{Title: "Купить молоко", Description: "и хлеб"},
+		{Title: "Позвонить маме", Description: "спросить про молоко"},
+	} {
+		inp.ActiveAt = time.Now().Add(time.Hour * 48).Format(domain.Format)
+		recorder := doJSON(t, router, http.MethodPost, "/api/v1/users/todo-list/todo", inp, tokens.AccessToken)
+		require.Equal(t, http.StatusOK, recorder.Code)
+	}
+
+	recorder := doJSON(t, router, http.MethodGet, "/api/v1/users/todo-list/search?q=молоко+-хлеб", nil, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var results []domain.SearchResult
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &results))
+	require.Len(t, results, 1)
+	require.Equal(t, "Позвонить маме", results[0].Title)
+	require.Equal(t, "спросить про <mark>молоко</mark>", results[0].Highlights["description"])
+}
+
+func TestServer_todoDetails(t *testing.T) {
+	router := newMemoryRouter(t)
+	tokens := signUpAndSignIn(t, router)
+
+	activeAt := time.Now().Add(time.Hour * 48)
+	due := activeAt.Add(time.Hour * 24).UTC().Truncate(time.Second)
+
+	recorder := doJSON(t, router, http.MethodPost, "/api/v1/users/todo-list/todo", domain.TodoRequest{
+		Title:       "Отчёт",
+		Description: "# План\n- собрать данные",
+		Priority:    domain.PriorityHigh,
+		Tags:        []string{"#Work", "report"},
+		ActiveAt:    activeAt.Format(domain.Format),
+		DueAt:       &due,
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var todo domain.Todo
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &todo))
+	require.Equal(t, domain.PriorityHigh, todo.Priority)
+	require.Equal(t, []string{"work", "report"}, todo.Tags)
+	require.NotNil(t, todo.DueAt)
+	require.True(t, due.Equal(*todo.DueAt))
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/todo-list/todo", domain.TodoRequest{
+		Title:    "Прочее",
+		Tags:     []string{"home"},
+		ActiveAt: activeAt.Format(domain.Format),
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/todo-list/todo", domain.TodoRequest{
+		Title:    "Срочно",
+		Priority: "urgent",
+		ActiveAt: activeAt.Format(domain.Format),
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	for query, want := range map[string][]string{
+		"tag=work":                        {"Отчёт"},
+		"tag=work,home":                   {},
+		"priority=medium":                 {"Прочее"},
+		"priority=low&priority=high":      {"Отчёт"},
+		"tag=report&priority=low,high":    {"Отчёт"},
+		"priority=medium,high&sort=title": {"Отчёт", "Прочее"},
+	} {
+		recorder := doJSON(t, router, http.MethodGet, "/api/v1/users/todo-list/todo?status=active,done&"+query, nil, tokens.AccessToken)
+		require.Equal(t, http.StatusOK, recorder.Code, query)
+
+		var page domain.TodoPage
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &page))
+
+		titles := []string{}
+		for _, todo := range page.Items {
+			titles = append(titles, todo.Title)
+		}
+		require.Equal(t, want, titles, query)
+	}
+
+	recorder = doJSON(t, router, http.MethodGet, "/api/v1/users/todo-list/todo?priority=urgent", nil, tokens.AccessToken)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// Editing the details keeps the title, which is not taken by another todo.
+	recorder = doJSON(t, router, http.MethodPut, "/api/v1/users/todo-list/todo/"+todo.ID, domain.TodoRequest{
+		Title:       "Отчёт",
+		Description: "# План\n- отправить",
+		Priority:    domain.PriorityLow,
+		ActiveAt:    activeAt.Format(domain.Format),
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &todo))
+	require.Equal(t, domain.PriorityLow, todo.Priority)
+	require.Equal(t, "# План\n- отправить", todo.Description)
+
+	recorder = doJSON(t, router, http.MethodPut, "/api/v1/users/todo-list/todo/"+todo.ID, domain.TodoRequest{
+		Title:    "Прочее",
+		ActiveAt: activeAt.Format(domain.Format),
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestServer_todoItems(t *testing.T) {
+	router := newMemoryRouter(t)
+	tokens := signUpAndSignIn(t, router)
+
+	recorder := doJSON(t, router, http.MethodPost, "/api/v1/users/todo-list/todo", domain.TodoRequest{
+		Title:    "Переезд",
+		ActiveAt: time.Now().Add(time.Hour * 48).Format(domain.Format),
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var todo domain.Todo
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &todo))
+	itemsURL := "/api/v1/users/todo-list/todo/" + todo.ID + "/items"
+
+	var items []domain.TodoItem
+	for _, title := range []string{"коробки", "грузчики", "ключи"} {
+		recorder := doJSON(t, router, http.MethodPost, itemsURL, domain.TodoItemRequest{Title: title}, tokens.AccessToken)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var item domain.TodoItem
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &item))
+		items = append(items, item)
+	}
+
+	recorder = doJSON(t, router, http.MethodPut, itemsURL, domain.TodoItemsOrder{
+		IDs: []string{items[1].ID, items[0].ID},
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodPut, itemsURL, domain.TodoItemsOrder{
+		IDs: []string{items[2].ID, items[0].ID, items[1].ID},
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var reordered []domain.TodoItem
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &reordered))
+	require.Equal(t, "ключи", reordered[0].Title)
+
+	for _, item := range items[:2] {
+		recorder := doJSON(t, router, http.MethodPut, itemsURL+"/"+item.ID+"/toggle", nil, tokens.AccessToken)
+		require.Equal(t, http.StatusOK, recorder.Code)
+	}
+
+	getTodo := func() domain.Todo {
+		recorder := doJSON(t, router, http.MethodGet, "/api/v1/users/todo-list/todo/"+todo.ID, nil, tokens.AccessToken)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var todo domain.Todo
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &todo))
+		return todo
+	}
+
+	todo = getTodo()
+	require.Equal(t, domain.Progress{Done: 2, Total: 3}, todo.Progress)
+	require.Equal(t, domain.Active, todo.Status)
+
+	other := signUpAndSignIn(t, router)
+	recorder = doJSON(t, router, http.MethodDelete, itemsURL+"/"+items[2].ID, nil, other.AccessToken)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodDelete, itemsURL+"/"+items[2].ID, nil, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	todo = getTodo()
+	require.Equal(t, domain.Progress{Done: 2, Total: 2}, todo.Progress)
+	require.Equal(t, domain.Done, todo.Status)
+}
+
+func TestServer_recurringTodo(t *testing.T) {
+	router := newMemoryRouter(t)
+	tokens := signUpAndSignIn(t, router)
+
+	activeAt := time.Now().Add(time.Hour * 48).UTC()
+
+	recorder := doJSON(t, router, http.MethodPost, "/api/v1/users/todo-list/todo", domain.TodoRequest{
+		Title:      "Стендап",
+		ActiveAt:   activeAt.Format(domain.Format),
+		Recurrence: "RRULE:FREQ=DAILY;COUNT=2",
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var todo domain.Todo
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &todo))
+	require.Equal(t, "FREQ=DAILY;COUNT=2", todo.Recurrence)
+	require.Equal(t, 1, todo.Occurrence)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/todo-list/todo/"+todo.ID+"/items", domain.TodoItemRequest{Title: "вчера"}, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/todo-list/todo", domain.TodoRequest{
+		Title:      "Отчёт",
+		ActiveAt:   activeAt.Format(domain.Format),
+		Recurrence: "FREQ=YEARLY",
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	listTodos := func() []domain.Todo {
+		recorder := doJSON(t, router, http.MethodGet, "/api/v1/users/todo-list/todo?status=active,done&sort=activeAt", nil, tokens.AccessToken)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var page domain.TodoPage
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &page))
+		return page.Items
+	}
+
+	recorder = doJSON(t, router, http.MethodPut, "/api/v1/users/todo-list/todo/"+todo.ID+"/done", nil, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	todos := listTodos()
+	require.Len(t, todos, 2)
+
+	next := todos[1]
+	nextActiveAt := activeAt.AddDate(0, 0, 1).Format(domain.Format)
+	require.Equal(t, nextActiveAt, next.ActiveAt.Format(domain.Format))
+	require.Equal(t, "Стендап ("+nextActiveAt+")", next.Title)
+	require.Equal(t, 2, next.Occurrence)
+	require.Equal(t, domain.Active, next.Status)
+	require.Equal(t, domain.Progress{Total: 1}, next.Progress)
+
+	// Completing it again does not repeat the occurrence, nor does the last
+	// one of the series.
+	recorder = doJSON(t, router, http.MethodPut, "/api/v1/users/todo-list/todo/"+todo.ID+"/done", nil, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodPut, "/api/v1/users/todo-list/todo/"+next.ID+"/done", nil, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	require.Len(t, listTodos(), 2)
+}
+
+func TestServer_userTimezone(t *testing.T) {
+	router := newMemoryRouter(t)
+
+	recorder := doJSON(t, router, http.MethodPost, "/api/v1/users/sign-up", domain.UserRequest{
+		UserName: utils.RandomString(10),
+		Email:    utils.RandomEmail(),
+		Password: utils.RandomString(10),
+		Timezone: "Mars/Olympus",
+	}, "")
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// Twelve hours ahead of UTC, the local date is a day ahead for half of
+	// the day.
+	zone := "Etc/GMT-12"
+	loc, err := time.LoadLocation(zone)
+	require.NoError(t, err)
+
+	user := domain.UserRequest{
+		UserName: utils.RandomString(10),
+		Email:    utils.RandomEmail(),
+		Password: utils.RandomString(10),
+		Timezone: zone,
+	}
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-up", user, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in", domain.UserSignInRequest{
+		Email:    user.Email,
+		Password: user.Password,
+	}, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var tokens domain.Token
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &tokens))
+
+	today := time.Now().In(loc)
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/todo-list/todo", domain.TodoRequest{
+		Title:    "Сегодня",
+		ActiveAt: today.Format(domain.Format),
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var todo domain.Todo
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &todo))
+	midnight := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, loc)
+	require.True(t, midnight.Equal(todo.ActiveAt))
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/todo-list/todo", domain.TodoRequest{
+		Title:    "Вчера",
+		ActiveAt: today.AddDate(0, 0, -1).Format(domain.Format),
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/todo-list/todo", domain.TodoRequest{
+		Title:    "Завтра",
+		ActiveAt: today.AddDate(0, 0, 1).Format(domain.Format),
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// Only the todo of today is active yet, on the user's clock.
+	recorder = doJSON(t, router, http.MethodGet, "/api/v1/users/todo-list/todo", nil, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var page domain.TodoPage
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &page))
+	require.Len(t, page.Items, 1)
+	require.Equal(t, todo.ID, page.Items[0].ID)
+
+	require.Equal(t, "Сегодня", page.Items[0].Title)
+
+	weekday := today.Weekday()
+	require.Equal(t, weekday == time.Saturday || weekday == time.Sunday, page.Items[0].IsDayOff)
+
+	recorder = doJSON(t, router, http.MethodGet, "/api/v1/users/todo-list/todo?status=active,done&active_from="+today.Format(domain.Format)+"&active_to="+today.Format(domain.Format), nil, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	page = domain.TodoPage{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &page))
+	require.Len(t, page.Items, 1)
+	require.Equal(t, todo.ID, page.Items[0].ID)
+}
+
+func TestServer_language(t *testing.T) {
+	router := newMemoryRouter(t)
+	tokens := signUpAndSignIn(t, router)
+
+	do := func(method, url string, body interface{}, accessToken string, language string) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&buf).Encode(body))
+		}
+
+		request, err := http.NewRequest(method, url, &buf)
+		require.NoError(t, err)
+		request.Header.Set(acceptLanguageHeaderKey, language)
+		if accessToken != "" {
+			request.Header.Set(authorizationHeaderKey, fmt.Sprintf("Bearer %s", accessToken))
+		}
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		return recorder
+	}
+
+	checkMessage := func(recorder *httptest.ResponseRecorder, language, code, message string) {
+		var res Response
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+		require.Equal(t, Response{Code: code, Message: message}, res)
+		require.Equal(t, language, recorder.Header().Get("Content-Language"))
+	}
+
+	recorder := do(http.MethodGet, "/api/v1/users/todo-list/todo/missing", nil, "", "ru-RU,ru;q=0.9,en;q=0.8")
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	checkMessage(recorder, "ru", "empty_auth_header", "нет заголовка авторизации")
+
+	recorder = do(http.MethodGet, "/api/v1/users/todo-list/todo/"+utils.RandomString(10), nil, tokens.AccessToken, "kk")
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+	checkMessage(recorder, "kk", "not_found", "табылмады")
+
+	recorder = do(http.MethodGet, "/api/v1/users/todo-list/todo/"+utils.RandomString(10), nil, tokens.AccessToken, "de")
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+	checkMessage(recorder, "en", "not_found", "not found")
+
+	recorder = do(http.MethodPost, "/api/v1/users/sign-up", domain.UserRequest{
+		UserName: utils.RandomString(10),
+		Email:    utils.RandomEmail(),
+		Password: utils.RandomString(10),
+		Language: "de",
+	}, "", "ru")
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	checkMessage(recorder, "ru", "invalid_language", "язык должен быть ru, kk или en")
+
+	saturday := time.Now().UTC().AddDate(0, 0, 2)
+	for saturday.Weekday() != time.Saturday {
+		saturday = saturday.AddDate(0, 0, 1)
+	}
+
+	recorder = do(http.MethodPost, "/api/v1/users/todo-list/todo", domain.TodoRequest{
+		Title:    "Суббота",
+		ActiveAt: saturday.Format(domain.Format),
+	}, tokens.AccessToken, "ru")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var todo domain.Todo
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &todo))
+	require.Equal(t, "Суббота", todo.Title)
+	require.Equal(t, domain.DayOffWeekend, todo.DayOffReason)
+	require.Equal(t, "Выходной", todo.DayOffLabel)
+
+	recorder = do(http.MethodPut, "/api/v1/users/language", domain.LanguageSelection{Language: "fr"}, tokens.AccessToken, "en")
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = do(http.MethodPut, "/api/v1/users/language", domain.LanguageSelection{Language: "kk"}, tokens.AccessToken, "en")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "kk", recorder.Header().Get("Content-Language"))
+
+	// The language of the user wins over the header.
+	recorder = do(http.MethodGet, "/api/v1/users/todo-list/todo?status=active,done", nil, tokens.AccessToken, "ru")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var page domain.TodoPage
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &page))
+	require.Len(t, page.Items, 1)
+	require.Equal(t, "Демалыс күні", page.Items[0].DayOffLabel)
+
+	recorder = do(http.MethodDelete, "/api/v1/users/todo-list/todo/"+todo.ID, nil, tokens.AccessToken, "ru")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	checkMessage(recorder, "kk", "todo_deleted", "Тапсырма жойылды")
+
+	recorder = do(http.MethodPut, "/api/v1/users/language", domain.LanguageSelection{}, tokens.AccessToken, "ru")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = do(http.MethodGet, "/api/v1/users/todo-list/todo/"+todo.ID, nil, tokens.AccessToken, "ru")
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+	checkMessage(recorder, "ru", "not_found", "не найдено")
+}
+
+// signUp registers a user whose sessions are started with signIn.
+func signUp(t *testing.T, router *gin.Engine) domain.UserRequest {
+	user := domain.UserRequest{
+		UserName: utils.RandomString(10),
+		Email:    utils.RandomEmail(),
+		Password: utils.RandomString(10),
+	}
+
+	recorder := doJSON(t, router, http.MethodPost, "/api/v1/users/sign-up", user, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	return user
+}
+
+func signIn(t *testing.T, router *gin.Engine, user domain.UserRequest, device string) domain.Token {
+	var buf bytes.Buffer
+	require.NoError(t, json.NewEncoder(&buf).Encode(domain.UserSignInRequest{
+		Email:    user.Email,
+		Password: user.Password,
+		Device:   device,
+	}))
+
+	request, err := http.NewRequest(http.MethodPost, "/api/v1/users/sign-in", &buf)
+	require.NoError(t, err)
+	request.Header.Set("User-Agent", device+"/1.0")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var tokens domain.Token
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &tokens))
+	return tokens
+}
+
+func TestServer_logout(t *testing.T) {
+	router := newMemoryRouter(t)
+	user := signUp(t, router)
+
+	authorized := func(tokens domain.Token) int {
+		return doJSON(t, router, http.MethodGet, "/api/v1/users/calendars", nil, tokens.AccessToken).Code
+	}
+
+	refresh := func(tokens domain.Token) (int, domain.Token) {
+		recorder := doJSON(t, router, http.MethodPost, "/api/v1/users/auth/refresh", domain.RefreshToken{RefreshToken: tokens.RefreshToken}, "")
+
+		var refreshed domain.Token
+		if recorder.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &refreshed))
+		}
+		return recorder.Code, refreshed
+	}
+
+	laptop, phone := signIn(t, router, user, "Laptop"), signIn(t, router, user, "Phone")
+
+	recorder := doJSON(t, router, http.MethodPost, "/api/v1/users/auth/logout", nil, laptop.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	require.Equal(t, http.StatusUnauthorized, authorized(laptop))
+	code, _ := refresh(laptop)
+	require.Equal(t, http.StatusNotFound, code)
+
+	// Signing out of the laptop keeps the phone signed in.
+	require.Equal(t, http.StatusOK, authorized(phone))
+	code, phone = refresh(phone)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, http.StatusOK, authorized(phone))
+
+	tablet := signIn(t, router, user, "Tablet")
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/auth/logout-everywhere", nil, phone.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	for _, tokens := range []domain.Token{phone, tablet} {
+		require.Equal(t, http.StatusUnauthorized, authorized(tokens))
+		code, _ := refresh(tokens)
+		require.Equal(t, http.StatusNotFound, code)
+	}
+
+	laptop = signIn(t, router, user, "Laptop")
+	require.Equal(t, http.StatusOK, authorized(laptop))
+	code, _ = refresh(laptop)
+	require.Equal(t, http.StatusOK, code)
+}
+
+func TestServer_sessions(t *testing.T) {
+	router := newMemoryRouter(t)
+	user := signUp(t, router)
+
+	laptop := signIn(t, router, user, "Laptop")
+	phone := signIn(t, router, user, "Phone")
+
+	getSessions := func(tokens domain.Token) []domain.Session {
+		recorder := doJSON(t, router, http.MethodGet, "/api/v1/users/sessions", nil, tokens.AccessToken)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var sessions []domain.Session
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &sessions))
+		return sessions
+	}
+
+	sessions := getSessions(phone)
+	require.Len(t, sessions, 2)
+
+	devices := make(map[string]domain.Session)
+	for _, session := range sessions {
+		require.NotEmpty(t, session.ID)
+		require.Equal(t, session.Device+"/1.0", session.UserAgent)
+		require.False(t, session.CreatedAt.IsZero())
+		require.True(t, session.ExpirationAt.After(time.Now()))
+		devices[session.Device] = session
+	}
+	require.True(t, devices["Phone"].Current)
+	require.False(t, devices["Laptop"].Current)
+
+	require.NotContains(t, doJSON(t, router, http.MethodGet, "/api/v1/users/sessions", nil, phone.AccessToken).Body.String(), phone.RefreshToken)
+
+	// Sessions of another user cannot be revoked.
+	other := signIn(t, router, signUp(t, router), "Other")
+	recorder := doJSON(t, router, http.MethodDelete, "/api/v1/users/sessions/"+devices["Laptop"].ID, nil, other.AccessToken)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodDelete, "/api/v1/users/sessions/"+devices["Laptop"].ID, nil, phone.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodGet, "/api/v1/users/sessions", nil, laptop.AccessToken)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/auth/refresh", domain.RefreshToken{RefreshToken: laptop.RefreshToken}, "")
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodDelete, "/api/v1/users/sessions/"+devices["Laptop"].ID, nil, phone.AccessToken)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// Refreshing keeps the session.
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/auth/refresh", domain.RefreshToken{RefreshToken: phone.RefreshToken}, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &phone))
+
+	sessions = getSessions(phone)
+	require.Len(t, sessions, 1)
+	require.Equal(t, devices["Phone"].ID, sessions[0].ID)
+	require.Equal(t, "Phone", sessions[0].Device)
+	require.True(t, sessions[0].Current)
+}
+
+func TestServer_refreshTokenReuse(t *testing.T) {
+	router := newMemoryRouter(t)
+	user := signUp(t, router)
+
+	phone := signIn(t, router, user, "Phone")
+	laptop := signIn(t, router, user, "Laptop")
+
+	refresh := func(refreshToken string) *httptest.ResponseRecorder {
+		return doJSON(t, router, http.MethodPost, "/api/v1/users/auth/refresh", domain.RefreshToken{RefreshToken: refreshToken}, "")
+	}
+
+	stolen := phone.RefreshToken
+
+	recorder := refresh(stolen)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &phone))
+	require.NotEqual(t, stolen, phone.RefreshToken)
+
+	recorder = refresh(phone.RefreshToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &phone))
+
+	// Replaying a replaced token ends the whole session.
+	recorder = refresh(stolen)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = refresh(phone.RefreshToken)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodGet, "/api/v1/users/sessions", nil, phone.AccessToken)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	// Other sessions are kept.
+	recorder = doJSON(t, router, http.MethodGet, "/api/v1/users/sessions", nil, laptop.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var sessions []domain.Session
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &sessions))
+	require.Len(t, sessions, 1)
+	require.Equal(t, "Laptop", sessions[0].Device)
+
+	recorder = refresh(laptop.RefreshToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestServer_scopes(t *testing.T) {
+	router := newMemoryRouter(t)
+	tokens := signUpAndSignIn(t, router)
+
+	manager, err := auth.NewManager("qwerty")
+	require.NoError(t, err)
+
+	claims, err := manager.Parse(tokens.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, domain.UserScopes, claims.Scopes)
+	require.Equal(t, auth.DefaultIssuer, claims.Issuer)
+	require.Equal(t, []string{auth.DefaultAudience}, claims.Audience)
+
+	readOnly, err := manager.NewJWT(auth.Claims{
+		Subject:   claims.Subject,
+		SessionID: claims.SessionID,
+		Scopes:    []string{domain.ScopeTodosRead},
+	}, time.Minute)
+	require.NoError(t, err)
+
+	recorder := doJSON(t, router, http.MethodGet, "/api/v1/users/todo-list/todo", nil, readOnly)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/todo-list/todo", domain.TodoRequest{
+		Title:    utils.RandomString(10),
+		ActiveAt: time.Now().Add(time.Hour * 48).Format(domain.Format),
+	}, readOnly)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	var res Response
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+	require.Equal(t, "insufficient_scope", res.Code)
+
+	for _, url := range []string{"/api/v1/users/sessions", "/api/v1/users/calendars"} {
+		recorder = doJSON(t, router, http.MethodGet, url, nil, readOnly)
+		require.Equal(t, http.StatusForbidden, recorder.Code, url)
+	}
+
+	// Tokens issued for another service are not accepted.
+	other, err := auth.NewManager("qwerty")
+	require.NoError(t, err)
+	other.SetIssuer(auth.DefaultIssuer, "billing")
+
+	foreign, err := other.NewJWT(auth.Claims{Subject: claims.Subject, Scopes: domain.UserScopes}, time.Minute)
+	require.NoError(t, err)
+
+	recorder = doJSON(t, router, http.MethodGet, "/api/v1/users/todo-list/todo", nil, foreign)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestServer_personalTokens(t *testing.T) {
+	router := newMemoryRouter(t)
+	tokens := signUpAndSignIn(t, router)
+
+	recorder := doJSON(t, router, http.MethodPost, "/api/v1/users/tokens", domain.PersonalTokenRequest{
+		Name:   "CI",
+		Scopes: []string{domain.ScopeTodosRead, domain.ScopeTodosWrite},
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var created domain.NewPersonalToken
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &created))
+	require.NotEmpty(t, created.ID)
+	require.True(t, auth.VerifyToken(created.Token, auth.PersonalTokenPrefix))
+	require.Nil(t, created.ExpiresAt)
+
+	// The personal token works in place of an access token, for its scopes.
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/todo-list/todo", domain.TodoRequest{
+		Title:    utils.RandomString(10),
+		ActiveAt: time.Now().Add(time.Hour * 48).Format(domain.Format),
+	}, created.Token)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodGet, "/api/v1/users/todo-list/todo", nil, created.Token)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	for _, url := range []string{"/api/v1/users/calendars", "/api/v1/users/sessions", "/api/v1/users/tokens"} {
+		recorder = doJSON(t, router, http.MethodGet, url, nil, created.Token)
+		require.Equal(t, http.StatusForbidden, recorder.Code, url)
+	}
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/auth/logout", nil, created.Token)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	// Only the hash is stored, the token is never shown again.
+	recorder = doJSON(t, router, http.MethodGet, "/api/v1/users/tokens", nil, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NotContains(t, recorder.Body.String(), created.Token)
+
+	var list []domain.PersonalToken
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &list))
+	require.Len(t, list, 1)
+	require.Equal(t, created.ID, list[0].ID)
+	require.Equal(t, "CI", list[0].Name)
+	require.Equal(t, []string{domain.ScopeTodosRead, domain.ScopeTodosWrite}, list[0].Scopes)
+	require.NotNil(t, list[0].LastUsedAt)
+
+	// Invalid requests.
+	expiredAt := time.Now().Add(-time.Hour)
+	for _, req := range []domain.PersonalTokenRequest{
+		{Name: "Admin", Scopes: []string{domain.ScopeAccount}},
+		{Name: "Old", Scopes: []string{domain.ScopeTodosRead}, ExpiresAt: &expiredAt},
+		{Name: "Empty"},
+	} {
+		recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/tokens", req, tokens.AccessToken)
+		require.Equal(t, http.StatusBadRequest, recorder.Code, req.Name)
+	}
+
+	// Tokens of another user cannot be revoked.
+	other := signUpAndSignIn(t, router)
+	recorder = doJSON(t, router, http.MethodDelete, "/api/v1/users/tokens/"+created.ID, nil, other.AccessToken)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodDelete, "/api/v1/users/tokens/"+created.ID, nil, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodGet, "/api/v1/users/todo-list/todo", nil, created.Token)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	// A malformed token is rejected like an invalid access token.
+	recorder = doJSON(t, router, http.MethodGet, "/api/v1/users/todo-list/todo", nil, auth.PersonalTokenPrefix+"qwerty")
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+// totpCode is the code of the secret the authenticator app shows at the time.
+func totpCode(t *testing.T, secret string, at time.Time) string {
+	code, err := auth.TOTPCode(secret, at)
+	require.NoError(t, err)
+	return code
+}
+
+func signInMFA(t *testing.T, router *gin.Engine, user domain.UserRequest) domain.Token {
+	recorder := doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in", domain.UserSignInRequest{
+		Email:    user.Email,
+		Password: user.Password,
+	}, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var tokens domain.Token
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &tokens))
+	require.Empty(t, tokens.AccessToken)
+	require.Empty(t, tokens.RefreshToken)
+	require.True(t, auth.VerifyToken(tokens.MFAToken, auth.MFATokenPrefix))
+	return tokens
+}
+
+func TestServer_mfa(t *testing.T) {
+	router := newMemoryRouter(t)
+	user := signUp(t, router)
+	tokens := signIn(t, router, user, "Laptop")
+
+	recorder := doJSON(t, router, http.MethodPost, "/api/v1/users/mfa", nil, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var enrollment domain.MFAEnrollment
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &enrollment))
+	require.NotEmpty(t, enrollment.Secret)
+	require.Contains(t, enrollment.URI, "otpauth://totp/")
+	require.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+
+	// Two-factor authentication is off until a code confirms the secret.
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/mfa/confirm", domain.MFACode{
+		Code: totpCode(t, enrollment.Secret, time.Now().Add(time.Hour)),
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	signIn(t, router, user, "Laptop")
+
+	code := totpCode(t, enrollment.Secret, time.Now())
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/mfa/confirm", domain.MFACode{Code: code}, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var recovery domain.RecoveryCodes
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &recovery))
+	require.Len(t, recovery.RecoveryCodes, 10)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/mfa", nil, tokens.AccessToken)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// The password alone gives an MFA token, the code completes the sign-in.
+	challenge := signInMFA(t, router, user)
+	require.False(t, challenge.MFAEnrollment)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in/mfa", domain.MFASignInRequest{
+		MFAToken: challenge.MFAToken,
+		Code:     code,
+	}, "")
+	require.Equal(t, http.StatusBadRequest, recorder.Code, "a code is accepted once")
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in/mfa", domain.MFASignInRequest{
+		MFAToken: challenge.MFAToken,
+		Code:     totpCode(t, enrollment.Secret, time.Now().Add(30*time.Second)),
+		Device:   "Phone",
+	}, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var signedIn domain.Token
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &signedIn))
+	require.NotEmpty(t, signedIn.AccessToken)
+	require.NotEmpty(t, signedIn.RefreshToken)
+	require.Empty(t, signedIn.RecoveryCodes)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in/mfa", domain.MFASignInRequest{
+		MFAToken: challenge.MFAToken,
+		Code:     recovery.RecoveryCodes[0],
+	}, "")
+	require.Equal(t, http.StatusUnauthorized, recorder.Code, "an MFA token is used once")
+
+	// A recovery code works once, typed in any case.
+	challenge = signInMFA(t, router, user)
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in/mfa", domain.MFASignInRequest{
+		MFAToken: challenge.MFAToken,
+		Code:     strings.ToUpper(recovery.RecoveryCodes[0]),
+	}, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	challenge = signInMFA(t, router, user)
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in/mfa", domain.MFASignInRequest{
+		MFAToken: challenge.MFAToken,
+		Code:     recovery.RecoveryCodes[0],
+	}, "")
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodGet, "/api/v1/users/mfa", nil, signedIn.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var status domain.MFAStatus
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &status))
+	require.Equal(t, domain.MFAStatus{Enabled: true, RecoveryCodesLeft: 9}, status)
+
+	// New recovery codes replace the unused ones.
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/mfa/recovery-codes", domain.MFACode{
+		Code: recovery.RecoveryCodes[1],
+	}, signedIn.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var regenerated domain.RecoveryCodes
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &regenerated))
+	require.Len(t, regenerated.RecoveryCodes, 10)
+
+	recorder = doJSON(t, router, http.MethodDelete, "/api/v1/users/mfa", domain.MFACode{
+		Code: recovery.RecoveryCodes[2],
+	}, signedIn.AccessToken)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodDelete, "/api/v1/users/mfa", domain.MFACode{
+		Code: regenerated.RecoveryCodes[0],
+	}, signedIn.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	tokens = signIn(t, router, user, "Laptop")
+	require.NotEmpty(t, tokens.AccessToken)
+	require.Empty(t, tokens.MFAToken)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in/mfa", domain.MFASignInRequest{
+		MFAToken: auth.MFATokenPrefix + "qwerty",
+		Code:     code,
+	}, "")
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestServer_mfaAttempts(t *testing.T) {
+	// Wrong MFA codes are failed sign-ins too, the limit of the account is
+	// raised to see the limits of MFA alone.
+	router := newTestRouter(t, service.MFAPolicy{}, &outbox{}, unverifiedTodos, service.SignInPolicy{
+		FreeAttempts:    20,
+		LockoutAttempts: 20,
+	})
+	user := signUp(t, router)
+	tokens := signIn(t, router, user, "Laptop")
+
+	recorder := doJSON(t, router, http.MethodPost, "/api/v1/users/mfa", nil, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var enrollment domain.MFAEnrollment
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &enrollment))
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/mfa/confirm", domain.MFACode{
+		Code: totpCode(t, enrollment.Secret, time.Now()),
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	challenge := signInMFA(t, router, user)
+	wrong := domain.MFASignInRequest{MFAToken: challenge.MFAToken, Code: "wrong-code"}
+	for i := 0; i < 5; i++ {
+		recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in/mfa", wrong, "")
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	}
+
+	// The MFA token is dropped, the password has to be entered again.
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in/mfa", domain.MFASignInRequest{
+		MFAToken: challenge.MFAToken,
+		Code:     totpCode(t, enrollment.Secret, time.Now().Add(30*time.Second)),
+	}, "")
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	// New MFA tokens do not give more guesses: the wrong codes of the user
+	// are counted across them and lock the MFA sign-in.
+	challenge = signInMFA(t, router, user)
+	wrong = domain.MFASignInRequest{MFAToken: challenge.MFAToken, Code: "wrong-code"}
+	for i := 0; i < 4; i++ {
+		recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in/mfa", wrong, "")
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	}
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in/mfa", wrong, "")
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.NotEmpty(t, recorder.Header().Get("Retry-After"))
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in", domain.UserSignInRequest{
+		Email:    user.Email,
+		Password: user.Password,
+	}, "")
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+}
+
+func TestServer_mfaRequired(t *testing.T) {
+	router := newMFARouter(t, service.MFAPolicy{Issuer: "Region Todo", RequiredDomains: []string{"example.org"}})
+
+	user := domain.UserRequest{
+		UserName: utils.RandomString(10),
+		Email:    utils.RandomString(10) + "@example.org",
+		Password: utils.RandomString(10),
+	}
+	recorder := doJSON(t, router, http.MethodPost, "/api/v1/users/sign-up", user, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// The organization enforces two-factor authentication, it is set up
+	// during the sign-in.
+	challenge := signInMFA(t, router, user)
+	require.True(t, challenge.MFAEnrollment)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in/mfa/enroll", domain.MFAEnrollRequest{
+		MFAToken: challenge.MFAToken,
+	}, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var enrollment domain.MFAEnrollment
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &enrollment))
+	require.Contains(t, enrollment.URI, "issuer=Region+Todo")
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in/mfa", domain.MFASignInRequest{
+		MFAToken: challenge.MFAToken,
+		Code:     totpCode(t, enrollment.Secret, time.Now()),
+	}, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var tokens domain.Token
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &tokens))
+	require.NotEmpty(t, tokens.AccessToken)
+	require.Len(t, tokens.RecoveryCodes, 10)
+
+	recorder = doJSON(t, router, http.MethodGet, "/api/v1/users/mfa", nil, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var status domain.MFAStatus
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &status))
+	require.Equal(t, domain.MFAStatus{Enabled: true, Required: true, RecoveryCodesLeft: 10}, status)
+
+	recorder = doJSON(t, router, http.MethodDelete, "/api/v1/users/mfa", domain.MFACode{
+		Code: tokens.RecoveryCodes[0],
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	// Once enabled, the sign-in asks for a code.
+	challenge = signInMFA(t, router, user)
+	require.False(t, challenge.MFAEnrollment)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in/mfa/enroll", domain.MFAEnrollRequest{
+		MFAToken: challenge.MFAToken,
+	}, "")
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestServer_passwordReset(t *testing.T) {
+	mailer := &outbox{}
+	router := newTestRouter(t, service.MFAPolicy{}, mailer, unverifiedTodos, service.SignInPolicy{})
+	user := signUp(t, router)
+	tokens := signIn(t, router, user, "Laptop")
+	sent := len(mailer.messages)
+
+	// An unknown email gets the same response, and no email.
+	recorder := doJSON(t, router, http.MethodPost, "/api/v1/users/password-reset", domain.PasswordResetRequest{
+		Email: utils.RandomEmail(),
+	}, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Len(t, mailer.messages, sent)
+
+	resetToken := func() string {
+		recorder := doJSON(t, router, http.MethodPost, "/api/v1/users/password-reset", domain.PasswordResetRequest{
+			Email: user.Email,
+		}, "")
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		msg := mailer.last(t)
+		require.Equal(t, user.Email, msg.To)
+		require.Equal(t, "Password reset", msg.Subject)
+
+		link := regexp.MustCompile(`https://todo\.example\.com/reset-password\?token=(\S+)`).FindStringSubmatch(msg.Body)
+		require.Len(t, link, 2, msg.Body)
+		return link[1]
+	}
+
+	// A new link replaces the one sent before.
+	replaced := resetToken()
+	token := resetToken()
+	require.NotEqual(t, replaced, token)
+	require.True(t, auth.VerifyToken(token, auth.PasswordResetTokenPrefix))
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/password-reset/confirm", domain.PasswordReset{
+		Token:    replaced,
+		Password: utils.RandomString(10),
+	}, "")
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/password-reset/confirm", domain.PasswordReset{
+		Token:    token,
+		Password: "short",
+	}, "")
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	password := utils.RandomString(10)
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/password-reset/confirm", domain.PasswordReset{
+		Token:    token,
+		Password: password,
+	}, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// The link works once.
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/password-reset/confirm", domain.PasswordReset{
+		Token:    token,
+		Password: utils.RandomString(10),
+	}, "")
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// Every session has ended.
+	recorder = doJSON(t, router, http.MethodGet, "/api/v1/users/sessions", nil, tokens.AccessToken)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/auth/refresh", domain.RefreshToken{
+		RefreshToken: tokens.RefreshToken,
+	}, "")
+	require.NotEqual(t, http.StatusOK, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in", domain.UserSignInRequest{
+		Email:    user.Email,
+		Password: user.Password,
+	}, "")
+	require.NotEqual(t, http.StatusOK, recorder.Code)
+
+	user.Password = password
+	signIn(t, router, user, "Laptop")
+}
+
+func TestServer_passwordResetLanguage(t *testing.T) {
+	mailer := &outbox{}
+	router := newTestRouter(t, service.MFAPolicy{}, mailer, unverifiedTodos, service.SignInPolicy{})
+	user := signUp(t, router)
+
+	var buf bytes.Buffer
+	require.NoError(t, json.NewEncoder(&buf).Encode(domain.PasswordResetRequest{Email: user.Email}))
+
+	request, err := http.NewRequest(http.MethodPost, "/api/v1/users/password-reset", &buf)
+	require.NoError(t, err)
+	request.Header.Set(acceptLanguageHeaderKey, "kk")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	require.Equal(t, "Құпия сөзді қалпына келтіру", mailer.last(t).Subject)
+}
+
+// verificationToken is the token of the verification link last sent to the
+// email.
+func verificationToken(t *testing.T, mailer *outbox, email string) string {
+	msg := mailer.last(t)
+	require.Equal(t, email, msg.To)
+
+	link := regexp.MustCompile(`https://todo\.example\.com/verify-email\?token=(\S+)`).FindStringSubmatch(msg.Body)
+	require.Len(t, link, 2, msg.Body)
+	return link[1]
+}
+
+func TestServer_emailVerification(t *testing.T) {
+	mailer := &outbox{}
+	router := newTestRouter(t, service.MFAPolicy{}, mailer, 1, service.SignInPolicy{})
+	user := signUp(t, router)
+	tokens := signIn(t, router, user, "Laptop")
+
+	msg := mailer.last(t)
+	require.Equal(t, "Verify your email", msg.Subject)
+	signUpToken := verificationToken(t, mailer, user.Email)
+
+	createTodo := func() *httptest.ResponseRecorder {
+		return doJSON(t, router, http.MethodPost, "/api/v1/users/todo-list/todo", domain.TodoRequest{
+			Title:    utils.RandomString(10),
+			ActiveAt: time.Now().AddDate(0, 0, 1).Format(domain.Format),
+		}, tokens.AccessToken)
+	}
+
+	// An unverified user has a todo to try the service, and no more.
+	recorder := createTodo()
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = createTodo()
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+	require.Contains(t, recorder.Body.String(), "email_not_verified")
+
+	// A new link replaces the one sent on sign-up.
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/verify-email/resend", nil, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	token := verificationToken(t, mailer, user.Email)
+	require.NotEqual(t, signUpToken, token)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/verify-email", domain.EmailVerification{Token: signUpToken}, "")
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	require.Contains(t, recorder.Body.String(), "invalid_verification_token")
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/verify-email", domain.EmailVerification{Token: token}, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// The link works once.
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/verify-email", domain.EmailVerification{Token: token}, "")
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = createTodo()
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/verify-email/resend", nil, tokens.AccessToken)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	require.Contains(t, recorder.Body.String(), "email_already_verified")
+}
+
+func TestServer_changeEmail(t *testing.T) {
+	mailer := &outbox{}
+	router := newTestRouter(t, service.MFAPolicy{}, mailer, 0, service.SignInPolicy{})
+	other := signUp(t, router)
+	user := signUp(t, router)
+	tokens := signIn(t, router, user, "Laptop")
+
+	recorder := doJSON(t, router, http.MethodPut, "/api/v1/users/email", domain.EmailChange{
+		Email:    utils.RandomEmail(),
+		Password: utils.RandomString(10),
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodPut, "/api/v1/users/email", domain.EmailChange{
+		Email:    other.Email,
+		Password: user.Password,
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	require.Contains(t, recorder.Body.String(), "email_already_exists")
+
+	email := utils.RandomEmail()
+	recorder = doJSON(t, router, http.MethodPut, "/api/v1/users/email", domain.EmailChange{
+		Email:    email,
+		Password: user.Password,
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	token := verificationToken(t, mailer, email)
+
+	// The user keeps the current email until the new one is verified.
+	signIn(t, router, user, "Laptop")
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/verify-email", domain.EmailVerification{Token: token}, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in", domain.UserSignInRequest{
+		Email:    user.Email,
+		Password: user.Password,
+	}, "")
+	require.NotEqual(t, http.StatusOK, recorder.Code)
+
+	user.Email = email
+	tokens = signIn(t, router, user, "Laptop")
+
+	// The new email is verified, todos can be created.
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/todo-list/todo", domain.TodoRequest{
+		Title:    utils.RandomString(10),
+		ActiveAt: time.Now().AddDate(0, 0, 1).Format(domain.Format),
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestServer_profile(t *testing.T) {
+	router := newMemoryRouter(t)
+	user := signUp(t, router)
+	tokens := signIn(t, router, user, "Laptop")
+
+	recorder := doJSON(t, router, http.MethodGet, "/api/v1/users/me", nil, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var profile domain.User
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &profile))
+	require.Equal(t, user.UserName, profile.UserName)
+	require.Equal(t, user.Email, profile.Email)
+	require.Equal(t, domain.DefaultTimezone, profile.Timezone)
+
+	userName, timezone := utils.RandomString(10), "Asia/Almaty"
+	recorder = doJSON(t, router, http.MethodPatch, "/api/v1/users/me", domain.UserUpdate{
+		UserName: &userName,
+		Timezone: &timezone,
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodGet, "/api/v1/users/me", nil, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &profile))
+	require.Equal(t, userName, profile.UserName)
+	require.Equal(t, timezone, profile.Timezone)
+	require.Equal(t, user.Email, profile.Email)
+
+	recorder = doJSON(t, router, http.MethodPatch, "/api/v1/users/me", domain.UserUpdate{Timezone: &userName}, tokens.AccessToken)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	empty := ""
+	recorder = doJSON(t, router, http.MethodPatch, "/api/v1/users/me", domain.UserUpdate{UserName: &empty}, tokens.AccessToken)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	require.Contains(t, recorder.Body.String(), "incorrect_username")
+}
+
+func TestServer_changePassword(t *testing.T) {
+	router := newMemoryRouter(t)
+	user := signUp(t, router)
+	other := signIn(t, router, user, "Phone")
+	tokens := signIn(t, router, user, "Laptop")
+
+	recorder := doJSON(t, router, http.MethodPut, "/api/v1/users/password", domain.PasswordChange{
+		Password:    utils.RandomString(10),
+		NewPassword: utils.RandomString(10),
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	password := utils.RandomString(10)
+	recorder = doJSON(t, router, http.MethodPut, "/api/v1/users/password", domain.PasswordChange{
+		Password:    user.Password,
+		NewPassword: password,
+		Device:      "Laptop",
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rotated domain.Token
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rotated))
+	require.NotEmpty(t, rotated.AccessToken)
+
+	// Every session ends, the response starts a new one.
+	for _, token := range []string{tokens.AccessToken, other.AccessToken} {
+		recorder = doJSON(t, router, http.MethodGet, "/api/v1/users/me", nil, token)
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	}
+
+	recorder = doJSON(t, router, http.MethodGet, "/api/v1/users/sessions", nil, rotated.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var sessions []domain.Session
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &sessions))
+	require.Len(t, sessions, 1)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in", domain.UserSignInRequest{
+		Email:    user.Email,
+		Password: user.Password,
+	}, "")
+	require.NotEqual(t, http.StatusOK, recorder.Code)
+
+	user.Password = password
+	signIn(t, router, user, "Laptop")
+}
+
+func TestServer_deleteAccount(t *testing.T) {
+	router := newMemoryRouter(t)
+	user := signUp(t, router)
+	tokens := signIn(t, router, user, "Laptop")
+
+	recorder := doJSON(t, router, http.MethodDelete, "/api/v1/users/me", domain.AccountDeletion{
+		Password: utils.RandomString(10),
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodDelete, "/api/v1/users/me", domain.AccountDeletion{
+		Password: user.Password,
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var deleted domain.User
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &deleted))
+	require.NotNil(t, deleted.DeleteAt)
+	require.WithinDuration(t, time.Now().Add(time.Hour), *deleted.DeleteAt, time.Minute)
+
+	recorder = doJSON(t, router, http.MethodGet, "/api/v1/users/me", nil, tokens.AccessToken)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	// Signing in within the grace period keeps the account.
+	tokens = signIn(t, router, user, "Laptop")
+
+	recorder = doJSON(t, router, http.MethodGet, "/api/v1/users/me", nil, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var profile domain.User
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &profile))
+	require.Nil(t, profile.DeleteAt)
+}
+
+func TestServer_signInLockout(t *testing.T) {
+	router := newTestRouter(t, service.MFAPolicy{}, &outbox{}, unverifiedTodos, service.SignInPolicy{
+		FreeAttempts:    5,
+		LockoutAttempts: 3,
+		LockoutDuration: time.Minute,
+	})
+	user := signUp(t, router)
+	other := signUp(t, router)
+
+	wrong := domain.UserSignInRequest{Email: user.Email, Password: utils.RandomString(10)}
+	for i := 0; i < 2; i++ {
+		recorder := doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in", wrong, "")
+		require.NotEqual(t, http.StatusOK, recorder.Code)
+		require.NotEqual(t, http.StatusTooManyRequests, recorder.Code)
+	}
+
+	recorder := doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in", wrong, "")
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Equal(t, "60", recorder.Header().Get("Retry-After"))
+	require.Contains(t, recorder.Body.String(), "too_many_attempts")
+
+	// The locked account may not sign in even with the right password.
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in", domain.UserSignInRequest{
+		Email:    user.Email,
+		Password: user.Password,
+	}, "")
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.NotEmpty(t, recorder.Header().Get("Retry-After"))
+
+	// Other accounts are not affected.
+	signIn(t, router, other, "Laptop")
+}
+
+func TestServer_signInLockoutMFA(t *testing.T) {
+	router := newTestRouter(t, service.MFAPolicy{}, &outbox{}, unverifiedTodos, service.SignInPolicy{
+		FreeAttempts:    5,
+		LockoutAttempts: 3,
+		LockoutDuration: time.Minute,
+	})
+	user := signUp(t, router)
+	tokens := signIn(t, router, user, "Laptop")
+
+	recorder := doJSON(t, router, http.MethodPost, "/api/v1/users/mfa", nil, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var enrollment domain.MFAEnrollment
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &enrollment))
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/mfa/confirm", domain.MFACode{
+		Code: totpCode(t, enrollment.Secret, time.Now()),
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in", domain.UserSignInRequest{
+		Email:    user.Email,
+		Password: utils.RandomString(10),
+	}, "")
+	require.NotEqual(t, http.StatusOK, recorder.Code)
+
+	// The right password alone does not forget the failed sign-in, and the
+	// wrong codes are counted with it.
+	challenge := signInMFA(t, router, user)
+	wrong := domain.MFASignInRequest{MFAToken: challenge.MFAToken, Code: "wrong-code"}
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in/mfa", wrong, "")
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in/mfa", wrong, "")
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Equal(t, "60", recorder.Header().Get("Retry-After"))
+
+	// The MFA token may not be used while the account is locked.
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in/mfa", domain.MFASignInRequest{
+		MFAToken: challenge.MFAToken,
+		Code:     totpCode(t, enrollment.Secret, time.Now().Add(30*time.Second)),
+	}, "")
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+}
+
+func TestServer_signInLockoutIP(t *testing.T) {
+	router := newTestRouter(t, service.MFAPolicy{}, &outbox{}, unverifiedTodos, service.SignInPolicy{
+		FreeAttempts:      5,
+		IPLockoutAttempts: 3,
+		LockoutDuration:   time.Minute,
+	})
+	user := signUp(t, router)
+
+	// A spoofed X-Forwarded-For does not make the requests come from other
+	// addresses.
+	signInFrom := func(email, password string, i int) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		require.NoError(t, json.NewEncoder(&buf).Encode(domain.UserSignInRequest{Email: email, Password: password}))
+
+		request := httptest.NewRequest(http.MethodPost, "/api/v1/users/sign-in", &buf)
+		request.RemoteAddr = "203.0.113.7:4321"
+		request.Header.Set("X-Forwarded-For", fmt.Sprintf("198.51.100.%d", i))
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		return recorder
+	}
+
+	for i := 0; i < 3; i++ {
+		recorder := signInFrom(utils.RandomEmail(), utils.RandomString(10), i)
+		require.NotEqual(t, http.StatusTooManyRequests, recorder.Code)
+	}
+
+	recorder := signInFrom(user.Email, user.Password, 3)
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.NotEmpty(t, recorder.Header().Get("Retry-After"))
+}
+
+func TestServer_passwordConfirmLockout(t *testing.T) {
+	router := newTestRouter(t, service.MFAPolicy{}, &outbox{}, unverifiedTodos, service.SignInPolicy{
+		FreeAttempts:    5,
+		LockoutAttempts: 3,
+		LockoutDuration: time.Minute,
+	})
+	user := signUp(t, router)
+	tokens := signIn(t, router, user, "Laptop")
+
+	// The current password can not be guessed with the access token faster
+	// than by signing in.
+	recorder := doJSON(t, router, http.MethodPut, "/api/v1/users/password", domain.PasswordChange{
+		Password:    utils.RandomString(10),
+		NewPassword: utils.RandomString(10),
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodDelete, "/api/v1/users/me", domain.AccountDeletion{
+		Password: utils.RandomString(10),
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodPut, "/api/v1/users/password", domain.PasswordChange{
+		Password:    utils.RandomString(10),
+		NewPassword: utils.RandomString(10),
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Equal(t, "60", recorder.Header().Get("Retry-After"))
+
+	recorder = doJSON(t, router, http.MethodDelete, "/api/v1/users/me", domain.AccountDeletion{
+		Password: user.Password,
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in", domain.UserSignInRequest{
+		Email:    user.Email,
+		Password: user.Password,
+	}, "")
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+}
+
+func TestServer_mfaManageAttempts(t *testing.T) {
+	router := newMemoryRouter(t)
+	user := signUp(t, router)
+	tokens := signIn(t, router, user, "Laptop")
+
+	recorder := doJSON(t, router, http.MethodPost, "/api/v1/users/mfa", nil, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var enrollment domain.MFAEnrollment
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &enrollment))
+
+	// Wrong codes with the access token are limited as in the sign-in.
+	for i := 0; i < 5; i++ {
+		recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/mfa/confirm", domain.MFACode{Code: "000000"}, tokens.AccessToken)
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	}
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/mfa/confirm", domain.MFACode{
+		Code: totpCode(t, enrollment.Secret, time.Now()),
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	for i := 0; i < 9; i++ {
+		recorder = doJSON(t, router, http.MethodDelete, "/api/v1/users/mfa", domain.MFACode{Code: "000000"}, tokens.AccessToken)
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	}
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/mfa/recovery-codes", domain.MFACode{Code: "000000"}, tokens.AccessToken)
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.NotEmpty(t, recorder.Header().Get("Retry-After"))
+
+	// The right code does not help while the user is locked out, nor does
+	// signing in.
+	recorder = doJSON(t, router, http.MethodDelete, "/api/v1/users/mfa", domain.MFACode{
+		Code: totpCode(t, enrollment.Secret, time.Now().Add(30*time.Second)),
+	}, tokens.AccessToken)
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+
+	recorder = doJSON(t, router, http.MethodPost, "/api/v1/users/sign-in", domain.UserSignInRequest{
+		Email:    user.Email,
+		Password: user.Password,
+	}, "")
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+}
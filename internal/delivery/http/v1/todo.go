@@ -6,7 +6,6 @@ import (
 
 	"github.com/begenov/region-llc-task/internal/domain"
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // @Summary		User Create New Todo List
@@ -18,35 +17,64 @@ import (
 // @Param			account	body		domain.TodoRequest	true	"Todo-List"
 // @Success		200		{object}	domain.Todo
 // @Failure		400		{object}	Response
+// @Failure		403		{object}	Response
 // @Failure		500		{object}	Response
 // @Router			/users/todo-list/todo [post]
 func (s *Server) createTodo(ctx *gin.Context) {
 	var inp domain.TodoRequest
 	if err := ctx.BindJSON(&inp); err != nil {
-		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest.Error(), fmt.Sprintf("ctx.BindJSON(): %v", err))
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindJSON(): %v", err))
 		return
 	}
 
 	id, err := getUserID(ctx, userCtx)
 	if err != nil {
-		newResponse(ctx, checkErrors(err), err.Error(), fmt.Sprintf("getUserID(): %v", err))
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
 		return
 	}
 
-	todo := domain.Todo{
-		UserID:   id,
-		Title:    inp.Title,
-		ActiveAt: inp.ActiveAt,
-		Status:   domain.Active,
+	todo, err := s.todoService.CreateTodo(ctx, id, inp)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.todoService.CreateTodo(): %v", err))
+		return
 	}
 
-	todo, err = s.todoService.CreateTodo(ctx, todo)
+	ctx.JSON(http.StatusOK, localizeTodo(ctx, todo))
+}
+
+// @Summary		User Get Todo
+// @Security UserAuth
+// @Tags			Todo List
+// @Description	User Get Todo By ID
+// @Accept			json
+// @Produce		json
+// @Param			domain.TodoURI path string		true	"Todo List id"
+// @Success		200		{object}	domain.Todo
+// @Failure		400		{object}	Response
+// @Failure		403		{object}	Response
+// @Failure		404		{object}	Response
+// @Failure		500		{object}	Response
+// @Router			/users/todo-list/todo/{id} [get]
+func (s *Server) getTodo(ctx *gin.Context) {
+	var uri domain.TodoURI
+	if err := ctx.BindUri(&uri); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindUri(): %v", err))
+		return
+	}
+
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	todo, err := s.todoService.GetTodoByID(ctx, uri.ID, id)
 	if err != nil {
-		newResponse(ctx, checkErrors(err), err.Error(), fmt.Sprintf("s.todoService.CreateTodo(): %v", err))
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.todoService.GetTodoByID(): %v", err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, todo)
+	ctx.JSON(http.StatusOK, localizeTodo(ctx, todo))
 }
 
 // @Summary		User Update Todo List
@@ -59,49 +87,36 @@ func (s *Server) createTodo(ctx *gin.Context) {
 // @Param			domain.TodoURI path string		true	"Todo List id"
 // @Success		200		{object}	domain.Todo
 // @Failure		400		{object}	Response
+// @Failure		403		{object}	Response
 // @Failure		404		{object}	Response
 // @Failure		500		{object}	Response
 // @Router			/users/todo-list/{id} [put]
 func (s *Server) updateTodo(ctx *gin.Context) {
 	var uri domain.TodoURI
 	if err := ctx.BindUri(&uri); err != nil {
-		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest.Error(), fmt.Sprintf("ctx.BindUri(): %v", err))
-		return
-	}
-
-	todoID, err := primitive.ObjectIDFromHex(uri.ID)
-	if err != nil {
-		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest.Error(), fmt.Sprintf("ctx.BindUri(): %v", err))
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindUri(): %v", err))
 		return
 	}
 
 	var inp domain.TodoRequest
 	if err := ctx.BindJSON(&inp); err != nil {
-		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest.Error(), fmt.Sprintf("ctx.BindJSON(): %v", err))
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindJSON(): %v", err))
 		return
 	}
 
 	id, err := getUserID(ctx, userCtx)
 	if err != nil {
-		newResponse(ctx, checkErrors(err), err.Error(), fmt.Sprintf("getUserID(): %v", err))
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
 		return
 	}
 
-	todo := domain.Todo{
-		ID:       todoID,
-		UserID:   id,
-		Title:    inp.Title,
-		ActiveAt: inp.ActiveAt,
-		Status:   domain.Active,
-	}
-
-	todo, err = s.todoService.UpdateTodo(ctx, todo)
+	todo, err := s.todoService.UpdateTodo(ctx, uri.ID, id, inp)
 	if err != nil {
-		newResponse(ctx, checkErrors(err), err.Error(), fmt.Sprintf("s.todoService.UpdateTodo(): %v", err))
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.todoService.UpdateTodo(): %v", err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, todo)
+	ctx.JSON(http.StatusOK, localizeTodo(ctx, todo))
 }
 
 // @Summary		User Delete Todo List
@@ -113,85 +128,155 @@ func (s *Server) updateTodo(ctx *gin.Context) {
 // @Param			domain.TodoURI path string		true	"Todo List ID"
 // @Success		200		{object}	Response
 // @Failure		400		{object}	Response
+// @Failure		403		{object}	Response
 // @Failure		404		{object}	Response
 // @Failure		500		{object}	Response
 // @Router			/users/todo-list/{id} [delete]
 func (s *Server) deleteTodo(ctx *gin.Context) {
 	var uri domain.TodoURI
 	if err := ctx.BindUri(&uri); err != nil {
-		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest.Error(), fmt.Sprintf("ctx.BindUri(): %v", err))
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindUri(): %v", err))
 		return
 	}
 
-	err := s.todoService.DeleteTodoByID(ctx, uri.ID)
+	id, err := getUserID(ctx, userCtx)
 	if err != nil {
-		newResponse(ctx, checkErrors(err), err.Error(), fmt.Sprintf("s.todoService.DeleteTodoByID(): %v", err))
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, Response{"Success Deleting Todo"})
+	err = s.todoService.DeleteTodoByID(ctx, uri.ID, id)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.todoService.DeleteTodoByID(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newMessage(ctx, "todo_deleted"))
 }
 
 // @Summary		User Update Todo List
 // @Security UserAuth
 // @Tags			Todo List
 // @Description	User Update Todo List
+// @Description	Completing an occurrence of a recurring todo creates the next one, titled with its date when the series title is taken.
 // @Accept			json
 // @Produce		json
 // @Param			domain.TodoURI path string		true	"Todo List id"
 // @Success		200		{object}	domain.Todo
 // @Failure		400		{object}	Response
+// @Failure		403		{object}	Response
 // @Failure		404		{object}	Response
 // @Failure		500		{object}	Response
 // @Router			/users/todo-list/{id}/done [put]
 func (s *Server) doneTodo(ctx *gin.Context) {
 	var uri domain.TodoURI
 	if err := ctx.BindUri(&uri); err != nil {
-		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest.Error(), fmt.Sprintf("ctx.BindUri(): %v", err))
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindUri(): %v", err))
 		return
 	}
 
 	id, err := getUserID(ctx, userCtx)
 	if err != nil {
-		newResponse(ctx, checkErrors(err), err.Error(), fmt.Sprintf("getUserID(): %v", err))
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
 		return
 	}
 
 	todo, err := s.todoService.UpdateTodoDoneByID(ctx, uri.ID, id)
 	if err != nil {
-		newResponse(ctx, checkErrors(err), err.Error(), fmt.Sprintf("s.todoService.UpdateTodoDoneByID(): %v", err))
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.todoService.UpdateTodoDoneByID(): %v", err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, todo)
+	ctx.JSON(http.StatusOK, localizeTodo(ctx, todo))
 }
 
-// @Summary		User Get Todo List By Status
+// @Summary		User Get Todo List
 // @Security UserAuth
 // @Tags			Todo List
-// @Description	User Get Todo List By Status
+// @Description	User Get Todo List filtered by status, activeAt range, title, tags and priority, one page at a time.
+// @Description	Listing only active todos leaves out the ones whose date has not come yet.
 // @Accept			json
 // @Produce		json
-// @Param	status	query	string	false	"Task status (default: active)"
-// @Success		200		{object}	[]domain.Todo
+// @Param	status		query	[]string	false	"Task statuses, repeated or comma separated (default: active)"	collectionFormat(csv)
+// @Param	active_from	query	string		false	"Earliest activeAt date in the user's timezone, inclusive (YYYY-MM-DD)"
+// @Param	active_to	query	string		false	"Latest activeAt date in the user's timezone, inclusive (YYYY-MM-DD)"
+// @Param	title		query	string		false	"Case-insensitive title substring"
+// @Param	tag			query	[]string	false	"Tags the todo must all have, repeated or comma separated"	collectionFormat(csv)
+// @Param	priority	query	[]string	false	"Priorities, repeated or comma separated: low, medium, high"	collectionFormat(csv)
+// @Param	sort		query	string		false	"Sort field: activeAt (default) or title"
+// @Param	order		query	string		false	"Sort order: asc (default) or desc"
+// @Param	limit		query	int			false	"Page size, 20 by default, at most 100"
+// @Param	cursor		query	string		false	"next_cursor of the previous page"
+// @Success		200		{object}	domain.TodoPage
 // @Failure		400		{object}	Response
+// @Failure		403		{object}	Response
 // @Failure		404		{object}	Response
 // @Failure		500		{object}	Response
-// @Router			/users/todo-list/todo{id} [get]
+// @Router			/users/todo-list/todo [get]
 func (s *Server) getTodos(ctx *gin.Context) {
-	status := ctx.DefaultQuery("status", "active")
+	var inp domain.TodoListRequest
+	if err := ctx.BindQuery(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindQuery(): %v", err))
+		return
+	}
+
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, http.StatusBadRequest, err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	page, err := s.todoService.GetTodos(ctx, id, inp)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.todoService.GetTodos(): %v", err))
+		return
+	}
+
+	for i := range page.Items {
+		page.Items[i] = localizeTodo(ctx, page.Items[i])
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+// @Summary		User Search Todo List
+// @Security UserAuth
+// @Tags			Todo List
+// @Description	Full-text search over title and description, best matches first.
+// @Description	All words are required, "quoted phrases" must appear as is, -word and -"phrase" exclude todos.
+// @Description	Matched fragments are wrapped in <mark> tags in highlights, the rest of the text is HTML-escaped.
+// @Accept			json
+// @Produce		json
+// @Param	q		query	string	true	"Search query"
+// @Param	limit	query	int		false	"Maximum number of results, 20 by default, at most 100"
+// @Success		200		{object}	[]domain.SearchResult
+// @Failure		400		{object}	Response
+// @Failure		403		{object}	Response
+// @Failure		404		{object}	Response
+// @Failure		500		{object}	Response
+// @Router			/users/todo-list/search [get]
+func (s *Server) searchTodos(ctx *gin.Context) {
+	var inp domain.SearchRequest
+	if err := ctx.BindQuery(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindQuery(): %v", err))
+		return
+	}
 
 	id, err := getUserID(ctx, userCtx)
 	if err != nil {
-		newResponse(ctx, http.StatusBadRequest, err.Error(), fmt.Sprintf("getUserID(): %v", err))
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
 		return
 	}
 
-	tasks, err := s.todoService.GetTodosByStatus(ctx, status, id)
+	results, err := s.todoService.SearchTodos(ctx, id, inp)
 	if err != nil {
-		newResponse(ctx, checkErrors(err), err.Error(), fmt.Sprintf("s.todoService.GetTodosByStatus(): %v", err))
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.todoService.SearchTodos(): %v", err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, tasks)
+	for i := range results {
+		results[i].Todo = localizeTodo(ctx, results[i].Todo)
+	}
+
+	ctx.JSON(http.StatusOK, results)
 }
@@ -0,0 +1,199 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary		User Get Todo Items
+// @Security UserAuth
+// @Tags			Todo Items
+// @Description	Checklist of the todo in its order
+// @Accept			json
+// @Produce		json
+// @Param			id	path		string	true	"Todo List id"
+// @Success		200	{object}	[]domain.TodoItem
+// @Failure		400	{object}	Response
+// @Failure		403	{object}	Response
+// @Failure		404	{object}	Response
+// @Failure		500	{object}	Response
+// @Router			/users/todo-list/todo/{id}/items [get]
+func (s *Server) getTodoItems(ctx *gin.Context) {
+	var uri domain.TodoURI
+	if err := ctx.BindUri(&uri); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindUri(): %v", err))
+		return
+	}
+
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	items, err := s.todoService.GetTodoItems(ctx, uri.ID, id)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.todoService.GetTodoItems(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, items)
+}
+
+// @Summary		User Create Todo Item
+// @Security UserAuth
+// @Tags			Todo Items
+// @Description	Adds an item to the end of the checklist, at most 100 items per todo
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string					true	"Todo List id"
+// @Param			item	body		domain.TodoItemRequest	true	"Todo Item"
+// @Success		200		{object}	domain.TodoItem
+// @Failure		400		{object}	Response
+// @Failure		403		{object}	Response
+// @Failure		404		{object}	Response
+// @Failure		500		{object}	Response
+// @Router			/users/todo-list/todo/{id}/items [post]
+func (s *Server) createTodoItem(ctx *gin.Context) {
+	var uri domain.TodoURI
+	if err := ctx.BindUri(&uri); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindUri(): %v", err))
+		return
+	}
+
+	var inp domain.TodoItemRequest
+	if err := ctx.BindJSON(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindJSON(): %v", err))
+		return
+	}
+
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	item, err := s.todoService.CreateTodoItem(ctx, uri.ID, id, inp)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.todoService.CreateTodoItem(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, item)
+}
+
+// @Summary		User Reorder Todo Items
+// @Security UserAuth
+// @Tags			Todo Items
+// @Description	Puts the items in the given order, ids must list every item of the todo once
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string					true	"Todo List id"
+// @Param			order	body		domain.TodoItemsOrder	true	"Item ids in the new order"
+// @Success		200		{object}	[]domain.TodoItem
+// @Failure		400		{object}	Response
+// @Failure		403		{object}	Response
+// @Failure		404		{object}	Response
+// @Failure		500		{object}	Response
+// @Router			/users/todo-list/todo/{id}/items [put]
+func (s *Server) reorderTodoItems(ctx *gin.Context) {
+	var uri domain.TodoURI
+	if err := ctx.BindUri(&uri); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindUri(): %v", err))
+		return
+	}
+
+	var inp domain.TodoItemsOrder
+	if err := ctx.BindJSON(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindJSON(): %v", err))
+		return
+	}
+
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	items, err := s.todoService.ReorderTodoItems(ctx, uri.ID, id, inp.IDs)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.todoService.ReorderTodoItems(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, items)
+}
+
+// @Summary		User Toggle Todo Item
+// @Security UserAuth
+// @Tags			Todo Items
+// @Description	Checks or unchecks the item. Checking the last item marks the todo done when auto-completion is on.
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string	true	"Todo List id"
+// @Param			item_id	path		string	true	"Todo Item id"
+// @Success		200		{object}	domain.TodoItem
+// @Failure		400		{object}	Response
+// @Failure		403		{object}	Response
+// @Failure		404		{object}	Response
+// @Failure		500		{object}	Response
+// @Router			/users/todo-list/todo/{id}/items/{item_id}/toggle [put]
+func (s *Server) toggleTodoItem(ctx *gin.Context) {
+	var uri domain.TodoItemURI
+	if err := ctx.BindUri(&uri); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindUri(): %v", err))
+		return
+	}
+
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	item, err := s.todoService.ToggleTodoItem(ctx, uri.ID, uri.ItemID, id)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.todoService.ToggleTodoItem(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, item)
+}
+
+// @Summary		User Delete Todo Item
+// @Security UserAuth
+// @Tags			Todo Items
+// @Description	Removes the item from the checklist
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string	true	"Todo List id"
+// @Param			item_id	path		string	true	"Todo Item id"
+// @Success		200		{object}	Response
+// @Failure		400		{object}	Response
+// @Failure		403		{object}	Response
+// @Failure		404		{object}	Response
+// @Failure		500		{object}	Response
+// @Router			/users/todo-list/todo/{id}/items/{item_id} [delete]
+func (s *Server) deleteTodoItem(ctx *gin.Context) {
+	var uri domain.TodoItemURI
+	if err := ctx.BindUri(&uri); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindUri(): %v", err))
+		return
+	}
+
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	if err := s.todoService.DeleteTodoItem(ctx, uri.ID, uri.ItemID, id); err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.todoService.DeleteTodoItem(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newMessage(ctx, "todo_item_deleted"))
+}
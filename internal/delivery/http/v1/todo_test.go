@@ -10,18 +10,30 @@ import (
 	"time"
 
 	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/internal/repository/memory"
 	repoMocks "github.com/begenov/region-llc-task/internal/repository/mocks"
 	"github.com/begenov/region-llc-task/internal/service"
+	serviceMocks "github.com/begenov/region-llc-task/internal/service/mocks"
 	"github.com/begenov/region-llc-task/pkg/auth"
 	"github.com/begenov/region-llc-task/pkg/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// newUserServiceStub serves the routes that only need the language of the
+// user, which is left to the Accept-Language header, and accepts every access
+// token.
+func newUserServiceStub(ctrl *gomock.Controller) *serviceMocks.MockUsers {
+	userService := serviceMocks.NewMockUsers(ctrl)
+	userService.EXPECT().CheckAccessToken(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+	userService.EXPECT().GetLanguage(gomock.Any(), gomock.Any()).AnyTimes().Return("", nil)
+
+	return userService
+}
+
 func addAuthorization(t *testing.T, request *http.Request, token auth.TokenManager, authorizationType string, username string, duration time.Duration) {
-	accessToken, err := token.NewJWT(username, duration)
+	accessToken, err := token.NewJWT(auth.Claims{Subject: username, Scopes: domain.UserScopes}, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, accessToken)
 
@@ -54,22 +66,23 @@ func TestServer_createTodo(t *testing.T) {
 				var count int64 = 0
 				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(count, nil)
 				userRepo.EXPECT().GetUserByID(gomock.Any(), gomock.Any()).Times(1).Return(domain.User{
-					ID:       primitive.NewObjectID(),
-					UserName: utils.RandomString(10),
-					Email:    utils.RandomString(10),
-					Password: utils.RandomString(10),
+					ID:            utils.RandomString(24),
+					UserName:      utils.RandomString(10),
+					Email:         utils.RandomString(10),
+					EmailVerified: true,
+					Password:      utils.RandomString(10),
 				}, nil)
 
 				todoRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).Return(domain.Todo{
-					ID:       primitive.NewObjectID(),
-					UserID:   primitive.NewObjectID(),
+					ID:       utils.RandomString(24),
+					UserID:   utils.RandomString(24),
 					Title:    inp.Title,
 					Author:   utils.RandomString(10),
-					ActiveAt: time.Now().Add(time.Hour * 24).Format(domain.Format),
+					ActiveAt: time.Now().Add(time.Hour * 24).UTC(),
 					Status:   domain.Active,
 				}, nil)
 			},
-			userID: primitive.NewObjectID().Hex(),
+			userID: utils.RandomString(24),
 			checkResponse: func(recoder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recoder.Code)
 			},
@@ -80,12 +93,13 @@ func TestServer_createTodo(t *testing.T) {
 				Title:    "",
 				ActiveAt: time.Now().Add(time.Hour * 24).Format(domain.Format),
 			},
-			userID: primitive.NewObjectID().Hex(),
+			userID: utils.RandomString(24),
 			setupAuth: func(request *http.Request, id string, token auth.TokenManager) {
 				addAuthorization(t, request, token, "Bearer", id, time.Minute)
 			},
 			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, inp domain.TodoRequest) {
 				// var count int64 = 0
+				userRepo.EXPECT().GetUserByID(gomock.Any(), gomock.Any()).Times(1).Return(domain.User{EmailVerified: true}, nil)
 				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 
 				todoRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
@@ -94,13 +108,31 @@ func TestServer_createTodo(t *testing.T) {
 				require.Equal(t, http.StatusBadRequest, recoder.Code)
 			},
 		},
+		{
+			name: "email not verified",
+			inp: domain.TodoRequest{
+				Title:    utils.RandomString(10),
+				ActiveAt: time.Now().Add(time.Hour * 24).Format(domain.Format),
+			},
+			userID: utils.RandomString(24),
+			setupAuth: func(request *http.Request, id string, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", id, time.Minute)
+			},
+			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, inp domain.TodoRequest) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), gomock.Any()).Times(1).Return(domain.User{}, nil)
+				todoRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recoder.Code)
+			},
+		},
 		{
 			name: "auth error",
 			inp: domain.TodoRequest{
 				Title:    "",
 				ActiveAt: time.Now().Add(time.Hour * 24).Format(domain.Format),
 			},
-			userID: utils.RandomString(10),
+			userID: "",
 			setupAuth: func(request *http.Request, id string, token auth.TokenManager) {
 				addAuthorization(t, request, token, "Bearer", id, time.Minute)
 			},
@@ -111,7 +143,7 @@ func TestServer_createTodo(t *testing.T) {
 				todoRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(recoder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusInternalServerError, recoder.Code)
+				require.Equal(t, http.StatusUnauthorized, recoder.Code)
 			},
 		},
 	}
@@ -122,13 +154,14 @@ func TestServer_createTodo(t *testing.T) {
 
 			todoRepo := repoMocks.NewMockTodo(ctrl)
 			userRepo := repoMocks.NewMockUsers(ctrl)
-			todoService := service.NewTodoService(todoRepo, userRepo)
+			todoService := service.NewTodoService(todoRepo, memory.NewTodoItemRepo(), userRepo, newCalendarService(t, userRepo), true, 0)
 			recorder := httptest.NewRecorder()
 			router := gin.Default()
 			api := router.Group("/api")
 			token, err := auth.NewManager("qwerty")
 			require.NoError(t, err)
 			handler := &Server{
+				userService:  newUserServiceStub(ctrl),
 				todoService:  todoService,
 				tokenManager: token,
 			}
@@ -155,10 +188,10 @@ func TestServer_updateTodo(t *testing.T) {
 		name          string
 		inp           domain.TodoRequest
 		uri           domain.TodoURI
-		userID        primitive.ObjectID
-		todoID        primitive.ObjectID
+		userID        string
+		todoID        string
 		setupAuth     func(request *http.Request, id string, token auth.TokenManager)
-		buildStubs    func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, inp domain.TodoRequest, id primitive.ObjectID, userID primitive.ObjectID)
+		buildStubs    func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, inp domain.TodoRequest, id string, userID string)
 		checkResponse func(recoder *httptest.ResponseRecorder)
 	}{
 		// TODO: Add test cases.
@@ -168,19 +201,24 @@ func TestServer_updateTodo(t *testing.T) {
 				Title:    utils.RandomString(10),
 				ActiveAt: time.Now().Add(time.Hour * 24).Format(domain.Format),
 			},
-			uri: domain.TodoURI{},
+			uri:    domain.TodoURI{},
+			todoID: utils.RandomString(24),
+			userID: utils.RandomString(24),
 			setupAuth: func(request *http.Request, id string, token auth.TokenManager) {
 				addAuthorization(t, request, token, "Bearer", id, time.Minute)
 			},
-			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, inp domain.TodoRequest, id primitive.ObjectID, userID primitive.ObjectID) {
-				var count int64 = 0
-				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(count, nil)
+			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, inp domain.TodoRequest, id string, userID string) {
+				activeAt, err := time.Parse(domain.Format, inp.ActiveAt)
+				require.NoError(t, err)
+
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 				todoRepo.EXPECT().UpdateTodo(gomock.Any(), gomock.Any()).Times(1).Return(nil)
-				todoRepo.EXPECT().GetTodoByID(gomock.Any(), gomock.Any()).Times(1).Return(domain.Todo{
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), id).Times(2).Return(domain.Todo{
 					ID:       id,
 					UserID:   userID,
 					Title:    inp.Title,
-					ActiveAt: inp.ActiveAt,
+					ActiveAt: activeAt,
 					Author:   utils.RandomString(10),
 					Status:   domain.Active,
 				}, nil)
@@ -196,12 +234,15 @@ func TestServer_updateTodo(t *testing.T) {
 				Title:    "",
 				ActiveAt: time.Now().Add(time.Hour * 24).Format(domain.Format),
 			},
-			uri: domain.TodoURI{},
+			uri:    domain.TodoURI{},
+			todoID: utils.RandomString(24),
+			userID: utils.RandomString(24),
 			setupAuth: func(request *http.Request, id string, token auth.TokenManager) {
 				addAuthorization(t, request, token, "Bearer", id, time.Minute)
 			},
-			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, inp domain.TodoRequest, id primitive.ObjectID, userID primitive.ObjectID) {
+			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, inp domain.TodoRequest, id string, userID string) {
 				// var count int64 = 0
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
 				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 				todoRepo.EXPECT().UpdateTodo(gomock.Any(), gomock.Any()).Times(0)
 				todoRepo.EXPECT().GetTodoByID(gomock.Any(), gomock.Any()).Times(0)
@@ -211,6 +252,34 @@ func TestServer_updateTodo(t *testing.T) {
 				require.Equal(t, http.StatusBadRequest, recoder.Code)
 			},
 		},
+		{
+			name: "forbidden",
+			inp: domain.TodoRequest{
+				Title:    utils.RandomString(10),
+				ActiveAt: time.Now().Add(time.Hour * 24).Format(domain.Format),
+			},
+			uri:    domain.TodoURI{},
+			todoID: utils.RandomString(24),
+			userID: utils.RandomString(24),
+			setupAuth: func(request *http.Request, id string, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", id, time.Minute)
+			},
+			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, inp domain.TodoRequest, id string, userID string) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), id).Times(1).Return(domain.Todo{
+					ID:     id,
+					UserID: utils.RandomString(24),
+					Title:  utils.RandomString(10),
+					Status: domain.Active,
+				}, nil)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().UpdateTodo(gomock.Any(), gomock.Any()).Times(0)
+			},
+
+			checkResponse: func(recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recoder.Code)
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -219,13 +288,14 @@ func TestServer_updateTodo(t *testing.T) {
 
 			todoRepo := repoMocks.NewMockTodo(ctrl)
 			userRepo := repoMocks.NewMockUsers(ctrl)
-			todoService := service.NewTodoService(todoRepo, userRepo)
+			todoService := service.NewTodoService(todoRepo, memory.NewTodoItemRepo(), userRepo, newCalendarService(t, userRepo), true, 0)
 			recorder := httptest.NewRecorder()
 			router := gin.Default()
 			api := router.Group("/api")
 			token, err := auth.NewManager("qwerty")
 			require.NoError(t, err)
 			handler := &Server{
+				userService:  newUserServiceStub(ctrl),
 				todoService:  todoService,
 				tokenManager: token,
 			}
@@ -234,11 +304,11 @@ func TestServer_updateTodo(t *testing.T) {
 			handler.Init(api)
 			server := httptest.NewServer(router)
 			defer server.Close()
-			url := fmt.Sprintf("%s/api/v1/users/todo-list/todo/%s", server.URL, tt.todoID.Hex())
+			url := fmt.Sprintf("%s/api/v1/users/todo-list/todo/%s", server.URL, tt.todoID)
 			body, err := json.Marshal(tt.inp)
 			require.NoError(t, err)
 			request, err := http.NewRequest(http.MethodPut, url, bytes.NewBuffer(body))
-			tt.setupAuth(request, tt.userID.Hex(), token)
+			tt.setupAuth(request, tt.userID, token)
 			require.NoError(t, err)
 			router.ServeHTTP(recorder, request)
 			tt.checkResponse(recorder)
@@ -253,21 +323,25 @@ func TestServer_deleteTodo(t *testing.T) {
 		uri           domain.TodoURI
 		userID        string
 		setupAuth     func(request *http.Request, id string, token auth.TokenManager)
-		buildStubs    func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo)
+		buildStubs    func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, todoID, userID string)
 		checkResponse func(recoder *httptest.ResponseRecorder)
 	}{
 		// TODO: Add test cases.
 		{
 			name: "status ok",
 			uri: domain.TodoURI{
-				ID: primitive.NewObjectID().Hex(),
+				ID: utils.RandomString(24),
 			},
-			userID: primitive.NewObjectID().Hex(),
+			userID: utils.RandomString(24),
 			setupAuth: func(request *http.Request, id string, token auth.TokenManager) {
 				addAuthorization(t, request, token, "Bearer", id, time.Minute)
 			},
-			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo) {
-				todoRepo.EXPECT().DeleteTodoByID(gomock.Any(), gomock.Any()).Return(nil)
+			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, todoID, userID string) {
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), todoID).Times(1).Return(domain.Todo{
+					ID:     todoID,
+					UserID: userID,
+				}, nil)
+				todoRepo.EXPECT().DeleteTodoByID(gomock.Any(), todoID, userID).Return(nil)
 			},
 			checkResponse: func(recoder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recoder.Code)
@@ -277,19 +351,40 @@ func TestServer_deleteTodo(t *testing.T) {
 		{
 			name: "todo invalid id",
 			uri: domain.TodoURI{
-				ID: "primitive.NewObjectID().Hex()",
+				ID: "utils.RandomString(24)",
 			},
-			userID: primitive.NewObjectID().Hex(),
+			userID: utils.RandomString(24),
 			setupAuth: func(request *http.Request, id string, token auth.TokenManager) {
 				addAuthorization(t, request, token, "Bearer", id, time.Minute)
 			},
-			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo) {
-				todoRepo.EXPECT().DeleteTodoByID(gomock.Any(), gomock.Any()).Times(0)
+			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, todoID, userID string) {
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), todoID).Times(1).Return(domain.Todo{}, domain.ErrTodoInvalidId)
+				todoRepo.EXPECT().DeleteTodoByID(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(recoder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recoder.Code)
 			},
 		},
+		{
+			name: "forbidden",
+			uri: domain.TodoURI{
+				ID: utils.RandomString(24),
+			},
+			userID: utils.RandomString(24),
+			setupAuth: func(request *http.Request, id string, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", id, time.Minute)
+			},
+			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, todoID, userID string) {
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), todoID).Times(1).Return(domain.Todo{
+					ID:     todoID,
+					UserID: utils.RandomString(24),
+				}, nil)
+				todoRepo.EXPECT().DeleteTodoByID(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recoder.Code)
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -298,17 +393,18 @@ func TestServer_deleteTodo(t *testing.T) {
 
 			todoRepo := repoMocks.NewMockTodo(ctrl)
 			userRepo := repoMocks.NewMockUsers(ctrl)
-			todoService := service.NewTodoService(todoRepo, userRepo)
+			todoService := service.NewTodoService(todoRepo, memory.NewTodoItemRepo(), userRepo, newCalendarService(t, userRepo), true, 0)
 			recorder := httptest.NewRecorder()
 			router := gin.Default()
 			api := router.Group("/api")
 			token, err := auth.NewManager("qwerty")
 			require.NoError(t, err)
 			handler := &Server{
+				userService:  newUserServiceStub(ctrl),
 				todoService:  todoService,
 				tokenManager: token,
 			}
-			tt.buildStubs(userRepo, todoRepo)
+			tt.buildStubs(userRepo, todoRepo, tt.uri.ID, tt.userID)
 
 			handler.Init(api)
 			server := httptest.NewServer(router)
@@ -332,51 +428,75 @@ func TestServer_doneTodo(t *testing.T) {
 		uri           domain.TodoURI
 		userID        string
 		setupAuth     func(request *http.Request, id string, token auth.TokenManager)
-		buildStubs    func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, todoID, userID primitive.ObjectID)
-		checkResponse func(recoder *httptest.ResponseRecorder, err1, err2 error)
+		buildStubs    func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, todoID, userID string)
+		checkResponse func(recoder *httptest.ResponseRecorder)
 	}{
 		// TODO: Add test cases.
 		{
 			name: "status ok",
 			uri: domain.TodoURI{
-				ID: primitive.NewObjectID().Hex(),
+				ID: utils.RandomString(24),
 			},
-			userID: primitive.NewObjectID().Hex(),
+			userID: utils.RandomString(24),
 			setupAuth: func(request *http.Request, id string, token auth.TokenManager) {
 				addAuthorization(t, request, token, "Bearer", id, time.Minute)
 			},
-			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, todoID, userID primitive.ObjectID) {
+			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, todoID, userID string) {
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), todoID).Times(1).Return(domain.Todo{
+					ID:     todoID,
+					UserID: userID,
+					Status: domain.Active,
+				}, nil)
 				todoRepo.EXPECT().UpdateTodoDoneByID(gomock.Any(), todoID, userID).Return(domain.Todo{
 					ID:       todoID,
 					UserID:   userID,
 					Title:    utils.RandomString(10),
-					ActiveAt: utils.RandomString(10),
+					ActiveAt: time.Now().UTC(),
 					Author:   utils.RandomString(10),
 					Status:   domain.Done,
 				}, nil)
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
 			},
-			checkResponse: func(recoder *httptest.ResponseRecorder, err1, err2 error) {
+			checkResponse: func(recoder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recoder.Code)
-				require.NoError(t, err1)
-				require.NoError(t, err2)
 			},
 		},
 		{
-			name: "status ok",
+			name: "invalid todo id",
 			uri: domain.TodoURI{
-				ID: "primitive.NewObjectID().Hex()",
+				ID: "utils.RandomString(24)",
 			},
-			userID: primitive.NewObjectID().Hex(),
+			userID: utils.RandomString(24),
 			setupAuth: func(request *http.Request, id string, token auth.TokenManager) {
 				addAuthorization(t, request, token, "Bearer", id, time.Minute)
 			},
-			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, todoID, userID primitive.ObjectID) {
-				todoRepo.EXPECT().UpdateTodoDoneByID(gomock.Any(), todoID, userID).Times(0)
+			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, todoID, userID string) {
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), todoID).Times(1).Return(domain.Todo{}, domain.ErrTodoInvalidId)
+				todoRepo.EXPECT().UpdateTodoDoneByID(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 			},
-			checkResponse: func(recoder *httptest.ResponseRecorder, err1, err2 error) {
+			checkResponse: func(recoder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recoder.Code)
-				require.Error(t, err1)
-				require.NoError(t, err2)
+			},
+		},
+		{
+			name: "forbidden",
+			uri: domain.TodoURI{
+				ID: utils.RandomString(24),
+			},
+			userID: utils.RandomString(24),
+			setupAuth: func(request *http.Request, id string, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", id, time.Minute)
+			},
+			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, todoID, userID string) {
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), todoID).Times(1).Return(domain.Todo{
+					ID:     todoID,
+					UserID: utils.RandomString(24),
+					Status: domain.Active,
+				}, nil)
+				todoRepo.EXPECT().UpdateTodoDoneByID(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recoder.Code)
 			},
 		},
 	}
@@ -387,20 +507,18 @@ func TestServer_doneTodo(t *testing.T) {
 
 			todoRepo := repoMocks.NewMockTodo(ctrl)
 			userRepo := repoMocks.NewMockUsers(ctrl)
-			todoService := service.NewTodoService(todoRepo, userRepo)
+			todoService := service.NewTodoService(todoRepo, memory.NewTodoItemRepo(), userRepo, newCalendarService(t, userRepo), true, 0)
 			recorder := httptest.NewRecorder()
 			router := gin.Default()
 			api := router.Group("/api")
 			token, err := auth.NewManager("qwerty")
 			require.NoError(t, err)
 			handler := &Server{
+				userService:  newUserServiceStub(ctrl),
 				todoService:  todoService,
 				tokenManager: token,
 			}
-			todoID, err1 := primitive.ObjectIDFromHex(tt.uri.ID)
-			userID, err2 := primitive.ObjectIDFromHex(tt.userID)
-
-			tt.buildStubs(userRepo, todoRepo, todoID, userID)
+			tt.buildStubs(userRepo, todoRepo, tt.uri.ID, tt.userID)
 
 			handler.Init(api)
 			server := httptest.NewServer(router)
@@ -412,114 +530,175 @@ func TestServer_doneTodo(t *testing.T) {
 			tt.setupAuth(request, tt.userID, token)
 			require.NoError(t, err)
 			router.ServeHTTP(recorder, request)
-			tt.checkResponse(recorder, err1, err2)
+			tt.checkResponse(recorder)
 		})
 	}
 }
 
 func TestServer_getTodos(t *testing.T) {
 
+	newTodos := func(userID string, status string) []domain.Todo {
+		return []domain.Todo{
+			{
+				ID:       utils.RandomString(24),
+				UserID:   userID,
+				Title:    utils.RandomString(10),
+				ActiveAt: time.Now().Add(time.Hour * 24).UTC(),
+				Author:   utils.RandomString(10),
+				Status:   status,
+			},
+			{
+				ID:       utils.RandomString(24),
+				UserID:   userID,
+				Title:    utils.RandomString(10),
+				ActiveAt: time.Now().Add(time.Hour * 24).UTC(),
+				Author:   utils.RandomString(10),
+				Status:   status,
+			},
+		}
+	}
+
 	tests := []struct {
 		name          string
 		userID        string
-		status        string
+		query         string
 		setupAuth     func(request *http.Request, id string, token auth.TokenManager)
-		buildStubs    func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, todoID, userID primitive.ObjectID, status string)
-		checkResponse func(recoder *httptest.ResponseRecorder, err2 error)
+		buildStubs    func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, userID string)
+		checkResponse func(recoder *httptest.ResponseRecorder)
 	}{
-		// TODO: Add test cases.
 		{
 			name:   "status ok",
-			userID: primitive.NewObjectID().Hex(),
+			userID: utils.RandomString(24),
 			setupAuth: func(request *http.Request, id string, token auth.TokenManager) {
 				addAuthorization(t, request, token, "Bearer", id, time.Minute)
 			},
-			status: domain.Active,
-			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, todoID, userID primitive.ObjectID, status string) {
-				todoRepo.EXPECT().GetTodoByStatus(gomock.Any(), status, userID).Return([]domain.Todo{
-					{
-						ID:       primitive.NewObjectID(),
-						UserID:   userID,
-						Title:    utils.RandomString(10),
-						ActiveAt: time.Now().Add(time.Hour * 24).Format(domain.Format),
-						Author:   utils.RandomString(10),
-						Status:   status,
-					},
-					{
-						ID:       primitive.NewObjectID(),
-						UserID:   userID,
-						Title:    utils.RandomString(10),
-						ActiveAt: time.Now().Add(time.Hour * 24).Format(domain.Format),
-						Author:   utils.RandomString(10),
-						Status:   status,
-					}}, nil)
-
+			query: "status=active",
+			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, userID string) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
+				todoRepo.EXPECT().GetCountByFilter(gomock.Any(), gomock.Any()).Times(1).Return(int64(2), nil)
+				todoRepo.EXPECT().GetTodos(gomock.Any(), gomock.Any()).Times(1).Return(newTodos(userID, domain.Active), nil)
 			},
-			checkResponse: func(recoder *httptest.ResponseRecorder, err2 error) {
+			checkResponse: func(recoder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recoder.Code)
-				require.NoError(t, err2)
+
+				var page domain.TodoPage
+				require.NoError(t, json.Unmarshal(recoder.Body.Bytes(), &page))
+				require.Len(t, page.Items, 2)
+				require.Equal(t, int64(2), page.Total)
+				require.Empty(t, page.NextCursor)
 			},
 		},
 		{
-			name:   "status ok",
-			userID: primitive.NewObjectID().Hex(),
+			name:   "filters and sort",
+			userID: utils.RandomString(24),
 			setupAuth: func(request *http.Request, id string, token auth.TokenManager) {
 				addAuthorization(t, request, token, "Bearer", id, time.Minute)
 			},
-			status: domain.Done,
-			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, todoID, userID primitive.ObjectID, status string) {
-				todoRepo.EXPECT().GetTodoByStatus(gomock.Any(), status, userID).Return([]domain.Todo{
-					{
-						ID:       primitive.NewObjectID(),
-						UserID:   userID,
-						Title:    utils.RandomString(10),
-						ActiveAt: time.Now().Add(time.Hour * 24).Format(domain.Format),
-						Author:   utils.RandomString(10),
-						Status:   status,
-					},
-					{
-						ID:       primitive.NewObjectID(),
-						UserID:   userID,
-						Title:    utils.RandomString(10),
-						ActiveAt: time.Now().Add(time.Hour * 24).Format(domain.Format),
-						Author:   utils.RandomString(10),
-						Status:   status,
-					}}, nil)
-
+			query: "status=done&status=active&active_from=2030-01-01&active_to=2030-02-01&title=abc&sort=title&order=desc&limit=1",
+			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, userID string) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
+				filter := domain.TodoFilter{
+					UserID:       userID,
+					Statuses:     []string{domain.Done, domain.Active},
+					ActiveFrom:   time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC),
+					ActiveBefore: time.Date(2030, time.February, 2, 0, 0, 0, 0, time.UTC),
+					Title:        "abc",
+					Sort:         domain.SortTitle,
+					Order:        domain.OrderDesc,
+					Limit:        1,
+				}
+				query := filter
+				query.Limit = 2
+
+				todoRepo.EXPECT().GetCountByFilter(gomock.Any(), filter).Times(1).Return(int64(2), nil)
+				todoRepo.EXPECT().GetTodos(gomock.Any(), query).Times(1).Return(newTodos(userID, domain.Done), nil)
 			},
-			checkResponse: func(recoder *httptest.ResponseRecorder, err2 error) {
+			checkResponse: func(recoder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recoder.Code)
-				require.NoError(t, err2)
+
+				var page domain.TodoPage
+				require.NoError(t, json.Unmarshal(recoder.Body.Bytes(), &page))
+				require.Len(t, page.Items, 1)
+				require.NotEmpty(t, page.NextCursor)
 			},
 		},
 		{
-			name:   "user id errors",
-			userID: "primitive.NewObjectID().Hex()",
+			name:   "invalid sort",
+			userID: utils.RandomString(24),
 			setupAuth: func(request *http.Request, id string, token auth.TokenManager) {
 				addAuthorization(t, request, token, "Bearer", id, time.Minute)
 			},
-			status: domain.Done,
-			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, todoID, userID primitive.ObjectID, status string) {
-				todoRepo.EXPECT().GetTodoByStatus(gomock.Any(), status, userID).Times(0)
+			query: "sort=author",
+			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, userID string) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
+				todoRepo.EXPECT().GetCountByFilter(gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().GetTodos(gomock.Any(), gomock.Any()).Times(0)
 			},
-			checkResponse: func(recoder *httptest.ResponseRecorder, err2 error) {
+			checkResponse: func(recoder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recoder.Code)
-				require.Error(t, err2)
+			},
+		},
+		{
+			name:   "invalid limit",
+			userID: utils.RandomString(24),
+			setupAuth: func(request *http.Request, id string, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", id, time.Minute)
+			},
+			query: "limit=abc",
+			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, userID string) {
+				todoRepo.EXPECT().GetCountByFilter(gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().GetTodos(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recoder.Code)
+			},
+		},
+		{
+			name:   "invalid cursor",
+			userID: utils.RandomString(24),
+			setupAuth: func(request *http.Request, id string, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", id, time.Minute)
+			},
+			query: "cursor=abc",
+			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, userID string) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
+				todoRepo.EXPECT().GetCountByFilter(gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().GetTodos(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recoder.Code)
+			},
+		},
+		{
+			name:   "unknown user id",
+			userID: "utils.RandomString(24)",
+			setupAuth: func(request *http.Request, id string, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", id, time.Minute)
+			},
+			query: "status=done",
+			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, userID string) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{}, domain.ErrNotFound)
+				todoRepo.EXPECT().GetCountByFilter(gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().GetTodos(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recoder.Code)
 			},
 		},
 		{
 			name:   "user id errors",
-			userID: primitive.NewObjectID().Hex(),
+			userID: utils.RandomString(24),
 			setupAuth: func(request *http.Request, id string, token auth.TokenManager) {
 				addAuthorization(t, request, token, "Bearer", id, time.Minute)
 			},
-			status: domain.Done,
-			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, todoID, userID primitive.ObjectID, status string) {
-				todoRepo.EXPECT().GetTodoByStatus(gomock.Any(), status, userID).Times(1).Return([]domain.Todo{}, domain.ErrInternalServer)
+			query: "status=done",
+			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, userID string) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
+				todoRepo.EXPECT().GetCountByFilter(gomock.Any(), gomock.Any()).Times(1).Return(int64(2), nil)
+				todoRepo.EXPECT().GetTodos(gomock.Any(), gomock.Any()).Times(1).Return(nil, domain.ErrInternalServer)
 			},
-			checkResponse: func(recoder *httptest.ResponseRecorder, err2 error) {
+			checkResponse: func(recoder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recoder.Code)
-				require.NoError(t, err2)
 			},
 		},
 	}
@@ -530,31 +709,131 @@ func TestServer_getTodos(t *testing.T) {
 
 			todoRepo := repoMocks.NewMockTodo(ctrl)
 			userRepo := repoMocks.NewMockUsers(ctrl)
-			todoService := service.NewTodoService(todoRepo, userRepo)
+			todoService := service.NewTodoService(todoRepo, memory.NewTodoItemRepo(), userRepo, newCalendarService(t, userRepo), true, 0)
 			recorder := httptest.NewRecorder()
 			router := gin.Default()
 			api := router.Group("/api")
 			token, err := auth.NewManager("qwerty")
 			require.NoError(t, err)
 			handler := &Server{
+				userService:  newUserServiceStub(ctrl),
 				todoService:  todoService,
 				tokenManager: token,
 			}
-			userID, err2 := primitive.ObjectIDFromHex(tt.userID)
-
-			tt.buildStubs(userRepo, todoRepo, primitive.NewObjectID(), userID, tt.status)
+			tt.buildStubs(userRepo, todoRepo, tt.userID)
 
 			handler.Init(api)
 			server := httptest.NewServer(router)
 			defer server.Close()
-			url := fmt.Sprintf("%s/api/v1/users/todo-list/todo?status=%s", server.URL, tt.status)
-			// body, err := json.Marshal(tt.inp)
-			require.NoError(t, err)
+			url := fmt.Sprintf("%s/api/v1/users/todo-list/todo?%s", server.URL, tt.query)
 			request, err := http.NewRequest(http.MethodGet, url, nil)
 			tt.setupAuth(request, tt.userID, token)
 			require.NoError(t, err)
 			router.ServeHTTP(recorder, request)
-			tt.checkResponse(recorder, err2)
+			tt.checkResponse(recorder)
+		})
+	}
+}
+
+func TestServer_searchTodos(t *testing.T) {
+
+	tests := []struct {
+		name          string
+		userID        string
+		query         string
+		setupAuth     func(request *http.Request, id string, token auth.TokenManager)
+		buildStubs    func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, userID string)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "status ok",
+			userID: utils.RandomString(24),
+			setupAuth: func(request *http.Request, id string, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", id, time.Minute)
+			},
+			query: "q=%22buy+milk%22+-coffee&limit=5",
+			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, userID string) {
+				query := domain.SearchQuery{
+					Phrases:  [][]string{{"buy", "milk"}},
+					Excluded: [][]string{{"coffee"}},
+				}
+				todoRepo.EXPECT().SearchTodos(gomock.Any(), userID, query, 5).Times(1).Return([]domain.SearchResult{
+					{
+						Todo: domain.Todo{
+							ID:     utils.RandomString(24),
+							UserID: userID,
+							Title:  "Buy milk",
+						},
+						Score: 1.5,
+					},
+				}, nil)
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
+			},
+			checkResponse: func(recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recoder.Code)
+
+				var results []domain.SearchResult
+				require.NoError(t, json.Unmarshal(recoder.Body.Bytes(), &results))
+				require.Len(t, results, 1)
+				require.Equal(t, "<mark>Buy milk</mark>", results[0].Highlights["title"])
+			},
+		},
+		{
+			name:   "empty query",
+			userID: utils.RandomString(24),
+			setupAuth: func(request *http.Request, id string, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", id, time.Minute)
+			},
+			query: "q=-coffee",
+			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, userID string) {
+				todoRepo.EXPECT().SearchTodos(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recoder.Code)
+			},
+		},
+		{
+			name:   "internal server",
+			userID: utils.RandomString(24),
+			setupAuth: func(request *http.Request, id string, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", id, time.Minute)
+			},
+			query: "q=milk",
+			buildStubs: func(userRepo *repoMocks.MockUsers, todoRepo *repoMocks.MockTodo, userID string) {
+				todoRepo.EXPECT().SearchTodos(gomock.Any(), userID, gomock.Any(), gomock.Any()).Times(1).Return(nil, domain.ErrInternalServer)
+			},
+			checkResponse: func(recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recoder.Code)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			todoRepo := repoMocks.NewMockTodo(ctrl)
+			userRepo := repoMocks.NewMockUsers(ctrl)
+			todoService := service.NewTodoService(todoRepo, memory.NewTodoItemRepo(), userRepo, newCalendarService(t, userRepo), true, 0)
+			recorder := httptest.NewRecorder()
+			router := gin.Default()
+			api := router.Group("/api")
+			token, err := auth.NewManager("qwerty")
+			require.NoError(t, err)
+			handler := &Server{
+				userService:  newUserServiceStub(ctrl),
+				todoService:  todoService,
+				tokenManager: token,
+			}
+			tt.buildStubs(userRepo, todoRepo, tt.userID)
+
+			handler.Init(api)
+			url := fmt.Sprintf("/api/v1/users/todo-list/search?%s", tt.query)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+			tt.setupAuth(request, tt.userID, token)
+			router.ServeHTTP(recorder, request)
+			tt.checkResponse(recorder)
 		})
 	}
 }
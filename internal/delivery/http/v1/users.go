@@ -13,17 +13,63 @@ func (s *Server) initLoadRoutes(api *gin.RouterGroup) {
 	{
 		users.POST("/sign-up", s.userSignUp)
 		users.POST("/sign-in", s.userSignIn)
+		users.POST("/sign-in/mfa", s.userSignInMFA)
+		users.POST("/sign-in/mfa/enroll", s.userEnrollMFAChallenge)
 		users.POST("/auth/refresh", s.userRefresh)
-		authenticated := users.Group("/", s.userIdentity)
+		users.POST("/password-reset", s.requestPasswordReset)
+		users.POST("/password-reset/confirm", s.resetPassword)
+		users.POST("/verify-email", s.verifyEmail)
+		authenticated := users.Group("/", s.userIdentity, s.userLanguage)
 		{
+			var (
+				account        = requireScope(domain.ScopeAccount)
+				todosRead      = requireScope(domain.ScopeTodosRead)
+				todosWrite     = requireScope(domain.ScopeTodosWrite)
+				calendarsRead  = requireScope(domain.ScopeCalendarsRead)
+				calendarsWrite = requireScope(domain.ScopeCalendarsWrite)
+			)
+
+			authenticated.POST("/auth/logout", account, s.userLogout)
+			authenticated.POST("/auth/logout-everywhere", account, s.userLogoutEverywhere)
+			authenticated.GET("/sessions", account, s.getSessions)
+			authenticated.DELETE("/sessions/:id", account, s.revokeSession)
+			authenticated.POST("/tokens", account, s.createPersonalToken)
+			authenticated.GET("/tokens", account, s.getPersonalTokens)
+			authenticated.DELETE("/tokens/:id", account, s.revokePersonalToken)
+			authenticated.GET("/mfa", account, s.getMFA)
+			authenticated.POST("/mfa", account, s.enrollMFA)
+			authenticated.POST("/mfa/confirm", account, s.confirmMFA)
+			authenticated.DELETE("/mfa", account, s.disableMFA)
+			authenticated.POST("/mfa/recovery-codes", account, s.regenerateRecoveryCodes)
+			authenticated.PUT("/language", account, s.selectLanguage)
+			authenticated.POST("/verify-email/resend", account, s.resendVerification)
+			authenticated.PUT("/email", account, s.changeEmail)
+			authenticated.GET("/me", account, s.getProfile)
+			authenticated.PATCH("/me", account, s.updateProfile)
+			authenticated.DELETE("/me", account, s.deleteAccount)
+			authenticated.PUT("/password", account, s.changePassword)
+
 			todo := authenticated.Group("/todo-list")
 			{
-				todo.POST("/todo", s.createTodo)
-				todo.PUT("/todo/:id", s.updateTodo)
-				todo.DELETE("/todo/:id", s.deleteTodo)
-				todo.PUT("/todo/:id/done", s.doneTodo)
-				todo.GET("/todo", s.getTodos)
+				todo.POST("/todo", todosWrite, s.createTodo)
+				todo.GET("/todo/:id", todosRead, s.getTodo)
+				todo.PUT("/todo/:id", todosWrite, s.updateTodo)
+				todo.DELETE("/todo/:id", todosWrite, s.deleteTodo)
+				todo.PUT("/todo/:id/done", todosWrite, s.doneTodo)
+				todo.GET("/todo/:id/items", todosRead, s.getTodoItems)
+				todo.POST("/todo/:id/items", todosWrite, s.createTodoItem)
+				todo.PUT("/todo/:id/items", todosWrite, s.reorderTodoItems)
+				todo.PUT("/todo/:id/items/:item_id/toggle", todosWrite, s.toggleTodoItem)
+				todo.DELETE("/todo/:id/items/:item_id", todosWrite, s.deleteTodoItem)
+				todo.GET("/todo", todosRead, s.getTodos)
+				todo.GET("/search", todosRead, s.searchTodos)
 			}
+
+			authenticated.GET("/calendars", calendarsRead, s.getCalendars)
+			authenticated.POST("/calendars", calendarsWrite, s.uploadCalendar)
+			authenticated.GET("/calendars/:id", calendarsRead, s.getCalendar)
+			authenticated.DELETE("/calendars/:id", calendarsWrite, s.deleteCalendar)
+			authenticated.PUT("/calendar", calendarsWrite, s.selectCalendar)
 		}
 	}
 }
@@ -42,13 +88,13 @@ func (s *Server) userSignUp(ctx *gin.Context) {
 	var req domain.UserRequest
 
 	if err := ctx.BindJSON(&req); err != nil {
-		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest.Error(), fmt.Sprintf("ctx.BindJSON(): %v", err))
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindJSON(): %v", err))
 		return
 	}
 
 	user, err := s.userService.SignUp(ctx, req)
 	if err != nil {
-		newResponse(ctx, checkErrors(err), err.Error(), fmt.Sprintf("ctx.BindJSON(): %v", err))
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("ctx.BindJSON(): %v", err))
 		return
 	}
 
@@ -57,31 +103,162 @@ func (s *Server) userSignUp(ctx *gin.Context) {
 
 // @Summary		Sign-in
 // @Tags			User
-// @Description	Sign-in
+// @Description	Sign-in. A user with two-factor authentication gets mfa_token instead of the tokens, to complete the sign-in at /users/sign-in/mfa; mfa_enrollment asks to set it up first at /users/sign-in/mfa/enroll. Repeated failures make the account or the IP address wait, 429 tells how long in Retry-After.
 // @Accept			json
 // @Produce		json
 // @Param			account	body		domain.UserSignInRequest	true	"User"
-// @Success		200		{object}	Response
+// @Success		200		{object}	domain.Token
 // @Failure		400		{object}	Response
 // @Failure		404		{object}	Response
+// @Failure		429		{object}	Response
+// @Header			429		{integer}	Retry-After	"Seconds to wait before the next attempt"
 // @Failure		500		{object}	Response
 // @Router			/users/sign-in [post]
 func (s *Server) userSignIn(ctx *gin.Context) {
 	var inp domain.UserSignInRequest
 	if err := ctx.BindJSON(&inp); err != nil {
-		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest.Error(), fmt.Sprintf("ctx.BindJSON(): %v", err))
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindJSON(): %v", err))
 		return
 	}
 
-	tokens, err := s.userService.SignIn(ctx, inp.Email, inp.Password)
+	tokens, err := s.userService.SignIn(ctx, inp.Email, inp.Password, getClient(ctx, inp.Device))
 	if err != nil {
-		newResponse(ctx, checkErrors(err), err.Error(), fmt.Sprintf("s.userService.SignIn(): %v", err))
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.userService.SignIn(): %v", err))
 		return
 	}
 
 	ctx.JSON(http.StatusOK, tokens)
 }
 
+// @Summary		Sign-in MFA
+// @Tags			User
+// @Description	Completes the sign-in with a code of the authenticator app or a recovery code. A sign-in that sets up two-factor authentication confirms it with the code and gets recovery_codes, shown only in this response. After five wrong codes the mfa_token stops working.
+// @Accept			json
+// @Produce		json
+// @Param			account	body		domain.MFASignInRequest	true	"MFA token and code"
+// @Success		200		{object}	domain.Token
+// @Failure		400		{object}	Response
+// @Failure		401		{object}	Response
+// @Failure		500		{object}	Response
+// @Router			/users/sign-in/mfa [post]
+func (s *Server) userSignInMFA(ctx *gin.Context) {
+	var inp domain.MFASignInRequest
+	if err := ctx.BindJSON(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindJSON(): %v", err))
+		return
+	}
+
+	tokens, err := s.userService.VerifyMFA(ctx, inp, getClient(ctx, inp.Device))
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.userService.VerifyMFA(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+// @Summary		Sign-in MFA Enroll
+// @Tags			User
+// @Description	Sets up two-factor authentication during the sign-in of a user whose organization enforces it, for the mfa_token of a sign-in with mfa_enrollment. The secret is confirmed by completing the sign-in with a code of it.
+// @Accept			json
+// @Produce		json
+// @Param			account	body		domain.MFAEnrollRequest	true	"MFA token"
+// @Success		200		{object}	domain.MFAEnrollment
+// @Failure		400		{object}	Response
+// @Failure		401		{object}	Response
+// @Failure		500		{object}	Response
+// @Router			/users/sign-in/mfa/enroll [post]
+func (s *Server) userEnrollMFAChallenge(ctx *gin.Context) {
+	var inp domain.MFAEnrollRequest
+	if err := ctx.BindJSON(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindJSON(): %v", err))
+		return
+	}
+
+	enrollment, err := s.userService.EnrollMFAChallenge(ctx, inp.MFAToken)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.userService.EnrollMFAChallenge(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, enrollment)
+}
+
+// @Summary		Request Password Reset
+// @Tags			User
+// @Description	Emails a link to reset the password, valid once for a limited time. The response is the same whether the email is registered or not.
+// @Accept			json
+// @Produce		json
+// @Param			account	body		domain.PasswordResetRequest	true	"Email"
+// @Success		200		{object}	Response
+// @Failure		400		{object}	Response
+// @Failure		500		{object}	Response
+// @Router			/users/password-reset [post]
+func (s *Server) requestPasswordReset(ctx *gin.Context) {
+	var inp domain.PasswordResetRequest
+	if err := ctx.BindJSON(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindJSON(): %v", err))
+		return
+	}
+
+	if err := s.userService.RequestPasswordReset(ctx, inp.Email, getLanguage(ctx)); err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.userService.RequestPasswordReset(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newMessage(ctx, "password_reset_requested"))
+}
+
+// @Summary		Reset Password
+// @Tags			User
+// @Description	Sets a new password with the token of a reset link. Every session of the user is ended, the user signs in again with the new password.
+// @Accept			json
+// @Produce		json
+// @Param			account	body		domain.PasswordReset	true	"Token and new password"
+// @Success		200		{object}	Response
+// @Failure		400		{object}	Response
+// @Failure		500		{object}	Response
+// @Router			/users/password-reset/confirm [post]
+func (s *Server) resetPassword(ctx *gin.Context) {
+	var inp domain.PasswordReset
+	if err := ctx.BindJSON(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindJSON(): %v", err))
+		return
+	}
+
+	if err := s.userService.ResetPassword(ctx, inp); err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.userService.ResetPassword(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newMessage(ctx, "password_reset"))
+}
+
+// @Summary		Verify Email
+// @Tags			User
+// @Description	Verifies the email with the token of a verification link, a changed email replaces the previous one. A link works once, and only the latest one sent does.
+// @Accept			json
+// @Produce		json
+// @Param			account	body		domain.EmailVerification	true	"Token"
+// @Success		200		{object}	Response
+// @Failure		400		{object}	Response
+// @Failure		500		{object}	Response
+// @Router			/users/verify-email [post]
+func (s *Server) verifyEmail(ctx *gin.Context) {
+	var inp domain.EmailVerification
+	if err := ctx.BindJSON(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindJSON(): %v", err))
+		return
+	}
+
+	if err := s.userService.VerifyEmail(ctx, inp.Token); err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.userService.VerifyEmail(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newMessage(ctx, "email_verified"))
+}
+
 // @Summary		Refresh Token
 // @Tags			User
 // @Description	Refresh Token
@@ -90,21 +267,629 @@ func (s *Server) userSignIn(ctx *gin.Context) {
 // @Param			account	body		domain.RefreshToken	true	"User"
 // @Success		200		{object}	domain.Token
 // @Failure		400		{object}	Response
+// @Failure		401		{object}	Response
 // @Failure		404		{object}	Response
 // @Failure		500		{object}	Response
 // @Router			/users/auth/refresh [post]
 func (s *Server) userRefresh(ctx *gin.Context) {
 	var inp domain.RefreshToken
 	if err := ctx.BindJSON(&inp); err != nil {
-		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest.Error(), fmt.Sprintf("ctx.BindJSON(): %v", err))
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindJSON(): %v", err))
 		return
 	}
 
-	res, err := s.userService.RefreshTokens(ctx, inp.RefreshToken)
+	res, err := s.userService.RefreshTokens(ctx, inp.RefreshToken, getClient(ctx, ""))
 	if err != nil {
-		newResponse(ctx, checkErrors(err), err.Error(), fmt.Sprintf("s.userService.RefreshTokens(): %v", err))
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.userService.RefreshTokens(): %v", err))
 		return
 	}
 
 	ctx.JSON(http.StatusOK, res)
 }
+
+// @Summary		Logout
+// @Security UserAuth
+// @Tags			User
+// @Description	Revokes the access token of the request and ends the session it was issued for
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	Response
+// @Failure		401	{object}	Response
+// @Failure		403	{object}	Response
+// @Failure		500	{object}	Response
+// @Router			/users/auth/logout [post]
+func (s *Server) userLogout(ctx *gin.Context) {
+	claims, err := getClaims(ctx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getClaims(): %v", err))
+		return
+	}
+
+	if err := s.userService.Logout(ctx, claims); err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.userService.Logout(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newMessage(ctx, "logged_out"))
+}
+
+// @Summary		Logout Everywhere
+// @Security UserAuth
+// @Tags			User
+// @Description	Ends every session of the user and revokes all the access tokens issued so far
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	Response
+// @Failure		401	{object}	Response
+// @Failure		403	{object}	Response
+// @Failure		500	{object}	Response
+// @Router			/users/auth/logout-everywhere [post]
+func (s *Server) userLogoutEverywhere(ctx *gin.Context) {
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	if err := s.userService.LogoutEverywhere(ctx, id); err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.userService.LogoutEverywhere(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newMessage(ctx, "logged_out"))
+}
+
+// @Summary		User Get Sessions
+// @Security UserAuth
+// @Tags			User
+// @Description	Active sessions of the user, one per signed in device, the most recently used first
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	[]domain.Session
+// @Failure		401	{object}	Response
+// @Failure		403	{object}	Response
+// @Failure		500	{object}	Response
+// @Router			/users/sessions [get]
+func (s *Server) getSessions(ctx *gin.Context) {
+	claims, err := getClaims(ctx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getClaims(): %v", err))
+		return
+	}
+
+	sessions, err := s.userService.GetSessions(ctx, claims.Subject, claims.SessionID)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.userService.GetSessions(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, sessions)
+}
+
+// @Summary		User Revoke Session
+// @Security UserAuth
+// @Tags			User
+// @Description	Ends a session of the user, its refresh token and access tokens stop working at once
+// @Accept			json
+// @Produce		json
+// @Param			id	path		string	true	"Session id"
+// @Success		200	{object}	Response
+// @Failure		400	{object}	Response
+// @Failure		401	{object}	Response
+// @Failure		403	{object}	Response
+// @Failure		404	{object}	Response
+// @Failure		500	{object}	Response
+// @Router			/users/sessions/{id} [delete]
+func (s *Server) revokeSession(ctx *gin.Context) {
+	var uri domain.SessionURI
+	if err := ctx.BindUri(&uri); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindUri(): %v", err))
+		return
+	}
+
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	if err := s.userService.RevokeSession(ctx, id, uri.ID); err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.userService.RevokeSession(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newMessage(ctx, "session_revoked"))
+}
+
+// @Summary		User Create Personal Token
+// @Security UserAuth
+// @Tags			User
+// @Description	Creates a long-lived token for scripts and CI with some of the scopes todos:read, todos:write, calendars:read and calendars:write, valid until expires_at or, without one, until revoked. The token is shown only in this response.
+// @Accept			json
+// @Produce		json
+// @Param			token	body		domain.PersonalTokenRequest	true	"Personal token"
+// @Success		200		{object}	domain.NewPersonalToken
+// @Failure		400		{object}	Response
+// @Failure		401		{object}	Response
+// @Failure		403		{object}	Response
+// @Failure		500		{object}	Response
+// @Router			/users/tokens [post]
+func (s *Server) createPersonalToken(ctx *gin.Context) {
+	var req domain.PersonalTokenRequest
+	if err := ctx.BindJSON(&req); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindJSON(): %v", err))
+		return
+	}
+
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	token, err := s.userService.CreatePersonalToken(ctx, id, req)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.userService.CreatePersonalToken(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, token)
+}
+
+// @Summary		User Get Personal Tokens
+// @Security UserAuth
+// @Tags			User
+// @Description	Personal tokens of the user that have not expired, the most recently created first
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	[]domain.PersonalToken
+// @Failure		401	{object}	Response
+// @Failure		403	{object}	Response
+// @Failure		500	{object}	Response
+// @Router			/users/tokens [get]
+func (s *Server) getPersonalTokens(ctx *gin.Context) {
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	tokens, err := s.userService.GetPersonalTokens(ctx, id)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.userService.GetPersonalTokens(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+// @Summary		User Revoke Personal Token
+// @Security UserAuth
+// @Tags			User
+// @Description	Revokes a personal token of the user, it stops working at once
+// @Accept			json
+// @Produce		json
+// @Param			id	path		string	true	"Personal token id"
+// @Success		200	{object}	Response
+// @Failure		400	{object}	Response
+// @Failure		401	{object}	Response
+// @Failure		403	{object}	Response
+// @Failure		404	{object}	Response
+// @Failure		500	{object}	Response
+// @Router			/users/tokens/{id} [delete]
+func (s *Server) revokePersonalToken(ctx *gin.Context) {
+	var uri domain.PersonalTokenURI
+	if err := ctx.BindUri(&uri); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindUri(): %v", err))
+		return
+	}
+
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	if err := s.userService.RevokePersonalToken(ctx, id, uri.ID); err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.userService.RevokePersonalToken(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newMessage(ctx, "personal_token_revoked"))
+}
+
+// @Summary		User Get MFA
+// @Security UserAuth
+// @Tags			User
+// @Description	Whether two-factor authentication is enabled, required by the organization of the user, and how many recovery codes are left
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	domain.MFAStatus
+// @Failure		401	{object}	Response
+// @Failure		403	{object}	Response
+// @Failure		500	{object}	Response
+// @Router			/users/mfa [get]
+func (s *Server) getMFA(ctx *gin.Context) {
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	status, err := s.userService.GetMFA(ctx, id)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.userService.GetMFA(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, status)
+}
+
+// @Summary		User Enroll MFA
+// @Security UserAuth
+// @Tags			User
+// @Description	Generates the secret of an authenticator app and its otpauth URI to show as a QR code. Two-factor authentication is enabled once a code of it is sent to /users/mfa/confirm.
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	domain.MFAEnrollment
+// @Failure		400	{object}	Response
+// @Failure		401	{object}	Response
+// @Failure		403	{object}	Response
+// @Failure		500	{object}	Response
+// @Router			/users/mfa [post]
+func (s *Server) enrollMFA(ctx *gin.Context) {
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	enrollment, err := s.userService.EnrollMFA(ctx, id)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.userService.EnrollMFA(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, enrollment)
+}
+
+// @Summary		User Confirm MFA
+// @Security UserAuth
+// @Tags			User
+// @Description	Enables two-factor authentication with a code of the authenticator app. The recovery codes are shown only in this response. Wrong codes count towards the MFA lockout of the sign-in, 429 tells how long to wait in Retry-After.
+// @Accept			json
+// @Produce		json
+// @Param			code	body		domain.MFACode	true	"Code"
+// @Success		200		{object}	domain.RecoveryCodes
+// @Failure		400		{object}	Response
+// @Failure		401		{object}	Response
+// @Failure		403		{object}	Response
+// @Failure		429		{object}	Response
+// @Header			429		{integer}	Retry-After	"Seconds to wait before the next attempt"
+// @Failure		500		{object}	Response
+// @Router			/users/mfa/confirm [post]
+func (s *Server) confirmMFA(ctx *gin.Context) {
+	var req domain.MFACode
+	if err := ctx.BindJSON(&req); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindJSON(): %v", err))
+		return
+	}
+
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	codes, err := s.userService.ConfirmMFA(ctx, id, req.Code)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.userService.ConfirmMFA(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, codes)
+}
+
+// @Summary		User Disable MFA
+// @Security UserAuth
+// @Tags			User
+// @Description	Turns two-factor authentication off with a code of the authenticator app or a recovery code. Users whose organization enforces it cannot turn it off. Wrong codes count towards the MFA lockout of the sign-in.
+// @Accept			json
+// @Produce		json
+// @Param			code	body		domain.MFACode	true	"Code"
+// @Success		200		{object}	Response
+// @Failure		400		{object}	Response
+// @Failure		401		{object}	Response
+// @Failure		403		{object}	Response
+// @Failure		429		{object}	Response
+// @Header			429		{integer}	Retry-After	"Seconds to wait before the next attempt"
+// @Failure		500		{object}	Response
+// @Router			/users/mfa [delete]
+func (s *Server) disableMFA(ctx *gin.Context) {
+	var req domain.MFACode
+	if err := ctx.BindJSON(&req); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindJSON(): %v", err))
+		return
+	}
+
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	if err := s.userService.DisableMFA(ctx, id, req.Code); err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.userService.DisableMFA(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newMessage(ctx, "mfa_disabled"))
+}
+
+// @Summary		User Regenerate Recovery Codes
+// @Security UserAuth
+// @Tags			User
+// @Description	Replaces the recovery codes with new ones, shown only in this response; the unused ones stop working. Wrong codes count towards the MFA lockout of the sign-in.
+// @Accept			json
+// @Produce		json
+// @Param			code	body		domain.MFACode	true	"Code"
+// @Success		200		{object}	domain.RecoveryCodes
+// @Failure		400		{object}	Response
+// @Failure		401		{object}	Response
+// @Failure		403		{object}	Response
+// @Failure		429		{object}	Response
+// @Header			429		{integer}	Retry-After	"Seconds to wait before the next attempt"
+// @Failure		500		{object}	Response
+// @Router			/users/mfa/recovery-codes [post]
+func (s *Server) regenerateRecoveryCodes(ctx *gin.Context) {
+	var req domain.MFACode
+	if err := ctx.BindJSON(&req); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindJSON(): %v", err))
+		return
+	}
+
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	codes, err := s.userService.RegenerateRecoveryCodes(ctx, id, req.Code)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.userService.RegenerateRecoveryCodes(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, codes)
+}
+
+// @Summary		User Select Language
+// @Security UserAuth
+// @Tags			User
+// @Description	Selects the language of the API messages: ru, kk or en. An empty language follows the Accept-Language header, which is used for the users who have not chosen one.
+// @Accept			json
+// @Produce		json
+// @Param			language	body		domain.LanguageSelection	true	"Language"
+// @Success		200			{object}	domain.LanguageSelection
+// @Failure		400			{object}	Response
+// @Failure		403			{object}	Response
+// @Failure		500			{object}	Response
+// @Router			/users/language [put]
+func (s *Server) selectLanguage(ctx *gin.Context) {
+	var inp domain.LanguageSelection
+	if err := ctx.BindJSON(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindJSON(): %v", err))
+		return
+	}
+
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	if err := s.userService.SetLanguage(ctx, id, inp.Language); err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.userService.SetLanguage(): %v", err))
+		return
+	}
+
+	if inp.Language != "" {
+		setLanguage(ctx, inp.Language)
+	}
+
+	ctx.JSON(http.StatusOK, inp)
+}
+
+// @Summary		Resend Verification
+// @Security UserAuth
+// @Tags			User
+// @Description	Emails a new verification link to the email of the user, the links sent before stop working.
+// @Produce		json
+// @Success		200	{object}	Response
+// @Failure		400	{object}	Response
+// @Failure		403	{object}	Response
+// @Failure		500	{object}	Response
+// @Router			/users/verify-email/resend [post]
+func (s *Server) resendVerification(ctx *gin.Context) {
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	if err := s.userService.ResendVerification(ctx, id, getLanguage(ctx)); err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.userService.ResendVerification(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newMessage(ctx, "email_verification_sent"))
+}
+
+// @Summary		Change Email
+// @Security UserAuth
+// @Tags			User
+// @Description	Emails a verification link to the new email. The user keeps the current email until the new one is verified.
+// @Accept			json
+// @Produce		json
+// @Param			email	body		domain.EmailChange	true	"New email and password"
+// @Success		200		{object}	Response
+// @Failure		400		{object}	Response
+// @Failure		403		{object}	Response
+// @Failure		500		{object}	Response
+// @Router			/users/email [put]
+func (s *Server) changeEmail(ctx *gin.Context) {
+	var inp domain.EmailChange
+	if err := ctx.BindJSON(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindJSON(): %v", err))
+		return
+	}
+
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	if err := s.userService.ChangeEmail(ctx, id, inp, getLanguage(ctx)); err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.userService.ChangeEmail(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newMessage(ctx, "email_verification_sent"))
+}
+
+// @Summary		Get Profile
+// @Security UserAuth
+// @Tags			User
+// @Description	Profile of the user
+// @Produce		json
+// @Success		200	{object}	domain.User
+// @Failure		401	{object}	Response
+// @Failure		403	{object}	Response
+// @Failure		500	{object}	Response
+// @Router			/users/me [get]
+func (s *Server) getProfile(ctx *gin.Context) {
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	user, err := s.userService.GetProfile(ctx, id)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.userService.GetProfile(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, user)
+}
+
+// @Summary		Update Profile
+// @Security UserAuth
+// @Tags			User
+// @Description	Changes the username, timezone or language of the user, the fields left out stay as they are. An empty timezone is UTC, an empty language follows the Accept-Language header.
+// @Accept			json
+// @Produce		json
+// @Param			profile	body		domain.UserUpdate	true	"Profile"
+// @Success		200		{object}	domain.User
+// @Failure		400		{object}	Response
+// @Failure		403		{object}	Response
+// @Failure		500		{object}	Response
+// @Router			/users/me [patch]
+func (s *Server) updateProfile(ctx *gin.Context) {
+	var inp domain.UserUpdate
+	if err := ctx.BindJSON(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindJSON(): %v", err))
+		return
+	}
+
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	user, err := s.userService.UpdateProfile(ctx, id, inp)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.userService.UpdateProfile(): %v", err))
+		return
+	}
+
+	if user.Language != "" {
+		setLanguage(ctx, user.Language)
+	}
+
+	ctx.JSON(http.StatusOK, user)
+}
+
+// @Summary		Change Password
+// @Security UserAuth
+// @Tags			User
+// @Description	Changes the password once the current one is confirmed. Every session of the user is ended, the response starts a new one for this device. Wrong passwords count as failed sign-ins of the account, 429 tells how long to wait in Retry-After.
+// @Accept			json
+// @Produce		json
+// @Param			password	body		domain.PasswordChange	true	"Current and new password"
+// @Success		200			{object}	domain.Token
+// @Failure		400			{object}	Response
+// @Failure		403			{object}	Response
+// @Failure		429			{object}	Response
+// @Header			429			{integer}	Retry-After	"Seconds to wait before the next attempt"
+// @Failure		500			{object}	Response
+// @Router			/users/password [put]
+func (s *Server) changePassword(ctx *gin.Context) {
+	var inp domain.PasswordChange
+	if err := ctx.BindJSON(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindJSON(): %v", err))
+		return
+	}
+
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	tokens, err := s.userService.ChangePassword(ctx, id, inp, getClient(ctx, inp.Device))
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.userService.ChangePassword(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+// @Summary		Delete Account
+// @Security UserAuth
+// @Tags			User
+// @Description	Deletes the account once the password is confirmed. Every session is ended and the personal tokens are revoked; the account, its todos and calendars are removed for good at delete_at. Signing in before then keeps the account. Wrong passwords count as failed sign-ins of the account, 429 tells how long to wait in Retry-After.
+// @Accept			json
+// @Produce		json
+// @Param			account	body		domain.AccountDeletion	true	"Password"
+// @Success		200		{object}	domain.User
+// @Failure		400		{object}	Response
+// @Failure		403		{object}	Response
+// @Failure		429		{object}	Response
+// @Header			429		{integer}	Retry-After	"Seconds to wait before the next attempt"
+// @Failure		500		{object}	Response
+// @Router			/users/me [delete]
+func (s *Server) deleteAccount(ctx *gin.Context) {
+	var inp domain.AccountDeletion
+	if err := ctx.BindJSON(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, domain.ErrInvalidRequest, fmt.Sprintf("ctx.BindJSON(): %v", err))
+		return
+	}
+
+	id, err := getUserID(ctx, userCtx)
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("getUserID(): %v", err))
+		return
+	}
+
+	user, err := s.userService.DeleteAccount(ctx, id, inp.Password, getClient(ctx, ""))
+	if err != nil {
+		newResponse(ctx, checkErrors(err), err, fmt.Sprintf("s.userService.DeleteAccount(): %v", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, user)
+}
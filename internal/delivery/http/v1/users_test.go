@@ -14,7 +14,6 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func TestServer_userSignUp(t *testing.T) {
@@ -34,7 +33,7 @@ func TestServer_userSignUp(t *testing.T) {
 			},
 			buildStubs: func(service *serviceMocks.MockUsers, inp domain.UserRequest) {
 				service.EXPECT().SignUp(gomock.Any(), inp).Times(1).Return(domain.User{
-					ID:       primitive.NewObjectID(),
+					ID:       utils.RandomString(24),
 					UserName: inp.UserName,
 					Email:    inp.Email,
 					Password: inp.Password,
@@ -111,10 +110,11 @@ func TestServer_userSignIn(t *testing.T) {
 			inp: domain.UserSignInRequest{
 				Email:    utils.RandomEmail(),
 				Password: utils.RandomString(10),
+				Device:   utils.RandomString(6),
 			},
 
 			buildStubs: func(service *serviceMocks.MockUsers, inp domain.UserSignInRequest) {
-				service.EXPECT().SignIn(gomock.Any(), inp.Email, inp.Password).Times(1).Return(domain.Token{
+				service.EXPECT().SignIn(gomock.Any(), inp.Email, inp.Password, domain.Client{Device: inp.Device}).Times(1).Return(domain.Token{
 					RefreshToken: utils.RandomString(10),
 					AccessToken:  utils.RandomString(25),
 				}, nil)
@@ -128,7 +128,7 @@ func TestServer_userSignIn(t *testing.T) {
 			inp:  domain.UserSignInRequest{},
 
 			buildStubs: func(service *serviceMocks.MockUsers, inp domain.UserSignInRequest) {
-				service.EXPECT().SignIn(gomock.Any(), inp.Email, inp.Password).Times(0)
+				service.EXPECT().SignIn(gomock.Any(), inp.Email, inp.Password, gomock.Any()).Times(0)
 			},
 			checkResponse: func(recoder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recoder.Code)
@@ -142,7 +142,7 @@ func TestServer_userSignIn(t *testing.T) {
 			},
 
 			buildStubs: func(service *serviceMocks.MockUsers, inp domain.UserSignInRequest) {
-				service.EXPECT().SignIn(gomock.Any(), inp.Email, inp.Password).Times(1).Return(domain.Token{}, domain.ErrInternalServer)
+				service.EXPECT().SignIn(gomock.Any(), inp.Email, inp.Password, gomock.Any()).Times(1).Return(domain.Token{}, domain.ErrInternalServer)
 			},
 			checkResponse: func(recoder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recoder.Code)
@@ -192,7 +192,7 @@ func TestServer_userRefresh(t *testing.T) {
 				RefreshToken: utils.RandomString(15),
 			},
 			buildStubs: func(service *serviceMocks.MockUsers, inp domain.RefreshToken) {
-				service.EXPECT().RefreshTokens(gomock.Any(), inp.RefreshToken).Times(1).Return(domain.Token{
+				service.EXPECT().RefreshTokens(gomock.Any(), inp.RefreshToken, gomock.Any()).Times(1).Return(domain.Token{
 					AccessToken:  utils.RandomString(10),
 					RefreshToken: utils.RandomString(10),
 				}, nil)
@@ -205,7 +205,7 @@ func TestServer_userRefresh(t *testing.T) {
 			name: "bad request",
 			inp:  domain.RefreshToken{},
 			buildStubs: func(service *serviceMocks.MockUsers, inp domain.RefreshToken) {
-				service.EXPECT().RefreshTokens(gomock.Any(), inp.RefreshToken).Times(0)
+				service.EXPECT().RefreshTokens(gomock.Any(), inp.RefreshToken, gomock.Any()).Times(0)
 			},
 			checkResponse: func(recoder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recoder.Code)
@@ -217,7 +217,7 @@ func TestServer_userRefresh(t *testing.T) {
 				RefreshToken: utils.RandomString(10),
 			},
 			buildStubs: func(service *serviceMocks.MockUsers, inp domain.RefreshToken) {
-				service.EXPECT().RefreshTokens(gomock.Any(), inp.RefreshToken).Times(1).Return(domain.Token{}, domain.ErrInternalServer)
+				service.EXPECT().RefreshTokens(gomock.Any(), inp.RefreshToken, gomock.Any()).Times(1).Return(domain.Token{}, domain.ErrInternalServer)
 			},
 			checkResponse: func(recoder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recoder.Code)
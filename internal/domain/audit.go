@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+const AuditSignInLockout = "sign_in_lockout"
+
+// AuditEvent is a security event kept in the audit log. A sign-in lockout
+// has Scope account or ip, Email and IP are those of the attempt that
+// triggered it, Until is when the lockout ends.
+type AuditEvent struct {
+	Type     string    `json:"type"`
+	Scope    string    `json:"scope,omitempty"`
+	Email    string    `json:"email,omitempty"`
+	IP       string    `json:"ip,omitempty"`
+	Attempts int64     `json:"attempts,omitempty"`
+	Until    time.Time `json:"until,omitempty"`
+	At       time.Time `json:"at"`
+}
@@ -0,0 +1,258 @@
+package domain
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// StandardCalendar has Saturdays and Sundays off and no holidays. Users
+	// who have not chosen a calendar get the default one of the server.
+	StandardCalendar = "standard"
+
+	MaxCalendarDays       = 2000
+	MaxCalendarNameLength = 100
+
+	DayOffWeekend = "weekend"
+	DayOffHoliday = "holiday"
+
+	icsDateFormat = "20060102"
+)
+
+// CalendarDay overrides a day of the week: a holiday is a day off, a
+// working day moved to a weekend is not. Reason names the holiday or the
+// transfer.
+type CalendarDay struct {
+	Date   string `json:"date" example:"2024-03-08"`
+	DayOff bool   `json:"day_off"`
+	Reason string `json:"reason,omitempty" example:"Международный женский день"`
+}
+
+// Calendar tells working days from days off. Saturdays and Sundays are off
+// unless Days list them as working days. Days are sorted by date. UserID is
+// empty for the calendars bundled with the server.
+type Calendar struct {
+	ID     string        `json:"id"`
+	UserID string        `json:"user_id,omitempty"`
+	Name   string        `json:"name"`
+	Days   []CalendarDay `json:"days,omitempty"`
+}
+
+type CalendarURI struct {
+	ID string `uri:"id" binding:"required"`
+}
+
+// CalendarSelection picks the calendar of the user, the default one of the
+// server when empty.
+type CalendarSelection struct {
+	Calendar string `json:"calendar" example:"kz"`
+}
+
+// DayOff reports whether the date is a day off and why: the name of the
+// holiday, DayOffHoliday when it has none, or DayOffWeekend.
+func (c Calendar) DayOff(date time.Time) (bool, string) {
+	key := date.Format(Format)
+	i := sort.Search(len(c.Days), func(i int) bool {
+		return c.Days[i].Date >= key
+	})
+
+	if i < len(c.Days) && c.Days[i].Date == key {
+		day := c.Days[i]
+		if !day.DayOff {
+			return false, ""
+		}
+
+		if day.Reason == "" {
+			return true, DayOffHoliday
+		}
+
+		return true, day.Reason
+	}
+
+	if weekday := date.Weekday(); weekday == time.Saturday || weekday == time.Sunday {
+		return true, DayOffWeekend
+	}
+
+	return false, ""
+}
+
+// ParseCalendarJSON reads a calendar in the form it is returned by the API:
+//
+//	{"name": "...", "days": [{"date": "2024-03-08", "day_off": true, "reason": "..."}]}
+func ParseCalendarJSON(data []byte) (Calendar, error) {
+	var calendar Calendar
+	if err := json.Unmarshal(data, &calendar); err != nil {
+		return Calendar{}, ErrInvalidCalendar
+	}
+
+	return newCalendar(calendar.Name, calendar.Days)
+}
+
+// ParseCalendarICS reads the all-day events of an iCalendar file. An event
+// is a day off, or a span of them when DTEND is given, unless its CATEGORIES
+// include WORKDAY, which marks a transferred working day. SUMMARY is the
+// reason, X-WR-CALNAME the name of the calendar.
+func ParseCalendarICS(data []byte) (Calendar, error) {
+	var (
+		name       string
+		days       []CalendarDay
+		event      map[string]string
+		inCalendar bool
+	)
+
+	for _, line := range unfoldICS(data) {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, _, _ = strings.Cut(key, ";")
+		key = strings.ToUpper(key)
+
+		switch {
+		case key == "BEGIN" && strings.EqualFold(value, "VCALENDAR"):
+			inCalendar = true
+		case key == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			event = make(map[string]string)
+		case key == "END" && strings.EqualFold(value, "VEVENT"):
+			if event == nil {
+				return Calendar{}, ErrInvalidCalendar
+			}
+
+			eventDays, err := icsEventDays(event)
+			if err != nil {
+				return Calendar{}, err
+			}
+			days = append(days, eventDays...)
+			if len(days) > MaxCalendarDays {
+				return Calendar{}, ErrInvalidCalendar
+			}
+			event = nil
+		case event != nil:
+			event[key] = value
+		case key == "X-WR-CALNAME":
+			name = unescapeICS(value)
+		}
+	}
+
+	if !inCalendar || event != nil {
+		return Calendar{}, ErrInvalidCalendar
+	}
+
+	return newCalendar(name, days)
+}
+
+func icsEventDays(event map[string]string) ([]CalendarDay, error) {
+	start, err := parseICSDate(event["DTSTART"])
+	if err != nil {
+		return nil, err
+	}
+
+	end := start.AddDate(0, 0, 1)
+	if value, ok := event["DTEND"]; ok {
+		end, err = parseICSDate(value)
+		if err != nil || !end.After(start) {
+			return nil, ErrInvalidCalendar
+		}
+	}
+
+	dayOff := true
+	for _, category := range strings.Split(event["CATEGORIES"], ",") {
+		if strings.EqualFold(strings.TrimSpace(category), "WORKDAY") {
+			dayOff = false
+		}
+	}
+
+	var days []CalendarDay
+	for date := start; date.Before(end); date = date.AddDate(0, 0, 1) {
+		if len(days) == MaxCalendarDays {
+			return nil, ErrInvalidCalendar
+		}
+
+		days = append(days, CalendarDay{
+			Date:   date.Format(Format),
+			DayOff: dayOff,
+			Reason: unescapeICS(event["SUMMARY"]),
+		})
+	}
+
+	return days, nil
+}
+
+// parseICSDate reads a DATE value, or the date part of a DATE-TIME one.
+func parseICSDate(value string) (time.Time, error) {
+	if len(value) < len(icsDateFormat) {
+		return time.Time{}, ErrInvalidCalendar
+	}
+
+	date, err := time.Parse(icsDateFormat, value[:len(icsDateFormat)])
+	if err != nil {
+		return time.Time{}, ErrInvalidCalendar
+	}
+
+	return date, nil
+}
+
+// unfoldICS splits the content into lines, joining the folded ones.
+func unfoldICS(data []byte) []string {
+	var lines []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if len(lines) > 0 && line != "" && (line[0] == ' ' || line[0] == '\t') {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+var icsEscapes = strings.NewReplacer(`\\`, `\`, `\;`, `;`, `\,`, `,`, `\n`, " ", `\N`, " ")
+
+func unescapeICS(value string) string {
+	return strings.TrimSpace(icsEscapes.Replace(value))
+}
+
+// newCalendar validates the days and sorts them by date. When a date is
+// listed more than once, the last entry wins.
+func newCalendar(name string, days []CalendarDay) (Calendar, error) {
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > MaxCalendarNameLength || len(days) > MaxCalendarDays {
+		return Calendar{}, ErrInvalidCalendar
+	}
+
+	byDate := make(map[string]CalendarDay, len(days))
+	for _, day := range days {
+		if _, err := time.Parse(Format, day.Date); err != nil {
+			return Calendar{}, ErrInvalidCalendar
+		}
+
+		if utf8.RuneCountInString(day.Reason) > MaxCalendarNameLength {
+			return Calendar{}, ErrInvalidCalendar
+		}
+
+		byDate[day.Date] = day
+	}
+
+	calendar := Calendar{
+		Name: name,
+		Days: make([]CalendarDay, 0, len(byDate)),
+	}
+	for _, day := range byDate {
+		calendar.Days = append(calendar.Days, day)
+	}
+	sort.Slice(calendar.Days, func(i, j int) bool {
+		return calendar.Days[i].Date < calendar.Days[j].Date
+	})
+
+	return calendar, nil
+}
@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendar_DayOff(t *testing.T) {
+	calendar, err := ParseCalendarJSON([]byte(`{
+		"name": "Test",
+		"days": [
+			{"date": "2030-03-08", "day_off": true, "reason": "Международный женский день"},
+			{"date": "2030-03-09", "day_off": false, "reason": "Перенос"},
+			{"date": "2030-03-11", "day_off": true}
+		]
+	}`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		date   string
+		dayOff bool
+		reason string
+	}{
+		{date: "2030-03-07", dayOff: false},
+		{date: "2030-03-08", dayOff: true, reason: "Международный женский день"},
+		{date: "2030-03-09", dayOff: false},
+		{date: "2030-03-10", dayOff: true, reason: DayOffWeekend},
+		{date: "2030-03-11", dayOff: true, reason: DayOffHoliday},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.date, func(t *testing.T) {
+			date, err := time.Parse(Format, tc.date)
+			require.NoError(t, err)
+
+			dayOff, reason := calendar.DayOff(date)
+			require.Equal(t, tc.dayOff, dayOff)
+			require.Equal(t, tc.reason, reason)
+		})
+	}
+
+	dayOff, reason := Calendar{}.DayOff(time.Date(2030, time.March, 9, 0, 0, 0, 0, time.UTC))
+	require.True(t, dayOff)
+	require.Equal(t, DayOffWeekend, reason)
+}
+
+func TestParseCalendarJSON(t *testing.T) {
+	calendar, err := ParseCalendarJSON([]byte(`{"name": " KZ ", "days": [
+		{"date": "2030-03-22", "day_off": true, "reason": "Наурыз"},
+		{"date": "2030-03-21", "day_off": true, "reason": "Наурыз"},
+		{"date": "2030-03-22", "day_off": true, "reason": "Наурыз мейрамы"}
+	]}`))
+	require.NoError(t, err)
+	require.Equal(t, Calendar{
+		Name: "KZ",
+		Days: []CalendarDay{
+			{Date: "2030-03-21", DayOff: true, Reason: "Наурыз"},
+			{Date: "2030-03-22", DayOff: true, Reason: "Наурыз мейрамы"},
+		},
+	}, calendar)
+
+	for _, data := range []string{
+		`not json`,
+		`{"days": [{"date": "22.03.2030", "day_off": true}]}`,
+		`{"days": [{"day_off": true}]}`,
+	} {
+		_, err := ParseCalendarJSON([]byte(data))
+		require.ErrorIs(t, err, ErrInvalidCalendar, data)
+	}
+}
+
+func TestParseCalendarICS(t *testing.T) {
+	calendar, err := ParseCalendarICS([]byte("BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"X-WR-CALNAME:Production calendar\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"DTSTART;VALUE=DATE:20300321\r\n" +
+		"DTEND;VALUE=DATE:20300324\r\n" +
+		"SUMMARY:Наурыз\r\n" +
+		"  мейрамы\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"DTSTART:20300330T000000Z\r\n" +
+		"SUMMARY:Transfer\\, working Saturday\r\n" +
+		"CATEGORIES:HOLIDAYS,WORKDAY\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"))
+	require.NoError(t, err)
+	require.Equal(t, Calendar{
+		Name: "Production calendar",
+		Days: []CalendarDay{
+			{Date: "2030-03-21", DayOff: true, Reason: "Наурыз мейрамы"},
+			{Date: "2030-03-22", DayOff: true, Reason: "Наурыз мейрамы"},
+			{Date: "2030-03-23", DayOff: true, Reason: "Наурыз мейрамы"},
+			{Date: "2030-03-30", DayOff: false, Reason: "Transfer, working Saturday"},
+		},
+	}, calendar)
+
+	for name, data := range map[string]string{
+		"not a calendar": "BEGIN:VEVENT\nDTSTART:20300321\nEND:VEVENT\n",
+		"no start":       "BEGIN:VCALENDAR\nBEGIN:VEVENT\nSUMMARY:x\nEND:VEVENT\nEND:VCALENDAR\n",
+		"end before":     "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:20300321\nDTEND:20300321\nEND:VEVENT\nEND:VCALENDAR\n",
+		"unterminated":   "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:20300321\n",
+		"too long":       "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:20300101\nDTEND:20400101\nEND:VEVENT\nEND:VCALENDAR\n",
+	} {
+		_, err := ParseCalendarICS([]byte(data))
+		require.ErrorIs(t, err, ErrInvalidCalendar, name)
+	}
+}
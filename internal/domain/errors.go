@@ -1,20 +1,81 @@
 package domain
 
-import "errors"
+import "time"
+
+// Error is an error reported to the client. ID is the stable key of its
+// message in the translation catalogs, Error returns the English text.
+type Error struct {
+	ID      string
+	message string
+}
+
+func newError(id string, message string) *Error {
+	return &Error{ID: id, message: message}
+}
+
+func (e *Error) Error() string {
+	return e.message
+}
+
+// RetryError is an error the client can retry after RetryAfter.
+type RetryError struct {
+	Err        *Error
+	RetryAfter time.Duration
+}
+
+func (e *RetryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
 
 var (
-	ErrInvalidRequest        = errors.New("invalid request")
-	ErrEmailAlreadyExists    = errors.New("email already exists")
-	ErrNotFound              = errors.New("not found")
-	ErrInternalServer        = errors.New("internal server")
-	ErrIncorrectDateFormat   = errors.New("incorrect date format")
-	ErrHeaderLength          = errors.New("header length exceeds 200 characters")
-	ErrTitleAlreadyExists    = errors.New("title already exists")
-	ErrIncorrectEmailAddress = errors.New("incorrect email address")
-	ErrIncorrectUserName     = errors.New("incorrect username")
-	ErrIncorrectPassword     = errors.New("incorrect password")
-	ErrInvalidTitle          = errors.New("invalid empty title")
-	ErrInvalidAuthHeader     = errors.New("invalid auth header")
-	ErrTodoInvalidId         = errors.New("invalid todo id")
-	ErrTodoActiveAtData      = errors.New("active date has already passed")
+	ErrInvalidRequest        = newError("invalid_request", "invalid request")
+	ErrEmailAlreadyExists    = newError("email_already_exists", "email already exists")
+	ErrNotFound              = newError("not_found", "not found")
+	ErrInternalServer        = newError("internal_server", "internal server")
+	ErrIncorrectDateFormat   = newError("incorrect_date_format", "incorrect date format")
+	ErrHeaderLength          = newError("title_too_long", "header length exceeds 200 characters")
+	ErrTitleAlreadyExists    = newError("title_already_exists", "title already exists")
+	ErrIncorrectEmailAddress = newError("incorrect_email_address", "incorrect email address")
+	ErrIncorrectUserName     = newError("incorrect_username", "incorrect username")
+	ErrIncorrectPassword     = newError("incorrect_password", "incorrect password")
+	ErrInvalidTitle          = newError("invalid_title", "invalid empty title")
+	ErrInvalidAuthHeader     = newError("invalid_auth_header", "invalid auth header")
+	ErrEmptyAuthHeader       = newError("empty_auth_header", "empty auth header")
+	ErrEmptyToken            = newError("empty_token", "token is empty")
+	ErrTokenRevoked          = newError("token_revoked", "token has been revoked")
+	ErrInsufficientScope     = newError("insufficient_scope", "token does not allow this operation")
+	ErrInvalidScope          = newError("invalid_scope", "scopes must be todos:read, todos:write, calendars:read or calendars:write")
+	ErrInvalidTokenName      = newError("invalid_token_name", "token name must be 1 to 100 characters long")
+	ErrInvalidExpiry         = newError("invalid_expiry", "expiry must be in the future")
+	ErrInvalidMFAToken       = newError("invalid_mfa_token", "sign-in has expired, sign in again")
+	ErrInvalidMFACode        = newError("invalid_mfa_code", "invalid two-factor authentication code")
+	ErrMFANotEnrolled        = newError("mfa_not_enrolled", "two-factor authentication is not set up")
+	ErrMFAAlreadyEnabled     = newError("mfa_already_enabled", "two-factor authentication is already enabled")
+	ErrMFARequired           = newError("mfa_required", "two-factor authentication is required for your organization")
+	ErrInvalidResetToken     = newError("invalid_reset_token", "password reset link is invalid or has expired")
+	ErrInvalidVerifyToken    = newError("invalid_verification_token", "email verification link is invalid or has expired")
+	ErrEmailAlreadyVerified  = newError("email_already_verified", "email is already verified")
+	ErrEmailNotVerified      = newError("email_not_verified", "verify your email to create more todos")
+	ErrTooManyAttempts       = newError("too_many_attempts", "too many failed sign-in attempts, try again later")
+	ErrTodoInvalidId         = newError("invalid_todo_id", "invalid todo id")
+	ErrTodoActiveAtData      = newError("active_date_passed", "active date has already passed")
+	ErrForbidden             = newError("forbidden", "forbidden")
+	ErrInvalidCursor         = newError("invalid_cursor", "invalid cursor")
+	ErrInvalidFilter         = newError("invalid_filter", "invalid filter")
+	ErrInvalidSearch         = newError("invalid_search", "search query has no words to match")
+	ErrDescriptionLength     = newError("description_too_long", "description exceeds 5000 characters")
+	ErrInvalidPriority       = newError("invalid_priority", "priority must be low, medium or high")
+	ErrInvalidTag            = newError("invalid_tag", "tags must be up to 32 letters, digits, '-' or '_'")
+	ErrTooManyTags           = newError("too_many_tags", "no more than 10 tags are allowed")
+	ErrTodoDueBeforeActive   = newError("due_before_active", "due time is before the active date")
+	ErrTooManyItems          = newError("too_many_items", "no more than 100 items are allowed")
+	ErrInvalidItemsOrder     = newError("invalid_items_order", "order must list every item of the todo once")
+	ErrInvalidRecurrence     = newError("invalid_recurrence", "recurrence must be an RRULE with FREQ of DAILY, WEEKLY or MONTHLY")
+	ErrInvalidTimezone       = newError("invalid_timezone", "timezone must be an IANA time zone name")
+	ErrInvalidCalendar       = newError("invalid_calendar", "calendar must be an iCalendar or JSON file of up to 2000 dated days")
+	ErrInvalidLanguage       = newError("invalid_language", "language must be ru, kk or en")
 )
@@ -0,0 +1,49 @@
+package domain
+
+// MFA is the TOTP two-factor authentication of a user. Secret is set on
+// enrollment, the MFA is Enabled once the user confirms it with a code.
+// LastStep is the time step of the last accepted code, so that a code is
+// accepted once. RecoveryCodes are the hashes of the unused recovery codes.
+type MFA struct {
+	UserID        string
+	Secret        string
+	Enabled       bool
+	LastStep      int64
+	RecoveryCodes []string
+}
+
+// MFAStatus is the two-factor authentication of a user as shown to them.
+// Required is set for the users of an organization that enforces it.
+type MFAStatus struct {
+	Enabled           bool `json:"enabled"`
+	Required          bool `json:"required"`
+	RecoveryCodesLeft int  `json:"recovery_codes_left"`
+}
+
+// MFAEnrollment is the secret of an authenticator app and its otpauth URI,
+// shown once as a QR code.
+type MFAEnrollment struct {
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	URI    string `json:"uri" example:"otpauth://totp/Region%20Todo:user@example.com?secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+}
+
+// MFACode is a code of the authenticator app or, where it is accepted, a
+// recovery code.
+type MFACode struct {
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+type MFASignInRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required" example:"123456"`
+	Device   string `json:"device" example:"iPhone"`
+}
+
+type MFAEnrollRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+// RecoveryCodes are shown once, when they are generated.
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"k7f2m-9xq4p"`
+}
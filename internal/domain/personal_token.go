@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+// PersonalToken is a long-lived access token a user creates for scripts and
+// CI instead of signing in with the password. The token itself is shown once,
+// when it is created, only TokenHash is stored. A token without ExpiresAt is
+// valid until it is revoked.
+type PersonalToken struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"-"`
+	TokenHash  string     `json:"-"`
+	Name       string     `json:"name" example:"CI"`
+	Scopes     []string   `json:"scopes" example:"todos:read"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+type PersonalTokenRequest struct {
+	Name      string     `json:"name" binding:"required" example:"CI"`
+	Scopes    []string   `json:"scopes" binding:"required" example:"todos:read"`
+	ExpiresAt *time.Time `json:"expires_at" format:"date-time"`
+}
+
+// NewPersonalToken is a personal token just created, along with the token
+// itself.
+type NewPersonalToken struct {
+	PersonalToken
+	Token string `json:"token" example:"rlp_..."`
+}
+
+type PersonalTokenURI struct {
+	ID string `uri:"id" binding:"required"`
+}
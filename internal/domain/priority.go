@@ -0,0 +1,13 @@
+package domain
+
+const (
+	PriorityLow    = "low"
+	PriorityMedium = "medium"
+	PriorityHigh   = "high"
+)
+
+const (
+	MaxDescriptionLength = 5000
+	MaxTags              = 10
+	MaxTagLength         = 32
+)
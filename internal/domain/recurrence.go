@@ -0,0 +1,332 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+
+	untilFormat = "20060102"
+)
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+var weekdayCodes = map[time.Weekday]string{
+	time.Monday:    "MO",
+	time.Tuesday:   "TU",
+	time.Wednesday: "WE",
+	time.Thursday:  "TH",
+	time.Friday:    "FR",
+	time.Saturday:  "SA",
+	time.Sunday:    "SU",
+}
+
+// WeekdayNum is a BYDAY value. N is zero for every such weekday of the
+// period, otherwise the ordinal of the weekday within the month, negative
+// when counted from the end: 1MO is the first Monday, -1FR the last Friday.
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+// Recurrence is the subset of an RFC 5545 RRULE todos repeat by:
+//
+//	FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;UNTIL=20301231
+//	FREQ=MONTHLY;BYDAY=-1FR;COUNT=12
+//
+// Weeks start on Monday. Until is a date in Format, empty when unbounded.
+// Count limits the number of occurrences, the first one included.
+type Recurrence struct {
+	Freq     string
+	Interval int
+	ByDay    []WeekdayNum
+	Until    string
+	Count    int
+}
+
+// ParseRecurrence parses an RRULE value, with or without the "RRULE:" prefix.
+func ParseRecurrence(rule string) (Recurrence, error) {
+	rule = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(rule)), "RRULE:")
+
+	r := Recurrence{Interval: 1}
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(rule, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" || seen[key] {
+			return Recurrence{}, ErrInvalidRecurrence
+		}
+		seen[key] = true
+
+		var err error
+		switch key {
+		case "FREQ":
+			r.Freq = value
+			if value != FreqDaily && value != FreqWeekly && value != FreqMonthly {
+				err = ErrInvalidRecurrence
+			}
+		case "INTERVAL":
+			r.Interval, err = parsePositive(value)
+		case "COUNT":
+			r.Count, err = parsePositive(value)
+		case "UNTIL":
+			r.Until, err = parseUntil(value)
+		case "BYDAY":
+			r.ByDay, err = parseByDay(value)
+		default:
+			err = ErrInvalidRecurrence
+		}
+
+		if err != nil {
+			return Recurrence{}, err
+		}
+	}
+
+	if r.Freq == "" || (r.Count > 0 && r.Until != "") {
+		return Recurrence{}, ErrInvalidRecurrence
+	}
+
+	for _, day := range r.ByDay {
+		if day.N != 0 && r.Freq != FreqMonthly {
+			return Recurrence{}, ErrInvalidRecurrence
+		}
+	}
+
+	return r, nil
+}
+
+// String renders the rule in a canonical form, the one todos are stored with.
+func (r Recurrence) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+
+	if r.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.Interval))
+	}
+
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			days[i] = weekdayCodes[day.Day]
+			if day.N != 0 {
+				days[i] = strconv.Itoa(day.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+
+	if r.Until != "" {
+		until, _ := time.Parse(Format, r.Until)
+		parts = append(parts, "UNTIL="+until.Format(untilFormat))
+	}
+
+	if r.Count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", r.Count))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// Next returns the date of the occurrence that follows the given one, which
+// is the occurrence-th of the series. It reports false once the series has
+// ended by Count or Until.
+func (r Recurrence) Next(date time.Time, occurrence int) (time.Time, bool) {
+	if r.Count > 0 && occurrence >= r.Count {
+		return time.Time{}, false
+	}
+
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	var (
+		next time.Time
+		ok   bool
+	)
+	switch r.Freq {
+	case FreqDaily:
+		next, ok = r.nextDaily(date, interval)
+	case FreqWeekly:
+		next, ok = r.nextWeekly(date, interval)
+	case FreqMonthly:
+		next, ok = r.nextMonthly(date, interval)
+	}
+
+	if !ok || (r.Until != "" && next.Format(Format) > r.Until) {
+		return time.Time{}, false
+	}
+
+	return next, true
+}
+
+func (r Recurrence) nextDaily(date time.Time, interval int) (time.Time, bool) {
+	// Weekdays repeat every seven steps, so a day that is not found by then
+	// never comes.
+	for i := 1; i <= 7; i++ {
+		next := date.AddDate(0, 0, i*interval)
+		if len(r.ByDay) == 0 || r.onDay(next) {
+			return next, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+func (r Recurrence) nextWeekly(date time.Time, interval int) (time.Time, bool) {
+	if len(r.ByDay) == 0 {
+		return date.AddDate(0, 0, 7*interval), true
+	}
+
+	monday := date.AddDate(0, 0, -((int(date.Weekday()) + 6) % 7))
+	for next := date.AddDate(0, 0, 1); next.Before(monday.AddDate(0, 0, 7)); next = next.AddDate(0, 0, 1) {
+		if r.onDay(next) {
+			return next, true
+		}
+	}
+
+	monday = monday.AddDate(0, 0, 7*interval)
+	for i := 0; i < 7; i++ {
+		if next := monday.AddDate(0, 0, i); r.onDay(next) {
+			return next, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+func (r Recurrence) nextMonthly(date time.Time, interval int) (time.Time, bool) {
+	first := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+
+	for _, day := range r.monthDays(first, date.Day()) {
+		if day.After(date) {
+			return day, true
+		}
+	}
+
+	// A month may lack the day, the 31st or the fifth Monday, so look a few
+	// years ahead before giving up.
+	for i := 1; i <= 60; i++ {
+		if days := r.monthDays(first.AddDate(0, i*interval, 0), date.Day()); len(days) > 0 {
+			return days[0], true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// monthDays returns the days of the month starting at first the rule falls
+// on, in order. Without BYDAY that is the day of the month of the series.
+func (r Recurrence) monthDays(first time.Time, dayOfMonth int) []time.Time {
+	last := first.AddDate(0, 1, -1)
+
+	if len(r.ByDay) == 0 {
+		if dayOfMonth > last.Day() {
+			return nil
+		}
+		return []time.Time{first.AddDate(0, 0, dayOfMonth-1)}
+	}
+
+	var days []time.Time
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		for _, wd := range r.ByDay {
+			if wd.Day != day.Weekday() {
+				continue
+			}
+
+			fromStart, fromEnd := (day.Day()-1)/7+1, -((last.Day()-day.Day())/7 + 1)
+			if wd.N == 0 || wd.N == fromStart || wd.N == fromEnd {
+				days = append(days, day)
+				break
+			}
+		}
+	}
+
+	return days
+}
+
+func (r Recurrence) onDay(date time.Time) bool {
+	for _, day := range r.ByDay {
+		if day.Day == date.Weekday() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parsePositive(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > 1000 {
+		return 0, ErrInvalidRecurrence
+	}
+
+	return n, nil
+}
+
+func parseUntil(value string) (string, error) {
+	if len(value) > len(untilFormat) {
+		// Date-time form, 20301231T235959Z: only the date matters for todos.
+		if _, err := time.Parse("20060102T150405Z", value); err != nil {
+			return "", ErrInvalidRecurrence
+		}
+		value = value[:len(untilFormat)]
+	}
+
+	until, err := time.Parse(untilFormat, value)
+	if err != nil {
+		return "", ErrInvalidRecurrence
+	}
+
+	return until.Format(Format), nil
+}
+
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+	seen := make(map[WeekdayNum]bool)
+
+	for _, item := range strings.Split(value, ",") {
+		if len(item) < 2 {
+			return nil, ErrInvalidRecurrence
+		}
+
+		day, ok := weekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, ErrInvalidRecurrence
+		}
+
+		wd := WeekdayNum{Day: day}
+		if prefix := item[:len(item)-2]; prefix != "" {
+			n, err := strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -5 || n > 5 {
+				return nil, ErrInvalidRecurrence
+			}
+			wd.N = n
+		}
+
+		if !seen[wd] {
+			seen[wd] = true
+			days = append(days, wd)
+		}
+	}
+
+	sort.SliceStable(days, func(i, j int) bool {
+		return (days[i].Day+6)%7 < (days[j].Day+6)%7
+	})
+
+	return days, nil
+}
@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+		err   error
+	}{
+		{name: "daily", input: "FREQ=DAILY", want: "FREQ=DAILY"},
+		{name: "prefix and case", input: " rrule:freq=weekly;interval=1;byday=we,mo ", want: "FREQ=WEEKLY;BYDAY=MO,WE"},
+		{name: "interval and count", input: "FREQ=DAILY;INTERVAL=2;COUNT=5", want: "FREQ=DAILY;INTERVAL=2;COUNT=5"},
+		{name: "until date-time", input: "FREQ=WEEKLY;UNTIL=20301231T235959Z", want: "FREQ=WEEKLY;UNTIL=20301231"},
+		{name: "monthly ordinals", input: "FREQ=MONTHLY;BYDAY=-1FR,1MO", want: "FREQ=MONTHLY;BYDAY=1MO,-1FR"},
+		{name: "empty", input: "", err: ErrInvalidRecurrence},
+		{name: "no freq", input: "INTERVAL=2", err: ErrInvalidRecurrence},
+		{name: "yearly", input: "FREQ=YEARLY", err: ErrInvalidRecurrence},
+		{name: "zero interval", input: "FREQ=DAILY;INTERVAL=0", err: ErrInvalidRecurrence},
+		{name: "count and until", input: "FREQ=DAILY;COUNT=2;UNTIL=20301231", err: ErrInvalidRecurrence},
+		{name: "unknown day", input: "FREQ=WEEKLY;BYDAY=XX", err: ErrInvalidRecurrence},
+		{name: "ordinal in weekly", input: "FREQ=WEEKLY;BYDAY=1MO", err: ErrInvalidRecurrence},
+		{name: "unsupported part", input: "FREQ=DAILY;BYHOUR=9", err: ErrInvalidRecurrence},
+		{name: "repeated part", input: "FREQ=DAILY;FREQ=WEEKLY", err: ErrInvalidRecurrence},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, err := ParseRecurrence(tc.input)
+			require.ErrorIs(t, err, tc.err)
+			if tc.err == nil {
+				require.Equal(t, tc.want, rule.String())
+			}
+		})
+	}
+}
+
+func TestRecurrence_Next(t *testing.T) {
+	tests := []struct {
+		name       string
+		rule       string
+		date       string
+		occurrence int
+		want       []string
+		ends       bool
+	}{
+		{
+			name: "daily",
+			rule: "FREQ=DAILY;INTERVAL=2",
+			date: "2030-01-30",
+			want: []string{"2030-02-01", "2030-02-03"},
+		},
+		{
+			name: "daily on weekdays",
+			rule: "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR",
+			date: "2030-01-03", // Thursday
+			want: []string{"2030-01-04", "2030-01-07", "2030-01-08"},
+		},
+		{
+			name: "weekly",
+			rule: "FREQ=WEEKLY",
+			date: "2030-01-07",
+			want: []string{"2030-01-14", "2030-01-21"},
+		},
+		{
+			name: "every other week on monday and wednesday",
+			rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE",
+			date: "2030-01-07", // Monday
+			want: []string{"2030-01-09", "2030-01-21", "2030-01-23", "2030-02-04"},
+		},
+		{
+			name: "monthly skips short months",
+			rule: "FREQ=MONTHLY",
+			date: "2030-01-31",
+			want: []string{"2030-03-31", "2030-05-31"},
+		},
+		{
+			name: "last friday of the month",
+			rule: "FREQ=MONTHLY;BYDAY=-1FR",
+			date: "2030-01-25",
+			want: []string{"2030-02-22", "2030-03-29"},
+		},
+		{
+			name: "first monday every quarter",
+			rule: "FREQ=MONTHLY;INTERVAL=3;BYDAY=1MO",
+			date: "2030-01-07",
+			want: []string{"2030-04-01", "2030-07-01"},
+		},
+		{
+			name: "until",
+			rule: "FREQ=WEEKLY;UNTIL=20300121",
+			date: "2030-01-07",
+			want: []string{"2030-01-14", "2030-01-21"},
+			ends: true,
+		},
+		{
+			name:       "count",
+			rule:       "FREQ=DAILY;COUNT=3",
+			date:       "2030-01-01",
+			occurrence: 1,
+			want:       []string{"2030-01-02", "2030-01-03"},
+			ends:       true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, err := ParseRecurrence(tc.rule)
+			require.NoError(t, err)
+
+			date, err := time.Parse(Format, tc.date)
+			require.NoError(t, err)
+
+			var got []string
+			occurrence := tc.occurrence
+			for len(got) < len(tc.want) {
+				next, ok := rule.Next(date, occurrence)
+				require.True(t, ok)
+				got = append(got, next.Format(Format))
+				date, occurrence = next, occurrence+1
+			}
+			require.Equal(t, tc.want, got)
+
+			_, ok := rule.Next(date, occurrence)
+			require.Equal(t, tc.ends, !ok)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package domain
+
+// Scopes of access tokens, each allows a group of operations. Reading an
+// object is allowed by its read scope, changing it by its write scope.
+// ScopeAccount allows managing the sessions and the settings of the user.
+const (
+	ScopeTodosRead      = "todos:read"
+	ScopeTodosWrite     = "todos:write"
+	ScopeCalendarsRead  = "calendars:read"
+	ScopeCalendarsWrite = "calendars:write"
+	ScopeAccount        = "account"
+)
+
+// UserScopes are granted to the tokens of a user who has signed in.
+var UserScopes = []string{
+	ScopeTodosRead,
+	ScopeTodosWrite,
+	ScopeCalendarsRead,
+	ScopeCalendarsWrite,
+	ScopeAccount,
+}
+
+// PersonalTokenScopes may be granted to personal tokens. ScopeAccount is not
+// among them, so that a leaked token can neither create other tokens nor end
+// the sessions of the user.
+var PersonalTokenScopes = []string{
+	ScopeTodosRead,
+	ScopeTodosWrite,
+	ScopeCalendarsRead,
+	ScopeCalendarsWrite,
+}
+
+// Principal is the authenticated caller of a request: the user, the session
+// of the access token and the scopes it allows. A request with a personal
+// token has no session.
+type Principal struct {
+	UserID    string
+	SessionID string
+	Scopes    []string
+}
+
+// HasScope reports whether the principal is allowed the scope.
+func (p Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,180 @@
+package domain
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+
+	// Matches in the title weigh more than matches in the description.
+	titleWeight       = 3
+	descriptionWeight = 1
+)
+
+type SearchRequest struct {
+	Query string `form:"q"`
+	Limit int    `form:"limit"`
+}
+
+// SearchQuery is a parsed search string. Every term and phrase must be
+// present and none of the excluded ones may be, words are compared case
+// insensitively as a whole:
+//
+//	milk "buy bread" -coffee -"call mom"
+type SearchQuery struct {
+	Terms    []string
+	Phrases  [][]string
+	Excluded [][]string
+}
+
+type SearchResult struct {
+	Todo
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// ParseSearchQuery splits the search string into terms, quoted phrases and
+// exclusions prefixed with a minus. It fails when nothing is left to match.
+func ParseSearchQuery(s string) (SearchQuery, error) {
+	var query SearchQuery
+
+	for len(s) > 0 {
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+		if s == "" {
+			break
+		}
+
+		excluded := false
+		if s[0] == '-' {
+			excluded = true
+			s = s[1:]
+		}
+
+		var chunk string
+		quoted := len(s) > 0 && s[0] == '"'
+		if quoted {
+			s = s[1:]
+			end := strings.IndexByte(s, '"')
+			if end < 0 {
+				chunk, s = s, ""
+			} else {
+				chunk, s = s[:end], s[end+1:]
+			}
+		} else {
+			end := strings.IndexFunc(s, unicode.IsSpace)
+			if end < 0 {
+				end = len(s)
+			}
+			chunk, s = s[:end], s[end:]
+		}
+
+		words := Words(chunk)
+		switch {
+		case len(words) == 0:
+		case excluded:
+			query.Excluded = append(query.Excluded, words)
+		case quoted && len(words) > 1:
+			query.Phrases = append(query.Phrases, words)
+		default:
+			query.Terms = append(query.Terms, words...)
+		}
+	}
+
+	if len(query.Terms) == 0 && len(query.Phrases) == 0 {
+		return SearchQuery{}, ErrInvalidSearch
+	}
+
+	return query, nil
+}
+
+// String renders the query back in a form understood by Mongo $text and
+// Postgres websearch_to_tsquery. Single terms are quoted as well, so that
+// Mongo requires all of them instead of any.
+func (q SearchQuery) String() string {
+	var parts []string
+
+	for _, term := range q.Terms {
+		parts = append(parts, `"`+term+`"`)
+	}
+
+	for _, phrase := range q.Phrases {
+		parts = append(parts, `"`+strings.Join(phrase, " ")+`"`)
+	}
+
+	for _, excluded := range q.Excluded {
+		if len(excluded) == 1 {
+			parts = append(parts, "-"+excluded[0])
+		} else {
+			parts = append(parts, `-"`+strings.Join(excluded, " ")+`"`)
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Positive returns the terms and phrases a matching todo contains.
+func (q SearchQuery) Positive() [][]string {
+	positive := make([][]string, 0, len(q.Terms)+len(q.Phrases))
+	for _, term := range q.Terms {
+		positive = append(positive, []string{term})
+	}
+
+	return append(positive, q.Phrases...)
+}
+
+// Match reports whether the todo satisfies the query and scores it by the
+// number of occurrences, weighted by the field they were found in. It is
+// used by backends without a full-text index of their own.
+func (q SearchQuery) Match(todo Todo) (float64, bool) {
+	title, description := Words(todo.Title), Words(todo.Description)
+
+	for _, excluded := range q.Excluded {
+		if count(title, excluded) > 0 || count(description, excluded) > 0 {
+			return 0, false
+		}
+	}
+
+	var score float64
+	for _, words := range q.Positive() {
+		inTitle, inDescription := count(title, words), count(description, words)
+		if inTitle == 0 && inDescription == 0 {
+			return 0, false
+		}
+
+		score += float64(inTitle*titleWeight + inDescription*descriptionWeight)
+	}
+
+	return score, true
+}
+
+// Words splits text into lowercase words of letters and digits.
+func Words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// count returns how many times the sequence of words occurs in text.
+func count(text []string, words []string) int {
+	n := 0
+	for i := 0; i+len(words) <= len(text); i++ {
+		if equal(text[i:i+len(words)], words) {
+			n++
+		}
+	}
+
+	return n
+}
+
+func equal(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
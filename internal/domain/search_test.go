@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  SearchQuery
+		err   error
+	}{
+		{
+			name:  "terms",
+			input: "  Buy MILK ",
+			want:  SearchQuery{Terms: []string{"buy", "milk"}},
+		},
+		{
+			name:  "phrase and exclusions",
+			input: `milk "buy  bread" -coffee -"call mom"`,
+			want: SearchQuery{
+				Terms:    []string{"milk"},
+				Phrases:  [][]string{{"buy", "bread"}},
+				Excluded: [][]string{{"coffee"}, {"call", "mom"}},
+			},
+		},
+		{
+			name:  "single word phrase is a term",
+			input: `"молоко"`,
+			want:  SearchQuery{Terms: []string{"молоко"}},
+		},
+		{
+			name:  "unterminated quote",
+			input: `"купить хлеб`,
+			want:  SearchQuery{Phrases: [][]string{{"купить", "хлеб"}}},
+		},
+		{
+			name:  "punctuation splits words",
+			input: "e-mail",
+			want:  SearchQuery{Terms: []string{"e", "mail"}},
+		},
+		{
+			name:  "only exclusions",
+			input: "-coffee",
+			err:   ErrInvalidSearch,
+		},
+		{
+			name:  "empty",
+			input: ` "" - `,
+			err:   ErrInvalidSearch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := ParseSearchQuery(tt.input)
+			require.Equal(t, tt.err, err)
+			require.Equal(t, tt.want, query)
+		})
+	}
+}
+
+func TestSearchQuery_String(t *testing.T) {
+	query, err := ParseSearchQuery(`milk "buy bread" -coffee -"call mom"`)
+	require.NoError(t, err)
+	require.Equal(t, `"milk" "buy bread" -coffee -"call mom"`, query.String())
+}
+
+func TestSearchQuery_Match(t *testing.T) {
+	todo := Todo{
+		Title:       "Купить молоко",
+		Description: "Молоко и хлеб, потом позвонить маме",
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		ok    bool
+		score float64
+	}{
+		{name: "title and description", input: "молоко", ok: true, score: titleWeight + descriptionWeight},
+		{name: "description only", input: "хлеб", ok: true, score: descriptionWeight},
+		{name: "all terms required", input: "молоко кофе", ok: false},
+		{name: "phrase", input: `"позвонить маме"`, ok: true, score: descriptionWeight},
+		{name: "phrase order", input: `"маме позвонить"`, ok: false},
+		{name: "whole words", input: "моло", ok: false},
+		{name: "excluded term", input: "молоко -хлеб", ok: false},
+		{name: "excluded phrase", input: `молоко -"купить молоко"`, ok: false},
+		{name: "excluded absent", input: "молоко -кофе", ok: true, score: titleWeight + descriptionWeight},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := ParseSearchQuery(tt.input)
+			require.NoError(t, err)
+
+			score, ok := query.Match(todo)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.score, score)
+		})
+	}
+}
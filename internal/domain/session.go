@@ -2,14 +2,46 @@ package domain
 
 import "time"
 
+// Session is a device a user has signed in from. A user has a session per
+// device, refreshing the tokens keeps the session and updates its last use.
+// Current marks the session of the access token of the request.
+//
+// The refresh tokens of a session form a family: every refresh replaces the
+// token, only RefreshTokenHash of the latest one is stored.
 type Session struct {
-	RefreshToken string    `bson:"refresh_token" json:"refresh_token"`
-	ExpirationAt time.Time `bson:"expiration_at" json:"expiration_at"`
+	ID               string    `json:"id"`
+	UserID           string    `json:"-"`
+	RefreshTokenHash string    `json:"-"`
+	Device           string    `json:"device,omitempty" example:"iPhone"`
+	UserAgent        string    `json:"user_agent,omitempty"`
+	IP               string    `json:"ip,omitempty" example:"203.0.113.7"`
+	CreatedAt        time.Time `json:"created_at"`
+	LastUsedAt       time.Time `json:"last_used_at"`
+	ExpirationAt     time.Time `json:"expiration_at"`
+	Current          bool      `json:"current"`
 }
 
+// Client describes the device a session is started or refreshed from.
+type Client struct {
+	Device    string
+	UserAgent string
+	IP        string
+}
+
+type SessionURI struct {
+	ID string `uri:"id" binding:"required"`
+}
+
+// Token is the result of signing in. A user with two-factor authentication
+// gets MFAToken instead of the tokens, to complete the sign-in with a code;
+// MFAEnrollment asks to set it up first. RecoveryCodes are shown once, when
+// the two-factor authentication is set up during the sign-in.
 type Token struct {
-	RefreshToken string `json:"refresh_token"`
-	AccessToken  string `json:"access_token"`
+	RefreshToken  string   `json:"refresh_token,omitempty"`
+	AccessToken   string   `json:"access_token,omitempty"`
+	MFAToken      string   `json:"mfa_token,omitempty"`
+	MFAEnrollment bool     `json:"mfa_enrollment,omitempty"`
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
 }
 
 type RefreshToken struct {
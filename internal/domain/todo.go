@@ -1,21 +1,44 @@
 package domain
 
-import (
-	"go.mongodb.org/mongo-driver/bson/primitive"
-)
+import "time"
 
+// Todo.Description is markdown and is returned as is, rendering is left to
+// the clients. Todo.Recurrence is an RRULE, see Recurrence; Occurrence is the
+// number of the todo in its series, starting from one. Todo.ActiveAt is the
+// instant the todo becomes active, midnight in the timezone of its owner
+// unless a time was given. IsDayOff tells whether that date is a day off in
+// the working calendar of the user, DayOffReason why, see Calendar.DayOff.
+// DayOffLabel is the reason in the language of the client.
 type Todo struct {
-	ID       primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	UserID   primitive.ObjectID `json:"user_id" bson:"user_id"`
-	Title    string             `json:"title" bson:"title"`
-	ActiveAt string             `json:"activeAt" bson:"activeAt"`
-	Author   string             `json:"author" bson:"author"`
-	Status   string             `json:"status" bson:"status"`
+	ID          string     `json:"id"`
+	UserID      string     `json:"user_id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Priority    string     `json:"priority" enums:"low,medium,high"`
+	Tags        []string   `json:"tags,omitempty"`
+	ActiveAt    time.Time  `json:"activeAt" format:"date-time"`
+	DueAt       *time.Time `json:"dueAt,omitempty" format:"date-time"`
+	Recurrence  string     `json:"recurrence,omitempty" example:"FREQ=WEEKLY;BYDAY=MO,WE"`
+	Occurrence  int        `json:"occurrence,omitempty"`
+	Author      string     `json:"author"`
+	Status      string     `json:"status"`
+	Progress    Progress   `json:"progress"`
+
+	IsDayOff     bool   `json:"is_day_off"`
+	DayOffReason string `json:"day_off_reason,omitempty" example:"weekend"`
+	DayOffLabel  string `json:"day_off_label,omitempty" example:"Выходной"`
 }
 
+// TodoRequest.ActiveAt is either a date in Format, read in the timezone of
+// the user, or an RFC 3339 timestamp.
 type TodoRequest struct {
-	Title    string `json:"title"`
-	ActiveAt string `json:"activeAt"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Priority    string     `json:"priority" enums:"low,medium,high" default:"medium"`
+	Tags        []string   `json:"tags"`
+	ActiveAt    string     `json:"activeAt" example:"2023-08-04"`
+	DueAt       *time.Time `json:"dueAt" format:"date-time"`
+	Recurrence  string     `json:"recurrence" example:"FREQ=WEEKLY;BYDAY=MO,WE"`
 }
 
 type TodoURI struct {
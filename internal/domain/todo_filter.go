@@ -0,0 +1,81 @@
+package domain
+
+import "time"
+
+const (
+	SortActiveAt = "activeAt"
+	SortTitle    = "title"
+
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+
+	DefaultTodoLimit = 20
+	MaxTodoLimit     = 100
+)
+
+// TodoListRequest holds the query parameters of the todo listing endpoint.
+type TodoListRequest struct {
+	Status     []string `form:"status"`
+	ActiveFrom string   `form:"active_from"`
+	ActiveTo   string   `form:"active_to"`
+	Title      string   `form:"title"`
+	Tag        []string `form:"tag"`
+	Priority   []string `form:"priority"`
+	Sort       string   `form:"sort"`
+	Order      string   `form:"order"`
+	Cursor     string   `form:"cursor"`
+	Limit      int      `form:"limit"`
+}
+
+// TodoFilter is a validated listing query passed down to the repositories.
+// ActiveFrom (inclusive) and ActiveBefore (exclusive) bound activeAt, zero
+// when unbounded. Title matches as a case-insensitive substring. A todo must
+// carry all of Tags and have one of Priorities.
+type TodoFilter struct {
+	UserID       string
+	Statuses     []string
+	ActiveFrom   time.Time
+	ActiveBefore time.Time
+	Title        string
+	Tags         []string
+	Priorities   []string
+	Sort         string
+	Order        string
+	After        *TodoCursor
+	Limit        int
+}
+
+// TodoCursor points at the last todo of the previous page: the value of the
+// sort field and the id that breaks ties between equal values.
+type TodoCursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+type TodoPage struct {
+	Items      []Todo `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      int64  `json:"total"`
+}
+
+// SortValue returns the value of the field the todos are sorted by, activeAt
+// in RFC 3339.
+func (f TodoFilter) SortValue(todo Todo) string {
+	if f.Sort == SortTitle {
+		return todo.Title
+	}
+
+	return todo.ActiveAt.UTC().Format(time.RFC3339Nano)
+}
+
+// ActiveAt returns the value of a cursor of the listing sorted by activeAt.
+func (c TodoCursor) ActiveAt() (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, c.Value)
+	if err != nil {
+		return time.Time{}, ErrInvalidCursor
+	}
+
+	return t, nil
+}
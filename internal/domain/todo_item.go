@@ -0,0 +1,32 @@
+package domain
+
+const MaxTodoItems = 100
+
+// TodoItem is a step of a todo. Items are listed by Position, ascending.
+type TodoItem struct {
+	ID       string `json:"id"`
+	TodoID   string `json:"todo_id"`
+	Title    string `json:"title"`
+	Done     bool   `json:"done"`
+	Position int    `json:"position"`
+}
+
+type TodoItemRequest struct {
+	Title string `json:"title"`
+}
+
+// TodoItemsOrder lists every item of a todo in the new order.
+type TodoItemsOrder struct {
+	IDs []string `json:"ids"`
+}
+
+type TodoItemURI struct {
+	ID     string `uri:"id" binding:"required"`
+	ItemID string `uri:"item_id" binding:"required"`
+}
+
+// Progress counts the done items of a todo out of all of them.
+type Progress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
@@ -1,25 +1,99 @@
 package domain
 
-import (
-	"go.mongodb.org/mongo-driver/bson/primitive"
-)
+import "time"
 
+const DefaultTimezone = "UTC"
+
+// User.Timezone is an IANA zone name, dates of the todos of the user are
+// evaluated in it. User.Calendar is the id of the working calendar of the
+// user, empty for the default one of the server. User.Language is the
+// language of the API messages, empty to follow the Accept-Language header.
+// User.EmailVerified is set once the user has opened the verification link
+// sent to the email. User.DeleteAt is when the account the user has deleted
+// is removed for good, signing in before then keeps it.
 type User struct {
-	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserName string             `bson:"username" json:"username"`
-	Email    string             `bson:"email" json:"email"`
-	Password string             `bson:"password" json:"-"`
-	Session  Session            `bson:"session,omitempty" json:"-"`
-	CreateAt string             `bson:"create_at" json:"create_at"`
+	ID            string     `json:"id"`
+	UserName      string     `json:"username"`
+	Email         string     `json:"email"`
+	EmailVerified bool       `json:"email_verified"`
+	Password      string     `json:"-"`
+	Timezone      string     `json:"timezone" example:"Asia/Almaty"`
+	Calendar      string     `json:"calendar,omitempty" example:"kz"`
+	Language      string     `json:"language,omitempty" example:"ru" enums:"ru,kk,en"`
+	CreateAt      string     `json:"create_at"`
+	DeleteAt      *time.Time `json:"delete_at,omitempty"`
 }
 
 type UserRequest struct {
 	UserName string `json:"username" binding:"required"`
 	Email    string `json:"email" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	Timezone string `json:"timezone" example:"Asia/Almaty" default:"UTC"`
+	Language string `json:"language" example:"ru" enums:"ru,kk,en"`
+}
+
+// UserUpdate changes the profile of the user, the fields left out stay as
+// they are.
+type UserUpdate struct {
+	UserName *string `json:"username"`
+	Timezone *string `json:"timezone" example:"Asia/Almaty"`
+	Language *string `json:"language" example:"ru" enums:"ru,kk,en"`
+}
+
+// PasswordChange.Device names the session started with the new password.
+type PasswordChange struct {
+	Password    string `json:"password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+	Device      string `json:"device" example:"iPhone"`
+}
+
+// AccountDeletion confirms the deletion of the account with the password.
+type AccountDeletion struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// LanguageSelection picks the language of the API messages for the user,
+// the one of the Accept-Language header when empty.
+type LanguageSelection struct {
+	Language string `json:"language" example:"kk" enums:"ru,kk,en"`
+}
+
+// Location returns the timezone of the user, UTC when it is not set.
+func (u User) Location() *time.Location {
+	loc, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+type PasswordResetRequest struct {
+	Email string `json:"email" binding:"required" example:"user@example.com"`
+}
+
+// PasswordReset sets a new password with the token of a reset link.
+type PasswordReset struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// EmailVerification verifies an email with the token of a verification link.
+type EmailVerification struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// EmailChange replaces the email of the user once the new one is verified,
+// the password confirms the change.
+type EmailChange struct {
+	Email    string `json:"email" binding:"required" example:"user@example.com"`
+	Password string `json:"password" binding:"required"`
 }
 
+// UserSignInRequest.Device names the session in the list of the sessions of
+// the user.
 type UserSignInRequest struct {
 	Email    string `json:"email" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	Device   string `json:"device" example:"iPhone"`
 }
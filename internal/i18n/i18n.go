@@ -0,0 +1,104 @@
+// Package i18n translates the messages of the API. Messages are looked up
+// by stable ids in the catalogs of locales/, one JSON file per language.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage is used when the client accepts none of the supported
+// languages, and for the ids missing from a catalog.
+const DefaultLanguage = "en"
+
+//go:embed locales/*.json
+var locales embed.FS
+
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]map[string]string {
+	entries, err := locales.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+
+	catalogs := make(map[string]map[string]string, len(entries))
+	for _, entry := range entries {
+		data, err := locales.ReadFile("locales/" + entry.Name())
+		if err != nil {
+			panic(err)
+		}
+
+		var catalog map[string]string
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			panic("i18n: " + entry.Name() + ": " + err.Error())
+		}
+
+		catalogs[strings.TrimSuffix(entry.Name(), path.Ext(entry.Name()))] = catalog
+	}
+
+	return catalogs
+}
+
+// Languages returns the supported languages in alphabetical order.
+func Languages() []string {
+	languages := make([]string, 0, len(catalogs))
+	for language := range catalogs {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+
+	return languages
+}
+
+func Supported(language string) bool {
+	_, ok := catalogs[language]
+	return ok
+}
+
+// Translate returns the message in the language. A message missing from its
+// catalog falls back to DefaultLanguage and then to the id itself.
+func Translate(language string, id string) string {
+	if message, ok := catalogs[language][id]; ok {
+		return message
+	}
+
+	if message, ok := catalogs[DefaultLanguage][id]; ok {
+		return message
+	}
+
+	return id
+}
+
+// Negotiate picks the supported language the client prefers most in an
+// Accept-Language header, such as "kk-KZ, ru;q=0.9, en;q=0.5". Regional
+// variants match their language.
+func Negotiate(acceptLanguage string) string {
+	best, bestQuality := DefaultLanguage, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		language, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if !Supported(language) {
+			continue
+		}
+
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = q
+		}
+
+		if quality > bestQuality {
+			best, bestQuality = language, quality
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,47 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header   string
+		language string
+	}{
+		{header: "", language: DefaultLanguage},
+		{header: "ru", language: "ru"},
+		{header: "kk-KZ,ru;q=0.9,en;q=0.8", language: "kk"},
+		{header: "de-DE, ru-RU;q=0.7, en;q=0.8", language: "en"},
+		{header: "en;q=0.1, RU;q=0.5", language: "ru"},
+		{header: "ru;q=0, kk;q=bad", language: DefaultLanguage},
+		{header: "de, fr", language: DefaultLanguage},
+		{header: "*", language: DefaultLanguage},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.header, func(t *testing.T) {
+			require.Equal(t, tc.language, Negotiate(tc.header))
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	require.Equal(t, "не найдено", Translate("ru", "not_found"))
+	require.Equal(t, "табылмады", Translate("kk", "not_found"))
+	require.Equal(t, "not found", Translate("de", "not_found"))
+	require.Equal(t, "unknown_id", Translate("ru", "unknown_id"))
+}
+
+func TestCatalogs(t *testing.T) {
+	require.Equal(t, []string{"en", "kk", "ru"}, Languages())
+
+	for _, language := range Languages() {
+		require.Len(t, catalogs[language], len(catalogs[DefaultLanguage]), language)
+		for id := range catalogs[DefaultLanguage] {
+			require.NotEmpty(t, catalogs[language][id], "%s: %s", language, id)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/google/uuid"
+)
+
+type CalendarRepo struct {
+	mu        sync.RWMutex
+	calendars map[string]domain.Calendar
+}
+
+func NewCalendarRepo() *CalendarRepo {
+	return &CalendarRepo{
+		calendars: make(map[string]domain.Calendar),
+	}
+}
+
+func (r *CalendarRepo) Create(ctx context.Context, calendar domain.Calendar) (domain.Calendar, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	calendar.ID = uuid.NewString()
+	r.calendars[calendar.ID] = calendar
+
+	return calendar, nil
+}
+
+func (r *CalendarRepo) GetCalendarByID(ctx context.Context, id string) (domain.Calendar, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	calendar, ok := r.calendars[id]
+	if !ok {
+		return domain.Calendar{}, domain.ErrNotFound
+	}
+
+	return calendar, nil
+}
+
+func (r *CalendarRepo) GetCalendars(ctx context.Context, userID string) ([]domain.Calendar, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var calendars []domain.Calendar
+	for _, calendar := range r.calendars {
+		if calendar.UserID == userID {
+			calendar.Days = nil
+			calendars = append(calendars, calendar)
+		}
+	}
+
+	sort.Slice(calendars, func(i, j int) bool {
+		if calendars[i].Name != calendars[j].Name {
+			return calendars[i].Name < calendars[j].Name
+		}
+
+		return calendars[i].ID < calendars[j].ID
+	})
+
+	return calendars, nil
+}
+
+func (r *CalendarRepo) DeleteCalendar(ctx context.Context, id string, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored, ok := r.calendars[id]; !ok || stored.UserID != userID {
+		return domain.ErrNotFound
+	}
+
+	delete(r.calendars, id)
+
+	return nil
+}
+
+func (r *CalendarRepo) deleteUserData(userID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, calendar := range r.calendars {
+		if calendar.UserID == userID {
+			delete(r.calendars, id)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/repository/repositorytest"
+	"github.com/google/uuid"
+)
+
+func TestRepositories(t *testing.T) {
+	todoRepo := NewTodoRepo()
+	calendarRepo := NewCalendarRepo()
+	sessionRepo := NewSessionRepo()
+	personalTokenRepo := NewPersonalTokenRepo()
+	mfaRepo := NewMFARepo()
+
+	repositorytest.Run(t, repositorytest.Repositories{
+		Users:          NewUserRepo(todoRepo, calendarRepo, sessionRepo, personalTokenRepo, mfaRepo),
+		Todo:           todoRepo,
+		Items:          NewTodoItemRepo(),
+		Calendars:      calendarRepo,
+		Sessions:       sessionRepo,
+		PersonalTokens: personalTokenRepo,
+		MFA:            mfaRepo,
+		MissingID:      uuid.NewString(),
+	})
+}
+
+func TestRedis(t *testing.T) {
+	repositorytest.RunRedis(t, NewRedis(), time.Sleep)
+}
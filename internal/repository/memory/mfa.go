@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+)
+
+type MFARepo struct {
+	mu  sync.RWMutex
+	mfa map[string]domain.MFA
+}
+
+func NewMFARepo() *MFARepo {
+	return &MFARepo{
+		mfa: make(map[string]domain.MFA),
+	}
+}
+
+func (r *MFARepo) GetMFA(ctx context.Context, userID string) (domain.MFA, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	mfa, ok := r.mfa[userID]
+	if !ok {
+		return domain.MFA{}, domain.ErrNotFound
+	}
+
+	mfa.RecoveryCodes = append([]string(nil), mfa.RecoveryCodes...)
+
+	return mfa, nil
+}
+
+func (r *MFARepo) SetMFA(ctx context.Context, mfa domain.MFA) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	mfa.RecoveryCodes = append([]string(nil), mfa.RecoveryCodes...)
+	r.mfa[mfa.UserID] = mfa
+
+	return nil
+}
+
+func (r *MFARepo) SetRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	mfa, ok := r.mfa[userID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	mfa.RecoveryCodes = append([]string(nil), codeHashes...)
+	r.mfa[userID] = mfa
+
+	return nil
+}
+
+func (r *MFARepo) UseStep(ctx context.Context, userID string, step int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	mfa, ok := r.mfa[userID]
+	if !ok || mfa.LastStep >= step {
+		return domain.ErrNotFound
+	}
+
+	mfa.LastStep = step
+	r.mfa[userID] = mfa
+
+	return nil
+}
+
+func (r *MFARepo) UseRecoveryCode(ctx context.Context, userID string, codeHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	mfa, ok := r.mfa[userID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	for i, hash := range mfa.RecoveryCodes {
+		if hash == codeHash {
+			mfa.RecoveryCodes = append(mfa.RecoveryCodes[:i:i], mfa.RecoveryCodes[i+1:]...)
+			r.mfa[userID] = mfa
+			return nil
+		}
+	}
+
+	return domain.ErrNotFound
+}
+
+func (r *MFARepo) DeleteMFA(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.mfa[userID]; !ok {
+		return domain.ErrNotFound
+	}
+
+	delete(r.mfa, userID)
+
+	return nil
+}
+
+func (r *MFARepo) deleteUserData(userID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.mfa, userID)
+}
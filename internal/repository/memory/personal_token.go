@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/google/uuid"
+)
+
+type PersonalTokenRepo struct {
+	mu     sync.RWMutex
+	tokens map[string]domain.PersonalToken
+}
+
+func NewPersonalTokenRepo() *PersonalTokenRepo {
+	return &PersonalTokenRepo{
+		tokens: make(map[string]domain.PersonalToken),
+	}
+}
+
+func (r *PersonalTokenRepo) Create(ctx context.Context, token domain.PersonalToken) (domain.PersonalToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token.ID = uuid.NewString()
+	token.Scopes = append([]string(nil), token.Scopes...)
+	r.tokens[token.ID] = token
+
+	return token, nil
+}
+
+func (r *PersonalTokenRepo) GetByTokenHash(ctx context.Context, tokenHash string) (domain.PersonalToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash && !tokenExpired(token, now) {
+			return token, nil
+		}
+	}
+
+	return domain.PersonalToken{}, domain.ErrNotFound
+}
+
+func (r *PersonalTokenRepo) GetTokens(ctx context.Context, userID string) ([]domain.PersonalToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	var tokens []domain.PersonalToken
+	for _, token := range r.tokens {
+		if token.UserID == userID && !tokenExpired(token, now) {
+			tokens = append(tokens, token)
+		}
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		if !tokens[i].CreatedAt.Equal(tokens[j].CreatedAt) {
+			return tokens[i].CreatedAt.After(tokens[j].CreatedAt)
+		}
+
+		return tokens[i].ID < tokens[j].ID
+	})
+
+	return tokens, nil
+}
+
+func (r *PersonalTokenRepo) SetLastUsed(ctx context.Context, id string, lastUsedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.tokens[id]
+	if !ok || tokenExpired(stored, time.Now()) {
+		return domain.ErrNotFound
+	}
+
+	stored.LastUsedAt = &lastUsedAt
+	r.tokens[id] = stored
+
+	return nil
+}
+
+func (r *PersonalTokenRepo) DeleteToken(ctx context.Context, id string, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored, ok := r.tokens[id]; !ok || stored.UserID != userID || tokenExpired(stored, time.Now()) {
+		return domain.ErrNotFound
+	}
+
+	delete(r.tokens, id)
+
+	return nil
+}
+
+func tokenExpired(token domain.PersonalToken, now time.Time) bool {
+	return token.ExpiresAt != nil && !token.ExpiresAt.After(now)
+}
+
+func (r *PersonalTokenRepo) deleteUserData(userID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, token := range r.tokens {
+		if token.UserID == userID {
+			delete(r.tokens, id)
+		}
+	}
+}
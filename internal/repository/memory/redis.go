@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+)
+
+type entry struct {
+	value     string
+	expiresAt time.Time
+}
+
+func (e entry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// Redis is an in-process replacement for the Redis repository. Keys with a
+// non-zero expiration are dropped lazily once their TTL has passed.
+type Redis struct {
+	mu      sync.Mutex
+	entries map[string]entry
+}
+
+func NewRedis() *Redis {
+	return &Redis{
+		entries: make(map[string]entry),
+	}
+}
+
+func (r *Redis) Set(key string, value string, expiration time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e := entry{value: value}
+	if expiration > 0 {
+		e.expiresAt = time.Now().Add(expiration)
+	}
+	r.entries[key] = e
+
+	return nil
+}
+
+func (r *Redis) Get(key string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.entries[key]
+	if !ok {
+		return "", domain.ErrNotFound
+	}
+
+	if e.expired(time.Now()) {
+		delete(r.entries, key)
+		return "", domain.ErrNotFound
+	}
+
+	return e.value, nil
+}
+
+func (r *Redis) Delete(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.entries, key)
+
+	return nil
+}
+
+func (r *Redis) Incr(key string, expiration time.Duration) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	e, ok := r.entries[key]
+	if !ok || e.expired(now) {
+		e = entry{value: "0"}
+		if expiration > 0 {
+			e.expiresAt = now.Add(expiration)
+		}
+	}
+
+	val, err := strconv.ParseInt(e.value, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	val++
+
+	e.value = strconv.FormatInt(val, 10)
+	r.entries[key] = e
+
+	return val, nil
+}
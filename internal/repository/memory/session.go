@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/google/uuid"
+)
+
+type SessionRepo struct {
+	mu       sync.RWMutex
+	sessions map[string]domain.Session
+}
+
+func NewSessionRepo() *SessionRepo {
+	return &SessionRepo{
+		sessions: make(map[string]domain.Session),
+	}
+}
+
+func (r *SessionRepo) Create(ctx context.Context, session domain.Session) (domain.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for id, stored := range r.sessions {
+		if !stored.ExpirationAt.After(now) {
+			delete(r.sessions, id)
+		}
+	}
+
+	session.ID = uuid.NewString()
+	r.sessions[session.ID] = session
+
+	return session, nil
+}
+
+func (r *SessionRepo) GetByRefreshToken(ctx context.Context, refreshTokenHash string) (domain.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	for _, session := range r.sessions {
+		if session.RefreshTokenHash == refreshTokenHash && session.ExpirationAt.After(now) {
+			return session, nil
+		}
+	}
+
+	return domain.Session{}, domain.ErrNotFound
+}
+
+func (r *SessionRepo) GetSessions(ctx context.Context, userID string) ([]domain.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	var sessions []domain.Session
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.ExpirationAt.After(now) {
+			sessions = append(sessions, session)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].LastUsedAt.Equal(sessions[j].LastUsedAt) {
+			return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+		}
+
+		return sessions[i].ID < sessions[j].ID
+	})
+
+	return sessions, nil
+}
+
+func (r *SessionRepo) RotateRefreshToken(ctx context.Context, session domain.Session, previousHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.sessions[session.ID]
+	if !ok || stored.RefreshTokenHash != previousHash || !stored.ExpirationAt.After(time.Now()) {
+		return domain.ErrNotFound
+	}
+
+	stored.RefreshTokenHash = session.RefreshTokenHash
+	stored.UserAgent = session.UserAgent
+	stored.IP = session.IP
+	stored.LastUsedAt = session.LastUsedAt
+	stored.ExpirationAt = session.ExpirationAt
+	r.sessions[session.ID] = stored
+
+	return nil
+}
+
+func (r *SessionRepo) DeleteSession(ctx context.Context, id string, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored, ok := r.sessions[id]; !ok || stored.UserID != userID || !stored.ExpirationAt.After(time.Now()) {
+		return domain.ErrNotFound
+	}
+
+	delete(r.sessions, id)
+
+	return nil
+}
+
+func (r *SessionRepo) DeleteSessions(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, session := range r.sessions {
+		if session.UserID == userID {
+			delete(r.sessions, id)
+		}
+	}
+
+	return nil
+}
+
+func (r *SessionRepo) deleteUserData(userID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, session := range r.sessions {
+		if session.UserID == userID {
+			delete(r.sessions, id)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/google/uuid"
+)
+
+type TodoRepo struct {
+	mu    sync.RWMutex
+	todos map[string]domain.Todo
+	// order keeps identifiers in insertion order, the way Mongo returns
+	// documents without an explicit sort.
+	order []string
+}
+
+func NewTodoRepo() *TodoRepo {
+	return &TodoRepo{
+		todos: make(map[string]domain.Todo),
+	}
+}
+
+func (r *TodoRepo) Create(ctx context.Context, todo domain.Todo) (domain.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todo.ID = uuid.NewString()
+	r.todos[todo.ID] = todo
+	r.order = append(r.order, todo.ID)
+
+	return todo, nil
+}
+
+func (r *TodoRepo) GetCountByTitle(ctx context.Context, title string, id string) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var count int64
+	for _, todo := range r.todos {
+		if todo.Title == title && todo.UserID == id {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (r *TodoRepo) GetTodoByID(ctx context.Context, id string) (domain.Todo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	todo, ok := r.todos[id]
+	if !ok {
+		return domain.Todo{}, domain.ErrNotFound
+	}
+
+	return todo, nil
+}
+
+// UpdateTodoID exists for parity with the Mongo repository, where the
+// identifier is written back after insertion. Identifiers are assigned in
+// Create here, so only the existence check remains.
+func (r *TodoRepo) UpdateTodoID(ctx context.Context, todo domain.Todo) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.todos[todo.ID]; !ok {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *TodoRepo) UpdateTodo(ctx context.Context, todo domain.Todo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.todos[todo.ID]
+	if !ok || stored.UserID != todo.UserID {
+		return domain.ErrNotFound
+	}
+
+	stored.Title = todo.Title
+	stored.Description = todo.Description
+	stored.Priority = todo.Priority
+	stored.Tags = todo.Tags
+	stored.ActiveAt = todo.ActiveAt
+	stored.DueAt = todo.DueAt
+	stored.Recurrence = todo.Recurrence
+	r.todos[todo.ID] = stored
+
+	return nil
+}
+
+func (r *TodoRepo) DeleteTodoByID(ctx context.Context, id string, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored, ok := r.todos[id]; !ok || stored.UserID != userID {
+		return domain.ErrNotFound
+	}
+
+	delete(r.todos, id)
+	for i, orderedID := range r.order {
+		if orderedID == id {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+func (r *TodoRepo) UpdateTodoDoneByID(ctx context.Context, id string, userID string) (domain.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todo, ok := r.todos[id]
+	if !ok || todo.UserID != userID {
+		return domain.Todo{}, domain.ErrNotFound
+	}
+
+	todo.Status = domain.Done
+	r.todos[id] = todo
+
+	return todo, nil
+}
+
+func (r *TodoRepo) GetTodos(ctx context.Context, filter domain.TodoFilter) ([]domain.Todo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	todos := r.match(filter)
+	sort.Slice(todos, func(i, j int) bool {
+		return less(filter, todos[i], todos[j])
+	})
+
+	if filter.After != nil {
+		after := domain.Todo{ID: filter.After.ID, Title: filter.After.Value}
+		if filter.Sort != domain.SortTitle {
+			activeAt, err := filter.After.ActiveAt()
+			if err != nil {
+				return nil, err
+			}
+			after.ActiveAt = activeAt
+		}
+
+		n := sort.Search(len(todos), func(i int) bool {
+			return less(filter, after, todos[i])
+		})
+		todos = todos[n:]
+	}
+
+	if filter.Limit > 0 && len(todos) > filter.Limit {
+		todos = todos[:filter.Limit]
+	}
+
+	return todos, nil
+}
+
+func (r *TodoRepo) GetCountByFilter(ctx context.Context, filter domain.TodoFilter) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return int64(len(r.match(filter))), nil
+}
+
+func (r *TodoRepo) SearchTodos(ctx context.Context, userID string, query domain.SearchQuery, limit int) ([]domain.SearchResult, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var results []domain.SearchResult
+	for i := len(r.order) - 1; i >= 0; i-- {
+		todo := r.todos[r.order[i]]
+		if todo.UserID != userID {
+			continue
+		}
+
+		if score, ok := query.Match(todo); ok {
+			results = append(results, domain.SearchResult{Todo: todo, Score: score})
+		}
+	}
+
+	return rank(results, limit), nil
+}
+
+func (r *TodoRepo) match(filter domain.TodoFilter) []domain.Todo {
+	var todos []domain.Todo
+	for _, id := range r.order {
+		todo := r.todos[id]
+		if todo.UserID != filter.UserID {
+			continue
+		}
+
+		if len(filter.Statuses) > 0 && !contains(filter.Statuses, todo.Status) {
+			continue
+		}
+
+		if !filter.ActiveFrom.IsZero() && todo.ActiveAt.Before(filter.ActiveFrom) {
+			continue
+		}
+
+		if !filter.ActiveBefore.IsZero() && !todo.ActiveAt.Before(filter.ActiveBefore) {
+			continue
+		}
+
+		if filter.Title != "" && !strings.Contains(strings.ToLower(todo.Title), strings.ToLower(filter.Title)) {
+			continue
+		}
+
+		if len(filter.Priorities) > 0 && !contains(filter.Priorities, todo.Priority) {
+			continue
+		}
+
+		if !containsAll(todo.Tags, filter.Tags) {
+			continue
+		}
+
+		todos = append(todos, todo)
+	}
+
+	return todos
+}
+
+// less orders todos by the sort field with the id as a tie-breaker, both in
+// the direction requested by the filter.
+func less(filter domain.TodoFilter, a, b domain.Todo) bool {
+	c := strings.Compare(a.Title, b.Title)
+	if filter.Sort != domain.SortTitle {
+		c = a.ActiveAt.Compare(b.ActiveAt)
+	}
+	if c == 0 {
+		c = strings.Compare(a.ID, b.ID)
+	}
+
+	if filter.Order == domain.OrderDesc {
+		return c > 0
+	}
+
+	return c < 0
+}
+
+// rank orders search results by score, the most recent first among equal
+// scores, and keeps at most limit of them.
+func rank(results []domain.SearchResult, limit int) []domain.SearchResult {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsAll(values []string, required []string) bool {
+	for _, v := range required {
+		if !contains(values, v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r *TodoRepo) deleteUserData(userID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order := r.order[:0]
+	for _, id := range r.order {
+		if r.todos[id].UserID == userID {
+			delete(r.todos, id)
+			continue
+		}
+		order = append(order, id)
+	}
+	r.order = order
+}
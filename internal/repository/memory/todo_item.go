@@ -0,0 +1,156 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/google/uuid"
+)
+
+type TodoItemRepo struct {
+	mu    sync.RWMutex
+	items map[string]domain.TodoItem
+}
+
+func NewTodoItemRepo() *TodoItemRepo {
+	return &TodoItemRepo{
+		items: make(map[string]domain.TodoItem),
+	}
+}
+
+// Create appends the item after the last item of the todo.
+func (r *TodoItemRepo) Create(ctx context.Context, item domain.TodoItem) (domain.TodoItem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	item.Position = 1
+	for _, stored := range r.items {
+		if stored.TodoID == item.TodoID && stored.Position >= item.Position {
+			item.Position = stored.Position + 1
+		}
+	}
+
+	item.ID = uuid.NewString()
+	r.items[item.ID] = item
+
+	return item, nil
+}
+
+func (r *TodoItemRepo) GetItems(ctx context.Context, todoID string) ([]domain.TodoItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var items []domain.TodoItem
+	for _, item := range r.items {
+		if item.TodoID == todoID {
+			items = append(items, item)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Position == items[j].Position {
+			return items[i].ID < items[j].ID
+		}
+
+		return items[i].Position < items[j].Position
+	})
+
+	return items, nil
+}
+
+func (r *TodoItemRepo) GetItemByID(ctx context.Context, id string, todoID string) (domain.TodoItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	item, ok := r.items[id]
+	if !ok || item.TodoID != todoID {
+		return domain.TodoItem{}, domain.ErrNotFound
+	}
+
+	return item, nil
+}
+
+func (r *TodoItemRepo) UpdateItemDone(ctx context.Context, id string, todoID string, done bool) (domain.TodoItem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	item, ok := r.items[id]
+	if !ok || item.TodoID != todoID {
+		return domain.TodoItem{}, domain.ErrNotFound
+	}
+
+	item.Done = done
+	r.items[id] = item
+
+	return item, nil
+}
+
+// ReorderItems moves the items to the positions of their ids in the list,
+// starting from one. Ids of other todos are ignored.
+func (r *TodoItemRepo) ReorderItems(ctx context.Context, todoID string, ids []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, id := range ids {
+		item, ok := r.items[id]
+		if !ok || item.TodoID != todoID {
+			continue
+		}
+
+		item.Position = i + 1
+		r.items[id] = item
+	}
+
+	return nil
+}
+
+func (r *TodoItemRepo) DeleteItem(ctx context.Context, id string, todoID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if item, ok := r.items[id]; !ok || item.TodoID != todoID {
+		return domain.ErrNotFound
+	}
+
+	delete(r.items, id)
+
+	return nil
+}
+
+func (r *TodoItemRepo) DeleteItems(ctx context.Context, todoID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, item := range r.items {
+		if item.TodoID == todoID {
+			delete(r.items, id)
+		}
+	}
+
+	return nil
+}
+
+// GetProgress counts the items of every todo. Todos without items are left
+// out of the result.
+func (r *TodoItemRepo) GetProgress(ctx context.Context, todoIDs []string) (map[string]domain.Progress, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	progress := make(map[string]domain.Progress)
+	for _, item := range r.items {
+		if !contains(todoIDs, item.TodoID) {
+			continue
+		}
+
+		p := progress[item.TodoID]
+		p.Total++
+		if item.Done {
+			p.Done++
+		}
+		progress[item.TodoID] = p
+	}
+
+	return progress, nil
+}
@@ -0,0 +1,186 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/google/uuid"
+)
+
+// userData is a repository of what users own, the things of a deleted user
+// are dropped from it.
+type userData interface {
+	deleteUserData(userID string)
+}
+
+// UserRepo deletes the things of a deleted user from the data repositories.
+// The checklists of the deleted todos are left behind, no todo refers to
+// them anymore.
+type UserRepo struct {
+	mu    sync.RWMutex
+	users map[string]domain.User
+	data  []userData
+}
+
+func NewUserRepo(data ...userData) *UserRepo {
+	return &UserRepo{
+		users: make(map[string]domain.User),
+		data:  data,
+	}
+}
+
+func (r *UserRepo) Create(ctx context.Context, user domain.User) (domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user.ID = uuid.NewString()
+	r.users[user.ID] = user
+
+	return user, nil
+}
+
+func (r *UserRepo) GetUserByEmail(ctx context.Context, email string) (domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+
+	return domain.User{}, domain.ErrNotFound
+}
+
+func (r *UserRepo) GetUserByID(ctx context.Context, id string) (domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return domain.User{}, domain.ErrNotFound
+	}
+
+	return user, nil
+}
+
+func (r *UserRepo) SetCalendar(ctx context.Context, userID string, calendarID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	user.Calendar = calendarID
+	r.users[userID] = user
+
+	return nil
+}
+
+func (r *UserRepo) SetLanguage(ctx context.Context, userID string, language string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	user.Language = language
+	r.users[userID] = user
+
+	return nil
+}
+
+func (r *UserRepo) SetPassword(ctx context.Context, userID string, passwordHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	user.Password = passwordHash
+	r.users[userID] = user
+
+	return nil
+}
+
+func (r *UserRepo) VerifyEmail(ctx context.Context, userID string, email string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	for id, other := range r.users {
+		if id != userID && other.Email == email {
+			return domain.ErrEmailAlreadyExists
+		}
+	}
+
+	user.Email = email
+	user.EmailVerified = true
+	r.users[userID] = user
+
+	return nil
+}
+
+func (r *UserRepo) UpdateUser(ctx context.Context, user domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[user.ID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	stored.UserName = user.UserName
+	stored.Timezone = user.Timezone
+	stored.Language = user.Language
+	r.users[user.ID] = stored
+
+	return nil
+}
+
+func (r *UserRepo) ScheduleDeletion(ctx context.Context, userID string, deleteAt *time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	user.DeleteAt = deleteAt
+	r.users[userID] = user
+
+	return nil
+}
+
+func (r *UserRepo) DeleteScheduled(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for id, user := range r.users {
+		if user.DeleteAt == nil || user.DeleteAt.After(before) {
+			continue
+		}
+
+		delete(r.users, id)
+		for _, data := range r.data {
+			data.deleteUserData(id)
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
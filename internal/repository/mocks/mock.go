@@ -11,7 +11,6 @@ import (
 
 	domain "github.com/begenov/region-llc-task/internal/domain"
 	gomock "github.com/golang/mock/gomock"
-	primitive "go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // MockUsers is a mock of Users interface.
@@ -52,19 +51,19 @@ func (mr *MockUsersMockRecorder) Create(ctx, user interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUsers)(nil).Create), ctx, user)
 }
 
-// GetByRefreshToken mocks base method.
-func (m *MockUsers) GetByRefreshToken(ctx context.Context, refreshToken string) (domain.User, error) {
+// DeleteScheduled mocks base method.
+func (m *MockUsers) DeleteScheduled(ctx context.Context, before time.Time) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByRefreshToken", ctx, refreshToken)
-	ret0, _ := ret[0].(domain.User)
+	ret := m.ctrl.Call(m, "DeleteScheduled", ctx, before)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetByRefreshToken indicates an expected call of GetByRefreshToken.
-func (mr *MockUsersMockRecorder) GetByRefreshToken(ctx, refreshToken interface{}) *gomock.Call {
+// DeleteScheduled indicates an expected call of DeleteScheduled.
+func (mr *MockUsersMockRecorder) DeleteScheduled(ctx, before interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByRefreshToken", reflect.TypeOf((*MockUsers)(nil).GetByRefreshToken), ctx, refreshToken)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteScheduled", reflect.TypeOf((*MockUsers)(nil).DeleteScheduled), ctx, before)
 }
 
 // GetUserByEmail mocks base method.
@@ -83,7 +82,7 @@ func (mr *MockUsersMockRecorder) GetUserByEmail(ctx, email interface{}) *gomock.
 }
 
 // GetUserByID mocks base method.
-func (m *MockUsers) GetUserByID(ctx context.Context, id primitive.ObjectID) (domain.User, error) {
+func (m *MockUsers) GetUserByID(ctx context.Context, id string) (domain.User, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetUserByID", ctx, id)
 	ret0, _ := ret[0].(domain.User)
@@ -97,18 +96,402 @@ func (mr *MockUsersMockRecorder) GetUserByID(ctx, id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockUsers)(nil).GetUserByID), ctx, id)
 }
 
-// SetSession mocks base method.
-func (m *MockUsers) SetSession(ctx context.Context, userID primitive.ObjectID, session domain.Session) error {
+// ScheduleDeletion mocks base method.
+func (m *MockUsers) ScheduleDeletion(ctx context.Context, userID string, deleteAt *time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleDeletion", ctx, userID, deleteAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ScheduleDeletion indicates an expected call of ScheduleDeletion.
+func (mr *MockUsersMockRecorder) ScheduleDeletion(ctx, userID, deleteAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleDeletion", reflect.TypeOf((*MockUsers)(nil).ScheduleDeletion), ctx, userID, deleteAt)
+}
+
+// SetCalendar mocks base method.
+func (m *MockUsers) SetCalendar(ctx context.Context, userID, calendarID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCalendar", ctx, userID, calendarID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetCalendar indicates an expected call of SetCalendar.
+func (mr *MockUsersMockRecorder) SetCalendar(ctx, userID, calendarID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCalendar", reflect.TypeOf((*MockUsers)(nil).SetCalendar), ctx, userID, calendarID)
+}
+
+// SetLanguage mocks base method.
+func (m *MockUsers) SetLanguage(ctx context.Context, userID, language string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLanguage", ctx, userID, language)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetLanguage indicates an expected call of SetLanguage.
+func (mr *MockUsersMockRecorder) SetLanguage(ctx, userID, language interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLanguage", reflect.TypeOf((*MockUsers)(nil).SetLanguage), ctx, userID, language)
+}
+
+// SetPassword mocks base method.
+func (m *MockUsers) SetPassword(ctx context.Context, userID, passwordHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPassword", ctx, userID, passwordHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPassword indicates an expected call of SetPassword.
+func (mr *MockUsersMockRecorder) SetPassword(ctx, userID, passwordHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPassword", reflect.TypeOf((*MockUsers)(nil).SetPassword), ctx, userID, passwordHash)
+}
+
+// UpdateUser mocks base method.
+func (m *MockUsers) UpdateUser(ctx context.Context, user domain.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUser", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUser indicates an expected call of UpdateUser.
+func (mr *MockUsersMockRecorder) UpdateUser(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUsers)(nil).UpdateUser), ctx, user)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockUsers) VerifyEmail(ctx context.Context, userID, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", ctx, userID, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockUsersMockRecorder) VerifyEmail(ctx, userID, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockUsers)(nil).VerifyEmail), ctx, userID, email)
+}
+
+// MockSessions is a mock of Sessions interface.
+type MockSessions struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionsMockRecorder
+}
+
+// MockSessionsMockRecorder is the mock recorder for MockSessions.
+type MockSessionsMockRecorder struct {
+	mock *MockSessions
+}
+
+// NewMockSessions creates a new mock instance.
+func NewMockSessions(ctrl *gomock.Controller) *MockSessions {
+	mock := &MockSessions{ctrl: ctrl}
+	mock.recorder = &MockSessionsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSessions) EXPECT() *MockSessionsMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockSessions) Create(ctx context.Context, session domain.Session) (domain.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, session)
+	ret0, _ := ret[0].(domain.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockSessionsMockRecorder) Create(ctx, session interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockSessions)(nil).Create), ctx, session)
+}
+
+// DeleteSession mocks base method.
+func (m *MockSessions) DeleteSession(ctx context.Context, id, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSession", ctx, id, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSession indicates an expected call of DeleteSession.
+func (mr *MockSessionsMockRecorder) DeleteSession(ctx, id, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSession", reflect.TypeOf((*MockSessions)(nil).DeleteSession), ctx, id, userID)
+}
+
+// DeleteSessions mocks base method.
+func (m *MockSessions) DeleteSessions(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSessions", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSessions indicates an expected call of DeleteSessions.
+func (mr *MockSessionsMockRecorder) DeleteSessions(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSessions", reflect.TypeOf((*MockSessions)(nil).DeleteSessions), ctx, userID)
+}
+
+// GetByRefreshToken mocks base method.
+func (m *MockSessions) GetByRefreshToken(ctx context.Context, refreshTokenHash string) (domain.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByRefreshToken", ctx, refreshTokenHash)
+	ret0, _ := ret[0].(domain.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByRefreshToken indicates an expected call of GetByRefreshToken.
+func (mr *MockSessionsMockRecorder) GetByRefreshToken(ctx, refreshTokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByRefreshToken", reflect.TypeOf((*MockSessions)(nil).GetByRefreshToken), ctx, refreshTokenHash)
+}
+
+// GetSessions mocks base method.
+func (m *MockSessions) GetSessions(ctx context.Context, userID string) ([]domain.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessions", ctx, userID)
+	ret0, _ := ret[0].([]domain.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessions indicates an expected call of GetSessions.
+func (mr *MockSessionsMockRecorder) GetSessions(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessions", reflect.TypeOf((*MockSessions)(nil).GetSessions), ctx, userID)
+}
+
+// RotateRefreshToken mocks base method.
+func (m *MockSessions) RotateRefreshToken(ctx context.Context, session domain.Session, previousHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateRefreshToken", ctx, session, previousHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RotateRefreshToken indicates an expected call of RotateRefreshToken.
+func (mr *MockSessionsMockRecorder) RotateRefreshToken(ctx, session, previousHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateRefreshToken", reflect.TypeOf((*MockSessions)(nil).RotateRefreshToken), ctx, session, previousHash)
+}
+
+// MockPersonalTokens is a mock of PersonalTokens interface.
+type MockPersonalTokens struct {
+	ctrl     *gomock.Controller
+	recorder *MockPersonalTokensMockRecorder
+}
+
+// MockPersonalTokensMockRecorder is the mock recorder for MockPersonalTokens.
+type MockPersonalTokensMockRecorder struct {
+	mock *MockPersonalTokens
+}
+
+// NewMockPersonalTokens creates a new mock instance.
+func NewMockPersonalTokens(ctrl *gomock.Controller) *MockPersonalTokens {
+	mock := &MockPersonalTokens{ctrl: ctrl}
+	mock.recorder = &MockPersonalTokensMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPersonalTokens) EXPECT() *MockPersonalTokensMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockPersonalTokens) Create(ctx context.Context, token domain.PersonalToken) (domain.PersonalToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, token)
+	ret0, _ := ret[0].(domain.PersonalToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockPersonalTokensMockRecorder) Create(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPersonalTokens)(nil).Create), ctx, token)
+}
+
+// DeleteToken mocks base method.
+func (m *MockPersonalTokens) DeleteToken(ctx context.Context, id, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteToken", ctx, id, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteToken indicates an expected call of DeleteToken.
+func (mr *MockPersonalTokensMockRecorder) DeleteToken(ctx, id, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteToken", reflect.TypeOf((*MockPersonalTokens)(nil).DeleteToken), ctx, id, userID)
+}
+
+// GetByTokenHash mocks base method.
+func (m *MockPersonalTokens) GetByTokenHash(ctx context.Context, tokenHash string) (domain.PersonalToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByTokenHash", ctx, tokenHash)
+	ret0, _ := ret[0].(domain.PersonalToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByTokenHash indicates an expected call of GetByTokenHash.
+func (mr *MockPersonalTokensMockRecorder) GetByTokenHash(ctx, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByTokenHash", reflect.TypeOf((*MockPersonalTokens)(nil).GetByTokenHash), ctx, tokenHash)
+}
+
+// GetTokens mocks base method.
+func (m *MockPersonalTokens) GetTokens(ctx context.Context, userID string) ([]domain.PersonalToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTokens", ctx, userID)
+	ret0, _ := ret[0].([]domain.PersonalToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTokens indicates an expected call of GetTokens.
+func (mr *MockPersonalTokensMockRecorder) GetTokens(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTokens", reflect.TypeOf((*MockPersonalTokens)(nil).GetTokens), ctx, userID)
+}
+
+// SetLastUsed mocks base method.
+func (m *MockPersonalTokens) SetLastUsed(ctx context.Context, id string, lastUsedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLastUsed", ctx, id, lastUsedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetLastUsed indicates an expected call of SetLastUsed.
+func (mr *MockPersonalTokensMockRecorder) SetLastUsed(ctx, id, lastUsedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLastUsed", reflect.TypeOf((*MockPersonalTokens)(nil).SetLastUsed), ctx, id, lastUsedAt)
+}
+
+// MockMFA is a mock of MFA interface.
+type MockMFA struct {
+	ctrl     *gomock.Controller
+	recorder *MockMFAMockRecorder
+}
+
+// MockMFAMockRecorder is the mock recorder for MockMFA.
+type MockMFAMockRecorder struct {
+	mock *MockMFA
+}
+
+// NewMockMFA creates a new mock instance.
+func NewMockMFA(ctrl *gomock.Controller) *MockMFA {
+	mock := &MockMFA{ctrl: ctrl}
+	mock.recorder = &MockMFAMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMFA) EXPECT() *MockMFAMockRecorder {
+	return m.recorder
+}
+
+// DeleteMFA mocks base method.
+func (m *MockMFA) DeleteMFA(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMFA", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMFA indicates an expected call of DeleteMFA.
+func (mr *MockMFAMockRecorder) DeleteMFA(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMFA", reflect.TypeOf((*MockMFA)(nil).DeleteMFA), ctx, userID)
+}
+
+// GetMFA mocks base method.
+func (m *MockMFA) GetMFA(ctx context.Context, userID string) (domain.MFA, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMFA", ctx, userID)
+	ret0, _ := ret[0].(domain.MFA)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMFA indicates an expected call of GetMFA.
+func (mr *MockMFAMockRecorder) GetMFA(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMFA", reflect.TypeOf((*MockMFA)(nil).GetMFA), ctx, userID)
+}
+
+// SetMFA mocks base method.
+func (m *MockMFA) SetMFA(ctx context.Context, mfa domain.MFA) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetSession", ctx, userID, session)
+	ret := m.ctrl.Call(m, "SetMFA", ctx, mfa)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SetSession indicates an expected call of SetSession.
-func (mr *MockUsersMockRecorder) SetSession(ctx, userID, session interface{}) *gomock.Call {
+// SetMFA indicates an expected call of SetMFA.
+func (mr *MockMFAMockRecorder) SetMFA(ctx, mfa interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSession", reflect.TypeOf((*MockUsers)(nil).SetSession), ctx, userID, session)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMFA", reflect.TypeOf((*MockMFA)(nil).SetMFA), ctx, mfa)
+}
+
+// SetRecoveryCodes mocks base method.
+func (m *MockMFA) SetRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRecoveryCodes", ctx, userID, codeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRecoveryCodes indicates an expected call of SetRecoveryCodes.
+func (mr *MockMFAMockRecorder) SetRecoveryCodes(ctx, userID, codeHashes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRecoveryCodes", reflect.TypeOf((*MockMFA)(nil).SetRecoveryCodes), ctx, userID, codeHashes)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockMFA) UseRecoveryCode(ctx context.Context, userID, codeHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", ctx, userID, codeHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockMFAMockRecorder) UseRecoveryCode(ctx, userID, codeHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockMFA)(nil).UseRecoveryCode), ctx, userID, codeHash)
+}
+
+// UseStep mocks base method.
+func (m *MockMFA) UseStep(ctx context.Context, userID string, step int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseStep", ctx, userID, step)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseStep indicates an expected call of UseStep.
+func (mr *MockMFAMockRecorder) UseStep(ctx, userID, step interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseStep", reflect.TypeOf((*MockMFA)(nil).UseStep), ctx, userID, step)
 }
 
 // MockTodo is a mock of Todo interface.
@@ -150,21 +533,36 @@ func (mr *MockTodoMockRecorder) Create(ctx, todo interface{}) *gomock.Call {
 }
 
 // DeleteTodoByID mocks base method.
-func (m *MockTodo) DeleteTodoByID(ctx context.Context, id primitive.ObjectID) error {
+func (m *MockTodo) DeleteTodoByID(ctx context.Context, id, userID string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteTodoByID", ctx, id)
+	ret := m.ctrl.Call(m, "DeleteTodoByID", ctx, id, userID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteTodoByID indicates an expected call of DeleteTodoByID.
-func (mr *MockTodoMockRecorder) DeleteTodoByID(ctx, id interface{}) *gomock.Call {
+func (mr *MockTodoMockRecorder) DeleteTodoByID(ctx, id, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTodoByID", reflect.TypeOf((*MockTodo)(nil).DeleteTodoByID), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTodoByID", reflect.TypeOf((*MockTodo)(nil).DeleteTodoByID), ctx, id, userID)
+}
+
+// GetCountByFilter mocks base method.
+func (m *MockTodo) GetCountByFilter(ctx context.Context, filter domain.TodoFilter) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCountByFilter", ctx, filter)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCountByFilter indicates an expected call of GetCountByFilter.
+func (mr *MockTodoMockRecorder) GetCountByFilter(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCountByFilter", reflect.TypeOf((*MockTodo)(nil).GetCountByFilter), ctx, filter)
 }
 
 // GetCountByTitle mocks base method.
-func (m *MockTodo) GetCountByTitle(ctx context.Context, title string, id primitive.ObjectID) (int64, error) {
+func (m *MockTodo) GetCountByTitle(ctx context.Context, title, id string) (int64, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetCountByTitle", ctx, title, id)
 	ret0, _ := ret[0].(int64)
@@ -179,7 +577,7 @@ func (mr *MockTodoMockRecorder) GetCountByTitle(ctx, title, id interface{}) *gom
 }
 
 // GetTodoByID mocks base method.
-func (m *MockTodo) GetTodoByID(ctx context.Context, id primitive.ObjectID) (domain.Todo, error) {
+func (m *MockTodo) GetTodoByID(ctx context.Context, id string) (domain.Todo, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetTodoByID", ctx, id)
 	ret0, _ := ret[0].(domain.Todo)
@@ -193,19 +591,34 @@ func (mr *MockTodoMockRecorder) GetTodoByID(ctx, id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTodoByID", reflect.TypeOf((*MockTodo)(nil).GetTodoByID), ctx, id)
 }
 
-// GetTodoByStatus mocks base method.
-func (m *MockTodo) GetTodoByStatus(ctx context.Context, status string, userID primitive.ObjectID) ([]domain.Todo, error) {
+// GetTodos mocks base method.
+func (m *MockTodo) GetTodos(ctx context.Context, filter domain.TodoFilter) ([]domain.Todo, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTodoByStatus", ctx, status, userID)
+	ret := m.ctrl.Call(m, "GetTodos", ctx, filter)
 	ret0, _ := ret[0].([]domain.Todo)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetTodoByStatus indicates an expected call of GetTodoByStatus.
-func (mr *MockTodoMockRecorder) GetTodoByStatus(ctx, status, userID interface{}) *gomock.Call {
+// GetTodos indicates an expected call of GetTodos.
+func (mr *MockTodoMockRecorder) GetTodos(ctx, filter interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTodoByStatus", reflect.TypeOf((*MockTodo)(nil).GetTodoByStatus), ctx, status, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTodos", reflect.TypeOf((*MockTodo)(nil).GetTodos), ctx, filter)
+}
+
+// SearchTodos mocks base method.
+func (m *MockTodo) SearchTodos(ctx context.Context, userID string, query domain.SearchQuery, limit int) ([]domain.SearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchTodos", ctx, userID, query, limit)
+	ret0, _ := ret[0].([]domain.SearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchTodos indicates an expected call of SearchTodos.
+func (mr *MockTodoMockRecorder) SearchTodos(ctx, userID, query, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchTodos", reflect.TypeOf((*MockTodo)(nil).SearchTodos), ctx, userID, query, limit)
 }
 
 // UpdateTodo mocks base method.
@@ -223,7 +636,7 @@ func (mr *MockTodoMockRecorder) UpdateTodo(ctx, todo interface{}) *gomock.Call {
 }
 
 // UpdateTodoDoneByID mocks base method.
-func (m *MockTodo) UpdateTodoDoneByID(ctx context.Context, id, userID primitive.ObjectID) (domain.Todo, error) {
+func (m *MockTodo) UpdateTodoDoneByID(ctx context.Context, id, userID string) (domain.Todo, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "UpdateTodoDoneByID", ctx, id, userID)
 	ret0, _ := ret[0].(domain.Todo)
@@ -251,6 +664,228 @@ func (mr *MockTodoMockRecorder) UpdateTodoID(ctx, todo interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTodoID", reflect.TypeOf((*MockTodo)(nil).UpdateTodoID), ctx, todo)
 }
 
+// MockTodoItems is a mock of TodoItems interface.
+type MockTodoItems struct {
+	ctrl     *gomock.Controller
+	recorder *MockTodoItemsMockRecorder
+}
+
+// MockTodoItemsMockRecorder is the mock recorder for MockTodoItems.
+type MockTodoItemsMockRecorder struct {
+	mock *MockTodoItems
+}
+
+// NewMockTodoItems creates a new mock instance.
+func NewMockTodoItems(ctrl *gomock.Controller) *MockTodoItems {
+	mock := &MockTodoItems{ctrl: ctrl}
+	mock.recorder = &MockTodoItemsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTodoItems) EXPECT() *MockTodoItemsMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockTodoItems) Create(ctx context.Context, item domain.TodoItem) (domain.TodoItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, item)
+	ret0, _ := ret[0].(domain.TodoItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockTodoItemsMockRecorder) Create(ctx, item interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTodoItems)(nil).Create), ctx, item)
+}
+
+// DeleteItem mocks base method.
+func (m *MockTodoItems) DeleteItem(ctx context.Context, id, todoID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteItem", ctx, id, todoID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteItem indicates an expected call of DeleteItem.
+func (mr *MockTodoItemsMockRecorder) DeleteItem(ctx, id, todoID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteItem", reflect.TypeOf((*MockTodoItems)(nil).DeleteItem), ctx, id, todoID)
+}
+
+// DeleteItems mocks base method.
+func (m *MockTodoItems) DeleteItems(ctx context.Context, todoID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteItems", ctx, todoID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteItems indicates an expected call of DeleteItems.
+func (mr *MockTodoItemsMockRecorder) DeleteItems(ctx, todoID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteItems", reflect.TypeOf((*MockTodoItems)(nil).DeleteItems), ctx, todoID)
+}
+
+// GetItemByID mocks base method.
+func (m *MockTodoItems) GetItemByID(ctx context.Context, id, todoID string) (domain.TodoItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetItemByID", ctx, id, todoID)
+	ret0, _ := ret[0].(domain.TodoItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetItemByID indicates an expected call of GetItemByID.
+func (mr *MockTodoItemsMockRecorder) GetItemByID(ctx, id, todoID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetItemByID", reflect.TypeOf((*MockTodoItems)(nil).GetItemByID), ctx, id, todoID)
+}
+
+// GetItems mocks base method.
+func (m *MockTodoItems) GetItems(ctx context.Context, todoID string) ([]domain.TodoItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetItems", ctx, todoID)
+	ret0, _ := ret[0].([]domain.TodoItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetItems indicates an expected call of GetItems.
+func (mr *MockTodoItemsMockRecorder) GetItems(ctx, todoID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetItems", reflect.TypeOf((*MockTodoItems)(nil).GetItems), ctx, todoID)
+}
+
+// GetProgress mocks base method.
+func (m *MockTodoItems) GetProgress(ctx context.Context, todoIDs []string) (map[string]domain.Progress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProgress", ctx, todoIDs)
+	ret0, _ := ret[0].(map[string]domain.Progress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProgress indicates an expected call of GetProgress.
+func (mr *MockTodoItemsMockRecorder) GetProgress(ctx, todoIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProgress", reflect.TypeOf((*MockTodoItems)(nil).GetProgress), ctx, todoIDs)
+}
+
+// ReorderItems mocks base method.
+func (m *MockTodoItems) ReorderItems(ctx context.Context, todoID string, ids []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReorderItems", ctx, todoID, ids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReorderItems indicates an expected call of ReorderItems.
+func (mr *MockTodoItemsMockRecorder) ReorderItems(ctx, todoID, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReorderItems", reflect.TypeOf((*MockTodoItems)(nil).ReorderItems), ctx, todoID, ids)
+}
+
+// UpdateItemDone mocks base method.
+func (m *MockTodoItems) UpdateItemDone(ctx context.Context, id, todoID string, done bool) (domain.TodoItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateItemDone", ctx, id, todoID, done)
+	ret0, _ := ret[0].(domain.TodoItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateItemDone indicates an expected call of UpdateItemDone.
+func (mr *MockTodoItemsMockRecorder) UpdateItemDone(ctx, id, todoID, done interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateItemDone", reflect.TypeOf((*MockTodoItems)(nil).UpdateItemDone), ctx, id, todoID, done)
+}
+
+// MockCalendars is a mock of Calendars interface.
+type MockCalendars struct {
+	ctrl     *gomock.Controller
+	recorder *MockCalendarsMockRecorder
+}
+
+// MockCalendarsMockRecorder is the mock recorder for MockCalendars.
+type MockCalendarsMockRecorder struct {
+	mock *MockCalendars
+}
+
+// NewMockCalendars creates a new mock instance.
+func NewMockCalendars(ctrl *gomock.Controller) *MockCalendars {
+	mock := &MockCalendars{ctrl: ctrl}
+	mock.recorder = &MockCalendarsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCalendars) EXPECT() *MockCalendarsMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockCalendars) Create(ctx context.Context, calendar domain.Calendar) (domain.Calendar, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, calendar)
+	ret0, _ := ret[0].(domain.Calendar)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockCalendarsMockRecorder) Create(ctx, calendar interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockCalendars)(nil).Create), ctx, calendar)
+}
+
+// DeleteCalendar mocks base method.
+func (m *MockCalendars) DeleteCalendar(ctx context.Context, id, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCalendar", ctx, id, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCalendar indicates an expected call of DeleteCalendar.
+func (mr *MockCalendarsMockRecorder) DeleteCalendar(ctx, id, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCalendar", reflect.TypeOf((*MockCalendars)(nil).DeleteCalendar), ctx, id, userID)
+}
+
+// GetCalendarByID mocks base method.
+func (m *MockCalendars) GetCalendarByID(ctx context.Context, id string) (domain.Calendar, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCalendarByID", ctx, id)
+	ret0, _ := ret[0].(domain.Calendar)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCalendarByID indicates an expected call of GetCalendarByID.
+func (mr *MockCalendarsMockRecorder) GetCalendarByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCalendarByID", reflect.TypeOf((*MockCalendars)(nil).GetCalendarByID), ctx, id)
+}
+
+// GetCalendars mocks base method.
+func (m *MockCalendars) GetCalendars(ctx context.Context, userID string) ([]domain.Calendar, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCalendars", ctx, userID)
+	ret0, _ := ret[0].([]domain.Calendar)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCalendars indicates an expected call of GetCalendars.
+func (mr *MockCalendarsMockRecorder) GetCalendars(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCalendars", reflect.TypeOf((*MockCalendars)(nil).GetCalendars), ctx, userID)
+}
+
 // MockRedis is a mock of Redis interface.
 type MockRedis struct {
 	ctrl     *gomock.Controller
@@ -303,6 +938,21 @@ func (mr *MockRedisMockRecorder) Get(key interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockRedis)(nil).Get), key)
 }
 
+// Incr mocks base method.
+func (m *MockRedis) Incr(key string, expiration time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Incr", key, expiration)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Incr indicates an expected call of Incr.
+func (mr *MockRedisMockRecorder) Incr(key, expiration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Incr", reflect.TypeOf((*MockRedis)(nil).Incr), key, expiration)
+}
+
 // Set mocks base method.
 func (m *MockRedis) Set(key, value string, expiration time.Duration) error {
 	m.ctrl.T.Helper()
@@ -0,0 +1,137 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CalendarRepo struct {
+	collection *mongo.Collection
+}
+
+func NewCalendarRepo(db *mongo.Database) *CalendarRepo {
+	return &CalendarRepo{db.Collection(calendarsCollection)}
+}
+
+// EnsureIndexes creates the index the calendars of a user are listed by.
+func (r *CalendarRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "name", Value: 1}},
+		Options: options.Index().SetName("user_id_name"),
+	})
+	if err != nil {
+		logger.Errorf("r.collection.Indexes().CreateOne(): %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *CalendarRepo) Create(ctx context.Context, calendar domain.Calendar) (domain.Calendar, error) {
+	if _, err := primitive.ObjectIDFromHex(calendar.UserID); err != nil {
+		return domain.Calendar{}, domain.ErrNotFound
+	}
+
+	doc := newCalendarDocument(calendar)
+	doc.ID = primitive.NilObjectID
+
+	result, err := r.collection.InsertOne(ctx, doc)
+	if err != nil {
+		logger.Errorf("r.collection.InsertOne(): %v", err)
+		return domain.Calendar{}, domain.ErrInternalServer
+	}
+
+	id, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		logger.Errorf("result.InsertedID.(primitive.ObjectID): %v", ok)
+		return domain.Calendar{}, domain.ErrInternalServer
+	}
+
+	calendar.ID = id.Hex()
+
+	return calendar, nil
+}
+
+func (r *CalendarRepo) GetCalendarByID(ctx context.Context, id string) (domain.Calendar, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.Calendar{}, domain.ErrNotFound
+	}
+
+	var calendar calendarDocument
+	if err := r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&calendar); err != nil {
+		logger.Errorf("r.collection.FindOne(): %v", err)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.Calendar{}, domain.ErrNotFound
+		}
+
+		return domain.Calendar{}, domain.ErrInternalServer
+	}
+
+	return calendar.toDomain(), nil
+}
+
+func (r *CalendarRepo) GetCalendars(ctx context.Context, userID string) ([]domain.Calendar, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, nil
+	}
+
+	opts := options.Find().
+		SetProjection(bson.M{"days": 0}).
+		SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}})
+	cur, err := r.collection.Find(ctx, bson.M{"user_id": id}, opts)
+	if err != nil {
+		logger.Errorf("r.collection.Find(): %v", err)
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var calendars []domain.Calendar
+	for cur.Next(ctx) {
+		var calendar calendarDocument
+		if err := cur.Decode(&calendar); err != nil {
+			logger.Errorf("cur.Decode(): %v", err)
+			return nil, err
+		}
+		calendars = append(calendars, calendar.toDomain())
+	}
+
+	if err := cur.Err(); err != nil {
+		logger.Errorf("cur.Err(): %v", err)
+		return nil, err
+	}
+
+	return calendars, nil
+}
+
+func (r *CalendarRepo) DeleteCalendar(ctx context.Context, id string, userID string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+
+	ownerID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID, "user_id": ownerID})
+	if err != nil {
+		logger.Errorf("r.collection.DeleteOne(): %v", err)
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
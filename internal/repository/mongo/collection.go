@@ -3,4 +3,11 @@ package mongo
 const (
 	usersCollection = "users"
 	todoCollection  = "todo"
+	itemsCollection = "todo_items"
+
+	calendarsCollection = "calendars"
+	sessionsCollection  = "sessions"
+
+	personalTokensCollection = "personal_tokens"
+	mfaCollection            = "mfa"
 )
@@ -0,0 +1,146 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MFARepo struct {
+	collection *mongo.Collection
+}
+
+func NewMFARepo(db *mongo.Database) *MFARepo {
+	return &MFARepo{
+		collection: db.Collection(mfaCollection),
+	}
+}
+
+func (r *MFARepo) GetMFA(ctx context.Context, userID string) (domain.MFA, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return domain.MFA{}, domain.ErrNotFound
+	}
+
+	var mfa mfaDocument
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&mfa); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.MFA{}, domain.ErrNotFound
+		}
+
+		logger.Errorf("r.collection.FindOne(): %v", err)
+		return domain.MFA{}, err
+	}
+
+	return mfa.toDomain(), nil
+}
+
+func (r *MFARepo) SetMFA(ctx context.Context, mfa domain.MFA) error {
+	if _, err := primitive.ObjectIDFromHex(mfa.UserID); err != nil {
+		return domain.ErrNotFound
+	}
+
+	doc := newMFADocument(mfa)
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": doc.UserID}, doc, options.Replace().SetUpsert(true))
+	if err != nil {
+		logger.Errorf("r.collection.ReplaceOne(): %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *MFARepo) SetRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+
+	if codeHashes == nil {
+		codeHashes = []string{}
+	}
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"recovery_codes": codeHashes}},
+	)
+	if err != nil {
+		logger.Errorf("r.collection.UpdateOne(): %v", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *MFARepo) UseStep(ctx context.Context, userID string, step int64) error {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "last_step": bson.M{"$lt": step}},
+		bson.M{"$set": bson.M{"last_step": step}},
+	)
+	if err != nil {
+		logger.Errorf("r.collection.UpdateOne(): %v", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *MFARepo) UseRecoveryCode(ctx context.Context, userID string, codeHash string) error {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "recovery_codes": codeHash},
+		bson.M{"$pull": bson.M{"recovery_codes": codeHash}},
+	)
+	if err != nil {
+		logger.Errorf("r.collection.UpdateOne(): %v", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *MFARepo) DeleteMFA(ctx context.Context, userID string) error {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		logger.Errorf("r.collection.DeleteOne(): %v", err)
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,303 @@
+package mongo
+
+import (
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Documents below describe how entities are laid out in Mongo. The domain
+// types only carry string identifiers, so hex ObjectIDs are converted here.
+
+// userDocument.EmailUnverified is stored instead of the verified flag, so
+// that the users registered before verification existed count as verified.
+type userDocument struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty"`
+	UserName        string             `bson:"username"`
+	Email           string             `bson:"email"`
+	EmailUnverified bool               `bson:"email_unverified,omitempty"`
+	Password        string             `bson:"password"`
+	Timezone        string             `bson:"timezone,omitempty"`
+	Calendar        string             `bson:"calendar,omitempty"`
+	Language        string             `bson:"language,omitempty"`
+	CreateAt        string             `bson:"create_at"`
+	DeleteAt        *time.Time         `bson:"delete_at,omitempty"`
+}
+
+type todoDocument struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	UserID      primitive.ObjectID `bson:"user_id"`
+	Title       string             `bson:"title"`
+	Description string             `bson:"description"`
+	Priority    string             `bson:"priority"`
+	Tags        []string           `bson:"tags"`
+	ActiveAt    time.Time          `bson:"activeAt"`
+	DueAt       *time.Time         `bson:"dueAt,omitempty"`
+	Recurrence  string             `bson:"recurrence,omitempty"`
+	Occurrence  int                `bson:"occurrence,omitempty"`
+	Author      string             `bson:"author"`
+	Status      string             `bson:"status"`
+}
+
+type calendarDocument struct {
+	ID     primitive.ObjectID    `bson:"_id,omitempty"`
+	UserID primitive.ObjectID    `bson:"user_id"`
+	Name   string                `bson:"name"`
+	Days   []calendarDayDocument `bson:"days,omitempty"`
+}
+
+type calendarDayDocument struct {
+	Date   string `bson:"date"`
+	DayOff bool   `bson:"day_off"`
+	Reason string `bson:"reason,omitempty"`
+}
+
+type sessionDocument struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty"`
+	UserID           primitive.ObjectID `bson:"user_id"`
+	RefreshTokenHash string             `bson:"refresh_token_hash"`
+	Device           string             `bson:"device,omitempty"`
+	UserAgent        string             `bson:"user_agent,omitempty"`
+	IP               string             `bson:"ip,omitempty"`
+	CreatedAt        time.Time          `bson:"created_at"`
+	LastUsedAt       time.Time          `bson:"last_used_at"`
+	ExpirationAt     time.Time          `bson:"expiration_at"`
+}
+
+type personalTokenDocument struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	UserID     primitive.ObjectID `bson:"user_id"`
+	TokenHash  string             `bson:"token_hash"`
+	Name       string             `bson:"name"`
+	Scopes     []string           `bson:"scopes"`
+	CreatedAt  time.Time          `bson:"created_at"`
+	LastUsedAt *time.Time         `bson:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time         `bson:"expires_at,omitempty"`
+}
+
+// mfaDocument is keyed by the user, a user has one MFA.
+type mfaDocument struct {
+	UserID        primitive.ObjectID `bson:"_id"`
+	Secret        string             `bson:"secret"`
+	Enabled       bool               `bson:"enabled"`
+	LastStep      int64              `bson:"last_step"`
+	RecoveryCodes []string           `bson:"recovery_codes"`
+}
+
+type todoItemDocument struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty"`
+	TodoID   primitive.ObjectID `bson:"todo_id"`
+	Title    string             `bson:"title"`
+	Done     bool               `bson:"done"`
+	Position int                `bson:"position"`
+}
+
+func newUserDocument(u domain.User) userDocument {
+	id, _ := primitive.ObjectIDFromHex(u.ID)
+
+	return userDocument{
+		ID:              id,
+		UserName:        u.UserName,
+		Email:           u.Email,
+		EmailUnverified: !u.EmailVerified,
+		Password:        u.Password,
+		Timezone:        u.Timezone,
+		Calendar:        u.Calendar,
+		Language:        u.Language,
+		CreateAt:        u.CreateAt,
+		DeleteAt:        u.DeleteAt,
+	}
+}
+
+func (u userDocument) toDomain() domain.User {
+	return domain.User{
+		ID:            u.ID.Hex(),
+		UserName:      u.UserName,
+		Email:         u.Email,
+		EmailVerified: !u.EmailUnverified,
+		Password:      u.Password,
+		Timezone:      u.Timezone,
+		Calendar:      u.Calendar,
+		Language:      u.Language,
+		CreateAt:      u.CreateAt,
+		DeleteAt:      u.DeleteAt,
+	}
+}
+
+func newTodoDocument(t domain.Todo) todoDocument {
+	id, _ := primitive.ObjectIDFromHex(t.ID)
+	userID, _ := primitive.ObjectIDFromHex(t.UserID)
+
+	return todoDocument{
+		ID:          id,
+		UserID:      userID,
+		Title:       t.Title,
+		Description: t.Description,
+		Priority:    t.Priority,
+		Tags:        t.Tags,
+		ActiveAt:    t.ActiveAt,
+		DueAt:       t.DueAt,
+		Recurrence:  t.Recurrence,
+		Occurrence:  t.Occurrence,
+		Author:      t.Author,
+		Status:      t.Status,
+	}
+}
+
+func (t todoDocument) toDomain() domain.Todo {
+	todo := domain.Todo{
+		ID:          t.ID.Hex(),
+		UserID:      t.UserID.Hex(),
+		Title:       t.Title,
+		Description: t.Description,
+		Priority:    t.Priority,
+		ActiveAt:    t.ActiveAt.UTC(),
+		Recurrence:  t.Recurrence,
+		Occurrence:  t.Occurrence,
+		Author:      t.Author,
+		Status:      t.Status,
+	}
+
+	if len(t.Tags) > 0 {
+		todo.Tags = t.Tags
+	}
+
+	if t.DueAt != nil {
+		due := t.DueAt.UTC()
+		todo.DueAt = &due
+	}
+
+	return todo
+}
+
+func (i todoItemDocument) toDomain() domain.TodoItem {
+	return domain.TodoItem{
+		ID:       i.ID.Hex(),
+		TodoID:   i.TodoID.Hex(),
+		Title:    i.Title,
+		Done:     i.Done,
+		Position: i.Position,
+	}
+}
+
+func newCalendarDocument(c domain.Calendar) calendarDocument {
+	id, _ := primitive.ObjectIDFromHex(c.ID)
+	userID, _ := primitive.ObjectIDFromHex(c.UserID)
+
+	doc := calendarDocument{
+		ID:     id,
+		UserID: userID,
+		Name:   c.Name,
+	}
+	for _, day := range c.Days {
+		doc.Days = append(doc.Days, calendarDayDocument(day))
+	}
+
+	return doc
+}
+
+func (c calendarDocument) toDomain() domain.Calendar {
+	calendar := domain.Calendar{
+		ID:     c.ID.Hex(),
+		UserID: c.UserID.Hex(),
+		Name:   c.Name,
+	}
+	for _, day := range c.Days {
+		calendar.Days = append(calendar.Days, domain.CalendarDay(day))
+	}
+
+	return calendar
+}
+
+func newSessionDocument(s domain.Session) sessionDocument {
+	id, _ := primitive.ObjectIDFromHex(s.ID)
+	userID, _ := primitive.ObjectIDFromHex(s.UserID)
+
+	return sessionDocument{
+		ID:               id,
+		UserID:           userID,
+		RefreshTokenHash: s.RefreshTokenHash,
+		Device:           s.Device,
+		UserAgent:        s.UserAgent,
+		IP:               s.IP,
+		CreatedAt:        s.CreatedAt,
+		LastUsedAt:       s.LastUsedAt,
+		ExpirationAt:     s.ExpirationAt,
+	}
+}
+
+func (s sessionDocument) toDomain() domain.Session {
+	return domain.Session{
+		ID:               s.ID.Hex(),
+		UserID:           s.UserID.Hex(),
+		RefreshTokenHash: s.RefreshTokenHash,
+		Device:           s.Device,
+		UserAgent:        s.UserAgent,
+		IP:               s.IP,
+		CreatedAt:        s.CreatedAt,
+		LastUsedAt:       s.LastUsedAt,
+		ExpirationAt:     s.ExpirationAt,
+	}
+}
+
+func newPersonalTokenDocument(t domain.PersonalToken) personalTokenDocument {
+	id, _ := primitive.ObjectIDFromHex(t.ID)
+	userID, _ := primitive.ObjectIDFromHex(t.UserID)
+
+	return personalTokenDocument{
+		ID:         id,
+		UserID:     userID,
+		TokenHash:  t.TokenHash,
+		Name:       t.Name,
+		Scopes:     t.Scopes,
+		CreatedAt:  t.CreatedAt,
+		LastUsedAt: t.LastUsedAt,
+		ExpiresAt:  t.ExpiresAt,
+	}
+}
+
+func (t personalTokenDocument) toDomain() domain.PersonalToken {
+	return domain.PersonalToken{
+		ID:         t.ID.Hex(),
+		UserID:     t.UserID.Hex(),
+		TokenHash:  t.TokenHash,
+		Name:       t.Name,
+		Scopes:     t.Scopes,
+		CreatedAt:  t.CreatedAt,
+		LastUsedAt: t.LastUsedAt,
+		ExpiresAt:  t.ExpiresAt,
+	}
+}
+
+func newMFADocument(m domain.MFA) mfaDocument {
+	userID, _ := primitive.ObjectIDFromHex(m.UserID)
+
+	codes := m.RecoveryCodes
+	if codes == nil {
+		codes = []string{}
+	}
+
+	return mfaDocument{
+		UserID:        userID,
+		Secret:        m.Secret,
+		Enabled:       m.Enabled,
+		LastStep:      m.LastStep,
+		RecoveryCodes: codes,
+	}
+}
+
+func (m mfaDocument) toDomain() domain.MFA {
+	var codes []string
+	if len(m.RecoveryCodes) > 0 {
+		codes = m.RecoveryCodes
+	}
+
+	return domain.MFA{
+		UserID:        m.UserID.Hex(),
+		Secret:        m.Secret,
+		Enabled:       m.Enabled,
+		LastStep:      m.LastStep,
+		RecoveryCodes: codes,
+	}
+}
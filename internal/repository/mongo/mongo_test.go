@@ -3,25 +3,72 @@ package mongo
 import (
 	"context"
 	"fmt"
+	"testing"
 	"time"
 
 	"github.com/begenov/region-llc-task/internal/config"
+	"github.com/begenov/region-llc-task/internal/repository/repositorytest"
 	"github.com/begenov/region-llc-task/pkg/database"
 	"github.com/begenov/region-llc-task/pkg/logger"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 var userRepo *UserRepo
 var todoRepo *TodoRepo
-var ctx context.Context
+var itemRepo *TodoItemRepo
+var calendarRepo *CalendarRepo
+var sessionRepo *SessionRepo
+var personalTokenRepo *PersonalTokenRepo
+var mfaRepo *MFARepo
+var ctx = context.Background()
 
 func init() {
 	client := createTestDatabaseClient()
 	db := client.Database("test")
 	userRepo = NewUserRepo(db)
 	todoRepo = NewTodoRepo(db)
+	itemRepo = NewTodoItemRepo(db)
+	calendarRepo = NewCalendarRepo(db)
+	sessionRepo = NewSessionRepo(db)
+	personalTokenRepo = NewPersonalTokenRepo(db)
+	mfaRepo = NewMFARepo(db)
+
+	if err := todoRepo.EnsureIndexes(context.Background()); err != nil {
+		logger.Fatalf("todoRepo.EnsureIndexes(): %v", err)
+	}
+
+	if err := itemRepo.EnsureIndexes(context.Background()); err != nil {
+		logger.Fatalf("itemRepo.EnsureIndexes(): %v", err)
+	}
+
+	if err := calendarRepo.EnsureIndexes(context.Background()); err != nil {
+		logger.Fatalf("calendarRepo.EnsureIndexes(): %v", err)
+	}
+
+	if err := sessionRepo.EnsureIndexes(context.Background()); err != nil {
+		logger.Fatalf("sessionRepo.EnsureIndexes(): %v", err)
+	}
+
+	if err := personalTokenRepo.EnsureIndexes(context.Background()); err != nil {
+		logger.Fatalf("personalTokenRepo.EnsureIndexes(): %v", err)
+	}
+}
+
+func TestRepositories(t *testing.T) {
+	repositorytest.Run(t, repositorytest.Repositories{
+		Users:          userRepo,
+		Todo:           todoRepo,
+		Items:          itemRepo,
+		Calendars:      calendarRepo,
+		Sessions:       sessionRepo,
+		PersonalTokens: personalTokenRepo,
+		MFA:            mfaRepo,
+		MissingID:      primitive.NewObjectID().Hex(),
+		ValidatesIDs:   true,
+	})
 }
 
 func createTestDatabaseClient() *mongo.Client {
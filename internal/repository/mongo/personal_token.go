@@ -0,0 +1,181 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PersonalTokenRepo struct {
+	collection *mongo.Collection
+}
+
+func NewPersonalTokenRepo(db *mongo.Database) *PersonalTokenRepo {
+	return &PersonalTokenRepo{
+		collection: db.Collection(personalTokensCollection),
+	}
+}
+
+// EnsureIndexes creates the indexes personal tokens are looked up by, expired
+// tokens are removed by the TTL index, tokens without expires_at are kept.
+func (r *PersonalTokenRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetName("token_hash").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("user_id_created_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetName("expires_at").SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		logger.Errorf("r.collection.Indexes().CreateMany(): %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *PersonalTokenRepo) Create(ctx context.Context, token domain.PersonalToken) (domain.PersonalToken, error) {
+	if _, err := primitive.ObjectIDFromHex(token.UserID); err != nil {
+		return domain.PersonalToken{}, domain.ErrNotFound
+	}
+
+	doc := newPersonalTokenDocument(token)
+	doc.ID = primitive.NilObjectID
+
+	result, err := r.collection.InsertOne(ctx, doc)
+	if err != nil {
+		logger.Errorf("r.collection.InsertOne(): %v", err)
+		return domain.PersonalToken{}, domain.ErrInternalServer
+	}
+
+	id, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		logger.Errorf("result.InsertedID.(primitive.ObjectID): %v", ok)
+		return domain.PersonalToken{}, domain.ErrInternalServer
+	}
+
+	token.ID = id.Hex()
+
+	return token, nil
+}
+
+func (r *PersonalTokenRepo) GetByTokenHash(ctx context.Context, tokenHash string) (domain.PersonalToken, error) {
+	var token personalTokenDocument
+	err := r.collection.FindOne(ctx, bson.M{
+		"token_hash": tokenHash,
+		"expires_at": notExpired(),
+	}).Decode(&token)
+	if err != nil {
+		logger.Errorf("r.collection.FindOne(): %v", err)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.PersonalToken{}, domain.ErrNotFound
+		}
+
+		return domain.PersonalToken{}, domain.ErrInternalServer
+	}
+
+	return token.toDomain(), nil
+}
+
+func (r *PersonalTokenRepo) GetTokens(ctx context.Context, userID string) ([]domain.PersonalToken, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, nil
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	cur, err := r.collection.Find(ctx, bson.M{"user_id": id, "expires_at": notExpired()}, opts)
+	if err != nil {
+		logger.Errorf("r.collection.Find(): %v", err)
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var tokens []domain.PersonalToken
+	for cur.Next(ctx) {
+		var token personalTokenDocument
+		if err := cur.Decode(&token); err != nil {
+			logger.Errorf("cur.Decode(): %v", err)
+			return nil, err
+		}
+		tokens = append(tokens, token.toDomain())
+	}
+
+	if err := cur.Err(); err != nil {
+		logger.Errorf("cur.Err(): %v", err)
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (r *PersonalTokenRepo) SetLastUsed(ctx context.Context, id string, lastUsedAt time.Time) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": objectID, "expires_at": notExpired()},
+		bson.M{"$set": bson.M{"last_used_at": lastUsedAt}},
+	)
+	if err != nil {
+		logger.Errorf("r.collection.UpdateOne(): %v", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *PersonalTokenRepo) DeleteToken(ctx context.Context, id string, userID string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+
+	ownerID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{
+		"_id":        objectID,
+		"user_id":    ownerID,
+		"expires_at": notExpired(),
+	})
+	if err != nil {
+		logger.Errorf("r.collection.DeleteOne(): %v", err)
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// notExpired matches the expires_at of a token that has not expired yet,
+// including a token without one. The TTL index removes expired tokens only
+// once a minute.
+func notExpired() bson.M {
+	return bson.M{"$not": bson.M{"$lte": time.Now()}}
+}
@@ -0,0 +1,283 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/auth"
+	"github.com/begenov/region-llc-task/pkg/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SessionRepo struct {
+	collection *mongo.Collection
+	users      *mongo.Collection
+}
+
+func NewSessionRepo(db *mongo.Database) *SessionRepo {
+	return &SessionRepo{
+		collection: db.Collection(sessionsCollection),
+		users:      db.Collection(usersCollection),
+	}
+}
+
+// Migrate moves the session embedded in the users, one per user, to the
+// sessions collection, and replaces the stored refresh tokens by their hash.
+func (r *SessionRepo) Migrate(ctx context.Context) error {
+	filter := bson.M{"session.refresh_token": bson.M{"$exists": true}}
+
+	cur, err := r.users.Aggregate(ctx, bson.A{
+		bson.M{"$match": filter},
+		bson.M{"$project": bson.M{
+			"_id":           0,
+			"user_id":       "$_id",
+			"refresh_token": "$session.refresh_token",
+			"created_at":    "$$NOW",
+			"last_used_at":  "$$NOW",
+			"expiration_at": "$session.expiration_at",
+		}},
+		bson.M{"$merge": bson.M{"into": sessionsCollection}},
+	})
+	if err != nil {
+		logger.Errorf("r.users.Aggregate(): %v", err)
+		return err
+	}
+	cur.Close(ctx)
+
+	if _, err := r.users.UpdateMany(ctx, filter, bson.M{"$unset": bson.M{"session": ""}}); err != nil {
+		logger.Errorf("r.users.UpdateMany(): %v", err)
+		return err
+	}
+
+	return r.hashRefreshTokens(ctx)
+}
+
+// hashRefreshTokens replaces the refresh tokens stored as they are, the unique
+// index on them is dropped first since the hashed sessions no longer have it.
+func (r *SessionRepo) hashRefreshTokens(ctx context.Context) error {
+	if _, err := r.collection.Indexes().DropOne(ctx, "refresh_token"); err != nil && !isNotFound(err) {
+		logger.Errorf("r.collection.Indexes().DropOne(): %v", err)
+		return err
+	}
+
+	filter := bson.M{"refresh_token": bson.M{"$exists": true}}
+
+	cur, err := r.collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"refresh_token": 1}))
+	if err != nil {
+		logger.Errorf("r.collection.Find(): %v", err)
+		return err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var doc struct {
+			ID           primitive.ObjectID `bson:"_id"`
+			RefreshToken string             `bson:"refresh_token"`
+		}
+		if err := cur.Decode(&doc); err != nil {
+			logger.Errorf("cur.Decode(): %v", err)
+			return err
+		}
+
+		_, err := r.collection.UpdateByID(ctx, doc.ID, bson.M{
+			"$set":   bson.M{"refresh_token_hash": auth.HashToken(doc.RefreshToken)},
+			"$unset": bson.M{"refresh_token": ""},
+		})
+		if err != nil {
+			logger.Errorf("r.collection.UpdateByID(): %v", err)
+			return err
+		}
+	}
+
+	if err := cur.Err(); err != nil {
+		logger.Errorf("cur.Err(): %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// EnsureIndexes creates the indexes sessions are looked up by, expired
+// sessions are removed by the TTL index.
+func (r *SessionRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "refresh_token_hash", Value: 1}},
+			Options: options.Index().SetName("refresh_token_hash").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "last_used_at", Value: -1}},
+			Options: options.Index().SetName("user_id_last_used_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "expiration_at", Value: 1}},
+			Options: options.Index().SetName("expiration_at").SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		logger.Errorf("r.collection.Indexes().CreateMany(): %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SessionRepo) Create(ctx context.Context, session domain.Session) (domain.Session, error) {
+	if _, err := primitive.ObjectIDFromHex(session.UserID); err != nil {
+		return domain.Session{}, domain.ErrNotFound
+	}
+
+	doc := newSessionDocument(session)
+	doc.ID = primitive.NilObjectID
+
+	result, err := r.collection.InsertOne(ctx, doc)
+	if err != nil {
+		logger.Errorf("r.collection.InsertOne(): %v", err)
+		return domain.Session{}, domain.ErrInternalServer
+	}
+
+	id, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		logger.Errorf("result.InsertedID.(primitive.ObjectID): %v", ok)
+		return domain.Session{}, domain.ErrInternalServer
+	}
+
+	session.ID = id.Hex()
+
+	return session, nil
+}
+
+func (r *SessionRepo) GetByRefreshToken(ctx context.Context, refreshTokenHash string) (domain.Session, error) {
+	var session sessionDocument
+	err := r.collection.FindOne(ctx, bson.M{
+		"refresh_token_hash": refreshTokenHash,
+		"expiration_at":      bson.M{"$gt": time.Now()},
+	}).Decode(&session)
+	if err != nil {
+		logger.Errorf("r.collection.FindOne(): %v", err)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.Session{}, domain.ErrNotFound
+		}
+
+		return domain.Session{}, domain.ErrInternalServer
+	}
+
+	return session.toDomain(), nil
+}
+
+func (r *SessionRepo) GetSessions(ctx context.Context, userID string) ([]domain.Session, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, nil
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "last_used_at", Value: -1}, {Key: "_id", Value: 1}})
+	cur, err := r.collection.Find(ctx, bson.M{"user_id": id, "expiration_at": bson.M{"$gt": time.Now()}}, opts)
+	if err != nil {
+		logger.Errorf("r.collection.Find(): %v", err)
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var sessions []domain.Session
+	for cur.Next(ctx) {
+		var session sessionDocument
+		if err := cur.Decode(&session); err != nil {
+			logger.Errorf("cur.Decode(): %v", err)
+			return nil, err
+		}
+		sessions = append(sessions, session.toDomain())
+	}
+
+	if err := cur.Err(); err != nil {
+		logger.Errorf("cur.Err(): %v", err)
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (r *SessionRepo) RotateRefreshToken(ctx context.Context, session domain.Session, previousHash string) error {
+	id, err := primitive.ObjectIDFromHex(session.ID)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+
+	filter := bson.M{
+		"_id":                id,
+		"refresh_token_hash": previousHash,
+		"expiration_at":      bson.M{"$gt": time.Now()},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+		"refresh_token_hash": session.RefreshTokenHash,
+		"user_agent":         session.UserAgent,
+		"ip":                 session.IP,
+		"last_used_at":       session.LastUsedAt,
+		"expiration_at":      session.ExpirationAt,
+	}})
+	if err != nil {
+		logger.Errorf("r.collection.UpdateOne(): %v", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *SessionRepo) DeleteSession(ctx context.Context, id string, userID string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+
+	ownerID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{
+		"_id":           objectID,
+		"user_id":       ownerID,
+		"expiration_at": bson.M{"$gt": time.Now()},
+	})
+	if err != nil {
+		logger.Errorf("r.collection.DeleteOne(): %v", err)
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *SessionRepo) DeleteSessions(ctx context.Context, userID string) error {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil
+	}
+
+	if _, err := r.collection.DeleteMany(ctx, bson.M{"user_id": id}); err != nil {
+		logger.Errorf("r.collection.DeleteMany(): %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// isNotFound reports a command on an index or a collection that does not
+// exist: IndexNotFound or NamespaceNotFound.
+func isNotFound(err error) bool {
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && (cmdErr.Code == 27 || cmdErr.Code == 26)
+}
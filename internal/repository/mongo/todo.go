@@ -3,12 +3,14 @@ package mongo
 import (
 	"context"
 	"errors"
+	"regexp"
 
 	"github.com/begenov/region-llc-task/internal/domain"
 	"github.com/begenov/region-llc-task/pkg/logger"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type TodoRepo struct {
@@ -20,7 +22,7 @@ func NewTodoRepo(db *mongo.Database) *TodoRepo {
 }
 
 func (r *TodoRepo) Create(ctx context.Context, todo domain.Todo) (domain.Todo, error) {
-	result, err := r.collection.InsertOne(ctx, todo)
+	result, err := r.collection.InsertOne(ctx, newTodoDocument(todo))
 	if err != nil {
 		logger.Errorf("r.collection.InsertOne(): %v", err)
 		return domain.Todo{}, domain.ErrInternalServer
@@ -32,13 +34,18 @@ func (r *TodoRepo) Create(ctx context.Context, todo domain.Todo) (domain.Todo, e
 		return domain.Todo{}, domain.ErrInternalServer
 	}
 
-	todo.ID = id
+	todo.ID = id.Hex()
 
 	return todo, nil
 }
 
-func (r *TodoRepo) GetCountByTitle(ctx context.Context, title string, id primitive.ObjectID) (int64, error) {
-	c, err := r.collection.CountDocuments(ctx, bson.M{"title": title, "user_id": id})
+func (r *TodoRepo) GetCountByTitle(ctx context.Context, title string, id string) (int64, error) {
+	userID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return 0, domain.ErrNotFound
+	}
+
+	c, err := r.collection.CountDocuments(ctx, bson.M{"title": title, "user_id": userID})
 	if errors.Is(err, mongo.ErrNoDocuments) {
 		return 0, domain.ErrNotFound
 	}
@@ -46,9 +53,14 @@ func (r *TodoRepo) GetCountByTitle(ctx context.Context, title string, id primiti
 	return c, err
 }
 
-func (r *TodoRepo) GetTodoByID(ctx context.Context, id primitive.ObjectID) (domain.Todo, error) {
-	var todo domain.Todo
-	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&todo); err != nil {
+func (r *TodoRepo) GetTodoByID(ctx context.Context, id string) (domain.Todo, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.Todo{}, domain.ErrTodoInvalidId
+	}
+
+	var todo todoDocument
+	if err := r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&todo); err != nil {
 		logger.Errorf("r.collection.FindOne(): %v", err)
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return domain.Todo{}, domain.ErrNotFound
@@ -57,11 +69,16 @@ func (r *TodoRepo) GetTodoByID(ctx context.Context, id primitive.ObjectID) (doma
 		return domain.Todo{}, err
 	}
 
-	return todo, nil
+	return todo.toDomain(), nil
 }
 
 func (r *TodoRepo) UpdateTodoID(ctx context.Context, todo domain.Todo) error {
-	_, err := r.collection.UpdateByID(ctx, todo.ID, bson.M{"$set": bson.M{"_id": todo.ID}})
+	objectID, err := primitive.ObjectIDFromHex(todo.ID)
+	if err != nil {
+		return domain.ErrTodoInvalidId
+	}
+
+	_, err = r.collection.UpdateByID(ctx, objectID, bson.M{"$set": bson.M{"_id": objectID}})
 	if errors.Is(err, mongo.ErrNoDocuments) {
 		return domain.ErrNotFound
 	}
@@ -70,39 +87,78 @@ func (r *TodoRepo) UpdateTodoID(ctx context.Context, todo domain.Todo) error {
 }
 
 func (r *TodoRepo) UpdateTodo(ctx context.Context, todo domain.Todo) error {
-	_, err := r.collection.UpdateByID(ctx, todo.ID, bson.M{"$set": bson.M{"title": todo.Title, "activeAt": todo.ActiveAt}})
+	objectID, err := primitive.ObjectIDFromHex(todo.ID)
 	if err != nil {
-		logger.Errorf("r.collection.UpdateByID(): %v", err)
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return domain.ErrNotFound
-		}
+		return domain.ErrTodoInvalidId
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(todo.UserID)
+	if err != nil {
+		return domain.ErrNotFound
+	}
 
+	filter := bson.M{"_id": objectID, "user_id": userObjectID}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+		"title":       todo.Title,
+		"description": todo.Description,
+		"priority":    todo.Priority,
+		"tags":        todo.Tags,
+		"activeAt":    todo.ActiveAt,
+		"dueAt":       todo.DueAt,
+		"recurrence":  todo.Recurrence,
+	}})
+	if err != nil {
+		logger.Errorf("r.collection.UpdateOne(): %v", err)
 		return err
 	}
 
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
 	return nil
 }
 
-func (r *TodoRepo) DeleteTodoByID(ctx context.Context, id primitive.ObjectID) error {
-	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+func (r *TodoRepo) DeleteTodoByID(ctx context.Context, id string, userID string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		logger.Errorf("r.collection.DeleteOne(): %v", err)
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return domain.ErrNotFound
-		}
+		return domain.ErrTodoInvalidId
+	}
 
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID, "user_id": userObjectID})
+	if err != nil {
+		logger.Errorf("r.collection.DeleteOne(): %v", err)
 		return err
 	}
 
+	if result.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+
 	return nil
 }
 
-func (r *TodoRepo) UpdateTodoDoneByID(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) (domain.Todo, error) {
-	filter := bson.M{"_id": id, "user_id": userID}
+func (r *TodoRepo) UpdateTodoDoneByID(ctx context.Context, id string, userID string) (domain.Todo, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.Todo{}, domain.ErrTodoInvalidId
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return domain.Todo{}, domain.ErrNotFound
+	}
+
+	filter := bson.M{"_id": objectID, "user_id": userObjectID}
 	update := bson.M{"$set": bson.M{"status": domain.Done}}
 
-	var updatedTodo domain.Todo
-	err := r.collection.FindOneAndUpdate(ctx, filter, update).Decode(&updatedTodo)
+	var updatedTodo todoDocument
+	err = r.collection.FindOneAndUpdate(ctx, filter, update).Decode(&updatedTodo)
 	if err != nil {
 		logger.Errorf("r.collection.FindOneAndUpdate(): %v", err)
 		if errors.Is(err, mongo.ErrNoDocuments) {
@@ -112,29 +168,43 @@ func (r *TodoRepo) UpdateTodoDoneByID(ctx context.Context, id primitive.ObjectID
 		return domain.Todo{}, err
 	}
 
-	return updatedTodo, nil
+	return updatedTodo.toDomain(), nil
 }
 
-func (r *TodoRepo) GetTodoByStatus(ctx context.Context, status string, userID primitive.ObjectID) ([]domain.Todo, error) {
-	var todos []domain.Todo
-	cur, err := r.collection.Find(ctx, bson.M{"status": status, "user_id": userID})
+func (r *TodoRepo) GetTodos(ctx context.Context, filter domain.TodoFilter) ([]domain.Todo, error) {
+	query, err := todoFilter(filter, true)
 	if err != nil {
-		logger.Errorf("r.collection.Find(): %v", err)
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, domain.ErrNotFound
-		}
+		return nil, err
+	}
 
+	field, direction := sortFields[filter.Sort], 1
+	if field == "" {
+		field = sortFields[domain.SortActiveAt]
+	}
+	if filter.Order == domain.OrderDesc {
+		direction = -1
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}})
+	if filter.Limit > 0 {
+		opts.SetLimit(int64(filter.Limit))
+	}
+
+	cur, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		logger.Errorf("r.collection.Find(): %v", err)
 		return nil, err
 	}
 	defer cur.Close(ctx)
 
+	var todos []domain.Todo
 	for cur.Next(ctx) {
-		var todo domain.Todo
+		var todo todoDocument
 		if err := cur.Decode(&todo); err != nil {
 			logger.Errorf("cur.Decode(): %v", err)
 			return nil, err
 		}
-		todos = append(todos, todo)
+		todos = append(todos, todo.toDomain())
 	}
 
 	if err := cur.Err(); err != nil {
@@ -144,3 +214,195 @@ func (r *TodoRepo) GetTodoByStatus(ctx context.Context, status string, userID pr
 
 	return todos, nil
 }
+
+func (r *TodoRepo) GetCountByFilter(ctx context.Context, filter domain.TodoFilter) (int64, error) {
+	query, err := todoFilter(filter, false)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		logger.Errorf("r.collection.CountDocuments(): %v", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Migrate converts activeAt of the todos stored as a date string, in UTC,
+// into a timestamp, and sets the medium priority of the todos created before
+// priorities, as the SQL storages default it.
+func (r *TodoRepo) Migrate(ctx context.Context) error {
+	_, err := r.collection.UpdateMany(ctx, bson.M{"activeAt": bson.M{"$type": "string"}}, bson.A{
+		bson.M{"$set": bson.M{"activeAt": bson.M{"$dateFromString": bson.M{
+			"dateString": "$activeAt",
+			"format":     "%Y-%m-%d",
+			"timezone":   "UTC",
+		}}}},
+	})
+	if err != nil {
+		logger.Errorf("r.collection.UpdateMany(): %v", err)
+		return err
+	}
+
+	_, err = r.collection.UpdateMany(ctx, bson.M{"priority": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"priority": domain.PriorityMedium}})
+	if err != nil {
+		logger.Errorf("r.collection.UpdateMany(priority): %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// EnsureIndexes creates the indexes the listing queries rely on: every query
+// is scoped to a user and sorted by one of sortFields with _id as a
+// tie-breaker. The title index also serves GetCountByTitle, the text index
+// serves SearchTodos and the multikey tags index the tag filter.
+func (r *TodoRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "activeAt", Value: 1}, {Key: "_id", Value: 1}},
+			Options: options.Index().SetName("user_id_activeAt_id"),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "title", Value: 1}, {Key: "_id", Value: 1}},
+			Options: options.Index().SetName("user_id_title_id"),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "status", Value: 1}, {Key: "activeAt", Value: 1}},
+			Options: options.Index().SetName("user_id_status_activeAt"),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "tags", Value: 1}},
+			Options: options.Index().SetName("user_id_tags"),
+		},
+		{
+			// Language "none" disables stemming and stop words, so that
+			// Russian, Kazakh and English titles are matched alike.
+			Keys: bson.D{{Key: "title", Value: "text"}, {Key: "description", Value: "text"}},
+			Options: options.Index().
+				SetName("title_description_text").
+				SetWeights(bson.M{"title": 3, "description": 1}).
+				SetDefaultLanguage("none"),
+		},
+	})
+	if err != nil {
+		logger.Errorf("r.collection.Indexes().CreateMany(): %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *TodoRepo) SearchTodos(ctx context.Context, userID string, query domain.SearchQuery, limit int) ([]domain.SearchResult, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, domain.ErrNotFound
+	}
+
+	filter := bson.M{"user_id": userObjectID, "$text": bson.M{"$search": query.String()}}
+	score := bson.M{"$meta": "textScore"}
+	opts := options.Find().
+		SetProjection(bson.M{"score": score}).
+		SetSort(bson.D{{Key: "score", Value: score}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit))
+
+	cur, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		logger.Errorf("r.collection.Find(): %v", err)
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var results []domain.SearchResult
+	for cur.Next(ctx) {
+		var doc struct {
+			todoDocument `bson:",inline"`
+			Score        float64 `bson:"score"`
+		}
+		if err := cur.Decode(&doc); err != nil {
+			logger.Errorf("cur.Decode(): %v", err)
+			return nil, err
+		}
+		results = append(results, domain.SearchResult{Todo: doc.toDomain(), Score: doc.Score})
+	}
+
+	if err := cur.Err(); err != nil {
+		logger.Errorf("cur.Err(): %v", err)
+		return nil, err
+	}
+
+	return results, nil
+}
+
+var sortFields = map[string]string{
+	domain.SortActiveAt: "activeAt",
+	domain.SortTitle:    "title",
+}
+
+// todoFilter builds the query document of a listing. The cursor condition is
+// only added when withCursor is set, so that the total count ignores it.
+func todoFilter(filter domain.TodoFilter, withCursor bool) (bson.M, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(filter.UserID)
+	if err != nil {
+		return nil, domain.ErrNotFound
+	}
+
+	query := bson.M{"user_id": userObjectID}
+
+	if len(filter.Statuses) > 0 {
+		query["status"] = bson.M{"$in": filter.Statuses}
+	}
+
+	activeAt := bson.M{}
+	if !filter.ActiveFrom.IsZero() {
+		activeAt["$gte"] = filter.ActiveFrom
+	}
+	if !filter.ActiveBefore.IsZero() {
+		activeAt["$lt"] = filter.ActiveBefore
+	}
+	if len(activeAt) > 0 {
+		query["activeAt"] = activeAt
+	}
+
+	if filter.Title != "" {
+		query["title"] = primitive.Regex{Pattern: regexp.QuoteMeta(filter.Title), Options: "i"}
+	}
+
+	if len(filter.Priorities) > 0 {
+		query["priority"] = bson.M{"$in": filter.Priorities}
+	}
+
+	if len(filter.Tags) > 0 {
+		query["tags"] = bson.M{"$all": filter.Tags}
+	}
+
+	if withCursor && filter.After != nil {
+		id, err := primitive.ObjectIDFromHex(filter.After.ID)
+		if err != nil {
+			return nil, domain.ErrInvalidCursor
+		}
+
+		field, op := sortFields[domain.SortTitle], "$gt"
+		var value interface{} = filter.After.Value
+		if filter.Sort != domain.SortTitle {
+			activeAt, err := filter.After.ActiveAt()
+			if err != nil {
+				return nil, err
+			}
+			field, value = sortFields[domain.SortActiveAt], activeAt
+		}
+		if filter.Order == domain.OrderDesc {
+			op = "$lt"
+		}
+
+		query["$or"] = bson.A{
+			bson.M{field: bson.M{op: value}},
+			bson.M{field: value, "_id": bson.M{op: id}},
+		}
+	}
+
+	return query, nil
+}
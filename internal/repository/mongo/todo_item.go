@@ -0,0 +1,268 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TodoItemRepo struct {
+	collection *mongo.Collection
+}
+
+func NewTodoItemRepo(db *mongo.Database) *TodoItemRepo {
+	return &TodoItemRepo{db.Collection(itemsCollection)}
+}
+
+// EnsureIndexes creates the index the checklist of a todo is read by.
+func (r *TodoItemRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "todo_id", Value: 1}, {Key: "position", Value: 1}},
+		Options: options.Index().SetName("todo_id_position"),
+	})
+	if err != nil {
+		logger.Errorf("r.collection.Indexes().CreateOne(): %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// Create appends the item after the last item of the todo.
+func (r *TodoItemRepo) Create(ctx context.Context, item domain.TodoItem) (domain.TodoItem, error) {
+	todoID, err := primitive.ObjectIDFromHex(item.TodoID)
+	if err != nil {
+		return domain.TodoItem{}, domain.ErrNotFound
+	}
+
+	var last todoItemDocument
+	err = r.collection.FindOne(ctx, bson.M{"todo_id": todoID}, options.FindOne().SetSort(bson.M{"position": -1})).Decode(&last)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		logger.Errorf("r.collection.FindOne(): %v", err)
+		return domain.TodoItem{}, domain.ErrInternalServer
+	}
+
+	doc := todoItemDocument{
+		TodoID:   todoID,
+		Title:    item.Title,
+		Done:     item.Done,
+		Position: last.Position + 1,
+	}
+
+	result, err := r.collection.InsertOne(ctx, doc)
+	if err != nil {
+		logger.Errorf("r.collection.InsertOne(): %v", err)
+		return domain.TodoItem{}, domain.ErrInternalServer
+	}
+
+	id, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		logger.Errorf("result.InsertedID.(primitive.ObjectID): %v", ok)
+		return domain.TodoItem{}, domain.ErrInternalServer
+	}
+	doc.ID = id
+
+	return doc.toDomain(), nil
+}
+
+func (r *TodoItemRepo) GetItems(ctx context.Context, todoID string) ([]domain.TodoItem, error) {
+	id, err := primitive.ObjectIDFromHex(todoID)
+	if err != nil {
+		return nil, domain.ErrNotFound
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "position", Value: 1}, {Key: "_id", Value: 1}})
+	cur, err := r.collection.Find(ctx, bson.M{"todo_id": id}, opts)
+	if err != nil {
+		logger.Errorf("r.collection.Find(): %v", err)
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var items []domain.TodoItem
+	for cur.Next(ctx) {
+		var item todoItemDocument
+		if err := cur.Decode(&item); err != nil {
+			logger.Errorf("cur.Decode(): %v", err)
+			return nil, err
+		}
+		items = append(items, item.toDomain())
+	}
+
+	if err := cur.Err(); err != nil {
+		logger.Errorf("cur.Err(): %v", err)
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *TodoItemRepo) GetItemByID(ctx context.Context, id string, todoID string) (domain.TodoItem, error) {
+	filter, err := itemFilter(id, todoID)
+	if err != nil {
+		return domain.TodoItem{}, err
+	}
+
+	var item todoItemDocument
+	if err := r.collection.FindOne(ctx, filter).Decode(&item); err != nil {
+		logger.Errorf("r.collection.FindOne(): %v", err)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.TodoItem{}, domain.ErrNotFound
+		}
+
+		return domain.TodoItem{}, err
+	}
+
+	return item.toDomain(), nil
+}
+
+func (r *TodoItemRepo) UpdateItemDone(ctx context.Context, id string, todoID string, done bool) (domain.TodoItem, error) {
+	filter, err := itemFilter(id, todoID)
+	if err != nil {
+		return domain.TodoItem{}, err
+	}
+
+	var item todoItemDocument
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = r.collection.FindOneAndUpdate(ctx, filter, bson.M{"$set": bson.M{"done": done}}, opts).Decode(&item)
+	if err != nil {
+		logger.Errorf("r.collection.FindOneAndUpdate(): %v", err)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.TodoItem{}, domain.ErrNotFound
+		}
+
+		return domain.TodoItem{}, err
+	}
+
+	return item.toDomain(), nil
+}
+
+// ReorderItems moves the items to the positions of their ids in the list,
+// starting from one. Ids of other todos are ignored.
+func (r *TodoItemRepo) ReorderItems(ctx context.Context, todoID string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, 0, len(ids))
+	for i, id := range ids {
+		filter, err := itemFilter(id, todoID)
+		if err != nil {
+			return err
+		}
+
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(filter).
+			SetUpdate(bson.M{"$set": bson.M{"position": i + 1}}))
+	}
+
+	if _, err := r.collection.BulkWrite(ctx, models); err != nil {
+		logger.Errorf("r.collection.BulkWrite(): %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *TodoItemRepo) DeleteItem(ctx context.Context, id string, todoID string) error {
+	filter, err := itemFilter(id, todoID)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		logger.Errorf("r.collection.DeleteOne(): %v", err)
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *TodoItemRepo) DeleteItems(ctx context.Context, todoID string) error {
+	id, err := primitive.ObjectIDFromHex(todoID)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+
+	if _, err := r.collection.DeleteMany(ctx, bson.M{"todo_id": id}); err != nil {
+		logger.Errorf("r.collection.DeleteMany(): %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetProgress counts the items of every todo. Todos without items are left
+// out of the result.
+func (r *TodoItemRepo) GetProgress(ctx context.Context, todoIDs []string) (map[string]domain.Progress, error) {
+	ids := make([]primitive.ObjectID, 0, len(todoIDs))
+	for _, todoID := range todoIDs {
+		if id, err := primitive.ObjectIDFromHex(todoID); err == nil {
+			ids = append(ids, id)
+		}
+	}
+
+	progress := make(map[string]domain.Progress)
+	if len(ids) == 0 {
+		return progress, nil
+	}
+
+	cur, err := r.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"todo_id": bson.M{"$in": ids}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$todo_id",
+			"done":  bson.M{"$sum": bson.M{"$cond": bson.A{"$done", 1, 0}}},
+			"total": bson.M{"$sum": 1},
+		}}},
+	})
+	if err != nil {
+		logger.Errorf("r.collection.Aggregate(): %v", err)
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var doc struct {
+			TodoID primitive.ObjectID `bson:"_id"`
+			Done   int                `bson:"done"`
+			Total  int                `bson:"total"`
+		}
+		if err := cur.Decode(&doc); err != nil {
+			logger.Errorf("cur.Decode(): %v", err)
+			return nil, err
+		}
+		progress[doc.TodoID.Hex()] = domain.Progress{Done: doc.Done, Total: doc.Total}
+	}
+
+	if err := cur.Err(); err != nil {
+		logger.Errorf("cur.Err(): %v", err)
+		return nil, err
+	}
+
+	return progress, nil
+}
+
+func itemFilter(id string, todoID string) (bson.M, error) {
+	itemID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrNotFound
+	}
+
+	parentID, err := primitive.ObjectIDFromHex(todoID)
+	if err != nil {
+		return nil, domain.ErrNotFound
+	}
+
+	return bson.M{"_id": itemID, "todo_id": parentID}, nil
+}
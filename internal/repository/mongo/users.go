@@ -13,6 +13,7 @@ import (
 )
 
 type UserRepo struct {
+	db         *mongo.Database
 	collection *mongo.Collection
 }
 
@@ -20,12 +21,13 @@ func NewUserRepo(db *mongo.Database) *UserRepo {
 	collection := db.Collection(usersCollection)
 
 	return &UserRepo{
+		db:         db,
 		collection: collection,
 	}
 }
 
 func (r *UserRepo) Create(ctx context.Context, user domain.User) (domain.User, error) {
-	result, err := r.collection.InsertOne(ctx, user)
+	result, err := r.collection.InsertOne(ctx, newUserDocument(user))
 	if err != nil {
 		logger.Errorf("r.collection.InsertOne(): %v", err)
 		return domain.User{}, domain.ErrInternalServer
@@ -37,13 +39,13 @@ func (r *UserRepo) Create(ctx context.Context, user domain.User) (domain.User, e
 		return domain.User{}, domain.ErrInternalServer
 	}
 
-	user.ID = id
+	user.ID = id.Hex()
 
 	return user, nil
 }
 
 func (r *UserRepo) GetUserByEmail(ctx context.Context, email string) (domain.User, error) {
-	var user domain.User
+	var user userDocument
 	err := r.collection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
 	if err != nil {
 		logger.Errorf("r.collection.FindOne(email): %v", err)
@@ -54,13 +56,17 @@ func (r *UserRepo) GetUserByEmail(ctx context.Context, email string) (domain.Use
 		return domain.User{}, domain.ErrInternalServer
 	}
 
-	return user, nil
+	return user.toDomain(), nil
 }
 
-func (r *UserRepo) GetUserByID(ctx context.Context, id primitive.ObjectID) (domain.User, error) {
-	var user domain.User
+func (r *UserRepo) GetUserByID(ctx context.Context, id string) (domain.User, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.User{}, domain.ErrNotFound
+	}
 
-	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&user)
+	var user userDocument
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&user)
 	if err != nil {
 		logger.Errorf("r.collection.FindOne(email): %v", err)
 		if errors.Is(err, mongo.ErrNoDocuments) {
@@ -71,31 +77,205 @@ func (r *UserRepo) GetUserByID(ctx context.Context, id primitive.ObjectID) (doma
 		return domain.User{}, domain.ErrInternalServer
 	}
 
-	return user, nil
+	return user.toDomain(), nil
 }
 
-func (r *UserRepo) GetByRefreshToken(ctx context.Context, refreshToken string) (domain.User, error) {
-	var user domain.User
-	if err := r.collection.FindOne(ctx, bson.M{
-		"session.refresh_token": refreshToken,
-		"session.expiration_at": bson.M{"$gt": time.Now()},
-	}).Decode(&user); err != nil {
-		logger.Errorf("r.collection.FindOne(email): %v", err)
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return domain.User{}, domain.ErrNotFound
-		}
+func (r *UserRepo) SetCalendar(ctx context.Context, userID string, calendarID string) error {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return domain.ErrNotFound
+	}
 
-		return domain.User{}, domain.ErrInternalServer
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{"calendar": calendarID}})
+	if err != nil {
+		logger.Errorf("r.collection.UpdateOne(): %v", err)
+		return err
 	}
 
-	return user, nil
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *UserRepo) SetLanguage(ctx context.Context, userID string, language string) error {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{"language": language}})
+	if err != nil {
+		logger.Errorf("r.collection.UpdateOne(): %v", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *UserRepo) SetPassword(ctx context.Context, userID string, passwordHash string) error {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{"password": passwordHash}})
+	if err != nil {
+		logger.Errorf("r.collection.UpdateOne(): %v", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *UserRepo) VerifyEmail(ctx context.Context, userID string, email string) error {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{"_id": bson.M{"$ne": objectID}, "email": email})
+	if err != nil {
+		logger.Errorf("r.collection.CountDocuments(): %v", err)
+		return err
+	}
+
+	if count > 0 {
+		return domain.ErrEmailAlreadyExists
+	}
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": objectID},
+		bson.M{"$set": bson.M{"email": email}, "$unset": bson.M{"email_unverified": ""}},
+	)
+	if err != nil {
+		logger.Errorf("r.collection.UpdateOne(): %v", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *UserRepo) UpdateUser(ctx context.Context, user domain.User) error {
+	objectID, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{
+		"username": user.UserName,
+		"timezone": user.Timezone,
+		"language": user.Language,
+	}})
+	if err != nil {
+		logger.Errorf("r.collection.UpdateOne(): %v", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
 }
 
-func (r *UserRepo) SetSession(ctx context.Context, userID primitive.ObjectID, session domain.Session) error {
-	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": bson.M{"session": session, "lastVisitAt": time.Now()}})
-	if errors.Is(err, mongo.ErrNoDocuments) {
+func (r *UserRepo) ScheduleDeletion(ctx context.Context, userID string, deleteAt *time.Time) error {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+
+	update := bson.M{"$unset": bson.M{"delete_at": ""}}
+	if deleteAt != nil {
+		update = bson.M{"$set": bson.M{"delete_at": *deleteAt}}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
+	if err != nil {
+		logger.Errorf("r.collection.UpdateOne(): %v", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
 		return domain.ErrNotFound
 	}
 
-	return err
+	return nil
+}
+
+// DeleteScheduled deletes what the users own before the users, a failed
+// deletion is completed by the next one.
+func (r *UserRepo) DeleteScheduled(ctx context.Context, before time.Time) (int64, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"delete_at": bson.M{"$lte": before}})
+	if err != nil {
+		logger.Errorf("r.collection.Find(): %v", err)
+		return 0, err
+	}
+
+	var users []userDocument
+	if err := cursor.All(ctx, &users); err != nil {
+		logger.Errorf("cursor.All(): %v", err)
+		return 0, err
+	}
+
+	var deleted int64
+	for _, user := range users {
+		if err := r.deleteUserData(ctx, user.ID); err != nil {
+			return deleted, err
+		}
+
+		result, err := r.collection.DeleteOne(ctx, bson.M{"_id": user.ID})
+		if err != nil {
+			logger.Errorf("r.collection.DeleteOne(): %v", err)
+			return deleted, err
+		}
+		deleted += result.DeletedCount
+	}
+
+	return deleted, nil
+}
+
+// deleteUserData deletes the todos with their checklists, the sessions and
+// everything else the user owns.
+func (r *UserRepo) deleteUserData(ctx context.Context, userID primitive.ObjectID) error {
+	todoIDs, err := r.db.Collection(todoCollection).Distinct(ctx, "_id", bson.M{"user_id": userID})
+	if err != nil {
+		logger.Errorf("r.db.Collection(todo).Distinct(): %v", err)
+		return err
+	}
+
+	if len(todoIDs) > 0 {
+		_, err := r.db.Collection(itemsCollection).DeleteMany(ctx, bson.M{"todo_id": bson.M{"$in": todoIDs}})
+		if err != nil {
+			logger.Errorf("r.db.Collection(todo_items).DeleteMany(): %v", err)
+			return err
+		}
+	}
+
+	for _, name := range []string{todoCollection, sessionsCollection, personalTokensCollection, calendarsCollection} {
+		if _, err := r.db.Collection(name).DeleteMany(ctx, bson.M{"user_id": userID}); err != nil {
+			logger.Errorf("r.db.Collection(%s).DeleteMany(): %v", name, err)
+			return err
+		}
+	}
+
+	if _, err := r.db.Collection(mfaCollection).DeleteOne(ctx, bson.M{"_id": userID}); err != nil {
+		logger.Errorf("r.db.Collection(mfa).DeleteOne(): %v", err)
+		return err
+	}
+
+	return nil
 }
@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/logger"
+)
+
+type CalendarRepo struct {
+	db *sql.DB
+}
+
+func NewCalendarRepo(db *sql.DB) *CalendarRepo {
+	return &CalendarRepo{
+		db: db,
+	}
+}
+
+func (r *CalendarRepo) Create(ctx context.Context, calendar domain.Calendar) (domain.Calendar, error) {
+	userID, ok := parseID(calendar.UserID)
+	if !ok {
+		return domain.Calendar{}, domain.ErrNotFound
+	}
+
+	days, err := json.Marshal(calendar.Days)
+	if err != nil {
+		logger.Errorf("json.Marshal(): %v", err)
+		return domain.Calendar{}, domain.ErrInternalServer
+	}
+
+	var id int64
+	err = r.db.QueryRowContext(ctx,
+		`INSERT INTO calendars (user_id, name, days) VALUES ($1, $2, $3) RETURNING id`,
+		userID, calendar.Name, string(days),
+	).Scan(&id)
+	if err != nil {
+		logger.Errorf("r.db.QueryRowContext(): %v", err)
+		return domain.Calendar{}, domain.ErrInternalServer
+	}
+
+	calendar.ID = formatID(id)
+
+	return calendar, nil
+}
+
+func (r *CalendarRepo) GetCalendarByID(ctx context.Context, id string) (domain.Calendar, error) {
+	calendarID, ok := parseID(id)
+	if !ok {
+		return domain.Calendar{}, domain.ErrNotFound
+	}
+
+	var (
+		calendar domain.Calendar
+		userID   int64
+		days     string
+	)
+
+	err := r.db.QueryRowContext(ctx, `SELECT user_id, name, days FROM calendars WHERE id = $1`, calendarID).
+		Scan(&userID, &calendar.Name, &days)
+	if err != nil {
+		logger.Errorf("r.db.QueryRowContext(): %v", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Calendar{}, domain.ErrNotFound
+		}
+
+		return domain.Calendar{}, domain.ErrInternalServer
+	}
+
+	if err := json.Unmarshal([]byte(days), &calendar.Days); err != nil {
+		logger.Errorf("json.Unmarshal(): %v", err)
+		return domain.Calendar{}, domain.ErrInternalServer
+	}
+
+	calendar.ID = formatID(calendarID)
+	calendar.UserID = formatID(userID)
+
+	return calendar, nil
+}
+
+func (r *CalendarRepo) GetCalendars(ctx context.Context, userID string) ([]domain.Calendar, error) {
+	id, ok := parseID(userID)
+	if !ok {
+		return nil, nil
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT id, name FROM calendars WHERE user_id = $1 ORDER BY name, id`, id)
+	if err != nil {
+		logger.Errorf("r.db.QueryContext(): %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var calendars []domain.Calendar
+	for rows.Next() {
+		var calendarID int64
+		calendar := domain.Calendar{UserID: userID}
+		if err := rows.Scan(&calendarID, &calendar.Name); err != nil {
+			logger.Errorf("rows.Scan(): %v", err)
+			return nil, err
+		}
+
+		calendar.ID = formatID(calendarID)
+		calendars = append(calendars, calendar)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Errorf("rows.Err(): %v", err)
+		return nil, err
+	}
+
+	return calendars, nil
+}
+
+func (r *CalendarRepo) DeleteCalendar(ctx context.Context, id string, userID string) error {
+	calendarID, ok := parseID(id)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	ownerID, ok := parseID(userID)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM calendars WHERE id = $1 AND user_id = $2`, calendarID, ownerID)
+	if err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+CREATE TABLE users (
+    id         BIGSERIAL PRIMARY KEY,
+    username   TEXT NOT NULL,
+    email      TEXT NOT NULL UNIQUE,
+    password   TEXT NOT NULL,
+    create_at  TEXT NOT NULL,
+    last_visit_at TIMESTAMPTZ
+);
+
+CREATE TABLE sessions (
+    user_id       BIGINT PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    refresh_token TEXT NOT NULL,
+    expiration_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX sessions_refresh_token_idx ON sessions (refresh_token);
+
+CREATE TABLE todos (
+    id        BIGSERIAL PRIMARY KEY,
+    user_id   BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    title     TEXT NOT NULL,
+    active_at TEXT NOT NULL,
+    author    TEXT NOT NULL,
+    status    TEXT NOT NULL
+);
+
+CREATE INDEX todos_user_id_status_idx ON todos (user_id, status);
+CREATE INDEX todos_user_id_title_idx ON todos (user_id, title);
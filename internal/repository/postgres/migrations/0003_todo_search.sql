@@ -0,0 +1,9 @@
+ALTER TABLE todos ADD COLUMN description TEXT NOT NULL DEFAULT '';
+
+-- The 'simple' configuration only lowercases words, so that search behaves
+-- the same for Russian, Kazakh and English texts. Title matches rank higher.
+ALTER TABLE todos ADD COLUMN search tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', title), 'A') || setweight(to_tsvector('simple', description), 'B')
+) STORED;
+
+CREATE INDEX todos_search_idx ON todos USING GIN (search);
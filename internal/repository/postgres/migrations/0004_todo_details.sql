@@ -0,0 +1,6 @@
+ALTER TABLE todos
+    ADD COLUMN priority TEXT NOT NULL DEFAULT 'medium',
+    ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN due_at TIMESTAMPTZ;
+
+CREATE INDEX todos_tags_idx ON todos USING GIN (tags);
@@ -0,0 +1,4 @@
+-- recurrence is an RRULE, empty for a one-off todo.
+ALTER TABLE todos
+    ADD COLUMN recurrence TEXT NOT NULL DEFAULT '',
+    ADD COLUMN occurrence INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,6 @@
+-- active_at was a date in UTC, now it is the instant the todo becomes active.
+-- Dates of a user are read in their timezone.
+ALTER TABLE users ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
+
+ALTER TABLE todos ALTER COLUMN active_at TYPE TIMESTAMPTZ
+    USING (active_at::date)::timestamp AT TIME ZONE 'UTC';
@@ -0,0 +1,12 @@
+-- Working calendars uploaded by users. The days are a JSON array, they are
+-- only ever read as a whole.
+CREATE TABLE calendars (
+    id      BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name    TEXT NOT NULL,
+    days    JSONB NOT NULL DEFAULT '[]'
+);
+
+CREATE INDEX calendars_user_id_idx ON calendars (user_id);
+
+ALTER TABLE users ADD COLUMN calendar TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+-- The language of the API messages chosen by the user, empty to follow the
+-- Accept-Language header.
+ALTER TABLE users ADD COLUMN language TEXT NOT NULL DEFAULT '';
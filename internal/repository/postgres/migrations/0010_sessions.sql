@@ -0,0 +1,23 @@
+-- A session per device instead of one per user. The current sessions are kept
+-- as they are, with the time of the migration as their creation and last use.
+ALTER TABLE sessions RENAME TO sessions_single;
+
+CREATE TABLE sessions (
+    id            BIGSERIAL PRIMARY KEY,
+    user_id       BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    refresh_token TEXT NOT NULL UNIQUE,
+    device        TEXT NOT NULL DEFAULT '',
+    user_agent    TEXT NOT NULL DEFAULT '',
+    ip            TEXT NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ NOT NULL,
+    last_used_at  TIMESTAMPTZ NOT NULL,
+    expiration_at TIMESTAMPTZ NOT NULL
+);
+
+INSERT INTO sessions (user_id, refresh_token, created_at, last_used_at, expiration_at)
+SELECT user_id, refresh_token, now(), now(), expiration_at
+FROM sessions_single;
+
+DROP TABLE sessions_single;
+
+CREATE INDEX sessions_user_id_idx ON sessions (user_id, last_used_at);
@@ -0,0 +1,4 @@
+-- Refresh tokens are stored by their hash, the hex SHA-256 of the token.
+ALTER TABLE sessions RENAME COLUMN refresh_token TO refresh_token_hash;
+
+UPDATE sessions SET refresh_token_hash = encode(sha256(convert_to(refresh_token_hash, 'UTF8')), 'hex');
@@ -0,0 +1,5 @@
+-- The time an account deleted by its user is removed for good, NULL for the
+-- accounts in use. Deleting a user deletes everything it owns.
+ALTER TABLE users ADD COLUMN delete_at TIMESTAMPTZ;
+
+CREATE INDEX users_delete_at_idx ON users (delete_at);
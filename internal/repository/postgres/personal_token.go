@@ -0,0 +1,172 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/logger"
+	"github.com/lib/pq"
+)
+
+type PersonalTokenRepo struct {
+	db *sql.DB
+}
+
+func NewPersonalTokenRepo(db *sql.DB) *PersonalTokenRepo {
+	return &PersonalTokenRepo{
+		db: db,
+	}
+}
+
+func (r *PersonalTokenRepo) Create(ctx context.Context, token domain.PersonalToken) (domain.PersonalToken, error) {
+	userID, ok := parseID(token.UserID)
+	if !ok {
+		return domain.PersonalToken{}, domain.ErrNotFound
+	}
+
+	var id int64
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO personal_tokens (user_id, token_hash, name, scopes, created_at, last_used_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		userID, token.TokenHash, token.Name, tagsArray(token.Scopes),
+		token.CreatedAt, token.LastUsedAt, token.ExpiresAt,
+	).Scan(&id)
+	if err != nil {
+		logger.Errorf("r.db.QueryRowContext(): %v", err)
+		if isForeignKeyViolation(err) {
+			return domain.PersonalToken{}, domain.ErrNotFound
+		}
+
+		return domain.PersonalToken{}, domain.ErrInternalServer
+	}
+
+	token.ID = formatID(id)
+
+	return token, nil
+}
+
+func (r *PersonalTokenRepo) GetByTokenHash(ctx context.Context, tokenHash string) (domain.PersonalToken, error) {
+	tokens, err := r.getTokens(ctx, `WHERE token_hash = $1 AND (expires_at IS NULL OR expires_at > $2)`, tokenHash, time.Now())
+	if err != nil {
+		return domain.PersonalToken{}, err
+	}
+
+	if len(tokens) == 0 {
+		return domain.PersonalToken{}, domain.ErrNotFound
+	}
+
+	return tokens[0], nil
+}
+
+func (r *PersonalTokenRepo) GetTokens(ctx context.Context, userID string) ([]domain.PersonalToken, error) {
+	id, ok := parseID(userID)
+	if !ok {
+		return nil, nil
+	}
+
+	return r.getTokens(ctx, `WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > $2) ORDER BY created_at DESC, id DESC`,
+		id, time.Now())
+}
+
+func (r *PersonalTokenRepo) SetLastUsed(ctx context.Context, id string, lastUsedAt time.Time) error {
+	tokenID, ok := parseID(id)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE personal_tokens SET last_used_at = $1
+		WHERE id = $2 AND (expires_at IS NULL OR expires_at > $3)`,
+		lastUsedAt, tokenID, time.Now(),
+	)
+	if err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *PersonalTokenRepo) DeleteToken(ctx context.Context, id string, userID string) error {
+	tokenID, ok := parseID(id)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	ownerID, ok := parseID(userID)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM personal_tokens
+		WHERE id = $1 AND user_id = $2 AND (expires_at IS NULL OR expires_at > $3)`,
+		tokenID, ownerID, time.Now(),
+	)
+	if err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *PersonalTokenRepo) getTokens(ctx context.Context, where string, args ...interface{}) ([]domain.PersonalToken, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, token_hash, name, scopes, created_at, last_used_at, expires_at
+		FROM personal_tokens
+		`+where, args...,
+	)
+	if err != nil {
+		logger.Errorf("r.db.QueryContext(): %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []domain.PersonalToken
+	for rows.Next() {
+		var (
+			token                 domain.PersonalToken
+			id, userID            int64
+			lastUsedAt, expiresAt sql.NullTime
+		)
+
+		err := rows.Scan(&id, &userID, &token.TokenHash, &token.Name, pq.Array(&token.Scopes), &token.CreatedAt,
+			&lastUsedAt, &expiresAt)
+		if err != nil {
+			logger.Errorf("rows.Scan(): %v", err)
+			return nil, err
+		}
+
+		token.ID = formatID(id)
+		token.UserID = formatID(userID)
+		token.LastUsedAt = nullTime(lastUsedAt)
+		token.ExpiresAt = nullTime(expiresAt)
+		tokens = append(tokens, token)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Errorf("rows.Err(): %v", err)
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	return &t.Time
+}
@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/config"
+	"github.com/begenov/region-llc-task/internal/repository/repositorytest"
+	"github.com/begenov/region-llc-task/pkg/database"
+	"github.com/begenov/region-llc-task/pkg/logger"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// missingID is a well-formed identifier that no row will ever have.
+const missingID = "9223372036854775807"
+
+var db *sql.DB
+
+func TestMain(m *testing.M) {
+	var terminate func()
+	var err error
+	db, terminate, err = createTestDatabase()
+	if err != nil {
+		logger.Warnf("skipping postgres repository tests: %v", err)
+		os.Exit(0)
+	}
+
+	code := m.Run()
+
+	db.Close()
+	terminate()
+	os.Exit(code)
+}
+
+func TestRepositories(t *testing.T) {
+	repositorytest.Run(t, repositorytest.Repositories{
+		Users:          NewUserRepo(db),
+		Todo:           NewTodoRepo(db),
+		Items:          NewTodoItemRepo(db),
+		Calendars:      NewCalendarRepo(db),
+		Sessions:       NewSessionRepo(db),
+		PersonalTokens: NewPersonalTokenRepo(db),
+		MFA:            NewMFARepo(db),
+		MissingID:      missingID,
+		ValidatesIDs:   true,
+		Constraints:    true,
+	})
+}
+
+func createTestDatabase() (*sql.DB, func(), error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	req := testcontainers.ContainerRequest{
+		Image:        "postgres:15-alpine",
+		ExposedPorts: []string{"5432/tcp"},
+		Env: map[string]string{
+			"POSTGRES_USER":     "test",
+			"POSTGRES_PASSWORD": "test",
+			"POSTGRES_DB":       "test",
+		},
+		WaitingFor: wait.ForAll(
+			wait.ForLog("database system is ready to accept connections").WithOccurrence(2),
+			wait.ForListeningPort("5432/tcp"),
+		),
+	}
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("testcontainers.GenericContainer(): %v", err)
+	}
+	terminate := func() { container.Terminate(context.Background()) }
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		terminate()
+		return nil, nil, err
+	}
+
+	port, err := container.MappedPort(ctx, "5432")
+	if err != nil {
+		terminate()
+		return nil, nil, err
+	}
+
+	db, err := database.NewPostgres(ctx, config.ConfigPostgres{
+		Uri: fmt.Sprintf("postgres://test:test@%s:%s/test?sslmode=disable", host, port.Port()),
+	})
+	if err != nil {
+		terminate()
+		return nil, nil, fmt.Errorf("database.NewPostgres(): %v", err)
+	}
+
+	if err := Migrate(ctx, db); err != nil {
+		db.Close()
+		terminate()
+		return nil, nil, fmt.Errorf("Migrate(): %v", err)
+	}
+
+	return db, terminate, nil
+}
@@ -0,0 +1,190 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/logger"
+)
+
+type SessionRepo struct {
+	db *sql.DB
+}
+
+func NewSessionRepo(db *sql.DB) *SessionRepo {
+	return &SessionRepo{
+		db: db,
+	}
+}
+
+// Create also purges the expired sessions of the user.
+func (r *SessionRepo) Create(ctx context.Context, session domain.Session) (domain.Session, error) {
+	userID, ok := parseID(session.UserID)
+	if !ok {
+		return domain.Session{}, domain.ErrNotFound
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Errorf("r.db.BeginTx(): %v", err)
+		return domain.Session{}, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = $1 AND expiration_at <= $2`, userID, time.Now())
+	if err != nil {
+		logger.Errorf("tx.ExecContext(purge): %v", err)
+		return domain.Session{}, err
+	}
+
+	var id int64
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO sessions (user_id, refresh_token_hash, device, user_agent, ip, created_at, last_used_at, expiration_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
+		userID, session.RefreshTokenHash, session.Device, session.UserAgent, session.IP,
+		session.CreatedAt, session.LastUsedAt, session.ExpirationAt,
+	).Scan(&id)
+	if err != nil {
+		logger.Errorf("tx.QueryRowContext(): %v", err)
+		if isForeignKeyViolation(err) {
+			return domain.Session{}, domain.ErrNotFound
+		}
+
+		return domain.Session{}, domain.ErrInternalServer
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Errorf("tx.Commit(): %v", err)
+		return domain.Session{}, err
+	}
+
+	session.ID = formatID(id)
+
+	return session, nil
+}
+
+func (r *SessionRepo) GetByRefreshToken(ctx context.Context, refreshTokenHash string) (domain.Session, error) {
+	sessions, err := r.getSessions(ctx, `WHERE refresh_token_hash = $1 AND expiration_at > $2`, refreshTokenHash, time.Now())
+	if err != nil {
+		return domain.Session{}, err
+	}
+
+	if len(sessions) == 0 {
+		return domain.Session{}, domain.ErrNotFound
+	}
+
+	return sessions[0], nil
+}
+
+func (r *SessionRepo) GetSessions(ctx context.Context, userID string) ([]domain.Session, error) {
+	id, ok := parseID(userID)
+	if !ok {
+		return nil, nil
+	}
+
+	return r.getSessions(ctx, `WHERE user_id = $1 AND expiration_at > $2 ORDER BY last_used_at DESC, id`, id, time.Now())
+}
+
+func (r *SessionRepo) RotateRefreshToken(ctx context.Context, session domain.Session, previousHash string) error {
+	id, ok := parseID(session.ID)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE sessions SET refresh_token_hash = $1, user_agent = $2, ip = $3, last_used_at = $4, expiration_at = $5
+		WHERE id = $6 AND refresh_token_hash = $7 AND expiration_at > $8`,
+		session.RefreshTokenHash, session.UserAgent, session.IP, session.LastUsedAt, session.ExpirationAt,
+		id, previousHash, time.Now(),
+	)
+	if err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *SessionRepo) DeleteSession(ctx context.Context, id string, userID string) error {
+	sessionID, ok := parseID(id)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	ownerID, ok := parseID(userID)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM sessions WHERE id = $1 AND user_id = $2 AND expiration_at > $3`,
+		sessionID, ownerID, time.Now())
+	if err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *SessionRepo) DeleteSessions(ctx context.Context, userID string) error {
+	id, ok := parseID(userID)
+	if !ok {
+		return nil
+	}
+
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = $1`, id); err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SessionRepo) getSessions(ctx context.Context, where string, args ...interface{}) ([]domain.Session, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, refresh_token_hash, device, user_agent, ip, created_at, last_used_at, expiration_at
+		FROM sessions
+		`+where, args...,
+	)
+	if err != nil {
+		logger.Errorf("r.db.QueryContext(): %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []domain.Session
+	for rows.Next() {
+		var (
+			session    domain.Session
+			id, userID int64
+		)
+
+		err := rows.Scan(&id, &userID, &session.RefreshTokenHash, &session.Device, &session.UserAgent, &session.IP,
+			&session.CreatedAt, &session.LastUsedAt, &session.ExpirationAt)
+		if err != nil {
+			logger.Errorf("rows.Scan(): %v", err)
+			return nil, err
+		}
+
+		session.ID = formatID(id)
+		session.UserID = formatID(userID)
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Errorf("rows.Err(): %v", err)
+		return nil, err
+	}
+
+	return sessions, nil
+}
@@ -0,0 +1,381 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/logger"
+	"github.com/lib/pq"
+)
+
+const todoColumns = `id, user_id, title, description, priority, tags, active_at, due_at, recurrence, occurrence, author, status`
+
+type TodoRepo struct {
+	db *sql.DB
+}
+
+func NewTodoRepo(db *sql.DB) *TodoRepo {
+	return &TodoRepo{
+		db: db,
+	}
+}
+
+func (r *TodoRepo) Create(ctx context.Context, todo domain.Todo) (domain.Todo, error) {
+	userID, ok := parseID(todo.UserID)
+	if !ok {
+		return domain.Todo{}, domain.ErrNotFound
+	}
+
+	var id int64
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO todos (user_id, title, description, priority, tags, active_at, due_at, recurrence, occurrence, author, status) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`,
+		userID, todo.Title, todo.Description, todo.Priority, tagsArray(todo.Tags), todo.ActiveAt, todo.DueAt, todo.Recurrence, todo.Occurrence, todo.Author, todo.Status,
+	).Scan(&id)
+	if err != nil {
+		logger.Errorf("r.db.QueryRowContext(): %v", err)
+		return domain.Todo{}, domain.ErrInternalServer
+	}
+
+	todo.ID = formatID(id)
+
+	return todo, nil
+}
+
+func (r *TodoRepo) GetCountByTitle(ctx context.Context, title string, id string) (int64, error) {
+	userID, ok := parseID(id)
+	if !ok {
+		return 0, domain.ErrNotFound
+	}
+
+	var count int64
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM todos WHERE title = $1 AND user_id = $2`, title, userID).Scan(&count)
+	if err != nil {
+		logger.Errorf("r.db.QueryRowContext(): %v", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *TodoRepo) GetTodoByID(ctx context.Context, id string) (domain.Todo, error) {
+	todoID, ok := parseID(id)
+	if !ok {
+		return domain.Todo{}, domain.ErrTodoInvalidId
+	}
+
+	todo, err := scanTodo(r.db.QueryRowContext(ctx, `SELECT `+todoColumns+` FROM todos WHERE id = $1`, todoID))
+	if err != nil {
+		logger.Errorf("r.db.QueryRowContext(): %v", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Todo{}, domain.ErrNotFound
+		}
+
+		return domain.Todo{}, err
+	}
+
+	return todo, nil
+}
+
+// UpdateTodoID is a no-op: identifiers are assigned by the database on insert.
+func (r *TodoRepo) UpdateTodoID(ctx context.Context, todo domain.Todo) error {
+	if _, ok := parseID(todo.ID); !ok {
+		return domain.ErrTodoInvalidId
+	}
+
+	return nil
+}
+
+func (r *TodoRepo) UpdateTodo(ctx context.Context, todo domain.Todo) error {
+	id, ok := parseID(todo.ID)
+	if !ok {
+		return domain.ErrTodoInvalidId
+	}
+
+	ownerID, ok := parseID(todo.UserID)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.db.ExecContext(ctx, `UPDATE todos SET title = $3, description = $4, priority = $5, tags = $6, active_at = $7, due_at = $8, recurrence = $9 WHERE id = $1 AND user_id = $2`,
+		id, ownerID, todo.Title, todo.Description, todo.Priority, tagsArray(todo.Tags), todo.ActiveAt, todo.DueAt, todo.Recurrence)
+	if err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *TodoRepo) DeleteTodoByID(ctx context.Context, id string, userID string) error {
+	todoID, ok := parseID(id)
+	if !ok {
+		return domain.ErrTodoInvalidId
+	}
+
+	ownerID, ok := parseID(userID)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM todos WHERE id = $1 AND user_id = $2`, todoID, ownerID)
+	if err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *TodoRepo) UpdateTodoDoneByID(ctx context.Context, id string, userID string) (domain.Todo, error) {
+	todoID, ok := parseID(id)
+	if !ok {
+		return domain.Todo{}, domain.ErrTodoInvalidId
+	}
+
+	ownerID, ok := parseID(userID)
+	if !ok {
+		return domain.Todo{}, domain.ErrNotFound
+	}
+
+	todo, err := scanTodo(r.db.QueryRowContext(ctx,
+		`UPDATE todos SET status = $3 WHERE id = $1 AND user_id = $2 RETURNING `+todoColumns,
+		todoID, ownerID, domain.Done,
+	))
+	if err != nil {
+		logger.Errorf("r.db.QueryRowContext(): %v", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Todo{}, domain.ErrNotFound
+		}
+
+		return domain.Todo{}, err
+	}
+
+	return todo, nil
+}
+
+func (r *TodoRepo) GetTodos(ctx context.Context, filter domain.TodoFilter) ([]domain.Todo, error) {
+	where, args, err := todoFilter(filter, true)
+	if err != nil {
+		return nil, err
+	}
+
+	column, direction := sortColumns[filter.Sort], "ASC"
+	if column == "" {
+		column = sortColumns[domain.SortActiveAt]
+	}
+	if filter.Order == domain.OrderDesc {
+		direction = "DESC"
+	}
+
+	query := fmt.Sprintf(`SELECT `+todoColumns+` FROM todos %s ORDER BY %s %s, id %s`, where, column, direction, direction)
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(` LIMIT %d`, filter.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.Errorf("r.db.QueryContext(): %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var todos []domain.Todo
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			logger.Errorf("scanTodo(): %v", err)
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Errorf("rows.Err(): %v", err)
+		return nil, err
+	}
+
+	return todos, nil
+}
+
+func (r *TodoRepo) GetCountByFilter(ctx context.Context, filter domain.TodoFilter) (int64, error) {
+	where, args, err := todoFilter(filter, false)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM todos `+where, args...).Scan(&count); err != nil {
+		logger.Errorf("r.db.QueryRowContext(): %v", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *TodoRepo) SearchTodos(ctx context.Context, userID string, query domain.SearchQuery, limit int) ([]domain.SearchResult, error) {
+	ownerID, ok := parseID(userID)
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+todoColumns+`, ts_rank(search, q) AS rank
+		FROM todos, websearch_to_tsquery('simple', $2) q
+		WHERE user_id = $1 AND search @@ q
+		ORDER BY rank DESC, id DESC
+		LIMIT $3`,
+		ownerID, query.String(), limit,
+	)
+	if err != nil {
+		logger.Errorf("r.db.QueryContext(): %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []domain.SearchResult
+	for rows.Next() {
+		var score float64
+		todo, err := scanTodo(rows, &score)
+		if err != nil {
+			logger.Errorf("scanTodo(): %v", err)
+			return nil, err
+		}
+
+		results = append(results, domain.SearchResult{Todo: todo, Score: score})
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Errorf("rows.Err(): %v", err)
+		return nil, err
+	}
+
+	return results, nil
+}
+
+var sortColumns = map[string]string{
+	domain.SortActiveAt: "active_at",
+	domain.SortTitle:    "title",
+}
+
+// todoFilter builds the WHERE clause of a listing query. The cursor condition
+// is only added when withCursor is set, so that the total count ignores it.
+func todoFilter(filter domain.TodoFilter, withCursor bool) (string, []interface{}, error) {
+	userID, ok := parseID(filter.UserID)
+	if !ok {
+		return "", nil, domain.ErrNotFound
+	}
+
+	var (
+		conds []string
+		args  []interface{}
+	)
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	conds = append(conds, "user_id = "+arg(userID))
+	if len(filter.Statuses) > 0 {
+		conds = append(conds, "status = ANY("+arg(pq.Array(filter.Statuses))+")")
+	}
+
+	if !filter.ActiveFrom.IsZero() {
+		conds = append(conds, "active_at >= "+arg(filter.ActiveFrom))
+	}
+
+	if !filter.ActiveBefore.IsZero() {
+		conds = append(conds, "active_at < "+arg(filter.ActiveBefore))
+	}
+
+	if filter.Title != "" {
+		conds = append(conds, "strpos(lower(title), lower("+arg(filter.Title)+")) > 0")
+	}
+
+	if len(filter.Priorities) > 0 {
+		conds = append(conds, "priority = ANY("+arg(pq.Array(filter.Priorities))+")")
+	}
+
+	if len(filter.Tags) > 0 {
+		conds = append(conds, "tags @> "+arg(pq.Array(filter.Tags)))
+	}
+
+	if withCursor && filter.After != nil {
+		id, ok := parseID(filter.After.ID)
+		if !ok {
+			return "", nil, domain.ErrInvalidCursor
+		}
+
+		column, op := sortColumns[domain.SortTitle], ">"
+		var value interface{} = filter.After.Value
+		if filter.Sort != domain.SortTitle {
+			activeAt, err := filter.After.ActiveAt()
+			if err != nil {
+				return "", nil, err
+			}
+			column, value = sortColumns[domain.SortActiveAt], activeAt
+		}
+		if filter.Order == domain.OrderDesc {
+			op = "<"
+		}
+
+		conds = append(conds, fmt.Sprintf("(%s, id) %s (%s, %s)", column, op, arg(value), arg(id)))
+	}
+
+	return "WHERE " + strings.Join(conds, " AND "), args, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTodo reads the todoColumns of a row followed by the extra columns of
+// the query, if any.
+func scanTodo(row scanner, extra ...interface{}) (domain.Todo, error) {
+	var (
+		todo       domain.Todo
+		id, userID int64
+		dueAt      sql.NullTime
+	)
+
+	dest := []interface{}{&id, &userID, &todo.Title, &todo.Description, &todo.Priority, pq.Array(&todo.Tags), &todo.ActiveAt, &dueAt, &todo.Recurrence, &todo.Occurrence, &todo.Author, &todo.Status}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return domain.Todo{}, err
+	}
+
+	todo.ID = formatID(id)
+	todo.UserID = formatID(userID)
+	todo.ActiveAt = todo.ActiveAt.UTC()
+
+	if len(todo.Tags) == 0 {
+		todo.Tags = nil
+	}
+
+	if dueAt.Valid {
+		due := dueAt.Time.UTC()
+		todo.DueAt = &due
+	}
+
+	return todo, nil
+}
+
+// tagsArray keeps an empty tag list from being written as NULL.
+func tagsArray(tags []string) interface{} {
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return pq.Array(tags)
+}
@@ -1,6 +1,7 @@
 package redis
 
 import (
+	"errors"
 	"time"
 
 	"github.com/begenov/region-llc-task/internal/domain"
@@ -31,11 +32,14 @@ func (r *Redis) Set(key string, value string, expiration time.Duration) error {
 
 func (r *Redis) Get(key string) (string, error) {
 
-	val := r.client.Get(key).String()
+	val, err := r.client.Get(key).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", domain.ErrNotFound
+		}
 
-	if val == "" {
-		logger.Errorf("Not Found: %v", key)
-		return "", domain.ErrNotFound
+		logger.Errorf("r.client.Get(): %v\t%s", err, key)
+		return "", err
 	}
 
 	return val, nil
@@ -50,3 +54,23 @@ func (r *Redis) Delete(key string) error {
 
 	return nil
 }
+
+// incrScript increments the counter and sets its expiration with the first
+// increment, both at once: a counter left without one would never reset.
+var incrScript = redis.NewScript(`
+local value = redis.call("INCR", KEYS[1])
+if value == 1 and tonumber(ARGV[1]) > 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return value
+`)
+
+func (r *Redis) Incr(key string, expiration time.Duration) (int64, error) {
+	val, err := incrScript.Run(r.client, []string{key}, int64(expiration/time.Millisecond)).Int64()
+	if err != nil {
+		logger.Errorf("incrScript.Run(): %v\t%s", err, key)
+		return 0, err
+	}
+
+	return val, nil
+}
@@ -5,13 +5,17 @@ import (
 	"time"
 
 	"github.com/alicebob/miniredis"
+	"github.com/begenov/region-llc-task/internal/repository/repositorytest"
 	"github.com/begenov/region-llc-task/pkg/logger"
 	"github.com/begenov/region-llc-task/pkg/utils"
 	"github.com/go-redis/redis"
 	"github.com/stretchr/testify/require"
 )
 
-var redisRepo *Redis
+var (
+	redisRepo *Redis
+	server    *miniredis.Miniredis
+)
 
 func init() {
 	s, err := miniredis.Run()
@@ -23,32 +27,41 @@ func init() {
 	})
 
 	redisRepo = NewRedis(client)
+	server = s
 
 }
 
-func setRedis(t *testing.T) string {
-	key := utils.RandomString(10)
-	value := utils.RandomString(15)
-	exp := time.Minute
-	err := redisRepo.Set(key, value, exp)
-	require.NoError(t, err)
-	return key
+func TestRedis(t *testing.T) {
+	repositorytest.RunRedis(t, redisRepo, server.FastForward)
 }
 
-func TestRedis_Set(t *testing.T) {
-	setRedis(t)
-}
+func TestRedis_IncrExpiration(t *testing.T) {
+	key := utils.RandomString(10)
 
-func TestRedis_Get(t *testing.T) {
-	key := setRedis(t)
+	for i := int64(1); i <= 3; i++ {
+		val, err := redisRepo.Incr(key, time.Minute)
+		require.NoError(t, err)
+		require.Equal(t, i, val)
+	}
 
 	res, err := redisRepo.Get(key)
 	require.NoError(t, err)
-	require.NotEmpty(t, res)
+	require.Equal(t, "3", res)
+	require.Equal(t, time.Minute, server.TTL(key))
+
+	// The counter expires a minute after the first increment and starts over.
+	server.FastForward(time.Minute)
+
+	val, err := redisRepo.Incr(key, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), val)
 }
 
-func TestRedis_Delete(t *testing.T) {
-	key := setRedis(t)
-	err := redisRepo.Delete(key)
+func TestRedis_IncrNoExpiration(t *testing.T) {
+	key := utils.RandomString(10)
+
+	val, err := redisRepo.Incr(key, 0)
 	require.NoError(t, err)
+	require.Equal(t, int64(1), val)
+	require.Zero(t, server.TTL(key))
 }
@@ -5,31 +5,113 @@ import (
 	"time"
 
 	"github.com/begenov/region-llc-task/internal/domain"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Users stores the users. VerifyEmail sets the email of the user and marks it
+// verified, ErrEmailAlreadyExists when another user has it. UpdateUser saves
+// the username, timezone and language of the user.
+//
+// ScheduleDeletion sets the time the user is deleted at, nil cancels the
+// deletion. DeleteScheduled deletes the users due by before along with their
+// todos, sessions and everything else they own, and reports how many.
+//
 //go:generate mockgen -source=repository.go -destination=mocks/mock.go
 type Users interface {
 	Create(ctx context.Context, user domain.User) (domain.User, error)
 	GetUserByEmail(ctx context.Context, email string) (domain.User, error)
-	SetSession(ctx context.Context, userID primitive.ObjectID, session domain.Session) error
-	GetByRefreshToken(ctx context.Context, refreshToken string) (domain.User, error)
-	GetUserByID(ctx context.Context, id primitive.ObjectID) (domain.User, error)
+	GetUserByID(ctx context.Context, id string) (domain.User, error)
+	SetCalendar(ctx context.Context, userID string, calendarID string) error
+	SetLanguage(ctx context.Context, userID string, language string) error
+	SetPassword(ctx context.Context, userID string, passwordHash string) error
+	VerifyEmail(ctx context.Context, userID string, email string) error
+	UpdateUser(ctx context.Context, user domain.User) error
+	ScheduleDeletion(ctx context.Context, userID string, deleteAt *time.Time) error
+	DeleteScheduled(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Sessions stores the sessions of users, one per device. Expired sessions are
+// neither returned nor counted as found. GetSessions lists the sessions of the
+// user, the most recently used first.
+//
+// Refresh tokens are stored and looked up by their hash only.
+// RotateRefreshToken replaces the refresh token of the session only while
+// previousHash is still its current one, ErrNotFound otherwise, so that a
+// refresh token is rotated at most once.
+type Sessions interface {
+	Create(ctx context.Context, session domain.Session) (domain.Session, error)
+	GetByRefreshToken(ctx context.Context, refreshTokenHash string) (domain.Session, error)
+	GetSessions(ctx context.Context, userID string) ([]domain.Session, error)
+	RotateRefreshToken(ctx context.Context, session domain.Session, previousHash string) error
+	DeleteSession(ctx context.Context, id string, userID string) error
+	DeleteSessions(ctx context.Context, userID string) error
+}
+
+// PersonalTokens stores the personal tokens of users by the hash of the token.
+// Expired tokens are neither returned nor counted as found. GetTokens lists
+// the tokens of the user, the most recently created first.
+type PersonalTokens interface {
+	Create(ctx context.Context, token domain.PersonalToken) (domain.PersonalToken, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (domain.PersonalToken, error)
+	GetTokens(ctx context.Context, userID string) ([]domain.PersonalToken, error)
+	SetLastUsed(ctx context.Context, id string, lastUsedAt time.Time) error
+	DeleteToken(ctx context.Context, id string, userID string) error
+}
+
+// MFA stores the two-factor authentication of users, ErrNotFound for a user
+// who has not set it up. SetMFA creates or replaces it along with the recovery
+// codes. UseStep and UseRecoveryCode accept a code once: UseStep only moves
+// LastStep forward and UseRecoveryCode removes the code, both report
+// ErrNotFound otherwise.
+type MFA interface {
+	GetMFA(ctx context.Context, userID string) (domain.MFA, error)
+	SetMFA(ctx context.Context, mfa domain.MFA) error
+	SetRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error
+	UseStep(ctx context.Context, userID string, step int64) error
+	UseRecoveryCode(ctx context.Context, userID string, codeHash string) error
+	DeleteMFA(ctx context.Context, userID string) error
 }
 
 type Todo interface {
 	Create(ctx context.Context, todo domain.Todo) (domain.Todo, error)
-	GetTodoByID(ctx context.Context, id primitive.ObjectID) (domain.Todo, error)
+	GetTodoByID(ctx context.Context, id string) (domain.Todo, error)
 	UpdateTodo(ctx context.Context, todo domain.Todo) error
-	GetCountByTitle(ctx context.Context, title string, id primitive.ObjectID) (int64, error)
-	DeleteTodoByID(ctx context.Context, id primitive.ObjectID) error
-	UpdateTodoDoneByID(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) (domain.Todo, error)
-	GetTodoByStatus(ctx context.Context, status string, userID primitive.ObjectID) ([]domain.Todo, error)
+	GetCountByTitle(ctx context.Context, title string, id string) (int64, error)
+	DeleteTodoByID(ctx context.Context, id string, userID string) error
+	UpdateTodoDoneByID(ctx context.Context, id string, userID string) (domain.Todo, error)
+	GetTodos(ctx context.Context, filter domain.TodoFilter) ([]domain.Todo, error)
+	GetCountByFilter(ctx context.Context, filter domain.TodoFilter) (int64, error)
+	SearchTodos(ctx context.Context, userID string, query domain.SearchQuery, limit int) ([]domain.SearchResult, error)
 	UpdateTodoID(ctx context.Context, todo domain.Todo) error
 }
 
+// TodoItems stores the checklist of a todo. Every method is scoped to the
+// todo, so that an item id of another todo is reported as ErrNotFound.
+type TodoItems interface {
+	Create(ctx context.Context, item domain.TodoItem) (domain.TodoItem, error)
+	GetItems(ctx context.Context, todoID string) ([]domain.TodoItem, error)
+	GetItemByID(ctx context.Context, id string, todoID string) (domain.TodoItem, error)
+	UpdateItemDone(ctx context.Context, id string, todoID string, done bool) (domain.TodoItem, error)
+	ReorderItems(ctx context.Context, todoID string, ids []string) error
+	DeleteItem(ctx context.Context, id string, todoID string) error
+	DeleteItems(ctx context.Context, todoID string) error
+	GetProgress(ctx context.Context, todoIDs []string) (map[string]domain.Progress, error)
+}
+
+// Calendars stores the working calendars uploaded by users. GetCalendars
+// lists the calendars of the user without their days.
+type Calendars interface {
+	Create(ctx context.Context, calendar domain.Calendar) (domain.Calendar, error)
+	GetCalendarByID(ctx context.Context, id string) (domain.Calendar, error)
+	GetCalendars(ctx context.Context, userID string) ([]domain.Calendar, error)
+	DeleteCalendar(ctx context.Context, id string, userID string) error
+}
+
+// Redis is the cache of short-lived keys. Incr adds one to the counter of
+// the key and returns it, the key expires expiration after the increment
+// that creates it.
 type Redis interface {
 	Set(key string, value string, expiration time.Duration) error
 	Get(key string) (string, error)
 	Delete(key string) error
+	Incr(key string, expiration time.Duration) (int64, error)
 }
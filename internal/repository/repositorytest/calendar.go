@@ -0,0 +1,51 @@
+package repositorytest
+
+import (
+	"testing"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func createCalendar(t *testing.T, r Repositories, user domain.User) domain.Calendar {
+	calendar, err := r.Calendars.Create(ctx, domain.Calendar{
+		UserID: user.ID,
+		Name:   utils.RandomString(10),
+		Days: []domain.CalendarDay{
+			{Date: "2030-03-08", DayOff: true, Reason: "Международный женский день"},
+			{Date: "2030-03-09", DayOff: false},
+		},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, calendar.ID)
+	require.Equal(t, user.ID, calendar.UserID)
+
+	return calendar
+}
+
+func testCalendarRepo(t *testing.T, r Repositories) {
+	user := createUser(t, r)
+	calendar := createCalendar(t, r, user)
+	other := createCalendar(t, r, createUser(t, r))
+
+	got, err := r.Calendars.GetCalendarByID(ctx, calendar.ID)
+	require.NoError(t, err)
+	require.Equal(t, calendar, got)
+
+	calendars, err := r.Calendars.GetCalendars(ctx, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, []domain.Calendar{{ID: calendar.ID, UserID: user.ID, Name: calendar.Name}}, calendars)
+
+	err = r.Calendars.DeleteCalendar(ctx, other.ID, user.ID)
+	require.Equal(t, domain.ErrNotFound, err)
+
+	err = r.Calendars.DeleteCalendar(ctx, calendar.ID, user.ID)
+	require.NoError(t, err)
+
+	_, err = r.Calendars.GetCalendarByID(ctx, calendar.ID)
+	require.Equal(t, domain.ErrNotFound, err)
+
+	_, err = r.Calendars.GetCalendarByID(ctx, r.MissingID)
+	require.Equal(t, domain.ErrNotFound, err)
+}
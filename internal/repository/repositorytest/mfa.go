@@ -0,0 +1,111 @@
+package repositorytest
+
+import (
+	"testing"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func createMFA(t *testing.T, r Repositories, userID string) domain.MFA {
+	mfa := domain.MFA{
+		UserID:        userID,
+		Secret:        utils.RandomString(32),
+		Enabled:       true,
+		RecoveryCodes: []string{"hash-a", "hash-b"},
+	}
+	require.NoError(t, r.MFA.SetMFA(ctx, mfa))
+
+	return mfa
+}
+
+func testMFARepoSetMFA(t *testing.T, r Repositories) {
+	user := createUser(t, r)
+
+	_, err := r.MFA.GetMFA(ctx, user.ID)
+	require.Equal(t, domain.ErrNotFound, err)
+
+	err = r.MFA.SetMFA(ctx, domain.MFA{UserID: user.ID, Secret: "pending"})
+	require.NoError(t, err)
+
+	mfa, err := r.MFA.GetMFA(ctx, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, user.ID, mfa.UserID)
+	require.Equal(t, "pending", mfa.Secret)
+	require.False(t, mfa.Enabled)
+	require.Empty(t, mfa.RecoveryCodes)
+
+	enabled := createMFA(t, r, user.ID)
+
+	mfa, err = r.MFA.GetMFA(ctx, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, enabled, mfa)
+}
+
+func testMFARepoSetMFAUserNotFound(t *testing.T, r Repositories) {
+	err := r.MFA.SetMFA(ctx, domain.MFA{UserID: r.MissingID, Secret: "pending"})
+	require.Equal(t, domain.ErrNotFound, err)
+}
+
+func testMFARepoSetRecoveryCodes(t *testing.T, r Repositories) {
+	user := createUser(t, r)
+	createMFA(t, r, user.ID)
+
+	err := r.MFA.SetRecoveryCodes(ctx, user.ID, []string{"hash-c"})
+	require.NoError(t, err)
+
+	mfa, err := r.MFA.GetMFA(ctx, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash-c"}, mfa.RecoveryCodes)
+
+	err = r.MFA.SetRecoveryCodes(ctx, createUser(t, r).ID, []string{"hash-c"})
+	require.Equal(t, domain.ErrNotFound, err)
+}
+
+func testMFARepoUseStep(t *testing.T, r Repositories) {
+	user := createUser(t, r)
+	createMFA(t, r, user.ID)
+
+	require.NoError(t, r.MFA.UseStep(ctx, user.ID, 100))
+	require.Equal(t, domain.ErrNotFound, r.MFA.UseStep(ctx, user.ID, 100))
+	require.Equal(t, domain.ErrNotFound, r.MFA.UseStep(ctx, user.ID, 99))
+	require.NoError(t, r.MFA.UseStep(ctx, user.ID, 101))
+
+	mfa, err := r.MFA.GetMFA(ctx, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(101), mfa.LastStep)
+
+	err = r.MFA.UseStep(ctx, createUser(t, r).ID, 100)
+	require.Equal(t, domain.ErrNotFound, err)
+}
+
+func testMFARepoUseRecoveryCode(t *testing.T, r Repositories) {
+	user := createUser(t, r)
+	createMFA(t, r, user.ID)
+
+	require.NoError(t, r.MFA.UseRecoveryCode(ctx, user.ID, "hash-a"))
+	require.Equal(t, domain.ErrNotFound, r.MFA.UseRecoveryCode(ctx, user.ID, "hash-a"))
+	require.Equal(t, domain.ErrNotFound, r.MFA.UseRecoveryCode(ctx, user.ID, "hash-c"))
+
+	mfa, err := r.MFA.GetMFA(ctx, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash-b"}, mfa.RecoveryCodes)
+
+	other := createUser(t, r)
+	createMFA(t, r, other.ID)
+	require.Equal(t, domain.ErrNotFound, r.MFA.UseRecoveryCode(ctx, user.ID, "hash-a"))
+	require.NoError(t, r.MFA.UseRecoveryCode(ctx, other.ID, "hash-a"))
+}
+
+func testMFARepoDeleteMFA(t *testing.T, r Repositories) {
+	user := createUser(t, r)
+	createMFA(t, r, user.ID)
+
+	require.NoError(t, r.MFA.DeleteMFA(ctx, user.ID))
+
+	_, err := r.MFA.GetMFA(ctx, user.ID)
+	require.Equal(t, domain.ErrNotFound, err)
+
+	require.Equal(t, domain.ErrNotFound, r.MFA.DeleteMFA(ctx, user.ID))
+}
@@ -0,0 +1,122 @@
+package repositorytest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func createPersonalToken(t *testing.T, r Repositories, userID string, createdAt time.Time, expiresAt *time.Time) domain.PersonalToken {
+	token, err := r.PersonalTokens.Create(ctx, domain.PersonalToken{
+		UserID:    userID,
+		TokenHash: utils.RandomString(64),
+		Name:      utils.RandomString(8),
+		Scopes:    []string{domain.ScopeTodosRead, domain.ScopeCalendarsRead},
+		CreatedAt: createdAt,
+		ExpiresAt: expiresAt,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, token.ID)
+
+	return token
+}
+
+func testPersonalTokenRepoGetByTokenHash(t *testing.T, r Repositories) {
+	user := createUser(t, r)
+	now := time.Now()
+	token := createPersonalToken(t, r, user.ID, now, nil)
+
+	tokenR, err := r.PersonalTokens.GetByTokenHash(ctx, token.TokenHash)
+	require.NoError(t, err)
+	require.Equal(t, token.ID, tokenR.ID)
+	require.Equal(t, user.ID, tokenR.UserID)
+	require.Equal(t, token.Name, tokenR.Name)
+	require.Equal(t, token.Scopes, tokenR.Scopes)
+	require.WithinDuration(t, now, tokenR.CreatedAt, time.Second)
+	require.Nil(t, tokenR.LastUsedAt)
+	require.Nil(t, tokenR.ExpiresAt)
+
+	expiresAt := now.Add(time.Minute)
+	expiring := createPersonalToken(t, r, user.ID, now, &expiresAt)
+
+	tokenR, err = r.PersonalTokens.GetByTokenHash(ctx, expiring.TokenHash)
+	require.NoError(t, err)
+	require.NotNil(t, tokenR.ExpiresAt)
+	require.WithinDuration(t, expiresAt, *tokenR.ExpiresAt, time.Second)
+
+	expiredAt := now.Add(-time.Minute)
+	expired := createPersonalToken(t, r, user.ID, now, &expiredAt)
+	_, err = r.PersonalTokens.GetByTokenHash(ctx, expired.TokenHash)
+	require.Equal(t, domain.ErrNotFound, err)
+
+	_, err = r.PersonalTokens.GetByTokenHash(ctx, utils.RandomString(64))
+	require.Equal(t, domain.ErrNotFound, err)
+}
+
+func testPersonalTokenRepoGetTokens(t *testing.T, r Repositories) {
+	user := createUser(t, r)
+	now := time.Now()
+	expiredAt := now.Add(-time.Minute)
+	older := createPersonalToken(t, r, user.ID, now.Add(-time.Hour), nil)
+	newer := createPersonalToken(t, r, user.ID, now, nil)
+	createPersonalToken(t, r, user.ID, now, &expiredAt)
+	createPersonalToken(t, r, createUser(t, r).ID, now, nil)
+
+	tokens, err := r.PersonalTokens.GetTokens(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+	require.Equal(t, newer.ID, tokens[0].ID)
+	require.Equal(t, older.ID, tokens[1].ID)
+}
+
+func testPersonalTokenRepoSetLastUsed(t *testing.T, r Repositories) {
+	user := createUser(t, r)
+	now := time.Now()
+	token := createPersonalToken(t, r, user.ID, now.Add(-time.Hour), nil)
+
+	err := r.PersonalTokens.SetLastUsed(ctx, token.ID, now)
+	require.NoError(t, err)
+
+	tokenR, err := r.PersonalTokens.GetByTokenHash(ctx, token.TokenHash)
+	require.NoError(t, err)
+	require.NotNil(t, tokenR.LastUsedAt)
+	require.WithinDuration(t, now, *tokenR.LastUsedAt, time.Second)
+
+	expiredAt := now.Add(-time.Minute)
+	expired := createPersonalToken(t, r, user.ID, now, &expiredAt)
+	err = r.PersonalTokens.SetLastUsed(ctx, expired.ID, now)
+	require.Equal(t, domain.ErrNotFound, err)
+
+	err = r.PersonalTokens.SetLastUsed(ctx, r.MissingID, now)
+	require.Equal(t, domain.ErrNotFound, err)
+}
+
+func testPersonalTokenRepoDeleteToken(t *testing.T, r Repositories) {
+	user := createUser(t, r)
+	token := createPersonalToken(t, r, user.ID, time.Now(), nil)
+
+	err := r.PersonalTokens.DeleteToken(ctx, token.ID, createUser(t, r).ID)
+	require.Equal(t, domain.ErrNotFound, err)
+
+	err = r.PersonalTokens.DeleteToken(ctx, token.ID, user.ID)
+	require.NoError(t, err)
+
+	_, err = r.PersonalTokens.GetByTokenHash(ctx, token.TokenHash)
+	require.Equal(t, domain.ErrNotFound, err)
+
+	err = r.PersonalTokens.DeleteToken(ctx, token.ID, user.ID)
+	require.Equal(t, domain.ErrNotFound, err)
+}
+
+func testPersonalTokenRepoCreateUserNotFound(t *testing.T, r Repositories) {
+	_, err := r.PersonalTokens.Create(ctx, domain.PersonalToken{
+		UserID:    r.MissingID,
+		TokenHash: utils.RandomString(64),
+		Name:      utils.RandomString(8),
+		CreatedAt: time.Now(),
+	})
+	require.Equal(t, domain.ErrNotFound, err)
+}
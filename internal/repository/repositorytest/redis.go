@@ -0,0 +1,119 @@
+package repositorytest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/internal/repository"
+	"github.com/begenov/region-llc-task/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// RunRedis runs the tests every repository.Redis has to pass. wait lets the
+// given time pass for the keys of redis, so that they expire.
+func RunRedis(t *testing.T, redis repository.Redis, wait func(d time.Duration)) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, redis repository.Redis, wait func(d time.Duration))
+	}{
+		{"Set", testRedisSet},
+		{"Get", testRedisGet},
+		{"Delete", testRedisDelete},
+		{"Expiration", testRedisExpiration},
+		{"Concurrent", testRedisConcurrent},
+		{"Incr", testRedisIncr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, redis, wait)
+		})
+	}
+}
+
+func setRedis(t *testing.T, redis repository.Redis) (string, string) {
+	key := utils.RandomString(10)
+	value := utils.RandomString(15)
+	exp := time.Minute
+	err := redis.Set(key, value, exp)
+	require.NoError(t, err)
+	return key, value
+}
+
+func testRedisSet(t *testing.T, redis repository.Redis, wait func(d time.Duration)) {
+	setRedis(t, redis)
+}
+
+func testRedisGet(t *testing.T, redis repository.Redis, wait func(d time.Duration)) {
+	key, value := setRedis(t, redis)
+
+	res, err := redis.Get(key)
+	require.NoError(t, err)
+	require.Equal(t, value, res)
+
+	_, err = redis.Get(utils.RandomString(12))
+	require.Equal(t, domain.ErrNotFound, err)
+}
+
+func testRedisDelete(t *testing.T, redis repository.Redis, wait func(d time.Duration)) {
+	key, _ := setRedis(t, redis)
+	err := redis.Delete(key)
+	require.NoError(t, err)
+
+	_, err = redis.Get(key)
+	require.Equal(t, domain.ErrNotFound, err)
+}
+
+func testRedisExpiration(t *testing.T, redis repository.Redis, wait func(d time.Duration)) {
+	key := utils.RandomString(10)
+	err := redis.Set(key, utils.RandomString(15), 10*time.Millisecond)
+	require.NoError(t, err)
+
+	wait(20 * time.Millisecond)
+
+	_, err = redis.Get(key)
+	require.Equal(t, domain.ErrNotFound, err)
+}
+
+func testRedisConcurrent(t *testing.T, redis repository.Redis, wait func(d time.Duration)) {
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key := utils.RandomString(10)
+			errs <- redis.Set(key, utils.RandomString(15), time.Minute)
+			_, err := redis.Get(key)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+}
+
+func testRedisIncr(t *testing.T, redis repository.Redis, wait func(d time.Duration)) {
+	key := utils.RandomString(10)
+
+	for i := int64(1); i <= 3; i++ {
+		val, err := redis.Incr(key, 10*time.Millisecond)
+		require.NoError(t, err)
+		require.Equal(t, i, val)
+	}
+
+	res, err := redis.Get(key)
+	require.NoError(t, err)
+	require.Equal(t, "3", res)
+
+	// The counter expires after the first increment and starts over.
+	wait(20 * time.Millisecond)
+
+	val, err := redis.Incr(key, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), val)
+}
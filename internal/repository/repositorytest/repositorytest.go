@@ -0,0 +1,108 @@
+// Package repositorytest holds the tests every storage backend of the
+// repositories has to pass. A backend runs them from its own tests with the
+// repositories it builds, and keeps there only the cases specific to it.
+package repositorytest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/begenov/region-llc-task/internal/repository"
+)
+
+var ctx = context.Background()
+
+// Repositories are the repositories of a backend under test, all on the same
+// storage.
+//
+// MissingID is a well-formed identifier that no record will ever have.
+// ValidatesIDs tells the backend reports a malformed todo id as
+// ErrTodoInvalidId rather than ErrNotFound. Constraints tells the backend
+// rejects a second user with the same email, and the records of a user who
+// does not exist.
+type Repositories struct {
+	Users          repository.Users
+	Todo           repository.Todo
+	Items          repository.TodoItems
+	Calendars      repository.Calendars
+	Sessions       repository.Sessions
+	PersonalTokens repository.PersonalTokens
+	MFA            repository.MFA
+
+	MissingID    string
+	ValidatesIDs bool
+	Constraints  bool
+}
+
+type test struct {
+	name string
+	test func(t *testing.T, r Repositories)
+}
+
+// Run runs the tests every backend has to pass against r.
+func Run(t *testing.T, r Repositories) {
+	tests := []test{
+		{"UserRepo_Create", testUserRepoCreate},
+		{"UserRepo_GetUserByEmail", testUserRepoGetUserByEmail},
+		{"UserRepo_GetUserByID", testUserRepoGetUserByID},
+		{"UserRepo_SetCalendar", testUserRepoSetCalendar},
+		{"UserRepo_SetLanguage", testUserRepoSetLanguage},
+		{"UserRepo_SetPassword", testUserRepoSetPassword},
+		{"UserRepo_VerifyEmail", testUserRepoVerifyEmail},
+		{"UserRepo_UpdateUser", testUserRepoUpdateUser},
+		{"UserRepo_DeleteScheduled", testUserRepoDeleteScheduled},
+
+		{"TodoRepo_Create", testTodoRepoCreate},
+		{"TodoRepo_GetCountByTitle", testTodoRepoGetCountByTitle},
+		{"TodoRepo_GetTodoByID", testTodoRepoGetTodoByID},
+		{"TodoRepo_UpdateTodo", testTodoRepoUpdateTodo},
+		{"TodoRepo_Recurrence", testTodoRepoRecurrence},
+		{"TodoRepo_DeleteTodoByID", testTodoRepoDeleteTodoByID},
+		{"TodoRepo_UpdateTodoDoneByID", testTodoRepoUpdateTodoDoneByID},
+		{"TodoRepo_GetTodos", testTodoRepoGetTodos},
+		{"TodoRepo_GetTodosPagination", testTodoRepoGetTodosPagination},
+		{"TodoRepo_GetTodosFilter", testTodoRepoGetTodosFilter},
+		{"TodoRepo_NotFound", testTodoRepoNotFound},
+		{"TodoRepo_OtherOwner", testTodoRepoOtherOwner},
+		{"TodoRepo_SearchTodos", testTodoRepoSearchTodos},
+
+		{"TodoItemRepo_GetItems", testTodoItemRepoGetItems},
+		{"TodoItemRepo_ReorderItems", testTodoItemRepoReorderItems},
+		{"TodoItemRepo_Progress", testTodoItemRepoProgress},
+		{"TodoItemRepo_Delete", testTodoItemRepoDelete},
+		{"TodoItemRepo_OtherTodo", testTodoItemRepoOtherTodo},
+
+		{"CalendarRepo", testCalendarRepo},
+
+		{"SessionRepo_GetByRefreshToken", testSessionRepoGetByRefreshToken},
+		{"SessionRepo_GetSessions", testSessionRepoGetSessions},
+		{"SessionRepo_RotateRefreshToken", testSessionRepoRotateRefreshToken},
+		{"SessionRepo_DeleteSession", testSessionRepoDeleteSession},
+		{"SessionRepo_DeleteSessions", testSessionRepoDeleteSessions},
+
+		{"PersonalTokenRepo_GetByTokenHash", testPersonalTokenRepoGetByTokenHash},
+		{"PersonalTokenRepo_GetTokens", testPersonalTokenRepoGetTokens},
+		{"PersonalTokenRepo_SetLastUsed", testPersonalTokenRepoSetLastUsed},
+		{"PersonalTokenRepo_DeleteToken", testPersonalTokenRepoDeleteToken},
+
+		{"MFARepo_SetMFA", testMFARepoSetMFA},
+		{"MFARepo_SetRecoveryCodes", testMFARepoSetRecoveryCodes},
+		{"MFARepo_UseStep", testMFARepoUseStep},
+		{"MFARepo_UseRecoveryCode", testMFARepoUseRecoveryCode},
+		{"MFARepo_DeleteMFA", testMFARepoDeleteMFA},
+	}
+	if r.Constraints {
+		tests = append(tests,
+			test{"UserRepo_CreateDuplicateEmail", testUserRepoCreateDuplicateEmail},
+			test{"SessionRepo_CreateUserNotFound", testSessionRepoCreateUserNotFound},
+			test{"PersonalTokenRepo_CreateUserNotFound", testPersonalTokenRepoCreateUserNotFound},
+			test{"MFARepo_SetMFAUserNotFound", testMFARepoSetMFAUserNotFound},
+		)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, r)
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package repositorytest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func createSession(t *testing.T, r Repositories, userID string, lastUsedAt time.Time, expirationAt time.Time) domain.Session {
+	session, err := r.Sessions.Create(ctx, domain.Session{
+		UserID:           userID,
+		RefreshTokenHash: utils.RandomString(64),
+		Device:           utils.RandomString(6),
+		UserAgent:        utils.RandomString(20),
+		IP:               "203.0.113.7",
+		CreatedAt:        lastUsedAt,
+		LastUsedAt:       lastUsedAt,
+		ExpirationAt:     expirationAt,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, session.ID)
+
+	return session
+}
+
+func testSessionRepoGetByRefreshToken(t *testing.T, r Repositories) {
+	user := createUser(t, r)
+	now := time.Now()
+	session := createSession(t, r, user.ID, now, now.Add(time.Minute))
+
+	sessionR, err := r.Sessions.GetByRefreshToken(ctx, session.RefreshTokenHash)
+	require.NoError(t, err)
+	require.Equal(t, session.ID, sessionR.ID)
+	require.Equal(t, user.ID, sessionR.UserID)
+	require.Equal(t, session.Device, sessionR.Device)
+	require.Equal(t, session.UserAgent, sessionR.UserAgent)
+	require.Equal(t, session.IP, sessionR.IP)
+	require.WithinDuration(t, session.CreatedAt, sessionR.CreatedAt, time.Second)
+
+	expired := createSession(t, r, user.ID, now, now.Add(-time.Minute))
+	_, err = r.Sessions.GetByRefreshToken(ctx, expired.RefreshTokenHash)
+	require.Equal(t, domain.ErrNotFound, err)
+
+	_, err = r.Sessions.GetByRefreshToken(ctx, utils.RandomString(64))
+	require.Equal(t, domain.ErrNotFound, err)
+}
+
+func testSessionRepoGetSessions(t *testing.T, r Repositories) {
+	user := createUser(t, r)
+	now := time.Now()
+	older := createSession(t, r, user.ID, now.Add(-time.Hour), now.Add(time.Minute))
+	newer := createSession(t, r, user.ID, now, now.Add(time.Minute))
+	createSession(t, r, user.ID, now, now.Add(-time.Minute))
+	createSession(t, r, createUser(t, r).ID, now, now.Add(time.Minute))
+
+	sessions, err := r.Sessions.GetSessions(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	require.Equal(t, newer.ID, sessions[0].ID)
+	require.Equal(t, older.ID, sessions[1].ID)
+}
+
+func testSessionRepoRotateRefreshToken(t *testing.T, r Repositories) {
+	user := createUser(t, r)
+	now := time.Now()
+	session := createSession(t, r, user.ID, now.Add(-time.Hour), now.Add(time.Minute))
+
+	previousHash := session.RefreshTokenHash
+	session.RefreshTokenHash = utils.RandomString(64)
+	session.UserAgent = utils.RandomString(20)
+	session.IP = "198.51.100.1"
+	session.LastUsedAt = now
+	session.ExpirationAt = now.Add(time.Hour)
+
+	err := r.Sessions.RotateRefreshToken(ctx, session, previousHash)
+	require.NoError(t, err)
+
+	_, err = r.Sessions.GetByRefreshToken(ctx, previousHash)
+	require.Equal(t, domain.ErrNotFound, err)
+
+	// The previous token has already been rotated.
+	err = r.Sessions.RotateRefreshToken(ctx, domain.Session{ID: session.ID, RefreshTokenHash: utils.RandomString(64)}, previousHash)
+	require.Equal(t, domain.ErrNotFound, err)
+
+	sessionR, err := r.Sessions.GetByRefreshToken(ctx, session.RefreshTokenHash)
+	require.NoError(t, err)
+	require.Equal(t, session.UserAgent, sessionR.UserAgent)
+	require.Equal(t, session.IP, sessionR.IP)
+	require.WithinDuration(t, now, sessionR.LastUsedAt, time.Second)
+	require.WithinDuration(t, now.Add(time.Hour), sessionR.ExpirationAt, time.Second)
+
+	session.ID = r.MissingID
+	err = r.Sessions.RotateRefreshToken(ctx, session, session.RefreshTokenHash)
+	require.Equal(t, domain.ErrNotFound, err)
+}
+
+func testSessionRepoDeleteSession(t *testing.T, r Repositories) {
+	user := createUser(t, r)
+	now := time.Now()
+	session := createSession(t, r, user.ID, now, now.Add(time.Minute))
+
+	err := r.Sessions.DeleteSession(ctx, session.ID, createUser(t, r).ID)
+	require.Equal(t, domain.ErrNotFound, err)
+
+	err = r.Sessions.DeleteSession(ctx, session.ID, user.ID)
+	require.NoError(t, err)
+
+	_, err = r.Sessions.GetByRefreshToken(ctx, session.RefreshTokenHash)
+	require.Equal(t, domain.ErrNotFound, err)
+
+	err = r.Sessions.DeleteSession(ctx, session.ID, user.ID)
+	require.Equal(t, domain.ErrNotFound, err)
+}
+
+func testSessionRepoDeleteSessions(t *testing.T, r Repositories) {
+	user := createUser(t, r)
+	other := createUser(t, r)
+	now := time.Now()
+	createSession(t, r, user.ID, now, now.Add(time.Minute))
+	createSession(t, r, user.ID, now, now.Add(time.Minute))
+	kept := createSession(t, r, other.ID, now, now.Add(time.Minute))
+
+	err := r.Sessions.DeleteSessions(ctx, user.ID)
+	require.NoError(t, err)
+
+	sessions, err := r.Sessions.GetSessions(ctx, user.ID)
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+
+	sessions, err = r.Sessions.GetSessions(ctx, other.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, kept.ID, sessions[0].ID)
+}
+
+func testSessionRepoCreateUserNotFound(t *testing.T, r Repositories) {
+	_, err := r.Sessions.Create(ctx, domain.Session{
+		UserID:           r.MissingID,
+		RefreshTokenHash: utils.RandomString(64),
+		ExpirationAt:     time.Now().Add(time.Minute),
+	})
+	require.Equal(t, domain.ErrNotFound, err)
+}
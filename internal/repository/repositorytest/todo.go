@@ -0,0 +1,457 @@
+package repositorytest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func createTodo(t *testing.T, r Repositories) domain.Todo {
+	user := createUser(t, r)
+
+	todo := domain.Todo{
+		UserID:   user.ID,
+		Title:    utils.RandomString(15),
+		ActiveAt: time.Now().UTC().Truncate(time.Second),
+		Author:   user.UserName,
+		Status:   domain.Active,
+	}
+
+	newTodo, err := r.Todo.Create(ctx, todo)
+	require.NoError(t, err)
+	require.NotEmpty(t, newTodo)
+
+	require.Equal(t, todo.ActiveAt, newTodo.ActiveAt)
+	require.Equal(t, todo.Title, newTodo.Title)
+	require.Equal(t, todo.UserID, newTodo.UserID)
+	require.Equal(t, todo.Author, newTodo.Author)
+	require.Equal(t, todo.Status, newTodo.Status)
+
+	require.NotEmpty(t, newTodo.ID)
+
+	err = r.Todo.UpdateTodoID(ctx, newTodo)
+	require.NoError(t, err)
+
+	return newTodo
+}
+
+func createTodos(t *testing.T, r Repositories) string {
+	user := createUser(t, r)
+
+	count := 10
+	var todos []domain.Todo
+	for i := 0; i < count; i++ {
+		todo := domain.Todo{
+			UserID:   user.ID,
+			Title:    utils.RandomString(15),
+			ActiveAt: time.Now().UTC().Truncate(time.Second),
+			Author:   user.UserName,
+			Status:   domain.Active,
+		}
+		newTodo, err := r.Todo.Create(ctx, todo)
+		require.NoError(t, err)
+		require.NotEmpty(t, newTodo)
+
+		require.Equal(t, todo.ActiveAt, newTodo.ActiveAt)
+		require.Equal(t, todo.Title, newTodo.Title)
+		require.Equal(t, todo.UserID, newTodo.UserID)
+		require.Equal(t, todo.Author, newTodo.Author)
+		require.Equal(t, todo.Status, newTodo.Status)
+
+		require.NotEmpty(t, newTodo.ID)
+
+		err = r.Todo.UpdateTodoID(ctx, newTodo)
+		require.NoError(t, err)
+
+		todos = append(todos, todo)
+	}
+
+	return user.ID
+}
+
+func testTodoRepoCreate(t *testing.T, r Repositories) {
+	createTodo(t, r)
+}
+
+func testTodoRepoGetCountByTitle(t *testing.T, r Repositories) {
+	todo := createTodo(t, r)
+
+	count, err := r.Todo.GetCountByTitle(ctx, todo.Title, todo.UserID)
+	require.NoError(t, err)
+
+	require.NotEmpty(t, count)
+}
+
+func testTodoRepoGetTodoByID(t *testing.T, r Repositories) {
+	todo := createTodo(t, r)
+	require.NotEmpty(t, todo)
+
+	todoI, err := r.Todo.GetTodoByID(ctx, todo.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, todoI)
+
+	require.Equal(t, todo.ID, todoI.ID)
+	require.Equal(t, todo.UserID, todoI.UserID)
+	require.Equal(t, todo.ActiveAt, todoI.ActiveAt)
+	require.Equal(t, todo.Title, todoI.Title)
+	require.Equal(t, todo.ActiveAt, todoI.ActiveAt)
+	require.Equal(t, todo.Status, todoI.Status)
+
+	todo, err = r.Todo.GetTodoByID(ctx, r.MissingID)
+	require.Error(t, err)
+	require.Equal(t, err, domain.ErrNotFound)
+
+	require.Empty(t, todo)
+
+	if r.ValidatesIDs {
+		_, err = r.Todo.GetTodoByID(ctx, utils.RandomString(10))
+		require.Equal(t, err, domain.ErrTodoInvalidId)
+	}
+}
+
+func testTodoRepoUpdateTodo(t *testing.T, r Repositories) {
+	todo := createTodo(t, r)
+
+	due := time.Now().Add(time.Hour * 24).UTC().Truncate(time.Second)
+	todo.Title = utils.RandomString(10)
+	todo.Description = "**" + utils.RandomString(20) + "**"
+	todo.Priority = domain.PriorityHigh
+	todo.Tags = []string{"work", "home"}
+	todo.ActiveAt = time.Now().Add(time.Hour * 12).UTC().Truncate(time.Second)
+	todo.DueAt = &due
+
+	err := r.Todo.UpdateTodo(ctx, todo)
+	require.NoError(t, err)
+
+	todoU, err := r.Todo.GetTodoByID(ctx, todo.ID)
+	require.NoError(t, err)
+
+	require.Equal(t, todo.Title, todoU.Title)
+	require.Equal(t, todo.Description, todoU.Description)
+	require.Equal(t, todo.Priority, todoU.Priority)
+	require.Equal(t, todo.Tags, todoU.Tags)
+	require.Equal(t, todo.ActiveAt, todoU.ActiveAt)
+	require.NotNil(t, todoU.DueAt)
+	require.True(t, due.Equal(*todoU.DueAt))
+}
+
+func testTodoRepoRecurrence(t *testing.T, r Repositories) {
+	user := createUser(t, r)
+
+	todo, err := r.Todo.Create(ctx, domain.Todo{
+		UserID:     user.ID,
+		Title:      utils.RandomString(15),
+		ActiveAt:   time.Now().UTC().Truncate(time.Second),
+		Recurrence: "FREQ=WEEKLY;BYDAY=MO,WE",
+		Occurrence: 2,
+		Author:     user.UserName,
+		Status:     domain.Active,
+	})
+	require.NoError(t, err)
+
+	stored, err := r.Todo.GetTodoByID(ctx, todo.ID)
+	require.NoError(t, err)
+	require.Equal(t, todo.Recurrence, stored.Recurrence)
+	require.Equal(t, 2, stored.Occurrence)
+
+	stored.Recurrence = "FREQ=DAILY;COUNT=5"
+	err = r.Todo.UpdateTodo(ctx, stored)
+	require.NoError(t, err)
+
+	stored, err = r.Todo.GetTodoByID(ctx, todo.ID)
+	require.NoError(t, err)
+	require.Equal(t, "FREQ=DAILY;COUNT=5", stored.Recurrence)
+	require.Equal(t, 2, stored.Occurrence)
+}
+
+func testTodoRepoDeleteTodoByID(t *testing.T, r Repositories) {
+	todo := createTodo(t, r)
+
+	err := r.Todo.DeleteTodoByID(ctx, todo.ID, todo.UserID)
+	require.NoError(t, err)
+}
+
+func testTodoRepoUpdateTodoDoneByID(t *testing.T, r Repositories) {
+	todo := createTodo(t, r)
+
+	todoU, err := r.Todo.UpdateTodoDoneByID(ctx, todo.ID, todo.UserID)
+	require.NoError(t, err)
+	require.NotEmpty(t, todoU)
+
+	require.Equal(t, todo.ID, todoU.ID)
+	require.Equal(t, todo.UserID, todoU.UserID)
+	require.Equal(t, todo.Title, todoU.Title)
+	require.Equal(t, todo.ActiveAt, todoU.ActiveAt)
+	require.Equal(t, todo.Author, todoU.Author)
+}
+
+func testTodoRepoGetTodos(t *testing.T, r Repositories) {
+	userID := createTodos(t, r)
+	filter := domain.TodoFilter{
+		UserID:   userID,
+		Statuses: []string{domain.Active},
+	}
+
+	todos, err := r.Todo.GetTodos(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, todos, 10)
+
+	count, err := r.Todo.GetCountByFilter(ctx, filter)
+	require.NoError(t, err)
+	require.Equal(t, int64(10), count)
+}
+
+func testTodoRepoGetTodosPagination(t *testing.T, r Repositories) {
+	userID := createTodos(t, r)
+
+	for _, order := range []string{domain.OrderAsc, domain.OrderDesc} {
+		filter := domain.TodoFilter{
+			UserID: userID,
+			Sort:   domain.SortTitle,
+			Order:  order,
+			Limit:  3,
+		}
+
+		var titles []string
+		seen := make(map[string]bool)
+		for {
+			todos, err := r.Todo.GetTodos(ctx, filter)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(todos), 3)
+
+			for _, todo := range todos {
+				require.False(t, seen[todo.ID])
+				seen[todo.ID] = true
+				titles = append(titles, todo.Title)
+			}
+
+			if len(todos) < filter.Limit {
+				break
+			}
+
+			last := todos[len(todos)-1]
+			filter.After = &domain.TodoCursor{Value: filter.SortValue(last), ID: last.ID}
+		}
+
+		require.Len(t, titles, 10)
+		for i := 1; i < len(titles); i++ {
+			if order == domain.OrderAsc {
+				require.LessOrEqual(t, titles[i-1], titles[i])
+			} else {
+				require.GreaterOrEqual(t, titles[i-1], titles[i])
+			}
+		}
+	}
+}
+
+func testTodoRepoGetTodosFilter(t *testing.T, r Repositories) {
+	user := createUser(t, r)
+	prefix := utils.RandomString(6)
+
+	priorities := []string{domain.PriorityLow, domain.PriorityMedium, domain.PriorityHigh, domain.PriorityHigh}
+	tags := [][]string{{"work"}, {"work", "home"}, {"home"}, nil}
+
+	for i := 0; i < 4; i++ {
+		status := domain.Active
+		if i%2 == 1 {
+			status = domain.Done
+		}
+
+		_, err := r.Todo.Create(ctx, domain.Todo{
+			UserID:   user.ID,
+			Title:    fmt.Sprintf("%s %d", prefix, i),
+			Priority: priorities[i],
+			Tags:     tags[i],
+			ActiveAt: time.Date(2030, time.January, i+1, 0, 0, 0, 0, time.UTC),
+			Author:   user.UserName,
+			Status:   status,
+		})
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name   string
+		filter domain.TodoFilter
+		want   int
+	}{
+		{
+			name:   "all",
+			filter: domain.TodoFilter{},
+			want:   4,
+		},
+		{
+			name:   "status",
+			filter: domain.TodoFilter{Statuses: []string{domain.Done}},
+			want:   2,
+		},
+		{
+			name:   "status set",
+			filter: domain.TodoFilter{Statuses: []string{domain.Active, domain.Done}},
+			want:   4,
+		},
+		{
+			name: "active range",
+			filter: domain.TodoFilter{
+				ActiveFrom:   time.Date(2030, time.January, 2, 0, 0, 0, 0, time.UTC),
+				ActiveBefore: time.Date(2030, time.January, 4, 0, 0, 0, 0, time.UTC),
+			},
+			want: 2,
+		},
+		{
+			name:   "title substring",
+			filter: domain.TodoFilter{Title: strings.ToUpper(prefix) + " 3"},
+			want:   1,
+		},
+		{
+			name:   "priority",
+			filter: domain.TodoFilter{Priorities: []string{domain.PriorityHigh}},
+			want:   2,
+		},
+		{
+			name:   "priority set",
+			filter: domain.TodoFilter{Priorities: []string{domain.PriorityLow, domain.PriorityMedium}},
+			want:   2,
+		},
+		{
+			name:   "tag",
+			filter: domain.TodoFilter{Tags: []string{"work"}},
+			want:   2,
+		},
+		{
+			name:   "all tags",
+			filter: domain.TodoFilter{Tags: []string{"work", "home"}, Priorities: []string{domain.PriorityMedium}},
+			want:   1,
+		},
+		{
+			name:   "no match",
+			filter: domain.TodoFilter{Title: utils.RandomString(10)},
+			want:   0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.UserID = user.ID
+
+			todos, err := r.Todo.GetTodos(ctx, tt.filter)
+			require.NoError(t, err)
+			require.Len(t, todos, tt.want)
+
+			count, err := r.Todo.GetCountByFilter(ctx, tt.filter)
+			require.NoError(t, err)
+			require.Equal(t, int64(tt.want), count)
+		})
+	}
+}
+
+func testTodoRepoNotFound(t *testing.T, r Repositories) {
+	err := r.Todo.UpdateTodo(ctx, domain.Todo{ID: r.MissingID, UserID: r.MissingID})
+	require.Equal(t, err, domain.ErrNotFound)
+
+	err = r.Todo.DeleteTodoByID(ctx, r.MissingID, r.MissingID)
+	require.Equal(t, err, domain.ErrNotFound)
+
+	_, err = r.Todo.UpdateTodoDoneByID(ctx, r.MissingID, r.MissingID)
+	require.Equal(t, err, domain.ErrNotFound)
+}
+
+func testTodoRepoOtherOwner(t *testing.T, r Repositories) {
+	todo := createTodo(t, r)
+	other := createUser(t, r)
+
+	err := r.Todo.UpdateTodo(ctx, domain.Todo{
+		ID:       todo.ID,
+		UserID:   other.ID,
+		Title:    utils.RandomString(10),
+		ActiveAt: todo.ActiveAt,
+	})
+	require.Equal(t, err, domain.ErrNotFound)
+
+	err = r.Todo.DeleteTodoByID(ctx, todo.ID, other.ID)
+	require.Equal(t, err, domain.ErrNotFound)
+
+	stored, err := r.Todo.GetTodoByID(ctx, todo.ID)
+	require.NoError(t, err)
+	require.Equal(t, todo.Title, stored.Title)
+}
+
+func testTodoRepoSearchTodos(t *testing.T, r Repositories) {
+	user := createUser(t, r)
+
+	create := func(title, description string) domain.Todo {
+		todo, err := r.Todo.Create(ctx, domain.Todo{
+			UserID:      user.ID,
+			Title:       title,
+			Description: description,
+			ActiveAt:    time.Now().UTC().Truncate(time.Second),
+			Author:      user.UserName,
+			Status:      domain.Active,
+		})
+		require.NoError(t, err)
+
+		return todo
+	}
+
+	milk := create("Купить молоко", "молоко и хлеб")
+	bread := create("Хлеб", "купить молоко по дороге домой")
+	coffee := create("Кофе", "купить зерна")
+	other := createUser(t, r)
+
+	tests := []struct {
+		name   string
+		query  string
+		userID string
+		want   []string
+	}{
+		{
+			name:   "title ranks higher",
+			query:  "молоко",
+			userID: user.ID,
+			want:   []string{milk.ID, bread.ID},
+		},
+		{
+			name:   "all terms",
+			query:  "купить зерна",
+			userID: user.ID,
+			want:   []string{coffee.ID},
+		},
+		{
+			name:   "phrase",
+			query:  `"молоко по дороге"`,
+			userID: user.ID,
+			want:   []string{bread.ID},
+		},
+		{
+			name:   "exclusion",
+			query:  "купить -хлеб",
+			userID: user.ID,
+			want:   []string{coffee.ID},
+		},
+		{
+			name:   "other user",
+			query:  "молоко",
+			userID: other.ID,
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := domain.ParseSearchQuery(tt.query)
+			require.NoError(t, err)
+
+			results, err := r.Todo.SearchTodos(ctx, tt.userID, query, 10)
+			require.NoError(t, err)
+
+			var ids []string
+			for _, result := range results {
+				require.Greater(t, result.Score, float64(0))
+				ids = append(ids, result.ID)
+			}
+			require.Equal(t, tt.want, ids)
+		})
+	}
+}
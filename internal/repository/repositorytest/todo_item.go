@@ -0,0 +1,123 @@
+package repositorytest
+
+import (
+	"testing"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func createTodoItems(t *testing.T, r Repositories, todo domain.Todo, count int) []domain.TodoItem {
+	var items []domain.TodoItem
+	for i := 0; i < count; i++ {
+		item, err := r.Items.Create(ctx, domain.TodoItem{
+			TodoID: todo.ID,
+			Title:  utils.RandomString(10),
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, item.ID)
+		require.Equal(t, todo.ID, item.TodoID)
+		require.Equal(t, i+1, item.Position)
+		require.False(t, item.Done)
+
+		items = append(items, item)
+	}
+
+	return items
+}
+
+func testTodoItemRepoGetItems(t *testing.T, r Repositories) {
+	todo := createTodo(t, r)
+	items := createTodoItems(t, r, todo, 3)
+
+	got, err := r.Items.GetItems(ctx, todo.ID)
+	require.NoError(t, err)
+	require.Equal(t, items, got)
+
+	item, err := r.Items.GetItemByID(ctx, items[1].ID, todo.ID)
+	require.NoError(t, err)
+	require.Equal(t, items[1], item)
+}
+
+func testTodoItemRepoReorderItems(t *testing.T, r Repositories) {
+	todo := createTodo(t, r)
+	items := createTodoItems(t, r, todo, 3)
+
+	err := r.Items.ReorderItems(ctx, todo.ID, []string{items[2].ID, items[0].ID, items[1].ID})
+	require.NoError(t, err)
+
+	got, err := r.Items.GetItems(ctx, todo.ID)
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+	require.Equal(t, []string{items[2].ID, items[0].ID, items[1].ID}, []string{got[0].ID, got[1].ID, got[2].ID})
+	require.Equal(t, []int{1, 2, 3}, []int{got[0].Position, got[1].Position, got[2].Position})
+
+	item, err := r.Items.Create(ctx, domain.TodoItem{TodoID: todo.ID, Title: utils.RandomString(10)})
+	require.NoError(t, err)
+	require.Equal(t, 4, item.Position)
+}
+
+func testTodoItemRepoProgress(t *testing.T, r Repositories) {
+	todo, other, empty := createTodo(t, r), createTodo(t, r), createTodo(t, r)
+	items := createTodoItems(t, r, todo, 3)
+	createTodoItems(t, r, other, 1)
+
+	item, err := r.Items.UpdateItemDone(ctx, items[0].ID, todo.ID, true)
+	require.NoError(t, err)
+	require.True(t, item.Done)
+
+	progress, err := r.Items.GetProgress(ctx, []string{todo.ID, other.ID, empty.ID})
+	require.NoError(t, err)
+	require.Equal(t, map[string]domain.Progress{
+		todo.ID:  {Done: 1, Total: 3},
+		other.ID: {Done: 0, Total: 1},
+	}, progress)
+
+	item, err = r.Items.UpdateItemDone(ctx, items[0].ID, todo.ID, false)
+	require.NoError(t, err)
+	require.False(t, item.Done)
+}
+
+func testTodoItemRepoDelete(t *testing.T, r Repositories) {
+	todo := createTodo(t, r)
+	items := createTodoItems(t, r, todo, 3)
+
+	err := r.Items.DeleteItem(ctx, items[0].ID, todo.ID)
+	require.NoError(t, err)
+
+	err = r.Items.DeleteItem(ctx, items[0].ID, todo.ID)
+	require.Equal(t, domain.ErrNotFound, err)
+
+	got, err := r.Items.GetItems(ctx, todo.ID)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+
+	err = r.Items.DeleteItems(ctx, todo.ID)
+	require.NoError(t, err)
+
+	got, err = r.Items.GetItems(ctx, todo.ID)
+	require.NoError(t, err)
+	require.Empty(t, got)
+}
+
+func testTodoItemRepoOtherTodo(t *testing.T, r Repositories) {
+	todo, other := createTodo(t, r), createTodo(t, r)
+	item := createTodoItems(t, r, todo, 1)[0]
+
+	_, err := r.Items.GetItemByID(ctx, item.ID, other.ID)
+	require.Equal(t, domain.ErrNotFound, err)
+
+	_, err = r.Items.UpdateItemDone(ctx, item.ID, other.ID, true)
+	require.Equal(t, domain.ErrNotFound, err)
+
+	err = r.Items.DeleteItem(ctx, item.ID, other.ID)
+	require.Equal(t, domain.ErrNotFound, err)
+
+	err = r.Items.ReorderItems(ctx, other.ID, []string{item.ID})
+	require.NoError(t, err)
+
+	got, err := r.Items.GetItemByID(ctx, item.ID, todo.ID)
+	require.NoError(t, err)
+	require.Equal(t, item, got)
+}
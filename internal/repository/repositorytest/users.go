@@ -0,0 +1,222 @@
+package repositorytest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func createUser(t *testing.T, r Repositories) domain.User {
+	user := domain.User{
+		UserName: utils.RandomString(7),
+		Email:    utils.RandomEmail(),
+		Password: utils.RandomString(7),
+		CreateAt: time.Now().Format("2006-01-02"),
+	}
+	newUser, err := r.Users.Create(ctx, user)
+	require.NoError(t, err)
+
+	require.Equal(t, newUser.UserName, user.UserName)
+	require.Equal(t, newUser.Password, user.Password)
+	require.Equal(t, newUser.Email, user.Email)
+	require.Equal(t, newUser.CreateAt, user.CreateAt)
+
+	require.NotEmpty(t, newUser.ID)
+
+	return newUser
+}
+
+func testUserRepoCreate(t *testing.T, r Repositories) {
+	createUser(t, r)
+}
+
+func testUserRepoGetUserByEmail(t *testing.T, r Repositories) {
+	user := createUser(t, r)
+	require.NotEmpty(t, user)
+
+	userE, err := r.Users.GetUserByEmail(ctx, user.Email)
+	require.NoError(t, err)
+	require.NotEmpty(t, userE)
+
+	require.Equal(t, user.ID, userE.ID)
+	require.Equal(t, user.Email, userE.Email)
+	require.Equal(t, user.UserName, userE.UserName)
+	require.Equal(t, user.CreateAt, userE.CreateAt)
+	require.Equal(t, user.Password, userE.Password)
+
+	_, err = r.Users.GetUserByEmail(ctx, "no-email")
+	require.Equal(t, err, domain.ErrNotFound)
+}
+
+func testUserRepoGetUserByID(t *testing.T, r Repositories) {
+	user := createUser(t, r)
+	require.NotEmpty(t, user)
+
+	userI, err := r.Users.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, userI)
+
+	require.Equal(t, user.ID, userI.ID)
+	require.Equal(t, user.Email, userI.Email)
+	require.Equal(t, user.UserName, userI.UserName)
+	require.Equal(t, user.CreateAt, userI.CreateAt)
+	require.Equal(t, user.Password, userI.Password)
+
+	_, err = r.Users.GetUserByID(ctx, r.MissingID)
+	require.Equal(t, err, domain.ErrNotFound)
+}
+
+func testUserRepoCreateDuplicateEmail(t *testing.T, r Repositories) {
+	user := createUser(t, r)
+
+	_, err := r.Users.Create(ctx, domain.User{
+		UserName: utils.RandomString(7),
+		Email:    user.Email,
+		Password: utils.RandomString(7),
+		CreateAt: time.Now().Format("2006-01-02"),
+	})
+	require.Equal(t, err, domain.ErrEmailAlreadyExists)
+}
+
+func testUserRepoSetCalendar(t *testing.T, r Repositories) {
+	user := createUser(t, r)
+
+	err := r.Users.SetCalendar(ctx, user.ID, "kz")
+	require.NoError(t, err)
+
+	userI, err := r.Users.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, "kz", userI.Calendar)
+
+	err = r.Users.SetCalendar(ctx, r.MissingID, "kz")
+	require.Equal(t, domain.ErrNotFound, err)
+}
+
+func testUserRepoSetLanguage(t *testing.T, r Repositories) {
+	user := createUser(t, r)
+
+	err := r.Users.SetLanguage(ctx, user.ID, "kk")
+	require.NoError(t, err)
+
+	userI, err := r.Users.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, "kk", userI.Language)
+
+	err = r.Users.SetLanguage(ctx, r.MissingID, "kk")
+	require.Equal(t, domain.ErrNotFound, err)
+}
+
+func testUserRepoSetPassword(t *testing.T, r Repositories) {
+	user := createUser(t, r)
+
+	err := r.Users.SetPassword(ctx, user.ID, "new-hash")
+	require.NoError(t, err)
+
+	userI, err := r.Users.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, "new-hash", userI.Password)
+
+	err = r.Users.SetPassword(ctx, r.MissingID, "new-hash")
+	require.Equal(t, domain.ErrNotFound, err)
+}
+
+func testUserRepoVerifyEmail(t *testing.T, r Repositories) {
+	user := createUser(t, r)
+	require.False(t, user.EmailVerified)
+
+	userI, err := r.Users.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	require.False(t, userI.EmailVerified)
+
+	email := utils.RandomEmail()
+	err = r.Users.VerifyEmail(ctx, user.ID, email)
+	require.NoError(t, err)
+
+	userI, err = r.Users.GetUserByEmail(ctx, email)
+	require.NoError(t, err)
+	require.Equal(t, user.ID, userI.ID)
+	require.True(t, userI.EmailVerified)
+
+	other := createUser(t, r)
+	err = r.Users.VerifyEmail(ctx, other.ID, email)
+	require.Equal(t, domain.ErrEmailAlreadyExists, err)
+
+	err = r.Users.VerifyEmail(ctx, r.MissingID, utils.RandomEmail())
+	require.Equal(t, domain.ErrNotFound, err)
+}
+
+func testUserRepoUpdateUser(t *testing.T, r Repositories) {
+	user := createUser(t, r)
+	user.UserName = utils.RandomString(8)
+	user.Timezone = "Asia/Almaty"
+	user.Language = "kk"
+
+	err := r.Users.UpdateUser(ctx, user)
+	require.NoError(t, err)
+
+	userI, err := r.Users.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, user.UserName, userI.UserName)
+	require.Equal(t, user.Timezone, userI.Timezone)
+	require.Equal(t, user.Language, userI.Language)
+	require.Equal(t, user.Email, userI.Email)
+
+	user.ID = r.MissingID
+	err = r.Users.UpdateUser(ctx, user)
+	require.Equal(t, domain.ErrNotFound, err)
+}
+
+func testUserRepoDeleteScheduled(t *testing.T, r Repositories) {
+	now := time.Now()
+	todo := createTodo(t, r)
+	createSession(t, r, todo.UserID, now, now.Add(time.Hour))
+	createMFA(t, r, todo.UserID)
+	kept := createUser(t, r)
+
+	deleteAt := now.Add(-time.Minute).Truncate(time.Second)
+	err := r.Users.ScheduleDeletion(ctx, todo.UserID, &deleteAt)
+	require.NoError(t, err)
+
+	user, err := r.Users.GetUserByID(ctx, todo.UserID)
+	require.NoError(t, err)
+	require.NotNil(t, user.DeleteAt)
+	require.True(t, deleteAt.Equal(*user.DeleteAt))
+
+	later := now.Add(time.Hour)
+	err = r.Users.ScheduleDeletion(ctx, kept.ID, &later)
+	require.NoError(t, err)
+
+	deleted, err := r.Users.DeleteScheduled(ctx, now)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, deleted, int64(1))
+
+	_, err = r.Users.GetUserByID(ctx, todo.UserID)
+	require.Equal(t, domain.ErrNotFound, err)
+
+	_, err = r.Todo.GetTodoByID(ctx, todo.ID)
+	require.Equal(t, domain.ErrNotFound, err)
+
+	sessions, err := r.Sessions.GetSessions(ctx, todo.UserID)
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+
+	_, err = r.MFA.GetMFA(ctx, todo.UserID)
+	require.Equal(t, domain.ErrNotFound, err)
+
+	// A cancelled deletion keeps the user.
+	err = r.Users.ScheduleDeletion(ctx, kept.ID, nil)
+	require.NoError(t, err)
+
+	_, err = r.Users.DeleteScheduled(ctx, later)
+	require.NoError(t, err)
+
+	user, err = r.Users.GetUserByID(ctx, kept.ID)
+	require.NoError(t, err)
+	require.Nil(t, user.DeleteAt)
+
+	err = r.Users.ScheduleDeletion(ctx, r.MissingID, &later)
+	require.Equal(t, domain.ErrNotFound, err)
+}
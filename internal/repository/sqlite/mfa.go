@@ -0,0 +1,212 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/logger"
+)
+
+type MFARepo struct {
+	db *sql.DB
+}
+
+func NewMFARepo(db *sql.DB) *MFARepo {
+	return &MFARepo{
+		db: db,
+	}
+}
+
+func (r *MFARepo) GetMFA(ctx context.Context, userID string) (domain.MFA, error) {
+	id, ok := parseID(userID)
+	if !ok {
+		return domain.MFA{}, domain.ErrNotFound
+	}
+
+	mfa := domain.MFA{UserID: userID}
+	err := r.db.QueryRowContext(ctx, `SELECT secret, enabled, last_step FROM user_mfa WHERE user_id = ?`, id).
+		Scan(&mfa.Secret, &mfa.Enabled, &mfa.LastStep)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.MFA{}, domain.ErrNotFound
+		}
+
+		logger.Errorf("r.db.QueryRowContext(): %v", err)
+		return domain.MFA{}, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT code_hash FROM mfa_recovery_codes WHERE user_id = ? ORDER BY code_hash`, id)
+	if err != nil {
+		logger.Errorf("r.db.QueryContext(): %v", err)
+		return domain.MFA{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			logger.Errorf("rows.Scan(): %v", err)
+			return domain.MFA{}, err
+		}
+		mfa.RecoveryCodes = append(mfa.RecoveryCodes, hash)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Errorf("rows.Err(): %v", err)
+		return domain.MFA{}, err
+	}
+
+	return mfa, nil
+}
+
+func (r *MFARepo) SetMFA(ctx context.Context, mfa domain.MFA) error {
+	id, ok := parseID(mfa.UserID)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Errorf("r.db.BeginTx(): %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO user_mfa (user_id, secret, enabled, last_step) VALUES (?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET secret = excluded.secret, enabled = excluded.enabled, last_step = excluded.last_step`,
+		id, mfa.Secret, mfa.Enabled, mfa.LastStep,
+	)
+	if err != nil {
+		logger.Errorf("tx.ExecContext(): %v", err)
+		if isForeignKeyViolation(err) {
+			return domain.ErrNotFound
+		}
+
+		return err
+	}
+
+	if err := setRecoveryCodes(ctx, tx, id, mfa.RecoveryCodes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Errorf("tx.Commit(): %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *MFARepo) SetRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error {
+	id, ok := parseID(userID)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Errorf("r.db.BeginTx(): %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM user_mfa WHERE user_id = ?)`, id).Scan(&exists)
+	if err != nil {
+		logger.Errorf("tx.QueryRowContext(): %v", err)
+		return err
+	}
+
+	if !exists {
+		return domain.ErrNotFound
+	}
+
+	if err := setRecoveryCodes(ctx, tx, id, codeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Errorf("tx.Commit(): %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *MFARepo) UseStep(ctx context.Context, userID string, step int64) error {
+	id, ok := parseID(userID)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.db.ExecContext(ctx, `UPDATE user_mfa SET last_step = ? WHERE user_id = ? AND last_step < ?`, step, id, step)
+	if err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *MFARepo) UseRecoveryCode(ctx context.Context, userID string, codeHash string) error {
+	id, ok := parseID(userID)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = ? AND code_hash = ?`, id, codeHash)
+	if err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *MFARepo) DeleteMFA(ctx context.Context, userID string) error {
+	id, ok := parseID(userID)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM user_mfa WHERE user_id = ?`, id)
+	if err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// setRecoveryCodes replaces the recovery codes of the user.
+func setRecoveryCodes(ctx context.Context, tx *sql.Tx, userID int64, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = ?`, userID); err != nil {
+		logger.Errorf("tx.ExecContext(delete): %v", err)
+		return err
+	}
+
+	for _, hash := range codeHashes {
+		_, err := tx.ExecContext(ctx, `INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES (?, ?)`, userID, hash)
+		if err != nil {
+			logger.Errorf("tx.ExecContext(insert): %v", err)
+			return err
+		}
+	}
+
+	return nil
+}
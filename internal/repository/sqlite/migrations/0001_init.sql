@@ -0,0 +1,38 @@
+CREATE TABLE users (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    username      TEXT NOT NULL,
+    email         TEXT NOT NULL UNIQUE,
+    password      TEXT NOT NULL,
+    create_at     TEXT NOT NULL,
+    last_visit_at INTEGER
+);
+
+CREATE TABLE sessions (
+    user_id       INTEGER PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    refresh_token TEXT NOT NULL,
+    expiration_at INTEGER NOT NULL
+);
+
+CREATE INDEX sessions_refresh_token_idx ON sessions (refresh_token);
+
+CREATE TABLE todos (
+    id        INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id   INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    title     TEXT NOT NULL,
+    active_at TEXT NOT NULL,
+    author    TEXT NOT NULL,
+    status    TEXT NOT NULL
+);
+
+CREATE INDEX todos_user_id_status_idx ON todos (user_id, status);
+CREATE INDEX todos_user_id_title_idx ON todos (user_id, title);
+
+-- kv replaces the Redis refresh-token cache; expires_at is a unix timestamp
+-- in milliseconds, NULL for keys without expiration.
+CREATE TABLE kv (
+    key        TEXT PRIMARY KEY,
+    value      TEXT NOT NULL,
+    expires_at INTEGER
+);
+
+CREATE INDEX kv_expires_at_idx ON kv (expires_at);
@@ -0,0 +1,6 @@
+-- Keyset pagination sorts by (field, id) within a user, the title index also
+-- serves the title uniqueness check.
+DROP INDEX todos_user_id_title_idx;
+
+CREATE INDEX todos_user_id_active_at_id_idx ON todos (user_id, active_at, id);
+CREATE INDEX todos_user_id_title_id_idx ON todos (user_id, title, id);
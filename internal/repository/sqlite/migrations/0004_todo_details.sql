@@ -0,0 +1,4 @@
+-- Tags are a JSON array of strings, due_at is unix seconds.
+ALTER TABLE todos ADD COLUMN priority TEXT NOT NULL DEFAULT 'medium';
+ALTER TABLE todos ADD COLUMN tags TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE todos ADD COLUMN due_at INTEGER;
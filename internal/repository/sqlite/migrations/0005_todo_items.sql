@@ -0,0 +1,9 @@
+CREATE TABLE todo_items (
+    id       INTEGER PRIMARY KEY AUTOINCREMENT,
+    todo_id  INTEGER NOT NULL REFERENCES todos (id) ON DELETE CASCADE,
+    title    TEXT NOT NULL,
+    done     BOOLEAN NOT NULL DEFAULT 0,
+    position INTEGER NOT NULL
+);
+
+CREATE INDEX todo_items_todo_id_position_idx ON todo_items (todo_id, position);
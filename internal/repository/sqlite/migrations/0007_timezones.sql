@@ -0,0 +1,5 @@
+-- active_at was a date in UTC, now it is the instant the todo becomes active,
+-- kept as fixed-width UTC text. Dates of a user are read in their timezone.
+ALTER TABLE users ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
+
+UPDATE todos SET active_at = active_at || 'T00:00:00.000000000Z' WHERE length(active_at) = 10;
@@ -0,0 +1,5 @@
+-- Refresh tokens are stored by their hash. SQLite cannot hash the stored
+-- tokens, so the current sessions end and their users sign in again.
+DELETE FROM sessions;
+
+ALTER TABLE sessions RENAME COLUMN refresh_token TO refresh_token_hash;
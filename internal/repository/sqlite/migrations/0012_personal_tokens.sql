@@ -0,0 +1,14 @@
+-- Personal tokens of scripts and CI, stored by the hex SHA-256 of the token.
+-- Scopes are a JSON array, expires_at is NULL for a token that never expires.
+CREATE TABLE personal_tokens (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id      INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token_hash   TEXT NOT NULL UNIQUE,
+    name         TEXT NOT NULL,
+    scopes       TEXT NOT NULL DEFAULT '[]',
+    created_at   INTEGER NOT NULL,
+    last_used_at INTEGER,
+    expires_at   INTEGER
+);
+
+CREATE INDEX personal_tokens_user_id_idx ON personal_tokens (user_id, created_at);
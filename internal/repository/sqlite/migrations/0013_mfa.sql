@@ -0,0 +1,15 @@
+-- TOTP two-factor authentication. last_step is the time step of the last
+-- accepted code, recovery codes are stored by the hex SHA-256 of the code and
+-- deleted once used.
+CREATE TABLE user_mfa (
+    user_id   INTEGER PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    secret    TEXT NOT NULL,
+    enabled   INTEGER NOT NULL DEFAULT 0,
+    last_step INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE mfa_recovery_codes (
+    user_id   INTEGER NOT NULL REFERENCES user_mfa (user_id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    PRIMARY KEY (user_id, code_hash)
+);
@@ -0,0 +1,3 @@
+-- Whether the user has opened the verification link sent to the email. The
+-- users registered before verification existed keep using their accounts.
+ALTER TABLE users ADD COLUMN email_verified INTEGER NOT NULL DEFAULT 1;
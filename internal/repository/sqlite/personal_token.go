@@ -0,0 +1,179 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/logger"
+)
+
+type PersonalTokenRepo struct {
+	db *sql.DB
+}
+
+func NewPersonalTokenRepo(db *sql.DB) *PersonalTokenRepo {
+	return &PersonalTokenRepo{
+		db: db,
+	}
+}
+
+func (r *PersonalTokenRepo) Create(ctx context.Context, token domain.PersonalToken) (domain.PersonalToken, error) {
+	userID, ok := parseID(token.UserID)
+	if !ok {
+		return domain.PersonalToken{}, domain.ErrNotFound
+	}
+
+	var id int64
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO personal_tokens (user_id, token_hash, name, scopes, created_at, last_used_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id`,
+		userID, token.TokenHash, token.Name, encodeTags(token.Scopes),
+		token.CreatedAt.Unix(), unixTime(token.LastUsedAt), unixTime(token.ExpiresAt),
+	).Scan(&id)
+	if err != nil {
+		logger.Errorf("r.db.QueryRowContext(): %v", err)
+		if isForeignKeyViolation(err) {
+			return domain.PersonalToken{}, domain.ErrNotFound
+		}
+
+		return domain.PersonalToken{}, domain.ErrInternalServer
+	}
+
+	token.ID = formatID(id)
+
+	return token, nil
+}
+
+func (r *PersonalTokenRepo) GetByTokenHash(ctx context.Context, tokenHash string) (domain.PersonalToken, error) {
+	tokens, err := r.getTokens(ctx, `WHERE token_hash = ? AND (expires_at IS NULL OR expires_at > ?)`, tokenHash, time.Now().Unix())
+	if err != nil {
+		return domain.PersonalToken{}, err
+	}
+
+	if len(tokens) == 0 {
+		return domain.PersonalToken{}, domain.ErrNotFound
+	}
+
+	return tokens[0], nil
+}
+
+func (r *PersonalTokenRepo) GetTokens(ctx context.Context, userID string) ([]domain.PersonalToken, error) {
+	id, ok := parseID(userID)
+	if !ok {
+		return nil, nil
+	}
+
+	return r.getTokens(ctx, `WHERE user_id = ? AND (expires_at IS NULL OR expires_at > ?) ORDER BY created_at DESC, id DESC`,
+		id, time.Now().Unix())
+}
+
+func (r *PersonalTokenRepo) SetLastUsed(ctx context.Context, id string, lastUsedAt time.Time) error {
+	tokenID, ok := parseID(id)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE personal_tokens SET last_used_at = ?
+		WHERE id = ? AND (expires_at IS NULL OR expires_at > ?)`,
+		lastUsedAt.Unix(), tokenID, time.Now().Unix(),
+	)
+	if err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *PersonalTokenRepo) DeleteToken(ctx context.Context, id string, userID string) error {
+	tokenID, ok := parseID(id)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	ownerID, ok := parseID(userID)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM personal_tokens
+		WHERE id = ? AND user_id = ? AND (expires_at IS NULL OR expires_at > ?)`,
+		tokenID, ownerID, time.Now().Unix(),
+	)
+	if err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *PersonalTokenRepo) getTokens(ctx context.Context, where string, args ...interface{}) ([]domain.PersonalToken, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, token_hash, name, scopes, created_at, last_used_at, expires_at
+		FROM personal_tokens
+		`+where, args...,
+	)
+	if err != nil {
+		logger.Errorf("r.db.QueryContext(): %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []domain.PersonalToken
+	for rows.Next() {
+		var (
+			token                 domain.PersonalToken
+			id, userID, createdAt int64
+			scopes                string
+			lastUsedAt, expiresAt sql.NullInt64
+		)
+
+		err := rows.Scan(&id, &userID, &token.TokenHash, &token.Name, &scopes, &createdAt, &lastUsedAt, &expiresAt)
+		if err != nil {
+			logger.Errorf("rows.Scan(): %v", err)
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(scopes), &token.Scopes); err != nil {
+			logger.Errorf("json.Unmarshal(): %v", err)
+			return nil, err
+		}
+
+		token.ID = formatID(id)
+		token.UserID = formatID(userID)
+		token.CreatedAt = time.Unix(createdAt, 0)
+		token.LastUsedAt = nullUnixTime(lastUsedAt)
+		token.ExpiresAt = nullUnixTime(expiresAt)
+		tokens = append(tokens, token)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Errorf("rows.Err(): %v", err)
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func nullUnixTime(t sql.NullInt64) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	res := time.Unix(t.Int64, 0)
+	return &res
+}
@@ -0,0 +1,104 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/logger"
+)
+
+// Redis implements repository.Redis on top of the kv table so that the
+// SQLite deployment does not need a Redis server. Expired keys are ignored on
+// read and purged on every write.
+type Redis struct {
+	db *sql.DB
+}
+
+func NewRedis(db *sql.DB) *Redis {
+	return &Redis{
+		db: db,
+	}
+}
+
+func (r *Redis) Set(key string, value string, expiration time.Duration) error {
+	ctx := context.Background()
+	now := time.Now()
+
+	var expiresAt sql.NullInt64
+	if expiration > 0 {
+		expiresAt = sql.NullInt64{Int64: now.Add(expiration).UnixMilli(), Valid: true}
+	}
+
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM kv WHERE expires_at <= ?`, now.UnixMilli()); err != nil {
+		logger.Errorf("r.db.ExecContext(purge): %v", err)
+		return err
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO kv (key, value, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at`,
+		key, value, expiresAt,
+	)
+	if err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *Redis) Get(key string) (string, error) {
+	var value string
+	err := r.db.QueryRowContext(context.Background(),
+		`SELECT value FROM kv WHERE key = ? AND (expires_at IS NULL OR expires_at > ?)`,
+		key, time.Now().UnixMilli(),
+	).Scan(&value)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", domain.ErrNotFound
+		}
+
+		logger.Errorf("r.db.QueryRowContext(): %v", err)
+		return "", err
+	}
+
+	return value, nil
+}
+
+func (r *Redis) Delete(key string) error {
+	if _, err := r.db.ExecContext(context.Background(), `DELETE FROM kv WHERE key = ?`, key); err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *Redis) Incr(key string, expiration time.Duration) (int64, error) {
+	now := time.Now().UnixMilli()
+
+	var expiresAt sql.NullInt64
+	if expiration > 0 {
+		expiresAt = sql.NullInt64{Int64: now + expiration.Milliseconds(), Valid: true}
+	}
+
+	// An expired counter that has not been purged yet starts over.
+	var val int64
+	err := r.db.QueryRowContext(context.Background(), `
+		INSERT INTO kv (key, value, expires_at) VALUES (?, '1', ?)
+		ON CONFLICT (key) DO UPDATE SET
+			value = CASE WHEN kv.expires_at <= ? THEN '1' ELSE CAST(CAST(kv.value AS INTEGER) + 1 AS TEXT) END,
+			expires_at = CASE WHEN kv.expires_at <= ? THEN excluded.expires_at ELSE kv.expires_at END
+		RETURNING CAST(value AS INTEGER)`,
+		key, expiresAt, now, now,
+	).Scan(&val)
+	if err != nil {
+		logger.Errorf("r.db.QueryRowContext(): %v", err)
+		return 0, err
+	}
+
+	return val, nil
+}
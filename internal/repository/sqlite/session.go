@@ -0,0 +1,194 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/logger"
+)
+
+type SessionRepo struct {
+	db *sql.DB
+}
+
+func NewSessionRepo(db *sql.DB) *SessionRepo {
+	return &SessionRepo{
+		db: db,
+	}
+}
+
+// Create also purges the expired sessions of the user.
+func (r *SessionRepo) Create(ctx context.Context, session domain.Session) (domain.Session, error) {
+	userID, ok := parseID(session.UserID)
+	if !ok {
+		return domain.Session{}, domain.ErrNotFound
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Errorf("r.db.BeginTx(): %v", err)
+		return domain.Session{}, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = ? AND expiration_at <= ?`, userID, time.Now().Unix())
+	if err != nil {
+		logger.Errorf("tx.ExecContext(purge): %v", err)
+		return domain.Session{}, err
+	}
+
+	var id int64
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO sessions (user_id, refresh_token_hash, device, user_agent, ip, created_at, last_used_at, expiration_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`,
+		userID, session.RefreshTokenHash, session.Device, session.UserAgent, session.IP,
+		session.CreatedAt.Unix(), session.LastUsedAt.Unix(), session.ExpirationAt.Unix(),
+	).Scan(&id)
+	if err != nil {
+		logger.Errorf("tx.QueryRowContext(): %v", err)
+		if isForeignKeyViolation(err) {
+			return domain.Session{}, domain.ErrNotFound
+		}
+
+		return domain.Session{}, domain.ErrInternalServer
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Errorf("tx.Commit(): %v", err)
+		return domain.Session{}, err
+	}
+
+	session.ID = formatID(id)
+
+	return session, nil
+}
+
+func (r *SessionRepo) GetByRefreshToken(ctx context.Context, refreshTokenHash string) (domain.Session, error) {
+	sessions, err := r.getSessions(ctx, `WHERE refresh_token_hash = ? AND expiration_at > ?`, refreshTokenHash, time.Now().Unix())
+	if err != nil {
+		return domain.Session{}, err
+	}
+
+	if len(sessions) == 0 {
+		return domain.Session{}, domain.ErrNotFound
+	}
+
+	return sessions[0], nil
+}
+
+func (r *SessionRepo) GetSessions(ctx context.Context, userID string) ([]domain.Session, error) {
+	id, ok := parseID(userID)
+	if !ok {
+		return nil, nil
+	}
+
+	return r.getSessions(ctx, `WHERE user_id = ? AND expiration_at > ? ORDER BY last_used_at DESC, id`, id, time.Now().Unix())
+}
+
+func (r *SessionRepo) RotateRefreshToken(ctx context.Context, session domain.Session, previousHash string) error {
+	id, ok := parseID(session.ID)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE sessions SET refresh_token_hash = ?, user_agent = ?, ip = ?, last_used_at = ?, expiration_at = ?
+		WHERE id = ? AND refresh_token_hash = ? AND expiration_at > ?`,
+		session.RefreshTokenHash, session.UserAgent, session.IP, session.LastUsedAt.Unix(), session.ExpirationAt.Unix(),
+		id, previousHash, time.Now().Unix(),
+	)
+	if err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *SessionRepo) DeleteSession(ctx context.Context, id string, userID string) error {
+	sessionID, ok := parseID(id)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	ownerID, ok := parseID(userID)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM sessions WHERE id = ? AND user_id = ? AND expiration_at > ?`,
+		sessionID, ownerID, time.Now().Unix())
+	if err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *SessionRepo) DeleteSessions(ctx context.Context, userID string) error {
+	id, ok := parseID(userID)
+	if !ok {
+		return nil
+	}
+
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = ?`, id); err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SessionRepo) getSessions(ctx context.Context, where string, args ...interface{}) ([]domain.Session, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, refresh_token_hash, device, user_agent, ip, created_at, last_used_at, expiration_at
+		FROM sessions
+		`+where, args...,
+	)
+	if err != nil {
+		logger.Errorf("r.db.QueryContext(): %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []domain.Session
+	for rows.Next() {
+		var (
+			session                             domain.Session
+			id, userID                          int64
+			createdAt, lastUsedAt, expirationAt int64
+		)
+
+		err := rows.Scan(&id, &userID, &session.RefreshTokenHash, &session.Device, &session.UserAgent, &session.IP,
+			&createdAt, &lastUsedAt, &expirationAt)
+		if err != nil {
+			logger.Errorf("rows.Scan(): %v", err)
+			return nil, err
+		}
+
+		session.ID = formatID(id)
+		session.UserID = formatID(userID)
+		session.CreatedAt = time.Unix(createdAt, 0)
+		session.LastUsedAt = time.Unix(lastUsedAt, 0)
+		session.ExpirationAt = time.Unix(expirationAt, 0)
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Errorf("rows.Err(): %v", err)
+		return nil, err
+	}
+
+	return sessions, nil
+}
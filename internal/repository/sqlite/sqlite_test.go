@@ -0,0 +1,70 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/config"
+	"github.com/begenov/region-llc-task/internal/repository/repositorytest"
+	"github.com/begenov/region-llc-task/pkg/database"
+	"github.com/begenov/region-llc-task/pkg/logger"
+	"github.com/stretchr/testify/require"
+)
+
+// missingID is a well-formed identifier that no row will ever have.
+const missingID = "9223372036854775807"
+
+var db *sql.DB
+var ctx = context.Background()
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "region-sqlite")
+	if err != nil {
+		logger.Fatalf("os.MkdirTemp(): %v", err)
+	}
+
+	db, err = database.NewSQLite(ctx, config.ConfigSQLite{
+		Path: filepath.Join(dir, "test.db"),
+	})
+	if err != nil {
+		logger.Fatalf("database.NewSQLite(): %v", err)
+	}
+
+	if err := Migrate(ctx, db); err != nil {
+		logger.Fatalf("Migrate(): %v", err)
+	}
+
+	code := m.Run()
+
+	db.Close()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestRepositories(t *testing.T) {
+	repositorytest.Run(t, repositorytest.Repositories{
+		Users:          NewUserRepo(db),
+		Todo:           NewTodoRepo(db),
+		Items:          NewTodoItemRepo(db),
+		Calendars:      NewCalendarRepo(db),
+		Sessions:       NewSessionRepo(db),
+		PersonalTokens: NewPersonalTokenRepo(db),
+		MFA:            NewMFARepo(db),
+		MissingID:      missingID,
+		ValidatesIDs:   true,
+		Constraints:    true,
+	})
+}
+
+func TestRedis(t *testing.T) {
+	repositorytest.RunRedis(t, NewRedis(db), time.Sleep)
+}
+
+func TestMigrate_Idempotent(t *testing.T) {
+	err := Migrate(ctx, db)
+	require.NoError(t, err)
+}
@@ -0,0 +1,418 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/logger"
+)
+
+const todoColumns = `id, user_id, title, description, priority, tags, active_at, due_at, recurrence, occurrence, author, status`
+
+// timestampFormat keeps active_at fixed-width in UTC, so that timestamps
+// compare as strings.
+const timestampFormat = "2006-01-02T15:04:05.000000000Z"
+
+type TodoRepo struct {
+	db *sql.DB
+}
+
+func NewTodoRepo(db *sql.DB) *TodoRepo {
+	return &TodoRepo{
+		db: db,
+	}
+}
+
+func (r *TodoRepo) Create(ctx context.Context, todo domain.Todo) (domain.Todo, error) {
+	userID, ok := parseID(todo.UserID)
+	if !ok {
+		return domain.Todo{}, domain.ErrNotFound
+	}
+
+	var id int64
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO todos (user_id, title, description, priority, tags, active_at, due_at, recurrence, occurrence, author, status) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`,
+		userID, todo.Title, todo.Description, todo.Priority, encodeTags(todo.Tags), timestamp(todo.ActiveAt), unixTime(todo.DueAt), todo.Recurrence, todo.Occurrence, todo.Author, todo.Status,
+	).Scan(&id)
+	if err != nil {
+		logger.Errorf("r.db.QueryRowContext(): %v", err)
+		return domain.Todo{}, domain.ErrInternalServer
+	}
+
+	todo.ID = formatID(id)
+
+	return todo, nil
+}
+
+func (r *TodoRepo) GetCountByTitle(ctx context.Context, title string, id string) (int64, error) {
+	userID, ok := parseID(id)
+	if !ok {
+		return 0, domain.ErrNotFound
+	}
+
+	var count int64
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM todos WHERE title = ? AND user_id = ?`, title, userID).Scan(&count)
+	if err != nil {
+		logger.Errorf("r.db.QueryRowContext(): %v", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *TodoRepo) GetTodoByID(ctx context.Context, id string) (domain.Todo, error) {
+	todoID, ok := parseID(id)
+	if !ok {
+		return domain.Todo{}, domain.ErrTodoInvalidId
+	}
+
+	todo, err := scanTodo(r.db.QueryRowContext(ctx, `SELECT `+todoColumns+` FROM todos WHERE id = ?`, todoID))
+	if err != nil {
+		logger.Errorf("r.db.QueryRowContext(): %v", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Todo{}, domain.ErrNotFound
+		}
+
+		return domain.Todo{}, err
+	}
+
+	return todo, nil
+}
+
+// UpdateTodoID is a no-op: identifiers are assigned by the database on insert.
+func (r *TodoRepo) UpdateTodoID(ctx context.Context, todo domain.Todo) error {
+	if _, ok := parseID(todo.ID); !ok {
+		return domain.ErrTodoInvalidId
+	}
+
+	return nil
+}
+
+func (r *TodoRepo) UpdateTodo(ctx context.Context, todo domain.Todo) error {
+	id, ok := parseID(todo.ID)
+	if !ok {
+		return domain.ErrTodoInvalidId
+	}
+
+	ownerID, ok := parseID(todo.UserID)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.db.ExecContext(ctx, `UPDATE todos SET title = ?, description = ?, priority = ?, tags = ?, active_at = ?, due_at = ?, recurrence = ? WHERE id = ? AND user_id = ?`,
+		todo.Title, todo.Description, todo.Priority, encodeTags(todo.Tags), timestamp(todo.ActiveAt), unixTime(todo.DueAt), todo.Recurrence, id, ownerID)
+	if err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *TodoRepo) DeleteTodoByID(ctx context.Context, id string, userID string) error {
+	todoID, ok := parseID(id)
+	if !ok {
+		return domain.ErrTodoInvalidId
+	}
+
+	ownerID, ok := parseID(userID)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM todos WHERE id = ? AND user_id = ?`, todoID, ownerID)
+	if err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *TodoRepo) UpdateTodoDoneByID(ctx context.Context, id string, userID string) (domain.Todo, error) {
+	todoID, ok := parseID(id)
+	if !ok {
+		return domain.Todo{}, domain.ErrTodoInvalidId
+	}
+
+	ownerID, ok := parseID(userID)
+	if !ok {
+		return domain.Todo{}, domain.ErrNotFound
+	}
+
+	todo, err := scanTodo(r.db.QueryRowContext(ctx,
+		`UPDATE todos SET status = ? WHERE id = ? AND user_id = ? RETURNING `+todoColumns,
+		domain.Done, todoID, ownerID,
+	))
+	if err != nil {
+		logger.Errorf("r.db.QueryRowContext(): %v", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Todo{}, domain.ErrNotFound
+		}
+
+		return domain.Todo{}, err
+	}
+
+	return todo, nil
+}
+
+func (r *TodoRepo) GetTodos(ctx context.Context, filter domain.TodoFilter) ([]domain.Todo, error) {
+	where, args, err := todoFilter(filter, true)
+	if err != nil {
+		return nil, err
+	}
+
+	column, direction := sortColumns[filter.Sort], "ASC"
+	if column == "" {
+		column = sortColumns[domain.SortActiveAt]
+	}
+	if filter.Order == domain.OrderDesc {
+		direction = "DESC"
+	}
+
+	query := fmt.Sprintf(`SELECT `+todoColumns+` FROM todos %s ORDER BY %s %s, id %s`, where, column, direction, direction)
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(` LIMIT %d`, filter.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.Errorf("r.db.QueryContext(): %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var todos []domain.Todo
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			logger.Errorf("scanTodo(): %v", err)
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Errorf("rows.Err(): %v", err)
+		return nil, err
+	}
+
+	return todos, nil
+}
+
+func (r *TodoRepo) GetCountByFilter(ctx context.Context, filter domain.TodoFilter) (int64, error) {
+	where, args, err := todoFilter(filter, false)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM todos `+where, args...).Scan(&count); err != nil {
+		logger.Errorf("r.db.QueryRowContext(): %v", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// SearchTodos matches the query against the todos of the user in Go:
+// SQLite lower() only folds ASCII and the driver is built without FTS5, so
+// the database cannot tell Cyrillic words apart on its own.
+func (r *TodoRepo) SearchTodos(ctx context.Context, userID string, query domain.SearchQuery, limit int) ([]domain.SearchResult, error) {
+	ownerID, ok := parseID(userID)
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+todoColumns+` FROM todos WHERE user_id = ? ORDER BY id DESC`, ownerID)
+	if err != nil {
+		logger.Errorf("r.db.QueryContext(): %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []domain.SearchResult
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			logger.Errorf("scanTodo(): %v", err)
+			return nil, err
+		}
+
+		if score, ok := query.Match(todo); ok {
+			results = append(results, domain.SearchResult{Todo: todo, Score: score})
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Errorf("rows.Err(): %v", err)
+		return nil, err
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+var sortColumns = map[string]string{
+	domain.SortActiveAt: "active_at",
+	domain.SortTitle:    "title",
+}
+
+// todoFilter builds the WHERE clause of a listing query. The cursor condition
+// is only added when withCursor is set, so that the total count ignores it.
+func todoFilter(filter domain.TodoFilter, withCursor bool) (string, []interface{}, error) {
+	userID, ok := parseID(filter.UserID)
+	if !ok {
+		return "", nil, domain.ErrNotFound
+	}
+
+	var (
+		conds []string
+		args  []interface{}
+	)
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "?"
+	}
+
+	conds = append(conds, "user_id = "+arg(userID))
+	if len(filter.Statuses) > 0 {
+		placeholders := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			placeholders[i] = arg(status)
+		}
+		conds = append(conds, "status IN ("+strings.Join(placeholders, ", ")+")")
+	}
+
+	if !filter.ActiveFrom.IsZero() {
+		conds = append(conds, "active_at >= "+arg(timestamp(filter.ActiveFrom)))
+	}
+
+	if !filter.ActiveBefore.IsZero() {
+		conds = append(conds, "active_at < "+arg(timestamp(filter.ActiveBefore)))
+	}
+
+	if filter.Title != "" {
+		conds = append(conds, "instr(lower(title), lower("+arg(filter.Title)+")) > 0")
+	}
+
+	if len(filter.Priorities) > 0 {
+		placeholders := make([]string, len(filter.Priorities))
+		for i, priority := range filter.Priorities {
+			placeholders[i] = arg(priority)
+		}
+		conds = append(conds, "priority IN ("+strings.Join(placeholders, ", ")+")")
+	}
+
+	for _, tag := range filter.Tags {
+		conds = append(conds, "EXISTS (SELECT 1 FROM json_each(tags) WHERE value = "+arg(tag)+")")
+	}
+
+	if withCursor && filter.After != nil {
+		id, ok := parseID(filter.After.ID)
+		if !ok {
+			return "", nil, domain.ErrInvalidCursor
+		}
+
+		column, op := sortColumns[domain.SortTitle], ">"
+		var value interface{} = filter.After.Value
+		if filter.Sort != domain.SortTitle {
+			activeAt, err := filter.After.ActiveAt()
+			if err != nil {
+				return "", nil, err
+			}
+			column, value = sortColumns[domain.SortActiveAt], timestamp(activeAt)
+		}
+		if filter.Order == domain.OrderDesc {
+			op = "<"
+		}
+
+		conds = append(conds, fmt.Sprintf("(%s, id) %s (%s, %s)", column, op, arg(value), arg(id)))
+	}
+
+	return "WHERE " + strings.Join(conds, " AND "), args, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTodo(row scanner) (domain.Todo, error) {
+	var (
+		todo       domain.Todo
+		id, userID int64
+		tags       string
+		activeAt   string
+		dueAt      sql.NullInt64
+	)
+
+	err := row.Scan(&id, &userID, &todo.Title, &todo.Description, &todo.Priority, &tags, &activeAt, &dueAt, &todo.Recurrence, &todo.Occurrence, &todo.Author, &todo.Status)
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	todo.ID = formatID(id)
+	todo.UserID = formatID(userID)
+
+	if err := json.Unmarshal([]byte(tags), &todo.Tags); err != nil {
+		return domain.Todo{}, err
+	}
+	if len(todo.Tags) == 0 {
+		todo.Tags = nil
+	}
+
+	todo.ActiveAt, err = time.Parse(timestampFormat, activeAt)
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	if dueAt.Valid {
+		due := time.Unix(dueAt.Int64, 0).UTC()
+		todo.DueAt = &due
+	}
+
+	return todo, nil
+}
+
+func encodeTags(tags []string) string {
+	if len(tags) == 0 {
+		return "[]"
+	}
+
+	data, _ := json.Marshal(tags)
+	return string(data)
+}
+
+func timestamp(t time.Time) string {
+	return t.UTC().Format(timestampFormat)
+}
+
+func unixTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+
+	return t.Unix()
+}
@@ -0,0 +1,274 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/logger"
+)
+
+const todoItemColumns = `id, todo_id, title, done, position`
+
+type TodoItemRepo struct {
+	db *sql.DB
+}
+
+func NewTodoItemRepo(db *sql.DB) *TodoItemRepo {
+	return &TodoItemRepo{
+		db: db,
+	}
+}
+
+// Create appends the item after the last item of the todo.
+func (r *TodoItemRepo) Create(ctx context.Context, item domain.TodoItem) (domain.TodoItem, error) {
+	todoID, ok := parseID(item.TodoID)
+	if !ok {
+		return domain.TodoItem{}, domain.ErrNotFound
+	}
+
+	var id int64
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO todo_items (todo_id, title, done, position)
+		SELECT ?, ?, ?, COALESCE(MAX(position), 0) + 1 FROM todo_items WHERE todo_id = ?
+		RETURNING id, position`,
+		todoID, item.Title, item.Done, todoID,
+	).Scan(&id, &item.Position)
+	if err != nil {
+		logger.Errorf("r.db.QueryRowContext(): %v", err)
+		return domain.TodoItem{}, domain.ErrInternalServer
+	}
+
+	item.ID = formatID(id)
+
+	return item, nil
+}
+
+func (r *TodoItemRepo) GetItems(ctx context.Context, todoID string) ([]domain.TodoItem, error) {
+	id, ok := parseID(todoID)
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+todoItemColumns+` FROM todo_items WHERE todo_id = ? ORDER BY position, id`, id)
+	if err != nil {
+		logger.Errorf("r.db.QueryContext(): %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []domain.TodoItem
+	for rows.Next() {
+		item, err := scanTodoItem(rows)
+		if err != nil {
+			logger.Errorf("scanTodoItem(): %v", err)
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Errorf("rows.Err(): %v", err)
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *TodoItemRepo) GetItemByID(ctx context.Context, id string, todoID string) (domain.TodoItem, error) {
+	itemID, ok := parseID(id)
+	if !ok {
+		return domain.TodoItem{}, domain.ErrNotFound
+	}
+
+	parentID, ok := parseID(todoID)
+	if !ok {
+		return domain.TodoItem{}, domain.ErrNotFound
+	}
+
+	item, err := scanTodoItem(r.db.QueryRowContext(ctx,
+		`SELECT `+todoItemColumns+` FROM todo_items WHERE id = ? AND todo_id = ?`, itemID, parentID))
+	if err != nil {
+		logger.Errorf("r.db.QueryRowContext(): %v", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.TodoItem{}, domain.ErrNotFound
+		}
+
+		return domain.TodoItem{}, err
+	}
+
+	return item, nil
+}
+
+func (r *TodoItemRepo) UpdateItemDone(ctx context.Context, id string, todoID string, done bool) (domain.TodoItem, error) {
+	itemID, ok := parseID(id)
+	if !ok {
+		return domain.TodoItem{}, domain.ErrNotFound
+	}
+
+	parentID, ok := parseID(todoID)
+	if !ok {
+		return domain.TodoItem{}, domain.ErrNotFound
+	}
+
+	item, err := scanTodoItem(r.db.QueryRowContext(ctx,
+		`UPDATE todo_items SET done = ? WHERE id = ? AND todo_id = ? RETURNING `+todoItemColumns,
+		done, itemID, parentID,
+	))
+	if err != nil {
+		logger.Errorf("r.db.QueryRowContext(): %v", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.TodoItem{}, domain.ErrNotFound
+		}
+
+		return domain.TodoItem{}, err
+	}
+
+	return item, nil
+}
+
+// ReorderItems moves the items to the positions of their ids in the list,
+// starting from one. Ids of other todos are ignored.
+func (r *TodoItemRepo) ReorderItems(ctx context.Context, todoID string, ids []string) error {
+	parentID, ok := parseID(todoID)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Errorf("r.db.BeginTx(): %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	for i, id := range ids {
+		itemID, ok := parseID(id)
+		if !ok {
+			return domain.ErrNotFound
+		}
+
+		_, err := tx.ExecContext(ctx, `UPDATE todo_items SET position = ? WHERE id = ? AND todo_id = ?`, i+1, itemID, parentID)
+		if err != nil {
+			logger.Errorf("tx.ExecContext(): %v", err)
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Errorf("tx.Commit(): %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *TodoItemRepo) DeleteItem(ctx context.Context, id string, todoID string) error {
+	itemID, ok := parseID(id)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	parentID, ok := parseID(todoID)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM todo_items WHERE id = ? AND todo_id = ?`, itemID, parentID)
+	if err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteItems removes the checklist of a todo. Deleting the todo itself
+// cascades to its items, so this only matters for the other backends.
+func (r *TodoItemRepo) DeleteItems(ctx context.Context, todoID string) error {
+	parentID, ok := parseID(todoID)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM todo_items WHERE todo_id = ?`, parentID); err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetProgress counts the items of every todo. Todos without items are left
+// out of the result.
+func (r *TodoItemRepo) GetProgress(ctx context.Context, todoIDs []string) (map[string]domain.Progress, error) {
+	var (
+		placeholders []string
+		args         []interface{}
+	)
+	for _, todoID := range todoIDs {
+		if id, ok := parseID(todoID); ok {
+			placeholders = append(placeholders, "?")
+			args = append(args, id)
+		}
+	}
+
+	progress := make(map[string]domain.Progress)
+	if len(args) == 0 {
+		return progress, nil
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT todo_id, SUM(done), COUNT(*)
+		FROM todo_items
+		WHERE todo_id IN (`+strings.Join(placeholders, ", ")+`)
+		GROUP BY todo_id`,
+		args...,
+	)
+	if err != nil {
+		logger.Errorf("r.db.QueryContext(): %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			todoID int64
+			p      domain.Progress
+		)
+		if err := rows.Scan(&todoID, &p.Done, &p.Total); err != nil {
+			logger.Errorf("rows.Scan(): %v", err)
+			return nil, err
+		}
+		progress[formatID(todoID)] = p
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Errorf("rows.Err(): %v", err)
+		return nil, err
+	}
+
+	return progress, nil
+}
+
+func scanTodoItem(row scanner) (domain.TodoItem, error) {
+	var (
+		item       domain.TodoItem
+		id, todoID int64
+	)
+
+	if err := row.Scan(&id, &todoID, &item.Title, &item.Done, &item.Position); err != nil {
+		return domain.TodoItem{}, err
+	}
+
+	item.ID = formatID(id)
+	item.TodoID = formatID(todoID)
+
+	return item, nil
+}
@@ -0,0 +1,224 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/logger"
+)
+
+type UserRepo struct {
+	db *sql.DB
+}
+
+func NewUserRepo(db *sql.DB) *UserRepo {
+	return &UserRepo{
+		db: db,
+	}
+}
+
+func (r *UserRepo) Create(ctx context.Context, user domain.User) (domain.User, error) {
+	result, err := r.db.ExecContext(ctx,
+		`INSERT INTO users (username, email, email_verified, password, timezone, language, create_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		user.UserName, user.Email, user.EmailVerified, user.Password, user.Timezone, user.Language, user.CreateAt,
+	)
+	if err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		if isUniqueViolation(err) {
+			return domain.User{}, domain.ErrEmailAlreadyExists
+		}
+
+		return domain.User{}, domain.ErrInternalServer
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		logger.Errorf("result.LastInsertId(): %v", err)
+		return domain.User{}, domain.ErrInternalServer
+	}
+
+	user.ID = formatID(id)
+
+	return user, nil
+}
+
+func (r *UserRepo) GetUserByEmail(ctx context.Context, email string) (domain.User, error) {
+	return r.getUser(ctx, `WHERE u.email = ?`, email)
+}
+
+func (r *UserRepo) GetUserByID(ctx context.Context, id string) (domain.User, error) {
+	userID, ok := parseID(id)
+	if !ok {
+		return domain.User{}, domain.ErrNotFound
+	}
+
+	return r.getUser(ctx, `WHERE u.id = ?`, userID)
+}
+
+func (r *UserRepo) getUser(ctx context.Context, where string, args ...interface{}) (domain.User, error) {
+	var (
+		user     domain.User
+		id       int64
+		deleteAt sql.NullInt64
+	)
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT u.id, u.username, u.email, u.email_verified, u.password, u.timezone, u.calendar, u.language, u.create_at, u.delete_at
+		FROM users u
+		`+where, args...,
+	).Scan(&id, &user.UserName, &user.Email, &user.EmailVerified, &user.Password, &user.Timezone, &user.Calendar, &user.Language, &user.CreateAt, &deleteAt)
+	if err != nil {
+		logger.Errorf("r.db.QueryRowContext(): %v", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.User{}, domain.ErrNotFound
+		}
+
+		return domain.User{}, domain.ErrInternalServer
+	}
+
+	user.ID = formatID(id)
+	user.DeleteAt = nullUnixTime(deleteAt)
+
+	return user, nil
+}
+
+func (r *UserRepo) SetCalendar(ctx context.Context, userID string, calendarID string) error {
+	id, ok := parseID(userID)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.db.ExecContext(ctx, `UPDATE users SET calendar = ? WHERE id = ?`, calendarID, id)
+	if err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *UserRepo) SetLanguage(ctx context.Context, userID string, language string) error {
+	id, ok := parseID(userID)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.db.ExecContext(ctx, `UPDATE users SET language = ? WHERE id = ?`, language, id)
+	if err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *UserRepo) SetPassword(ctx context.Context, userID string, passwordHash string) error {
+	id, ok := parseID(userID)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.db.ExecContext(ctx, `UPDATE users SET password = ? WHERE id = ?`, passwordHash, id)
+	if err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *UserRepo) VerifyEmail(ctx context.Context, userID string, email string) error {
+	id, ok := parseID(userID)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.db.ExecContext(ctx, `UPDATE users SET email = ?, email_verified = 1 WHERE id = ?`, email, id)
+	if err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		if isUniqueViolation(err) {
+			return domain.ErrEmailAlreadyExists
+		}
+
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *UserRepo) UpdateUser(ctx context.Context, user domain.User) error {
+	id, ok := parseID(user.ID)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE users SET username = ?, timezone = ?, language = ? WHERE id = ?`,
+		user.UserName, user.Timezone, user.Language, id,
+	)
+	if err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *UserRepo) ScheduleDeletion(ctx context.Context, userID string, deleteAt *time.Time) error {
+	id, ok := parseID(userID)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	result, err := r.db.ExecContext(ctx, `UPDATE users SET delete_at = ? WHERE id = ?`, unixTime(deleteAt), id)
+	if err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return err
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteScheduled relies on the foreign keys to delete what the users own.
+func (r *UserRepo) DeleteScheduled(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM users WHERE delete_at <= ?`, before.Unix())
+	if err != nil {
+		logger.Errorf("r.db.ExecContext(): %v", err)
+		return 0, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		logger.Errorf("result.RowsAffected(): %v", err)
+		return 0, err
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/logger"
+)
+
+const defaultDeletionGrace = 30 * 24 * time.Hour
+
+// AccountPolicy.DeletionGrace is how long a deleted account is kept before
+// it is removed for good, the user can sign in to keep it until then.
+type AccountPolicy struct {
+	DeletionGrace time.Duration
+}
+
+func (s *UserService) GetProfile(ctx context.Context, userID string) (domain.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Errorf("s.userRepo.GetUserByID(): %v", err)
+		return domain.User{}, err
+	}
+
+	return user, nil
+}
+
+// UpdateProfile changes the fields of the profile set in the update, an
+// empty timezone is the default one.
+func (s *UserService) UpdateProfile(ctx context.Context, userID string, inp domain.UserUpdate) (domain.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Errorf("s.userRepo.GetUserByID(): %v", err)
+		return domain.User{}, err
+	}
+
+	if inp.UserName != nil {
+		if *inp.UserName == "" {
+			return domain.User{}, domain.ErrIncorrectUserName
+		}
+		user.UserName = *inp.UserName
+	}
+
+	if inp.Timezone != nil {
+		user.Timezone, err = validateTimezone(*inp.Timezone)
+		if err != nil {
+			return domain.User{}, err
+		}
+	}
+
+	if inp.Language != nil {
+		if err := validateLanguage(*inp.Language); err != nil {
+			return domain.User{}, err
+		}
+		user.Language = *inp.Language
+	}
+
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		logger.Errorf("s.userRepo.UpdateUser(): %v", err)
+		return domain.User{}, err
+	}
+
+	return user, nil
+}
+
+// ChangePassword sets a new password once the current one is confirmed.
+// Every session of the user is ended, a new one is started for the client.
+func (s *UserService) ChangePassword(ctx context.Context, userID string, inp domain.PasswordChange, client domain.Client) (domain.Token, error) {
+	if len(inp.NewPassword) < 6 {
+		return domain.Token{}, domain.ErrIncorrectPassword
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Errorf("s.userRepo.GetUserByID(): %v", err)
+		return domain.Token{}, err
+	}
+
+	if err := s.confirmPassword(ctx, user, inp.Password, client); err != nil {
+		return domain.Token{}, err
+	}
+
+	passwordHash, err := s.hash.GenerateFromPassword(inp.NewPassword)
+	if err != nil {
+		logger.Errorf("s.hash.GenerateFromPassword(): %v", err)
+		return domain.Token{}, domain.ErrInternalServer
+	}
+
+	if err := s.userRepo.SetPassword(ctx, userID, passwordHash); err != nil {
+		logger.Errorf("s.userRepo.SetPassword(): %v", err)
+		return domain.Token{}, err
+	}
+
+	if err := s.LogoutEverywhere(ctx, userID); err != nil {
+		return domain.Token{}, err
+	}
+
+	return s.createSession(ctx, userID, client)
+}
+
+// DeleteAccount schedules the deletion of the user once the password is
+// confirmed. Every session is ended and the personal tokens are revoked, the
+// todos and the rest are deleted along with the user when the grace period
+// ends. Signing in before then cancels the deletion.
+func (s *UserService) DeleteAccount(ctx context.Context, userID string, password string, client domain.Client) (domain.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Errorf("s.userRepo.GetUserByID(): %v", err)
+		return domain.User{}, err
+	}
+
+	if err := s.confirmPassword(ctx, user, password, client); err != nil {
+		return domain.User{}, err
+	}
+
+	deleteAt := time.Now().Add(s.account.DeletionGrace).UTC().Truncate(time.Second)
+	if err := s.userRepo.ScheduleDeletion(ctx, userID, &deleteAt); err != nil {
+		logger.Errorf("s.userRepo.ScheduleDeletion(): %v", err)
+		return domain.User{}, err
+	}
+	user.DeleteAt = &deleteAt
+
+	if err := s.LogoutEverywhere(ctx, userID); err != nil {
+		return domain.User{}, err
+	}
+
+	tokens, err := s.personalTokenRepo.GetTokens(ctx, userID)
+	if err != nil {
+		logger.Errorf("s.personalTokenRepo.GetTokens(): %v", err)
+		return domain.User{}, err
+	}
+
+	for _, token := range tokens {
+		if err := s.personalTokenRepo.DeleteToken(ctx, token.ID, userID); err != nil {
+			logger.Errorf("s.personalTokenRepo.DeleteToken(): %v", err)
+			return domain.User{}, err
+		}
+	}
+
+	return user, nil
+}
+
+// PurgeDeletedUsers deletes the accounts whose grace period has ended, and
+// reports how many.
+func (s *UserService) PurgeDeletedUsers(ctx context.Context) (int64, error) {
+	deleted, err := s.userRepo.DeleteScheduled(ctx, time.Now())
+	if err != nil {
+		logger.Errorf("s.userRepo.DeleteScheduled(): %v", err)
+		return deleted, err
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/logger"
+)
+
+// auditor records the audit events of the service.
+type auditor interface {
+	Audit(ctx context.Context, event domain.AuditEvent)
+}
+
+// logAuditor writes the audit events to the log as JSON.
+type logAuditor struct{}
+
+func (logAuditor) Audit(ctx context.Context, event domain.AuditEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		logger.Errorf("json.Marshal(): %v", err)
+		return
+	}
+
+	logger.Warnf("audit: %s", data)
+}
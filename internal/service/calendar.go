@@ -0,0 +1,281 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/internal/repository"
+	"github.com/begenov/region-llc-task/pkg/logger"
+)
+
+// bundledCalendars are shipped with the server, the id of a calendar is the
+// name of its file.
+//
+//go:embed calendars/*.json
+var bundledCalendars embed.FS
+
+type CalendarService struct {
+	calendarRepo repository.Calendars
+	userRepo     repository.Users
+	bundled      map[string]domain.Calendar
+	defaultID    string
+}
+
+// NewCalendarService serves the bundled calendars along with the ones
+// uploaded by users. defaultID is the calendar of the users who have not
+// chosen one, StandardCalendar when empty.
+func NewCalendarService(calendarRepo repository.Calendars, userRepo repository.Users,
+	bundled []domain.Calendar, defaultID string) (*CalendarService, error) {
+	s := &CalendarService{
+		calendarRepo: calendarRepo,
+		userRepo:     userRepo,
+		bundled: map[string]domain.Calendar{
+			domain.StandardCalendar: {ID: domain.StandardCalendar, Name: "Standard"},
+		},
+		defaultID: defaultID,
+	}
+
+	for _, calendar := range bundled {
+		s.bundled[calendar.ID] = calendar
+	}
+
+	if s.defaultID == "" {
+		s.defaultID = domain.StandardCalendar
+	}
+
+	if _, ok := s.bundled[s.defaultID]; !ok {
+		return nil, fmt.Errorf("unknown default calendar %q", s.defaultID)
+	}
+
+	return s, nil
+}
+
+// LoadCalendars reads the bundled calendars and the .ics and .json files of
+// dir, if given. A file of dir replaces the bundled calendar of the same id.
+func LoadCalendars(dir string) ([]domain.Calendar, error) {
+	entries, err := bundledCalendars.ReadDir("calendars")
+	if err != nil {
+		return nil, err
+	}
+
+	var calendars []domain.Calendar
+	for _, entry := range entries {
+		data, err := bundledCalendars.ReadFile("calendars/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		calendar, err := parseCalendarFile(entry.Name(), data)
+		if err != nil {
+			return nil, err
+		}
+		calendars = append(calendars, calendar)
+	}
+
+	if dir == "" {
+		return calendars, nil
+	}
+
+	entries, err = os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".ics" && ext != ".json") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		calendar, err := parseCalendarFile(entry.Name(), data)
+		if err != nil {
+			return nil, err
+		}
+		calendars = append(calendars, calendar)
+	}
+
+	return calendars, nil
+}
+
+func parseCalendarFile(name string, data []byte) (domain.Calendar, error) {
+	calendar, err := parseCalendar(data)
+	if err != nil {
+		return domain.Calendar{}, fmt.Errorf("%s: %w", name, err)
+	}
+
+	calendar.ID = strings.TrimSuffix(name, filepath.Ext(name))
+	if calendar.Name == "" {
+		calendar.Name = calendar.ID
+	}
+
+	return calendar, nil
+}
+
+// parseCalendar tells an iCalendar file from a JSON one by its first line.
+func parseCalendar(data []byte) (domain.Calendar, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if bytes.HasPrefix(bytes.ToUpper(bytes.TrimSpace(data)), []byte("BEGIN:VCALENDAR")) {
+		return domain.ParseCalendarICS(data)
+	}
+
+	return domain.ParseCalendarJSON(data)
+}
+
+// GetCalendars lists the bundled calendars and the ones of the user, without
+// their days.
+func (s *CalendarService) GetCalendars(ctx context.Context, userID string) ([]domain.Calendar, error) {
+	calendars := make([]domain.Calendar, 0, len(s.bundled))
+	for _, calendar := range s.bundled {
+		calendars = append(calendars, domain.Calendar{ID: calendar.ID, Name: calendar.Name})
+	}
+	sort.Slice(calendars, func(i, j int) bool {
+		return calendars[i].ID < calendars[j].ID
+	})
+
+	own, err := s.calendarRepo.GetCalendars(ctx, userID)
+	if err != nil {
+		logger.Errorf("s.calendarRepo.GetCalendars(): %v", err)
+		return nil, err
+	}
+
+	return append(calendars, own...), nil
+}
+
+// GetCalendar returns a bundled calendar or one of the user. A calendar of
+// another user is reported as ErrForbidden.
+func (s *CalendarService) GetCalendar(ctx context.Context, id string, userID string) (domain.Calendar, error) {
+	if calendar, ok := s.bundled[id]; ok {
+		return calendar, nil
+	}
+
+	calendar, err := s.calendarRepo.GetCalendarByID(ctx, id)
+	if err != nil {
+		logger.Errorf("s.calendarRepo.GetCalendarByID(): %v", err)
+		return domain.Calendar{}, err
+	}
+
+	if calendar.UserID != userID {
+		return domain.Calendar{}, domain.ErrForbidden
+	}
+
+	return calendar, nil
+}
+
+// UploadCalendar stores an iCalendar or JSON calendar of the user. name
+// overrides the name given in the file.
+func (s *CalendarService) UploadCalendar(ctx context.Context, userID string, name string, data []byte) (domain.Calendar, error) {
+	calendar, err := parseCalendar(data)
+	if err != nil {
+		logger.Errorf("parseCalendar(): %v", err)
+		return domain.Calendar{}, err
+	}
+
+	if name = strings.TrimSpace(name); name != "" {
+		calendar.Name = name
+	}
+
+	if calendar.Name == "" || len([]rune(calendar.Name)) > domain.MaxCalendarNameLength {
+		return domain.Calendar{}, domain.ErrInvalidCalendar
+	}
+
+	calendar.UserID = userID
+
+	calendar, err = s.calendarRepo.Create(ctx, calendar)
+	if err != nil {
+		logger.Errorf("s.calendarRepo.Create(): %v", err)
+		return domain.Calendar{}, err
+	}
+
+	return calendar, nil
+}
+
+// DeleteCalendar deletes a calendar of the user. The user falls back to the
+// default calendar when it was the chosen one.
+func (s *CalendarService) DeleteCalendar(ctx context.Context, id string, userID string) error {
+	if _, err := s.GetCalendar(ctx, id, userID); err != nil {
+		return err
+	}
+
+	if _, ok := s.bundled[id]; ok {
+		return domain.ErrForbidden
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Errorf("s.userRepo.GetUserByID(): %v", err)
+		return err
+	}
+
+	if err := s.calendarRepo.DeleteCalendar(ctx, id, userID); err != nil {
+		logger.Errorf("s.calendarRepo.DeleteCalendar(): %v", err)
+		return err
+	}
+
+	if user.Calendar == id {
+		if err := s.userRepo.SetCalendar(ctx, userID, ""); err != nil {
+			logger.Errorf("s.userRepo.SetCalendar(): %v", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SelectCalendar makes the calendar the one of the user, an empty id resets
+// it to the default one. It returns the calendar without its days.
+func (s *CalendarService) SelectCalendar(ctx context.Context, userID string, id string) (domain.Calendar, error) {
+	calendarID := id
+	if calendarID == "" {
+		calendarID = s.defaultID
+	}
+
+	calendar, err := s.GetCalendar(ctx, calendarID, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.Calendar{}, domain.ErrInvalidCalendar
+		}
+
+		return domain.Calendar{}, err
+	}
+
+	if err := s.userRepo.SetCalendar(ctx, userID, id); err != nil {
+		logger.Errorf("s.userRepo.SetCalendar(): %v", err)
+		return domain.Calendar{}, err
+	}
+
+	calendar.Days = nil
+
+	return calendar, nil
+}
+
+// calendarOf returns the calendar chosen by the user. A calendar that is no
+// longer there is replaced by the default one.
+func (s *CalendarService) calendarOf(ctx context.Context, user domain.User) (domain.Calendar, error) {
+	if user.Calendar == "" {
+		return s.bundled[s.defaultID], nil
+	}
+
+	calendar, err := s.GetCalendar(ctx, user.Calendar, user.ID)
+	if errors.Is(err, domain.ErrNotFound) || errors.Is(err, domain.ErrForbidden) {
+		return s.bundled[s.defaultID], nil
+	}
+
+	if err != nil {
+		return domain.Calendar{}, err
+	}
+
+	return calendar, nil
+}
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	mocksRepo "github.com/begenov/region-llc-task/internal/repository/mocks"
+	"github.com/begenov/region-llc-task/pkg/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// newCalendarService returns a calendar service with the standard calendar
+// as the default one, which is all the todo tests need.
+func newCalendarService(t *testing.T, ctrl *gomock.Controller) *CalendarService {
+	calendars, err := NewCalendarService(mocksRepo.NewMockCalendars(ctrl), mocksRepo.NewMockUsers(ctrl), nil, "")
+	require.NoError(t, err)
+
+	return calendars
+}
+
+func TestLoadCalendars(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "acme.ics"), []byte("BEGIN:VCALENDAR\n"+
+		"BEGIN:VEVENT\nDTSTART;VALUE=DATE:20300102\nSUMMARY:Founders day\nEND:VEVENT\n"+
+		"END:VCALENDAR\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a calendar"), 0o600))
+
+	calendars, err := LoadCalendars(dir)
+	require.NoError(t, err)
+	require.Len(t, calendars, 2)
+
+	kz := calendars[0]
+	require.Equal(t, "kz", kz.ID)
+	dayOff, reason := kz.DayOff(time.Date(2026, time.March, 24, 0, 0, 0, 0, time.UTC))
+	require.True(t, dayOff)
+	require.Equal(t, "Перенос выходного дня: Наурыз мейрамы", reason)
+
+	require.Equal(t, domain.Calendar{
+		ID:   "acme",
+		Name: "acme",
+		Days: []domain.CalendarDay{{Date: "2030-01-02", DayOff: true, Reason: "Founders day"}},
+	}, calendars[1])
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o600))
+	_, err = LoadCalendars(dir)
+	require.ErrorIs(t, err, domain.ErrInvalidCalendar)
+}
+
+func TestNewCalendarService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, err := NewCalendarService(mocksRepo.NewMockCalendars(ctrl), mocksRepo.NewMockUsers(ctrl), nil, "kz")
+	require.Error(t, err)
+
+	calendars, err := LoadCalendars("")
+	require.NoError(t, err)
+
+	_, err = NewCalendarService(mocksRepo.NewMockCalendars(ctrl), mocksRepo.NewMockUsers(ctrl), calendars, "kz")
+	require.NoError(t, err)
+}
+
+func TestCalendarService_GetCalendar(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	calendarRepo := mocksRepo.NewMockCalendars(ctrl)
+	calendarService, err := NewCalendarService(calendarRepo, mocksRepo.NewMockUsers(ctrl), nil, "")
+	require.NoError(t, err)
+
+	userID := utils.RandomString(10)
+	own := domain.Calendar{ID: utils.RandomString(10), UserID: userID, Name: "Own"}
+	other := domain.Calendar{ID: utils.RandomString(10), UserID: utils.RandomString(10), Name: "Other"}
+
+	calendar, err := calendarService.GetCalendar(ctx, domain.StandardCalendar, userID)
+	require.NoError(t, err)
+	require.Equal(t, domain.StandardCalendar, calendar.ID)
+
+	calendarRepo.EXPECT().GetCalendarByID(gomock.Any(), own.ID).Times(1).Return(own, nil)
+	calendar, err = calendarService.GetCalendar(ctx, own.ID, userID)
+	require.NoError(t, err)
+	require.Equal(t, own, calendar)
+
+	calendarRepo.EXPECT().GetCalendarByID(gomock.Any(), other.ID).Times(1).Return(other, nil)
+	_, err = calendarService.GetCalendar(ctx, other.ID, userID)
+	require.Equal(t, domain.ErrForbidden, err)
+
+	calendarRepo.EXPECT().GetCalendars(gomock.Any(), userID).Times(1).Return([]domain.Calendar{{ID: own.ID, UserID: userID, Name: own.Name}}, nil)
+	calendars, err := calendarService.GetCalendars(ctx, userID)
+	require.NoError(t, err)
+	require.Equal(t, []domain.Calendar{
+		{ID: domain.StandardCalendar, Name: "Standard"},
+		{ID: own.ID, UserID: userID, Name: own.Name},
+	}, calendars)
+}
+
+func TestCalendarService_UploadCalendar(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	calendarRepo := mocksRepo.NewMockCalendars(ctrl)
+	calendarService, err := NewCalendarService(calendarRepo, mocksRepo.NewMockUsers(ctrl), nil, "")
+	require.NoError(t, err)
+
+	userID := utils.RandomString(10)
+
+	tests := []struct {
+		name          string
+		calendarName  string
+		data          string
+		buildStubs    func()
+		checkResponse func(calendar domain.Calendar, err error)
+	}{
+		{
+			name: "ICS",
+			data: "BEGIN:VCALENDAR\r\nX-WR-CALNAME:Office\r\nBEGIN:VEVENT\r\nDTSTART:20300102\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+			buildStubs: func() {
+				calendarRepo.EXPECT().Create(gomock.Any(), domain.Calendar{
+					UserID: userID,
+					Name:   "Office",
+					Days:   []domain.CalendarDay{{Date: "2030-01-02", DayOff: true}},
+				}).Times(1).Return(domain.Calendar{ID: "1", UserID: userID, Name: "Office"}, nil)
+			},
+			checkResponse: func(calendar domain.Calendar, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "1", calendar.ID)
+			},
+		},
+		{
+			name:         "JSON with Name",
+			calendarName: " Office ",
+			data:         `{"days": [{"date": "2030-01-05", "day_off": false}]}`,
+			buildStubs: func() {
+				calendarRepo.EXPECT().Create(gomock.Any(), domain.Calendar{
+					UserID: userID,
+					Name:   "Office",
+					Days:   []domain.CalendarDay{{Date: "2030-01-05"}},
+				}).Times(1).Return(domain.Calendar{ID: "2", UserID: userID, Name: "Office"}, nil)
+			},
+			checkResponse: func(calendar domain.Calendar, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "2", calendar.ID)
+			},
+		},
+		{
+			name:       "No Name",
+			data:       `{"days": []}`,
+			buildStubs: func() {},
+			checkResponse: func(calendar domain.Calendar, err error) {
+				require.Equal(t, domain.ErrInvalidCalendar, err)
+			},
+		},
+		{
+			name:       "Invalid",
+			data:       "BEGIN:VCALENDAR\nBEGIN:VEVENT\n",
+			buildStubs: func() {},
+			checkResponse: func(calendar domain.Calendar, err error) {
+				require.Equal(t, domain.ErrInvalidCalendar, err)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.buildStubs()
+			calendar, err := calendarService.UploadCalendar(ctx, userID, tc.calendarName, []byte(tc.data))
+			tc.checkResponse(calendar, err)
+		})
+	}
+}
+
+func TestCalendarService_DeleteCalendar(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	calendarRepo := mocksRepo.NewMockCalendars(ctrl)
+	userRepo := mocksRepo.NewMockUsers(ctrl)
+	calendarService, err := NewCalendarService(calendarRepo, userRepo, nil, "")
+	require.NoError(t, err)
+
+	user := domain.User{ID: utils.RandomString(10)}
+	calendar := domain.Calendar{ID: utils.RandomString(10), UserID: user.ID, Name: "Office"}
+	user.Calendar = calendar.ID
+
+	err = calendarService.DeleteCalendar(ctx, domain.StandardCalendar, user.ID)
+	require.Equal(t, domain.ErrForbidden, err)
+
+	calendarRepo.EXPECT().GetCalendarByID(gomock.Any(), calendar.ID).Times(1).Return(calendar, nil)
+	userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+	calendarRepo.EXPECT().DeleteCalendar(gomock.Any(), calendar.ID, user.ID).Times(1).Return(nil)
+	userRepo.EXPECT().SetCalendar(gomock.Any(), user.ID, "").Times(1).Return(nil)
+
+	err = calendarService.DeleteCalendar(ctx, calendar.ID, user.ID)
+	require.NoError(t, err)
+}
+
+func TestCalendarService_SelectCalendar(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	calendarRepo := mocksRepo.NewMockCalendars(ctrl)
+	userRepo := mocksRepo.NewMockUsers(ctrl)
+	calendarService, err := NewCalendarService(calendarRepo, userRepo, nil, "")
+	require.NoError(t, err)
+
+	userID := utils.RandomString(10)
+	calendar := domain.Calendar{
+		ID:     utils.RandomString(10),
+		UserID: userID,
+		Name:   "Office",
+		Days:   []domain.CalendarDay{{Date: "2030-01-02", DayOff: true}},
+	}
+
+	calendarRepo.EXPECT().GetCalendarByID(gomock.Any(), calendar.ID).Times(1).Return(calendar, nil)
+	userRepo.EXPECT().SetCalendar(gomock.Any(), userID, calendar.ID).Times(1).Return(nil)
+
+	selected, err := calendarService.SelectCalendar(ctx, userID, calendar.ID)
+	require.NoError(t, err)
+	require.Equal(t, domain.Calendar{ID: calendar.ID, UserID: userID, Name: "Office"}, selected)
+
+	userRepo.EXPECT().SetCalendar(gomock.Any(), userID, "").Times(1).Return(nil)
+
+	selected, err = calendarService.SelectCalendar(ctx, userID, "")
+	require.NoError(t, err)
+	require.Equal(t, domain.StandardCalendar, selected.ID)
+
+	calendarRepo.EXPECT().GetCalendarByID(gomock.Any(), "missing").Times(1).Return(domain.Calendar{}, domain.ErrNotFound)
+
+	_, err = calendarService.SelectCalendar(ctx, userID, "missing")
+	require.Equal(t, domain.ErrInvalidCalendar, err)
+}
+
+func TestTodoService_fillDaysOff(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	calendarRepo := mocksRepo.NewMockCalendars(ctrl)
+	bundled, err := LoadCalendars("")
+	require.NoError(t, err)
+	calendarService, err := NewCalendarService(calendarRepo, mocksRepo.NewMockUsers(ctrl), bundled, "kz")
+	require.NoError(t, err)
+
+	todoService := NewTodoService(mocksRepo.NewMockTodo(ctrl), mocksRepo.NewMockTodoItems(ctrl),
+		mocksRepo.NewMockUsers(ctrl), calendarService, true, 0)
+
+	almaty := domain.User{ID: utils.RandomString(10), Timezone: "Asia/Almaty"}
+	todos := []domain.Todo{
+		// 2026-03-08 19:30 UTC is already Monday the 9th in Almaty.
+		{ActiveAt: time.Date(2026, time.March, 8, 19, 30, 0, 0, time.UTC)},
+		{ActiveAt: time.Date(2026, time.March, 10, 0, 0, 0, 0, time.UTC)},
+		{ActiveAt: time.Date(2026, time.March, 14, 0, 0, 0, 0, time.UTC)},
+	}
+
+	require.NoError(t, todoService.fillDaysOff(ctx, almaty, todos))
+	require.True(t, todos[0].IsDayOff)
+	require.Equal(t, "Перенос выходного дня: Международный женский день", todos[0].DayOffReason)
+	require.False(t, todos[1].IsDayOff)
+	require.Empty(t, todos[1].DayOffReason)
+	require.True(t, todos[2].IsDayOff)
+	require.Equal(t, domain.DayOffWeekend, todos[2].DayOffReason)
+
+	// A calendar that was deleted falls back to the default one.
+	almaty.Calendar = utils.RandomString(10)
+	calendarRepo.EXPECT().GetCalendarByID(gomock.Any(), almaty.Calendar).Times(1).Return(domain.Calendar{}, domain.ErrNotFound)
+
+	require.NoError(t, todoService.fillDaysOff(ctx, almaty, todos[:1]))
+	require.True(t, todos[0].IsDayOff)
+}
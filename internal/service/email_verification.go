@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	netmail "net/mail"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/auth"
+	"github.com/begenov/region-llc-task/pkg/logger"
+)
+
+const (
+	// emailVerificationKey holds the user and the email of a verification
+	// link by the hash of its token, until the link expires.
+	emailVerificationKey = "email-verification:"
+	// emailVerificationUserKey holds the hash of the latest verification
+	// token of a user, a new link replaces the ones sent before.
+	emailVerificationUserKey = "email-verification-user:"
+
+	defaultEmailVerificationTTL = 24 * time.Hour
+)
+
+// EmailVerificationPolicy.URL is the page of the client that verifies an
+// email, verification links are the URL with the token in the token query
+// parameter. TokenTTL is how long a verification link works.
+type EmailVerificationPolicy struct {
+	URL      string
+	TokenTTL time.Duration
+}
+
+// emailVerification is the email a verification link is sent to, it becomes
+// the email of the user once verified.
+type emailVerification struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// sendVerification emails a verification link for the email to the user.
+func (s *UserService) sendVerification(ctx context.Context, user domain.User, email string, language string) error {
+	token, err := auth.NewToken(auth.EmailTokenPrefix)
+	if err != nil {
+		logger.Errorf("auth.NewToken(): %v", err)
+		return err
+	}
+
+	link, err := tokenLink(s.verification.URL, token)
+	if err != nil {
+		logger.Errorf("tokenLink(): %v", err)
+		return err
+	}
+
+	value, err := json.Marshal(emailVerification{UserID: user.ID, Email: email})
+	if err != nil {
+		logger.Errorf("json.Marshal(): %v", err)
+		return err
+	}
+
+	tokenHash := auth.HashToken(token)
+	if err := s.redisRepo.Set(emailVerificationKey+tokenHash, string(value), s.verification.TokenTTL); err != nil {
+		logger.Errorf("s.redisRepo.Set(): %v", err)
+		return err
+	}
+
+	if err := s.redisRepo.Set(emailVerificationUserKey+user.ID, tokenHash, s.verification.TokenTTL); err != nil {
+		logger.Errorf("s.redisRepo.Set(): %v", err)
+		return err
+	}
+
+	if user.Language != "" {
+		language = user.Language
+	}
+
+	return s.sendLink(ctx, email, language, "email_verification", link)
+}
+
+// VerifyEmail marks the email of the verification token as verified, the
+// email of the user is replaced when it was changed. A token works once, and
+// only the latest one sent to the user does.
+func (s *UserService) VerifyEmail(ctx context.Context, token string) error {
+	if !auth.VerifyToken(token, auth.EmailTokenPrefix) {
+		return domain.ErrInvalidVerifyToken
+	}
+
+	tokenHash := auth.HashToken(token)
+	value, err := s.redisRepo.Get(emailVerificationKey + tokenHash)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.ErrInvalidVerifyToken
+	}
+	if err != nil {
+		logger.Errorf("s.redisRepo.Get(): %v", err)
+		return err
+	}
+
+	var verification emailVerification
+	if err := json.Unmarshal([]byte(value), &verification); err != nil {
+		logger.Errorf("json.Unmarshal(): %v", err)
+		return err
+	}
+
+	latest, err := s.redisRepo.Get(emailVerificationUserKey + verification.UserID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		logger.Errorf("s.redisRepo.Get(): %v", err)
+		return err
+	}
+
+	if err := s.redisRepo.Delete(emailVerificationKey + tokenHash); err != nil {
+		logger.Errorf("s.redisRepo.Delete(): %v", err)
+		return err
+	}
+
+	if latest != tokenHash {
+		return domain.ErrInvalidVerifyToken
+	}
+
+	if err := s.redisRepo.Delete(emailVerificationUserKey + verification.UserID); err != nil {
+		logger.Errorf("s.redisRepo.Delete(): %v", err)
+		return err
+	}
+
+	err = s.userRepo.VerifyEmail(ctx, verification.UserID, verification.Email)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.ErrInvalidVerifyToken
+	}
+	if err != nil {
+		logger.Errorf("s.userRepo.VerifyEmail(): %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// ResendVerification emails a new verification link to the user, the links
+// sent before stop working.
+func (s *UserService) ResendVerification(ctx context.Context, userID string, language string) error {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Errorf("s.userRepo.GetUserByID(): %v", err)
+		return err
+	}
+
+	if user.EmailVerified {
+		return domain.ErrEmailAlreadyVerified
+	}
+
+	return s.sendVerification(ctx, user, user.Email, language)
+}
+
+// ChangeEmail emails a verification link to the new email of the user. The
+// user keeps the current email until the new one is verified.
+func (s *UserService) ChangeEmail(ctx context.Context, userID string, inp domain.EmailChange, language string) error {
+	if _, err := netmail.ParseAddress(inp.Email); err != nil {
+		return domain.ErrIncorrectEmailAddress
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Errorf("s.userRepo.GetUserByID(): %v", err)
+		return err
+	}
+
+	if err := s.hash.CompareHashAndPassword(user.Password, inp.Password); err != nil {
+		return domain.ErrIncorrectPassword
+	}
+
+	if inp.Email == user.Email && user.EmailVerified {
+		return domain.ErrEmailAlreadyVerified
+	}
+
+	_, err = s.userRepo.GetUserByEmail(ctx, inp.Email)
+	if err == nil && inp.Email != user.Email {
+		return domain.ErrEmailAlreadyExists
+	}
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		logger.Errorf("s.userRepo.GetUserByEmail(): %v", err)
+		return err
+	}
+
+	return s.sendVerification(ctx, user, inp.Email, language)
+}
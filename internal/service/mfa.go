@@ -0,0 +1,558 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/auth"
+	"github.com/begenov/region-llc-task/pkg/logger"
+)
+
+const (
+	// mfaChallengeKey holds a sign-in waiting for a code by the hash of its
+	// MFA token, until the token expires. mfaAttemptsKey counts the wrong
+	// codes of the token.
+	mfaChallengeKey = "mfa-challenge:"
+	mfaAttemptsKey  = "mfa-attempts:"
+	// mfaFailuresKey counts the wrong codes of a user whatever MFA tokens
+	// they came with, mfaLockedKey keeps until when the user gets none.
+	mfaFailuresKey = "mfa-failures:"
+	mfaLockedKey   = "mfa-locked:"
+
+	defaultMFAChallengeTTL = 5 * time.Minute
+	// maxMFAAttempts is how many wrong codes an MFA token takes before it is
+	// dropped, and the password has to be entered again.
+	maxMFAAttempts = 5
+	// maxMFAFailures is how many wrong codes a user may enter within
+	// mfaLockoutDuration. Then no MFA token is issued or accepted for the user
+	// until mfaLockoutDuration has passed.
+	maxMFAFailures     = 10
+	mfaLockoutDuration = 15 * time.Minute
+	recoveryCodes      = 10
+)
+
+// MFAPolicy configures the two-factor authentication. Issuer is the name
+// authenticator apps show next to the account. The users whose email is in
+// one of RequiredDomains, the organizations that enforce two-factor
+// authentication, have to set it up to sign in. ChallengeTTL is how long the
+// MFA token of a sign-in is valid.
+type MFAPolicy struct {
+	Issuer          string
+	RequiredDomains []string
+	ChallengeTTL    time.Duration
+}
+
+// mfaChallenge is a sign-in that has passed the password check. Enrollment
+// marks the sign-in of a user who has to set up two-factor authentication
+// first.
+type mfaChallenge struct {
+	UserID     string    `json:"user_id"`
+	Enrollment bool      `json:"enrollment"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// mfaRequired reports whether the organization of the email enforces
+// two-factor authentication.
+func (s *UserService) mfaRequired(email string) bool {
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		return false
+	}
+
+	domainName := strings.ToLower(email[at+1:])
+	for _, required := range s.mfa.RequiredDomains {
+		if strings.ToLower(required) == domainName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// signInMFA returns the MFA token of a user who signs in with two-factor
+// authentication, or an empty token when the password is enough.
+func (s *UserService) signInMFA(ctx context.Context, user domain.User) (domain.Token, error) {
+	mfa, err := s.mfaRepo.GetMFA(ctx, user.ID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		logger.Errorf("s.mfaRepo.GetMFA(): %v", err)
+		return domain.Token{}, err
+	}
+
+	if !mfa.Enabled && !s.mfaRequired(user.Email) {
+		return domain.Token{}, nil
+	}
+
+	if err := s.checkBlocked(mfaLockedKey + user.ID); err != nil {
+		return domain.Token{}, err
+	}
+
+	token, err := auth.NewToken(auth.MFATokenPrefix)
+	if err != nil {
+		logger.Errorf("auth.NewToken(): %v", err)
+		return domain.Token{}, err
+	}
+
+	challenge := mfaChallenge{
+		UserID:     user.ID,
+		Enrollment: !mfa.Enabled,
+		ExpiresAt:  time.Now().Add(s.mfa.ChallengeTTL),
+	}
+	if err := s.setMFAChallenge(auth.HashToken(token), challenge); err != nil {
+		return domain.Token{}, err
+	}
+
+	return domain.Token{
+		MFAToken:      token,
+		MFAEnrollment: challenge.Enrollment,
+	}, nil
+}
+
+// VerifyMFA completes the sign-in of the MFA token with a code of the
+// authenticator app or a recovery code. A sign-in that sets up two-factor
+// authentication confirms it with the code and gets the recovery codes.
+// Wrong codes are limited per MFA token and per user, and count as failed
+// sign-ins of the account.
+func (s *UserService) VerifyMFA(ctx context.Context, inp domain.MFASignInRequest, client domain.Client) (domain.Token, error) {
+	tokenHash := auth.HashToken(inp.MFAToken)
+
+	challenge, err := s.getMFAChallenge(inp.MFAToken)
+	if err != nil {
+		return domain.Token{}, err
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, challenge.UserID)
+	if err != nil {
+		logger.Errorf("s.userRepo.GetUserByID(): %v", err)
+		return domain.Token{}, err
+	}
+
+	// Wrong codes count as failed sign-ins of the account, their backoff
+	// holds here too.
+	if err := s.checkSignIn(user.Email, client); err != nil {
+		return domain.Token{}, err
+	}
+
+	if err := s.checkBlocked(mfaLockedKey + challenge.UserID); err != nil {
+		s.deleteMFAChallenge(tokenHash)
+		return domain.Token{}, err
+	}
+
+	var codes []string
+	if challenge.Enrollment {
+		codes, err = s.confirmMFA(ctx, challenge.UserID, inp.Code)
+	} else {
+		err = s.checkMFACode(ctx, challenge.UserID, inp.Code)
+	}
+
+	if errors.Is(err, domain.ErrInvalidMFACode) {
+		if err := s.failMFA(tokenHash, challenge, client); err != nil {
+			return domain.Token{}, err
+		}
+
+		if err := s.failSignIn(ctx, user.Email, client); err != nil {
+			return domain.Token{}, err
+		}
+
+		return domain.Token{}, err
+	}
+	if err != nil {
+		return domain.Token{}, err
+	}
+
+	s.deleteMFAChallenge(tokenHash)
+	if err := s.redisRepo.Delete(mfaFailuresKey + challenge.UserID); err != nil {
+		logger.Errorf("s.redisRepo.Delete(): %v", err)
+		return domain.Token{}, err
+	}
+
+	token, err := s.signInSession(ctx, user, client)
+	if err != nil {
+		return domain.Token{}, err
+	}
+
+	token.RecoveryCodes = codes
+
+	return token, nil
+}
+
+// failMFA counts a wrong code of the MFA token. The token is dropped after
+// maxMFAAttempts of them, the user is locked out after maxMFAFailures.
+func (s *UserService) failMFA(tokenHash string, challenge mfaChallenge, client domain.Client) error {
+	if err := s.countMFAFailure(challenge.UserID, client.IP); err != nil {
+		s.deleteMFAChallenge(tokenHash)
+		return err
+	}
+
+	ttl := time.Until(challenge.ExpiresAt)
+	if ttl <= 0 {
+		s.deleteMFAChallenge(tokenHash)
+		return nil
+	}
+
+	attempts, err := s.redisRepo.Incr(mfaAttemptsKey+tokenHash, ttl)
+	if err != nil {
+		logger.Errorf("s.redisRepo.Incr(): %v", err)
+		return err
+	}
+
+	if attempts >= maxMFAAttempts {
+		logger.Warnf("security: too many wrong MFA codes for user %s, ip %q", challenge.UserID, client.IP)
+		s.deleteMFAChallenge(tokenHash)
+	}
+
+	return nil
+}
+
+// countMFAFailure counts a wrong code of the user, wherever it was entered.
+// After maxMFAFailures of them the user is locked out with a RetryError.
+func (s *UserService) countMFAFailure(userID string, ip string) error {
+	failures, err := s.redisRepo.Incr(mfaFailuresKey+userID, mfaLockoutDuration)
+	if err != nil {
+		logger.Errorf("s.redisRepo.Incr(): %v", err)
+		return err
+	}
+
+	if failures < maxMFAFailures {
+		return nil
+	}
+
+	logger.Warnf("security: too many wrong MFA codes for user %s, ip %q, locked out", userID, ip)
+	if err := s.block(mfaLockedKey+userID, mfaLockoutDuration); err != nil {
+		return err
+	}
+
+	return &domain.RetryError{Err: domain.ErrTooManyAttempts, RetryAfter: mfaLockoutDuration}
+}
+
+// limitMFACode runs check of a code entered by a signed in user under the
+// same lockout as the sign-in: a wrong code is counted, a right one clears
+// the count.
+func (s *UserService) limitMFACode(userID string, check func() error) error {
+	if err := s.checkBlocked(mfaLockedKey + userID); err != nil {
+		return err
+	}
+
+	err := check()
+	if errors.Is(err, domain.ErrInvalidMFACode) {
+		if err := s.countMFAFailure(userID, ""); err != nil {
+			return err
+		}
+
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.redisRepo.Delete(mfaFailuresKey + userID); err != nil {
+		logger.Errorf("s.redisRepo.Delete(): %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// EnrollMFAChallenge sets up two-factor authentication during the sign-in of
+// a user who has to have it, the MFA token stands for the access token.
+func (s *UserService) EnrollMFAChallenge(ctx context.Context, mfaToken string) (domain.MFAEnrollment, error) {
+	challenge, err := s.getMFAChallenge(mfaToken)
+	if err != nil {
+		return domain.MFAEnrollment{}, err
+	}
+
+	if !challenge.Enrollment {
+		return domain.MFAEnrollment{}, domain.ErrMFAAlreadyEnabled
+	}
+
+	return s.EnrollMFA(ctx, challenge.UserID)
+}
+
+func (s *UserService) GetMFA(ctx context.Context, userID string) (domain.MFAStatus, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Errorf("s.userRepo.GetUserByID(): %v", err)
+		return domain.MFAStatus{}, err
+	}
+
+	mfa, err := s.mfaRepo.GetMFA(ctx, userID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		logger.Errorf("s.mfaRepo.GetMFA(): %v", err)
+		return domain.MFAStatus{}, err
+	}
+
+	status := domain.MFAStatus{
+		Enabled:  mfa.Enabled,
+		Required: s.mfaRequired(user.Email),
+	}
+	if mfa.Enabled {
+		status.RecoveryCodesLeft = len(mfa.RecoveryCodes)
+	}
+
+	return status, nil
+}
+
+// EnrollMFA generates a new secret for the user, two-factor authentication is
+// enabled once ConfirmMFA gets a code of it. Enrolling again replaces a
+// secret that has not been confirmed.
+func (s *UserService) EnrollMFA(ctx context.Context, userID string) (domain.MFAEnrollment, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Errorf("s.userRepo.GetUserByID(): %v", err)
+		return domain.MFAEnrollment{}, err
+	}
+
+	mfa, err := s.mfaRepo.GetMFA(ctx, userID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		logger.Errorf("s.mfaRepo.GetMFA(): %v", err)
+		return domain.MFAEnrollment{}, err
+	}
+
+	if mfa.Enabled {
+		return domain.MFAEnrollment{}, domain.ErrMFAAlreadyEnabled
+	}
+
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		logger.Errorf("auth.NewTOTPSecret(): %v", err)
+		return domain.MFAEnrollment{}, err
+	}
+
+	if err := s.mfaRepo.SetMFA(ctx, domain.MFA{UserID: userID, Secret: secret}); err != nil {
+		logger.Errorf("s.mfaRepo.SetMFA(): %v", err)
+		return domain.MFAEnrollment{}, err
+	}
+
+	return domain.MFAEnrollment{
+		Secret: secret,
+		URI:    auth.TOTPURI(s.mfa.Issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmMFA enables the two-factor authentication set up by EnrollMFA with a
+// code of the authenticator app. The recovery codes are returned only here.
+func (s *UserService) ConfirmMFA(ctx context.Context, userID string, code string) (domain.RecoveryCodes, error) {
+	var codes []string
+	err := s.limitMFACode(userID, func() error {
+		var err error
+		codes, err = s.confirmMFA(ctx, userID, code)
+		return err
+	})
+	if err != nil {
+		return domain.RecoveryCodes{}, err
+	}
+
+	return domain.RecoveryCodes{RecoveryCodes: codes}, nil
+}
+
+// DisableMFA turns the two-factor authentication off, it takes a code so that
+// a stolen access token is not enough. Users of an organization that enforces
+// it cannot turn it off.
+func (s *UserService) DisableMFA(ctx context.Context, userID string, code string) error {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Errorf("s.userRepo.GetUserByID(): %v", err)
+		return err
+	}
+
+	if s.mfaRequired(user.Email) {
+		return domain.ErrMFARequired
+	}
+
+	err = s.limitMFACode(userID, func() error {
+		return s.checkMFACode(ctx, userID, code)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.mfaRepo.DeleteMFA(ctx, userID); err != nil {
+		logger.Errorf("s.mfaRepo.DeleteMFA(): %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user, the unused
+// ones stop working.
+func (s *UserService) RegenerateRecoveryCodes(ctx context.Context, userID string, code string) (domain.RecoveryCodes, error) {
+	err := s.limitMFACode(userID, func() error {
+		return s.checkMFACode(ctx, userID, code)
+	})
+	if err != nil {
+		return domain.RecoveryCodes{}, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return domain.RecoveryCodes{}, err
+	}
+
+	if err := s.mfaRepo.SetRecoveryCodes(ctx, userID, hashes); err != nil {
+		logger.Errorf("s.mfaRepo.SetRecoveryCodes(): %v", err)
+		return domain.RecoveryCodes{}, err
+	}
+
+	return domain.RecoveryCodes{RecoveryCodes: codes}, nil
+}
+
+func (s *UserService) confirmMFA(ctx context.Context, userID string, code string) ([]string, error) {
+	mfa, err := s.mfaRepo.GetMFA(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, domain.ErrMFANotEnrolled
+	}
+	if err != nil {
+		logger.Errorf("s.mfaRepo.GetMFA(): %v", err)
+		return nil, err
+	}
+
+	if mfa.Enabled {
+		return nil, domain.ErrMFAAlreadyEnabled
+	}
+
+	step, ok := auth.ValidateTOTP(mfa.Secret, code, time.Now())
+	if !ok {
+		return nil, domain.ErrInvalidMFACode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	mfa.Enabled = true
+	mfa.LastStep = step
+	mfa.RecoveryCodes = hashes
+	if err := s.mfaRepo.SetMFA(ctx, mfa); err != nil {
+		logger.Errorf("s.mfaRepo.SetMFA(): %v", err)
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// checkMFACode accepts a code of the authenticator app once, or uses up a
+// recovery code.
+func (s *UserService) checkMFACode(ctx context.Context, userID string, code string) error {
+	mfa, err := s.mfaRepo.GetMFA(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.ErrMFANotEnrolled
+	}
+	if err != nil {
+		logger.Errorf("s.mfaRepo.GetMFA(): %v", err)
+		return err
+	}
+
+	if !mfa.Enabled {
+		return domain.ErrMFANotEnrolled
+	}
+
+	if auth.IsTOTPCode(code) {
+		step, ok := auth.ValidateTOTP(mfa.Secret, code, time.Now())
+		if !ok {
+			return domain.ErrInvalidMFACode
+		}
+
+		// A code seen before is refused, it may have been looked over the
+		// shoulder.
+		err := s.mfaRepo.UseStep(ctx, userID, step)
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ErrInvalidMFACode
+		}
+		if err != nil {
+			logger.Errorf("s.mfaRepo.UseStep(): %v", err)
+			return err
+		}
+
+		return nil
+	}
+
+	err = s.mfaRepo.UseRecoveryCode(ctx, userID, auth.HashToken(auth.NormalizeRecoveryCode(code)))
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.ErrInvalidMFACode
+	}
+	if err != nil {
+		logger.Errorf("s.mfaRepo.UseRecoveryCode(): %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *UserService) getMFAChallenge(token string) (mfaChallenge, error) {
+	if !auth.VerifyToken(token, auth.MFATokenPrefix) {
+		return mfaChallenge{}, domain.ErrInvalidMFAToken
+	}
+
+	value, err := s.redisRepo.Get(mfaChallengeKey + auth.HashToken(token))
+	if errors.Is(err, domain.ErrNotFound) {
+		return mfaChallenge{}, domain.ErrInvalidMFAToken
+	}
+	if err != nil {
+		logger.Errorf("s.redisRepo.Get(): %v", err)
+		return mfaChallenge{}, err
+	}
+
+	var challenge mfaChallenge
+	if err := json.Unmarshal([]byte(value), &challenge); err != nil {
+		logger.Errorf("json.Unmarshal(): %v", err)
+		return mfaChallenge{}, domain.ErrInvalidMFAToken
+	}
+
+	return challenge, nil
+}
+
+func (s *UserService) setMFAChallenge(tokenHash string, challenge mfaChallenge) error {
+	ttl := time.Until(challenge.ExpiresAt)
+	if ttl <= 0 {
+		return domain.ErrInvalidMFAToken
+	}
+
+	value, err := json.Marshal(challenge)
+	if err != nil {
+		logger.Errorf("json.Marshal(): %v", err)
+		return err
+	}
+
+	if err := s.redisRepo.Set(mfaChallengeKey+tokenHash, string(value), ttl); err != nil {
+		logger.Errorf("s.redisRepo.Set(): %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// deleteMFAChallenge drops a used MFA token. A failure is only logged, the
+// token expires soon anyway.
+func (s *UserService) deleteMFAChallenge(tokenHash string) {
+	if err := s.redisRepo.Delete(mfaChallengeKey + tokenHash); err != nil {
+		logger.Errorf("s.redisRepo.Delete(): %v", err)
+	}
+
+	if err := s.redisRepo.Delete(mfaAttemptsKey + tokenHash); err != nil {
+		logger.Errorf("s.redisRepo.Delete(): %v", err)
+	}
+}
+
+// newRecoveryCodes returns the recovery codes to show and the hashes to
+// store.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodes)
+	hashes := make([]string, recoveryCodes)
+	for i := range codes {
+		code, err := auth.NewRecoveryCode()
+		if err != nil {
+			logger.Errorf("auth.NewRecoveryCode(): %v", err)
+			return nil, nil, err
+		}
+
+		codes[i] = code
+		hashes[i] = auth.HashToken(auth.NormalizeRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
@@ -9,8 +9,8 @@ import (
 	reflect "reflect"
 
 	domain "github.com/begenov/region-llc-task/internal/domain"
+	auth "github.com/begenov/region-llc-task/pkg/auth"
 	gomock "github.com/golang/mock/gomock"
-	primitive "go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // MockUsers is a mock of Users interface.
@@ -36,34 +36,383 @@ func (m *MockUsers) EXPECT() *MockUsersMockRecorder {
 	return m.recorder
 }
 
+// ChangeEmail mocks base method.
+func (m *MockUsers) ChangeEmail(ctx context.Context, userID string, inp domain.EmailChange, language string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeEmail", ctx, userID, inp, language)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangeEmail indicates an expected call of ChangeEmail.
+func (mr *MockUsersMockRecorder) ChangeEmail(ctx, userID, inp, language interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeEmail", reflect.TypeOf((*MockUsers)(nil).ChangeEmail), ctx, userID, inp, language)
+}
+
+// ChangePassword mocks base method.
+func (m *MockUsers) ChangePassword(ctx context.Context, userID string, inp domain.PasswordChange, client domain.Client) (domain.Token, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, userID, inp, client)
+	ret0, _ := ret[0].(domain.Token)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockUsersMockRecorder) ChangePassword(ctx, userID, inp, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockUsers)(nil).ChangePassword), ctx, userID, inp, client)
+}
+
+// CheckAccessToken mocks base method.
+func (m *MockUsers) CheckAccessToken(ctx context.Context, claims auth.Claims) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckAccessToken", ctx, claims)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckAccessToken indicates an expected call of CheckAccessToken.
+func (mr *MockUsersMockRecorder) CheckAccessToken(ctx, claims interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckAccessToken", reflect.TypeOf((*MockUsers)(nil).CheckAccessToken), ctx, claims)
+}
+
+// CheckPersonalToken mocks base method.
+func (m *MockUsers) CheckPersonalToken(ctx context.Context, token string) (domain.Principal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckPersonalToken", ctx, token)
+	ret0, _ := ret[0].(domain.Principal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckPersonalToken indicates an expected call of CheckPersonalToken.
+func (mr *MockUsersMockRecorder) CheckPersonalToken(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckPersonalToken", reflect.TypeOf((*MockUsers)(nil).CheckPersonalToken), ctx, token)
+}
+
+// ConfirmMFA mocks base method.
+func (m *MockUsers) ConfirmMFA(ctx context.Context, userID, code string) (domain.RecoveryCodes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmMFA", ctx, userID, code)
+	ret0, _ := ret[0].(domain.RecoveryCodes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmMFA indicates an expected call of ConfirmMFA.
+func (mr *MockUsersMockRecorder) ConfirmMFA(ctx, userID, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmMFA", reflect.TypeOf((*MockUsers)(nil).ConfirmMFA), ctx, userID, code)
+}
+
+// CreatePersonalToken mocks base method.
+func (m *MockUsers) CreatePersonalToken(ctx context.Context, userID string, inp domain.PersonalTokenRequest) (domain.NewPersonalToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePersonalToken", ctx, userID, inp)
+	ret0, _ := ret[0].(domain.NewPersonalToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePersonalToken indicates an expected call of CreatePersonalToken.
+func (mr *MockUsersMockRecorder) CreatePersonalToken(ctx, userID, inp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePersonalToken", reflect.TypeOf((*MockUsers)(nil).CreatePersonalToken), ctx, userID, inp)
+}
+
+// DeleteAccount mocks base method.
+func (m *MockUsers) DeleteAccount(ctx context.Context, userID, password string, client domain.Client) (domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccount", ctx, userID, password, client)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteAccount indicates an expected call of DeleteAccount.
+func (mr *MockUsersMockRecorder) DeleteAccount(ctx, userID, password, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockUsers)(nil).DeleteAccount), ctx, userID, password, client)
+}
+
+// DisableMFA mocks base method.
+func (m *MockUsers) DisableMFA(ctx context.Context, userID, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableMFA", ctx, userID, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableMFA indicates an expected call of DisableMFA.
+func (mr *MockUsersMockRecorder) DisableMFA(ctx, userID, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableMFA", reflect.TypeOf((*MockUsers)(nil).DisableMFA), ctx, userID, code)
+}
+
+// EnrollMFA mocks base method.
+func (m *MockUsers) EnrollMFA(ctx context.Context, userID string) (domain.MFAEnrollment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollMFA", ctx, userID)
+	ret0, _ := ret[0].(domain.MFAEnrollment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrollMFA indicates an expected call of EnrollMFA.
+func (mr *MockUsersMockRecorder) EnrollMFA(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollMFA", reflect.TypeOf((*MockUsers)(nil).EnrollMFA), ctx, userID)
+}
+
+// EnrollMFAChallenge mocks base method.
+func (m *MockUsers) EnrollMFAChallenge(ctx context.Context, mfaToken string) (domain.MFAEnrollment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollMFAChallenge", ctx, mfaToken)
+	ret0, _ := ret[0].(domain.MFAEnrollment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrollMFAChallenge indicates an expected call of EnrollMFAChallenge.
+func (mr *MockUsersMockRecorder) EnrollMFAChallenge(ctx, mfaToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollMFAChallenge", reflect.TypeOf((*MockUsers)(nil).EnrollMFAChallenge), ctx, mfaToken)
+}
+
+// GetLanguage mocks base method.
+func (m *MockUsers) GetLanguage(ctx context.Context, userID string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLanguage", ctx, userID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLanguage indicates an expected call of GetLanguage.
+func (mr *MockUsersMockRecorder) GetLanguage(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLanguage", reflect.TypeOf((*MockUsers)(nil).GetLanguage), ctx, userID)
+}
+
+// GetMFA mocks base method.
+func (m *MockUsers) GetMFA(ctx context.Context, userID string) (domain.MFAStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMFA", ctx, userID)
+	ret0, _ := ret[0].(domain.MFAStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMFA indicates an expected call of GetMFA.
+func (mr *MockUsersMockRecorder) GetMFA(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMFA", reflect.TypeOf((*MockUsers)(nil).GetMFA), ctx, userID)
+}
+
+// GetPersonalTokens mocks base method.
+func (m *MockUsers) GetPersonalTokens(ctx context.Context, userID string) ([]domain.PersonalToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPersonalTokens", ctx, userID)
+	ret0, _ := ret[0].([]domain.PersonalToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPersonalTokens indicates an expected call of GetPersonalTokens.
+func (mr *MockUsersMockRecorder) GetPersonalTokens(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPersonalTokens", reflect.TypeOf((*MockUsers)(nil).GetPersonalTokens), ctx, userID)
+}
+
+// GetProfile mocks base method.
+func (m *MockUsers) GetProfile(ctx context.Context, userID string) (domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProfile", ctx, userID)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProfile indicates an expected call of GetProfile.
+func (mr *MockUsersMockRecorder) GetProfile(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProfile", reflect.TypeOf((*MockUsers)(nil).GetProfile), ctx, userID)
+}
+
+// GetSessions mocks base method.
+func (m *MockUsers) GetSessions(ctx context.Context, userID, currentID string) ([]domain.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessions", ctx, userID, currentID)
+	ret0, _ := ret[0].([]domain.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessions indicates an expected call of GetSessions.
+func (mr *MockUsersMockRecorder) GetSessions(ctx, userID, currentID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessions", reflect.TypeOf((*MockUsers)(nil).GetSessions), ctx, userID, currentID)
+}
+
+// Logout mocks base method.
+func (m *MockUsers) Logout(ctx context.Context, claims auth.Claims) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logout", ctx, claims)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockUsersMockRecorder) Logout(ctx, claims interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockUsers)(nil).Logout), ctx, claims)
+}
+
+// LogoutEverywhere mocks base method.
+func (m *MockUsers) LogoutEverywhere(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogoutEverywhere", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LogoutEverywhere indicates an expected call of LogoutEverywhere.
+func (mr *MockUsersMockRecorder) LogoutEverywhere(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogoutEverywhere", reflect.TypeOf((*MockUsers)(nil).LogoutEverywhere), ctx, userID)
+}
+
 // RefreshTokens mocks base method.
-func (m *MockUsers) RefreshTokens(ctx context.Context, refreshToken string) (domain.Token, error) {
+func (m *MockUsers) RefreshTokens(ctx context.Context, refreshToken string, client domain.Client) (domain.Token, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RefreshTokens", ctx, refreshToken)
+	ret := m.ctrl.Call(m, "RefreshTokens", ctx, refreshToken, client)
 	ret0, _ := ret[0].(domain.Token)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // RefreshTokens indicates an expected call of RefreshTokens.
-func (mr *MockUsersMockRecorder) RefreshTokens(ctx, refreshToken interface{}) *gomock.Call {
+func (mr *MockUsersMockRecorder) RefreshTokens(ctx, refreshToken, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshTokens", reflect.TypeOf((*MockUsers)(nil).RefreshTokens), ctx, refreshToken, client)
+}
+
+// RegenerateRecoveryCodes mocks base method.
+func (m *MockUsers) RegenerateRecoveryCodes(ctx context.Context, userID, code string) (domain.RecoveryCodes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegenerateRecoveryCodes", ctx, userID, code)
+	ret0, _ := ret[0].(domain.RecoveryCodes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegenerateRecoveryCodes indicates an expected call of RegenerateRecoveryCodes.
+func (mr *MockUsersMockRecorder) RegenerateRecoveryCodes(ctx, userID, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegenerateRecoveryCodes", reflect.TypeOf((*MockUsers)(nil).RegenerateRecoveryCodes), ctx, userID, code)
+}
+
+// RequestPasswordReset mocks base method.
+func (m *MockUsers) RequestPasswordReset(ctx context.Context, email, language string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestPasswordReset", ctx, email, language)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequestPasswordReset indicates an expected call of RequestPasswordReset.
+func (mr *MockUsersMockRecorder) RequestPasswordReset(ctx, email, language interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestPasswordReset", reflect.TypeOf((*MockUsers)(nil).RequestPasswordReset), ctx, email, language)
+}
+
+// ResendVerification mocks base method.
+func (m *MockUsers) ResendVerification(ctx context.Context, userID, language string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResendVerification", ctx, userID, language)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResendVerification indicates an expected call of ResendVerification.
+func (mr *MockUsersMockRecorder) ResendVerification(ctx, userID, language interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResendVerification", reflect.TypeOf((*MockUsers)(nil).ResendVerification), ctx, userID, language)
+}
+
+// ResetPassword mocks base method.
+func (m *MockUsers) ResetPassword(ctx context.Context, inp domain.PasswordReset) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", ctx, inp)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockUsersMockRecorder) ResetPassword(ctx, inp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockUsers)(nil).ResetPassword), ctx, inp)
+}
+
+// RevokePersonalToken mocks base method.
+func (m *MockUsers) RevokePersonalToken(ctx context.Context, userID, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokePersonalToken", ctx, userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokePersonalToken indicates an expected call of RevokePersonalToken.
+func (mr *MockUsersMockRecorder) RevokePersonalToken(ctx, userID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokePersonalToken", reflect.TypeOf((*MockUsers)(nil).RevokePersonalToken), ctx, userID, id)
+}
+
+// RevokeSession mocks base method.
+func (m *MockUsers) RevokeSession(ctx context.Context, userID, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockUsersMockRecorder) RevokeSession(ctx, userID, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshTokens", reflect.TypeOf((*MockUsers)(nil).RefreshTokens), ctx, refreshToken)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockUsers)(nil).RevokeSession), ctx, userID, id)
+}
+
+// SetLanguage mocks base method.
+func (m *MockUsers) SetLanguage(ctx context.Context, userID, language string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLanguage", ctx, userID, language)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetLanguage indicates an expected call of SetLanguage.
+func (mr *MockUsersMockRecorder) SetLanguage(ctx, userID, language interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLanguage", reflect.TypeOf((*MockUsers)(nil).SetLanguage), ctx, userID, language)
 }
 
 // SignIn mocks base method.
-func (m *MockUsers) SignIn(ctx context.Context, email, password string) (domain.Token, error) {
+func (m *MockUsers) SignIn(ctx context.Context, email, password string, client domain.Client) (domain.Token, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SignIn", ctx, email, password)
+	ret := m.ctrl.Call(m, "SignIn", ctx, email, password, client)
 	ret0, _ := ret[0].(domain.Token)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // SignIn indicates an expected call of SignIn.
-func (mr *MockUsersMockRecorder) SignIn(ctx, email, password interface{}) *gomock.Call {
+func (mr *MockUsersMockRecorder) SignIn(ctx, email, password, client interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignIn", reflect.TypeOf((*MockUsers)(nil).SignIn), ctx, email, password)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignIn", reflect.TypeOf((*MockUsers)(nil).SignIn), ctx, email, password, client)
 }
 
 // SignUp mocks base method.
@@ -81,6 +430,50 @@ func (mr *MockUsersMockRecorder) SignUp(ctx, inp interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignUp", reflect.TypeOf((*MockUsers)(nil).SignUp), ctx, inp)
 }
 
+// UpdateProfile mocks base method.
+func (m *MockUsers) UpdateProfile(ctx context.Context, userID string, inp domain.UserUpdate) (domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProfile", ctx, userID, inp)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateProfile indicates an expected call of UpdateProfile.
+func (mr *MockUsersMockRecorder) UpdateProfile(ctx, userID, inp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockUsers)(nil).UpdateProfile), ctx, userID, inp)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockUsers) VerifyEmail(ctx context.Context, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockUsersMockRecorder) VerifyEmail(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockUsers)(nil).VerifyEmail), ctx, token)
+}
+
+// VerifyMFA mocks base method.
+func (m *MockUsers) VerifyMFA(ctx context.Context, inp domain.MFASignInRequest, client domain.Client) (domain.Token, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyMFA", ctx, inp, client)
+	ret0, _ := ret[0].(domain.Token)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyMFA indicates an expected call of VerifyMFA.
+func (mr *MockUsersMockRecorder) VerifyMFA(ctx, inp, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyMFA", reflect.TypeOf((*MockUsers)(nil).VerifyMFA), ctx, inp, client)
+}
+
 // MockTodo is a mock of Todo interface.
 type MockTodo struct {
 	ctrl     *gomock.Controller
@@ -105,66 +498,170 @@ func (m *MockTodo) EXPECT() *MockTodoMockRecorder {
 }
 
 // CreateTodo mocks base method.
-func (m *MockTodo) CreateTodo(ctx context.Context, todo domain.Todo) (domain.Todo, error) {
+func (m *MockTodo) CreateTodo(ctx context.Context, userID string, inp domain.TodoRequest) (domain.Todo, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateTodo", ctx, todo)
+	ret := m.ctrl.Call(m, "CreateTodo", ctx, userID, inp)
 	ret0, _ := ret[0].(domain.Todo)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateTodo indicates an expected call of CreateTodo.
-func (mr *MockTodoMockRecorder) CreateTodo(ctx, todo interface{}) *gomock.Call {
+func (mr *MockTodoMockRecorder) CreateTodo(ctx, userID, inp interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTodo", reflect.TypeOf((*MockTodo)(nil).CreateTodo), ctx, todo)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTodo", reflect.TypeOf((*MockTodo)(nil).CreateTodo), ctx, userID, inp)
+}
+
+// CreateTodoItem mocks base method.
+func (m *MockTodo) CreateTodoItem(ctx context.Context, todoID, userID string, inp domain.TodoItemRequest) (domain.TodoItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTodoItem", ctx, todoID, userID, inp)
+	ret0, _ := ret[0].(domain.TodoItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTodoItem indicates an expected call of CreateTodoItem.
+func (mr *MockTodoMockRecorder) CreateTodoItem(ctx, todoID, userID, inp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTodoItem", reflect.TypeOf((*MockTodo)(nil).CreateTodoItem), ctx, todoID, userID, inp)
 }
 
 // DeleteTodoByID mocks base method.
-func (m *MockTodo) DeleteTodoByID(ctx context.Context, id string) error {
+func (m *MockTodo) DeleteTodoByID(ctx context.Context, id, userID string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteTodoByID", ctx, id)
+	ret := m.ctrl.Call(m, "DeleteTodoByID", ctx, id, userID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteTodoByID indicates an expected call of DeleteTodoByID.
-func (mr *MockTodoMockRecorder) DeleteTodoByID(ctx, id interface{}) *gomock.Call {
+func (mr *MockTodoMockRecorder) DeleteTodoByID(ctx, id, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTodoByID", reflect.TypeOf((*MockTodo)(nil).DeleteTodoByID), ctx, id, userID)
+}
+
+// DeleteTodoItem mocks base method.
+func (m *MockTodo) DeleteTodoItem(ctx context.Context, todoID, itemID, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTodoItem", ctx, todoID, itemID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTodoItem indicates an expected call of DeleteTodoItem.
+func (mr *MockTodoMockRecorder) DeleteTodoItem(ctx, todoID, itemID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTodoItem", reflect.TypeOf((*MockTodo)(nil).DeleteTodoItem), ctx, todoID, itemID, userID)
+}
+
+// GetTodoByID mocks base method.
+func (m *MockTodo) GetTodoByID(ctx context.Context, id, userID string) (domain.Todo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTodoByID", ctx, id, userID)
+	ret0, _ := ret[0].(domain.Todo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTodoByID indicates an expected call of GetTodoByID.
+func (mr *MockTodoMockRecorder) GetTodoByID(ctx, id, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTodoByID", reflect.TypeOf((*MockTodo)(nil).DeleteTodoByID), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTodoByID", reflect.TypeOf((*MockTodo)(nil).GetTodoByID), ctx, id, userID)
 }
 
-// GetTodosByStatus mocks base method.
-func (m *MockTodo) GetTodosByStatus(ctx context.Context, status string, userID primitive.ObjectID) ([]domain.Todo, error) {
+// GetTodoItems mocks base method.
+func (m *MockTodo) GetTodoItems(ctx context.Context, todoID, userID string) ([]domain.TodoItem, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTodosByStatus", ctx, status, userID)
-	ret0, _ := ret[0].([]domain.Todo)
+	ret := m.ctrl.Call(m, "GetTodoItems", ctx, todoID, userID)
+	ret0, _ := ret[0].([]domain.TodoItem)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetTodosByStatus indicates an expected call of GetTodosByStatus.
-func (mr *MockTodoMockRecorder) GetTodosByStatus(ctx, status, userID interface{}) *gomock.Call {
+// GetTodoItems indicates an expected call of GetTodoItems.
+func (mr *MockTodoMockRecorder) GetTodoItems(ctx, todoID, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTodosByStatus", reflect.TypeOf((*MockTodo)(nil).GetTodosByStatus), ctx, status, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTodoItems", reflect.TypeOf((*MockTodo)(nil).GetTodoItems), ctx, todoID, userID)
+}
+
+// GetTodos mocks base method.
+func (m *MockTodo) GetTodos(ctx context.Context, userID string, inp domain.TodoListRequest) (domain.TodoPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTodos", ctx, userID, inp)
+	ret0, _ := ret[0].(domain.TodoPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTodos indicates an expected call of GetTodos.
+func (mr *MockTodoMockRecorder) GetTodos(ctx, userID, inp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTodos", reflect.TypeOf((*MockTodo)(nil).GetTodos), ctx, userID, inp)
+}
+
+// ReorderTodoItems mocks base method.
+func (m *MockTodo) ReorderTodoItems(ctx context.Context, todoID, userID string, ids []string) ([]domain.TodoItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReorderTodoItems", ctx, todoID, userID, ids)
+	ret0, _ := ret[0].([]domain.TodoItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReorderTodoItems indicates an expected call of ReorderTodoItems.
+func (mr *MockTodoMockRecorder) ReorderTodoItems(ctx, todoID, userID, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReorderTodoItems", reflect.TypeOf((*MockTodo)(nil).ReorderTodoItems), ctx, todoID, userID, ids)
+}
+
+// SearchTodos mocks base method.
+func (m *MockTodo) SearchTodos(ctx context.Context, userID string, inp domain.SearchRequest) ([]domain.SearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchTodos", ctx, userID, inp)
+	ret0, _ := ret[0].([]domain.SearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchTodos indicates an expected call of SearchTodos.
+func (mr *MockTodoMockRecorder) SearchTodos(ctx, userID, inp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchTodos", reflect.TypeOf((*MockTodo)(nil).SearchTodos), ctx, userID, inp)
+}
+
+// ToggleTodoItem mocks base method.
+func (m *MockTodo) ToggleTodoItem(ctx context.Context, todoID, itemID, userID string) (domain.TodoItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ToggleTodoItem", ctx, todoID, itemID, userID)
+	ret0, _ := ret[0].(domain.TodoItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ToggleTodoItem indicates an expected call of ToggleTodoItem.
+func (mr *MockTodoMockRecorder) ToggleTodoItem(ctx, todoID, itemID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ToggleTodoItem", reflect.TypeOf((*MockTodo)(nil).ToggleTodoItem), ctx, todoID, itemID, userID)
 }
 
 // UpdateTodo mocks base method.
-func (m *MockTodo) UpdateTodo(ctx context.Context, todo domain.Todo) (domain.Todo, error) {
+func (m *MockTodo) UpdateTodo(ctx context.Context, id, userID string, inp domain.TodoRequest) (domain.Todo, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateTodo", ctx, todo)
+	ret := m.ctrl.Call(m, "UpdateTodo", ctx, id, userID, inp)
 	ret0, _ := ret[0].(domain.Todo)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // UpdateTodo indicates an expected call of UpdateTodo.
-func (mr *MockTodoMockRecorder) UpdateTodo(ctx, todo interface{}) *gomock.Call {
+func (mr *MockTodoMockRecorder) UpdateTodo(ctx, id, userID, inp interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTodo", reflect.TypeOf((*MockTodo)(nil).UpdateTodo), ctx, todo)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTodo", reflect.TypeOf((*MockTodo)(nil).UpdateTodo), ctx, id, userID, inp)
 }
 
 // UpdateTodoDoneByID mocks base method.
-func (m *MockTodo) UpdateTodoDoneByID(ctx context.Context, id string, userID primitive.ObjectID) (domain.Todo, error) {
+func (m *MockTodo) UpdateTodoDoneByID(ctx context.Context, id, userID string) (domain.Todo, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "UpdateTodoDoneByID", ctx, id, userID)
 	ret0, _ := ret[0].(domain.Todo)
@@ -177,3 +674,100 @@ func (mr *MockTodoMockRecorder) UpdateTodoDoneByID(ctx, id, userID interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTodoDoneByID", reflect.TypeOf((*MockTodo)(nil).UpdateTodoDoneByID), ctx, id, userID)
 }
+
+// MockCalendars is a mock of Calendars interface.
+type MockCalendars struct {
+	ctrl     *gomock.Controller
+	recorder *MockCalendarsMockRecorder
+}
+
+// MockCalendarsMockRecorder is the mock recorder for MockCalendars.
+type MockCalendarsMockRecorder struct {
+	mock *MockCalendars
+}
+
+// NewMockCalendars creates a new mock instance.
+func NewMockCalendars(ctrl *gomock.Controller) *MockCalendars {
+	mock := &MockCalendars{ctrl: ctrl}
+	mock.recorder = &MockCalendarsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCalendars) EXPECT() *MockCalendarsMockRecorder {
+	return m.recorder
+}
+
+// DeleteCalendar mocks base method.
+func (m *MockCalendars) DeleteCalendar(ctx context.Context, id, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCalendar", ctx, id, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCalendar indicates an expected call of DeleteCalendar.
+func (mr *MockCalendarsMockRecorder) DeleteCalendar(ctx, id, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCalendar", reflect.TypeOf((*MockCalendars)(nil).DeleteCalendar), ctx, id, userID)
+}
+
+// GetCalendar mocks base method.
+func (m *MockCalendars) GetCalendar(ctx context.Context, id, userID string) (domain.Calendar, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCalendar", ctx, id, userID)
+	ret0, _ := ret[0].(domain.Calendar)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCalendar indicates an expected call of GetCalendar.
+func (mr *MockCalendarsMockRecorder) GetCalendar(ctx, id, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCalendar", reflect.TypeOf((*MockCalendars)(nil).GetCalendar), ctx, id, userID)
+}
+
+// GetCalendars mocks base method.
+func (m *MockCalendars) GetCalendars(ctx context.Context, userID string) ([]domain.Calendar, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCalendars", ctx, userID)
+	ret0, _ := ret[0].([]domain.Calendar)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCalendars indicates an expected call of GetCalendars.
+func (mr *MockCalendarsMockRecorder) GetCalendars(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCalendars", reflect.TypeOf((*MockCalendars)(nil).GetCalendars), ctx, userID)
+}
+
+// SelectCalendar mocks base method.
+func (m *MockCalendars) SelectCalendar(ctx context.Context, userID, id string) (domain.Calendar, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectCalendar", ctx, userID, id)
+	ret0, _ := ret[0].(domain.Calendar)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectCalendar indicates an expected call of SelectCalendar.
+func (mr *MockCalendarsMockRecorder) SelectCalendar(ctx, userID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectCalendar", reflect.TypeOf((*MockCalendars)(nil).SelectCalendar), ctx, userID, id)
+}
+
+// UploadCalendar mocks base method.
+func (m *MockCalendars) UploadCalendar(ctx context.Context, userID, name string, data []byte) (domain.Calendar, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadCalendar", ctx, userID, name, data)
+	ret0, _ := ret[0].(domain.Calendar)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UploadCalendar indicates an expected call of UploadCalendar.
+func (mr *MockCalendarsMockRecorder) UploadCalendar(ctx, userID, name, data interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadCalendar", reflect.TypeOf((*MockCalendars)(nil).UploadCalendar), ctx, userID, name, data)
+}
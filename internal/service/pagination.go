@@ -0,0 +1,181 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+)
+
+// newTodoFilter validates the listing parameters and fills in the defaults:
+// active todos, sorted by activeAt ascending, DefaultTodoLimit per page.
+// Listing only active todos keeps the original meaning of "active": todos
+// that have not become active by now are left out. The activeAt range is a
+// pair of dates of the user, loc is their timezone.
+func newTodoFilter(userID string, inp domain.TodoListRequest, now time.Time, loc *time.Location) (domain.TodoFilter, error) {
+	filter := domain.TodoFilter{
+		UserID: userID,
+		Title:  strings.TrimSpace(inp.Title),
+		Sort:   inp.Sort,
+		Order:  inp.Order,
+		Limit:  inp.Limit,
+	}
+
+	statuses, err := parseStatuses(inp.Status)
+	if err != nil {
+		return domain.TodoFilter{}, err
+	}
+	filter.Statuses = statuses
+
+	if inp.ActiveFrom != "" {
+		from, err := time.ParseInLocation(domain.Format, inp.ActiveFrom, loc)
+		if err != nil {
+			return domain.TodoFilter{}, domain.ErrIncorrectDateFormat
+		}
+		filter.ActiveFrom = from
+	}
+
+	if inp.ActiveTo != "" {
+		to, err := time.ParseInLocation(domain.Format, inp.ActiveTo, loc)
+		if err != nil {
+			return domain.TodoFilter{}, domain.ErrIncorrectDateFormat
+		}
+		filter.ActiveBefore = to.AddDate(0, 0, 1)
+	}
+
+	if !filter.ActiveFrom.IsZero() && !filter.ActiveBefore.IsZero() && !filter.ActiveFrom.Before(filter.ActiveBefore) {
+		return domain.TodoFilter{}, domain.ErrInvalidFilter
+	}
+
+	if len(statuses) == 1 && statuses[0] == domain.Active {
+		if filter.ActiveBefore.IsZero() || filter.ActiveBefore.After(now) {
+			filter.ActiveBefore = now
+		}
+	}
+
+	if len(filter.Title) > 200 {
+		return domain.TodoFilter{}, domain.ErrHeaderLength
+	}
+
+	tags, err := normalizeTags(splitValues(inp.Tag))
+	if err != nil {
+		return domain.TodoFilter{}, domain.ErrInvalidFilter
+	}
+	if len(tags) > 0 {
+		filter.Tags = tags
+	}
+
+	for _, priority := range splitValues(inp.Priority) {
+		switch priority {
+		case domain.PriorityLow, domain.PriorityMedium, domain.PriorityHigh:
+		default:
+			return domain.TodoFilter{}, domain.ErrInvalidFilter
+		}
+		filter.Priorities = append(filter.Priorities, priority)
+	}
+
+	switch filter.Sort {
+	case "":
+		filter.Sort = domain.SortActiveAt
+	case domain.SortActiveAt, domain.SortTitle:
+	default:
+		return domain.TodoFilter{}, domain.ErrInvalidFilter
+	}
+
+	switch filter.Order {
+	case "":
+		filter.Order = domain.OrderAsc
+	case domain.OrderAsc, domain.OrderDesc:
+	default:
+		return domain.TodoFilter{}, domain.ErrInvalidFilter
+	}
+
+	switch {
+	case filter.Limit < 0:
+		return domain.TodoFilter{}, domain.ErrInvalidFilter
+	case filter.Limit == 0:
+		filter.Limit = domain.DefaultTodoLimit
+	case filter.Limit > domain.MaxTodoLimit:
+		filter.Limit = domain.MaxTodoLimit
+	}
+
+	if inp.Cursor != "" {
+		cursor, err := decodeCursor(inp.Cursor)
+		if err != nil {
+			return domain.TodoFilter{}, err
+		}
+
+		if cursor.Sort != filter.Sort || cursor.Order != filter.Order {
+			return domain.TodoFilter{}, domain.ErrInvalidCursor
+		}
+		filter.After = &cursor
+	}
+
+	return filter, nil
+}
+
+// parseStatuses accepts both repeated and comma separated status parameters.
+func parseStatuses(values []string) ([]string, error) {
+	statuses := splitValues(values)
+	for _, status := range statuses {
+		if status != domain.Active && status != domain.Done {
+			return nil, domain.ErrInvalidFilter
+		}
+	}
+
+	if len(statuses) == 0 {
+		statuses = []string{domain.Active}
+	}
+
+	return statuses, nil
+}
+
+// splitValues flattens repeated and comma separated query parameters,
+// dropping empty and duplicate values.
+func splitValues(values []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+
+	for _, value := range values {
+		for _, v := range strings.Split(value, ",") {
+			v = strings.TrimSpace(v)
+			if v == "" || seen[v] {
+				continue
+			}
+
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+
+	return result
+}
+
+// Cursors are opaque to clients: base64 encoded JSON of domain.TodoCursor.
+
+func encodeCursor(filter domain.TodoFilter, last domain.Todo) string {
+	data, _ := json.Marshal(domain.TodoCursor{
+		Sort:  filter.Sort,
+		Order: filter.Order,
+		Value: filter.SortValue(last),
+		ID:    last.ID,
+	})
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(cursor string) (domain.TodoCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return domain.TodoCursor{}, domain.ErrInvalidCursor
+	}
+
+	var c domain.TodoCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return domain.TodoCursor{}, domain.ErrInvalidCursor
+	}
+
+	return c, nil
+}
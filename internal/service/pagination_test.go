@@ -0,0 +1,189 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTodoFilter(t *testing.T) {
+	now := time.Date(2030, time.March, 10, 12, 0, 0, 0, time.UTC)
+	loc := time.FixedZone("UTC+6", 6*60*60)
+	userID := utils.RandomString(24)
+
+	tests := []struct {
+		name  string
+		inp   domain.TodoListRequest
+		check func(filter domain.TodoFilter, err error)
+	}{
+		{
+			name: "defaults",
+			inp:  domain.TodoListRequest{},
+			check: func(filter domain.TodoFilter, err error) {
+				require.NoError(t, err)
+				require.Equal(t, domain.TodoFilter{
+					UserID:       userID,
+					Statuses:     []string{domain.Active},
+					ActiveBefore: now,
+					Sort:         domain.SortActiveAt,
+					Order:        domain.OrderAsc,
+					Limit:        domain.DefaultTodoLimit,
+				}, filter)
+			},
+		},
+		{
+			name: "active range is clamped to now",
+			inp:  domain.TodoListRequest{ActiveFrom: "2030-03-01", ActiveTo: "2030-04-01"},
+			check: func(filter domain.TodoFilter, err error) {
+				require.NoError(t, err)
+				require.Equal(t, time.Date(2030, time.March, 1, 0, 0, 0, 0, loc), filter.ActiveFrom)
+				require.Equal(t, now, filter.ActiveBefore)
+			},
+		},
+		{
+			name: "dates are those of the user",
+			inp:  domain.TodoListRequest{Status: []string{domain.Done}, ActiveFrom: "2030-03-05", ActiveTo: "2030-03-05"},
+			check: func(filter domain.TodoFilter, err error) {
+				require.NoError(t, err)
+				require.True(t, time.Date(2030, time.March, 4, 18, 0, 0, 0, time.UTC).Equal(filter.ActiveFrom))
+				require.True(t, time.Date(2030, time.March, 5, 18, 0, 0, 0, time.UTC).Equal(filter.ActiveBefore))
+			},
+		},
+		{
+			name: "status set is not clamped",
+			inp:  domain.TodoListRequest{Status: []string{"done,active", "done"}, ActiveTo: "2030-04-01"},
+			check: func(filter domain.TodoFilter, err error) {
+				require.NoError(t, err)
+				require.Equal(t, []string{domain.Done, domain.Active}, filter.Statuses)
+				require.Equal(t, time.Date(2030, time.April, 2, 0, 0, 0, 0, loc), filter.ActiveBefore)
+			},
+		},
+		{
+			name: "limit is capped",
+			inp:  domain.TodoListRequest{Limit: 1000, Sort: domain.SortTitle, Order: domain.OrderDesc},
+			check: func(filter domain.TodoFilter, err error) {
+				require.NoError(t, err)
+				require.Equal(t, domain.MaxTodoLimit, filter.Limit)
+				require.Equal(t, domain.SortTitle, filter.Sort)
+				require.Equal(t, domain.OrderDesc, filter.Order)
+			},
+		},
+		{
+			name: "unknown status",
+			inp:  domain.TodoListRequest{Status: []string{"archived"}},
+			check: func(filter domain.TodoFilter, err error) {
+				require.Equal(t, domain.ErrInvalidFilter, err)
+			},
+		},
+		{
+			name: "tags and priorities",
+			inp:  domain.TodoListRequest{Tag: []string{"Work,#home", "work"}, Priority: []string{"high,low"}},
+			check: func(filter domain.TodoFilter, err error) {
+				require.NoError(t, err)
+				require.Equal(t, []string{"work", "home"}, filter.Tags)
+				require.Equal(t, []string{domain.PriorityHigh, domain.PriorityLow}, filter.Priorities)
+			},
+		},
+		{
+			name: "unknown priority",
+			inp:  domain.TodoListRequest{Priority: []string{"urgent"}},
+			check: func(filter domain.TodoFilter, err error) {
+				require.Equal(t, domain.ErrInvalidFilter, err)
+			},
+		},
+		{
+			name: "invalid tag",
+			inp:  domain.TodoListRequest{Tag: []string{"a b"}},
+			check: func(filter domain.TodoFilter, err error) {
+				require.Equal(t, domain.ErrInvalidFilter, err)
+			},
+		},
+		{
+			name: "unknown sort",
+			inp:  domain.TodoListRequest{Sort: "author"},
+			check: func(filter domain.TodoFilter, err error) {
+				require.Equal(t, domain.ErrInvalidFilter, err)
+			},
+		},
+		{
+			name: "unknown order",
+			inp:  domain.TodoListRequest{Order: "up"},
+			check: func(filter domain.TodoFilter, err error) {
+				require.Equal(t, domain.ErrInvalidFilter, err)
+			},
+		},
+		{
+			name: "negative limit",
+			inp:  domain.TodoListRequest{Limit: -1},
+			check: func(filter domain.TodoFilter, err error) {
+				require.Equal(t, domain.ErrInvalidFilter, err)
+			},
+		},
+		{
+			name: "incorrect date",
+			inp:  domain.TodoListRequest{ActiveFrom: "10.03.2030"},
+			check: func(filter domain.TodoFilter, err error) {
+				require.Equal(t, domain.ErrIncorrectDateFormat, err)
+			},
+		},
+		{
+			name: "reversed range",
+			inp:  domain.TodoListRequest{ActiveFrom: "2030-03-05", ActiveTo: "2030-03-01"},
+			check: func(filter domain.TodoFilter, err error) {
+				require.Equal(t, domain.ErrInvalidFilter, err)
+			},
+		},
+		{
+			name: "cursor of another sort",
+			inp: domain.TodoListRequest{
+				Sort: domain.SortTitle,
+				Cursor: encodeCursor(domain.TodoFilter{Sort: domain.SortActiveAt, Order: domain.OrderAsc}, domain.Todo{
+					ID:       utils.RandomString(24),
+					ActiveAt: time.Date(2030, time.March, 1, 0, 0, 0, 0, time.UTC),
+				}),
+			},
+			check: func(filter domain.TodoFilter, err error) {
+				require.Equal(t, domain.ErrInvalidCursor, err)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := newTodoFilter(userID, tt.inp, now, loc)
+			tt.check(filter, err)
+		})
+	}
+}
+
+func TestCursor_RoundTrip(t *testing.T) {
+	filter := domain.TodoFilter{Sort: domain.SortTitle, Order: domain.OrderDesc}
+	todo := domain.Todo{
+		ID:    utils.RandomString(24),
+		Title: utils.RandomString(10),
+	}
+
+	cursor, err := decodeCursor(encodeCursor(filter, todo))
+	require.NoError(t, err)
+	require.Equal(t, domain.TodoCursor{
+		Sort:  domain.SortTitle,
+		Order: domain.OrderDesc,
+		Value: todo.Title,
+		ID:    todo.ID,
+	}, cursor)
+
+	filter = domain.TodoFilter{Sort: domain.SortActiveAt, Order: domain.OrderAsc}
+	todo.ActiveAt = time.Date(2030, time.March, 1, 18, 0, 0, 0, time.UTC)
+
+	cursor, err = decodeCursor(encodeCursor(filter, todo))
+	require.NoError(t, err)
+
+	activeAt, err := cursor.ActiveAt()
+	require.NoError(t, err)
+	require.True(t, todo.ActiveAt.Equal(activeAt))
+
+	_, err = decodeCursor("not a cursor")
+	require.Equal(t, domain.ErrInvalidCursor, err)
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	netmail "net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/internal/i18n"
+	"github.com/begenov/region-llc-task/pkg/auth"
+	"github.com/begenov/region-llc-task/pkg/logger"
+	"github.com/begenov/region-llc-task/pkg/mail"
+)
+
+const (
+	// passwordResetKey holds the user of a reset link by the hash of its
+	// token, until the link expires.
+	passwordResetKey = "password-reset:"
+	// passwordResetUserKey holds the hash of the latest reset token of a
+	// user, a new link replaces the ones sent before.
+	passwordResetUserKey = "password-reset-user:"
+
+	defaultPasswordResetTTL = time.Hour
+)
+
+// PasswordResetPolicy.URL is the page of the client where a new password is
+// chosen, reset links are the URL with the token in the token query
+// parameter. TokenTTL is how long a reset link works.
+type PasswordResetPolicy struct {
+	URL      string
+	TokenTTL time.Duration
+}
+
+// RequestPasswordReset emails a reset link to the user with the email, in
+// the language of the user or else of the request. An unknown email is not
+// reported, the same response does not tell which emails are registered.
+func (s *UserService) RequestPasswordReset(ctx context.Context, email string, language string) error {
+	if _, err := netmail.ParseAddress(email); err != nil {
+		return domain.ErrIncorrectEmailAddress
+	}
+
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		logger.Errorf("s.userRepo.GetUserByEmail(): %v", err)
+		return err
+	}
+
+	token, err := auth.NewToken(auth.PasswordResetTokenPrefix)
+	if err != nil {
+		logger.Errorf("auth.NewToken(): %v", err)
+		return err
+	}
+
+	link, err := tokenLink(s.reset.URL, token)
+	if err != nil {
+		logger.Errorf("tokenLink(): %v", err)
+		return err
+	}
+
+	tokenHash := auth.HashToken(token)
+	if err := s.redisRepo.Set(passwordResetKey+tokenHash, user.ID, s.reset.TokenTTL); err != nil {
+		logger.Errorf("s.redisRepo.Set(): %v", err)
+		return err
+	}
+
+	if err := s.redisRepo.Set(passwordResetUserKey+user.ID, tokenHash, s.reset.TokenTTL); err != nil {
+		logger.Errorf("s.redisRepo.Set(): %v", err)
+		return err
+	}
+
+	if user.Language != "" {
+		language = user.Language
+	}
+
+	return s.sendLink(ctx, user.Email, language, "password_reset", link)
+}
+
+// ResetPassword sets the password of the user of the reset token. A token
+// works once, and only the latest one sent to the user does. Every session
+// of the user is ended, the access tokens issued for them are revoked.
+func (s *UserService) ResetPassword(ctx context.Context, inp domain.PasswordReset) error {
+	if !auth.VerifyToken(inp.Token, auth.PasswordResetTokenPrefix) {
+		return domain.ErrInvalidResetToken
+	}
+
+	if len(inp.Password) < 6 {
+		return domain.ErrIncorrectPassword
+	}
+
+	tokenHash := auth.HashToken(inp.Token)
+	userID, err := s.redisRepo.Get(passwordResetKey + tokenHash)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.ErrInvalidResetToken
+	}
+	if err != nil {
+		logger.Errorf("s.redisRepo.Get(): %v", err)
+		return err
+	}
+
+	latest, err := s.redisRepo.Get(passwordResetUserKey + userID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		logger.Errorf("s.redisRepo.Get(): %v", err)
+		return err
+	}
+
+	if err := s.redisRepo.Delete(passwordResetKey + tokenHash); err != nil {
+		logger.Errorf("s.redisRepo.Delete(): %v", err)
+		return err
+	}
+
+	if latest != tokenHash {
+		return domain.ErrInvalidResetToken
+	}
+
+	if err := s.redisRepo.Delete(passwordResetUserKey + userID); err != nil {
+		logger.Errorf("s.redisRepo.Delete(): %v", err)
+		return err
+	}
+
+	passwordHash, err := s.hash.GenerateFromPassword(inp.Password)
+	if err != nil {
+		logger.Errorf("s.hash.GenerateFromPassword(): %v", err)
+		return domain.ErrInternalServer
+	}
+
+	if err := s.userRepo.SetPassword(ctx, userID, passwordHash); err != nil {
+		logger.Errorf("s.userRepo.SetPassword(): %v", err)
+		return err
+	}
+
+	return s.LogoutEverywhere(ctx, userID)
+}
+
+// tokenLink is the page at base with the token in the token query parameter.
+func tokenLink(base string, token string) (string, error) {
+	link, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	return link.String(), nil
+}
+
+// sendLink emails the link in the mail.<name> message of the language.
+func (s *UserService) sendLink(ctx context.Context, to string, language string, name string, link string) error {
+	err := s.mailer.Send(ctx, mail.Message{
+		To:      to,
+		Subject: i18n.Translate(language, "mail."+name+".subject"),
+		Body:    strings.ReplaceAll(i18n.Translate(language, "mail."+name+".body"), "{url}", link),
+	})
+	if err != nil {
+		logger.Errorf("s.mailer.Send(): %v", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/auth"
+	"github.com/begenov/region-llc-task/pkg/logger"
+)
+
+const (
+	maxTokenNameLength = 100
+
+	// lastUsedPrecision is how stale LastUsedAt of a personal token may get,
+	// so that every request of a script does not write to the storage.
+	lastUsedPrecision = time.Minute
+)
+
+// CreatePersonalToken creates a personal token of the user with scopes out of
+// domain.PersonalTokenScopes. The token is returned only here, it is stored
+// by its hash.
+func (s *UserService) CreatePersonalToken(ctx context.Context, userID string, inp domain.PersonalTokenRequest) (domain.NewPersonalToken, error) {
+	name := strings.TrimSpace(inp.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxTokenNameLength {
+		return domain.NewPersonalToken{}, domain.ErrInvalidTokenName
+	}
+
+	scopes, err := validatePersonalTokenScopes(inp.Scopes)
+	if err != nil {
+		logger.Errorf("validatePersonalTokenScopes(): %v", err)
+		return domain.NewPersonalToken{}, err
+	}
+
+	now := time.Now()
+	if inp.ExpiresAt != nil && !inp.ExpiresAt.After(now) {
+		return domain.NewPersonalToken{}, domain.ErrInvalidExpiry
+	}
+
+	token, err := auth.NewToken(auth.PersonalTokenPrefix)
+	if err != nil {
+		logger.Errorf("auth.NewToken(): %v", err)
+		return domain.NewPersonalToken{}, err
+	}
+
+	personalToken, err := s.personalTokenRepo.Create(ctx, domain.PersonalToken{
+		UserID:    userID,
+		TokenHash: auth.HashToken(token),
+		Name:      name,
+		Scopes:    scopes,
+		CreatedAt: now,
+		ExpiresAt: inp.ExpiresAt,
+	})
+	if err != nil {
+		logger.Errorf("s.personalTokenRepo.Create(): %v", err)
+		return domain.NewPersonalToken{}, err
+	}
+
+	return domain.NewPersonalToken{PersonalToken: personalToken, Token: token}, nil
+}
+
+// GetPersonalTokens lists the personal tokens of the user that have not
+// expired, the most recently created first.
+func (s *UserService) GetPersonalTokens(ctx context.Context, userID string) ([]domain.PersonalToken, error) {
+	tokens, err := s.personalTokenRepo.GetTokens(ctx, userID)
+	if err != nil {
+		logger.Errorf("s.personalTokenRepo.GetTokens(): %v", err)
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// RevokePersonalToken deletes a personal token of the user, ErrNotFound for
+// the token of another user. The token stops working right away.
+func (s *UserService) RevokePersonalToken(ctx context.Context, userID string, id string) error {
+	if err := s.personalTokenRepo.DeleteToken(ctx, id, userID); err != nil {
+		logger.Errorf("s.personalTokenRepo.DeleteToken(): %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// CheckPersonalToken authenticates a request by a personal token and records
+// its use. A malformed, unknown, revoked or expired token is reported as
+// ErrInvalidAuthHeader, like an invalid access token.
+func (s *UserService) CheckPersonalToken(ctx context.Context, token string) (domain.Principal, error) {
+	if !auth.VerifyToken(token, auth.PersonalTokenPrefix) {
+		return domain.Principal{}, domain.ErrInvalidAuthHeader
+	}
+
+	personalToken, err := s.personalTokenRepo.GetByTokenHash(ctx, auth.HashToken(token))
+	if err != nil {
+		logger.Errorf("s.personalTokenRepo.GetByTokenHash(): %v", err)
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.Principal{}, domain.ErrInvalidAuthHeader
+		}
+
+		return domain.Principal{}, err
+	}
+
+	now := time.Now()
+	if personalToken.LastUsedAt == nil || now.Sub(*personalToken.LastUsedAt) >= lastUsedPrecision {
+		// A failed update only leaves LastUsedAt stale, the request goes on.
+		if err := s.personalTokenRepo.SetLastUsed(ctx, personalToken.ID, now); err != nil {
+			logger.Errorf("s.personalTokenRepo.SetLastUsed(): %v", err)
+		}
+	}
+
+	return domain.Principal{
+		UserID: personalToken.UserID,
+		Scopes: personalToken.Scopes,
+	}, nil
+}
+
+// validatePersonalTokenScopes returns the scopes without repeats, each must
+// be one of domain.PersonalTokenScopes.
+func validatePersonalTokenScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, domain.ErrInvalidScope
+	}
+
+	res := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !containsScope(domain.PersonalTokenScopes, scope) {
+			return nil, domain.ErrInvalidScope
+		}
+
+		if !containsScope(res, scope) {
+			res = append(res, scope)
+		}
+	}
+
+	return res, nil
+}
+
+func containsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"github.com/begenov/region-llc-task/internal/domain"
+)
+
+// Action is an operation a user performs on a todo.
+type Action string
+
+const (
+	ActionRead   Action = "read"
+	ActionUpdate Action = "update"
+	ActionDone   Action = "done"
+	ActionDelete Action = "delete"
+)
+
+// Role is the relation between a user and a todo. Only the owner exists for
+// now; shared access adds roles with a narrower set of actions.
+type Role string
+
+const (
+	RoleNone  Role = ""
+	RoleOwner Role = "owner"
+)
+
+var rolePermissions = map[Role][]Action{
+	RoleOwner: {ActionRead, ActionUpdate, ActionDone, ActionDelete},
+}
+
+// Policy decides whether a user may perform an action on a todo.
+type Policy interface {
+	Authorize(userID string, todo domain.Todo, action Action) error
+}
+
+// RolePolicy resolves the role of the user for the todo and checks it
+// against rolePermissions.
+type RolePolicy struct {
+	roleOf func(userID string, todo domain.Todo) Role
+}
+
+// NewOwnerPolicy returns a policy that grants every action to the author of
+// the todo and nothing to anyone else.
+func NewOwnerPolicy() *RolePolicy {
+	return &RolePolicy{
+		roleOf: ownerRole,
+	}
+}
+
+func (p *RolePolicy) Authorize(userID string, todo domain.Todo, action Action) error {
+	if userID == "" {
+		return domain.ErrForbidden
+	}
+
+	for _, allowed := range rolePermissions[p.roleOf(userID, todo)] {
+		if allowed == action {
+			return nil
+		}
+	}
+
+	return domain.ErrForbidden
+}
+
+func ownerRole(userID string, todo domain.Todo) Role {
+	if todo.UserID == userID {
+		return RoleOwner
+	}
+
+	return RoleNone
+}
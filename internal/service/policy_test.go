@@ -0,0 +1,59 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOwnerPolicy_Authorize(t *testing.T) {
+	policy := NewOwnerPolicy()
+
+	owner := utils.RandomString(24)
+	todo := domain.Todo{
+		ID:     utils.RandomString(24),
+		UserID: owner,
+	}
+
+	actions := []Action{ActionRead, ActionUpdate, ActionDone, ActionDelete}
+
+	tests := []struct {
+		name   string
+		userID string
+		want   error
+	}{
+		{
+			name:   "owner",
+			userID: owner,
+			want:   nil,
+		},
+		{
+			name:   "other user",
+			userID: utils.RandomString(24),
+			want:   domain.ErrForbidden,
+		},
+		{
+			name:   "anonymous",
+			userID: "",
+			want:   domain.ErrForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, action := range actions {
+				err := policy.Authorize(tt.userID, todo, action)
+				require.Equal(t, tt.want, err, action)
+			}
+		})
+	}
+}
+
+func TestOwnerPolicy_UnknownAction(t *testing.T) {
+	policy := NewOwnerPolicy()
+
+	userID := utils.RandomString(24)
+	err := policy.Authorize(userID, domain.Todo{UserID: userID}, Action("share"))
+	require.Equal(t, domain.ErrForbidden, err)
+}
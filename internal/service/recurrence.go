@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/logger"
+)
+
+// markDone marks an active todo done and, when it recurs, creates its next
+// occurrence. The next occurrence is worked out first so that a todo is not
+// left done with its series broken.
+func (s *TodoService) markDone(ctx context.Context, todo domain.Todo) (domain.Todo, error) {
+	next, ok, err := s.nextOccurrence(ctx, todo, time.Now())
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	done, err := s.todoRepo.UpdateTodoDoneByID(ctx, todo.ID, todo.UserID)
+	if err != nil {
+		logger.Errorf("s.todoRepo.UpdateTodoDoneByID(): %v", err)
+		return domain.Todo{}, err
+	}
+
+	// A repository may return the todo as it was before the update.
+	if done.Status == domain.Active {
+		done.Status = domain.Done
+	}
+
+	if !ok {
+		return done, nil
+	}
+
+	next, err = s.todoRepo.Create(ctx, next)
+	if err != nil {
+		logger.Errorf("s.todoRepo.Create(): %v", err)
+		return domain.Todo{}, err
+	}
+
+	items, err := s.itemRepo.GetItems(ctx, todo.ID)
+	if err != nil {
+		logger.Errorf("s.itemRepo.GetItems(): %v", err)
+		return domain.Todo{}, err
+	}
+
+	for _, item := range items {
+		if _, err := s.itemRepo.Create(ctx, domain.TodoItem{TodoID: next.ID, Title: item.Title}); err != nil {
+			logger.Errorf("s.itemRepo.Create(): %v", err)
+			return domain.Todo{}, err
+		}
+	}
+
+	return done, nil
+}
+
+// nextOccurrence builds the todo that follows an active recurring one, with
+// the checklist left to be copied by the caller. It reports false for a
+// one-off todo and once the series has ended.
+//
+// Dates are those of the owner's timezone and an occurrence becomes active
+// at the same time of day as the previous one. Occurrences of days that have
+// already passed are skipped, so that completing a daily todo late does not
+// leave a trail of overdue copies. A skipped occurrence still counts towards
+// COUNT.
+//
+// Titles are unique per user and the completed occurrence keeps its own, so
+// the next one is dated: "Stand-up (2030-01-02)". The series title is
+// recovered by dropping the date of the previous occurrence.
+func (s *TodoService) nextOccurrence(ctx context.Context, todo domain.Todo, now time.Time) (domain.Todo, bool, error) {
+	if todo.Recurrence == "" || todo.Status != domain.Active {
+		return domain.Todo{}, false, nil
+	}
+
+	rule, err := domain.ParseRecurrence(todo.Recurrence)
+	if err != nil {
+		logger.Errorf("domain.ParseRecurrence(): %v", err)
+		return domain.Todo{}, false, err
+	}
+
+	loc, err := s.location(ctx, todo.UserID)
+	if err != nil {
+		return domain.Todo{}, false, err
+	}
+
+	occurrence := todo.Occurrence
+	if occurrence < 1 {
+		occurrence = 1
+	}
+
+	// The rule steps over calendar dates, kept as UTC midnights.
+	activeAt := todo.ActiveAt.In(loc)
+	date := func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	today := date(now.In(loc))
+	next := date(activeAt)
+	for {
+		var ok bool
+		next, ok = rule.Next(next, occurrence)
+		if !ok {
+			return domain.Todo{}, false, nil
+		}
+		occurrence++
+
+		if !next.Before(today) {
+			break
+		}
+	}
+
+	nextActiveAt := time.Date(next.Year(), next.Month(), next.Day(),
+		activeAt.Hour(), activeAt.Minute(), activeAt.Second(), 0, loc).UTC()
+
+	title, err := s.occurrenceTitle(ctx, todo, activeAt.Format(domain.Format), next.Format(domain.Format))
+	if err != nil {
+		return domain.Todo{}, false, err
+	}
+
+	following := domain.Todo{
+		UserID:      todo.UserID,
+		Title:       title,
+		Description: todo.Description,
+		Priority:    todo.Priority,
+		Tags:        todo.Tags,
+		ActiveAt:    nextActiveAt,
+		Recurrence:  todo.Recurrence,
+		Occurrence:  occurrence,
+		Author:      todo.Author,
+		Status:      domain.Active,
+	}
+
+	if todo.DueAt != nil {
+		due := todo.DueAt.Add(nextActiveAt.Sub(todo.ActiveAt))
+		following.DueAt = &due
+	}
+
+	return following, true, nil
+}
+
+// occurrenceTitle picks the title of the occurrence that follows todo, which
+// is dated prev, on date next: the series title when it is free, the dated
+// one otherwise.
+func (s *TodoService) occurrenceTitle(ctx context.Context, todo domain.Todo, prev string, next string) (string, error) {
+	base := strings.TrimSuffix(todo.Title, fmt.Sprintf(" (%s)", prev))
+
+	for _, title := range []string{base, fmt.Sprintf("%s (%s)", base, next)} {
+		err := s.checkTitle(ctx, todo.UserID, title)
+		if err == nil {
+			return title, nil
+		}
+
+		if err != domain.ErrTitleAlreadyExists {
+			logger.Errorf("s.checkTitle(): %v", err)
+			return "", err
+		}
+	}
+
+	return "", domain.ErrTitleAlreadyExists
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	mocksRepo "github.com/begenov/region-llc-task/internal/repository/mocks"
+	"github.com/begenov/region-llc-task/pkg/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTodoService_UpdateTodoDoneByIDRecurring(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Almaty")
+	require.NoError(t, err)
+
+	// Half past midnight on Monday in Almaty is still Sunday in UTC.
+	activeAt := time.Date(2099, time.January, 5, 0, 30, 0, 0, loc).UTC()
+	due := time.Date(2099, time.January, 5, 18, 0, 0, 0, loc).UTC()
+
+	weekly := func() domain.Todo {
+		return domain.Todo{
+			ID:         utils.RandomString(24),
+			UserID:     utils.RandomString(24),
+			Title:      "Weekly report",
+			Priority:   domain.PriorityHigh,
+			Tags:       []string{"work"},
+			ActiveAt:   activeAt,
+			DueAt:      &due,
+			Recurrence: "FREQ=WEEKLY;BYDAY=MO,WE",
+			Occurrence: 1,
+			Author:     "author",
+			Status:     domain.Active,
+		}
+	}
+
+	tests := []struct {
+		name          string
+		todo          func() domain.Todo
+		buildStubs    func(todoRepo *mocksRepo.MockTodo, itemRepo *mocksRepo.MockTodoItems, todo domain.Todo)
+		checkResponse func(todo domain.Todo, err error)
+	}{
+		{
+			name: "OK",
+			todo: weekly,
+			buildStubs: func(todoRepo *mocksRepo.MockTodo, itemRepo *mocksRepo.MockTodoItems, todo domain.Todo) {
+				nextDue := due.AddDate(0, 0, 2)
+				next := domain.Todo{
+					UserID:     todo.UserID,
+					Title:      "Weekly report (2099-01-07)",
+					Priority:   todo.Priority,
+					Tags:       todo.Tags,
+					ActiveAt:   time.Date(2099, time.January, 7, 0, 30, 0, 0, loc).UTC(),
+					DueAt:      &nextDue,
+					Recurrence: todo.Recurrence,
+					Occurrence: 2,
+					Author:     todo.Author,
+					Status:     domain.Active,
+				}
+
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), "Weekly report", todo.UserID).Times(1).Return(int64(1), nil)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), next.Title, todo.UserID).Times(1).Return(int64(0), nil)
+				todoRepo.EXPECT().UpdateTodoDoneByID(gomock.Any(), todo.ID, todo.UserID).Times(1).Return(todo, nil)
+				todoRepo.EXPECT().Create(gomock.Any(), next).Times(1).Return(domain.Todo{ID: "next"}, nil)
+				itemRepo.EXPECT().GetItems(gomock.Any(), todo.ID).Times(1).Return([]domain.TodoItem{{ID: "1", TodoID: todo.ID, Title: "numbers", Done: true}}, nil)
+				itemRepo.EXPECT().Create(gomock.Any(), domain.TodoItem{TodoID: "next", Title: "numbers"}).Times(1)
+			},
+			checkResponse: func(todo domain.Todo, err error) {
+				require.NoError(t, err)
+				require.Equal(t, domain.Done, todo.Status)
+			},
+		},
+		{
+			name: "series title is reused when free",
+			todo: func() domain.Todo {
+				todo := weekly()
+				todo.Title = "Weekly report (2099-01-05)"
+				todo.DueAt = nil
+				return todo
+			},
+			buildStubs: func(todoRepo *mocksRepo.MockTodo, itemRepo *mocksRepo.MockTodoItems, todo domain.Todo) {
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), "Weekly report", todo.UserID).Times(1).Return(int64(0), nil)
+				todoRepo.EXPECT().UpdateTodoDoneByID(gomock.Any(), todo.ID, todo.UserID).Times(1).Return(todo, nil)
+				todoRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ interface{}, next domain.Todo) (domain.Todo, error) {
+					require.Equal(t, "Weekly report", next.Title)
+					require.Nil(t, next.DueAt)
+					return next, nil
+				})
+				itemRepo.EXPECT().GetItems(gomock.Any(), todo.ID).Times(1).Return(nil, nil)
+			},
+			checkResponse: func(todo domain.Todo, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "series ended",
+			todo: func() domain.Todo {
+				todo := weekly()
+				todo.Recurrence = "FREQ=WEEKLY;COUNT=2"
+				todo.Occurrence = 2
+				return todo
+			},
+			buildStubs: func(todoRepo *mocksRepo.MockTodo, itemRepo *mocksRepo.MockTodoItems, todo domain.Todo) {
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().UpdateTodoDoneByID(gomock.Any(), todo.ID, todo.UserID).Times(1).Return(todo, nil)
+				todoRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(todo domain.Todo, err error) {
+				require.NoError(t, err)
+				require.Equal(t, domain.Done, todo.Status)
+			},
+		},
+		{
+			name: "already done",
+			todo: func() domain.Todo {
+				todo := weekly()
+				todo.Status = domain.Done
+				return todo
+			},
+			buildStubs: func(todoRepo *mocksRepo.MockTodo, itemRepo *mocksRepo.MockTodoItems, todo domain.Todo) {
+				todoRepo.EXPECT().UpdateTodoDoneByID(gomock.Any(), todo.ID, todo.UserID).Times(1).Return(todo, nil)
+				todoRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(todo domain.Todo, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "title already exists",
+			todo: weekly,
+			buildStubs: func(todoRepo *mocksRepo.MockTodo, itemRepo *mocksRepo.MockTodoItems, todo domain.Todo) {
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), gomock.Any(), todo.UserID).Times(2).Return(int64(1), nil)
+				todoRepo.EXPECT().UpdateTodoDoneByID(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(todo domain.Todo, err error) {
+				require.Equal(t, domain.ErrTitleAlreadyExists, err)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			todoRepo := mocksRepo.NewMockTodo(ctrl)
+			itemRepo := mocksRepo.NewMockTodoItems(ctrl)
+			userRepo := mocksRepo.NewMockUsers(ctrl)
+
+			todoService := NewTodoService(todoRepo, itemRepo, userRepo, newCalendarService(t, ctrl), true, 0)
+
+			itemRepo.EXPECT().GetProgress(gomock.Any(), gomock.Any()).AnyTimes().Return(map[string]domain.Progress{}, nil)
+
+			todo := tc.todo()
+			userRepo.EXPECT().GetUserByID(gomock.Any(), todo.UserID).AnyTimes().Return(domain.User{ID: todo.UserID, Timezone: loc.String()}, nil)
+			todoRepo.EXPECT().GetTodoByID(gomock.Any(), todo.ID).Times(1).Return(todo, nil)
+			tc.buildStubs(todoRepo, itemRepo, todo)
+
+			tc.checkResponse(todoService.UpdateTodoDoneByID(ctx, todo.ID, todo.UserID))
+		})
+	}
+}
+
+func TestTodoService_nextOccurrence(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	todoRepo := mocksRepo.NewMockTodo(ctrl)
+	userRepo := mocksRepo.NewMockUsers(ctrl)
+	todoService := NewTodoService(todoRepo, mocksRepo.NewMockTodoItems(ctrl), userRepo, newCalendarService(t, ctrl), true, 0)
+
+	todoRepo.EXPECT().GetCountByTitle(gomock.Any(), "Stand-up", gomock.Any()).AnyTimes().Return(int64(1), nil)
+	todoRepo.EXPECT().GetCountByTitle(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(int64(0), nil)
+
+	loc := time.FixedZone("UTC+5", 5*60*60)
+	userRepo.EXPECT().GetUserByID(gomock.Any(), gomock.Any()).AnyTimes().Return(domain.User{Timezone: "Etc/GMT-5"}, nil)
+
+	todo := domain.Todo{
+		UserID:     utils.RandomString(24),
+		Title:      "Stand-up",
+		ActiveAt:   time.Date(2099, time.January, 1, 0, 0, 0, 0, loc).UTC(),
+		Recurrence: "FREQ=DAILY;COUNT=10",
+		Occurrence: 1,
+		Status:     domain.Active,
+	}
+	now := time.Date(2099, time.January, 5, 9, 30, 0, 0, loc)
+
+	// Completed four days late: the passed occurrences are skipped.
+	next, ok, err := todoService.nextOccurrence(ctx, todo, now)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.True(t, time.Date(2099, time.January, 5, 0, 0, 0, 0, loc).Equal(next.ActiveAt))
+	require.Equal(t, 5, next.Occurrence)
+	require.Equal(t, "Stand-up (2099-01-05)", next.Title)
+
+	// The series ends before today.
+	todo.Recurrence = "FREQ=DAILY;COUNT=3"
+	_, ok, err = todoService.nextOccurrence(ctx, todo, now)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	todo.Recurrence = "FREQ=DAILY;UNTIL=20990103"
+	_, ok, err = todoService.nextOccurrence(ctx, todo, now)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
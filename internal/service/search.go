@@ -0,0 +1,130 @@
+package service
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+const (
+	markOpen  = "<mark>"
+	markClose = "</mark>"
+
+	// Fields longer than fragmentLength runes are cut to a fragment around
+	// the first match, starting at most fragmentLead runes before it.
+	fragmentLength = 160
+	fragmentLead   = 60
+)
+
+type span struct {
+	start, end int
+}
+
+// highlight wraps every occurrence of the search terms and phrases in text
+// with <mark> tags. The text itself is HTML-escaped so that the result can be
+// rendered as HTML. It returns an empty string when nothing matched.
+func highlight(text string, positive [][]string) string {
+	runes := []rune(text)
+	words, spans := wordSpans(runes)
+
+	var marks []span
+	for i := range words {
+		for _, seq := range positive {
+			if i+len(seq) > len(words) || !hasPrefix(words[i:], seq) {
+				continue
+			}
+
+			mark := span{spans[i].start, spans[i+len(seq)-1].end}
+			if n := len(marks); n > 0 && mark.start <= marks[n-1].end {
+				if mark.end > marks[n-1].end {
+					marks[n-1].end = mark.end
+				}
+				continue
+			}
+			marks = append(marks, mark)
+		}
+	}
+
+	if len(marks) == 0 {
+		return ""
+	}
+
+	from, to := 0, len(runes)
+	if len(runes) > fragmentLength {
+		from = marks[0].start - fragmentLead
+		if from < 0 {
+			from = 0
+		}
+		to = from + fragmentLength
+		if to > len(runes) {
+			to, from = len(runes), len(runes)-fragmentLength
+		}
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+
+	pos := from
+	for _, mark := range marks {
+		if mark.end <= from || mark.start >= to {
+			continue
+		}
+
+		start, end := mark.start, mark.end
+		if start < from {
+			start = from
+		}
+		if end > to {
+			end = to
+		}
+
+		b.WriteString(html.EscapeString(string(runes[pos:start])))
+		b.WriteString(markOpen)
+		b.WriteString(html.EscapeString(string(runes[start:end])))
+		b.WriteString(markClose)
+		pos = end
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:to])))
+
+	if to < len(runes) {
+		b.WriteString("…")
+	}
+
+	return b.String()
+}
+
+// wordSpans splits text into lowercase words the same way domain.Words does
+// and remembers where each word starts and ends.
+func wordSpans(runes []rune) ([]string, []span) {
+	var (
+		words []string
+		spans []span
+	)
+
+	start := -1
+	for i := 0; i <= len(runes); i++ {
+		inWord := i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]))
+		switch {
+		case inWord && start < 0:
+			start = i
+		case !inWord && start >= 0:
+			words = append(words, strings.ToLower(string(runes[start:i])))
+			spans = append(spans, span{start, i})
+			start = -1
+		}
+	}
+
+	return words, spans
+}
+
+func hasPrefix(words []string, seq []string) bool {
+	for i := range seq {
+		if words[i] != seq[i] {
+			return false
+		}
+	}
+
+	return true
+}
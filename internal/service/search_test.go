@@ -0,0 +1,65 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHighlight(t *testing.T) {
+	long := strings.Repeat("слово ", 40) + "Молоко" + strings.Repeat(" слово", 40)
+
+	tests := []struct {
+		name  string
+		text  string
+		query string
+		want  string
+	}{
+		{
+			name:  "terms",
+			text:  "Купить Молоко и хлеб",
+			query: "молоко хлеб",
+			want:  "Купить <mark>Молоко</mark> и <mark>хлеб</mark>",
+		},
+		{
+			name:  "phrase",
+			text:  "Потом позвонить маме, позвонить",
+			query: `"позвонить маме"`,
+			want:  "Потом <mark>позвонить маме</mark>, позвонить",
+		},
+		{
+			name:  "overlapping matches merge",
+			text:  "buy bread now",
+			query: `"buy bread" bread now`,
+			want:  "<mark>buy bread</mark> <mark>now</mark>",
+		},
+		{
+			name:  "markup is escaped",
+			text:  `<img src=x onerror="alert(1)"> milk & bread`,
+			query: "milk",
+			want:  `&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>milk</mark> &amp; bread`,
+		},
+		{
+			name:  "no match",
+			text:  "Купить молоко",
+			query: "хлеб",
+			want:  "",
+		},
+		{
+			name:  "long text is cut around the first match",
+			text:  long,
+			query: "молоко",
+			want:  "…" + string([]rune(long)[180:240]) + "<mark>Молоко</mark>" + string([]rune(long)[246:340]) + "…",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := domain.ParseSearchQuery(tt.query)
+			require.NoError(t, err)
+
+			require.Equal(t, tt.want, highlight(tt.text, query.Positive()))
+		})
+	}
+}
@@ -4,20 +4,63 @@ import (
 	"context"
 
 	"github.com/begenov/region-llc-task/internal/domain"
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	"github.com/begenov/region-llc-task/pkg/auth"
 )
 
 //go:generate mockgen -source=service.go -destination=mocks/mock.go
 type Users interface {
 	SignUp(ctx context.Context, inp domain.UserRequest) (domain.User, error)
-	SignIn(ctx context.Context, email, password string) (domain.Token, error)
-	RefreshTokens(ctx context.Context, refreshToken string) (domain.Token, error)
+	SignIn(ctx context.Context, email, password string, client domain.Client) (domain.Token, error)
+	VerifyMFA(ctx context.Context, inp domain.MFASignInRequest, client domain.Client) (domain.Token, error)
+	EnrollMFAChallenge(ctx context.Context, mfaToken string) (domain.MFAEnrollment, error)
+	RequestPasswordReset(ctx context.Context, email string, language string) error
+	ResetPassword(ctx context.Context, inp domain.PasswordReset) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, userID string, language string) error
+	ChangeEmail(ctx context.Context, userID string, inp domain.EmailChange, language string) error
+	RefreshTokens(ctx context.Context, refreshToken string, client domain.Client) (domain.Token, error)
+	CheckAccessToken(ctx context.Context, claims auth.Claims) error
+	Logout(ctx context.Context, claims auth.Claims) error
+	LogoutEverywhere(ctx context.Context, userID string) error
+	GetSessions(ctx context.Context, userID string, currentID string) ([]domain.Session, error)
+	RevokeSession(ctx context.Context, userID string, id string) error
+	CreatePersonalToken(ctx context.Context, userID string, inp domain.PersonalTokenRequest) (domain.NewPersonalToken, error)
+	GetPersonalTokens(ctx context.Context, userID string) ([]domain.PersonalToken, error)
+	RevokePersonalToken(ctx context.Context, userID string, id string) error
+	CheckPersonalToken(ctx context.Context, token string) (domain.Principal, error)
+	GetMFA(ctx context.Context, userID string) (domain.MFAStatus, error)
+	EnrollMFA(ctx context.Context, userID string) (domain.MFAEnrollment, error)
+	ConfirmMFA(ctx context.Context, userID string, code string) (domain.RecoveryCodes, error)
+	DisableMFA(ctx context.Context, userID string, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID string, code string) (domain.RecoveryCodes, error)
+	GetProfile(ctx context.Context, userID string) (domain.User, error)
+	UpdateProfile(ctx context.Context, userID string, inp domain.UserUpdate) (domain.User, error)
+	ChangePassword(ctx context.Context, userID string, inp domain.PasswordChange, client domain.Client) (domain.Token, error)
+	DeleteAccount(ctx context.Context, userID string, password string, client domain.Client) (domain.User, error)
+	GetLanguage(ctx context.Context, userID string) (string, error)
+	SetLanguage(ctx context.Context, userID string, language string) error
 }
 
 type Todo interface {
-	CreateTodo(ctx context.Context, todo domain.Todo) (domain.Todo, error)
-	UpdateTodo(ctx context.Context, todo domain.Todo) (domain.Todo, error)
-	DeleteTodoByID(ctx context.Context, id string) error
-	UpdateTodoDoneByID(ctx context.Context, id string, userID primitive.ObjectID) (domain.Todo, error)
-	GetTodosByStatus(ctx context.Context, status string, userID primitive.ObjectID) ([]domain.Todo, error)
+	CreateTodo(ctx context.Context, userID string, inp domain.TodoRequest) (domain.Todo, error)
+	UpdateTodo(ctx context.Context, id string, userID string, inp domain.TodoRequest) (domain.Todo, error)
+	GetTodoByID(ctx context.Context, id string, userID string) (domain.Todo, error)
+	DeleteTodoByID(ctx context.Context, id string, userID string) error
+	UpdateTodoDoneByID(ctx context.Context, id string, userID string) (domain.Todo, error)
+	GetTodos(ctx context.Context, userID string, inp domain.TodoListRequest) (domain.TodoPage, error)
+	SearchTodos(ctx context.Context, userID string, inp domain.SearchRequest) ([]domain.SearchResult, error)
+
+	GetTodoItems(ctx context.Context, todoID string, userID string) ([]domain.TodoItem, error)
+	CreateTodoItem(ctx context.Context, todoID string, userID string, inp domain.TodoItemRequest) (domain.TodoItem, error)
+	ReorderTodoItems(ctx context.Context, todoID string, userID string, ids []string) ([]domain.TodoItem, error)
+	ToggleTodoItem(ctx context.Context, todoID string, itemID string, userID string) (domain.TodoItem, error)
+	DeleteTodoItem(ctx context.Context, todoID string, itemID string, userID string) error
+}
+
+type Calendars interface {
+	GetCalendars(ctx context.Context, userID string) ([]domain.Calendar, error)
+	GetCalendar(ctx context.Context, id string, userID string) (domain.Calendar, error)
+	UploadCalendar(ctx context.Context, userID string, name string, data []byte) (domain.Calendar, error)
+	DeleteCalendar(ctx context.Context, id string, userID string) error
+	SelectCalendar(ctx context.Context, userID string, id string) (domain.Calendar, error)
 }
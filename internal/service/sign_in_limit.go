@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/logger"
+)
+
+const (
+	defaultSignInFreeAttempts      = 3
+	defaultSignInBackoff           = time.Second
+	defaultSignInLockoutAttempts   = 10
+	defaultSignInIPLockoutAttempts = 100
+	defaultSignInLockoutDuration   = 15 * time.Minute
+	defaultSignInWindow            = time.Hour
+)
+
+const (
+	// signInFailuresKey counts the failed sign-ins of an account or an IP
+	// address, signInBlockedKey keeps until when they may not sign in.
+	signInFailuresKey = "sign-in-failures:"
+	signInBlockedKey  = "sign-in-blocked:"
+
+	signInScopeAccount = "account"
+	signInScopeIP      = "ip"
+)
+
+// SignInPolicy limits failed sign-ins, which are counted for Window after
+// the first one. After FreeAttempts failures of an account every further
+// one makes it wait Backoff, doubled with each failure. LockoutAttempts
+// failures of an account, or IPLockoutAttempts from an IP address, lock it
+// for LockoutDuration.
+type SignInPolicy struct {
+	FreeAttempts      int
+	Backoff           time.Duration
+	LockoutAttempts   int
+	IPLockoutAttempts int
+	LockoutDuration   time.Duration
+	Window            time.Duration
+}
+
+func (p SignInPolicy) withDefaults() SignInPolicy {
+	if p.FreeAttempts <= 0 {
+		p.FreeAttempts = defaultSignInFreeAttempts
+	}
+
+	if p.Backoff <= 0 {
+		p.Backoff = defaultSignInBackoff
+	}
+
+	if p.LockoutAttempts <= 0 {
+		p.LockoutAttempts = defaultSignInLockoutAttempts
+	}
+
+	if p.IPLockoutAttempts <= 0 {
+		p.IPLockoutAttempts = defaultSignInIPLockoutAttempts
+	}
+
+	if p.LockoutDuration <= 0 {
+		p.LockoutDuration = defaultSignInLockoutDuration
+	}
+
+	if p.Window <= 0 {
+		p.Window = defaultSignInWindow
+	}
+
+	return p
+}
+
+// backoff is how long an account waits after the failure, zero while it has
+// free attempts left.
+func (p SignInPolicy) backoff(attempts int64) time.Duration {
+	extra := attempts - int64(p.FreeAttempts)
+	if extra <= 0 {
+		return 0
+	}
+
+	delay := p.Backoff
+	for i := int64(1); i < extra && delay < p.LockoutDuration; i++ {
+		delay *= 2
+	}
+
+	if delay > p.LockoutDuration {
+		return p.LockoutDuration
+	}
+
+	return delay
+}
+
+func signInAccount(email string) string {
+	return signInScopeAccount + ":" + strings.ToLower(email)
+}
+
+func signInIP(ip string) string {
+	return signInScopeIP + ":" + ip
+}
+
+// checkSignIn returns a RetryError while the account or the IP address may
+// not sign in.
+func (s *UserService) checkSignIn(email string, client domain.Client) error {
+	subjects := []string{signInAccount(email)}
+	if client.IP != "" {
+		subjects = append(subjects, signInIP(client.IP))
+	}
+
+	for _, subject := range subjects {
+		if err := s.checkBlocked(signInBlockedKey + subject); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkBlocked returns a RetryError until the time kept by block under the
+// key.
+func (s *UserService) checkBlocked(key string) error {
+	value, err := s.redisRepo.Get(key)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		logger.Errorf("s.redisRepo.Get(): %v", err)
+		return err
+	}
+
+	until, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		logger.Errorf("time.Parse(): %v", err)
+		return domain.ErrInternalServer
+	}
+
+	if retryAfter := time.Until(until); retryAfter > 0 {
+		return &domain.RetryError{Err: domain.ErrTooManyAttempts, RetryAfter: retryAfter}
+	}
+
+	return nil
+}
+
+// failSignIn counts a failed sign-in of the account from the IP address. It
+// returns a RetryError when the failure makes them wait before the next one.
+func (s *UserService) failSignIn(ctx context.Context, email string, client domain.Client) error {
+	attempts, err := s.redisRepo.Incr(signInFailuresKey+signInAccount(email), s.signIn.Window)
+	if err != nil {
+		logger.Errorf("s.redisRepo.Incr(): %v", err)
+		return err
+	}
+
+	delay := s.signIn.backoff(attempts)
+	if attempts >= int64(s.signIn.LockoutAttempts) {
+		delay = s.signIn.LockoutDuration
+		s.auditor.Audit(ctx, domain.AuditEvent{
+			Type:     domain.AuditSignInLockout,
+			Scope:    signInScopeAccount,
+			Email:    email,
+			IP:       client.IP,
+			Attempts: attempts,
+			Until:    time.Now().Add(delay).UTC(),
+			At:       time.Now().UTC(),
+		})
+	}
+
+	if client.IP != "" {
+		ipAttempts, err := s.redisRepo.Incr(signInFailuresKey+signInIP(client.IP), s.signIn.Window)
+		if err != nil {
+			logger.Errorf("s.redisRepo.Incr(): %v", err)
+			return err
+		}
+
+		if ipAttempts >= int64(s.signIn.IPLockoutAttempts) {
+			if err := s.blockSignIn(signInIP(client.IP), s.signIn.LockoutDuration); err != nil {
+				return err
+			}
+
+			s.auditor.Audit(ctx, domain.AuditEvent{
+				Type:     domain.AuditSignInLockout,
+				Scope:    signInScopeIP,
+				Email:    email,
+				IP:       client.IP,
+				Attempts: ipAttempts,
+				Until:    time.Now().Add(s.signIn.LockoutDuration).UTC(),
+				At:       time.Now().UTC(),
+			})
+		}
+	}
+
+	if delay == 0 {
+		return nil
+	}
+
+	if err := s.blockSignIn(signInAccount(email), delay); err != nil {
+		return err
+	}
+
+	return &domain.RetryError{Err: domain.ErrTooManyAttempts, RetryAfter: delay}
+}
+
+func (s *UserService) blockSignIn(subject string, delay time.Duration) error {
+	return s.block(signInBlockedKey+subject, delay)
+}
+
+// block keeps under the key until when it is blocked, for checkBlocked.
+func (s *UserService) block(key string, delay time.Duration) error {
+	until := time.Now().Add(delay)
+	if err := s.redisRepo.Set(key, until.Format(time.RFC3339Nano), delay); err != nil {
+		logger.Errorf("s.redisRepo.Set(): %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// confirmPassword checks the password of a signed in user. Wrong ones count
+// as failed sign-ins of the account, a stolen access token does not give
+// more guesses than the sign-in does.
+func (s *UserService) confirmPassword(ctx context.Context, user domain.User, password string, client domain.Client) error {
+	if err := s.checkSignIn(user.Email, client); err != nil {
+		return err
+	}
+
+	if err := s.hash.CompareHashAndPassword(user.Password, password); err != nil {
+		if err := s.failSignIn(ctx, user.Email, client); err != nil {
+			return err
+		}
+
+		return domain.ErrIncorrectPassword
+	}
+
+	return nil
+}
+
+// resetSignIn forgets the failed sign-ins of the account once it signs in.
+// A failure is only logged, the session is already started.
+func (s *UserService) resetSignIn(email string) {
+	if err := s.redisRepo.Delete(signInFailuresKey + signInAccount(email)); err != nil {
+		logger.Errorf("s.redisRepo.Delete(): %v", err)
+	}
+}
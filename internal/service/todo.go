@@ -2,45 +2,66 @@ package service
 
 import (
 	"context"
-	"sort"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/begenov/region-llc-task/internal/domain"
 	"github.com/begenov/region-llc-task/internal/repository"
 	"github.com/begenov/region-llc-task/pkg/logger"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// TodoService.unverifiedLimit is how many todos a user whose email is not
+// verified may have, none for 0.
 type TodoService struct {
-	todoRepo repository.Todo
-	userRepo repository.Users
+	todoRepo        repository.Todo
+	itemRepo        repository.TodoItems
+	userRepo        repository.Users
+	calendars       *CalendarService
+	policy          Policy
+	autoComplete    bool
+	unverifiedLimit int
 }
 
-func NewTodoService(todoRepo repository.Todo, userRepo repository.Users) *TodoService {
+func NewTodoService(todoRepo repository.Todo, itemRepo repository.TodoItems, userRepo repository.Users,
+	calendars *CalendarService, autoComplete bool, unverifiedLimit int) *TodoService {
 	return &TodoService{
-		todoRepo: todoRepo,
-		userRepo: userRepo,
+		todoRepo:        todoRepo,
+		itemRepo:        itemRepo,
+		userRepo:        userRepo,
+		calendars:       calendars,
+		policy:          NewOwnerPolicy(),
+		autoComplete:    autoComplete,
+		unverifiedLimit: unverifiedLimit,
 	}
 }
 
-func (s *TodoService) CreateTodo(ctx context.Context, todo domain.Todo) (domain.Todo, error) {
-
-	if err := validateTodo(todo.Title, todo.ActiveAt); err != nil {
-		logger.Errorf("validateTodo(): %v", err)
+func (s *TodoService) CreateTodo(ctx context.Context, userID string, inp domain.TodoRequest) (domain.Todo, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Errorf("s.userRepo.GetUserByID(): %v", err)
 		return domain.Todo{}, err
 	}
 
-	if err := s.checkTitle(ctx, todo.UserID, todo.Title); err != nil {
+	if err := s.checkVerified(ctx, user); err != nil {
 		return domain.Todo{}, err
 	}
 
-	user, err := s.userRepo.GetUserByID(ctx, todo.UserID)
+	todo, err := newTodo(userID, inp, user.Location(), time.Now())
 	if err != nil {
-		logger.Errorf("s.userRepo.GetUserByID(): %v", err)
+		logger.Errorf("newTodo(): %v", err)
+		return domain.Todo{}, err
+	}
+
+	if err := s.checkTitle(ctx, todo.UserID, todo.Title); err != nil {
 		return domain.Todo{}, err
 	}
 
 	todo.Author = user.UserName
+	if todo.Recurrence != "" {
+		todo.Occurrence = 1
+	}
 
 	todo, err = s.todoRepo.Create(ctx, todo)
 	if err != nil {
@@ -48,104 +69,264 @@ func (s *TodoService) CreateTodo(ctx context.Context, todo domain.Todo) (domain.
 		return domain.Todo{}, err
 	}
 
-	return todo, nil
+	todos := []domain.Todo{todo}
+	if err := s.fillDaysOff(ctx, user, todos); err != nil {
+		return domain.Todo{}, err
+	}
+
+	return todos[0], nil
+}
+
+// checkVerified limits the todos of a user whose email is not verified.
+func (s *TodoService) checkVerified(ctx context.Context, user domain.User) error {
+	if user.EmailVerified {
+		return nil
+	}
+
+	if s.unverifiedLimit <= 0 {
+		return domain.ErrEmailNotVerified
+	}
+
+	count, err := s.todoRepo.GetCountByFilter(ctx, domain.TodoFilter{UserID: user.ID})
+	if err != nil {
+		logger.Errorf("s.todoRepo.GetCountByFilter(): %v", err)
+		return err
+	}
+
+	if count >= int64(s.unverifiedLimit) {
+		return domain.ErrEmailNotVerified
+	}
+
+	return nil
 }
 
-func (s *TodoService) UpdateTodo(ctx context.Context, todo domain.Todo) (domain.Todo, error) {
+func (s *TodoService) UpdateTodo(ctx context.Context, id string, userID string, inp domain.TodoRequest) (domain.Todo, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Errorf("s.userRepo.GetUserByID(): %v", err)
+		return domain.Todo{}, err
+	}
 
-	if err := validateTodo(todo.Title, todo.ActiveAt); err != nil {
-		logger.Errorf("validateTodo(): %v", err)
+	todo, err := newTodo(userID, inp, user.Location(), time.Now())
+	if err != nil {
+		logger.Errorf("newTodo(): %v", err)
 		return domain.Todo{}, err
 	}
+	todo.ID = id
 
-	if err := s.checkTitle(ctx, todo.UserID, todo.Title); err != nil {
+	current, err := s.authorize(ctx, todo.ID, todo.UserID, ActionUpdate)
+	if err != nil {
 		return domain.Todo{}, err
 	}
 
+	// The todo itself has the title it keeps.
+	if todo.Title != current.Title {
+		if err := s.checkTitle(ctx, todo.UserID, todo.Title); err != nil {
+			return domain.Todo{}, err
+		}
+	}
+
 	if err := s.todoRepo.UpdateTodo(ctx, todo); err != nil {
 		logger.Errorf("s.todoRepo.UpdateTodo(): %v", err)
 		return domain.Todo{}, err
 	}
 
-	todo, err := s.todoRepo.GetTodoByID(ctx, todo.ID)
+	todo, err = s.todoRepo.GetTodoByID(ctx, todo.ID)
 	if err != nil {
 		logger.Errorf("s.todoRepo.GetTodoByID(): %v", err)
 		return domain.Todo{}, err
 	}
 
-	return todo, nil
+	todos := []domain.Todo{todo}
+	if err := s.fillProgress(ctx, todos); err != nil {
+		return domain.Todo{}, err
+	}
+
+	if err := s.fillDaysOff(ctx, user, todos); err != nil {
+		return domain.Todo{}, err
+	}
+
+	return todos[0], nil
 }
 
-func (s *TodoService) DeleteTodoByID(ctx context.Context, id string) error {
-	todoID, err := primitive.ObjectIDFromHex(id)
+func (s *TodoService) GetTodoByID(ctx context.Context, id string, userID string) (domain.Todo, error) {
+	todo, err := s.authorize(ctx, id, userID, ActionRead)
 	if err != nil {
-		return domain.ErrTodoInvalidId
+		return domain.Todo{}, err
 	}
 
-	if err := s.todoRepo.DeleteTodoByID(ctx, todoID); err != nil {
+	return s.withDetails(ctx, todo)
+}
+
+func (s *TodoService) DeleteTodoByID(ctx context.Context, id string, userID string) error {
+	if _, err := s.authorize(ctx, id, userID, ActionDelete); err != nil {
+		return err
+	}
+
+	if err := s.todoRepo.DeleteTodoByID(ctx, id, userID); err != nil {
+		return err
+	}
+
+	if err := s.itemRepo.DeleteItems(ctx, id); err != nil {
+		logger.Errorf("s.itemRepo.DeleteItems(): %v", err)
 		return err
 	}
 
 	return nil
 }
 
-func (s *TodoService) UpdateTodoDoneByID(ctx context.Context, id string, userID primitive.ObjectID) (domain.Todo, error) {
-	todoID, err := primitive.ObjectIDFromHex(id)
+// UpdateTodoDoneByID marks the todo done. Completing an occurrence of a
+// recurring todo creates the next one.
+func (s *TodoService) UpdateTodoDoneByID(ctx context.Context, id string, userID string) (domain.Todo, error) {
+	todo, err := s.authorize(ctx, id, userID, ActionDone)
 	if err != nil {
-		return domain.Todo{}, domain.ErrTodoInvalidId
+		return domain.Todo{}, err
 	}
 
-	todo, err := s.todoRepo.UpdateTodoDoneByID(ctx, todoID, userID)
+	todo, err = s.markDone(ctx, todo)
 	if err != nil {
 		return domain.Todo{}, err
 	}
 
-	if todo.Status == domain.Active {
-		logger.Info("TODO")
-		todo.Status = domain.Done
+	return s.withDetails(ctx, todo)
+}
+
+func (s *TodoService) GetTodos(ctx context.Context, userID string, inp domain.TodoListRequest) (domain.TodoPage, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Errorf("s.userRepo.GetUserByID(): %v", err)
+		return domain.TodoPage{}, err
 	}
 
-	return todo, nil
+	filter, err := newTodoFilter(userID, inp, time.Now(), user.Location())
+	if err != nil {
+		logger.Errorf("newTodoFilter(): %v", err)
+		return domain.TodoPage{}, err
+	}
+
+	total, err := s.todoRepo.GetCountByFilter(ctx, filter)
+	if err != nil {
+		logger.Errorf("s.todoRepo.GetCountByFilter(): %v", err)
+		return domain.TodoPage{}, err
+	}
+
+	// One extra todo tells whether there is a next page.
+	query := filter
+	query.Limit = filter.Limit + 1
+
+	todos, err := s.todoRepo.GetTodos(ctx, query)
+	if err != nil {
+		logger.Errorf("s.todoRepo.GetTodos(): %v", err)
+		return domain.TodoPage{}, err
+	}
+
+	page := domain.TodoPage{
+		Items: make([]domain.Todo, 0, len(todos)),
+		Total: total,
+	}
+
+	if len(todos) > filter.Limit {
+		todos = todos[:filter.Limit]
+		page.NextCursor = encodeCursor(filter, todos[len(todos)-1])
+	}
+
+	if err := s.fillProgress(ctx, todos); err != nil {
+		return domain.TodoPage{}, err
+	}
+
+	if err := s.fillDaysOff(ctx, user, todos); err != nil {
+		return domain.TodoPage{}, err
+	}
+
+	page.Items = append(page.Items, todos...)
+
+	return page, nil
 }
 
-func (s *TodoService) GetTodosByStatus(ctx context.Context, status string, userID primitive.ObjectID) ([]domain.Todo, error) {
-	if status == "" {
-		status = "active"
+func (s *TodoService) SearchTodos(ctx context.Context, userID string, inp domain.SearchRequest) ([]domain.SearchResult, error) {
+	query, err := domain.ParseSearchQuery(inp.Query)
+	if err != nil {
+		logger.Errorf("domain.ParseSearchQuery(): %v", err)
+		return nil, err
+	}
+
+	limit := inp.Limit
+	switch {
+	case limit < 0:
+		return nil, domain.ErrInvalidFilter
+	case limit == 0:
+		limit = domain.DefaultSearchLimit
+	case limit > domain.MaxSearchLimit:
+		limit = domain.MaxSearchLimit
 	}
 
-	todos, err := s.todoRepo.GetTodoByStatus(ctx, status, userID)
+	results, err := s.todoRepo.SearchTodos(ctx, userID, query, limit)
 	if err != nil {
+		logger.Errorf("s.todoRepo.SearchTodos(): %v", err)
 		return nil, err
 	}
 
-	var result []domain.Todo
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Errorf("s.userRepo.GetUserByID(): %v", err)
+		return nil, err
+	}
 
-	for _, todo := range todos {
-		activeAtTime, err := parseTimeString(todo.ActiveAt)
-		if err != nil {
-			logger.Errorf("parseTimeString(): %v", err)
-			continue
-		}
+	todos := make([]domain.Todo, len(results))
+	for i := range results {
+		todos[i] = results[i].Todo
+	}
+	if err := s.fillProgress(ctx, todos); err != nil {
+		return nil, err
+	}
 
-		if status == domain.Active && activeAtTime.After(time.Now()) {
-			continue
-		}
+	if err := s.fillDaysOff(ctx, user, todos); err != nil {
+		return nil, err
+	}
 
-		weekendTitle := ""
-		if activeAtTime.Weekday() == time.Saturday || activeAtTime.Weekday() == time.Sunday {
-			weekendTitle = "ВЫХОДНОЙ - "
+	positive := query.Positive()
+	for i := range results {
+		results[i].Todo = todos[i]
+		highlights := make(map[string]string)
+		if fragment := highlight(results[i].Title, positive); fragment != "" {
+			highlights["title"] = fragment
 		}
+		if fragment := highlight(results[i].Description, positive); fragment != "" {
+			highlights["description"] = fragment
+		}
+		results[i].Highlights = highlights
+	}
 
-		todo.Title = weekendTitle + todo.Title
-		result = append(result, todo)
+	if results == nil {
+		results = []domain.SearchResult{}
 	}
 
-	sortByCreatedAt(result)
+	return results, nil
+}
+
+// authorize loads the todo and checks that the user may perform the action
+// on it. A missing todo is reported as ErrNotFound, someone else's as
+// ErrForbidden.
+func (s *TodoService) authorize(ctx context.Context, id string, userID string, action Action) (domain.Todo, error) {
+	if id == "" {
+		return domain.Todo{}, domain.ErrTodoInvalidId
+	}
 
-	return result, nil
+	todo, err := s.todoRepo.GetTodoByID(ctx, id)
+	if err != nil {
+		logger.Errorf("s.todoRepo.GetTodoByID(): %v", err)
+		return domain.Todo{}, err
+	}
+
+	if err := s.policy.Authorize(userID, todo, action); err != nil {
+		logger.Errorf("s.policy.Authorize(%s): %v", action, err)
+		return domain.Todo{}, err
+	}
+
+	return todo, nil
 }
 
-func (s *TodoService) checkTitle(ctx context.Context, user_id primitive.ObjectID, title string) error {
+func (s *TodoService) checkTitle(ctx context.Context, user_id string, title string) error {
 
 	count, err := s.todoRepo.GetCountByTitle(ctx, title, user_id)
 	if err != nil {
@@ -159,27 +340,94 @@ func (s *TodoService) checkTitle(ctx context.Context, user_id primitive.ObjectID
 	return nil
 }
 
-func sortByCreatedAt(todos []domain.Todo) {
-	sort.Slice(todos, func(i, j int) bool {
-		timeI, err := parseTimeString(todos[i].ActiveAt)
-		if err != nil {
-			return true
-		}
+// location returns the timezone of the user.
+func (s *TodoService) location(ctx context.Context, userID string) (*time.Location, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Errorf("s.userRepo.GetUserByID(): %v", err)
+		return nil, err
+	}
 
-		timeJ, err := parseTimeString(todos[j].ActiveAt)
-		if err != nil {
-			return false
-		}
+	return user.Location(), nil
+}
+
+// withDetails sets the checklist progress of the todo and whether it falls
+// on a day off of its owner.
+func (s *TodoService) withDetails(ctx context.Context, todo domain.Todo) (domain.Todo, error) {
+	todo, err := s.withProgress(ctx, todo)
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, todo.UserID)
+	if err != nil {
+		logger.Errorf("s.userRepo.GetUserByID(): %v", err)
+		return domain.Todo{}, err
+	}
+
+	todos := []domain.Todo{todo}
+	if err := s.fillDaysOff(ctx, user, todos); err != nil {
+		return domain.Todo{}, err
+	}
+
+	return todos[0], nil
+}
+
+// fillDaysOff marks in place the todos of the user that become active on a
+// day off of the working calendar of the user, in the timezone of the user.
+func (s *TodoService) fillDaysOff(ctx context.Context, user domain.User, todos []domain.Todo) error {
+	if len(todos) == 0 {
+		return nil
+	}
+
+	calendar, err := s.calendars.calendarOf(ctx, user)
+	if err != nil {
+		logger.Errorf("s.calendars.calendarOf(): %v", err)
+		return err
+	}
 
-		return timeI.Before(timeJ)
-	})
+	loc := user.Location()
+	for i := range todos {
+		todos[i].IsDayOff, todos[i].DayOffReason = calendar.DayOff(todos[i].ActiveAt.In(loc))
+	}
 
+	return nil
 }
 
-func parseTimeString(activeAt string) (time.Time, error) {
-	layout := "2006-01-02"
+// newTodo builds an active todo of the user from the request and validates
+// it. loc is the timezone of the user.
+func newTodo(userID string, inp domain.TodoRequest, loc *time.Location, now time.Time) (domain.Todo, error) {
+	activeAt, err := parseActiveAt(inp.ActiveAt, loc)
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	todo := domain.Todo{
+		UserID:      userID,
+		Title:       inp.Title,
+		Description: inp.Description,
+		Priority:    inp.Priority,
+		Tags:        inp.Tags,
+		ActiveAt:    activeAt,
+		DueAt:       inp.DueAt,
+		Recurrence:  inp.Recurrence,
+		Status:      domain.Active,
+	}
 
-	t, err := time.Parse(layout, activeAt)
+	if err := validateTodo(&todo, loc, now); err != nil {
+		return domain.Todo{}, err
+	}
+
+	return todo, nil
+}
+
+// parseActiveAt reads a date as midnight in loc, a timestamp as is.
+func parseActiveAt(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.ParseInLocation(domain.Format, value, loc); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
 	if err != nil {
 		return time.Time{}, domain.ErrIncorrectDateFormat
 	}
@@ -187,26 +435,99 @@ func parseTimeString(activeAt string) (time.Time, error) {
 	return t, nil
 }
 
-func validateTodo(title string, activeAt string) error {
+// startOfDay returns midnight of the day t falls on in loc.
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
 
-	if title == "" {
+// validateTodo checks the todo and normalizes the fields it accepts in more
+// than one form: an empty priority means medium, tags are lowercased and
+// deduplicated, the recurrence rule is rewritten in its canonical form,
+// times are kept in UTC to the second. A todo may become active today, as
+// the day goes in loc, but not earlier.
+func validateTodo(todo *domain.Todo, loc *time.Location, now time.Time) error {
+
+	if todo.Title == "" {
 		return domain.ErrInvalidTitle
 	}
 
-	if len(title) > 200 {
+	if len(todo.Title) > 200 {
 		return domain.ErrHeaderLength
 	}
 
-	t, err := time.Parse("2006-01-02", activeAt)
+	if utf8.RuneCountInString(todo.Description) > domain.MaxDescriptionLength {
+		return domain.ErrDescriptionLength
+	}
+
+	switch todo.Priority {
+	case "":
+		todo.Priority = domain.PriorityMedium
+	case domain.PriorityLow, domain.PriorityMedium, domain.PriorityHigh:
+	default:
+		return domain.ErrInvalidPriority
+	}
+
+	tags, err := normalizeTags(todo.Tags)
 	if err != nil {
-		return domain.ErrIncorrectDateFormat
+		return err
 	}
+	todo.Tags = tags
 
-	currentTime := time.Now()
+	if todo.Recurrence != "" {
+		rule, err := domain.ParseRecurrence(todo.Recurrence)
+		if err != nil {
+			return err
+		}
+		todo.Recurrence = rule.String()
+	}
 
-	if t.Before(currentTime) {
+	if todo.ActiveAt.Before(startOfDay(now, loc)) {
 		return domain.ErrTodoActiveAtData
 	}
+	todo.ActiveAt = todo.ActiveAt.UTC().Truncate(time.Second)
+
+	if todo.DueAt != nil {
+		if todo.DueAt.Before(todo.ActiveAt) {
+			return domain.ErrTodoDueBeforeActive
+		}
+
+		due := todo.DueAt.UTC().Truncate(time.Second)
+		todo.DueAt = &due
+	}
 
 	return nil
 }
+
+// normalizeTags lowercases the tags, drops a leading '#' and duplicates. Tags
+// are letters, digits, '-' and '_' so that they can be passed in a query
+// string as a comma separated list.
+func normalizeTags(values []string) ([]string, error) {
+	var tags []string
+	seen := make(map[string]bool)
+
+	for _, tag := range values {
+		tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+		if tag == "" || utf8.RuneCountInString(tag) > domain.MaxTagLength {
+			return nil, domain.ErrInvalidTag
+		}
+
+		for _, r := range tag {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+				return nil, domain.ErrInvalidTag
+			}
+		}
+
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	if len(tags) > domain.MaxTags {
+		return nil, domain.ErrTooManyTags
+	}
+
+	return tags, nil
+}
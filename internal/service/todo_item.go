@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/pkg/logger"
+)
+
+// Checklist items are a part of their todo: reading them needs ActionRead on
+// the todo, changing them ActionUpdate.
+
+func (s *TodoService) GetTodoItems(ctx context.Context, todoID string, userID string) ([]domain.TodoItem, error) {
+	if _, err := s.authorize(ctx, todoID, userID, ActionRead); err != nil {
+		return nil, err
+	}
+
+	items, err := s.itemRepo.GetItems(ctx, todoID)
+	if err != nil {
+		logger.Errorf("s.itemRepo.GetItems(): %v", err)
+		return nil, err
+	}
+
+	if items == nil {
+		items = []domain.TodoItem{}
+	}
+
+	return items, nil
+}
+
+func (s *TodoService) CreateTodoItem(ctx context.Context, todoID string, userID string, inp domain.TodoItemRequest) (domain.TodoItem, error) {
+	if err := validateTodoItem(inp.Title); err != nil {
+		logger.Errorf("validateTodoItem(): %v", err)
+		return domain.TodoItem{}, err
+	}
+
+	if _, err := s.authorize(ctx, todoID, userID, ActionUpdate); err != nil {
+		return domain.TodoItem{}, err
+	}
+
+	progress, err := s.itemRepo.GetProgress(ctx, []string{todoID})
+	if err != nil {
+		logger.Errorf("s.itemRepo.GetProgress(): %v", err)
+		return domain.TodoItem{}, err
+	}
+
+	if progress[todoID].Total >= domain.MaxTodoItems {
+		return domain.TodoItem{}, domain.ErrTooManyItems
+	}
+
+	item, err := s.itemRepo.Create(ctx, domain.TodoItem{
+		TodoID: todoID,
+		Title:  inp.Title,
+	})
+	if err != nil {
+		logger.Errorf("s.itemRepo.Create(): %v", err)
+		return domain.TodoItem{}, err
+	}
+
+	return item, nil
+}
+
+// ReorderTodoItems expects the ids of all items of the todo, each once, and
+// returns the items in the new order.
+func (s *TodoService) ReorderTodoItems(ctx context.Context, todoID string, userID string, ids []string) ([]domain.TodoItem, error) {
+	if _, err := s.authorize(ctx, todoID, userID, ActionUpdate); err != nil {
+		return nil, err
+	}
+
+	items, err := s.itemRepo.GetItems(ctx, todoID)
+	if err != nil {
+		logger.Errorf("s.itemRepo.GetItems(): %v", err)
+		return nil, err
+	}
+
+	if len(ids) != len(items) {
+		return nil, domain.ErrInvalidItemsOrder
+	}
+
+	known := make(map[string]bool, len(items))
+	for _, item := range items {
+		known[item.ID] = true
+	}
+	for _, id := range ids {
+		if !known[id] {
+			return nil, domain.ErrInvalidItemsOrder
+		}
+		delete(known, id)
+	}
+
+	if err := s.itemRepo.ReorderItems(ctx, todoID, ids); err != nil {
+		logger.Errorf("s.itemRepo.ReorderItems(): %v", err)
+		return nil, err
+	}
+
+	items, err = s.itemRepo.GetItems(ctx, todoID)
+	if err != nil {
+		logger.Errorf("s.itemRepo.GetItems(): %v", err)
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// ToggleTodoItem flips the item between done and not done. Checking the last
+// item completes the todo when auto-completion is on.
+func (s *TodoService) ToggleTodoItem(ctx context.Context, todoID string, itemID string, userID string) (domain.TodoItem, error) {
+	todo, err := s.authorize(ctx, todoID, userID, ActionUpdate)
+	if err != nil {
+		return domain.TodoItem{}, err
+	}
+
+	item, err := s.itemRepo.GetItemByID(ctx, itemID, todoID)
+	if err != nil {
+		logger.Errorf("s.itemRepo.GetItemByID(): %v", err)
+		return domain.TodoItem{}, err
+	}
+
+	item, err = s.itemRepo.UpdateItemDone(ctx, itemID, todoID, !item.Done)
+	if err != nil {
+		logger.Errorf("s.itemRepo.UpdateItemDone(): %v", err)
+		return domain.TodoItem{}, err
+	}
+
+	if item.Done {
+		if err := s.completeIfDone(ctx, todo); err != nil {
+			return domain.TodoItem{}, err
+		}
+	}
+
+	return item, nil
+}
+
+// DeleteTodoItem removes the item. Removing the last unchecked item completes
+// the todo when auto-completion is on.
+func (s *TodoService) DeleteTodoItem(ctx context.Context, todoID string, itemID string, userID string) error {
+	todo, err := s.authorize(ctx, todoID, userID, ActionUpdate)
+	if err != nil {
+		return err
+	}
+
+	if err := s.itemRepo.DeleteItem(ctx, itemID, todoID); err != nil {
+		logger.Errorf("s.itemRepo.DeleteItem(): %v", err)
+		return err
+	}
+
+	return s.completeIfDone(ctx, todo)
+}
+
+// completeIfDone marks an active todo done once every item of its checklist
+// is done, the way UpdateTodoDoneByID does. A todo is never reopened by
+// unchecking an item.
+func (s *TodoService) completeIfDone(ctx context.Context, todo domain.Todo) error {
+	if !s.autoComplete || todo.Status != domain.Active {
+		return nil
+	}
+
+	progress, err := s.itemRepo.GetProgress(ctx, []string{todo.ID})
+	if err != nil {
+		logger.Errorf("s.itemRepo.GetProgress(): %v", err)
+		return err
+	}
+
+	if p := progress[todo.ID]; p.Total == 0 || p.Done < p.Total {
+		return nil
+	}
+
+	_, err = s.markDone(ctx, todo)
+	return err
+}
+
+func (s *TodoService) withProgress(ctx context.Context, todo domain.Todo) (domain.Todo, error) {
+	todos := []domain.Todo{todo}
+	if err := s.fillProgress(ctx, todos); err != nil {
+		return domain.Todo{}, err
+	}
+
+	return todos[0], nil
+}
+
+// fillProgress sets the checklist progress of every todo in place.
+func (s *TodoService) fillProgress(ctx context.Context, todos []domain.Todo) error {
+	if len(todos) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+	}
+
+	progress, err := s.itemRepo.GetProgress(ctx, ids)
+	if err != nil {
+		logger.Errorf("s.itemRepo.GetProgress(): %v", err)
+		return err
+	}
+
+	for i := range todos {
+		todos[i].Progress = progress[todos[i].ID]
+	}
+
+	return nil
+}
+
+func validateTodoItem(title string) error {
+	if title == "" {
+		return domain.ErrInvalidTitle
+	}
+
+	if len(title) > 200 {
+		return domain.ErrHeaderLength
+	}
+
+	return nil
+}
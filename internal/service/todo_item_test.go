@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/begenov/region-llc-task/internal/domain"
+	mocksRepo "github.com/begenov/region-llc-task/internal/repository/mocks"
+	"github.com/begenov/region-llc-task/pkg/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTodoService_CreateTodoItem(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	todoRepo := mocksRepo.NewMockTodo(ctrl)
+	itemRepo := mocksRepo.NewMockTodoItems(ctrl)
+	userRepo := mocksRepo.NewMockUsers(ctrl)
+
+	todoService := NewTodoService(todoRepo, itemRepo, userRepo, newCalendarService(t, ctrl), true, 0)
+
+	tests := []struct {
+		name          string
+		title         string
+		buildStubs    func(todo domain.Todo)
+		checkResponse func(item domain.TodoItem, err error)
+	}{
+		{
+			name:  "OK",
+			title: utils.RandomString(10),
+			buildStubs: func(todo domain.Todo) {
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), todo.ID).Times(1).Return(todo, nil)
+				itemRepo.EXPECT().GetProgress(gomock.Any(), []string{todo.ID}).Times(1).Return(map[string]domain.Progress{
+					todo.ID: {Done: 1, Total: 2},
+				}, nil)
+				itemRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+					func(ctx context.Context, item domain.TodoItem) (domain.TodoItem, error) {
+						require.Equal(t, todo.ID, item.TodoID)
+						item.ID = utils.RandomString(24)
+						item.Position = 3
+						return item, nil
+					})
+			},
+			checkResponse: func(item domain.TodoItem, err error) {
+				require.NoError(t, err)
+				require.Equal(t, 3, item.Position)
+			},
+		},
+		{
+			name:  "empty title",
+			title: "",
+			buildStubs: func(todo domain.Todo) {
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), todo.ID).Times(0)
+			},
+			checkResponse: func(item domain.TodoItem, err error) {
+				require.Equal(t, domain.ErrInvalidTitle, err)
+			},
+		},
+		{
+			name:  "too many items",
+			title: utils.RandomString(10),
+			buildStubs: func(todo domain.Todo) {
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), todo.ID).Times(1).Return(todo, nil)
+				itemRepo.EXPECT().GetProgress(gomock.Any(), []string{todo.ID}).Times(1).Return(map[string]domain.Progress{
+					todo.ID: {Total: domain.MaxTodoItems},
+				}, nil)
+			},
+			checkResponse: func(item domain.TodoItem, err error) {
+				require.Equal(t, domain.ErrTooManyItems, err)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todo := domain.Todo{ID: utils.RandomString(24), UserID: utils.RandomString(24), Status: domain.Active}
+			tt.buildStubs(todo)
+
+			item, err := todoService.CreateTodoItem(context.Background(), todo.ID, todo.UserID, domain.TodoItemRequest{Title: tt.title})
+			tt.checkResponse(item, err)
+		})
+	}
+}
+
+func TestTodoService_ReorderTodoItems(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	todoRepo := mocksRepo.NewMockTodo(ctrl)
+	itemRepo := mocksRepo.NewMockTodoItems(ctrl)
+	userRepo := mocksRepo.NewMockUsers(ctrl)
+
+	todoService := NewTodoService(todoRepo, itemRepo, userRepo, newCalendarService(t, ctrl), true, 0)
+
+	a, b := utils.RandomString(24), utils.RandomString(24)
+
+	tests := []struct {
+		name string
+		ids  []string
+		err  error
+	}{
+		{name: "OK", ids: []string{b, a}},
+		{name: "missing item", ids: []string{b}, err: domain.ErrInvalidItemsOrder},
+		{name: "repeated item", ids: []string{b, b}, err: domain.ErrInvalidItemsOrder},
+		{name: "unknown item", ids: []string{b, utils.RandomString(24)}, err: domain.ErrInvalidItemsOrder},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todo := domain.Todo{ID: utils.RandomString(24), UserID: utils.RandomString(24), Status: domain.Active}
+			items := []domain.TodoItem{{ID: a, TodoID: todo.ID, Position: 1}, {ID: b, TodoID: todo.ID, Position: 2}}
+
+			todoRepo.EXPECT().GetTodoByID(gomock.Any(), todo.ID).Times(1).Return(todo, nil)
+			itemRepo.EXPECT().GetItems(gomock.Any(), todo.ID).Times(1).Return(items, nil)
+			if tt.err == nil {
+				itemRepo.EXPECT().ReorderItems(gomock.Any(), todo.ID, tt.ids).Times(1).Return(nil)
+				itemRepo.EXPECT().GetItems(gomock.Any(), todo.ID).Times(1).Return(items, nil)
+			}
+
+			_, err := todoService.ReorderTodoItems(context.Background(), todo.ID, todo.UserID, tt.ids)
+			require.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestTodoService_ToggleTodoItem(t *testing.T) {
+	tests := []struct {
+		name          string
+		autoComplete  bool
+		status        string
+		buildStubs    func(todoRepo *mocksRepo.MockTodo, itemRepo *mocksRepo.MockTodoItems, todo domain.Todo, item domain.TodoItem)
+		checkResponse func(item domain.TodoItem, err error)
+	}{
+		{
+			name:         "last item completes the todo",
+			autoComplete: true,
+			status:       domain.Active,
+			buildStubs: func(todoRepo *mocksRepo.MockTodo, itemRepo *mocksRepo.MockTodoItems, todo domain.Todo, item domain.TodoItem) {
+				itemRepo.EXPECT().GetItemByID(gomock.Any(), item.ID, todo.ID).Times(1).Return(item, nil)
+				item.Done = true
+				itemRepo.EXPECT().UpdateItemDone(gomock.Any(), item.ID, todo.ID, true).Times(1).Return(item, nil)
+				itemRepo.EXPECT().GetProgress(gomock.Any(), []string{todo.ID}).Times(1).Return(map[string]domain.Progress{
+					todo.ID: {Done: 2, Total: 2},
+				}, nil)
+				todoRepo.EXPECT().UpdateTodoDoneByID(gomock.Any(), todo.ID, todo.UserID).Times(1).Return(todo, nil)
+			},
+			checkResponse: func(item domain.TodoItem, err error) {
+				require.NoError(t, err)
+				require.True(t, item.Done)
+			},
+		},
+		{
+			name:         "items left",
+			autoComplete: true,
+			status:       domain.Active,
+			buildStubs: func(todoRepo *mocksRepo.MockTodo, itemRepo *mocksRepo.MockTodoItems, todo domain.Todo, item domain.TodoItem) {
+				itemRepo.EXPECT().GetItemByID(gomock.Any(), item.ID, todo.ID).Times(1).Return(item, nil)
+				item.Done = true
+				itemRepo.EXPECT().UpdateItemDone(gomock.Any(), item.ID, todo.ID, true).Times(1).Return(item, nil)
+				itemRepo.EXPECT().GetProgress(gomock.Any(), []string{todo.ID}).Times(1).Return(map[string]domain.Progress{
+					todo.ID: {Done: 1, Total: 2},
+				}, nil)
+				todoRepo.EXPECT().UpdateTodoDoneByID(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(item domain.TodoItem, err error) {
+				require.NoError(t, err)
+				require.True(t, item.Done)
+			},
+		},
+		{
+			name:         "auto-completion off",
+			autoComplete: false,
+			status:       domain.Active,
+			buildStubs: func(todoRepo *mocksRepo.MockTodo, itemRepo *mocksRepo.MockTodoItems, todo domain.Todo, item domain.TodoItem) {
+				itemRepo.EXPECT().GetItemByID(gomock.Any(), item.ID, todo.ID).Times(1).Return(item, nil)
+				item.Done = true
+				itemRepo.EXPECT().UpdateItemDone(gomock.Any(), item.ID, todo.ID, true).Times(1).Return(item, nil)
+				itemRepo.EXPECT().GetProgress(gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().UpdateTodoDoneByID(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(item domain.TodoItem, err error) {
+				require.NoError(t, err)
+				require.True(t, item.Done)
+			},
+		},
+		{
+			name:         "uncheck",
+			autoComplete: true,
+			status:       domain.Done,
+			buildStubs: func(todoRepo *mocksRepo.MockTodo, itemRepo *mocksRepo.MockTodoItems, todo domain.Todo, item domain.TodoItem) {
+				item.Done = true
+				itemRepo.EXPECT().GetItemByID(gomock.Any(), item.ID, todo.ID).Times(1).Return(item, nil)
+				item.Done = false
+				itemRepo.EXPECT().UpdateItemDone(gomock.Any(), item.ID, todo.ID, false).Times(1).Return(item, nil)
+				todoRepo.EXPECT().UpdateTodoDoneByID(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(item domain.TodoItem, err error) {
+				require.NoError(t, err)
+				require.False(t, item.Done)
+			},
+		},
+		{
+			name:         "item of another todo",
+			autoComplete: true,
+			status:       domain.Active,
+			buildStubs: func(todoRepo *mocksRepo.MockTodo, itemRepo *mocksRepo.MockTodoItems, todo domain.Todo, item domain.TodoItem) {
+				itemRepo.EXPECT().GetItemByID(gomock.Any(), item.ID, todo.ID).Times(1).Return(domain.TodoItem{}, domain.ErrNotFound)
+				itemRepo.EXPECT().UpdateItemDone(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(item domain.TodoItem, err error) {
+				require.Equal(t, domain.ErrNotFound, err)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			todoRepo := mocksRepo.NewMockTodo(ctrl)
+			itemRepo := mocksRepo.NewMockTodoItems(ctrl)
+			todoService := NewTodoService(todoRepo, itemRepo, mocksRepo.NewMockUsers(ctrl), newCalendarService(t, ctrl), tt.autoComplete, 0)
+
+			todo := domain.Todo{ID: utils.RandomString(24), UserID: utils.RandomString(24), Status: tt.status}
+			item := domain.TodoItem{ID: utils.RandomString(24), TodoID: todo.ID, Position: 1}
+
+			todoRepo.EXPECT().GetTodoByID(gomock.Any(), todo.ID).Times(1).Return(todo, nil)
+			tt.buildStubs(todoRepo, itemRepo, todo, item)
+
+			item, err := todoService.ToggleTodoItem(context.Background(), todo.ID, item.ID, todo.UserID)
+			tt.checkResponse(item, err)
+		})
+	}
+}
+
+func TestTodoService_TodoItemsForbidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	todoRepo := mocksRepo.NewMockTodo(ctrl)
+	itemRepo := mocksRepo.NewMockTodoItems(ctrl)
+	todoService := NewTodoService(todoRepo, itemRepo, mocksRepo.NewMockUsers(ctrl), newCalendarService(t, ctrl), true, 0)
+
+	todo := domain.Todo{ID: utils.RandomString(24), UserID: utils.RandomString(24), Status: domain.Active}
+	userID, itemID := utils.RandomString(24), utils.RandomString(24)
+	todoRepo.EXPECT().GetTodoByID(gomock.Any(), todo.ID).Times(5).Return(todo, nil)
+
+	_, err := todoService.GetTodoItems(context.Background(), todo.ID, userID)
+	require.Equal(t, domain.ErrForbidden, err)
+
+	_, err = todoService.CreateTodoItem(context.Background(), todo.ID, userID, domain.TodoItemRequest{Title: "step"})
+	require.Equal(t, domain.ErrForbidden, err)
+
+	_, err = todoService.ReorderTodoItems(context.Background(), todo.ID, userID, []string{itemID})
+	require.Equal(t, domain.ErrForbidden, err)
+
+	_, err = todoService.ToggleTodoItem(context.Background(), todo.ID, itemID, userID)
+	require.Equal(t, domain.ErrForbidden, err)
+
+	err = todoService.DeleteTodoItem(context.Background(), todo.ID, itemID, userID)
+	require.Equal(t, domain.ErrForbidden, err)
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -10,7 +11,6 @@ import (
 	"github.com/begenov/region-llc-task/pkg/utils"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func TestTodoService_CreateTodo(t *testing.T) {
@@ -18,84 +18,139 @@ func TestTodoService_CreateTodo(t *testing.T) {
 	defer ctrl.Finish()
 
 	todoRepo := mocksRepo.NewMockTodo(ctrl)
+	itemRepo := mocksRepo.NewMockTodoItems(ctrl)
 	userRepo := mocksRepo.NewMockUsers(ctrl)
 
-	todoService := NewTodoService(todoRepo, userRepo)
+	todoService := NewTodoService(todoRepo, itemRepo, userRepo, newCalendarService(t, ctrl), true, 0)
+
+	// Checklist progress is covered in todo_item_test.go.
+	itemRepo.EXPECT().GetProgress(gomock.Any(), gomock.Any()).AnyTimes().Return(map[string]domain.Progress{}, nil)
+	itemRepo.EXPECT().DeleteItems(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+
+	loc, err := time.LoadLocation("Asia/Almaty")
+	require.NoError(t, err)
+	tomorrow := time.Now().In(loc).AddDate(0, 0, 1).Format(domain.Format)
+
+	newUser := func() domain.User {
+		return domain.User{
+			ID:            utils.RandomString(24),
+			UserName:      utils.RandomString(10),
+			Email:         utils.RandomEmail(),
+			EmailVerified: true,
+			Password:      utils.RandomString(10),
+			Timezone:      loc.String(),
+		}
+	}
 
 	type args struct {
 		ctx  context.Context
-		todo domain.Todo
+		inp  domain.TodoRequest
 		user domain.User
 	}
 	tests := []struct {
 		name          string
 		args          args
-		buildStubs    func(user domain.User, todo domain.Todo)
+		buildStubs    func(user domain.User, inp domain.TodoRequest)
 		checkResponse func(todo domain.Todo, err error)
 	}{
 		// TODO: Add test cases.
 		{
 			name: "OK",
 			args: args{
-				ctx: ctx,
-				todo: domain.Todo{
-					ID:       primitive.NewObjectID(),
-					UserID:   primitive.NewObjectID(),
-					Title:    utils.RandomString(10),
-					ActiveAt: time.Now().Add(time.Hour * 52).Format(domain.Format),
-					Status:   domain.Active,
-				},
-				user: domain.User{
-					UserName: utils.RandomString(10),
-					Email:    utils.RandomEmail(),
-					Password: utils.RandomString(10),
-				},
-			},
-			buildStubs: func(user domain.User, todo domain.Todo) {
-				var count int64 = 0
-				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), todo.Title, todo.UserID).Times(1).Return(count, nil)
-				userRepo.EXPECT().GetUserByID(gomock.Any(), todo.UserID).Times(1).Return(domain.User{
-					ID:       todo.UserID,
-					UserName: user.UserName,
-					Email:    user.Email,
-					Password: user.Password,
-				}, nil)
+				ctx:  ctx,
+				inp:  domain.TodoRequest{Title: utils.RandomString(10), ActiveAt: tomorrow},
+				user: newUser(),
+			},
+			buildStubs: func(user domain.User, inp domain.TodoRequest) {
+				activeAt, err := time.ParseInLocation(domain.Format, inp.ActiveAt, loc)
+				require.NoError(t, err)
 
-				todoRepo.EXPECT().Create(gomock.Any(), todo).Times(1).Return(domain.Todo{
-					ID:       todo.ID,
-					UserID:   todo.UserID,
-					Title:    todo.Title,
+				todo := domain.Todo{
+					UserID:   user.ID,
+					Title:    inp.Title,
+					Priority: domain.PriorityMedium,
+					ActiveAt: activeAt.UTC(),
 					Author:   user.UserName,
-					ActiveAt: todo.ActiveAt,
-					Status:   todo.Status,
-				}, nil)
+					Status:   domain.Active,
+				}
+
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), inp.Title, user.ID).Times(1).Return(int64(0), nil)
+
+				created := todo
+				created.ID = utils.RandomString(24)
+				todoRepo.EXPECT().Create(gomock.Any(), todo).Times(1).Return(created, nil)
 			},
 			checkResponse: func(todo domain.Todo, err error) {
 				require.NoError(t, err)
 				require.NotEmpty(t, todo)
 			},
 		},
+		{
+			name: "today in the user's timezone",
+			args: args{
+				ctx:  ctx,
+				inp:  domain.TodoRequest{Title: utils.RandomString(10), ActiveAt: time.Now().In(loc).Format(domain.Format)},
+				user: newUser(),
+			},
+			buildStubs: func(user domain.User, inp domain.TodoRequest) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), inp.Title, user.ID).Times(1).Return(int64(0), nil)
+				todoRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, todo domain.Todo) (domain.Todo, error) {
+					return todo, nil
+				})
+			},
+			checkResponse: func(todo domain.Todo, err error) {
+				require.NoError(t, err)
+				require.Equal(t, time.Now().In(loc).Format(domain.Format), todo.ActiveAt.In(loc).Format(domain.Format))
+			},
+		},
+		{
+			name: "yesterday in the user's timezone",
+			args: args{
+				ctx:  ctx,
+				inp:  domain.TodoRequest{Title: utils.RandomString(10), ActiveAt: time.Now().In(loc).AddDate(0, 0, -1).Format(domain.Format)},
+				user: newUser(),
+			},
+			buildStubs: func(user domain.User, inp domain.TodoRequest) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(todo domain.Todo, err error) {
+				require.Equal(t, err, domain.ErrTodoActiveAtData)
+			},
+		},
+		{
+			name: "timestamp",
+			args: args{
+				ctx:  ctx,
+				inp:  domain.TodoRequest{Title: utils.RandomString(10), ActiveAt: "2099-01-05T09:30:00+05:00"},
+				user: newUser(),
+			},
+			buildStubs: func(user domain.User, inp domain.TodoRequest) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), inp.Title, user.ID).Times(1).Return(int64(0), nil)
+				todoRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, todo domain.Todo) (domain.Todo, error) {
+					return todo, nil
+				})
+			},
+			checkResponse: func(todo domain.Todo, err error) {
+				require.NoError(t, err)
+				require.Equal(t, time.Date(2099, time.January, 5, 4, 30, 0, 0, time.UTC), todo.ActiveAt)
+			},
+		},
 		{
 			name: "invalid empty title",
 			args: args{
-				ctx: ctx,
-				todo: domain.Todo{
-					ID:       primitive.NewObjectID(),
-					UserID:   primitive.NewObjectID(),
-					Title:    "",
-					ActiveAt: "2023-08-09",
-					Status:   domain.Active,
-				},
-				user: domain.User{
-					UserName: utils.RandomString(10),
-					Email:    utils.RandomEmail(),
-					Password: utils.RandomString(10),
-				},
-			},
-			buildStubs: func(user domain.User, todo domain.Todo) {
-				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), todo.Title, todo.UserID).Times(0)
-				userRepo.EXPECT().GetUserByID(gomock.Any(), todo.UserID).Times(0)
-				todoRepo.EXPECT().Create(gomock.Any(), todo).Times(0)
+				ctx:  ctx,
+				inp:  domain.TodoRequest{Title: "", ActiveAt: tomorrow},
+				user: newUser(),
+			},
+			buildStubs: func(user domain.User, inp domain.TodoRequest) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(todo domain.Todo, err error) {
 				require.Equal(t, err, domain.ErrInvalidTitle)
@@ -104,24 +159,14 @@ func TestTodoService_CreateTodo(t *testing.T) {
 		{
 			name: "header length exceeds 200 characters",
 			args: args{
-				ctx: ctx,
-				todo: domain.Todo{
-					ID:       primitive.NewObjectID(),
-					UserID:   primitive.NewObjectID(),
-					Title:    utils.RandomString(256),
-					ActiveAt: "2023-08-09",
-					Status:   domain.Active,
-				},
-				user: domain.User{
-					UserName: utils.RandomString(10),
-					Email:    utils.RandomEmail(),
-					Password: utils.RandomString(10),
-				},
-			},
-			buildStubs: func(user domain.User, todo domain.Todo) {
-				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), todo.Title, todo.UserID).Times(0)
-				userRepo.EXPECT().GetUserByID(gomock.Any(), todo.UserID).Times(0)
-				todoRepo.EXPECT().Create(gomock.Any(), todo).Times(0)
+				ctx:  ctx,
+				inp:  domain.TodoRequest{Title: utils.RandomString(256), ActiveAt: tomorrow},
+				user: newUser(),
+			},
+			buildStubs: func(user domain.User, inp domain.TodoRequest) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(todo domain.Todo, err error) {
 				require.Equal(t, err, domain.ErrHeaderLength)
@@ -130,24 +175,14 @@ func TestTodoService_CreateTodo(t *testing.T) {
 		{
 			name: "incorrect date format",
 			args: args{
-				ctx: ctx,
-				todo: domain.Todo{
-					ID:       primitive.NewObjectID(),
-					UserID:   primitive.NewObjectID(),
-					Title:    utils.RandomString(50),
-					ActiveAt: "invalid data format",
-					Status:   domain.Active,
-				},
-				user: domain.User{
-					UserName: utils.RandomString(10),
-					Email:    utils.RandomEmail(),
-					Password: utils.RandomString(10),
-				},
-			},
-			buildStubs: func(user domain.User, todo domain.Todo) {
-				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), todo.Title, todo.UserID).Times(0)
-				userRepo.EXPECT().GetUserByID(gomock.Any(), todo.UserID).Times(0)
-				todoRepo.EXPECT().Create(gomock.Any(), todo).Times(0)
+				ctx:  ctx,
+				inp:  domain.TodoRequest{Title: utils.RandomString(50), ActiveAt: "invalid data format"},
+				user: newUser(),
+			},
+			buildStubs: func(user domain.User, inp domain.TodoRequest) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(todo domain.Todo, err error) {
 				require.Equal(t, err, domain.ErrIncorrectDateFormat)
@@ -156,25 +191,14 @@ func TestTodoService_CreateTodo(t *testing.T) {
 		{
 			name: "not found",
 			args: args{
-				ctx: ctx,
-				todo: domain.Todo{
-					ID:       primitive.NewObjectID(),
-					UserID:   primitive.NewObjectID(),
-					Title:    utils.RandomString(50),
-					ActiveAt: time.Now().Add(time.Hour * 24).Format("2006-01-02"),
-					Status:   domain.Active,
-				},
-				user: domain.User{
-					UserName: utils.RandomString(10),
-					Email:    utils.RandomEmail(),
-					Password: utils.RandomString(10),
-				},
-			},
-			buildStubs: func(user domain.User, todo domain.Todo) {
-				var count int64 = 0
-				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), todo.Title, todo.UserID).Times(1).Return(count, domain.ErrNotFound)
-				userRepo.EXPECT().GetUserByID(gomock.Any(), todo.UserID).Times(0)
-				todoRepo.EXPECT().Create(gomock.Any(), todo).Times(0)
+				ctx:  ctx,
+				inp:  domain.TodoRequest{Title: utils.RandomString(50), ActiveAt: tomorrow},
+				user: newUser(),
+			},
+			buildStubs: func(user domain.User, inp domain.TodoRequest) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), inp.Title, user.ID).Times(1).Return(int64(0), domain.ErrNotFound)
+				todoRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(todo domain.Todo, err error) {
 				require.Equal(t, err, domain.ErrNotFound)
@@ -183,25 +207,14 @@ func TestTodoService_CreateTodo(t *testing.T) {
 		{
 			name: "title already exists",
 			args: args{
-				ctx: ctx,
-				todo: domain.Todo{
-					ID:       primitive.NewObjectID(),
-					UserID:   primitive.NewObjectID(),
-					Title:    utils.RandomString(50),
-					ActiveAt: time.Now().Add(time.Hour * 24).Format("2006-01-02"),
-					Status:   domain.Active,
-				},
-				user: domain.User{
-					UserName: utils.RandomString(10),
-					Email:    utils.RandomEmail(),
-					Password: utils.RandomString(10),
-				},
-			},
-			buildStubs: func(user domain.User, todo domain.Todo) {
-				var count int64 = 1
-				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), todo.Title, todo.UserID).Times(1).Return(count, nil)
-				userRepo.EXPECT().GetUserByID(gomock.Any(), todo.UserID).Times(0)
-				todoRepo.EXPECT().Create(gomock.Any(), todo).Times(0)
+				ctx:  ctx,
+				inp:  domain.TodoRequest{Title: utils.RandomString(50), ActiveAt: tomorrow},
+				user: newUser(),
+			},
+			buildStubs: func(user domain.User, inp domain.TodoRequest) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), inp.Title, user.ID).Times(1).Return(int64(1), nil)
+				todoRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(todo domain.Todo, err error) {
 				require.Equal(t, err, domain.ErrTitleAlreadyExists)
@@ -210,25 +223,14 @@ func TestTodoService_CreateTodo(t *testing.T) {
 		{
 			name: "not found user",
 			args: args{
-				ctx: ctx,
-				todo: domain.Todo{
-					ID:       primitive.NewObjectID(),
-					UserID:   primitive.NewObjectID(),
-					Title:    utils.RandomString(50),
-					ActiveAt: time.Now().Add(time.Hour * 24).Format("2006-01-02"),
-					Status:   domain.Active,
-				},
-				user: domain.User{
-					UserName: utils.RandomString(10),
-					Email:    utils.RandomEmail(),
-					Password: utils.RandomString(10),
-				},
-			},
-			buildStubs: func(user domain.User, todo domain.Todo) {
-				var count int64 = 0
-				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), todo.Title, todo.UserID).Times(1).Return(count, nil)
-				userRepo.EXPECT().GetUserByID(gomock.Any(), todo.UserID).Times(1).Return(domain.User{}, domain.ErrNotFound)
-				todoRepo.EXPECT().Create(gomock.Any(), todo).Times(0)
+				ctx:  ctx,
+				inp:  domain.TodoRequest{Title: utils.RandomString(50), ActiveAt: tomorrow},
+				user: newUser(),
+			},
+			buildStubs: func(user domain.User, inp domain.TodoRequest) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(domain.User{}, domain.ErrNotFound)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(todo domain.Todo, err error) {
 				require.Equal(t, err, domain.ErrNotFound)
@@ -237,94 +239,162 @@ func TestTodoService_CreateTodo(t *testing.T) {
 		{
 			name: "internal server by create",
 			args: args{
-				ctx: ctx,
-				todo: domain.Todo{
-					ID:       primitive.NewObjectID(),
-					UserID:   primitive.NewObjectID(),
-					Title:    utils.RandomString(50),
-					ActiveAt: time.Now().Add(time.Hour * 24).Format("2006-01-02"),
-					Status:   domain.Active,
-				},
-				user: domain.User{
-					UserName: utils.RandomString(10),
-					Email:    utils.RandomEmail(),
-					Password: utils.RandomString(10),
-				},
-			},
-			buildStubs: func(user domain.User, todo domain.Todo) {
-				var count int64 = 0
-				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), todo.Title, todo.UserID).Times(1).Return(count, nil)
-				userRepo.EXPECT().GetUserByID(gomock.Any(), todo.UserID).Times(1).Return(domain.User{
-					ID:       todo.UserID,
-					UserName: user.UserName,
-					Email:    user.Email,
-					Password: user.Password,
-				}, nil)
-
-				todoRepo.EXPECT().Create(gomock.Any(), todo).Times(1).Return(domain.Todo{}, domain.ErrInternalServer)
+				ctx:  ctx,
+				inp:  domain.TodoRequest{Title: utils.RandomString(50), ActiveAt: tomorrow},
+				user: newUser(),
+			},
+			buildStubs: func(user domain.User, inp domain.TodoRequest) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), inp.Title, user.ID).Times(1).Return(int64(0), nil)
+				todoRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).Return(domain.Todo{}, domain.ErrInternalServer)
 			},
 			checkResponse: func(todo domain.Todo, err error) {
 				require.Equal(t, err, domain.ErrInternalServer)
 			},
 		},
+		{
+			name: "invalid priority",
+			args: args{
+				ctx:  ctx,
+				inp:  domain.TodoRequest{Title: utils.RandomString(10), Priority: "urgent", ActiveAt: tomorrow},
+				user: newUser(),
+			},
+			buildStubs: func(user domain.User, inp domain.TodoRequest) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(todo domain.Todo, err error) {
+				require.Equal(t, err, domain.ErrInvalidPriority)
+			},
+		},
+		{
+			name: "due before active date",
+			args: args{
+				ctx:  ctx,
+				inp:  domain.TodoRequest{Title: utils.RandomString(10), ActiveAt: tomorrow, DueAt: timePtr(time.Now())},
+				user: newUser(),
+			},
+			buildStubs: func(user domain.User, inp domain.TodoRequest) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(todo domain.Todo, err error) {
+				require.Equal(t, err, domain.ErrTodoDueBeforeActive)
+			},
+		},
+		{
+			name: "email not verified",
+			args: args{
+				ctx:  ctx,
+				inp:  domain.TodoRequest{Title: utils.RandomString(10), ActiveAt: tomorrow},
+				user: domain.User{ID: utils.RandomString(24), Email: utils.RandomEmail()},
+			},
+			buildStubs: func(user domain.User, inp domain.TodoRequest) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				todoRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(todo domain.Todo, err error) {
+				require.Equal(t, domain.ErrEmailNotVerified, err)
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.args.todo.Author = tt.args.user.UserName
-			tt.buildStubs(tt.args.user, tt.args.todo)
+			tt.buildStubs(tt.args.user, tt.args.inp)
 
-			todo, err := todoService.CreateTodo(tt.args.ctx, tt.args.todo)
+			todo, err := todoService.CreateTodo(tt.args.ctx, tt.args.user.ID, tt.args.inp)
 
 			tt.checkResponse(todo, err)
 		})
 	}
 }
 
+func TestTodoService_CreateTodoUnverified(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	todoRepo := mocksRepo.NewMockTodo(ctrl)
+	userRepo := mocksRepo.NewMockUsers(ctrl)
+
+	todoService := NewTodoService(todoRepo, mocksRepo.NewMockTodoItems(ctrl), userRepo, newCalendarService(t, ctrl), true, 2)
+
+	user := domain.User{ID: utils.RandomString(24), UserName: utils.RandomString(10), Email: utils.RandomEmail()}
+	inp := domain.TodoRequest{Title: utils.RandomString(10), ActiveAt: time.Now().AddDate(0, 0, 1).Format(domain.Format)}
+
+	userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(2).Return(user, nil)
+	gomock.InOrder(
+		todoRepo.EXPECT().GetCountByFilter(gomock.Any(), domain.TodoFilter{UserID: user.ID}).Times(1).Return(int64(1), nil),
+		todoRepo.EXPECT().GetCountByFilter(gomock.Any(), domain.TodoFilter{UserID: user.ID}).Times(1).Return(int64(2), nil),
+	)
+	todoRepo.EXPECT().GetCountByTitle(gomock.Any(), inp.Title, user.ID).Times(1).Return(int64(0), nil)
+	todoRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, todo domain.Todo) (domain.Todo, error) {
+		return todo, nil
+	})
+
+	_, err := todoService.CreateTodo(ctx, user.ID, inp)
+	require.NoError(t, err)
+
+	_, err = todoService.CreateTodo(ctx, user.ID, inp)
+	require.Equal(t, domain.ErrEmailNotVerified, err)
+}
+
 func TestTodoService_UpdateTodo(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	todoRepo := mocksRepo.NewMockTodo(ctrl)
+	itemRepo := mocksRepo.NewMockTodoItems(ctrl)
 	userRepo := mocksRepo.NewMockUsers(ctrl)
 
-	todoService := NewTodoService(todoRepo, userRepo)
+	todoService := NewTodoService(todoRepo, itemRepo, userRepo, newCalendarService(t, ctrl), true, 0)
+
+	// Checklist progress is covered in todo_item_test.go.
+	itemRepo.EXPECT().GetProgress(gomock.Any(), gomock.Any()).AnyTimes().Return(map[string]domain.Progress{}, nil)
+	itemRepo.EXPECT().DeleteItems(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format(domain.Format)
 
 	type args struct {
-		ctx  context.Context
-		todo domain.Todo
+		ctx    context.Context
+		id     string
+		userID string
+		inp    domain.TodoRequest
 	}
 	tests := []struct {
 		name          string
 		args          args
-		buildStubs    func(todo domain.Todo)
+		buildStubs    func(id, userID string, inp domain.TodoRequest)
 		checkResponse func(todo domain.Todo, err error)
 	}{
 		// TODO: Add test cases.
 		{
 			name: "OK",
 			args: args{
-				ctx: ctx,
-				todo: domain.Todo{
-					ID:       primitive.NewObjectID(),
-					UserID:   primitive.NewObjectID(),
-					Title:    utils.RandomString(10),
-					ActiveAt: time.Now().Add(time.Hour * 24).Format("2006-01-02"),
-					Author:   utils.RandomString(10),
-					Status:   domain.Active,
-				},
+				ctx:    ctx,
+				id:     utils.RandomString(24),
+				userID: utils.RandomString(24),
+				inp:    domain.TodoRequest{Title: utils.RandomString(10), ActiveAt: tomorrow},
 			},
-			buildStubs: func(todo domain.Todo) {
-				var count int64 = 0
-				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), todo.Title, todo.UserID).Times(1).Return(count, nil)
+			buildStubs: func(id, userID string, inp domain.TodoRequest) {
+				activeAt, err := time.Parse(domain.Format, inp.ActiveAt)
+				require.NoError(t, err)
+
+				todo := domain.Todo{
+					ID:       id,
+					UserID:   userID,
+					Title:    inp.Title,
+					Priority: domain.PriorityMedium,
+					ActiveAt: activeAt,
+					Status:   domain.Active,
+				}
+
+				// The title is kept, so it is not checked against the todo itself.
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 				todoRepo.EXPECT().UpdateTodo(gomock.Any(), todo).Times(1).Return(nil)
-				todoRepo.EXPECT().GetTodoByID(gomock.Any(), todo.ID).Times(1).Return(domain.Todo{
-					ID:       todo.ID,
-					UserID:   todo.UserID,
-					Title:    todo.Title,
-					ActiveAt: todo.ActiveAt,
-					Author:   todo.Author,
-					Status:   todo.Status,
-				}, nil)
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), id).Times(2).Return(todo, nil)
 
 			},
 			checkResponse: func(todo domain.Todo, err error) {
@@ -333,22 +403,52 @@ func TestTodoService_UpdateTodo(t *testing.T) {
 			},
 		},
 		{
-			name: "inavalid title empty",
+			name: "renamed",
 			args: args{
-				ctx: ctx,
-				todo: domain.Todo{
-					ID:       primitive.NewObjectID(),
-					UserID:   primitive.NewObjectID(),
-					Title:    "",
-					ActiveAt: time.Now().Add(time.Hour * 24).Format("2006-01-02"),
-					Author:   utils.RandomString(10),
+				ctx:    ctx,
+				id:     utils.RandomString(24),
+				userID: utils.RandomString(24),
+				inp:    domain.TodoRequest{Title: utils.RandomString(10), ActiveAt: tomorrow},
+			},
+			buildStubs: func(id, userID string, inp domain.TodoRequest) {
+				activeAt, err := time.Parse(domain.Format, inp.ActiveAt)
+				require.NoError(t, err)
+
+				todo := domain.Todo{
+					ID:       id,
+					UserID:   userID,
+					Title:    inp.Title,
+					Priority: domain.PriorityMedium,
+					ActiveAt: activeAt,
 					Status:   domain.Active,
-				},
+				}
+				current := todo
+				current.Title = utils.RandomString(10)
+
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), id).Times(1).Return(current, nil)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), inp.Title, userID).Times(1).Return(int64(0), nil)
+				todoRepo.EXPECT().UpdateTodo(gomock.Any(), todo).Times(1).Return(nil)
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), id).Times(1).Return(todo, nil)
 			},
-			buildStubs: func(todo domain.Todo) {
-				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), todo.Title, todo.UserID).Times(0)
-				todoRepo.EXPECT().UpdateTodo(gomock.Any(), todo).Times(0)
-				todoRepo.EXPECT().GetTodoByID(gomock.Any(), todo.ID).Times(0)
+			checkResponse: func(todo domain.Todo, err error) {
+				require.NoError(t, err)
+				require.NotEmpty(t, todo)
+			},
+		},
+		{
+			name: "inavalid title empty",
+			args: args{
+				ctx:    ctx,
+				id:     utils.RandomString(24),
+				userID: utils.RandomString(24),
+				inp:    domain.TodoRequest{Title: "", ActiveAt: tomorrow},
+			},
+			buildStubs: func(id, userID string, inp domain.TodoRequest) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().UpdateTodo(gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), id).Times(0)
 
 			},
 			checkResponse: func(todo domain.Todo, err error) {
@@ -358,20 +458,16 @@ func TestTodoService_UpdateTodo(t *testing.T) {
 		{
 			name: "header length",
 			args: args{
-				ctx: ctx,
-				todo: domain.Todo{
-					ID:       primitive.NewObjectID(),
-					UserID:   primitive.NewObjectID(),
-					Title:    utils.RandomString(255),
-					ActiveAt: time.Now().Add(time.Hour * 24).Format("2006-01-02"),
-					Author:   utils.RandomString(10),
-					Status:   domain.Active,
-				},
-			},
-			buildStubs: func(todo domain.Todo) {
-				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), todo.Title, todo.UserID).Times(0)
-				todoRepo.EXPECT().UpdateTodo(gomock.Any(), todo).Times(0)
-				todoRepo.EXPECT().GetTodoByID(gomock.Any(), todo.ID).Times(0)
+				ctx:    ctx,
+				id:     utils.RandomString(24),
+				userID: utils.RandomString(24),
+				inp:    domain.TodoRequest{Title: utils.RandomString(255), ActiveAt: tomorrow},
+			},
+			buildStubs: func(id, userID string, inp domain.TodoRequest) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().UpdateTodo(gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), id).Times(0)
 
 			},
 			checkResponse: func(todo domain.Todo, err error) {
@@ -381,20 +477,16 @@ func TestTodoService_UpdateTodo(t *testing.T) {
 		{
 			name: "incorrect date format",
 			args: args{
-				ctx: ctx,
-				todo: domain.Todo{
-					ID:       primitive.NewObjectID(),
-					UserID:   primitive.NewObjectID(),
-					Title:    utils.RandomString(25),
-					ActiveAt: "asf",
-					Author:   utils.RandomString(10),
-					Status:   domain.Active,
-				},
-			},
-			buildStubs: func(todo domain.Todo) {
-				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), todo.Title, todo.UserID).Times(0)
-				todoRepo.EXPECT().UpdateTodo(gomock.Any(), todo).Times(0)
-				todoRepo.EXPECT().GetTodoByID(gomock.Any(), todo.ID).Times(0)
+				ctx:    ctx,
+				id:     utils.RandomString(24),
+				userID: utils.RandomString(24),
+				inp:    domain.TodoRequest{Title: utils.RandomString(25), ActiveAt: "asf"},
+			},
+			buildStubs: func(id, userID string, inp domain.TodoRequest) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().UpdateTodo(gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), id).Times(0)
 
 			},
 			checkResponse: func(todo domain.Todo, err error) {
@@ -402,23 +494,37 @@ func TestTodoService_UpdateTodo(t *testing.T) {
 			},
 		},
 		{
-			name: "check title",
+			name: "user not found",
 			args: args{
-				ctx: ctx,
-				todo: domain.Todo{
-					ID:       primitive.NewObjectID(),
-					UserID:   primitive.NewObjectID(),
-					Title:    utils.RandomString(25),
-					ActiveAt: time.Now().Add(time.Hour * 24).Format(domain.Format),
-					Author:   utils.RandomString(10),
-					Status:   domain.Active,
-				},
+				ctx:    ctx,
+				id:     utils.RandomString(24),
+				userID: utils.RandomString(24),
+				inp:    domain.TodoRequest{Title: utils.RandomString(25), ActiveAt: tomorrow},
+			},
+			buildStubs: func(id, userID string, inp domain.TodoRequest) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{}, domain.ErrNotFound)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().UpdateTodo(gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), id).Times(0)
+
 			},
-			buildStubs: func(todo domain.Todo) {
-				var count int64 = 0
-				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), todo.Title, todo.UserID).Times(1).Return(count, domain.ErrInternalServer)
-				todoRepo.EXPECT().UpdateTodo(gomock.Any(), todo).Times(0)
-				todoRepo.EXPECT().GetTodoByID(gomock.Any(), todo.ID).Times(0)
+			checkResponse: func(todo domain.Todo, err error) {
+				require.Equal(t, err, domain.ErrNotFound)
+			},
+		},
+		{
+			name: "check title",
+			args: args{
+				ctx:    ctx,
+				id:     utils.RandomString(24),
+				userID: utils.RandomString(24),
+				inp:    domain.TodoRequest{Title: utils.RandomString(25), ActiveAt: tomorrow},
+			},
+			buildStubs: func(id, userID string, inp domain.TodoRequest) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), inp.Title, userID).Times(1).Return(int64(0), domain.ErrInternalServer)
+				todoRepo.EXPECT().UpdateTodo(gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), id).Times(1).Return(domain.Todo{ID: id, UserID: userID}, nil)
 
 			},
 			checkResponse: func(todo domain.Todo, err error) {
@@ -428,21 +534,16 @@ func TestTodoService_UpdateTodo(t *testing.T) {
 		{
 			name: "check title",
 			args: args{
-				ctx: ctx,
-				todo: domain.Todo{
-					ID:       primitive.NewObjectID(),
-					UserID:   primitive.NewObjectID(),
-					Title:    utils.RandomString(25),
-					ActiveAt: time.Now().Add(time.Hour * 24).Format(domain.Format),
-					Author:   utils.RandomString(10),
-					Status:   domain.Active,
-				},
-			},
-			buildStubs: func(todo domain.Todo) {
-				var count int64 = 1
-				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), todo.Title, todo.UserID).Times(1).Return(count, nil)
-				todoRepo.EXPECT().UpdateTodo(gomock.Any(), todo).Times(0)
-				todoRepo.EXPECT().GetTodoByID(gomock.Any(), todo.ID).Times(0)
+				ctx:    ctx,
+				id:     utils.RandomString(24),
+				userID: utils.RandomString(24),
+				inp:    domain.TodoRequest{Title: utils.RandomString(25), ActiveAt: tomorrow},
+			},
+			buildStubs: func(id, userID string, inp domain.TodoRequest) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), inp.Title, userID).Times(1).Return(int64(1), nil)
+				todoRepo.EXPECT().UpdateTodo(gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), id).Times(1).Return(domain.Todo{ID: id, UserID: userID}, nil)
 
 			},
 			checkResponse: func(todo domain.Todo, err error) {
@@ -452,21 +553,16 @@ func TestTodoService_UpdateTodo(t *testing.T) {
 		{
 			name: "todo not found",
 			args: args{
-				ctx: ctx,
-				todo: domain.Todo{
-					ID:       primitive.NewObjectID(),
-					UserID:   primitive.NewObjectID(),
-					Title:    utils.RandomString(25),
-					ActiveAt: time.Now().Add(time.Hour * 24).Format(domain.Format),
-					Author:   utils.RandomString(10),
-					Status:   domain.Active,
-				},
-			},
-			buildStubs: func(todo domain.Todo) {
-				var count int64 = 0
-				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), todo.Title, todo.UserID).Times(1).Return(count, nil)
-				todoRepo.EXPECT().UpdateTodo(gomock.Any(), todo).Times(1).Return(domain.ErrNotFound)
-				todoRepo.EXPECT().GetTodoByID(gomock.Any(), todo.ID).Times(0)
+				ctx:    ctx,
+				id:     utils.RandomString(24),
+				userID: utils.RandomString(24),
+				inp:    domain.TodoRequest{Title: utils.RandomString(25), ActiveAt: tomorrow},
+			},
+			buildStubs: func(id, userID string, inp domain.TodoRequest) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().UpdateTodo(gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), id).Times(1).Return(domain.Todo{}, domain.ErrNotFound)
 
 			},
 			checkResponse: func(todo domain.Todo, err error) {
@@ -476,21 +572,16 @@ func TestTodoService_UpdateTodo(t *testing.T) {
 		{
 			name: "todo internal server",
 			args: args{
-				ctx: ctx,
-				todo: domain.Todo{
-					ID:       primitive.NewObjectID(),
-					UserID:   primitive.NewObjectID(),
-					Title:    utils.RandomString(25),
-					ActiveAt: time.Now().Add(time.Hour * 24).Format(domain.Format),
-					Author:   utils.RandomString(10),
-					Status:   domain.Active,
-				},
-			},
-			buildStubs: func(todo domain.Todo) {
-				var count int64 = 0
-				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), todo.Title, todo.UserID).Times(1).Return(count, nil)
-				todoRepo.EXPECT().UpdateTodo(gomock.Any(), todo).Times(1).Return(domain.ErrInternalServer)
-				todoRepo.EXPECT().GetTodoByID(gomock.Any(), todo.ID).Times(0)
+				ctx:    ctx,
+				id:     utils.RandomString(24),
+				userID: utils.RandomString(24),
+				inp:    domain.TodoRequest{Title: utils.RandomString(25), ActiveAt: tomorrow},
+			},
+			buildStubs: func(id, userID string, inp domain.TodoRequest) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), inp.Title, userID).Times(1).Return(int64(0), nil)
+				todoRepo.EXPECT().UpdateTodo(gomock.Any(), gomock.Any()).Times(1).Return(domain.ErrInternalServer)
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), id).Times(1).Return(domain.Todo{ID: id, UserID: userID}, nil)
 
 			},
 			checkResponse: func(todo domain.Todo, err error) {
@@ -500,33 +591,48 @@ func TestTodoService_UpdateTodo(t *testing.T) {
 		{
 			name: "get user by id",
 			args: args{
-				ctx: ctx,
-				todo: domain.Todo{
-					ID:       primitive.NewObjectID(),
-					UserID:   primitive.NewObjectID(),
-					Title:    utils.RandomString(25),
-					ActiveAt: time.Now().Add(time.Hour * 24).Format(domain.Format),
-					Author:   utils.RandomString(10),
-					Status:   domain.Active,
-				},
-			},
-			buildStubs: func(todo domain.Todo) {
-				var count int64 = 0
-				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), todo.Title, todo.UserID).Times(1).Return(count, nil)
-				todoRepo.EXPECT().UpdateTodo(gomock.Any(), todo).Times(1).Return(nil)
-				todoRepo.EXPECT().GetTodoByID(gomock.Any(), todo.ID).Times(1).Return(domain.Todo{}, domain.ErrInternalServer)
+				ctx:    ctx,
+				id:     utils.RandomString(24),
+				userID: utils.RandomString(24),
+				inp:    domain.TodoRequest{Title: utils.RandomString(25), ActiveAt: tomorrow},
+			},
+			buildStubs: func(id, userID string, inp domain.TodoRequest) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), inp.Title, userID).Times(1).Return(int64(0), nil)
+				todoRepo.EXPECT().UpdateTodo(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), id).Times(1).Return(domain.Todo{ID: id, UserID: userID}, nil)
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), id).Times(1).Return(domain.Todo{}, domain.ErrInternalServer)
 
 			},
 			checkResponse: func(todo domain.Todo, err error) {
 				require.Equal(t, err, domain.ErrInternalServer)
 			},
 		},
+		{
+			name: "forbidden",
+			args: args{
+				ctx:    ctx,
+				id:     utils.RandomString(24),
+				userID: utils.RandomString(24),
+				inp:    domain.TodoRequest{Title: utils.RandomString(25), ActiveAt: tomorrow},
+			},
+			buildStubs: func(id, userID string, inp domain.TodoRequest) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), id).Times(1).Return(domain.Todo{ID: id, UserID: utils.RandomString(24)}, nil)
+				todoRepo.EXPECT().GetCountByTitle(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().UpdateTodo(gomock.Any(), gomock.Any()).Times(0)
+
+			},
+			checkResponse: func(todo domain.Todo, err error) {
+				require.Equal(t, err, domain.ErrForbidden)
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.buildStubs(tt.args.todo)
+			tt.buildStubs(tt.args.id, tt.args.userID, tt.args.inp)
 
-			todo, err := todoService.UpdateTodo(tt.args.ctx, tt.args.todo)
+			todo, err := todoService.UpdateTodo(tt.args.ctx, tt.args.id, tt.args.userID, tt.args.inp)
 
 			tt.checkResponse(todo, err)
 		})
@@ -538,28 +644,36 @@ func TestTodoService_DeleteTodoByID(t *testing.T) {
 	defer ctrl.Finish()
 
 	todoRepo := mocksRepo.NewMockTodo(ctrl)
+	itemRepo := mocksRepo.NewMockTodoItems(ctrl)
 	userRepo := mocksRepo.NewMockUsers(ctrl)
 
-	todoService := NewTodoService(todoRepo, userRepo)
+	todoService := NewTodoService(todoRepo, itemRepo, userRepo, newCalendarService(t, ctrl), true, 0)
+
+	// Checklist progress is covered in todo_item_test.go.
+	itemRepo.EXPECT().GetProgress(gomock.Any(), gomock.Any()).AnyTimes().Return(map[string]domain.Progress{}, nil)
+	itemRepo.EXPECT().DeleteItems(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
 	type args struct {
-		ctx context.Context
-		id  string
+		ctx    context.Context
+		id     string
+		userID string
 	}
 	tests := []struct {
 		name          string
 		args          args
-		buildStubs    func(id primitive.ObjectID)
+		buildStubs    func(id, userID string)
 		checkResponse func(err error)
 	}{
 		// TODO: Add test cases.
 		{
 			name: "Ok",
 			args: args{
-				ctx: ctx,
-				id:  primitive.NewObjectID().Hex(),
+				ctx:    ctx,
+				id:     utils.RandomString(24),
+				userID: utils.RandomString(24),
 			},
-			buildStubs: func(id primitive.ObjectID) {
-				todoRepo.EXPECT().DeleteTodoByID(gomock.Any(), id).Return(nil)
+			buildStubs: func(id, userID string) {
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), id).Times(1).Return(domain.Todo{ID: id, UserID: userID}, nil)
+				todoRepo.EXPECT().DeleteTodoByID(gomock.Any(), id, userID).Return(nil)
 			},
 			checkResponse: func(err error) {
 				require.NoError(t, err)
@@ -568,11 +682,13 @@ func TestTodoService_DeleteTodoByID(t *testing.T) {
 		{
 			name: "todo invalid id",
 			args: args{
-				ctx: ctx,
-				id:  utils.RandomString(10),
+				ctx:    ctx,
+				id:     "",
+				userID: utils.RandomString(24),
 			},
-			buildStubs: func(id primitive.ObjectID) {
-				todoRepo.EXPECT().DeleteTodoByID(gomock.Any(), id).Times(0)
+			buildStubs: func(id, userID string) {
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), id).Times(0)
+				todoRepo.EXPECT().DeleteTodoByID(gomock.Any(), id, userID).Times(0)
 			},
 			checkResponse: func(err error) {
 				require.Equal(t, err, domain.ErrTodoInvalidId)
@@ -581,23 +697,39 @@ func TestTodoService_DeleteTodoByID(t *testing.T) {
 		{
 			name: "not found",
 			args: args{
-				ctx: ctx,
-				id:  primitive.NewObjectID().Hex(),
+				ctx:    ctx,
+				id:     utils.RandomString(24),
+				userID: utils.RandomString(24),
 			},
-			buildStubs: func(id primitive.ObjectID) {
-				todoRepo.EXPECT().DeleteTodoByID(gomock.Any(), id).Times(1).Return(domain.ErrNotFound)
+			buildStubs: func(id, userID string) {
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), id).Times(1).Return(domain.Todo{}, domain.ErrNotFound)
+				todoRepo.EXPECT().DeleteTodoByID(gomock.Any(), id, userID).Times(0)
 			},
 			checkResponse: func(err error) {
 				require.Equal(t, err, domain.ErrNotFound)
 			},
 		},
+		{
+			name: "forbidden",
+			args: args{
+				ctx:    ctx,
+				id:     utils.RandomString(24),
+				userID: utils.RandomString(24),
+			},
+			buildStubs: func(id, userID string) {
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), id).Times(1).Return(domain.Todo{ID: id, UserID: utils.RandomString(24)}, nil)
+				todoRepo.EXPECT().DeleteTodoByID(gomock.Any(), id, userID).Times(0)
+			},
+			checkResponse: func(err error) {
+				require.Equal(t, err, domain.ErrForbidden)
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			id, _ := primitive.ObjectIDFromHex(tt.args.id)
-			tt.buildStubs(id)
+			tt.buildStubs(tt.args.id, tt.args.userID)
 
-			err := todoService.DeleteTodoByID(tt.args.ctx, tt.args.id)
+			err := todoService.DeleteTodoByID(tt.args.ctx, tt.args.id, tt.args.userID)
 
 			tt.checkResponse(err)
 		})
@@ -609,42 +741,48 @@ func TestTodoService_UpdateTodoDoneByID(t *testing.T) {
 	defer ctrl.Finish()
 
 	todoRepo := mocksRepo.NewMockTodo(ctrl)
+	itemRepo := mocksRepo.NewMockTodoItems(ctrl)
 	userRepo := mocksRepo.NewMockUsers(ctrl)
 
-	todoService := NewTodoService(todoRepo, userRepo)
+	todoService := NewTodoService(todoRepo, itemRepo, userRepo, newCalendarService(t, ctrl), true, 0)
+
+	// Checklist progress is covered in todo_item_test.go.
+	itemRepo.EXPECT().GetProgress(gomock.Any(), gomock.Any()).AnyTimes().Return(map[string]domain.Progress{}, nil)
+	itemRepo.EXPECT().DeleteItems(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
 
 	type args struct {
 		ctx    context.Context
 		id     string
-		userID primitive.ObjectID
+		userID string
 	}
 	tests := []struct {
 		name          string
 		args          args
-		buildStubs    func(todoID, userID primitive.ObjectID)
-		checkResponse func(todo domain.Todo, err1, err2 error)
+		buildStubs    func(todoID, userID string)
+		checkResponse func(todo domain.Todo, err error)
 	}{
 		// TODO: Add test cases.
 		{
 			name: "OK",
 			args: args{
 				ctx:    ctx,
-				id:     primitive.NewObjectID().Hex(),
-				userID: primitive.NewObjectID(),
+				id:     utils.RandomString(24),
+				userID: utils.RandomString(24),
 			},
-			buildStubs: func(todoID, userID primitive.ObjectID) {
+			buildStubs: func(todoID, userID string) {
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), todoID).Times(1).Return(domain.Todo{ID: todoID, UserID: userID}, nil)
 				todoRepo.EXPECT().UpdateTodoDoneByID(gomock.Any(), todoID, userID).Return(domain.Todo{
 					ID:       todoID,
 					UserID:   userID,
 					Title:    utils.RandomString(10),
-					ActiveAt: utils.RandomString(10),
+					ActiveAt: time.Now().UTC(),
 					Author:   utils.RandomString(10),
 					Status:   domain.Done,
 				}, nil)
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
 			},
-			checkResponse: func(todo domain.Todo, err1, err2 error) {
-				require.NoError(t, err1)
-				require.NoError(t, err2)
+			checkResponse: func(todo domain.Todo, err error) {
+				require.NoError(t, err)
 				require.Equal(t, todo.Status, domain.Done)
 			},
 		},
@@ -653,130 +791,398 @@ func TestTodoService_UpdateTodoDoneByID(t *testing.T) {
 			args: args{
 				ctx:    ctx,
 				id:     "",
-				userID: primitive.NewObjectID(),
+				userID: utils.RandomString(24),
 			},
-			buildStubs: func(todoID, userID primitive.ObjectID) {
+			buildStubs: func(todoID, userID string) {
 				todoRepo.EXPECT().UpdateTodoDoneByID(gomock.Any(), todoID, userID).Times(0)
 			},
-			checkResponse: func(todo domain.Todo, err1, err2 error) {
-				require.Equal(t, err1.Error(), "the provided hex string is not a valid ObjectID")
-				require.Equal(t, err2, domain.ErrTodoInvalidId)
-				// require.Equal(t, todo.Status, domain.Done)
+			checkResponse: func(todo domain.Todo, err error) {
+				require.Equal(t, err, domain.ErrTodoInvalidId)
 			},
 		},
 		{
 			name: "not found",
 			args: args{
 				ctx:    ctx,
-				id:     primitive.NewObjectID().Hex(),
-				userID: primitive.NewObjectID(),
+				id:     utils.RandomString(24),
+				userID: utils.RandomString(24),
+			},
+			buildStubs: func(todoID, userID string) {
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), todoID).Times(1).Return(domain.Todo{}, domain.ErrNotFound)
+				todoRepo.EXPECT().UpdateTodoDoneByID(gomock.Any(), todoID, userID).Times(0)
+			},
+			checkResponse: func(todo domain.Todo, err error) {
+				require.Equal(t, err, domain.ErrNotFound)
+			},
+		},
+		{
+			name: "forbidden",
+			args: args{
+				ctx:    ctx,
+				id:     utils.RandomString(24),
+				userID: utils.RandomString(24),
 			},
-			buildStubs: func(todoID, userID primitive.ObjectID) {
-				todoRepo.EXPECT().UpdateTodoDoneByID(gomock.Any(), todoID, userID).Times(1).Return(domain.Todo{}, domain.ErrNotFound)
+			buildStubs: func(todoID, userID string) {
+				todoRepo.EXPECT().GetTodoByID(gomock.Any(), todoID).Times(1).Return(domain.Todo{ID: todoID, UserID: utils.RandomString(24)}, nil)
+				todoRepo.EXPECT().UpdateTodoDoneByID(gomock.Any(), todoID, userID).Times(0)
 			},
-			checkResponse: func(todo domain.Todo, err1, err2 error) {
-				require.NoError(t, err1)
-				require.Equal(t, err2, domain.ErrNotFound)
-				// require.Equal(t, todo.Status, domain.Done)
+			checkResponse: func(todo domain.Todo, err error) {
+				require.Equal(t, err, domain.ErrForbidden)
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			todoID, err1 := primitive.ObjectIDFromHex(tt.args.id)
-			tt.buildStubs(todoID, tt.args.userID)
+			tt.buildStubs(tt.args.id, tt.args.userID)
 
-			todo, err2 := todoService.UpdateTodoDoneByID(tt.args.ctx, tt.args.id, tt.args.userID)
+			todo, err := todoService.UpdateTodoDoneByID(tt.args.ctx, tt.args.id, tt.args.userID)
 
-			tt.checkResponse(todo, err1, err2)
+			tt.checkResponse(todo, err)
 		})
 	}
 }
 
-func TestTodoService_GetTodosByStatus(t *testing.T) {
+func TestTodoService_GetTodos(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	todoRepo := mocksRepo.NewMockTodo(ctrl)
+	itemRepo := mocksRepo.NewMockTodoItems(ctrl)
 	userRepo := mocksRepo.NewMockUsers(ctrl)
 
-	todoService := NewTodoService(todoRepo, userRepo)
+	todoService := NewTodoService(todoRepo, itemRepo, userRepo, newCalendarService(t, ctrl), true, 0)
+
+	// Checklist progress is covered in todo_item_test.go.
+	itemRepo.EXPECT().GetProgress(gomock.Any(), gomock.Any()).AnyTimes().Return(map[string]domain.Progress{}, nil)
+	itemRepo.EXPECT().DeleteItems(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+
+	newTodos := func(userID string, n int) []domain.Todo {
+		var todos []domain.Todo
+		for i := 0; i < n; i++ {
+			todos = append(todos, domain.Todo{
+				ID:       utils.RandomString(24),
+				UserID:   userID,
+				Title:    utils.RandomString(10),
+				ActiveAt: time.Now().UTC(),
+				Author:   utils.RandomString(10),
+				Status:   domain.Active,
+			})
+		}
+
+		return todos
+	}
 
 	type args struct {
 		ctx    context.Context
-		status string
-		userID primitive.ObjectID
+		userID string
+		inp    domain.TodoListRequest
 	}
 	tests := []struct {
 		name          string
 		args          args
-		buildStubs    func(status string, userID primitive.ObjectID)
-		checkResponse func(todos []domain.Todo, err error)
+		buildStubs    func(userID string)
+		checkResponse func(page domain.TodoPage, err error)
 	}{
-		// TODO: Add test cases.
 		{
 			name: "OK",
 			args: args{
 				ctx:    ctx,
-				status: domain.Active,
-				userID: primitive.NewObjectID(),
+				userID: utils.RandomString(24),
+				inp:    domain.TodoListRequest{Limit: 2},
+			},
+			buildStubs: func(userID string) {
+				isFilter := gomock.AssignableToTypeOf(domain.TodoFilter{})
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID, Timezone: "Asia/Almaty"}, nil)
+				todoRepo.EXPECT().GetCountByFilter(gomock.Any(), isFilter).Times(1).DoAndReturn(func(_ context.Context, filter domain.TodoFilter) (int64, error) {
+					require.Equal(t, []string{domain.Active}, filter.Statuses)
+					require.WithinDuration(t, time.Now(), filter.ActiveBefore, time.Minute)
+					require.Equal(t, 2, filter.Limit)
+					return 5, nil
+				})
+				todoRepo.EXPECT().GetTodos(gomock.Any(), isFilter).Times(1).DoAndReturn(func(_ context.Context, query domain.TodoFilter) ([]domain.Todo, error) {
+					require.Equal(t, 3, query.Limit)
+					return newTodos(userID, 3), nil
+				})
+			},
+			checkResponse: func(page domain.TodoPage, err error) {
+				require.NoError(t, err)
+				require.Len(t, page.Items, 2)
+				require.Equal(t, int64(5), page.Total)
+				require.NotEmpty(t, page.NextCursor)
+
+				cursor, err := decodeCursor(page.NextCursor)
+				require.NoError(t, err)
+				require.Equal(t, page.Items[1].ID, cursor.ID)
 			},
-			buildStubs: func(status string, userID primitive.ObjectID) {
-				todoRepo.EXPECT().GetTodoByStatus(gomock.Any(), status, userID).Return([]domain.Todo{
-					{
-						ID:       primitive.NewObjectID(),
-						UserID:   userID,
-						Title:    utils.RandomString(10),
-						ActiveAt: time.Now().Add(time.Hour * 24).Format(domain.Format),
-						Author:   utils.RandomString(10),
-						Status:   status,
-					},
-					{
-						ID:       primitive.NewObjectID(),
-						UserID:   userID,
-						Title:    utils.RandomString(10),
-						ActiveAt: time.Now().Add(time.Hour * 24).Format(domain.Format),
-						Author:   utils.RandomString(10),
-						Status:   status,
-					},
-					{
-						ID:       primitive.NewObjectID(),
-						UserID:   userID,
-						Title:    utils.RandomString(10),
-						ActiveAt: time.Now().Add(time.Hour * 24).Format(domain.Format),
-						Author:   utils.RandomString(10),
-						Status:   status,
-					},
-					{
-						ID:       primitive.NewObjectID(),
-						UserID:   userID,
-						Title:    utils.RandomString(10),
-						ActiveAt: time.Now().Add(time.Hour * 24).Format(domain.Format),
-						Author:   utils.RandomString(10),
-						Status:   status,
-					},
+		},
+		{
+			name: "last page",
+			args: args{
+				ctx:    ctx,
+				userID: utils.RandomString(24),
+				inp:    domain.TodoListRequest{Status: []string{"active,done"}},
+			},
+			buildStubs: func(userID string) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
+				todoRepo.EXPECT().GetCountByFilter(gomock.Any(), gomock.Any()).Times(1).Return(int64(3), nil)
+				todoRepo.EXPECT().GetTodos(gomock.Any(), gomock.Any()).Times(1).Return(newTodos(userID, 3), nil)
+			},
+			checkResponse: func(page domain.TodoPage, err error) {
+				require.NoError(t, err)
+				require.Len(t, page.Items, 3)
+				require.Empty(t, page.NextCursor)
+			},
+		},
+		{
+			name: "invalid filter",
+			args: args{
+				ctx:    ctx,
+				userID: utils.RandomString(24),
+				inp:    domain.TodoListRequest{Status: []string{utils.RandomString(6)}},
+			},
+			buildStubs: func(userID string) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
+				todoRepo.EXPECT().GetCountByFilter(gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().GetTodos(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(page domain.TodoPage, err error) {
+				require.Equal(t, err, domain.ErrInvalidFilter)
+			},
+		},
+		{
+			name: "invalid cursor",
+			args: args{
+				ctx:    ctx,
+				userID: utils.RandomString(24),
+				inp:    domain.TodoListRequest{Cursor: utils.RandomString(10)},
+			},
+			buildStubs: func(userID string) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
+				todoRepo.EXPECT().GetCountByFilter(gomock.Any(), gomock.Any()).Times(0)
+				todoRepo.EXPECT().GetTodos(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(page domain.TodoPage, err error) {
+				require.Equal(t, err, domain.ErrInvalidCursor)
+			},
+		},
+		{
+			name: "internal server",
+			args: args{
+				ctx:    ctx,
+				userID: utils.RandomString(24),
+			},
+			buildStubs: func(userID string) {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
+				todoRepo.EXPECT().GetCountByFilter(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), domain.ErrInternalServer)
+				todoRepo.EXPECT().GetTodos(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(page domain.TodoPage, err error) {
+				require.Equal(t, err, domain.ErrInternalServer)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.buildStubs(tt.args.userID)
+
+			page, err := todoService.GetTodos(tt.args.ctx, tt.args.userID, tt.args.inp)
+
+			tt.checkResponse(page, err)
+		})
+	}
+}
+
+func TestTodoService_SearchTodos(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	todoRepo := mocksRepo.NewMockTodo(ctrl)
+	itemRepo := mocksRepo.NewMockTodoItems(ctrl)
+	userRepo := mocksRepo.NewMockUsers(ctrl)
+
+	todoService := NewTodoService(todoRepo, itemRepo, userRepo, newCalendarService(t, ctrl), true, 0)
+
+	// Checklist progress is covered in todo_item_test.go.
+	itemRepo.EXPECT().GetProgress(gomock.Any(), gomock.Any()).AnyTimes().Return(map[string]domain.Progress{}, nil)
+	itemRepo.EXPECT().DeleteItems(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+
+	type args struct {
+		ctx    context.Context
+		userID string
+		inp    domain.SearchRequest
+	}
+	tests := []struct {
+		name          string
+		args          args
+		buildStubs    func(userID string)
+		checkResponse func(results []domain.SearchResult, err error)
+	}{
+		{
+			name: "OK",
+			args: args{
+				ctx:    ctx,
+				userID: utils.RandomString(24),
+				inp:    domain.SearchRequest{Query: `молоко -кофе`},
+			},
+			buildStubs: func(userID string) {
+				query, err := domain.ParseSearchQuery(`молоко -кофе`)
+				require.NoError(t, err)
+
+				todoRepo.EXPECT().SearchTodos(gomock.Any(), userID, query, domain.DefaultSearchLimit).Times(1).Return([]domain.SearchResult{
 					{
-						ID:       primitive.NewObjectID(),
-						UserID:   userID,
-						Title:    utils.RandomString(10),
-						ActiveAt: time.Now().Add(time.Hour * 24).Format(domain.Format),
-						Author:   utils.RandomString(10),
-						Status:   status,
+						Todo: domain.Todo{
+							ID:          utils.RandomString(24),
+							UserID:      userID,
+							Title:       "Купить молоко",
+							Description: "Без сахара",
+						},
+						Score: 3,
 					},
 				}, nil)
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
 			},
-			checkResponse: func(todos []domain.Todo, err error) {
+			checkResponse: func(results []domain.SearchResult, err error) {
 				require.NoError(t, err)
+				require.Len(t, results, 1)
+				require.Equal(t, map[string]string{"title": "Купить <mark>молоко</mark>"}, results[0].Highlights)
+			},
+		},
+		{
+			name: "no results",
+			args: args{
+				ctx:    ctx,
+				userID: utils.RandomString(24),
+				inp:    domain.SearchRequest{Query: utils.RandomString(10), Limit: 1000},
+			},
+			buildStubs: func(userID string) {
+				todoRepo.EXPECT().SearchTodos(gomock.Any(), userID, gomock.Any(), domain.MaxSearchLimit).Times(1).Return(nil, nil)
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID}, nil)
+			},
+			checkResponse: func(results []domain.SearchResult, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, results)
+				require.Empty(t, results)
+			},
+		},
+		{
+			name: "invalid search",
+			args: args{
+				ctx:    ctx,
+				userID: utils.RandomString(24),
+				inp:    domain.SearchRequest{Query: "-кофе"},
+			},
+			buildStubs: func(userID string) {
+				todoRepo.EXPECT().SearchTodos(gomock.Any(), userID, gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(results []domain.SearchResult, err error) {
+				require.Equal(t, err, domain.ErrInvalidSearch)
+			},
+		},
+		{
+			name: "internal server",
+			args: args{
+				ctx:    ctx,
+				userID: utils.RandomString(24),
+				inp:    domain.SearchRequest{Query: utils.RandomString(10)},
+			},
+			buildStubs: func(userID string) {
+				todoRepo.EXPECT().SearchTodos(gomock.Any(), userID, gomock.Any(), gomock.Any()).Times(1).Return(nil, domain.ErrInternalServer)
+			},
+			checkResponse: func(results []domain.SearchResult, err error) {
+				require.Equal(t, err, domain.ErrInternalServer)
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.buildStubs(tt.args.status, tt.args.userID)
+			tt.buildStubs(tt.args.userID)
+
+			results, err := todoService.SearchTodos(tt.args.ctx, tt.args.userID, tt.args.inp)
+
+			tt.checkResponse(results, err)
+		})
+	}
+}
+
+func TestValidateTodo(t *testing.T) {
+	now := time.Date(2030, time.March, 10, 12, 0, 0, 0, time.UTC)
+	loc := time.FixedZone("UTC+6", 6*60*60)
+	activeAt := now.Add(time.Hour * 48)
+	due := now.Add(time.Hour * 96)
+
+	tests := []struct {
+		name string
+		todo domain.Todo
+		want domain.Todo
+		err  error
+	}{
+		{
+			name: "defaults",
+			todo: domain.Todo{Title: "title", ActiveAt: activeAt},
+			want: domain.Todo{Title: "title", Priority: domain.PriorityMedium, ActiveAt: activeAt},
+		},
+		{
+			name: "normalized tags and due time",
+			todo: domain.Todo{Title: "title", Priority: domain.PriorityHigh, Tags: []string{"#Work", "work", " home "}, ActiveAt: activeAt, DueAt: &due},
+			want: domain.Todo{Title: "title", Priority: domain.PriorityHigh, Tags: []string{"work", "home"}, ActiveAt: activeAt, DueAt: timePtr(due.UTC().Truncate(time.Second))},
+		},
+		{
+			name: "description too long",
+			todo: domain.Todo{Title: "title", Description: strings.Repeat("я", domain.MaxDescriptionLength+1), ActiveAt: activeAt},
+			err:  domain.ErrDescriptionLength,
+		},
+		{
+			name: "invalid tag",
+			todo: domain.Todo{Title: "title", Tags: []string{"two words"}, ActiveAt: activeAt},
+			err:  domain.ErrInvalidTag,
+		},
+		{
+			name: "too long tag",
+			todo: domain.Todo{Title: "title", Tags: []string{strings.Repeat("a", domain.MaxTagLength+1)}, ActiveAt: activeAt},
+			err:  domain.ErrInvalidTag,
+		},
+		{
+			name: "too many tags",
+			todo: domain.Todo{Title: "title", Tags: strings.Split("a,b,c,d,e,f,g,h,i,j,k", ","), ActiveAt: activeAt},
+			err:  domain.ErrTooManyTags,
+		},
+		{
+			name: "canonical recurrence",
+			todo: domain.Todo{Title: "title", ActiveAt: activeAt, Recurrence: "rrule:freq=weekly;interval=1;byday=we,mo"},
+			want: domain.Todo{Title: "title", Priority: domain.PriorityMedium, ActiveAt: activeAt, Recurrence: "FREQ=WEEKLY;BYDAY=MO,WE"},
+		},
+		{
+			name: "today in the user's timezone",
+			todo: domain.Todo{Title: "title", ActiveAt: time.Date(2030, time.March, 10, 0, 0, 0, 0, loc)},
+			want: domain.Todo{Title: "title", Priority: domain.PriorityMedium, ActiveAt: time.Date(2030, time.March, 9, 18, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "yesterday in the user's timezone",
+			todo: domain.Todo{Title: "title", ActiveAt: time.Date(2030, time.March, 9, 23, 0, 0, 0, loc)},
+			err:  domain.ErrTodoActiveAtData,
+		},
+		{
+			name: "invalid recurrence",
+			todo: domain.Todo{Title: "title", ActiveAt: activeAt, Recurrence: "FREQ=YEARLY"},
+			err:  domain.ErrInvalidRecurrence,
+		},
+	}
 
-			todos, err := todoService.GetTodosByStatus(tt.args.ctx, tt.args.status, tt.args.userID)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todo := tt.todo
+			err := validateTodo(&todo, loc, now)
+			if tt.err != nil {
+				require.Equal(t, tt.err, err)
+				return
+			}
 
-			tt.checkResponse(todos, err)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, todo)
 		})
 	}
 }
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
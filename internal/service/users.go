@@ -2,35 +2,88 @@ package service
 
 import (
 	"context"
-	"net/mail"
+	"errors"
+	netmail "net/mail"
+	"strings"
 	"time"
 
 	"github.com/begenov/region-llc-task/internal/domain"
+	"github.com/begenov/region-llc-task/internal/i18n"
 	"github.com/begenov/region-llc-task/internal/repository"
 	"github.com/begenov/region-llc-task/pkg/auth"
 	"github.com/begenov/region-llc-task/pkg/hash"
 	"github.com/begenov/region-llc-task/pkg/logger"
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	"github.com/begenov/region-llc-task/pkg/mail"
+)
+
+const (
+	// deniedTokenKey marks a revoked access token by its jti until it
+	// expires.
+	deniedTokenKey = "denylist:"
+	// revokedSessionKey marks an ended session, so that the access tokens
+	// issued for it are revoked until they expire.
+	revokedSessionKey = "revoked-session:"
+	// usedRefreshTokenKey marks a replaced refresh token by its hash with the
+	// user and session it was issued for, until it would have expired.
+	usedRefreshTokenKey = "used-refresh-token:"
 )
 
 type UserService struct {
-	userRepo        repository.Users
-	hash            hash.PasswordHasher
-	manager         *auth.Manager
-	accessTokenTTL  time.Duration
-	refreshTokenTTL time.Duration
-	redisRepo       repository.Redis
+	userRepo          repository.Users
+	sessionRepo       repository.Sessions
+	personalTokenRepo repository.PersonalTokens
+	mfaRepo           repository.MFA
+	hash              hash.PasswordHasher
+	manager           *auth.Manager
+	accessTokenTTL    time.Duration
+	refreshTokenTTL   time.Duration
+	redisRepo         repository.Redis
+	mfa               MFAPolicy
+	mailer            mail.Mailer
+	reset             PasswordResetPolicy
+	verification      EmailVerificationPolicy
+	account           AccountPolicy
+	signIn            SignInPolicy
+	auditor           auditor
 }
 
-func NewUserService(userRepo repository.Users, hash hash.PasswordHasher, manager *auth.Manager,
-	accessTokenTTL time.Duration, refreshTokenTTL time.Duration, redisRepo repository.Redis) *UserService {
+func NewUserService(userRepo repository.Users, sessionRepo repository.Sessions, personalTokenRepo repository.PersonalTokens,
+	mfaRepo repository.MFA, hash hash.PasswordHasher, manager *auth.Manager, accessTokenTTL time.Duration,
+	refreshTokenTTL time.Duration, redisRepo repository.Redis, mfa MFAPolicy, mailer mail.Mailer,
+	reset PasswordResetPolicy, verification EmailVerificationPolicy, account AccountPolicy, signIn SignInPolicy) *UserService {
+	if mfa.ChallengeTTL <= 0 {
+		mfa.ChallengeTTL = defaultMFAChallengeTTL
+	}
+
+	if reset.TokenTTL <= 0 {
+		reset.TokenTTL = defaultPasswordResetTTL
+	}
+
+	if verification.TokenTTL <= 0 {
+		verification.TokenTTL = defaultEmailVerificationTTL
+	}
+
+	if account.DeletionGrace <= 0 {
+		account.DeletionGrace = defaultDeletionGrace
+	}
+
 	return &UserService{
-		userRepo:        userRepo,
-		hash:            hash,
-		manager:         manager,
-		accessTokenTTL:  accessTokenTTL,
-		refreshTokenTTL: refreshTokenTTL,
-		redisRepo:       redisRepo,
+		userRepo:          userRepo,
+		sessionRepo:       sessionRepo,
+		personalTokenRepo: personalTokenRepo,
+		mfaRepo:           mfaRepo,
+		hash:              hash,
+		manager:           manager,
+		accessTokenTTL:    accessTokenTTL,
+		refreshTokenTTL:   refreshTokenTTL,
+		redisRepo:         redisRepo,
+		mfa:               mfa,
+		mailer:            mailer,
+		reset:             reset,
+		verification:      verification,
+		account:           account,
+		signIn:            signIn.withDefaults(),
+		auditor:           logAuditor{},
 	}
 }
 
@@ -45,7 +98,18 @@ func (s *UserService) SignUp(ctx context.Context, inp domain.UserRequest) (domai
 		return domain.User{}, domain.ErrIncorrectUserName
 	}
 
-	_, err := s.userRepo.GetUserByEmail(ctx, inp.Email)
+	timezone, err := validateTimezone(inp.Timezone)
+	if err != nil {
+		logger.Errorf("validateTimezone(): %v", err)
+		return domain.User{}, err
+	}
+
+	if err := validateLanguage(inp.Language); err != nil {
+		logger.Errorf("validateLanguage(): %v", err)
+		return domain.User{}, err
+	}
+
+	_, err = s.userRepo.GetUserByEmail(ctx, inp.Email)
 	if err == nil {
 		logger.Errorf("s.registerRepo.GetRegisterUsername(): %v", err)
 		return domain.User{}, domain.ErrEmailAlreadyExists
@@ -61,6 +125,8 @@ func (s *UserService) SignUp(ctx context.Context, inp domain.UserRequest) (domai
 		UserName: inp.UserName,
 		Email:    inp.Email,
 		Password: passwordHash,
+		Timezone: timezone,
+		Language: inp.Language,
 		CreateAt: time.Now().Format("2006-01-02"),
 	}
 
@@ -70,17 +136,35 @@ func (s *UserService) SignUp(ctx context.Context, inp domain.UserRequest) (domai
 		return user, err
 	}
 
+	// The account is created anyway, the link can be sent again.
+	if err := s.sendVerification(ctx, user, user.Email, inp.Language); err != nil {
+		logger.Errorf("s.sendVerification(): %v", err)
+	}
+
 	return user, nil
 }
 
-func (s *UserService) SignIn(ctx context.Context, email, password string) (domain.Token, error) {
+// SignIn starts a session of the user. A user with two-factor authentication
+// gets an MFA token instead, the session is started by VerifyMFA. Signing in
+// keeps a deleted account whose grace period has not ended. Failed sign-ins
+// are limited by the SignInPolicy.
+func (s *UserService) SignIn(ctx context.Context, email, password string, client domain.Client) (domain.Token, error) {
 
 	if err := validateUser(email, password); err != nil {
 		logger.Errorf("validateUser(): %v", err)
 		return domain.Token{}, err
 	}
 
+	if err := s.checkSignIn(email, client); err != nil {
+		return domain.Token{}, err
+	}
+
 	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if errors.Is(err, domain.ErrNotFound) {
+		if err := s.failSignIn(ctx, email, client); err != nil {
+			return domain.Token{}, err
+		}
+	}
 	if err != nil {
 		logger.Errorf("s.userRepo.GetUserByEmail(): %v", err)
 		return domain.Token{}, err
@@ -88,68 +172,312 @@ func (s *UserService) SignIn(ctx context.Context, email, password string) (domai
 
 	if err := s.hash.CompareHashAndPassword(user.Password, password); err != nil {
 		logger.Errorf("s.hash.CompareHashAndPassword(): %v", err)
+		if err := s.failSignIn(ctx, email, client); err != nil {
+			return domain.Token{}, err
+		}
 		return domain.Token{}, err
 	}
 
-	return s.createSession(ctx, user.ID)
+	token, err := s.signInMFA(ctx, user)
+	if err != nil {
+		return domain.Token{}, err
+	}
+
+	if token.MFAToken != "" {
+		return token, nil
+	}
+
+	return s.signInSession(ctx, user, client)
 }
 
-func (s *UserService) RefreshTokens(ctx context.Context, refreshToken string) (domain.Token, error) {
-	idString, err := s.redisRepo.Get(refreshToken)
-	if err == nil {
-		idObject, err := primitive.ObjectIDFromHex(idString)
+// RefreshTokens issues new tokens for the session of the refresh token, the
+// refresh token itself is replaced. A refresh token is accepted once: using a
+// replaced one again means it has leaked, so the session it belongs to is
+// ended for every holder of its tokens.
+func (s *UserService) RefreshTokens(ctx context.Context, refreshToken string, client domain.Client) (domain.Token, error) {
+	tokenHash := auth.HashToken(refreshToken)
+
+	session, err := s.sessionRepo.GetByRefreshToken(ctx, tokenHash)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.Token{}, s.checkRefreshTokenReuse(ctx, tokenHash, client)
+	}
+	if err != nil {
+		logger.Errorf("s.sessionRepo.GetByRefreshToken(): %v", err)
+		return domain.Token{}, err
+	}
+
+	var res domain.Token
+	res.RefreshToken, err = s.manager.NewRefreshToken()
+	if err != nil {
+		logger.Errorf("s.manager.NewRefreshToken(): %v", err)
+		return domain.Token{}, err
+	}
+
+	// The token is marked as used before it is replaced, so that it is
+	// never replaced without being recognized afterwards.
+	err = s.redisRepo.Set(usedRefreshTokenKey+tokenHash, session.UserID+":"+session.ID, time.Until(session.ExpirationAt))
+	if err != nil {
+		logger.Errorf("s.redisRepo.Set(): %v", err)
+		return domain.Token{}, err
+	}
+
+	now := time.Now()
+	rotated := session
+	rotated.RefreshTokenHash = auth.HashToken(res.RefreshToken)
+	rotated.UserAgent = client.UserAgent
+	rotated.IP = client.IP
+	rotated.LastUsedAt = now
+	rotated.ExpirationAt = now.Add(s.refreshTokenTTL)
+
+	err = s.sessionRepo.RotateRefreshToken(ctx, rotated, tokenHash)
+	if errors.Is(err, domain.ErrNotFound) {
+		// A concurrent refresh has replaced the token in between.
+		return domain.Token{}, s.revokeRefreshTokenFamily(ctx, session.UserID, session.ID, client)
+	}
+	if err != nil {
+		logger.Errorf("s.sessionRepo.RotateRefreshToken(): %v", err)
+		return domain.Token{}, err
+	}
+
+	res.AccessToken, err = s.manager.NewJWT(accessClaims(session.UserID, session.ID), s.accessTokenTTL)
+	if err != nil {
+		logger.Errorf("s.manager.NewJWT(): %v", err)
+		return domain.Token{}, err
+	}
+
+	return res, nil
+}
+
+// checkRefreshTokenReuse revokes the session of a refresh token that has
+// already been replaced, ErrNotFound for a token that is unknown or expired.
+func (s *UserService) checkRefreshTokenReuse(ctx context.Context, tokenHash string, client domain.Client) error {
+	family, err := s.redisRepo.Get(usedRefreshTokenKey + tokenHash)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.ErrNotFound
+	}
+	if err != nil {
+		logger.Errorf("s.redisRepo.Get(): %v", err)
+		return err
+	}
+
+	userID, sessionID, _ := strings.Cut(family, ":")
+
+	return s.revokeRefreshTokenFamily(ctx, userID, sessionID, client)
+}
+
+// revokeRefreshTokenFamily ends the session of a reused refresh token and
+// reports the reuse as a security event.
+func (s *UserService) revokeRefreshTokenFamily(ctx context.Context, userID string, sessionID string, client domain.Client) error {
+	logger.Warnf("security: refresh token reused, session %s of user %s revoked, ip %q, user agent %q",
+		sessionID, userID, client.IP, client.UserAgent)
+
+	if err := s.RevokeSession(ctx, userID, sessionID); err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
+
+	return domain.ErrTokenRevoked
+}
+
+// CheckAccessToken reports ErrTokenRevoked for an access token revoked by
+// Logout or issued for a session that has ended.
+func (s *UserService) CheckAccessToken(ctx context.Context, claims auth.Claims) error {
+	keys := []string{deniedTokenKey + claims.ID}
+	if claims.SessionID != "" {
+		keys = append(keys, revokedSessionKey+claims.SessionID)
+	}
+
+	for _, key := range keys {
+		_, err := s.redisRepo.Get(key)
 		if err == nil {
-			return s.createSession(ctx, idObject)
+			return domain.ErrTokenRevoked
+		}
+
+		if !errors.Is(err, domain.ErrNotFound) {
+			logger.Errorf("s.redisRepo.Get(): %v", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Logout revokes the access token and ends the session it was issued for.
+func (s *UserService) Logout(ctx context.Context, claims auth.Claims) error {
+	if ttl := time.Until(claims.ExpiresAt); ttl > 0 {
+		if err := s.redisRepo.Set(deniedTokenKey+claims.ID, claims.Subject, ttl); err != nil {
+			logger.Errorf("s.redisRepo.Set(): %v", err)
+			return err
 		}
 	}
 
-	user, err := s.userRepo.GetByRefreshToken(ctx, refreshToken)
+	if claims.SessionID == "" {
+		return nil
+	}
+
+	err := s.RevokeSession(ctx, claims.Subject, claims.SessionID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+// LogoutEverywhere ends every session of the user, which revokes the access
+// tokens issued for them.
+func (s *UserService) LogoutEverywhere(ctx context.Context, userID string) error {
+	sessions, err := s.sessionRepo.GetSessions(ctx, userID)
 	if err != nil {
-		return domain.Token{}, err
+		logger.Errorf("s.sessionRepo.GetSessions(): %v", err)
+		return err
+	}
+
+	if err := s.sessionRepo.DeleteSessions(ctx, userID); err != nil {
+		logger.Errorf("s.sessionRepo.DeleteSessions(): %v", err)
+		return err
+	}
+
+	for _, session := range sessions {
+		if err := s.revokeSession(session.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetSessions lists the active sessions of the user, currentID is the
+// session of the request.
+func (s *UserService) GetSessions(ctx context.Context, userID string, currentID string) ([]domain.Session, error) {
+	sessions, err := s.sessionRepo.GetSessions(ctx, userID)
+	if err != nil {
+		logger.Errorf("s.sessionRepo.GetSessions(): %v", err)
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession ends a session of the user, ErrNotFound for the session of
+// another user.
+func (s *UserService) RevokeSession(ctx context.Context, userID string, id string) error {
+	if err := s.sessionRepo.DeleteSession(ctx, id, userID); err != nil {
+		logger.Errorf("s.sessionRepo.DeleteSession(): %v", err)
+		return err
+	}
+
+	return s.revokeSession(id)
+}
+
+// revokeSession denies the access tokens of the session for as long as they
+// may live.
+func (s *UserService) revokeSession(id string) error {
+	if err := s.redisRepo.Set(revokedSessionKey+id, id, s.accessTokenTTL); err != nil {
+		logger.Errorf("s.redisRepo.Set(): %v", err)
+		return err
 	}
 
-	return s.createSession(ctx, user.ID)
+	return nil
+}
+
+// GetLanguage returns the language of the API messages chosen by the user,
+// empty when there is none.
+func (s *UserService) GetLanguage(ctx context.Context, userID string) (string, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Errorf("s.userRepo.GetUserByID(): %v", err)
+		return "", err
+	}
 
+	return user.Language, nil
 }
 
-func (s *UserService) createSession(ctx context.Context, userId primitive.ObjectID) (domain.Token, error) {
+func (s *UserService) SetLanguage(ctx context.Context, userID string, language string) error {
+	if err := validateLanguage(language); err != nil {
+		logger.Errorf("validateLanguage(): %v", err)
+		return err
+	}
+
+	if err := s.userRepo.SetLanguage(ctx, userID, language); err != nil {
+		logger.Errorf("s.userRepo.SetLanguage(): %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *UserService) createSession(ctx context.Context, userID string, client domain.Client) (domain.Token, error) {
 	var (
 		res domain.Token
 		err error
 	)
 
-	id := userId.Hex()
-
-	res.AccessToken, err = s.manager.NewJWT(id, s.accessTokenTTL)
+	res.RefreshToken, err = s.manager.NewRefreshToken()
 	if err != nil {
-		logger.Errorf("s.manager.NewJWT(): %v", err)
+		logger.Errorf("s.manager.NewRefreshToken(): %v", err)
 		return res, err
 	}
 
-	res.RefreshToken, err = s.manager.NewRefreshToken()
+	now := time.Now()
+	session, err := s.sessionRepo.Create(ctx, domain.Session{
+		UserID:           userID,
+		RefreshTokenHash: auth.HashToken(res.RefreshToken),
+		Device:           client.Device,
+		UserAgent:        client.UserAgent,
+		IP:               client.IP,
+		CreatedAt:        now,
+		LastUsedAt:       now,
+		ExpirationAt:     now.Add(s.refreshTokenTTL),
+	})
 	if err != nil {
-		logger.Errorf("s.manager.NewRefreshToken(): %v", err)
-		return res, err
+		logger.Errorf("s.sessionRepo.Create(): %v", err)
+		return domain.Token{}, err
 	}
 
-	session := domain.Session{
-		RefreshToken: res.RefreshToken,
-		ExpirationAt: time.Now().Add(s.refreshTokenTTL),
+	res.AccessToken, err = s.manager.NewJWT(accessClaims(userID, session.ID), s.accessTokenTTL)
+	if err != nil {
+		logger.Errorf("s.manager.NewJWT(): %v", err)
+		return domain.Token{}, err
 	}
 
-	err = s.redisRepo.Set(session.RefreshToken, id, s.accessTokenTTL*2)
+	return res, nil
+}
+
+// signInSession starts the session of a signed in user. The failed sign-ins
+// of the account are forgotten and its pending deletion is cancelled only
+// then, a password without the MFA code does neither.
+func (s *UserService) signInSession(ctx context.Context, user domain.User, client domain.Client) (domain.Token, error) {
+	token, err := s.createSession(ctx, user.ID, client)
 	if err != nil {
-		logger.Errorf("s.redisRepo.Set(): %v", err)
-		return res, err
+		return domain.Token{}, err
+	}
+
+	if user.DeleteAt != nil {
+		if err := s.userRepo.ScheduleDeletion(ctx, user.ID, nil); err != nil {
+			logger.Errorf("s.userRepo.ScheduleDeletion(): %v", err)
+			return domain.Token{}, err
+		}
 	}
 
-	err = s.userRepo.SetSession(ctx, userId, session)
+	s.resetSignIn(user.Email)
 
-	return res, err
+	return token, nil
+}
+
+// accessClaims are the claims of the access tokens of a session.
+func accessClaims(userID string, sessionID string) auth.Claims {
+	return auth.Claims{
+		Subject:   userID,
+		SessionID: sessionID,
+		Scopes:    domain.UserScopes,
+	}
 }
 
 func validateUser(email, password string) error {
-	_, err := mail.ParseAddress(email)
+	_, err := netmail.ParseAddress(email)
 	if err != nil {
 		return domain.ErrIncorrectEmailAddress
 	}
@@ -160,3 +488,28 @@ func validateUser(email, password string) error {
 
 	return nil
 }
+
+// validateTimezone checks that the timezone is a known IANA zone, the
+// default one when empty.
+func validateTimezone(timezone string) (string, error) {
+	if timezone == "" {
+		return domain.DefaultTimezone, nil
+	}
+
+	// time.LoadLocation also accepts "Local", which depends on the server.
+	if _, err := time.LoadLocation(timezone); err != nil || timezone == "Local" {
+		return "", domain.ErrInvalidTimezone
+	}
+
+	return timezone, nil
+}
+
+// validateLanguage checks that the API messages are translated to the
+// language, an empty one follows the Accept-Language header.
+func validateLanguage(language string) error {
+	if language != "" && !i18n.Supported(language) {
+		return domain.ErrInvalidLanguage
+	}
+
+	return nil
+}
@@ -2,6 +2,9 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -9,10 +12,11 @@ import (
 	mocksRepo "github.com/begenov/region-llc-task/internal/repository/mocks"
 	"github.com/begenov/region-llc-task/pkg/auth"
 	mocksHash "github.com/begenov/region-llc-task/pkg/hash/mocks"
+	"github.com/begenov/region-llc-task/pkg/mail"
+	mocksMail "github.com/begenov/region-llc-task/pkg/mail/mocks"
 	"github.com/begenov/region-llc-task/pkg/utils"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 var ctx = context.Background()
@@ -24,13 +28,14 @@ func TestUserService_SignUp(t *testing.T) {
 	userRepo := mocksRepo.NewMockUsers(ctrl)
 	redisRepo := mocksRepo.NewMockRedis(ctrl)
 	hash := mocksHash.NewMockPasswordHasher(ctrl)
+	mailer := mocksMail.NewMockMailer(ctrl)
 
 	manager, err := auth.NewManager("qwerty")
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	userService := *NewUserService(userRepo, hash, manager, time.Minute, time.Minute, redisRepo)
+	userService := *NewUserService(userRepo, mocksRepo.NewMockSessions(ctrl), mocksRepo.NewMockPersonalTokens(ctrl), mocksRepo.NewMockMFA(ctrl), hash, manager, time.Minute, time.Minute, redisRepo, MFAPolicy{}, mailer, PasswordResetPolicy{}, EmailVerificationPolicy{}, AccountPolicy{}, SignInPolicy{})
 
 	type args struct {
 		ctx context.Context
@@ -57,11 +62,18 @@ func TestUserService_SignUp(t *testing.T) {
 				userRepo.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(1).Return(domain.User{}, domain.ErrNotFound)
 				hash.EXPECT().GenerateFromPassword(gomock.Any()).Times(1).Return(user.Password, nil)
 				userRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).Return(domain.User{
+					ID:       utils.RandomString(24),
 					UserName: user.UserName,
 					Email:    user.Email,
 					Password: user.Password,
 					CreateAt: time.Now().Format("2006-01-02"),
 				}, nil)
+				redisRepo.EXPECT().Set(gomock.Any(), gomock.Any(), defaultEmailVerificationTTL).Times(2).Return(nil)
+				mailer.EXPECT().Send(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, msg mail.Message) error {
+					require.Equal(t, user.Email, msg.To)
+					require.Contains(t, msg.Body, "token="+auth.EmailTokenPrefix)
+					return nil
+				})
 			},
 			checkResponse: func(user domain.User, req domain.UserRequest, err error) {
 				require.NoError(t, err)
@@ -69,6 +81,96 @@ func TestUserService_SignUp(t *testing.T) {
 				require.Equal(t, req.Email, user.Email)
 			},
 		},
+		{
+			name: "Timezone",
+			args: args{
+				ctx: ctx,
+				inp: domain.UserRequest{
+					UserName: utils.RandomString(10),
+					Email:    utils.RandomEmail(),
+					Password: utils.RandomString(7),
+					Timezone: "Asia/Almaty",
+				},
+			},
+			buildStubs: func(user domain.UserRequest) {
+				userRepo.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(1).Return(domain.User{}, domain.ErrNotFound)
+				hash.EXPECT().GenerateFromPassword(gomock.Any()).Times(1).Return(user.Password, nil)
+				userRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, user domain.User) (domain.User, error) {
+					require.Equal(t, "Asia/Almaty", user.Timezone)
+					return user, nil
+				})
+				redisRepo.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(2).Return(nil)
+				mailer.EXPECT().Send(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+			},
+			checkResponse: func(user domain.User, req domain.UserRequest, err error) {
+				require.NoError(t, err)
+				require.Equal(t, req.Timezone, user.Timezone)
+			},
+		},
+		{
+			name: "Verification Not Sent",
+			args: args{
+				ctx: ctx,
+				inp: domain.UserRequest{
+					UserName: utils.RandomString(10),
+					Email:    utils.RandomEmail(),
+					Password: utils.RandomString(7),
+				},
+			},
+			buildStubs: func(user domain.UserRequest) {
+				userRepo.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(1).Return(domain.User{}, domain.ErrNotFound)
+				hash.EXPECT().GenerateFromPassword(gomock.Any()).Times(1).Return(user.Password, nil)
+				userRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, user domain.User) (domain.User, error) {
+					return user, nil
+				})
+				redisRepo.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(2).Return(nil)
+				mailer.EXPECT().Send(gomock.Any(), gomock.Any()).Times(1).Return(domain.ErrInternalServer)
+			},
+			checkResponse: func(user domain.User, req domain.UserRequest, err error) {
+				require.NoError(t, err)
+				require.False(t, user.EmailVerified)
+			},
+		},
+		{
+			name: "Incorrect Timezone",
+			args: args{
+				ctx: ctx,
+				inp: domain.UserRequest{
+					UserName: utils.RandomString(10),
+					Email:    utils.RandomEmail(),
+					Password: utils.RandomString(7),
+					Timezone: "GMT+6",
+				},
+			},
+			buildStubs: func(user domain.UserRequest) {
+				userRepo.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(0)
+				hash.EXPECT().GenerateFromPassword(gomock.Any()).Times(0)
+				userRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(user domain.User, req domain.UserRequest, err error) {
+				require.Equal(t, err, domain.ErrInvalidTimezone)
+			},
+		},
+		{
+			name: "Incorrect Language",
+			args: args{
+				ctx: ctx,
+				inp: domain.UserRequest{
+					UserName: utils.RandomString(10),
+					Email:    utils.RandomEmail(),
+					Password: utils.RandomString(7),
+					Language: "de",
+				},
+			},
+			buildStubs: func(user domain.UserRequest) {
+				userRepo.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(0)
+				hash.EXPECT().GenerateFromPassword(gomock.Any()).Times(0)
+				userRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(user domain.User, req domain.UserRequest, err error) {
+				require.Equal(t, err, domain.ErrInvalidLanguage)
+			},
+		},
 		{
 			name: "Incorrect Email Address",
 			args: args{
@@ -206,6 +308,8 @@ func TestUserService_SignIn(t *testing.T) {
 	defer ctrl.Finish()
 
 	userRepo := mocksRepo.NewMockUsers(ctrl)
+	sessionRepo := mocksRepo.NewMockSessions(ctrl)
+	mfaRepo := mocksRepo.NewMockMFA(ctrl)
 	redisRepo := mocksRepo.NewMockRedis(ctrl)
 	hash := mocksHash.NewMockPasswordHasher(ctrl)
 
@@ -213,7 +317,10 @@ func TestUserService_SignIn(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	userService := *NewUserService(userRepo, hash, manager, time.Minute, time.Minute, redisRepo)
+	userService := *NewUserService(userRepo, sessionRepo, mocksRepo.NewMockPersonalTokens(ctrl), mfaRepo, hash, manager, time.Minute, time.Minute, redisRepo, MFAPolicy{}, mocksMail.NewMockMailer(ctrl), PasswordResetPolicy{}, EmailVerificationPolicy{}, AccountPolicy{}, SignInPolicy{})
+
+	sessionID := utils.RandomString(24)
+	var refreshTokenHash string
 
 	type args struct {
 		ctx      context.Context
@@ -235,7 +342,8 @@ func TestUserService_SignIn(t *testing.T) {
 				password: utils.RandomString(10),
 			},
 			buildStubs: func(email, password string) {
-				id := primitive.NewObjectID()
+				redisRepo.EXPECT().Get(signInBlockedKey+signInAccount(email)).Times(1).Return("", domain.ErrNotFound)
+				id := utils.RandomString(24)
 				userRepo.EXPECT().GetUserByEmail(gomock.Any(), email).Times(1).Return(domain.User{
 					ID:       id,
 					UserName: utils.RandomString(10),
@@ -244,13 +352,59 @@ func TestUserService_SignIn(t *testing.T) {
 					CreateAt: time.Now().Format("2006-01-02"),
 				}, nil)
 				hash.EXPECT().CompareHashAndPassword(gomock.Any(), gomock.Any()).Times(1).Return(nil)
-				redisRepo.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil)
-				userRepo.EXPECT().SetSession(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil)
+				redisRepo.EXPECT().Delete(signInFailuresKey + signInAccount(email)).Times(1).Return(nil)
+				mfaRepo.EXPECT().GetMFA(gomock.Any(), gomock.Any()).Times(1).Return(domain.MFA{}, domain.ErrNotFound)
+				sessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, session domain.Session) (domain.Session, error) {
+					require.Equal(t, id, session.UserID)
+					require.Equal(t, "Phone", session.Device)
+					require.Equal(t, session.CreatedAt, session.LastUsedAt)
+					require.Equal(t, session.CreatedAt.Add(time.Minute), session.ExpirationAt)
+					refreshTokenHash = session.RefreshTokenHash
+					session.ID = sessionID
+					return session, nil
+				})
 			},
 			checkResponse: func(token domain.Token, err error) {
 				require.NoError(t, err)
 				require.NotEmpty(t, token.AccessToken)
 				require.NotEmpty(t, token.RefreshToken)
+				require.Equal(t, auth.HashToken(token.RefreshToken), refreshTokenHash)
+
+				claims, err := manager.Parse(token.AccessToken)
+				require.NoError(t, err)
+				require.Equal(t, sessionID, claims.SessionID)
+				require.Equal(t, domain.UserScopes, claims.Scopes)
+			},
+		},
+		{
+			name: "Deleted Account",
+			args: args{
+				ctx:      ctx,
+				email:    utils.RandomEmail(),
+				password: utils.RandomString(10),
+			},
+			buildStubs: func(email, password string) {
+				redisRepo.EXPECT().Get(signInBlockedKey+signInAccount(email)).Times(1).Return("", domain.ErrNotFound)
+				id := utils.RandomString(24)
+				deleteAt := time.Now().Add(time.Hour)
+				userRepo.EXPECT().GetUserByEmail(gomock.Any(), email).Times(1).Return(domain.User{
+					ID:       id,
+					Email:    email,
+					Password: password,
+					DeleteAt: &deleteAt,
+				}, nil)
+				hash.EXPECT().CompareHashAndPassword(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+				redisRepo.EXPECT().Delete(signInFailuresKey + signInAccount(email)).Times(1).Return(nil)
+				userRepo.EXPECT().ScheduleDeletion(gomock.Any(), id, nil).Times(1).Return(nil)
+				mfaRepo.EXPECT().GetMFA(gomock.Any(), gomock.Any()).Times(1).Return(domain.MFA{}, domain.ErrNotFound)
+				sessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, session domain.Session) (domain.Session, error) {
+					session.ID = sessionID
+					return session, nil
+				})
+			},
+			checkResponse: func(token domain.Token, err error) {
+				require.NoError(t, err)
+				require.NotEmpty(t, token.AccessToken)
 			},
 		},
 		{
@@ -264,8 +418,7 @@ func TestUserService_SignIn(t *testing.T) {
 			buildStubs: func(email, password string) {
 				userRepo.EXPECT().GetUserByEmail(gomock.Any(), email).Times(0)
 				hash.EXPECT().CompareHashAndPassword(gomock.Any(), gomock.Any()).Times(0)
-				redisRepo.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
-				userRepo.EXPECT().SetSession(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				sessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(token domain.Token, err error) {
 				require.Equal(t, err, domain.ErrIncorrectEmailAddress)
@@ -282,8 +435,7 @@ func TestUserService_SignIn(t *testing.T) {
 			buildStubs: func(email, password string) {
 				userRepo.EXPECT().GetUserByEmail(gomock.Any(), email).Times(0)
 				hash.EXPECT().CompareHashAndPassword(gomock.Any(), gomock.Any()).Times(0)
-				redisRepo.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
-				userRepo.EXPECT().SetSession(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				sessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(token domain.Token, err error) {
 				require.Equal(t, err, domain.ErrIncorrectPassword)
@@ -298,10 +450,11 @@ func TestUserService_SignIn(t *testing.T) {
 			},
 
 			buildStubs: func(email, password string) {
+				redisRepo.EXPECT().Get(signInBlockedKey+signInAccount(email)).Times(1).Return("", domain.ErrNotFound)
 				userRepo.EXPECT().GetUserByEmail(gomock.Any(), email).Times(1).Return(domain.User{}, domain.ErrNotFound)
+				redisRepo.EXPECT().Incr(signInFailuresKey+signInAccount(email), defaultSignInWindow).Times(1).Return(int64(1), nil)
 				hash.EXPECT().CompareHashAndPassword(gomock.Any(), gomock.Any()).Times(0)
-				redisRepo.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
-				userRepo.EXPECT().SetSession(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				sessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(token domain.Token, err error) {
 				require.Equal(t, err, domain.ErrNotFound)
@@ -316,10 +469,10 @@ func TestUserService_SignIn(t *testing.T) {
 			},
 
 			buildStubs: func(email, password string) {
+				redisRepo.EXPECT().Get(signInBlockedKey+signInAccount(email)).Times(1).Return("", domain.ErrNotFound)
 				userRepo.EXPECT().GetUserByEmail(gomock.Any(), email).Times(1).Return(domain.User{}, domain.ErrInternalServer)
 				hash.EXPECT().CompareHashAndPassword(gomock.Any(), gomock.Any()).Times(0)
-				redisRepo.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
-				userRepo.EXPECT().SetSession(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				sessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(token domain.Token, err error) {
 				require.Equal(t, err, domain.ErrInternalServer)
@@ -334,23 +487,24 @@ func TestUserService_SignIn(t *testing.T) {
 			},
 
 			buildStubs: func(email, password string) {
+				redisRepo.EXPECT().Get(signInBlockedKey+signInAccount(email)).Times(1).Return("", domain.ErrNotFound)
 				userRepo.EXPECT().GetUserByEmail(gomock.Any(), email).Times(1).Return(domain.User{
-					ID:       primitive.NewObjectID(),
+					ID:       utils.RandomString(24),
 					UserName: utils.RandomString(10),
 					Email:    email,
 					Password: password,
 					CreateAt: time.Now().Format("2006-01-02"),
 				}, nil)
 				hash.EXPECT().CompareHashAndPassword(gomock.Any(), gomock.Any()).Times(1).Return(domain.ErrInternalServer)
-				redisRepo.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
-				userRepo.EXPECT().SetSession(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				redisRepo.EXPECT().Incr(signInFailuresKey+signInAccount(email), defaultSignInWindow).Times(1).Return(int64(1), nil)
+				sessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(token domain.Token, err error) {
 				require.Equal(t, err, domain.ErrInternalServer)
 			},
 		},
 		{
-			name: "set redis",
+			name: "create session",
 			args: args{
 				ctx:      ctx,
 				email:    utils.RandomEmail(),
@@ -358,16 +512,18 @@ func TestUserService_SignIn(t *testing.T) {
 			},
 
 			buildStubs: func(email, password string) {
+				redisRepo.EXPECT().Get(signInBlockedKey+signInAccount(email)).Times(1).Return("", domain.ErrNotFound)
 				userRepo.EXPECT().GetUserByEmail(gomock.Any(), email).Times(1).Return(domain.User{
-					ID:       primitive.NewObjectID(),
+					ID:       utils.RandomString(24),
 					UserName: utils.RandomString(10),
 					Email:    email,
 					Password: password,
 					CreateAt: time.Now().Format("2006-01-02"),
 				}, nil)
 				hash.EXPECT().CompareHashAndPassword(gomock.Any(), gomock.Any()).Times(1).Return(nil)
-				redisRepo.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(domain.ErrInternalServer)
-				userRepo.EXPECT().SetSession(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				mfaRepo.EXPECT().GetMFA(gomock.Any(), gomock.Any()).Times(1).Return(domain.MFA{}, domain.ErrNotFound)
+				sessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).Return(domain.Session{}, domain.ErrInternalServer)
+				redisRepo.EXPECT().Delete(signInFailuresKey + signInAccount(email)).Times(0)
 			},
 			checkResponse: func(token domain.Token, err error) {
 				require.Equal(t, err, domain.ErrInternalServer)
@@ -378,7 +534,7 @@ func TestUserService_SignIn(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.buildStubs(tt.args.email, tt.args.password)
 
-			token, err := userService.SignIn(tt.args.ctx, tt.args.email, tt.args.password)
+			token, err := userService.SignIn(tt.args.ctx, tt.args.email, tt.args.password, domain.Client{Device: "Phone"})
 
 			tt.checkResponse(token, err)
 		})
@@ -389,97 +545,1823 @@ func TestUserService_RefreshTokens(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	userRepo := mocksRepo.NewMockUsers(ctrl)
+	sessionRepo := mocksRepo.NewMockSessions(ctrl)
 	redisRepo := mocksRepo.NewMockRedis(ctrl)
-	hash := mocksHash.NewMockPasswordHasher(ctrl)
 
 	manager, err := auth.NewManager("qwerty")
-	if err != nil {
-		t.Fatal(err)
-	}
+	require.NoError(t, err)
 
-	userService := *NewUserService(userRepo, hash, manager, time.Minute, time.Minute, redisRepo)
+	userService := *NewUserService(mocksRepo.NewMockUsers(ctrl), sessionRepo, mocksRepo.NewMockPersonalTokens(ctrl), mocksRepo.NewMockMFA(ctrl), mocksHash.NewMockPasswordHasher(ctrl), manager, time.Minute, time.Hour, redisRepo, MFAPolicy{}, mocksMail.NewMockMailer(ctrl), PasswordResetPolicy{}, EmailVerificationPolicy{}, AccountPolicy{}, SignInPolicy{})
 
-	type args struct {
-		ctx     context.Context
-		refresh string
+	client := domain.Client{UserAgent: "Phone/2.0", IP: "198.51.100.1"}
+
+	newSession := func(refresh string) domain.Session {
+		return domain.Session{
+			ID:               utils.RandomString(24),
+			UserID:           utils.RandomString(24),
+			RefreshTokenHash: auth.HashToken(refresh),
+			Device:           "Phone",
+			UserAgent:        "Phone/1.0",
+			CreatedAt:        time.Now().Add(-time.Hour),
+			LastUsedAt:       time.Now().Add(-time.Hour),
+			ExpirationAt:     time.Now().Add(time.Minute),
+		}
 	}
 
 	tests := []struct {
 		name          string
-		args          args
+		refresh       string
 		buildStubs    func(refresh string)
 		checkResponse func(token domain.Token, err error)
 	}{
 		{
-			name: "OK-1",
-			args: args{
-				ctx:     ctx,
-				refresh: utils.RandomString(10),
-			},
+			name:    "OK",
+			refresh: utils.RandomString(10),
 			buildStubs: func(refresh string) {
-				id := primitive.NewObjectID()
-				redisRepo.EXPECT().Get(gomock.Any()).Times(1).Return(id.Hex(), nil)
-				redisRepo.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil)
-				userRepo.EXPECT().SetSession(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil)
-				userRepo.EXPECT().GetByRefreshToken(gomock.Any(), gomock.Any()).Times(0)
+				session := newSession(refresh)
+
+				sessionRepo.EXPECT().GetByRefreshToken(gomock.Any(), auth.HashToken(refresh)).Times(1).Return(session, nil)
+				redisRepo.EXPECT().Set(usedRefreshTokenKey+auth.HashToken(refresh), session.UserID+":"+session.ID, gomock.Any()).Times(1).Return(nil)
+				sessionRepo.EXPECT().RotateRefreshToken(gomock.Any(), gomock.Any(), auth.HashToken(refresh)).Times(1).DoAndReturn(func(_ context.Context, rotated domain.Session, _ string) error {
+					require.Equal(t, session.ID, rotated.ID)
+					require.NotEqual(t, session.RefreshTokenHash, rotated.RefreshTokenHash)
+					require.Equal(t, "Phone", rotated.Device)
+					require.Equal(t, client.UserAgent, rotated.UserAgent)
+					require.Equal(t, client.IP, rotated.IP)
+					require.Equal(t, session.CreatedAt, rotated.CreatedAt)
+					require.WithinDuration(t, time.Now(), rotated.LastUsedAt, time.Second)
+					require.Equal(t, rotated.LastUsedAt.Add(time.Hour), rotated.ExpirationAt)
+					return nil
+				})
 			},
 			checkResponse: func(token domain.Token, err error) {
 				require.NoError(t, err)
 				require.NotEmpty(t, token.AccessToken)
 				require.NotEmpty(t, token.RefreshToken)
-
 			},
 		},
 		{
-			name: "OK-2",
-			args: args{
-				ctx:     ctx,
-				refresh: utils.RandomString(10),
+			name:    "error not found refresh token",
+			refresh: utils.RandomString(10),
+			buildStubs: func(refresh string) {
+				sessionRepo.EXPECT().GetByRefreshToken(gomock.Any(), auth.HashToken(refresh)).Times(1).Return(domain.Session{}, domain.ErrNotFound)
+				redisRepo.EXPECT().Get(usedRefreshTokenKey+auth.HashToken(refresh)).Times(1).Return("", domain.ErrNotFound)
+				sessionRepo.EXPECT().RotateRefreshToken(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(token domain.Token, err error) {
+				require.Equal(t, err, domain.ErrNotFound)
 			},
+		},
+		{
+			name:    "reused refresh token",
+			refresh: utils.RandomString(10),
 			buildStubs: func(refresh string) {
-				id := primitive.NewObjectID()
-				redisRepo.EXPECT().Get(gomock.Any()).Times(1).Return("", nil)
-				redisRepo.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil)
-				userRepo.EXPECT().SetSession(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil)
-				userRepo.EXPECT().GetByRefreshToken(gomock.Any(), gomock.Any()).Times(1).Return(domain.User{
-					ID:       id,
-					UserName: utils.RandomString(10),
-					Email:    utils.RandomEmail(),
-					Password: utils.RandomString(10),
-				}, nil)
+				session := newSession(refresh)
+
+				sessionRepo.EXPECT().GetByRefreshToken(gomock.Any(), auth.HashToken(refresh)).Times(1).Return(domain.Session{}, domain.ErrNotFound)
+				redisRepo.EXPECT().Get(usedRefreshTokenKey+auth.HashToken(refresh)).Times(1).Return(session.UserID+":"+session.ID, nil)
+				sessionRepo.EXPECT().DeleteSession(gomock.Any(), session.ID, session.UserID).Times(1).Return(nil)
+				redisRepo.EXPECT().Set(revokedSessionKey+session.ID, session.ID, time.Minute).Times(1).Return(nil)
 			},
 			checkResponse: func(token domain.Token, err error) {
-				require.NoError(t, err)
-				require.NotEmpty(t, token.AccessToken)
-				require.NotEmpty(t, token.RefreshToken)
+				require.Equal(t, err, domain.ErrTokenRevoked)
+				require.Empty(t, token)
+			},
+		},
+		{
+			name:    "reused after the session ended",
+			refresh: utils.RandomString(10),
+			buildStubs: func(refresh string) {
+				session := newSession(refresh)
 
+				sessionRepo.EXPECT().GetByRefreshToken(gomock.Any(), auth.HashToken(refresh)).Times(1).Return(domain.Session{}, domain.ErrNotFound)
+				redisRepo.EXPECT().Get(usedRefreshTokenKey+auth.HashToken(refresh)).Times(1).Return(session.UserID+":"+session.ID, nil)
+				sessionRepo.EXPECT().DeleteSession(gomock.Any(), session.ID, session.UserID).Times(1).Return(domain.ErrNotFound)
+			},
+			checkResponse: func(token domain.Token, err error) {
+				require.Equal(t, err, domain.ErrTokenRevoked)
 			},
 		},
 		{
-			name: "error not found refresh token",
-			args: args{
-				ctx:     ctx,
-				refresh: utils.RandomString(10),
+			name:    "concurrent refresh",
+			refresh: utils.RandomString(10),
+			buildStubs: func(refresh string) {
+				session := newSession(refresh)
+
+				sessionRepo.EXPECT().GetByRefreshToken(gomock.Any(), auth.HashToken(refresh)).Times(1).Return(session, nil)
+				redisRepo.EXPECT().Set(usedRefreshTokenKey+auth.HashToken(refresh), session.UserID+":"+session.ID, gomock.Any()).Times(1).Return(nil)
+				sessionRepo.EXPECT().RotateRefreshToken(gomock.Any(), gomock.Any(), auth.HashToken(refresh)).Times(1).Return(domain.ErrNotFound)
+				sessionRepo.EXPECT().DeleteSession(gomock.Any(), session.ID, session.UserID).Times(1).Return(nil)
+				redisRepo.EXPECT().Set(revokedSessionKey+session.ID, session.ID, time.Minute).Times(1).Return(nil)
+			},
+			checkResponse: func(token domain.Token, err error) {
+				require.Equal(t, err, domain.ErrTokenRevoked)
+				require.Empty(t, token)
 			},
+		},
+		{
+			name:    "internal error",
+			refresh: utils.RandomString(10),
 			buildStubs: func(refresh string) {
-				redisRepo.EXPECT().Get(gomock.Any()).Times(1).Return("", nil)
-				redisRepo.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
-				userRepo.EXPECT().SetSession(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
-				userRepo.EXPECT().GetByRefreshToken(gomock.Any(), gomock.Any()).Times(1).Return(domain.User{}, domain.ErrNotFound)
+				session := newSession(refresh)
+
+				sessionRepo.EXPECT().GetByRefreshToken(gomock.Any(), auth.HashToken(refresh)).Times(1).Return(session, nil)
+				redisRepo.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(domain.ErrInternalServer)
+				sessionRepo.EXPECT().RotateRefreshToken(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(token domain.Token, err error) {
-				require.Equal(t, err, domain.ErrNotFound)
+				require.Equal(t, err, domain.ErrInternalServer)
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.buildStubs(tt.args.refresh)
+			tt.buildStubs(tt.refresh)
 
-			token, err := userService.RefreshTokens(tt.args.ctx, tt.args.refresh)
+			token, err := userService.RefreshTokens(ctx, tt.refresh, client)
 
 			tt.checkResponse(token, err)
 		})
 	}
 }
+
+func TestUserService_CheckAccessToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	redisRepo := mocksRepo.NewMockRedis(ctrl)
+
+	manager, err := auth.NewManager("qwerty")
+	require.NoError(t, err)
+
+	userService := NewUserService(mocksRepo.NewMockUsers(ctrl), mocksRepo.NewMockSessions(ctrl), mocksRepo.NewMockPersonalTokens(ctrl), mocksRepo.NewMockMFA(ctrl), mocksHash.NewMockPasswordHasher(ctrl), manager, time.Minute, time.Minute, redisRepo, MFAPolicy{}, mocksMail.NewMockMailer(ctrl), PasswordResetPolicy{}, EmailVerificationPolicy{}, AccountPolicy{}, SignInPolicy{})
+
+	claims := auth.Claims{
+		Subject:   utils.RandomString(24),
+		ID:        utils.RandomString(32),
+		SessionID: utils.RandomString(24),
+		IssuedAt:  time.Now(),
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+
+	tests := []struct {
+		name       string
+		buildStubs func()
+		err        error
+	}{
+		{
+			name: "OK",
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(deniedTokenKey+claims.ID).Times(1).Return("", domain.ErrNotFound)
+				redisRepo.EXPECT().Get(revokedSessionKey+claims.SessionID).Times(1).Return("", domain.ErrNotFound)
+			},
+		},
+		{
+			name: "Denied",
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(deniedTokenKey+claims.ID).Times(1).Return(claims.Subject, nil)
+			},
+			err: domain.ErrTokenRevoked,
+		},
+		{
+			name: "Session Revoked",
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(deniedTokenKey+claims.ID).Times(1).Return("", domain.ErrNotFound)
+				redisRepo.EXPECT().Get(revokedSessionKey+claims.SessionID).Times(1).Return(claims.SessionID, nil)
+			},
+			err: domain.ErrTokenRevoked,
+		},
+		{
+			name: "Internal Error",
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(deniedTokenKey+claims.ID).Times(1).Return("", domain.ErrInternalServer)
+			},
+			err: domain.ErrInternalServer,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.buildStubs()
+
+			require.Equal(t, tt.err, userService.CheckAccessToken(ctx, claims))
+		})
+	}
+}
+
+func TestUserService_Logout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sessionRepo := mocksRepo.NewMockSessions(ctrl)
+	redisRepo := mocksRepo.NewMockRedis(ctrl)
+
+	manager, err := auth.NewManager("qwerty")
+	require.NoError(t, err)
+
+	userService := NewUserService(mocksRepo.NewMockUsers(ctrl), sessionRepo, mocksRepo.NewMockPersonalTokens(ctrl), mocksRepo.NewMockMFA(ctrl), mocksHash.NewMockPasswordHasher(ctrl), manager, time.Minute, time.Minute, redisRepo, MFAPolicy{}, mocksMail.NewMockMailer(ctrl), PasswordResetPolicy{}, EmailVerificationPolicy{}, AccountPolicy{}, SignInPolicy{})
+
+	claims := auth.Claims{
+		Subject:   utils.RandomString(24),
+		ID:        utils.RandomString(32),
+		SessionID: utils.RandomString(24),
+		IssuedAt:  time.Now(),
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+
+	redisRepo.EXPECT().Set(deniedTokenKey+claims.ID, claims.Subject, gomock.Any()).Times(1).Return(nil)
+	sessionRepo.EXPECT().DeleteSession(gomock.Any(), claims.SessionID, claims.Subject).Times(1).Return(nil)
+	redisRepo.EXPECT().Set(revokedSessionKey+claims.SessionID, claims.SessionID, time.Minute).Times(1).Return(nil)
+
+	require.NoError(t, userService.Logout(ctx, claims))
+
+	// The session may have been revoked already.
+	redisRepo.EXPECT().Set(deniedTokenKey+claims.ID, claims.Subject, gomock.Any()).Times(1).Return(nil)
+	sessionRepo.EXPECT().DeleteSession(gomock.Any(), claims.SessionID, claims.Subject).Times(1).Return(domain.ErrNotFound)
+
+	require.NoError(t, userService.Logout(ctx, claims))
+}
+
+func TestUserService_LogoutEverywhere(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sessionRepo := mocksRepo.NewMockSessions(ctrl)
+	redisRepo := mocksRepo.NewMockRedis(ctrl)
+
+	manager, err := auth.NewManager("qwerty")
+	require.NoError(t, err)
+
+	userService := NewUserService(mocksRepo.NewMockUsers(ctrl), sessionRepo, mocksRepo.NewMockPersonalTokens(ctrl), mocksRepo.NewMockMFA(ctrl), mocksHash.NewMockPasswordHasher(ctrl), manager, time.Minute, time.Minute, redisRepo, MFAPolicy{}, mocksMail.NewMockMailer(ctrl), PasswordResetPolicy{}, EmailVerificationPolicy{}, AccountPolicy{}, SignInPolicy{})
+
+	userID := utils.RandomString(24)
+	sessions := []domain.Session{{ID: utils.RandomString(24)}, {ID: utils.RandomString(24)}}
+
+	sessionRepo.EXPECT().GetSessions(gomock.Any(), userID).Times(1).Return(sessions, nil)
+	sessionRepo.EXPECT().DeleteSessions(gomock.Any(), userID).Times(1).Return(nil)
+	for _, session := range sessions {
+		redisRepo.EXPECT().Set(revokedSessionKey+session.ID, session.ID, time.Minute).Times(1).Return(nil)
+	}
+
+	require.NoError(t, userService.LogoutEverywhere(ctx, userID))
+}
+
+func TestUserService_GetSessions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sessionRepo := mocksRepo.NewMockSessions(ctrl)
+
+	manager, err := auth.NewManager("qwerty")
+	require.NoError(t, err)
+
+	userService := NewUserService(mocksRepo.NewMockUsers(ctrl), sessionRepo, mocksRepo.NewMockPersonalTokens(ctrl), mocksRepo.NewMockMFA(ctrl), mocksHash.NewMockPasswordHasher(ctrl), manager, time.Minute, time.Minute, mocksRepo.NewMockRedis(ctrl), MFAPolicy{}, mocksMail.NewMockMailer(ctrl), PasswordResetPolicy{}, EmailVerificationPolicy{}, AccountPolicy{}, SignInPolicy{})
+
+	userID := utils.RandomString(24)
+	sessions := []domain.Session{{ID: utils.RandomString(24)}, {ID: utils.RandomString(24)}}
+
+	sessionRepo.EXPECT().GetSessions(gomock.Any(), userID).Times(1).Return(sessions, nil)
+
+	res, err := userService.GetSessions(ctx, userID, sessions[1].ID)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	require.False(t, res[0].Current)
+	require.True(t, res[1].Current)
+}
+
+func TestUserService_RevokeSession(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sessionRepo := mocksRepo.NewMockSessions(ctrl)
+	redisRepo := mocksRepo.NewMockRedis(ctrl)
+
+	manager, err := auth.NewManager("qwerty")
+	require.NoError(t, err)
+
+	userService := NewUserService(mocksRepo.NewMockUsers(ctrl), sessionRepo, mocksRepo.NewMockPersonalTokens(ctrl), mocksRepo.NewMockMFA(ctrl), mocksHash.NewMockPasswordHasher(ctrl), manager, time.Minute, time.Minute, redisRepo, MFAPolicy{}, mocksMail.NewMockMailer(ctrl), PasswordResetPolicy{}, EmailVerificationPolicy{}, AccountPolicy{}, SignInPolicy{})
+
+	userID := utils.RandomString(24)
+	id := utils.RandomString(24)
+
+	sessionRepo.EXPECT().DeleteSession(gomock.Any(), id, userID).Times(1).Return(nil)
+	redisRepo.EXPECT().Set(revokedSessionKey+id, id, time.Minute).Times(1).Return(nil)
+
+	require.NoError(t, userService.RevokeSession(ctx, userID, id))
+
+	sessionRepo.EXPECT().DeleteSession(gomock.Any(), id, userID).Times(1).Return(domain.ErrNotFound)
+	redisRepo.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	require.Equal(t, domain.ErrNotFound, userService.RevokeSession(ctx, userID, id))
+}
+
+func TestUserService_SetLanguage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocksRepo.NewMockUsers(ctrl)
+
+	manager, err := auth.NewManager("qwerty")
+	require.NoError(t, err)
+
+	userService := NewUserService(userRepo, mocksRepo.NewMockSessions(ctrl), mocksRepo.NewMockPersonalTokens(ctrl), mocksRepo.NewMockMFA(ctrl), mocksHash.NewMockPasswordHasher(ctrl), manager, time.Minute, time.Minute, mocksRepo.NewMockRedis(ctrl), MFAPolicy{}, mocksMail.NewMockMailer(ctrl), PasswordResetPolicy{}, EmailVerificationPolicy{}, AccountPolicy{}, SignInPolicy{})
+
+	userID := utils.RandomString(24)
+
+	userRepo.EXPECT().SetLanguage(gomock.Any(), userID, "kk").Times(1).Return(nil)
+	require.NoError(t, userService.SetLanguage(ctx, userID, "kk"))
+
+	userRepo.EXPECT().SetLanguage(gomock.Any(), userID, "").Times(1).Return(nil)
+	require.NoError(t, userService.SetLanguage(ctx, userID, ""))
+
+	userRepo.EXPECT().SetLanguage(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	require.Equal(t, domain.ErrInvalidLanguage, userService.SetLanguage(ctx, userID, "KK"))
+
+	userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(domain.User{ID: userID, Language: "ru"}, nil)
+	language, err := userService.GetLanguage(ctx, userID)
+	require.NoError(t, err)
+	require.Equal(t, "ru", language)
+}
+
+func TestUserService_CreatePersonalToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	personalTokenRepo := mocksRepo.NewMockPersonalTokens(ctrl)
+
+	manager, err := auth.NewManager("qwerty")
+	require.NoError(t, err)
+
+	userService := NewUserService(mocksRepo.NewMockUsers(ctrl), mocksRepo.NewMockSessions(ctrl), personalTokenRepo, mocksRepo.NewMockMFA(ctrl), mocksHash.NewMockPasswordHasher(ctrl), manager, time.Minute, time.Minute, mocksRepo.NewMockRedis(ctrl), MFAPolicy{}, mocksMail.NewMockMailer(ctrl), PasswordResetPolicy{}, EmailVerificationPolicy{}, AccountPolicy{}, SignInPolicy{})
+
+	userID := utils.RandomString(24)
+	expiresAt := time.Now().Add(time.Hour)
+	expiredAt := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name          string
+		inp           domain.PersonalTokenRequest
+		buildStubs    func()
+		checkResponse func(res domain.NewPersonalToken, err error)
+	}{
+		{
+			name: "OK",
+			inp: domain.PersonalTokenRequest{
+				Name:      " CI ",
+				Scopes:    []string{domain.ScopeTodosRead, domain.ScopeTodosWrite, domain.ScopeTodosRead},
+				ExpiresAt: &expiresAt,
+			},
+			buildStubs: func() {
+				personalTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, token domain.PersonalToken) (domain.PersonalToken, error) {
+						token.ID = utils.RandomString(24)
+						return token, nil
+					})
+			},
+			checkResponse: func(res domain.NewPersonalToken, err error) {
+				require.NoError(t, err)
+				require.NotEmpty(t, res.ID)
+				require.Equal(t, userID, res.UserID)
+				require.Equal(t, "CI", res.Name)
+				require.Equal(t, []string{domain.ScopeTodosRead, domain.ScopeTodosWrite}, res.Scopes)
+				require.Equal(t, &expiresAt, res.ExpiresAt)
+				require.True(t, auth.VerifyToken(res.Token, auth.PersonalTokenPrefix))
+				require.Equal(t, auth.HashToken(res.Token), res.TokenHash)
+			},
+		},
+		{
+			name: "Blank Name",
+			inp:  domain.PersonalTokenRequest{Name: "  ", Scopes: []string{domain.ScopeTodosRead}},
+			buildStubs: func() {
+				personalTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(res domain.NewPersonalToken, err error) {
+				require.Equal(t, domain.ErrInvalidTokenName, err)
+			},
+		},
+		{
+			name: "No Scopes",
+			inp:  domain.PersonalTokenRequest{Name: "CI"},
+			buildStubs: func() {
+				personalTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(res domain.NewPersonalToken, err error) {
+				require.Equal(t, domain.ErrInvalidScope, err)
+			},
+		},
+		{
+			name: "Account Scope",
+			inp:  domain.PersonalTokenRequest{Name: "CI", Scopes: []string{domain.ScopeTodosRead, domain.ScopeAccount}},
+			buildStubs: func() {
+				personalTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(res domain.NewPersonalToken, err error) {
+				require.Equal(t, domain.ErrInvalidScope, err)
+			},
+		},
+		{
+			name: "Expired",
+			inp:  domain.PersonalTokenRequest{Name: "CI", Scopes: []string{domain.ScopeTodosRead}, ExpiresAt: &expiredAt},
+			buildStubs: func() {
+				personalTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(res domain.NewPersonalToken, err error) {
+				require.Equal(t, domain.ErrInvalidExpiry, err)
+			},
+		},
+		{
+			name: "Internal Error",
+			inp:  domain.PersonalTokenRequest{Name: "CI", Scopes: []string{domain.ScopeTodosRead}},
+			buildStubs: func() {
+				personalTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).
+					Return(domain.PersonalToken{}, domain.ErrInternalServer)
+			},
+			checkResponse: func(res domain.NewPersonalToken, err error) {
+				require.Equal(t, domain.ErrInternalServer, err)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.buildStubs()
+
+			res, err := userService.CreatePersonalToken(ctx, userID, tt.inp)
+			tt.checkResponse(res, err)
+		})
+	}
+}
+
+func TestUserService_CheckPersonalToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	personalTokenRepo := mocksRepo.NewMockPersonalTokens(ctrl)
+
+	manager, err := auth.NewManager("qwerty")
+	require.NoError(t, err)
+
+	userService := NewUserService(mocksRepo.NewMockUsers(ctrl), mocksRepo.NewMockSessions(ctrl), personalTokenRepo, mocksRepo.NewMockMFA(ctrl), mocksHash.NewMockPasswordHasher(ctrl), manager, time.Minute, time.Minute, mocksRepo.NewMockRedis(ctrl), MFAPolicy{}, mocksMail.NewMockMailer(ctrl), PasswordResetPolicy{}, EmailVerificationPolicy{}, AccountPolicy{}, SignInPolicy{})
+
+	token, err := auth.NewToken(auth.PersonalTokenPrefix)
+	require.NoError(t, err)
+
+	refreshToken, err := auth.NewToken(auth.RefreshTokenPrefix)
+	require.NoError(t, err)
+
+	recently := time.Now().Add(-time.Second)
+	personalToken := domain.PersonalToken{
+		ID:     utils.RandomString(24),
+		UserID: utils.RandomString(24),
+		Scopes: []string{domain.ScopeTodosRead},
+	}
+
+	tests := []struct {
+		name       string
+		token      string
+		buildStubs func()
+		err        error
+	}{
+		{
+			name:  "OK",
+			token: token,
+			buildStubs: func() {
+				personalTokenRepo.EXPECT().GetByTokenHash(gomock.Any(), auth.HashToken(token)).Times(1).Return(personalToken, nil)
+				personalTokenRepo.EXPECT().SetLastUsed(gomock.Any(), personalToken.ID, gomock.Any()).Times(1).Return(nil)
+			},
+		},
+		{
+			name:  "Recently Used",
+			token: token,
+			buildStubs: func() {
+				used := personalToken
+				used.LastUsedAt = &recently
+				personalTokenRepo.EXPECT().GetByTokenHash(gomock.Any(), auth.HashToken(token)).Times(1).Return(used, nil)
+				personalTokenRepo.EXPECT().SetLastUsed(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+		{
+			name:  "Last Use Not Recorded",
+			token: token,
+			buildStubs: func() {
+				personalTokenRepo.EXPECT().GetByTokenHash(gomock.Any(), auth.HashToken(token)).Times(1).Return(personalToken, nil)
+				personalTokenRepo.EXPECT().SetLastUsed(gomock.Any(), personalToken.ID, gomock.Any()).Times(1).Return(domain.ErrInternalServer)
+			},
+		},
+		{
+			name:  "Malformed",
+			token: token[:len(token)-1],
+			buildStubs: func() {
+				personalTokenRepo.EXPECT().GetByTokenHash(gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrInvalidAuthHeader,
+		},
+		{
+			name:  "Refresh Token",
+			token: refreshToken,
+			buildStubs: func() {
+				personalTokenRepo.EXPECT().GetByTokenHash(gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrInvalidAuthHeader,
+		},
+		{
+			name:  "Revoked",
+			token: token,
+			buildStubs: func() {
+				personalTokenRepo.EXPECT().GetByTokenHash(gomock.Any(), auth.HashToken(token)).Times(1).Return(domain.PersonalToken{}, domain.ErrNotFound)
+			},
+			err: domain.ErrInvalidAuthHeader,
+		},
+		{
+			name:  "Internal Error",
+			token: token,
+			buildStubs: func() {
+				personalTokenRepo.EXPECT().GetByTokenHash(gomock.Any(), auth.HashToken(token)).Times(1).Return(domain.PersonalToken{}, domain.ErrInternalServer)
+			},
+			err: domain.ErrInternalServer,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.buildStubs()
+
+			principal, err := userService.CheckPersonalToken(ctx, tt.token)
+			require.Equal(t, tt.err, err)
+			if err != nil {
+				return
+			}
+
+			require.Equal(t, personalToken.UserID, principal.UserID)
+			require.Empty(t, principal.SessionID)
+			require.Equal(t, personalToken.Scopes, principal.Scopes)
+		})
+	}
+}
+
+func TestUserService_SignInMFA(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocksRepo.NewMockUsers(ctrl)
+	sessionRepo := mocksRepo.NewMockSessions(ctrl)
+	mfaRepo := mocksRepo.NewMockMFA(ctrl)
+	redisRepo := mocksRepo.NewMockRedis(ctrl)
+	hash := mocksHash.NewMockPasswordHasher(ctrl)
+
+	manager, err := auth.NewManager("qwerty")
+	require.NoError(t, err)
+
+	userService := NewUserService(userRepo, sessionRepo, mocksRepo.NewMockPersonalTokens(ctrl), mfaRepo, hash, manager,
+		time.Minute, time.Minute, redisRepo, MFAPolicy{RequiredDomains: []string{"Example.org"}},
+		mocksMail.NewMockMailer(ctrl), PasswordResetPolicy{}, EmailVerificationPolicy{}, AccountPolicy{}, SignInPolicy{})
+
+	tests := []struct {
+		name       string
+		email      string
+		mfa        domain.MFA
+		enrollment bool
+	}{
+		{
+			name:  "Enabled",
+			email: utils.RandomEmail(),
+			mfa:   domain.MFA{Secret: "JBSWY3DPEHPK3PXP", Enabled: true},
+		},
+		{
+			name:       "Required",
+			email:      utils.RandomString(8) + "@example.org",
+			enrollment: true,
+		},
+		{
+			name:  "Required And Enabled",
+			email: utils.RandomString(8) + "@example.org",
+			mfa:   domain.MFA{Secret: "JBSWY3DPEHPK3PXP", Enabled: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := utils.RandomString(24)
+			var challengeKey string
+
+			redisRepo.EXPECT().Get(signInBlockedKey+signInAccount(tt.email)).Times(1).Return("", domain.ErrNotFound)
+			userRepo.EXPECT().GetUserByEmail(gomock.Any(), tt.email).Times(1).Return(domain.User{ID: id, Email: tt.email}, nil)
+			hash.EXPECT().CompareHashAndPassword(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+			if tt.mfa.Enabled {
+				mfa := tt.mfa
+				mfa.UserID = id
+				mfaRepo.EXPECT().GetMFA(gomock.Any(), id).Times(1).Return(mfa, nil)
+			} else {
+				mfaRepo.EXPECT().GetMFA(gomock.Any(), id).Times(1).Return(domain.MFA{}, domain.ErrNotFound)
+			}
+			redisRepo.EXPECT().Get(mfaLockedKey+id).Times(1).Return("", domain.ErrNotFound)
+			redisRepo.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(key string, value string, ttl time.Duration) error {
+				require.Contains(t, value, id)
+				require.InDelta(t, defaultMFAChallengeTTL, ttl, float64(time.Second))
+				challengeKey = key
+				return nil
+			})
+			sessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			redisRepo.EXPECT().Delete(gomock.Any()).Times(0)
+
+			token, err := userService.SignIn(ctx, tt.email, utils.RandomString(10), domain.Client{})
+			require.NoError(t, err)
+			require.Empty(t, token.AccessToken)
+			require.Empty(t, token.RefreshToken)
+			require.True(t, auth.VerifyToken(token.MFAToken, auth.MFATokenPrefix))
+			require.Equal(t, mfaChallengeKey+auth.HashToken(token.MFAToken), challengeKey)
+			require.Equal(t, tt.enrollment, token.MFAEnrollment)
+		})
+	}
+
+	t.Run("Locked Out", func(t *testing.T) {
+		id := utils.RandomString(24)
+		email := utils.RandomEmail()
+
+		redisRepo.EXPECT().Get(signInBlockedKey+signInAccount(email)).Times(1).Return("", domain.ErrNotFound)
+		userRepo.EXPECT().GetUserByEmail(gomock.Any(), email).Times(1).Return(domain.User{ID: id, Email: email}, nil)
+		hash.EXPECT().CompareHashAndPassword(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+		mfaRepo.EXPECT().GetMFA(gomock.Any(), id).Times(1).Return(domain.MFA{UserID: id, Enabled: true}, nil)
+		redisRepo.EXPECT().Get(mfaLockedKey+id).Times(1).Return(time.Now().Add(time.Minute).Format(time.RFC3339Nano), nil)
+		redisRepo.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := userService.SignIn(ctx, email, utils.RandomString(10), domain.Client{})
+		require.ErrorIs(t, err, domain.ErrTooManyAttempts)
+	})
+
+	// The password alone does not cancel the deletion of the account.
+	t.Run("Deleted Account", func(t *testing.T) {
+		id := utils.RandomString(24)
+		email := utils.RandomEmail()
+		deleteAt := time.Now().Add(time.Hour)
+
+		redisRepo.EXPECT().Get(signInBlockedKey+signInAccount(email)).Times(1).Return("", domain.ErrNotFound)
+		userRepo.EXPECT().GetUserByEmail(gomock.Any(), email).Times(1).Return(domain.User{ID: id, Email: email, DeleteAt: &deleteAt}, nil)
+		hash.EXPECT().CompareHashAndPassword(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+		mfaRepo.EXPECT().GetMFA(gomock.Any(), id).Times(1).Return(domain.MFA{UserID: id, Enabled: true}, nil)
+		redisRepo.EXPECT().Get(mfaLockedKey+id).Times(1).Return("", domain.ErrNotFound)
+		redisRepo.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil)
+		userRepo.EXPECT().ScheduleDeletion(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		token, err := userService.SignIn(ctx, email, utils.RandomString(10), domain.Client{})
+		require.NoError(t, err)
+		require.NotEmpty(t, token.MFAToken)
+	})
+}
+
+func TestUserService_VerifyMFA(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocksRepo.NewMockUsers(ctrl)
+	sessionRepo := mocksRepo.NewMockSessions(ctrl)
+	mfaRepo := mocksRepo.NewMockMFA(ctrl)
+	redisRepo := mocksRepo.NewMockRedis(ctrl)
+
+	manager, err := auth.NewManager("qwerty")
+	require.NoError(t, err)
+
+	userService := NewUserService(userRepo, sessionRepo, mocksRepo.NewMockPersonalTokens(ctrl), mfaRepo,
+		mocksHash.NewMockPasswordHasher(ctrl), manager, time.Minute, time.Minute, redisRepo, MFAPolicy{}, mocksMail.NewMockMailer(ctrl), PasswordResetPolicy{}, EmailVerificationPolicy{}, AccountPolicy{}, SignInPolicy{})
+
+	secret, err := auth.NewTOTPSecret()
+	require.NoError(t, err)
+
+	code, err := auth.TOTPCode(secret, time.Now())
+	require.NoError(t, err)
+
+	mfaToken, err := auth.NewToken(auth.MFATokenPrefix)
+	require.NoError(t, err)
+	key := mfaChallengeKey + auth.HashToken(mfaToken)
+
+	userID := utils.RandomString(24)
+	user := domain.User{ID: userID, Email: utils.RandomEmail()}
+	mfa := domain.MFA{UserID: userID, Secret: secret, Enabled: true, RecoveryCodes: []string{auth.HashToken("abcdefghjk")}}
+	challenge := func() string {
+		value, err := json.Marshal(mfaChallenge{UserID: userID, ExpiresAt: time.Now().Add(time.Minute)})
+		require.NoError(t, err)
+		return string(value)
+	}
+	attemptsKey := mfaAttemptsKey + auth.HashToken(mfaToken)
+	lockedKey := mfaLockedKey + userID
+	failuresKey := mfaFailuresKey + userID
+	signInKey := signInFailuresKey + signInAccount(user.Email)
+	signInBlocked := signInBlockedKey + signInAccount(user.Email)
+
+	tests := []struct {
+		name       string
+		mfaToken   string
+		code       string
+		buildStubs func()
+		err        error
+	}{
+		{
+			name:     "OK",
+			mfaToken: mfaToken,
+			code:     code,
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(key).Times(1).Return(challenge(), nil)
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(user, nil)
+				redisRepo.EXPECT().Get(signInBlocked).Times(1).Return("", domain.ErrNotFound)
+				redisRepo.EXPECT().Get(lockedKey).Times(1).Return("", domain.ErrNotFound)
+				mfaRepo.EXPECT().GetMFA(gomock.Any(), userID).Times(1).Return(mfa, nil)
+				mfaRepo.EXPECT().UseStep(gomock.Any(), userID, gomock.Any()).Times(1).Return(nil)
+				redisRepo.EXPECT().Delete(key).Times(1).Return(nil)
+				redisRepo.EXPECT().Delete(attemptsKey).Times(1).Return(nil)
+				redisRepo.EXPECT().Delete(failuresKey).Times(1).Return(nil)
+				sessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).Return(domain.Session{ID: utils.RandomString(24)}, nil)
+				redisRepo.EXPECT().Delete(signInKey).Times(1).Return(nil)
+			},
+		},
+		{
+			name:     "Recovery Code",
+			mfaToken: mfaToken,
+			code:     "ABCDE-FGHJK",
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(key).Times(1).Return(challenge(), nil)
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(user, nil)
+				redisRepo.EXPECT().Get(signInBlocked).Times(1).Return("", domain.ErrNotFound)
+				redisRepo.EXPECT().Get(lockedKey).Times(1).Return("", domain.ErrNotFound)
+				mfaRepo.EXPECT().GetMFA(gomock.Any(), userID).Times(1).Return(mfa, nil)
+				mfaRepo.EXPECT().UseRecoveryCode(gomock.Any(), userID, auth.HashToken("abcdefghjk")).Times(1).Return(nil)
+				redisRepo.EXPECT().Delete(key).Times(1).Return(nil)
+				redisRepo.EXPECT().Delete(attemptsKey).Times(1).Return(nil)
+				redisRepo.EXPECT().Delete(failuresKey).Times(1).Return(nil)
+				sessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).Return(domain.Session{ID: utils.RandomString(24)}, nil)
+				redisRepo.EXPECT().Delete(signInKey).Times(1).Return(nil)
+			},
+		},
+		{
+			name:     "Deleted Account",
+			mfaToken: mfaToken,
+			code:     code,
+			buildStubs: func() {
+				deleteAt := time.Now().Add(time.Hour)
+				deleted := user
+				deleted.DeleteAt = &deleteAt
+
+				redisRepo.EXPECT().Get(key).Times(1).Return(challenge(), nil)
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(deleted, nil)
+				redisRepo.EXPECT().Get(signInBlocked).Times(1).Return("", domain.ErrNotFound)
+				redisRepo.EXPECT().Get(lockedKey).Times(1).Return("", domain.ErrNotFound)
+				mfaRepo.EXPECT().GetMFA(gomock.Any(), userID).Times(1).Return(mfa, nil)
+				mfaRepo.EXPECT().UseStep(gomock.Any(), userID, gomock.Any()).Times(1).Return(nil)
+				redisRepo.EXPECT().Delete(key).Times(1).Return(nil)
+				redisRepo.EXPECT().Delete(attemptsKey).Times(1).Return(nil)
+				redisRepo.EXPECT().Delete(failuresKey).Times(1).Return(nil)
+				sessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).Return(domain.Session{ID: utils.RandomString(24)}, nil)
+				userRepo.EXPECT().ScheduleDeletion(gomock.Any(), userID, nil).Times(1).Return(nil)
+				redisRepo.EXPECT().Delete(signInKey).Times(1).Return(nil)
+			},
+		},
+		{
+			name:     "Code Reused",
+			mfaToken: mfaToken,
+			code:     code,
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(key).Times(1).Return(challenge(), nil)
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(user, nil)
+				redisRepo.EXPECT().Get(signInBlocked).Times(1).Return("", domain.ErrNotFound)
+				redisRepo.EXPECT().Get(lockedKey).Times(1).Return("", domain.ErrNotFound)
+				mfaRepo.EXPECT().GetMFA(gomock.Any(), userID).Times(1).Return(mfa, nil)
+				mfaRepo.EXPECT().UseStep(gomock.Any(), userID, gomock.Any()).Times(1).Return(domain.ErrNotFound)
+				redisRepo.EXPECT().Incr(failuresKey, mfaLockoutDuration).Times(1).Return(int64(1), nil)
+				redisRepo.EXPECT().Incr(attemptsKey, gomock.Any()).Times(1).Return(int64(1), nil)
+				redisRepo.EXPECT().Incr(signInKey, defaultSignInWindow).Times(1).Return(int64(1), nil)
+				redisRepo.EXPECT().Delete(gomock.Any()).Times(0)
+				sessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrInvalidMFACode,
+		},
+		{
+			name:     "Too Many Attempts",
+			mfaToken: mfaToken,
+			code:     "000000",
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(key).Times(1).Return(challenge(), nil)
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(user, nil)
+				redisRepo.EXPECT().Get(signInBlocked).Times(1).Return("", domain.ErrNotFound)
+				redisRepo.EXPECT().Get(lockedKey).Times(1).Return("", domain.ErrNotFound)
+				mfaRepo.EXPECT().GetMFA(gomock.Any(), userID).Times(1).Return(mfa, nil)
+				redisRepo.EXPECT().Incr(failuresKey, mfaLockoutDuration).Times(1).Return(int64(maxMFAAttempts), nil)
+				redisRepo.EXPECT().Incr(attemptsKey, gomock.Any()).Times(1).Return(int64(maxMFAAttempts), nil)
+				redisRepo.EXPECT().Incr(signInKey, defaultSignInWindow).Times(1).Return(int64(1), nil)
+				redisRepo.EXPECT().Delete(key).Times(1).Return(nil)
+				redisRepo.EXPECT().Delete(attemptsKey).Times(1).Return(nil)
+				sessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrInvalidMFACode,
+		},
+		{
+			name:     "Too Many Failures",
+			mfaToken: mfaToken,
+			code:     "000000",
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(key).Times(1).Return(challenge(), nil)
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(user, nil)
+				redisRepo.EXPECT().Get(signInBlocked).Times(1).Return("", domain.ErrNotFound)
+				redisRepo.EXPECT().Get(lockedKey).Times(1).Return("", domain.ErrNotFound)
+				mfaRepo.EXPECT().GetMFA(gomock.Any(), userID).Times(1).Return(mfa, nil)
+				redisRepo.EXPECT().Incr(failuresKey, mfaLockoutDuration).Times(1).Return(int64(maxMFAFailures), nil)
+				redisRepo.EXPECT().Delete(key).Times(1).Return(nil)
+				redisRepo.EXPECT().Delete(attemptsKey).Times(1).Return(nil)
+				redisRepo.EXPECT().Set(lockedKey, gomock.Any(), mfaLockoutDuration).Times(1).Return(nil)
+				sessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrTooManyAttempts,
+		},
+		{
+			name:     "Sign-In Backoff",
+			mfaToken: mfaToken,
+			code:     "000000",
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(key).Times(1).Return(challenge(), nil)
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(user, nil)
+				redisRepo.EXPECT().Get(signInBlocked).Times(1).Return("", domain.ErrNotFound)
+				redisRepo.EXPECT().Get(lockedKey).Times(1).Return("", domain.ErrNotFound)
+				mfaRepo.EXPECT().GetMFA(gomock.Any(), userID).Times(1).Return(mfa, nil)
+				redisRepo.EXPECT().Incr(failuresKey, mfaLockoutDuration).Times(1).Return(int64(1), nil)
+				redisRepo.EXPECT().Incr(attemptsKey, gomock.Any()).Times(1).Return(int64(1), nil)
+				redisRepo.EXPECT().Incr(signInKey, defaultSignInWindow).Times(1).Return(int64(defaultSignInFreeAttempts+1), nil)
+				redisRepo.EXPECT().Set(signInBlocked, gomock.Any(), defaultSignInBackoff).Times(1).Return(nil)
+				sessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrTooManyAttempts,
+		},
+		{
+			name:     "Sign-In Blocked",
+			mfaToken: mfaToken,
+			code:     code,
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(key).Times(1).Return(challenge(), nil)
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(user, nil)
+				redisRepo.EXPECT().Get(signInBlocked).Times(1).Return(time.Now().Add(time.Second).Format(time.RFC3339Nano), nil)
+				mfaRepo.EXPECT().GetMFA(gomock.Any(), gomock.Any()).Times(0)
+				sessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrTooManyAttempts,
+		},
+		{
+			name:     "Locked Out",
+			mfaToken: mfaToken,
+			code:     code,
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(key).Times(1).Return(challenge(), nil)
+				userRepo.EXPECT().GetUserByID(gomock.Any(), userID).Times(1).Return(user, nil)
+				redisRepo.EXPECT().Get(signInBlocked).Times(1).Return("", domain.ErrNotFound)
+				redisRepo.EXPECT().Get(lockedKey).Times(1).Return(time.Now().Add(time.Minute).Format(time.RFC3339Nano), nil)
+				redisRepo.EXPECT().Delete(key).Times(1).Return(nil)
+				redisRepo.EXPECT().Delete(attemptsKey).Times(1).Return(nil)
+				mfaRepo.EXPECT().GetMFA(gomock.Any(), gomock.Any()).Times(0)
+				sessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrTooManyAttempts,
+		},
+		{
+			name:     "Expired",
+			mfaToken: mfaToken,
+			code:     code,
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(key).Times(1).Return("", domain.ErrNotFound)
+				mfaRepo.EXPECT().GetMFA(gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrInvalidMFAToken,
+		},
+		{
+			name:     "Malformed",
+			mfaToken: mfaToken[:len(mfaToken)-1],
+			code:     code,
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(gomock.Any()).Times(0)
+			},
+			err: domain.ErrInvalidMFAToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.buildStubs()
+
+			token, err := userService.VerifyMFA(ctx, domain.MFASignInRequest{MFAToken: tt.mfaToken, Code: tt.code}, domain.Client{})
+			require.ErrorIs(t, err, tt.err)
+			if err != nil {
+				return
+			}
+
+			require.NotEmpty(t, token.AccessToken)
+			require.NotEmpty(t, token.RefreshToken)
+			require.Empty(t, token.MFAToken)
+		})
+	}
+}
+
+func TestUserService_RegenerateRecoveryCodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mfaRepo := mocksRepo.NewMockMFA(ctrl)
+	redisRepo := mocksRepo.NewMockRedis(ctrl)
+
+	manager, err := auth.NewManager("qwerty")
+	require.NoError(t, err)
+
+	userService := NewUserService(mocksRepo.NewMockUsers(ctrl), mocksRepo.NewMockSessions(ctrl), mocksRepo.NewMockPersonalTokens(ctrl), mfaRepo,
+		mocksHash.NewMockPasswordHasher(ctrl), manager, time.Minute, time.Minute, redisRepo, MFAPolicy{}, mocksMail.NewMockMailer(ctrl), PasswordResetPolicy{}, EmailVerificationPolicy{}, AccountPolicy{}, SignInPolicy{})
+
+	secret, err := auth.NewTOTPSecret()
+	require.NoError(t, err)
+
+	code, err := auth.TOTPCode(secret, time.Now())
+	require.NoError(t, err)
+
+	userID := utils.RandomString(24)
+	mfa := domain.MFA{UserID: userID, Secret: secret, Enabled: true}
+	lockedKey := mfaLockedKey + userID
+	failuresKey := mfaFailuresKey + userID
+
+	tests := []struct {
+		name       string
+		code       string
+		buildStubs func()
+		err        error
+	}{
+		{
+			name: "OK",
+			code: code,
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(lockedKey).Times(1).Return("", domain.ErrNotFound)
+				mfaRepo.EXPECT().GetMFA(gomock.Any(), userID).Times(1).Return(mfa, nil)
+				mfaRepo.EXPECT().UseStep(gomock.Any(), userID, gomock.Any()).Times(1).Return(nil)
+				redisRepo.EXPECT().Delete(failuresKey).Times(1).Return(nil)
+				mfaRepo.EXPECT().SetRecoveryCodes(gomock.Any(), userID, gomock.Len(recoveryCodes)).Times(1).Return(nil)
+			},
+		},
+		{
+			name: "Wrong Code",
+			code: "000000",
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(lockedKey).Times(1).Return("", domain.ErrNotFound)
+				mfaRepo.EXPECT().GetMFA(gomock.Any(), userID).Times(1).Return(mfa, nil)
+				redisRepo.EXPECT().Incr(failuresKey, mfaLockoutDuration).Times(1).Return(int64(1), nil)
+				mfaRepo.EXPECT().SetRecoveryCodes(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrInvalidMFACode,
+		},
+		{
+			name: "Too Many Failures",
+			code: "000000",
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(lockedKey).Times(1).Return("", domain.ErrNotFound)
+				mfaRepo.EXPECT().GetMFA(gomock.Any(), userID).Times(1).Return(mfa, nil)
+				redisRepo.EXPECT().Incr(failuresKey, mfaLockoutDuration).Times(1).Return(int64(maxMFAFailures), nil)
+				redisRepo.EXPECT().Set(lockedKey, gomock.Any(), mfaLockoutDuration).Times(1).Return(nil)
+				mfaRepo.EXPECT().SetRecoveryCodes(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrTooManyAttempts,
+		},
+		{
+			name: "Locked Out",
+			code: code,
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(lockedKey).Times(1).Return(time.Now().Add(time.Minute).Format(time.RFC3339Nano), nil)
+				mfaRepo.EXPECT().GetMFA(gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrTooManyAttempts,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.buildStubs()
+
+			codes, err := userService.RegenerateRecoveryCodes(ctx, userID, tt.code)
+			require.ErrorIs(t, err, tt.err)
+			if err == nil {
+				require.Len(t, codes.RecoveryCodes, recoveryCodes)
+			}
+		})
+	}
+}
+
+func TestUserService_RequestPasswordReset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocksRepo.NewMockUsers(ctrl)
+	redisRepo := mocksRepo.NewMockRedis(ctrl)
+	mailer := mocksMail.NewMockMailer(ctrl)
+
+	manager, err := auth.NewManager("qwerty")
+	require.NoError(t, err)
+
+	userService := NewUserService(userRepo, mocksRepo.NewMockSessions(ctrl), mocksRepo.NewMockPersonalTokens(ctrl), mocksRepo.NewMockMFA(ctrl),
+		mocksHash.NewMockPasswordHasher(ctrl), manager, time.Minute, time.Minute, redisRepo, MFAPolicy{}, mailer,
+		PasswordResetPolicy{URL: "https://todo.example.com/reset?from=mail"}, EmailVerificationPolicy{}, AccountPolicy{}, SignInPolicy{})
+
+	user := domain.User{ID: utils.RandomString(24), Email: utils.RandomEmail()}
+
+	tests := []struct {
+		name       string
+		email      string
+		language   string
+		buildStubs func()
+		err        error
+	}{
+		{
+			name:     "OK",
+			email:    user.Email,
+			language: "en",
+			buildStubs: func() {
+				var tokenHash string
+				userRepo.EXPECT().GetUserByEmail(gomock.Any(), user.Email).Times(1).Return(user, nil)
+				redisRepo.EXPECT().Set(gomock.Any(), user.ID, defaultPasswordResetTTL).Times(1).DoAndReturn(func(key string, _ string, _ time.Duration) error {
+					tokenHash = strings.TrimPrefix(key, passwordResetKey)
+					return nil
+				})
+				redisRepo.EXPECT().Set(passwordResetUserKey+user.ID, gomock.Any(), defaultPasswordResetTTL).Times(1).DoAndReturn(func(_ string, value string, _ time.Duration) error {
+					require.Equal(t, tokenHash, value)
+					return nil
+				})
+				mailer.EXPECT().Send(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, msg mail.Message) error {
+					require.Equal(t, user.Email, msg.To)
+					require.Equal(t, "Password reset", msg.Subject)
+
+					token := regexp.MustCompile(`https://todo\.example\.com/reset\?from=mail&token=(\S+)`).FindStringSubmatch(msg.Body)
+					require.Len(t, token, 2, msg.Body)
+					require.Equal(t, tokenHash, auth.HashToken(token[1]))
+					return nil
+				})
+			},
+		},
+		{
+			name:     "User Language",
+			email:    user.Email,
+			language: "en",
+			buildStubs: func() {
+				russian := user
+				russian.Language = "ru"
+				userRepo.EXPECT().GetUserByEmail(gomock.Any(), user.Email).Times(1).Return(russian, nil)
+				redisRepo.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(2).Return(nil)
+				mailer.EXPECT().Send(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, msg mail.Message) error {
+					require.Equal(t, "Сброс пароля", msg.Subject)
+					return nil
+				})
+			},
+		},
+		{
+			name:  "Unknown Email",
+			email: utils.RandomEmail(),
+			buildStubs: func() {
+				userRepo.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(1).Return(domain.User{}, domain.ErrNotFound)
+				redisRepo.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				mailer.EXPECT().Send(gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+		{
+			name:  "Incorrect Email Address",
+			email: "user",
+			buildStubs: func() {
+				userRepo.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrIncorrectEmailAddress,
+		},
+		{
+			name:  "Mail Not Sent",
+			email: user.Email,
+			buildStubs: func() {
+				userRepo.EXPECT().GetUserByEmail(gomock.Any(), user.Email).Times(1).Return(user, nil)
+				redisRepo.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(2).Return(nil)
+				mailer.EXPECT().Send(gomock.Any(), gomock.Any()).Times(1).Return(domain.ErrInternalServer)
+			},
+			err: domain.ErrInternalServer,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.buildStubs()
+
+			err := userService.RequestPasswordReset(ctx, tt.email, tt.language)
+			require.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestUserService_ResetPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocksRepo.NewMockUsers(ctrl)
+	sessionRepo := mocksRepo.NewMockSessions(ctrl)
+	redisRepo := mocksRepo.NewMockRedis(ctrl)
+	hash := mocksHash.NewMockPasswordHasher(ctrl)
+
+	manager, err := auth.NewManager("qwerty")
+	require.NoError(t, err)
+
+	userService := NewUserService(userRepo, sessionRepo, mocksRepo.NewMockPersonalTokens(ctrl), mocksRepo.NewMockMFA(ctrl),
+		hash, manager, time.Minute, time.Minute, redisRepo, MFAPolicy{}, mocksMail.NewMockMailer(ctrl), PasswordResetPolicy{}, EmailVerificationPolicy{}, AccountPolicy{}, SignInPolicy{})
+
+	token, err := auth.NewToken(auth.PasswordResetTokenPrefix)
+	require.NoError(t, err)
+	tokenHash := auth.HashToken(token)
+
+	userID := utils.RandomString(24)
+	sessionID := utils.RandomString(24)
+
+	tests := []struct {
+		name       string
+		token      string
+		password   string
+		buildStubs func()
+		err        error
+	}{
+		{
+			name:     "OK",
+			token:    token,
+			password: "new-password",
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(passwordResetKey+tokenHash).Times(1).Return(userID, nil)
+				redisRepo.EXPECT().Get(passwordResetUserKey+userID).Times(1).Return(tokenHash, nil)
+				redisRepo.EXPECT().Delete(passwordResetKey + tokenHash).Times(1).Return(nil)
+				redisRepo.EXPECT().Delete(passwordResetUserKey + userID).Times(1).Return(nil)
+				hash.EXPECT().GenerateFromPassword("new-password").Times(1).Return("new-hash", nil)
+				userRepo.EXPECT().SetPassword(gomock.Any(), userID, "new-hash").Times(1).Return(nil)
+				sessionRepo.EXPECT().GetSessions(gomock.Any(), userID).Times(1).Return([]domain.Session{{ID: sessionID}}, nil)
+				sessionRepo.EXPECT().DeleteSessions(gomock.Any(), userID).Times(1).Return(nil)
+				redisRepo.EXPECT().Set(revokedSessionKey+sessionID, gomock.Any(), gomock.Any()).Times(1).Return(nil)
+			},
+		},
+		{
+			name:     "Replaced",
+			token:    token,
+			password: "new-password",
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(passwordResetKey+tokenHash).Times(1).Return(userID, nil)
+				redisRepo.EXPECT().Get(passwordResetUserKey+userID).Times(1).Return(utils.RandomString(64), nil)
+				redisRepo.EXPECT().Delete(passwordResetKey + tokenHash).Times(1).Return(nil)
+				redisRepo.EXPECT().Delete(passwordResetUserKey + userID).Times(0)
+				userRepo.EXPECT().SetPassword(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrInvalidResetToken,
+		},
+		{
+			name:     "Expired",
+			token:    token,
+			password: "new-password",
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(passwordResetKey+tokenHash).Times(1).Return("", domain.ErrNotFound)
+				userRepo.EXPECT().SetPassword(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrInvalidResetToken,
+		},
+		{
+			name:     "Incorrect Password",
+			token:    token,
+			password: "short",
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(gomock.Any()).Times(0)
+			},
+			err: domain.ErrIncorrectPassword,
+		},
+		{
+			name:     "Malformed",
+			token:    token[:len(token)-1],
+			password: "new-password",
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(gomock.Any()).Times(0)
+			},
+			err: domain.ErrInvalidResetToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.buildStubs()
+
+			err := userService.ResetPassword(ctx, domain.PasswordReset{Token: tt.token, Password: tt.password})
+			require.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestUserService_VerifyEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocksRepo.NewMockUsers(ctrl)
+	redisRepo := mocksRepo.NewMockRedis(ctrl)
+
+	manager, err := auth.NewManager("qwerty")
+	require.NoError(t, err)
+
+	userService := NewUserService(userRepo, mocksRepo.NewMockSessions(ctrl), mocksRepo.NewMockPersonalTokens(ctrl), mocksRepo.NewMockMFA(ctrl),
+		mocksHash.NewMockPasswordHasher(ctrl), manager, time.Minute, time.Minute, redisRepo, MFAPolicy{}, mocksMail.NewMockMailer(ctrl),
+		PasswordResetPolicy{}, EmailVerificationPolicy{}, AccountPolicy{}, SignInPolicy{})
+
+	token, err := auth.NewToken(auth.EmailTokenPrefix)
+	require.NoError(t, err)
+	tokenHash := auth.HashToken(token)
+
+	userID := utils.RandomString(24)
+	email := utils.RandomEmail()
+	verification, err := json.Marshal(emailVerification{UserID: userID, Email: email})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		token      string
+		buildStubs func()
+		err        error
+	}{
+		{
+			name:  "OK",
+			token: token,
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(emailVerificationKey+tokenHash).Times(1).Return(string(verification), nil)
+				redisRepo.EXPECT().Get(emailVerificationUserKey+userID).Times(1).Return(tokenHash, nil)
+				redisRepo.EXPECT().Delete(emailVerificationKey + tokenHash).Times(1).Return(nil)
+				redisRepo.EXPECT().Delete(emailVerificationUserKey + userID).Times(1).Return(nil)
+				userRepo.EXPECT().VerifyEmail(gomock.Any(), userID, email).Times(1).Return(nil)
+			},
+		},
+		{
+			name:  "Email Taken Meanwhile",
+			token: token,
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(emailVerificationKey+tokenHash).Times(1).Return(string(verification), nil)
+				redisRepo.EXPECT().Get(emailVerificationUserKey+userID).Times(1).Return(tokenHash, nil)
+				redisRepo.EXPECT().Delete(gomock.Any()).Times(2).Return(nil)
+				userRepo.EXPECT().VerifyEmail(gomock.Any(), userID, email).Times(1).Return(domain.ErrEmailAlreadyExists)
+			},
+			err: domain.ErrEmailAlreadyExists,
+		},
+		{
+			name:  "Replaced",
+			token: token,
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(emailVerificationKey+tokenHash).Times(1).Return(string(verification), nil)
+				redisRepo.EXPECT().Get(emailVerificationUserKey+userID).Times(1).Return(utils.RandomString(64), nil)
+				redisRepo.EXPECT().Delete(emailVerificationKey + tokenHash).Times(1).Return(nil)
+				userRepo.EXPECT().VerifyEmail(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrInvalidVerifyToken,
+		},
+		{
+			name:  "Expired",
+			token: token,
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(emailVerificationKey+tokenHash).Times(1).Return("", domain.ErrNotFound)
+				userRepo.EXPECT().VerifyEmail(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrInvalidVerifyToken,
+		},
+		{
+			name:  "Reset Token",
+			token: "rlw_" + strings.TrimPrefix(token, auth.EmailTokenPrefix),
+			buildStubs: func() {
+				redisRepo.EXPECT().Get(gomock.Any()).Times(0)
+			},
+			err: domain.ErrInvalidVerifyToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.buildStubs()
+
+			err := userService.VerifyEmail(ctx, tt.token)
+			require.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestUserService_ChangeEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocksRepo.NewMockUsers(ctrl)
+	redisRepo := mocksRepo.NewMockRedis(ctrl)
+	hash := mocksHash.NewMockPasswordHasher(ctrl)
+	mailer := mocksMail.NewMockMailer(ctrl)
+
+	manager, err := auth.NewManager("qwerty")
+	require.NoError(t, err)
+
+	userService := NewUserService(userRepo, mocksRepo.NewMockSessions(ctrl), mocksRepo.NewMockPersonalTokens(ctrl), mocksRepo.NewMockMFA(ctrl),
+		hash, manager, time.Minute, time.Minute, redisRepo, MFAPolicy{}, mailer, PasswordResetPolicy{},
+		EmailVerificationPolicy{URL: "https://todo.example.com/verify-email", TokenTTL: time.Hour}, AccountPolicy{}, SignInPolicy{})
+
+	user := domain.User{ID: utils.RandomString(24), Email: utils.RandomEmail(), EmailVerified: true, Password: "hash"}
+	email := utils.RandomEmail()
+
+	tests := []struct {
+		name       string
+		inp        domain.EmailChange
+		buildStubs func()
+		err        error
+	}{
+		{
+			name: "OK",
+			inp:  domain.EmailChange{Email: email, Password: "password"},
+			buildStubs: func() {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				hash.EXPECT().CompareHashAndPassword("hash", "password").Times(1).Return(nil)
+				userRepo.EXPECT().GetUserByEmail(gomock.Any(), email).Times(1).Return(domain.User{}, domain.ErrNotFound)
+				redisRepo.EXPECT().Set(gomock.Any(), gomock.Any(), time.Hour).Times(1).DoAndReturn(func(_ string, value string, _ time.Duration) error {
+					var verification emailVerification
+					require.NoError(t, json.Unmarshal([]byte(value), &verification))
+					require.Equal(t, emailVerification{UserID: user.ID, Email: email}, verification)
+					return nil
+				})
+				redisRepo.EXPECT().Set(emailVerificationUserKey+user.ID, gomock.Any(), time.Hour).Times(1).Return(nil)
+				mailer.EXPECT().Send(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, msg mail.Message) error {
+					require.Equal(t, email, msg.To)
+					require.Contains(t, msg.Body, "https://todo.example.com/verify-email?token="+auth.EmailTokenPrefix)
+					return nil
+				})
+			},
+		},
+		{
+			name: "Email Already Exists",
+			inp:  domain.EmailChange{Email: email, Password: "password"},
+			buildStubs: func() {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				hash.EXPECT().CompareHashAndPassword("hash", "password").Times(1).Return(nil)
+				userRepo.EXPECT().GetUserByEmail(gomock.Any(), email).Times(1).Return(domain.User{ID: utils.RandomString(24)}, nil)
+				mailer.EXPECT().Send(gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrEmailAlreadyExists,
+		},
+		{
+			name: "Already Verified",
+			inp:  domain.EmailChange{Email: user.Email, Password: "password"},
+			buildStubs: func() {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				hash.EXPECT().CompareHashAndPassword("hash", "password").Times(1).Return(nil)
+				mailer.EXPECT().Send(gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrEmailAlreadyVerified,
+		},
+		{
+			name: "Incorrect Password",
+			inp:  domain.EmailChange{Email: email, Password: "wrong"},
+			buildStubs: func() {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				hash.EXPECT().CompareHashAndPassword("hash", "wrong").Times(1).Return(domain.ErrIncorrectPassword)
+				mailer.EXPECT().Send(gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrIncorrectPassword,
+		},
+		{
+			name: "Incorrect Email Address",
+			inp:  domain.EmailChange{Email: "user", Password: "password"},
+			buildStubs: func() {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrIncorrectEmailAddress,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.buildStubs()
+
+			err := userService.ChangeEmail(ctx, user.ID, tt.inp, "en")
+			require.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestUserService_UpdateProfile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocksRepo.NewMockUsers(ctrl)
+
+	manager, err := auth.NewManager("qwerty")
+	require.NoError(t, err)
+
+	userService := NewUserService(userRepo, mocksRepo.NewMockSessions(ctrl), mocksRepo.NewMockPersonalTokens(ctrl), mocksRepo.NewMockMFA(ctrl),
+		mocksHash.NewMockPasswordHasher(ctrl), manager, time.Minute, time.Minute, mocksRepo.NewMockRedis(ctrl), MFAPolicy{},
+		mocksMail.NewMockMailer(ctrl), PasswordResetPolicy{}, EmailVerificationPolicy{}, AccountPolicy{}, SignInPolicy{})
+
+	user := domain.User{ID: utils.RandomString(24), UserName: "user", Timezone: "UTC", Language: "ru"}
+	userName, timezone, language, empty := "name", "Asia/Almaty", "kk", ""
+
+	tests := []struct {
+		name       string
+		inp        domain.UserUpdate
+		buildStubs func()
+		user       domain.User
+		err        error
+	}{
+		{
+			name: "OK",
+			inp:  domain.UserUpdate{UserName: &userName, Timezone: &timezone},
+			buildStubs: func() {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				userRepo.EXPECT().UpdateUser(gomock.Any(), domain.User{ID: user.ID, UserName: userName, Timezone: timezone, Language: "ru"}).Times(1).Return(nil)
+			},
+			user: domain.User{ID: user.ID, UserName: userName, Timezone: timezone, Language: "ru"},
+		},
+		{
+			name: "Reset Timezone And Language",
+			inp:  domain.UserUpdate{Timezone: &empty, Language: &empty},
+			buildStubs: func() {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(domain.User{ID: user.ID, UserName: "user", Timezone: timezone, Language: language}, nil)
+				userRepo.EXPECT().UpdateUser(gomock.Any(), domain.User{ID: user.ID, UserName: "user", Timezone: "UTC"}).Times(1).Return(nil)
+			},
+			user: domain.User{ID: user.ID, UserName: "user", Timezone: "UTC"},
+		},
+		{
+			name: "Empty User Name",
+			inp:  domain.UserUpdate{UserName: &empty},
+			buildStubs: func() {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				userRepo.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrIncorrectUserName,
+		},
+		{
+			name: "Invalid Timezone",
+			inp:  domain.UserUpdate{Timezone: &userName},
+			buildStubs: func() {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				userRepo.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrInvalidTimezone,
+		},
+		{
+			name: "Invalid Language",
+			inp:  domain.UserUpdate{Language: &userName},
+			buildStubs: func() {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				userRepo.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrInvalidLanguage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.buildStubs()
+
+			updated, err := userService.UpdateProfile(ctx, user.ID, tt.inp)
+			require.Equal(t, tt.err, err)
+			require.Equal(t, tt.user, updated)
+		})
+	}
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocksRepo.NewMockUsers(ctrl)
+	sessionRepo := mocksRepo.NewMockSessions(ctrl)
+	redisRepo := mocksRepo.NewMockRedis(ctrl)
+	hash := mocksHash.NewMockPasswordHasher(ctrl)
+
+	manager, err := auth.NewManager("qwerty")
+	require.NoError(t, err)
+
+	userService := NewUserService(userRepo, sessionRepo, mocksRepo.NewMockPersonalTokens(ctrl), mocksRepo.NewMockMFA(ctrl),
+		hash, manager, time.Minute, time.Minute, redisRepo, MFAPolicy{}, mocksMail.NewMockMailer(ctrl), PasswordResetPolicy{},
+		EmailVerificationPolicy{}, AccountPolicy{}, SignInPolicy{})
+
+	user := domain.User{ID: utils.RandomString(24), Email: utils.RandomEmail(), Password: "hash"}
+	session := domain.Session{ID: utils.RandomString(24), UserID: user.ID}
+	blockedKey := signInBlockedKey + signInAccount(user.Email)
+	failuresKey := signInFailuresKey + signInAccount(user.Email)
+
+	tests := []struct {
+		name       string
+		inp        domain.PasswordChange
+		buildStubs func()
+		err        error
+	}{
+		{
+			name: "OK",
+			inp:  domain.PasswordChange{Password: "password", NewPassword: "new password"},
+			buildStubs: func() {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				redisRepo.EXPECT().Get(blockedKey).Times(1).Return("", domain.ErrNotFound)
+				hash.EXPECT().CompareHashAndPassword("hash", "password").Times(1).Return(nil)
+				hash.EXPECT().GenerateFromPassword("new password").Times(1).Return("new hash", nil)
+				userRepo.EXPECT().SetPassword(gomock.Any(), user.ID, "new hash").Times(1).Return(nil)
+				sessionRepo.EXPECT().GetSessions(gomock.Any(), user.ID).Times(1).Return([]domain.Session{session}, nil)
+				sessionRepo.EXPECT().DeleteSessions(gomock.Any(), user.ID).Times(1).Return(nil)
+				redisRepo.EXPECT().Set(revokedSessionKey+session.ID, session.ID, time.Minute).Times(1).Return(nil)
+				sessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, created domain.Session) (domain.Session, error) {
+					require.Equal(t, user.ID, created.UserID)
+					created.ID = utils.RandomString(24)
+					return created, nil
+				})
+			},
+		},
+		{
+			name: "Incorrect Password",
+			inp:  domain.PasswordChange{Password: "wrong", NewPassword: "new password"},
+			buildStubs: func() {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				redisRepo.EXPECT().Get(blockedKey).Times(1).Return("", domain.ErrNotFound)
+				hash.EXPECT().CompareHashAndPassword("hash", "wrong").Times(1).Return(domain.ErrIncorrectPassword)
+				redisRepo.EXPECT().Incr(failuresKey, defaultSignInWindow).Times(1).Return(int64(1), nil)
+				userRepo.EXPECT().SetPassword(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				sessionRepo.EXPECT().DeleteSessions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrIncorrectPassword,
+		},
+		{
+			name: "Backoff",
+			inp:  domain.PasswordChange{Password: "wrong", NewPassword: "new password"},
+			buildStubs: func() {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				redisRepo.EXPECT().Get(blockedKey).Times(1).Return("", domain.ErrNotFound)
+				hash.EXPECT().CompareHashAndPassword("hash", "wrong").Times(1).Return(domain.ErrIncorrectPassword)
+				redisRepo.EXPECT().Incr(failuresKey, defaultSignInWindow).Times(1).Return(int64(defaultSignInFreeAttempts+1), nil)
+				redisRepo.EXPECT().Set(blockedKey, gomock.Any(), defaultSignInBackoff).Times(1).Return(nil)
+				userRepo.EXPECT().SetPassword(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrTooManyAttempts,
+		},
+		{
+			name: "Locked Out",
+			inp:  domain.PasswordChange{Password: "password", NewPassword: "new password"},
+			buildStubs: func() {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				redisRepo.EXPECT().Get(blockedKey).Times(1).Return(time.Now().Add(time.Minute).Format(time.RFC3339Nano), nil)
+				hash.EXPECT().CompareHashAndPassword(gomock.Any(), gomock.Any()).Times(0)
+				userRepo.EXPECT().SetPassword(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrTooManyAttempts,
+		},
+		{
+			name: "Short New Password",
+			inp:  domain.PasswordChange{Password: "password", NewPassword: "new"},
+			buildStubs: func() {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), gomock.Any()).Times(0)
+			},
+			err: domain.ErrIncorrectPassword,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.buildStubs()
+
+			token, err := userService.ChangePassword(ctx, user.ID, tt.inp, domain.Client{Device: "Phone"})
+			require.ErrorIs(t, err, tt.err)
+			if err == nil {
+				require.NotEmpty(t, token.AccessToken)
+				require.NotEmpty(t, token.RefreshToken)
+			}
+		})
+	}
+}
+
+func TestUserService_DeleteAccount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocksRepo.NewMockUsers(ctrl)
+	sessionRepo := mocksRepo.NewMockSessions(ctrl)
+	personalTokenRepo := mocksRepo.NewMockPersonalTokens(ctrl)
+	redisRepo := mocksRepo.NewMockRedis(ctrl)
+	hash := mocksHash.NewMockPasswordHasher(ctrl)
+
+	manager, err := auth.NewManager("qwerty")
+	require.NoError(t, err)
+
+	userService := NewUserService(userRepo, sessionRepo, personalTokenRepo, mocksRepo.NewMockMFA(ctrl),
+		hash, manager, time.Minute, time.Minute, redisRepo, MFAPolicy{}, mocksMail.NewMockMailer(ctrl),
+		PasswordResetPolicy{}, EmailVerificationPolicy{}, AccountPolicy{DeletionGrace: 48 * time.Hour}, SignInPolicy{})
+
+	user := domain.User{ID: utils.RandomString(24), Email: utils.RandomEmail(), Password: "hash"}
+	token := domain.PersonalToken{ID: utils.RandomString(24), UserID: user.ID}
+	blockedKey := signInBlockedKey + signInAccount(user.Email)
+	client := domain.Client{IP: "203.0.113.7"}
+
+	tests := []struct {
+		name          string
+		password      string
+		buildStubs    func()
+		checkResponse func(deleted domain.User, err error)
+	}{
+		{
+			name:     "OK",
+			password: "password",
+			buildStubs: func() {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				redisRepo.EXPECT().Get(blockedKey).Times(1).Return("", domain.ErrNotFound)
+				redisRepo.EXPECT().Get(signInBlockedKey+signInIP(client.IP)).Times(1).Return("", domain.ErrNotFound)
+				hash.EXPECT().CompareHashAndPassword("hash", "password").Times(1).Return(nil)
+				userRepo.EXPECT().ScheduleDeletion(gomock.Any(), user.ID, gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, _ string, deleteAt *time.Time) error {
+					require.NotNil(t, deleteAt)
+					require.WithinDuration(t, time.Now().Add(48*time.Hour), *deleteAt, time.Minute)
+					return nil
+				})
+				sessionRepo.EXPECT().GetSessions(gomock.Any(), user.ID).Times(1).Return(nil, nil)
+				sessionRepo.EXPECT().DeleteSessions(gomock.Any(), user.ID).Times(1).Return(nil)
+				personalTokenRepo.EXPECT().GetTokens(gomock.Any(), user.ID).Times(1).Return([]domain.PersonalToken{token}, nil)
+				personalTokenRepo.EXPECT().DeleteToken(gomock.Any(), token.ID, user.ID).Times(1).Return(nil)
+			},
+			checkResponse: func(deleted domain.User, err error) {
+				require.NoError(t, err)
+				require.Equal(t, user.ID, deleted.ID)
+				require.NotNil(t, deleted.DeleteAt)
+			},
+		},
+		{
+			name:     "Incorrect Password",
+			password: "wrong",
+			buildStubs: func() {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				redisRepo.EXPECT().Get(blockedKey).Times(1).Return("", domain.ErrNotFound)
+				redisRepo.EXPECT().Get(signInBlockedKey+signInIP(client.IP)).Times(1).Return("", domain.ErrNotFound)
+				hash.EXPECT().CompareHashAndPassword("hash", "wrong").Times(1).Return(domain.ErrIncorrectPassword)
+				redisRepo.EXPECT().Incr(signInFailuresKey+signInAccount(user.Email), defaultSignInWindow).Times(1).Return(int64(1), nil)
+				redisRepo.EXPECT().Incr(signInFailuresKey+signInIP(client.IP), defaultSignInWindow).Times(1).Return(int64(1), nil)
+				userRepo.EXPECT().ScheduleDeletion(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(deleted domain.User, err error) {
+				require.Equal(t, domain.ErrIncorrectPassword, err)
+			},
+		},
+		{
+			name:     "Locked Out",
+			password: "password",
+			buildStubs: func() {
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Times(1).Return(user, nil)
+				redisRepo.EXPECT().Get(blockedKey).Times(1).Return(time.Now().Add(time.Minute).Format(time.RFC3339Nano), nil)
+				hash.EXPECT().CompareHashAndPassword(gomock.Any(), gomock.Any()).Times(0)
+				userRepo.EXPECT().ScheduleDeletion(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(deleted domain.User, err error) {
+				require.ErrorIs(t, err, domain.ErrTooManyAttempts)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.buildStubs()
+
+			deleted, err := userService.DeleteAccount(ctx, user.ID, tt.password, client)
+			tt.checkResponse(deleted, err)
+		})
+	}
+}
+
+// auditLog keeps the audit events instead of writing them to the log.
+type auditLog struct {
+	events []domain.AuditEvent
+}
+
+func (a *auditLog) Audit(ctx context.Context, event domain.AuditEvent) {
+	a.events = append(a.events, event)
+}
+
+func TestSignInPolicy_backoff(t *testing.T) {
+	policy := SignInPolicy{FreeAttempts: 3, Backoff: time.Second, LockoutDuration: time.Minute}.withDefaults()
+
+	for attempts, delay := range map[int64]time.Duration{
+		1:  0,
+		3:  0,
+		4:  time.Second,
+		5:  2 * time.Second,
+		7:  8 * time.Second,
+		10: time.Minute,
+		70: time.Minute,
+	} {
+		require.Equal(t, delay, policy.backoff(attempts), attempts)
+	}
+}
+
+func TestUserService_SignInLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocksRepo.NewMockUsers(ctrl)
+	redisRepo := mocksRepo.NewMockRedis(ctrl)
+	hash := mocksHash.NewMockPasswordHasher(ctrl)
+
+	manager, err := auth.NewManager("qwerty")
+	require.NoError(t, err)
+
+	userService := NewUserService(userRepo, mocksRepo.NewMockSessions(ctrl), mocksRepo.NewMockPersonalTokens(ctrl), mocksRepo.NewMockMFA(ctrl),
+		hash, manager, time.Minute, time.Minute, redisRepo, MFAPolicy{}, mocksMail.NewMockMailer(ctrl), PasswordResetPolicy{},
+		EmailVerificationPolicy{}, AccountPolicy{}, SignInPolicy{
+			FreeAttempts:      3,
+			Backoff:           time.Second,
+			LockoutAttempts:   10,
+			IPLockoutAttempts: 50,
+			LockoutDuration:   time.Hour,
+			Window:            time.Hour,
+		})
+	audit := &auditLog{}
+	userService.auditor = audit
+
+	email := utils.RandomEmail()
+	client := domain.Client{Device: "Phone", IP: "198.51.100.1"}
+	user := domain.User{ID: utils.RandomString(24), Email: email, Password: "hash"}
+
+	failedSignIn := func(attempts, ipAttempts int64) {
+		redisRepo.EXPECT().Get(signInBlockedKey+signInAccount(email)).Times(1).Return("", domain.ErrNotFound)
+		redisRepo.EXPECT().Get(signInBlockedKey+signInIP(client.IP)).Times(1).Return("", domain.ErrNotFound)
+		userRepo.EXPECT().GetUserByEmail(gomock.Any(), email).Times(1).Return(user, nil)
+		hash.EXPECT().CompareHashAndPassword("hash", "password").Times(1).Return(domain.ErrIncorrectPassword)
+		redisRepo.EXPECT().Incr(signInFailuresKey+signInAccount(email), time.Hour).Times(1).Return(attempts, nil)
+		redisRepo.EXPECT().Incr(signInFailuresKey+signInIP(client.IP), time.Hour).Times(1).Return(ipAttempts, nil)
+	}
+
+	tests := []struct {
+		name          string
+		buildStubs    func()
+		checkResponse func(err error)
+	}{
+		{
+			name: "Free Attempt",
+			buildStubs: func() {
+				failedSignIn(3, 3)
+				redisRepo.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(err error) {
+				require.Equal(t, domain.ErrIncorrectPassword, err)
+				require.Empty(t, audit.events)
+			},
+		},
+		{
+			name: "Backoff",
+			buildStubs: func() {
+				failedSignIn(5, 5)
+				redisRepo.EXPECT().Set(signInBlockedKey+signInAccount(email), gomock.Any(), 2*time.Second).Times(1).Return(nil)
+			},
+			checkResponse: func(err error) {
+				var retryErr *domain.RetryError
+				require.ErrorAs(t, err, &retryErr)
+				require.Equal(t, domain.ErrTooManyAttempts, retryErr.Err)
+				require.Equal(t, 2*time.Second, retryErr.RetryAfter)
+				require.Empty(t, audit.events)
+			},
+		},
+		{
+			name: "Account Lockout",
+			buildStubs: func() {
+				failedSignIn(10, 10)
+				redisRepo.EXPECT().Set(signInBlockedKey+signInAccount(email), gomock.Any(), time.Hour).Times(1).Return(nil)
+			},
+			checkResponse: func(err error) {
+				var retryErr *domain.RetryError
+				require.ErrorAs(t, err, &retryErr)
+				require.Equal(t, time.Hour, retryErr.RetryAfter)
+
+				require.Len(t, audit.events, 1)
+				require.Equal(t, domain.AuditSignInLockout, audit.events[0].Type)
+				require.Equal(t, signInScopeAccount, audit.events[0].Scope)
+				require.Equal(t, email, audit.events[0].Email)
+				require.Equal(t, client.IP, audit.events[0].IP)
+				require.Equal(t, int64(10), audit.events[0].Attempts)
+			},
+		},
+		{
+			name: "IP Lockout",
+			buildStubs: func() {
+				failedSignIn(1, 50)
+				redisRepo.EXPECT().Set(signInBlockedKey+signInIP(client.IP), gomock.Any(), time.Hour).Times(1).Return(nil)
+			},
+			checkResponse: func(err error) {
+				require.Equal(t, domain.ErrIncorrectPassword, err)
+
+				require.Len(t, audit.events, 1)
+				require.Equal(t, signInScopeIP, audit.events[0].Scope)
+				require.Equal(t, int64(50), audit.events[0].Attempts)
+			},
+		},
+		{
+			name: "Locked",
+			buildStubs: func() {
+				until := time.Now().Add(time.Minute).Format(time.RFC3339Nano)
+				redisRepo.EXPECT().Get(signInBlockedKey+signInAccount(email)).Times(1).Return(until, nil)
+				userRepo.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(0)
+				hash.EXPECT().CompareHashAndPassword(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(err error) {
+				var retryErr *domain.RetryError
+				require.ErrorAs(t, err, &retryErr)
+				require.InDelta(t, time.Minute, retryErr.RetryAfter, float64(time.Second))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audit.events = nil
+			tt.buildStubs()
+
+			_, err := userService.SignIn(ctx, email, "password", client)
+			tt.checkResponse(err)
+		})
+	}
+}
@@ -1,23 +1,78 @@
 package auth
 
 import (
+	"crypto/rand"
 	"errors"
 	"fmt"
-	"math/rand"
+	"sort"
+	"strings"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/golang-jwt/jwt/v5"
 )
 
 // TokenManager provides logic for JWT & Refresh tokens generation and parsing.
+// JWKS lists the public keys access tokens may be verified with, none for
+// tokens signed with a shared secret.
 type TokenManager interface {
-	NewJWT(userId string, ttl time.Duration) (string, error)
-	Parse(accessToken string) (string, error)
+	NewJWT(claims Claims, ttl time.Duration) (string, error)
+	Parse(accessToken string) (Claims, error)
 	NewRefreshToken() (string, error)
+	JWKS() JWKS
 }
 
+// Default issuer and audience of access tokens.
+const (
+	DefaultIssuer   = "region-llc-task"
+	DefaultAudience = "region-llc-task-api"
+)
+
+// leeway is the clock skew allowed when checking exp, nbf and iat, the
+// clocks of the services verifying our tokens may differ slightly.
+const leeway = 30 * time.Second
+
+// Claims identify an access token: ID is its unique jti, by which the token
+// can be revoked before it expires. SessionID is the session it was issued
+// for, Scopes are the operations the token allows.
+//
+// NewJWT takes Subject, SessionID and Scopes, the other claims are set by the
+// Manager.
+type Claims struct {
+	Subject   string
+	ID        string
+	SessionID string
+	Scopes    []string
+	Issuer    string
+	Audience  []string
+	IssuedAt  time.Time
+	NotBefore time.Time
+	ExpiresAt time.Time
+}
+
+// tokenClaims is the payload of a token, scopes are space separated as in
+// RFC 8693.
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	SessionID string `json:"sid,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+}
+
+// Manager signs access tokens either with HS256 and a shared signing key or,
+// when it has keys, with the asymmetric key of the rotation that has started
+// last. A key that has been replaced is still accepted and published for the
+// overlap, so that the tokens it has signed stay valid until they expire. Keys
+// that have not started yet are published in advance.
+//
+// Tokens are issued for the issuer and audience of the Manager, DefaultIssuer
+// and DefaultAudience unless SetIssuer is called, and tokens of another
+// issuer or audience are rejected.
 type Manager struct {
 	signingKey string
+	keys       []Key
+	overlap    time.Duration
+	issuer     string
+	audience   string
+	now        func() time.Time
 }
 
 func NewManager(signingKey string) (*Manager, error) {
@@ -25,47 +80,186 @@ func NewManager(signingKey string) (*Manager, error) {
 		return nil, errors.New("empty signing key")
 	}
 
-	return &Manager{signingKey: signingKey}, nil
+	return &Manager{signingKey: signingKey, issuer: DefaultIssuer, audience: DefaultAudience, now: time.Now}, nil
 }
 
-func (m *Manager) NewJWT(userId string, ttl time.Duration) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{
-		ExpiresAt: time.Now().Add(ttl).Unix(),
-		Subject:   userId,
+// NewKeyManager returns a Manager signing with the keys, overlap should be
+// at least the TTL of access tokens.
+func NewKeyManager(keys []Key, overlap time.Duration) (*Manager, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no signing keys")
+	}
+
+	keys = append([]Key(nil), keys...)
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].NotBefore.Before(keys[j].NotBefore)
 	})
 
-	return token.SignedString([]byte(m.signingKey))
+	ids := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if ids[key.ID] {
+			return nil, fmt.Errorf("duplicate key id %q", key.ID)
+		}
+		ids[key.ID] = true
+	}
+
+	return &Manager{keys: keys, overlap: overlap, issuer: DefaultIssuer, audience: DefaultAudience, now: time.Now}, nil
 }
 
-func (m *Manager) Parse(accessToken string) (string, error) {
-	token, err := jwt.Parse(accessToken, func(token *jwt.Token) (i interface{}, err error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
+// SetIssuer changes the issuer and audience of the tokens, before any is
+// issued.
+func (m *Manager) SetIssuer(issuer string, audience string) {
+	m.issuer = issuer
+	m.audience = audience
+}
 
-		return []byte(m.signingKey), nil
-	})
-	if err != nil {
+func (m *Manager) NewJWT(claims Claims, ttl time.Duration) (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
 		return "", err
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
+	now := m.now()
+	payload := tokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{m.audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			ID:        fmt.Sprintf("%x", id),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    m.issuer,
+			NotBefore: jwt.NewNumericDate(now),
+			Subject:   claims.Subject,
+		},
+		SessionID: claims.SessionID,
+		Scope:     strings.Join(claims.Scopes, " "),
+	}
+
+	if len(m.keys) == 0 {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, payload).SignedString([]byte(m.signingKey))
+	}
+
+	key, ok := m.signingKeyAt(now)
 	if !ok {
-		return "", fmt.Errorf("error get user claims from token")
+		return "", errors.New("no signing key has started")
 	}
 
-	return claims["sub"].(string), nil
+	token := jwt.NewWithClaims(key.method, payload)
+	token.Header["kid"] = key.ID
+
+	return token.SignedString(key.private)
 }
 
+// Parse verifies the signature and the claims of the token: the algorithm
+// must be one the Manager signs with, exp and nbf must be present and, up to
+// the leeway, reached and not reached yet, iat must not be in the future, iss
+// and aud must be those of the Manager.
+func (m *Manager) Parse(accessToken string) (Claims, error) {
+	var claims tokenClaims
+	_, err := jwt.ParseWithClaims(accessToken, &claims, m.verificationKey,
+		jwt.WithValidMethods(m.algorithms()),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithIssuer(m.issuer),
+		jwt.WithAudience(m.audience),
+		jwt.WithLeeway(leeway),
+		jwt.WithTimeFunc(m.now),
+	)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	if claims.Subject == "" {
+		return Claims{}, fmt.Errorf("error get subject from token")
+	}
+
+	// The parser only checks nbf when it is present.
+	if claims.ID == "" || claims.NotBefore == nil || claims.IssuedAt == nil {
+		return Claims{}, fmt.Errorf("error get jti, nbf or iat from token")
+	}
+
+	return Claims{
+		Subject:   claims.Subject,
+		ID:        claims.ID,
+		SessionID: claims.SessionID,
+		Scopes:    strings.Fields(claims.Scope),
+		Issuer:    claims.Issuer,
+		Audience:  claims.Audience,
+		IssuedAt:  claims.IssuedAt.Time,
+		NotBefore: claims.NotBefore.Time,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
+
+// NewRefreshToken returns an opaque token with RefreshTokenPrefix.
 func (m *Manager) NewRefreshToken() (string, error) {
-	b := make([]byte, 32)
+	return NewToken(RefreshTokenPrefix)
+}
 
-	s := rand.NewSource(time.Now().Unix())
-	r := rand.New(s)
+// JWKS lists the keys that are published at the moment.
+func (m *Manager) JWKS() JWKS {
+	res := JWKS{Keys: []JWK{}}
+	for _, key := range m.publishedKeys(m.now()) {
+		res.Keys = append(res.Keys, key.jwk())
+	}
 
-	if _, err := r.Read(b); err != nil {
-		return "", err
+	return res
+}
+
+// algorithms are the algorithms of the tokens the Manager accepts.
+func (m *Manager) algorithms() []string {
+	if len(m.keys) == 0 {
+		return []string{jwt.SigningMethodHS256.Alg()}
+	}
+
+	var res []string
+	for _, key := range m.keys {
+		res = append(res, key.Algorithm())
+	}
+
+	return res
+}
+
+func (m *Manager) verificationKey(token *jwt.Token) (interface{}, error) {
+	if len(m.keys) == 0 {
+		return []byte(m.signingKey), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	for _, key := range m.publishedKeys(m.now()) {
+		if key.ID != kid {
+			continue
+		}
+
+		if token.Method.Alg() != key.Algorithm() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return key.private.Public(), nil
+	}
+
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
+
+// signingKeyAt returns the key that has started last at the time.
+func (m *Manager) signingKeyAt(now time.Time) (Key, bool) {
+	for i := len(m.keys) - 1; i >= 0; i-- {
+		if !m.keys[i].NotBefore.After(now) {
+			return m.keys[i], true
+		}
+	}
+
+	return Key{}, false
+}
+
+// publishedKeys drops the keys replaced for longer than the overlap.
+func (m *Manager) publishedKeys(now time.Time) []Key {
+	var keys []Key
+	for i, key := range m.keys {
+		if i+1 < len(m.keys) && !now.Before(m.keys[i+1].NotBefore.Add(m.overlap)) {
+			continue
+		}
+		keys = append(keys, key)
 	}
 
-	return fmt.Sprintf("%x", b), nil
+	return keys
 }
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_Claims(t *testing.T) {
+	manager, err := NewManager("qwerty")
+	require.NoError(t, err)
+
+	token, err := manager.NewJWT(Claims{
+		Subject:   "user",
+		SessionID: "session",
+		Scopes:    []string{"todos:read", "account"},
+	}, time.Minute)
+	require.NoError(t, err)
+
+	claims, err := manager.Parse(token)
+	require.NoError(t, err)
+	require.Equal(t, "user", claims.Subject)
+	require.Equal(t, "session", claims.SessionID)
+	require.Equal(t, []string{"todos:read", "account"}, claims.Scopes)
+	require.Equal(t, DefaultIssuer, claims.Issuer)
+	require.Equal(t, []string{DefaultAudience}, claims.Audience)
+	require.Len(t, claims.ID, 32)
+	require.WithinDuration(t, time.Now(), claims.IssuedAt, time.Second)
+	require.Equal(t, claims.IssuedAt, claims.NotBefore)
+	require.Equal(t, claims.IssuedAt.Add(time.Minute), claims.ExpiresAt)
+}
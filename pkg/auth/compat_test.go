@@ -0,0 +1,308 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"hash"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The compatibility suite builds tokens by hand rather than with a JWT
+// library, so that it documents which tokens Manager.Parse accepts no matter
+// the library it is built on. Tokens of the previous library had aud as a
+// string and integer dates, those are still accepted.
+
+type signer func(signingInput string) []byte
+
+func hmacSigner(h func() hash.Hash, secret string) signer {
+	return func(signingInput string) []byte {
+		mac := hmac.New(h, []byte(secret))
+		mac.Write([]byte(signingInput))
+		return mac.Sum(nil)
+	}
+}
+
+func noSignature(string) []byte {
+	return nil
+}
+
+func rawToken(t *testing.T, header, payload map[string]interface{}, sign signer) string {
+	encode := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		require.NoError(t, err)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	signingInput := encode(header) + "." + encode(payload)
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sign(signingInput))
+}
+
+func validPayload(now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"sub":   "user",
+		"jti":   "0123456789abcdef",
+		"sid":   "session",
+		"scope": "todos:read",
+		"iss":   DefaultIssuer,
+		"aud":   DefaultAudience,
+		"iat":   now.Unix(),
+		"nbf":   now.Unix(),
+		"exp":   now.Add(time.Minute).Unix(),
+	}
+}
+
+func TestManager_ParseCompatibility(t *testing.T) {
+	manager, err := NewManager("qwerty")
+	require.NoError(t, err)
+
+	now := time.Now()
+	manager.now = func() time.Time { return now }
+
+	hs256 := hmacSigner(sha256.New, "qwerty")
+
+	tests := []struct {
+		name     string
+		alg      string
+		modify   func(payload map[string]interface{})
+		sign     signer
+		tamper   func(token string) string
+		accepted bool
+	}{
+		{
+			name:     "HS256 with aud as a string",
+			accepted: true,
+		},
+		{
+			name:     "aud as a list with ours",
+			modify:   func(p map[string]interface{}) { p["aud"] = []string{"billing", DefaultAudience} },
+			accepted: true,
+		},
+		{
+			name:     "fractional dates",
+			modify:   func(p map[string]interface{}) { p["exp"] = float64(now.Add(time.Minute).UnixNano()) / 1e9 },
+			accepted: true,
+		},
+		{
+			name:     "expired within the leeway",
+			modify:   func(p map[string]interface{}) { p["exp"] = now.Add(-leeway / 2).Unix() },
+			accepted: true,
+		},
+		{
+			name:     "nbf within the leeway",
+			modify:   func(p map[string]interface{}) { p["nbf"] = now.Add(leeway / 2).Unix() },
+			accepted: true,
+		},
+		{
+			name:     "iat within the leeway",
+			modify:   func(p map[string]interface{}) { p["iat"] = now.Add(leeway / 2).Unix() },
+			accepted: true,
+		},
+		{
+			name:   "expired",
+			modify: func(p map[string]interface{}) { p["exp"] = now.Add(-2 * leeway).Unix() },
+		},
+		{
+			name:   "nbf beyond the leeway",
+			modify: func(p map[string]interface{}) { p["nbf"] = now.Add(2 * leeway).Unix() },
+		},
+		{
+			name:   "iat in the future",
+			modify: func(p map[string]interface{}) { p["iat"] = now.Add(2 * leeway).Unix() },
+		},
+		{
+			name:   "no exp",
+			modify: func(p map[string]interface{}) { delete(p, "exp") },
+		},
+		{
+			name:   "no nbf",
+			modify: func(p map[string]interface{}) { delete(p, "nbf") },
+		},
+		{
+			name:   "no iat",
+			modify: func(p map[string]interface{}) { delete(p, "iat") },
+		},
+		{
+			name:   "no jti",
+			modify: func(p map[string]interface{}) { delete(p, "jti") },
+		},
+		{
+			name:   "no sub",
+			modify: func(p map[string]interface{}) { delete(p, "sub") },
+		},
+		{
+			name:   "other issuer",
+			modify: func(p map[string]interface{}) { p["iss"] = "billing" },
+		},
+		{
+			name:   "other audience",
+			modify: func(p map[string]interface{}) { p["aud"] = []string{"billing"} },
+		},
+		{
+			name:   "no audience",
+			modify: func(p map[string]interface{}) { delete(p, "aud") },
+		},
+		{
+			name: "alg none",
+			alg:  "none",
+			sign: noSignature,
+		},
+		{
+			name: "alg none with a signature",
+			alg:  "none",
+		},
+		{
+			name: "HS512",
+			alg:  "HS512",
+			sign: hmacSigner(sha512.New, "qwerty"),
+		},
+		{
+			name: "HS256 header with an HS512 signature",
+			sign: hmacSigner(sha512.New, "qwerty"),
+		},
+		{
+			name: "RS256 header with an HS256 signature",
+			alg:  "RS256",
+		},
+		{
+			name: "other secret",
+			sign: hmacSigner(sha256.New, "secret"),
+		},
+		{
+			name: "tampered signature",
+			tamper: func(token string) string {
+				sig := []byte(token[strings.LastIndex(token, ".")+1:])
+				sig[0] ^= 1
+				return token[:strings.LastIndex(token, ".")+1] + string(sig)
+			},
+		},
+		{
+			name: "tampered payload",
+			tamper: func(token string) string {
+				parts := strings.Split(token, ".")
+				payload := validPayload(now)
+				payload["sub"] = "admin"
+				data, _ := json.Marshal(payload)
+				parts[1] = base64.RawURLEncoding.EncodeToString(data)
+				return strings.Join(parts, ".")
+			},
+		},
+		{
+			name:   "not a JWT",
+			tamper: func(token string) string { return "qwerty" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+			if tt.alg != "" {
+				header["alg"] = tt.alg
+			}
+
+			payload := validPayload(now)
+			if tt.modify != nil {
+				tt.modify(payload)
+			}
+
+			sign := hs256
+			if tt.sign != nil {
+				sign = tt.sign
+			}
+
+			token := rawToken(t, header, payload, sign)
+			if tt.tamper != nil {
+				token = tt.tamper(token)
+			}
+
+			claims, err := manager.Parse(token)
+			if !tt.accepted {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, "user", claims.Subject)
+			require.Equal(t, "session", claims.SessionID)
+			require.Equal(t, []string{"todos:read"}, claims.Scopes)
+		})
+	}
+}
+
+func TestKeyManager_ParseCompatibility(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey := writeRSAKey(t, filepath.Join(dir, "rsa.pem"))
+	writeEd25519Key(t, filepath.Join(dir, "ed.pem"))
+	writeKeysFile(t, dir, []keyEntry{{ID: "rsa", File: "rsa.pem"}, {ID: "ed", File: "ed.pem"}})
+
+	keys, err := LoadKeys(dir)
+	require.NoError(t, err)
+
+	// Both keys are published, the second one signs.
+	manager, err := NewKeyManager(keys, time.Hour)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	publicPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		header map[string]interface{}
+		sign   signer
+	}{
+		{
+			// The public key is known to everyone, it must not be taken
+			// for an HMAC secret.
+			name:   "HS256 with the public key as the secret",
+			header: map[string]interface{}{"alg": "HS256", "kid": "rsa"},
+			sign:   hmacSigner(sha256.New, publicPEM),
+		},
+		{
+			name:   "alg none",
+			header: map[string]interface{}{"alg": "none", "kid": "ed"},
+			sign:   noSignature,
+		},
+		{
+			name:   "EdDSA header with an RS256 kid",
+			header: map[string]interface{}{"alg": "EdDSA", "kid": "rsa"},
+			sign:   noSignature,
+		},
+		{
+			name:   "unknown kid",
+			header: map[string]interface{}{"alg": "EdDSA", "kid": "other"},
+			sign:   noSignature,
+		},
+		{
+			name:   "no kid",
+			header: map[string]interface{}{"alg": "EdDSA"},
+			sign:   noSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := manager.Parse(rawToken(t, tt.header, validPayload(now), tt.sign))
+			require.Error(t, err)
+		})
+	}
+
+	// A token the manager signs passes the same checks.
+	token, err := manager.NewJWT(Claims{Subject: "user"}, time.Minute)
+	require.NoError(t, err)
+
+	_, err = manager.Parse(token)
+	require.NoError(t, err)
+}
@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeysFile lists the keys of a keys directory, in the order of rotation:
+//
+//	[
+//		{"kid": "2024-05", "file": "2024-05.pem"},
+//		{"kid": "2024-06", "file": "2024-06.pem", "not_before": "2024-06-01T00:00:00Z"}
+//	]
+//
+// Files are PEM private keys relative to the directory, not_before may be
+// omitted for a key that signs right away.
+const KeysFile = "keys.json"
+
+// minRSAKeySize is the smallest RSA key accepted for RS256.
+const minRSAKeySize = 2048
+
+// Key is an asymmetric key access tokens are signed with, identified by the
+// kid header of the tokens. A key signs from NotBefore until the next key of
+// the rotation starts.
+type Key struct {
+	ID        string
+	NotBefore time.Time
+	method    jwt.SigningMethod
+	private   crypto.Signer
+}
+
+// Algorithm is the alg of the tokens signed with the key: RS256 or EdDSA.
+func (k Key) Algorithm() string {
+	return k.method.Alg()
+}
+
+// JWK is the public part of a key in the JSON Web Key format, RFC 7517.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	ID        string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+// JWKS is the set of keys tokens may be verified with.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+type keyEntry struct {
+	ID        string    `json:"kid"`
+	File      string    `json:"file"`
+	NotBefore time.Time `json:"not_before"`
+}
+
+// LoadKeys reads the keys listed in the KeysFile of the directory.
+func LoadKeys(dir string) ([]Key, error) {
+	data, err := os.ReadFile(filepath.Join(dir, KeysFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []keyEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%s: %v", KeysFile, err)
+	}
+
+	keys := make([]Key, 0, len(entries))
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, entry.File))
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := ParseKey(entry.ID, data, entry.NotBefore)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// ParseKey reads a PEM private key: RSA, PKCS #1 or PKCS #8, for RS256 or
+// Ed25519, PKCS #8, for EdDSA.
+func ParseKey(id string, data []byte, notBefore time.Time) (Key, error) {
+	if id == "" {
+		return Key{}, errors.New("empty key id")
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, fmt.Errorf("key %s: no PEM data", id)
+	}
+
+	var (
+		private interface{}
+		err     error
+	)
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return Key{}, fmt.Errorf("key %s: unsupported PEM type %q", id, block.Type)
+	}
+	if err != nil {
+		return Key{}, fmt.Errorf("key %s: %v", id, err)
+	}
+
+	key := Key{ID: id, NotBefore: notBefore}
+
+	switch private := private.(type) {
+	case *rsa.PrivateKey:
+		if private.N.BitLen() < minRSAKeySize {
+			return Key{}, fmt.Errorf("key %s: RSA key of %d bits, at least %d required", id, private.N.BitLen(), minRSAKeySize)
+		}
+		key.method, key.private = jwt.SigningMethodRS256, private
+	case ed25519.PrivateKey:
+		key.method, key.private = jwt.SigningMethodEdDSA, private
+	default:
+		return Key{}, fmt.Errorf("key %s: unsupported key type %T", id, private)
+	}
+
+	return key, nil
+}
+
+// jwk returns the public part of the key.
+func (k Key) jwk() JWK {
+	res := JWK{
+		ID:        k.ID,
+		Use:       "sig",
+		Algorithm: k.Algorithm(),
+	}
+
+	switch public := k.private.Public().(type) {
+	case *rsa.PublicKey:
+		res.KeyType = "RSA"
+		res.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		res.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		res.KeyType = "OKP"
+		res.Curve = "Ed25519"
+		res.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+
+	return res
+}
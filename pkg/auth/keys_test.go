@@ -0,0 +1,272 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeRSAKey(t *testing.T, path string) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	require.NoError(t, os.WriteFile(path, data, 0600))
+
+	return key
+}
+
+func writeEd25519Key(t *testing.T, path string) ed25519.PrivateKey {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	require.NoError(t, os.WriteFile(path, data, 0600))
+
+	return key
+}
+
+func writeKeysFile(t *testing.T, dir string, entries []keyEntry) {
+	data, err := json.Marshal(entries)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, KeysFile), data, 0600))
+}
+
+func TestLoadKeys(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey := writeRSAKey(t, filepath.Join(dir, "rsa.pem"))
+	edKey := writeEd25519Key(t, filepath.Join(dir, "ed.pem"))
+
+	notBefore := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	writeKeysFile(t, dir, []keyEntry{
+		{ID: "rsa", File: "rsa.pem"},
+		{ID: "ed", File: "ed.pem", NotBefore: notBefore},
+	})
+
+	keys, err := LoadKeys(dir)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+
+	require.Equal(t, "rsa", keys[0].ID)
+	require.Equal(t, "RS256", keys[0].Algorithm())
+	require.True(t, keys[0].NotBefore.IsZero())
+	require.Equal(t, rsaKey.Public(), keys[0].private.Public())
+
+	require.Equal(t, "ed", keys[1].ID)
+	require.Equal(t, "EdDSA", keys[1].Algorithm())
+	require.True(t, notBefore.Equal(keys[1].NotBefore))
+	require.Equal(t, edKey.Public(), keys[1].private.Public())
+
+	_, err = LoadKeys(t.TempDir())
+	require.Error(t, err)
+}
+
+func TestParseKey(t *testing.T) {
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		id   string
+		data []byte
+	}{
+		{
+			name: "small RSA key",
+			id:   "small",
+			data: pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(small)}),
+		},
+		{
+			name: "public key",
+			id:   "public",
+			data: pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte("key")}),
+		},
+		{
+			name: "not PEM",
+			id:   "text",
+			data: []byte("qwerty"),
+		},
+		{
+			name: "empty id",
+			data: pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(small)}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseKey(tt.id, tt.data, time.Time{})
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestKeyManager_Sign(t *testing.T) {
+	dir := t.TempDir()
+	writeRSAKey(t, filepath.Join(dir, "rsa.pem"))
+	writeEd25519Key(t, filepath.Join(dir, "ed.pem"))
+
+	for _, id := range []string{"rsa", "ed"} {
+		t.Run(id, func(t *testing.T) {
+			writeKeysFile(t, dir, []keyEntry{{ID: id, File: id + ".pem"}})
+
+			keys, err := LoadKeys(dir)
+			require.NoError(t, err)
+
+			manager, err := NewKeyManager(keys, time.Hour)
+			require.NoError(t, err)
+
+			token, err := manager.NewJWT(Claims{Subject: "user", SessionID: "session"}, time.Minute)
+			require.NoError(t, err)
+
+			claims, err := manager.Parse(token)
+			require.NoError(t, err)
+			require.Equal(t, "user", claims.Subject)
+			require.Equal(t, "session", claims.SessionID)
+
+			// Tokens of the shared secret are not accepted.
+			hmac, err := NewManager("qwerty")
+			require.NoError(t, err)
+
+			token, err = hmac.NewJWT(Claims{Subject: "user", SessionID: "session"}, time.Minute)
+			require.NoError(t, err)
+
+			_, err = manager.Parse(token)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestKeyManager_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	writeRSAKey(t, filepath.Join(dir, "old.pem"))
+	writeEd25519Key(t, filepath.Join(dir, "new.pem"))
+
+	rotation := time.Now().Add(24 * time.Hour)
+	writeKeysFile(t, dir, []keyEntry{
+		{ID: "new", File: "new.pem", NotBefore: rotation},
+		{ID: "old", File: "old.pem", NotBefore: rotation.Add(-30 * 24 * time.Hour)},
+	})
+
+	keys, err := LoadKeys(dir)
+	require.NoError(t, err)
+
+	manager, err := NewKeyManager(keys, time.Hour)
+	require.NoError(t, err)
+
+	kids := func() []string {
+		var res []string
+		for _, key := range manager.JWKS().Keys {
+			res = append(res, key.ID)
+		}
+		return res
+	}
+
+	// Before the rotation the new key is published, but does not sign.
+	manager.now = func() time.Time { return rotation.Add(-time.Minute) }
+
+	oldToken, err := manager.NewJWT(Claims{Subject: "user", SessionID: "session"}, 2*time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, []string{"old", "new"}, kids())
+
+	// During the overlap the new key signs, tokens of the old one are valid.
+	manager.now = func() time.Time { return rotation.Add(time.Minute) }
+
+	newToken, err := manager.NewJWT(Claims{Subject: "user", SessionID: "session"}, 2*time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, []string{"old", "new"}, kids())
+
+	_, err = manager.Parse(oldToken)
+	require.NoError(t, err)
+
+	_, err = manager.Parse(newToken)
+	require.NoError(t, err)
+
+	// After the overlap the old key is dropped.
+	manager.now = func() time.Time { return rotation.Add(time.Hour) }
+
+	require.Equal(t, []string{"new"}, kids())
+
+	_, err = manager.Parse(oldToken)
+	require.Error(t, err)
+
+	_, err = manager.Parse(newToken)
+	require.NoError(t, err)
+}
+
+func TestKeyManager_Errors(t *testing.T) {
+	_, err := NewKeyManager(nil, time.Hour)
+	require.Error(t, err)
+
+	dir := t.TempDir()
+	writeEd25519Key(t, filepath.Join(dir, "ed.pem"))
+	writeKeysFile(t, dir, []keyEntry{{ID: "ed", File: "ed.pem"}, {ID: "ed", File: "ed.pem"}})
+
+	keys, err := LoadKeys(dir)
+	require.NoError(t, err)
+
+	_, err = NewKeyManager(keys, time.Hour)
+	require.Error(t, err)
+
+	// No key has started yet.
+	keys[0].NotBefore = time.Now().Add(time.Hour)
+
+	manager, err := NewKeyManager(keys[:1], time.Hour)
+	require.NoError(t, err)
+
+	_, err = manager.NewJWT(Claims{Subject: "user", SessionID: "session"}, time.Minute)
+	require.Error(t, err)
+}
+
+func TestManager_JWKS(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey := writeRSAKey(t, filepath.Join(dir, "rsa.pem"))
+	edKey := writeEd25519Key(t, filepath.Join(dir, "ed.pem"))
+	writeKeysFile(t, dir, []keyEntry{
+		{ID: "rsa", File: "rsa.pem"},
+		{ID: "ed", File: "ed.pem", NotBefore: time.Now().Add(time.Hour)},
+	})
+
+	keys, err := LoadKeys(dir)
+	require.NoError(t, err)
+
+	manager, err := NewKeyManager(keys, time.Hour)
+	require.NoError(t, err)
+
+	jwks := manager.JWKS()
+	require.Len(t, jwks.Keys, 2)
+
+	rsaJWK := jwks.Keys[0]
+	require.Equal(t, JWK{KeyType: "RSA", ID: "rsa", Use: "sig", Algorithm: "RS256", N: rsaJWK.N, E: "AQAB"}, rsaJWK)
+
+	n, err := base64.RawURLEncoding.DecodeString(rsaJWK.N)
+	require.NoError(t, err)
+	require.Equal(t, 0, new(big.Int).SetBytes(n).Cmp(rsaKey.N))
+
+	edJWK := jwks.Keys[1]
+	require.Equal(t, JWK{KeyType: "OKP", ID: "ed", Use: "sig", Algorithm: "EdDSA", Curve: "Ed25519", X: edJWK.X}, edJWK)
+
+	x, err := base64.RawURLEncoding.DecodeString(edJWK.X)
+	require.NoError(t, err)
+	require.Equal(t, []byte(edKey.Public().(ed25519.PublicKey)), x)
+
+	// A shared secret is never published.
+	hmac, err := NewManager("qwerty")
+	require.NoError(t, err)
+
+	data, err := json.Marshal(hmac.JWKS())
+	require.NoError(t, err)
+	require.JSONEq(t, `{"keys": []}`, string(data))
+}
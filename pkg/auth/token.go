@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/crc32"
+	"strings"
+)
+
+// Prefixes mark the kind of opaque tokens, so that secret scanners can tell a
+// leaked one from random text.
+const (
+	RefreshTokenPrefix       = "rlr_"
+	PersonalTokenPrefix      = "rlp_"
+	MFATokenPrefix           = "rlm_"
+	PasswordResetTokenPrefix = "rlw_"
+	EmailTokenPrefix         = "rle_"
+)
+
+const (
+	base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+	// tokenSecretLength base62 characters carry about 190 random bits.
+	tokenSecretLength = 32
+	// tokenChecksumLength base62 characters hold any CRC32.
+	tokenChecksumLength = 6
+)
+
+// NewToken returns an opaque token: the prefix, a random base62 secret from
+// crypto/rand and a base62 CRC32 checksum of both. The checksum lets scanners
+// and VerifyToken recognize a token without looking it up.
+func NewToken(prefix string) (string, error) {
+	secret := make([]byte, 0, tokenSecretLength)
+	buf := make([]byte, tokenSecretLength)
+
+	for len(secret) < tokenSecretLength {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+
+		for _, b := range buf {
+			// Bytes above the largest multiple of 62 would bias the
+			// first characters of the alphabet.
+			if b >= 248 || len(secret) == tokenSecretLength {
+				continue
+			}
+			secret = append(secret, base62[b%62])
+		}
+	}
+
+	token := prefix + string(secret)
+
+	return token + checksum(token), nil
+}
+
+// VerifyToken reports whether the token was made by NewToken with the prefix,
+// false for a mistyped or truncated one.
+func VerifyToken(token string, prefix string) bool {
+	if len(token) != len(prefix)+tokenSecretLength+tokenChecksumLength || !strings.HasPrefix(token, prefix) {
+		return false
+	}
+
+	body := token[:len(token)-tokenChecksumLength]
+	for _, c := range body[len(prefix):] {
+		if !strings.ContainsRune(base62, c) {
+			return false
+		}
+	}
+
+	return checksum(body) == token[len(body):]
+}
+
+// HashToken returns the hex SHA-256 of an opaque token, tokens are stored by
+// their hash so that a leaked store cannot be used to sign in.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// checksum encodes the CRC32 of s in tokenChecksumLength base62 characters.
+func checksum(s string) string {
+	sum := crc32.ChecksumIEEE([]byte(s))
+
+	res := make([]byte, tokenChecksumLength)
+	for i := len(res) - 1; i >= 0; i-- {
+		res[i] = base62[sum%62]
+		sum /= 62
+	}
+
+	return string(res)
+}
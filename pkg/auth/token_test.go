@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewToken(t *testing.T) {
+	token, err := NewToken(RefreshTokenPrefix)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(token, RefreshTokenPrefix))
+	require.Len(t, token, len(RefreshTokenPrefix)+tokenSecretLength+tokenChecksumLength)
+	require.True(t, VerifyToken(token, RefreshTokenPrefix))
+
+	for _, c := range strings.TrimPrefix(token, RefreshTokenPrefix) {
+		require.Contains(t, base62, string(c))
+	}
+}
+
+func TestVerifyToken(t *testing.T) {
+	token, err := NewToken(RefreshTokenPrefix)
+	require.NoError(t, err)
+
+	// Changes a character of the secret to another one of the alphabet.
+	tampered := []byte(token)
+	i := len(RefreshTokenPrefix)
+	tampered[i] = base62[(strings.IndexByte(base62, tampered[i])+1)%len(base62)]
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{name: "OK", token: token, valid: true},
+		{name: "other prefix", token: PersonalTokenPrefix + strings.TrimPrefix(token, RefreshTokenPrefix)},
+		{name: "tampered", token: string(tampered)},
+		{name: "truncated", token: token[:len(token)-1]},
+		{name: "extended", token: token + "0"},
+		{name: "not base62", token: token[:len(RefreshTokenPrefix)] + "-" + token[len(RefreshTokenPrefix)+1:]},
+		{name: "hex", token: strings.Repeat("ab", 32)},
+		{name: "empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.valid, VerifyToken(tt.token, RefreshTokenPrefix))
+		})
+	}
+}
+
+func TestManager_NewRefreshTokenConcurrent(t *testing.T) {
+	manager, err := NewManager("qwerty")
+	require.NoError(t, err)
+
+	const (
+		workers = 16
+		tokens  = 500
+	)
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		seen = make(map[string]struct{}, workers*tokens)
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < tokens; i++ {
+				token, err := manager.NewRefreshToken()
+				require.NoError(t, err)
+				require.True(t, VerifyToken(token, RefreshTokenPrefix))
+
+				mu.Lock()
+				seen[token] = struct{}{}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Len(t, seen, workers*tokens)
+}
+
+func TestManager_NewJWTConcurrent(t *testing.T) {
+	manager, err := NewManager("qwerty")
+	require.NoError(t, err)
+
+	const workers = 16
+
+	var (
+		wg  sync.WaitGroup
+		ids = make([]string, workers)
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			token, err := manager.NewJWT(Claims{Subject: "user", SessionID: "session"}, time.Minute)
+			require.NoError(t, err)
+
+			claims, err := manager.Parse(token)
+			require.NoError(t, err)
+			ids[w] = claims.ID
+		}(w)
+	}
+	wg.Wait()
+
+	unique := make(map[string]struct{}, workers)
+	for _, id := range ids {
+		require.NotEmpty(t, id)
+		unique[id] = struct{}{}
+	}
+	require.Len(t, unique, workers)
+}
+
+func TestHashToken(t *testing.T) {
+	token, err := NewToken(RefreshTokenPrefix)
+	require.NoError(t, err)
+
+	require.Equal(t, HashToken(token), HashToken(token))
+	require.Len(t, HashToken(token), 64)
+	require.NotContains(t, HashToken(token), token)
+	require.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", HashToken(""))
+}
@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP parameters of RFC 6238 as authenticator apps expect them by default:
+// HMAC-SHA1, six digits and 30 second steps.
+const (
+	totpPeriod = 30
+	totpDigits = 6
+	// totpSkew is how many steps before and after the current one a code is
+	// still accepted from, for the clock of the phone drifting.
+	totpSkew = 1
+	// totpSecretSize is the length of secrets in bytes, 160 bits as RFC 4226
+	// recommends.
+	totpSecretSize = 20
+)
+
+const (
+	// recoveryAlphabet has 32 characters, without the look-alike 0, 1, i and
+	// l, so that a byte maps to a character without bias.
+	recoveryAlphabet = "abcdefghjkmnopqrstuvwxyz23456789"
+	// recoveryCodeLength characters carry 50 random bits.
+	recoveryCodeLength = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewTOTPSecret returns a random TOTP secret, base32 without padding as
+// authenticator apps take it.
+func NewTOTPSecret() (string, error) {
+	secret := make([]byte, totpSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// TOTPURI returns the otpauth:// URI of the secret, which authenticator apps
+// read from a QR code. The account is shown in the app next to the issuer.
+func TOTPURI(issuer string, account string, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(totpPeriod))
+
+	uri := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + account,
+		RawQuery: query.Encode(),
+	}
+
+	return uri.String()
+}
+
+// ValidateTOTP reports whether the code is valid for the secret at the time,
+// along with the time step it is valid for. Storing the step of the last
+// accepted code lets the caller refuse a code seen before.
+func ValidateTOTP(secret string, code string, at time.Time) (int64, bool) {
+	if !IsTOTPCode(code) {
+		return 0, false
+	}
+
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(key) == 0 {
+		return 0, false
+	}
+
+	current := at.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(hotp(key, step, totpDigits)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// TOTPCode returns the code of the secret at the time, as an authenticator
+// app shows it.
+func TOTPCode(secret string, at time.Time) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	return hotp(key, at.Unix()/totpPeriod, totpDigits), nil
+}
+
+// IsTOTPCode reports whether the code looks like a TOTP code rather than a
+// recovery code.
+func IsTOTPCode(code string) bool {
+	return len(code) == totpDigits && strings.Trim(code, "0123456789") == ""
+}
+
+// NewRecoveryCode returns a random single-use code to sign in with when the
+// authenticator is lost, in two groups of five: xxxxx-xxxxx.
+func NewRecoveryCode() (string, error) {
+	buf := make([]byte, recoveryCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	code := make([]byte, 0, recoveryCodeLength+1)
+	for i, b := range buf {
+		if i == recoveryCodeLength/2 {
+			code = append(code, '-')
+		}
+		code = append(code, recoveryAlphabet[int(b)%len(recoveryAlphabet)])
+	}
+
+	return string(code), nil
+}
+
+// NormalizeRecoveryCode drops the case, spaces and dashes a user may type a
+// recovery code with, codes are hashed in this form.
+func NormalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(code)))
+}
+
+// hotp is the HMAC-based one-time password of RFC 4226 for the counter.
+func hotp(key []byte, counter int64, digits int) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
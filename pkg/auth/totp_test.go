@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA-1 secret of the test vectors of RFC 4226 and RFC 6238.
+var rfcSecret = []byte("12345678901234567890")
+
+func TestHOTP(t *testing.T) {
+	// RFC 4226, appendix D.
+	codes := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+
+	for counter, code := range codes {
+		require.Equal(t, code, hotp(rfcSecret, int64(counter), 6))
+	}
+}
+
+func TestTOTP_RFC6238(t *testing.T) {
+	// RFC 6238, appendix B, SHA-1.
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "94287082"},
+		{unix: 1111111109, code: "07081804"},
+		{unix: 1111111111, code: "14050471"},
+		{unix: 1234567890, code: "89005924"},
+		{unix: 2000000000, code: "69279037"},
+		{unix: 20000000000, code: "65353130"},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.code, hotp(rfcSecret, tt.unix/totpPeriod, 8), tt.unix)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret := totpEncoding.EncodeToString(rfcSecret)
+	at := time.Unix(1111111111, 0)
+	step := at.Unix() / totpPeriod
+	code := hotp(rfcSecret, step, totpDigits)
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		at     time.Time
+		step   int64
+		valid  bool
+	}{
+		{name: "OK", secret: secret, code: code, at: at, step: step, valid: true},
+		{name: "lowercase secret", secret: strings.ToLower(secret), code: code, at: at, step: step, valid: true},
+		{name: "previous step", secret: secret, code: code, at: at.Add(totpPeriod * time.Second), step: step, valid: true},
+		{name: "next step", secret: secret, code: code, at: at.Add(-totpPeriod * time.Second), step: step, valid: true},
+		{name: "two steps late", secret: secret, code: code, at: at.Add(2 * totpPeriod * time.Second)},
+		{name: "other code", secret: secret, code: hotp(rfcSecret, step+5, totpDigits), at: at},
+		{name: "short code", secret: secret, code: code[1:], at: at},
+		{name: "not digits", secret: secret, code: "12345a", at: at},
+		{name: "invalid secret", secret: "not base32!", code: code, at: at},
+		{name: "empty secret", code: code, at: at},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, valid := ValidateTOTP(tt.secret, tt.code, tt.at)
+			require.Equal(t, tt.valid, valid)
+			require.Equal(t, tt.step, step)
+		})
+	}
+}
+
+func TestNewTOTPSecret(t *testing.T) {
+	secret, err := NewTOTPSecret()
+	require.NoError(t, err)
+	require.Len(t, secret, 32)
+
+	other, err := NewTOTPSecret()
+	require.NoError(t, err)
+	require.NotEqual(t, secret, other)
+
+	code, err := TOTPCode(secret, time.Now())
+	require.NoError(t, err)
+
+	_, valid := ValidateTOTP(secret, code, time.Now())
+	require.True(t, valid)
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri, err := url.Parse(TOTPURI("Region Todo", "user@example.com", "JBSWY3DPEHPK3PXP"))
+	require.NoError(t, err)
+
+	require.Equal(t, "otpauth", uri.Scheme)
+	require.Equal(t, "totp", uri.Host)
+	require.Equal(t, "/Region Todo:user@example.com", uri.Path)
+	require.Equal(t, "JBSWY3DPEHPK3PXP", uri.Query().Get("secret"))
+	require.Equal(t, "Region Todo", uri.Query().Get("issuer"))
+	require.Equal(t, "6", uri.Query().Get("digits"))
+	require.Equal(t, "30", uri.Query().Get("period"))
+}
+
+func TestNewRecoveryCode(t *testing.T) {
+	seen := make(map[string]struct{})
+	for i := 0; i < 100; i++ {
+		code, err := NewRecoveryCode()
+		require.NoError(t, err)
+		require.Len(t, code, recoveryCodeLength+1)
+		require.Equal(t, byte('-'), code[recoveryCodeLength/2])
+		require.False(t, IsTOTPCode(code))
+
+		for _, c := range NormalizeRecoveryCode(code) {
+			require.Contains(t, recoveryAlphabet, string(c))
+		}
+
+		seen[code] = struct{}{}
+	}
+	require.Len(t, seen, 100)
+
+	require.Equal(t, "abcdefghjk", NormalizeRecoveryCode(" ABCDE-fghjk "))
+	require.Equal(t, "abcdefghjk", NormalizeRecoveryCode("abcde fghjk"))
+}
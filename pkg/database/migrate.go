@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+const createMigrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version TEXT PRIMARY KEY
+)`
+
+// Migrate applies every *.sql file from migrations that has not been
+// recorded in schema_migrations yet. Files run in lexical order, each one in
+// its own transaction, so names are expected to start with a sequence number.
+func Migrate(ctx context.Context, db *sql.DB, migrations fs.FS) error {
+	if _, err := db.ExecContext(ctx, createMigrationsTable); err != nil {
+		return fmt.Errorf("db.ExecContext(): %v", err)
+	}
+
+	files, err := fs.Glob(migrations, "*.sql")
+	if err != nil {
+		return fmt.Errorf("fs.Glob(): %v", err)
+	}
+	sort.Strings(files)
+
+	applied := make(map[string]bool)
+	rows, err := db.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return fmt.Errorf("db.QueryContext(): %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return fmt.Errorf("rows.Scan(): %v", err)
+		}
+		applied[version] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows.Err(): %v", err)
+	}
+
+	for _, file := range files {
+		version := strings.TrimSuffix(file, ".sql")
+		if applied[version] {
+			continue
+		}
+
+		query, err := fs.ReadFile(migrations, file)
+		if err != nil {
+			return fmt.Errorf("fs.ReadFile(%s): %v", file, err)
+		}
+
+		if err := apply(ctx, db, version, string(query)); err != nil {
+			return fmt.Errorf("apply(%s): %v", file, err)
+		}
+	}
+
+	return nil
+}
+
+func apply(ctx context.Context, db *sql.DB, version string, query string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version) VALUES ($1)", version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,25 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/begenov/region-llc-task/internal/config"
+	_ "github.com/lib/pq"
+)
+
+func NewPostgres(ctx context.Context, cfg config.ConfigPostgres) (*sql.DB, error) {
+	db, err := sql.Open("postgres", cfg.Uri)
+	if err != nil {
+		return nil, fmt.Errorf("sql.Open(): %v", err)
+	}
+
+	err = db.PingContext(ctx)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("db.PingContext(): %v", err)
+	}
+
+	return db, nil
+}
@@ -0,0 +1,31 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/begenov/region-llc-task/internal/config"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// NewSQLite opens the database file at cfg.Path, creating it if needed.
+// SQLite allows a single writer at a time, so the pool is limited to one
+// connection instead of surfacing SQLITE_BUSY to callers.
+func NewSQLite(ctx context.Context, cfg config.ConfigSQLite) (*sql.DB, error) {
+	dsn := fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL", cfg.Path)
+
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("sql.Open(): %v", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	err = db.PingContext(ctx)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("db.PingContext(): %v", err)
+	}
+
+	return db, nil
+}
@@ -0,0 +1,49 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/begenov/region-llc-task/pkg/logger"
+)
+
+// FileMailer appends messages to a file instead of sending them, for
+// development and tests. With no file the messages are written to the log.
+type FileMailer struct {
+	mu   sync.Mutex
+	path string
+	from string
+}
+
+func NewFileMailer(path string, from string) *FileMailer {
+	return &FileMailer{
+		path: path,
+		from: from,
+	}
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	data := msg.format(m.from, time.Now())
+
+	if m.path == "" {
+		logger.Infof("mail to %s:\n%s", msg.To, data)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(data, "\r\n"...)); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,41 @@
+// Package mail sends the emails of the service: over SMTP, or to a file or
+// the log where no mail server is at hand.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"time"
+)
+
+//go:generate mockgen -source=mail.go -destination=mocks/mock.go
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// format returns the message as RFC 5322 text with CRLF line endings. The
+// subject is encoded for non-ASCII text, the body is sent as UTF-8.
+func (m Message) format(from string, date time.Time) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", m.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.Write(bytes.ReplaceAll(bytes.ReplaceAll([]byte(m.Body), []byte("\r\n"), []byte("\n")), []byte("\n"), []byte("\r\n")))
+	buf.WriteString("\r\n")
+
+	return buf.Bytes()
+}
@@ -0,0 +1,144 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var message = Message{
+	To:      "user@example.com",
+	Subject: "Сброс пароля",
+	Body:    "Line one\nLine two",
+}
+
+func TestMessage_format(t *testing.T) {
+	date := time.Date(2023, 8, 4, 10, 0, 0, 0, time.UTC)
+	text := string(message.format("Region Todo <noreply@example.com>", date))
+
+	require.Contains(t, text, "From: Region Todo <noreply@example.com>\r\n")
+	require.Contains(t, text, "To: user@example.com\r\n")
+	require.Contains(t, text, "Subject: =?utf-8?q?")
+	require.Contains(t, text, "Date: Fri, 04 Aug 2023 10:00:00 +0000\r\n")
+	require.Contains(t, text, "Content-Type: text/plain; charset=utf-8\r\n")
+	require.True(t, strings.HasSuffix(text, "\r\n\r\nLine one\r\nLine two\r\n"))
+}
+
+func TestFileMailer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.txt")
+	mailer := NewFileMailer(path, "noreply@example.com")
+
+	require.NoError(t, mailer.Send(context.Background(), message))
+	require.NoError(t, mailer.Send(context.Background(), message))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, 2, strings.Count(string(data), "To: user@example.com\r\n"))
+
+	require.NoError(t, NewFileMailer("", "noreply@example.com").Send(context.Background(), message))
+}
+
+func TestSMTPMailer(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     string
+		envelope string
+		header   string
+	}{
+		{
+			name:     "Address",
+			from:     "noreply@example.com",
+			envelope: "MAIL FROM:<noreply@example.com> BODY=8BITMIME",
+			header:   "From: noreply@example.com",
+		},
+		{
+			name:     "Display Name",
+			from:     "Region Todo <noreply@example.com>",
+			envelope: "MAIL FROM:<noreply@example.com> BODY=8BITMIME",
+			header:   "From: Region Todo <noreply@example.com>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			defer listener.Close()
+
+			received := make(chan []string, 1)
+			go serveSMTP(listener, received)
+
+			addr := listener.Addr().(*net.TCPAddr)
+			mailer, err := NewSMTPMailer("127.0.0.1", addr.Port, "", "", tt.from)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			require.NoError(t, mailer.Send(ctx, message))
+
+			commands := <-received
+			require.Contains(t, commands, tt.envelope)
+			require.Contains(t, commands, tt.header)
+			require.Contains(t, commands, "RCPT TO:<user@example.com>")
+			require.Contains(t, commands, "To: user@example.com")
+			require.Contains(t, commands, "Line two")
+		})
+	}
+
+	_, err := NewSMTPMailer("127.0.0.1", 25, "", "", "Region Todo")
+	require.Error(t, err)
+}
+
+// serveSMTP accepts one message as a minimal SMTP server, like MailHog does,
+// and sends the lines it has read.
+func serveSMTP(listener net.Listener, received chan<- []string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	var lines []string
+	r := bufio.NewReader(conn)
+	write := func(reply string) {
+		conn.Write([]byte(reply + "\r\n"))
+	}
+
+	write("220 localhost ESMTP")
+	data := false
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			received <- lines
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		lines = append(lines, line)
+
+		switch {
+		case data && line == ".":
+			data = false
+			write("250 OK")
+		case data:
+		case strings.HasPrefix(line, "EHLO"):
+			write("250-localhost")
+			write("250 8BITMIME")
+		case line == "DATA":
+			data = true
+			write("354 Start mail input")
+		case line == "QUIT":
+			write("221 Bye")
+			received <- lines
+			return
+		default:
+			write("250 OK")
+		}
+	}
+}